@@ -67,10 +67,31 @@ describe('DataSourceWithBackend', () => {
             },
           ],
         },
+        "headers": Object {},
         "method": "POST",
         "requestId": undefined,
         "url": "/api/ds/query",
       }
     `);
   });
+
+  test('sends the dashboard of the queries', () => {
+    const settings = {
+      name: 'test',
+      id: 1234,
+      jsonData: {},
+    } as DataSourceInstanceSettings<DataSourceJsonData>;
+
+    mockDatasourceRequest.mockReset();
+    mockDatasourceRequest.mockReturnValue(Promise.resolve({}));
+    const ds = new MyDataSource(settings);
+
+    ds.query({
+      dashboardId: 42,
+      targets: [{ refId: 'A' }],
+    } as DataQueryRequest);
+
+    const args = mockDatasourceRequest.mock.calls[0][0];
+    expect(args.headers).toEqual({ 'X-Dashboard-Id': 42 });
+  });
 });
@@ -55,7 +55,7 @@ export class DataSourceWithBackend<
    * Ideally final -- any other implementation may not work as expected
    */
   query(request: DataQueryRequest<TQuery>): Observable<DataQueryResponse> {
-    const { intervalMs, maxDataPoints, range, requestId } = request;
+    const { intervalMs, maxDataPoints, range, requestId, dashboardId } = request;
     let targets = request.targets;
 
     if (this.filterQuery) {
@@ -103,12 +103,19 @@ export class DataSourceWithBackend<
       body.to = range.to.valueOf().toString();
     }
 
+    // Lets the backend count the queries of dashboards for the dashboard insights
+    const headers: Record<string, any> = {};
+    if (dashboardId) {
+      headers['X-Dashboard-Id'] = dashboardId;
+    }
+
     return getBackendSrv()
       .fetch({
         url: '/api/ds/query',
         method: 'POST',
         data: body,
         requestId,
+        headers,
       })
       .pipe(
         map((rsp: any) => {
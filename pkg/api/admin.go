@@ -48,3 +48,21 @@ func AdminGetStats(c *models.ReqContext) response.Response {
 
 	return response.JSON(200, statsQuery.Result)
 }
+
+// GET /api/admin/stats/active-users
+func AdminGetActiveUserStats(c *models.ReqContext) response.Response {
+	days := 30
+	if c.Query("days") != "" {
+		days = c.QueryInt("days")
+	}
+	if days < 1 || days > 365 {
+		return response.Error(400, "days must be between 1 and 365", nil)
+	}
+
+	query := models.GetActiveUserStatsQuery{Days: days}
+	if err := bus.DispatchCtx(c.Req.Context(), &query); err != nil {
+		return response.Error(500, "Failed to get active user stats from database", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
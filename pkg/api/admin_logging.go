@@ -0,0 +1,50 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GET /api/admin/logging/levels
+func AdminGetLogLevels(c *models.ReqContext) response.Response {
+	return response.JSON(200, log.GetLevels())
+}
+
+// GET /api/admin/logging/recent
+func AdminGetRecentLogs(c *models.ReqContext) response.Response {
+	limit := 100
+	if c.Query("limit") != "" {
+		limit = c.QueryInt("limit")
+	}
+	if limit < 1 {
+		return response.Error(400, "limit must be greater than 0", nil)
+	}
+
+	records, err := log.Recent(c.Query("level"), c.Query("logger"), limit)
+	if err != nil {
+		return response.Error(400, err.Error(), nil)
+	}
+	return response.JSON(200, records)
+}
+
+// PUT /api/admin/logging/levels/:logger
+func AdminSetLogLevel(c *models.ReqContext, form dtos.AdminSetLogLevelForm) response.Response {
+	loggerName := c.Params(":logger")
+	if err := log.SetLevel(loggerName, form.Level); err != nil {
+		return response.Error(400, err.Error(), nil)
+	}
+
+	c.Logger.Info("Log level changed", "target", loggerName, "level", form.Level)
+	return response.Success("Log level changed")
+}
+
+// DELETE /api/admin/logging/levels/:logger
+func AdminResetLogLevel(c *models.ReqContext) response.Response {
+	loggerName := c.Params(":logger")
+	log.ResetLevel(loggerName)
+
+	c.Logger.Info("Log level reset", "target", loggerName)
+	return response.Success("Log level reset to the configured level")
+}
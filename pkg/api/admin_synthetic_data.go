@@ -0,0 +1,36 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Upper bounds for a single request, so a typo can't fill up the database.
+const (
+	syntheticMaxFolders     = 1000
+	syntheticMaxDashboards  = 100000
+	syntheticMaxUsers       = 10000
+	syntheticMaxTeams       = 1000
+	syntheticMaxAnnotations = 1000000
+)
+
+// POST /api/admin/synthetic-data
+func GenerateSyntheticData(c *models.ReqContext, cmd models.GenerateSyntheticDataCommand) response.Response {
+	if !inRange(cmd.Folders, syntheticMaxFolders) || !inRange(cmd.Dashboards, syntheticMaxDashboards) ||
+		!inRange(cmd.Users, syntheticMaxUsers) || !inRange(cmd.Teams, syntheticMaxTeams) ||
+		!inRange(cmd.Annotations, syntheticMaxAnnotations) {
+		return response.Error(400, "Requested amount of synthetic data is out of range", nil)
+	}
+
+	cmd.OrgId = c.OrgId
+	if err := bus.DispatchCtx(c.Req.Context(), &cmd); err != nil {
+		return response.Error(500, "Failed to generate synthetic data", err)
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+func inRange(n, max int) bool {
+	return n >= 0 && n <= max
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSyntheticData(t *testing.T) {
+	var generated *models.GenerateSyntheticDataCommand
+	handler := withBusHandlerCtx(func(ctx context.Context, cmd *models.GenerateSyntheticDataCommand) error {
+		generated = cmd
+		cmd.Result = &models.SyntheticDataResult{Prefix: "synth-abc", Dashboards: cmd.Dashboards}
+		return nil
+	})
+	enabled := func(hs *HTTPServer) {
+		hs.Cfg.FeatureToggles = map[string]bool{"syntheticData": true}
+	}
+
+	t.Run("is not available unless enabled", func(t *testing.T) {
+		ts := setupTestServer(t, handler)
+		ts.post(t, "/api/admin/synthetic-data", map[string]int{"dashboards": 10}, grafanaAdminUser()).
+			requireStatus(http.StatusNotFound)
+	})
+
+	t.Run("requires a server admin", func(t *testing.T) {
+		ts := setupTestServer(t, handler, enabled)
+		ts.post(t, "/api/admin/synthetic-data", map[string]int{"dashboards": 10}, adminUser()).
+			requireStatus(http.StatusForbidden)
+	})
+
+	t.Run("generates data in the org of the user", func(t *testing.T) {
+		ts := setupTestServer(t, handler, enabled)
+		ts.post(t, "/api/admin/synthetic-data", map[string]int{"dashboards": 10, "seed": 3}, grafanaAdminUser()).
+			requireStatus(http.StatusOK).
+			requireJSONEq(`{"prefix": "synth-abc", "folders": 0, "dashboards": 10, "users": 0, "teams": 0, "teamMembers": 0, "annotations": 0}`)
+		require.Equal(t, testOrgID, generated.OrgId)
+		require.Equal(t, int64(3), generated.Seed)
+	})
+
+	t.Run("rejects amounts out of range", func(t *testing.T) {
+		ts := setupTestServer(t, handler, enabled)
+		for _, body := range []map[string]int{{"users": -1}, {"dashboards": syntheticMaxDashboards + 1}} {
+			ts.post(t, "/api/admin/synthetic-data", body, grafanaAdminUser()).requireStatus(http.StatusBadRequest)
+		}
+	})
+}
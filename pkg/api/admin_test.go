@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminGetActiveUserStats(t *testing.T) {
+	var queried *models.GetActiveUserStatsQuery
+	ts := setupTestServer(t, withBusHandlerCtx(func(ctx context.Context, query *models.GetActiveUserStatsQuery) error {
+		queried = query
+		query.Result = []*models.ActiveUserStats{{Day: "2021-03-10", DailyActiveUsers: 1, WeeklyActiveUsers: 2, MonthlyActiveUsers: 3, ActiveUsers: 3, ActiveUserWindow: 2592000}}
+		return nil
+	}))
+
+	t.Run("requires a server admin", func(t *testing.T) {
+		ts.get(t, "/api/admin/stats/active-users", adminUser()).requireStatus(http.StatusForbidden)
+	})
+
+	t.Run("gets the counts of the last 30 days by default", func(t *testing.T) {
+		var stats []*models.ActiveUserStats
+		ts.get(t, "/api/admin/stats/active-users", grafanaAdminUser()).requireStatus(http.StatusOK).decode(&stats)
+		require.Equal(t, 30, queried.Days)
+		require.Len(t, stats, 1)
+		require.Equal(t, int64(1), stats[0].DailyActiveUsers)
+
+		ts.get(t, "/api/admin/stats/active-users?days=7", grafanaAdminUser()).requireStatus(http.StatusOK)
+		require.Equal(t, 7, queried.Days)
+	})
+
+	t.Run("rejects days out of range", func(t *testing.T) {
+		for _, days := range []string{"0", "366", "week"} {
+			ts.get(t, "/api/admin/stats/active-users?days="+days, grafanaAdminUser()).requireStatus(http.StatusBadRequest)
+		}
+	})
+}
+
+func TestAdminLogLevels(t *testing.T) {
+	ts := setupTestServer(t)
+	t.Cleanup(func() {
+		log.ResetLevel("tsdb.prometheus")
+	})
+
+	t.Run("requires a server admin", func(t *testing.T) {
+		ts.get(t, "/api/admin/logging/levels", adminUser()).requireStatus(http.StatusForbidden)
+		ts.put(t, "/api/admin/logging/levels/tsdb.prometheus", dtos.AdminSetLogLevelForm{Level: "debug"}, adminUser()).requireStatus(http.StatusForbidden)
+	})
+
+	t.Run("sets and resets the level of a logger", func(t *testing.T) {
+		ts.put(t, "/api/admin/logging/levels/tsdb.prometheus", dtos.AdminSetLogLevelForm{Level: "debug"}, grafanaAdminUser()).requireStatus(http.StatusOK)
+
+		var levels map[string]string
+		ts.get(t, "/api/admin/logging/levels", grafanaAdminUser()).requireStatus(http.StatusOK).decode(&levels)
+		require.Equal(t, "debug", levels["tsdb.prometheus"])
+
+		ts.delete(t, "/api/admin/logging/levels/tsdb.prometheus", grafanaAdminUser()).requireStatus(http.StatusOK)
+		levels = nil
+		ts.get(t, "/api/admin/logging/levels", grafanaAdminUser()).requireStatus(http.StatusOK).decode(&levels)
+		require.NotContains(t, levels, "tsdb.prometheus")
+	})
+
+	t.Run("rejects unknown levels", func(t *testing.T) {
+		ts.put(t, "/api/admin/logging/levels/tsdb.prometheus", dtos.AdminSetLogLevelForm{Level: "verbose"}, grafanaAdminUser()).requireStatus(http.StatusBadRequest)
+	})
+}
+
+func TestAdminRecentLogs(t *testing.T) {
+	ts := setupTestServer(t)
+
+	t.Run("requires a server admin", func(t *testing.T) {
+		ts.get(t, "/api/admin/logging/recent", adminUser()).requireStatus(http.StatusForbidden)
+	})
+
+	t.Run("gets recent log records", func(t *testing.T) {
+		var records []log.RecentRecord
+		ts.get(t, "/api/admin/logging/recent?level=error&limit=10", grafanaAdminUser()).requireStatus(http.StatusOK).decode(&records)
+		require.LessOrEqual(t, len(records), 10)
+	})
+
+	t.Run("rejects unknown levels and invalid limits", func(t *testing.T) {
+		ts.get(t, "/api/admin/logging/recent?level=verbose", grafanaAdminUser()).requireStatus(http.StatusBadRequest)
+		ts.get(t, "/api/admin/logging/recent?limit=0", grafanaAdminUser()).requireStatus(http.StatusBadRequest)
+	})
+}
@@ -280,8 +280,8 @@ func (hs *HTTPServer) registerRoutes() {
 		}, reqOrgAdmin)
 
 		apiRoute.Get("/frontend/settings/", hs.GetFrontendSettings)
-		apiRoute.Any("/datasources/proxy/:id/*", reqSignedIn, hs.ProxyDataSourceRequest)
-		apiRoute.Any("/datasources/proxy/:id", reqSignedIn, hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/proxy/:id/*", reqSignedIn, hs.trackDashboardQueries, hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/proxy/:id", reqSignedIn, hs.trackDashboardQueries, hs.ProxyDataSourceRequest)
 		apiRoute.Any("/datasources/:id/resources", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/resources/*", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/health", routing.Wrap(hs.CheckDatasourceHealth))
@@ -352,12 +352,12 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Get("/search/", routing.Wrap(Search))
 
 		// metrics
-		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetrics))
+		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), hs.trackDashboardQueries, routing.Wrap(hs.QueryMetrics))
 		apiRoute.Get("/tsdb/testdata/gensql", reqGrafanaAdmin, routing.Wrap(GenerateSQLTestData))
 		apiRoute.Get("/tsdb/testdata/random-walk", routing.Wrap(hs.GetTestDataRandomWalk))
 
 		// DataSource w/ expressions
-		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), hs.trackDashboardQueries, routing.Wrap(hs.QueryMetricsV2))
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", bind(dtos.AlertTestCommand{}), routing.Wrap(hs.AlertTest))
@@ -398,7 +398,7 @@ func (hs *HTTPServer) registerRoutes() {
 		})
 
 		// short urls
-		apiRoute.Post("/short-urls", bind(dtos.CreateShortURLCmd{}), routing.Wrap(hs.createShortURL))
+		apiRoute.Post("/short-urls", quota("short_url"), bind(dtos.CreateShortURLCmd{}), routing.Wrap(hs.createShortURL))
 	}, reqSignedIn)
 
 	// admin api
@@ -413,6 +413,11 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Get("/users/:id/quotas", routing.Wrap(GetUserQuotas))
 		adminRoute.Put("/users/:id/quotas/:target", bind(models.UpdateUserQuotaCmd{}), routing.Wrap(UpdateUserQuota))
 		adminRoute.Get("/stats", routing.Wrap(AdminGetStats))
+		adminRoute.Get("/stats/active-users", routing.Wrap(AdminGetActiveUserStats))
+		adminRoute.Get("/logging/levels", routing.Wrap(AdminGetLogLevels))
+		adminRoute.Put("/logging/levels/:logger", bind(dtos.AdminSetLogLevelForm{}), routing.Wrap(AdminSetLogLevel))
+		adminRoute.Delete("/logging/levels/:logger", routing.Wrap(AdminResetLogLevel))
+		adminRoute.Get("/logging/recent", routing.Wrap(AdminGetRecentLogs))
 		adminRoute.Post("/pause-all-alerts", bind(dtos.PauseAllAlertsCommand{}), routing.Wrap(PauseAllAlerts))
 
 		adminRoute.Post("/users/:id/logout", routing.Wrap(hs.AdminLogoutUser))
@@ -427,6 +432,10 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Post("/ldap/sync/:id", routing.Wrap(hs.PostSyncUserWithLDAP))
 		adminRoute.Get("/ldap/:username", routing.Wrap(hs.GetUserFromLDAP))
 		adminRoute.Get("/ldap/status", routing.Wrap(hs.GetLDAPStatus))
+
+		if hs.Cfg.IsSyntheticDataEnabled() {
+			adminRoute.Post("/synthetic-data", bind(models.GenerateSyntheticDataCommand{}), routing.Wrap(GenerateSyntheticData))
+		}
 	}, reqGrafanaAdmin)
 
 	// rendering
@@ -45,6 +45,7 @@ func DeleteAPIKey(c *models.ReqContext) response.Response {
 	if err != nil {
 		return response.Error(500, "Failed to delete API key", err)
 	}
+	auditLogger(c).Info("API key deleted", "apiKeyId", id)
 
 	return response.Success("API key deleted")
 }
@@ -81,6 +82,8 @@ func (hs *HTTPServer) AddAPIKey(c *models.ReqContext, cmd models.AddApiKeyComman
 		return response.Error(500, "Failed to add API Key", err)
 	}
 
+	auditLogger(c).Info("API key created", "apiKeyId", cmd.Result.Id, "apiKeyName", cmd.Result.Name, "role", cmd.Result.Role)
+
 	result := &dtos.NewApiKeyResult{
 		ID:   cmd.Result.Id,
 		Name: cmd.Result.Name,
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// auditLogger returns a logger of security relevant events of a request, with the address of the
+// client and the user of the request, if signed in.
+func auditLogger(c *models.ReqContext) *log.ConcreteLogger {
+	ctx := []interface{}{"remote_addr", c.RemoteAddr()}
+	if c.IsSignedIn {
+		ctx = append(ctx, "userId", c.UserId, "orgId", c.OrgId, "uname", c.Login)
+	}
+	return log.NewAuditLogger(ctx...)
+}
@@ -77,6 +77,10 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) response.Response {
 	canSave, _ := guardian.CanSave()
 	canAdmin, _ := guardian.CanAdmin()
 
+	if hs.Cfg.IsDashboardInsightsEnabled() && !dash.IsFolder {
+		hs.DashboardInsights.RecordView(c.OrgId, dash.Id)
+	}
+
 	isStarred, err := isDashboardStarredByUser(c, dash.Id)
 	if err != nil {
 		return response.Error(500, "Error while checking if dashboard was starred by user", err)
@@ -144,6 +148,10 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) response.Response {
 		}
 	}
 
+	if hs.Live.IsEnabled() {
+		meta.Presence = hs.Live.GrafanaScope.Dashboards.DashboardPresence(c.OrgId, dash.Uid)
+	}
+
 	// make sure db version is in sync with json model version
 	dash.Data.Set("version", dash.Version)
 
@@ -239,6 +247,14 @@ func (hs *HTTPServer) deleteDashboard(c *models.ReqContext) response.Response {
 		return response.Error(500, "Failed to delete dashboard", err)
 	}
 
+	// Tell everyone listening that the dashboard is gone
+	if hs.Live.IsEnabled() {
+		err := hs.Live.GrafanaScope.Dashboards.DashboardDeleted(dash.Uid, c.UserId)
+		if err != nil {
+			hs.log.Warn("unable to broadcast delete event", "uid", dash.Uid, "error", err)
+		}
+	}
+
 	return response.JSON(200, util.DynMap{
 		"title":   dash.Title,
 		"message": fmt.Sprintf("Dashboard %s deleted", dash.Title),
@@ -323,15 +339,39 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		}
 	}
 
-	c.TimeRequest(metrics.MApiDashboardSave)
-	return response.JSON(200, util.DynMap{
+	result := util.DynMap{
 		"status":  "success",
 		"slug":    dashboard.Slug,
 		"version": dashboard.Version,
 		"id":      dashboard.Id,
 		"uid":     dashboard.Uid,
 		"url":     dashboard.GetUrl(),
-	})
+	}
+
+	// Warn about other users who are still editing the dashboard, their changes now conflict with
+	// the saved version
+	if editors := hs.getOtherDashboardEditors(c, dashboard.Uid); len(editors) > 0 {
+		result["editors"] = editors
+		result["warning"] = "Other users are editing this dashboard, their unsaved changes conflict with this version"
+	}
+
+	c.TimeRequest(metrics.MApiDashboardSave)
+	return response.JSON(200, result)
+}
+
+// getOtherDashboardEditors returns who other than the signed in user is editing a dashboard.
+func (hs *HTTPServer) getOtherDashboardEditors(c *models.ReqContext, uid string) []*models.DashboardPresence {
+	editors := []*models.DashboardPresence{}
+	if !hs.Live.IsEnabled() {
+		return editors
+	}
+
+	for _, p := range hs.Live.GrafanaScope.Dashboards.DashboardPresence(c.OrgId, uid) {
+		if p.Editing && p.UserID != c.UserId {
+			editors = append(editors, p)
+		}
+	}
+	return editors
 }
 
 func (hs *HTTPServer) dashboardSaveErrorToApiResponse(err error) response.Response {
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// dashboardIDHeader is the header in which panels send the ID of their dashboard with data requests.
+const dashboardIDHeader = "X-Dashboard-Id"
+
+// trackDashboardQueries counts data requests made by dashboard panels for the dashboard insights,
+// as failed if they get an error response.
+func (hs *HTTPServer) trackDashboardQueries(c *models.ReqContext) {
+	if !hs.Cfg.IsDashboardInsightsEnabled() {
+		return
+	}
+
+	dashboardID, err := strconv.ParseInt(c.Req.Header.Get(dashboardIDHeader), 10, 64)
+	if err != nil || dashboardID <= 0 {
+		return
+	}
+
+	c.Next()
+	hs.DashboardInsights.RecordQuery(c.OrgId, dashboardID, c.Resp.Status() >= 400)
+}
@@ -107,6 +107,7 @@ func (hs *HTTPServer) UpdateDashboardPermissions(c *models.ReqContext, apiCmd dt
 		return response.Error(500, "Failed to create permission", err)
 	}
 
+	auditLogger(c).Info("Dashboard permissions updated", "dashboardId", dashID, "permissions", len(apiCmd.Items))
 	return response.Success("Dashboard permissions updated")
 }
 
@@ -164,7 +164,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 				"/api/dashboards/db/:slug", role, func(sc *scenarioContext) {
 					state := setUp()
 
-					callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg()})
+					callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg(), Live: &live.GrafanaLive{Cfg: setting.NewCfg()}})
 					assert.Equal(t, 403, sc.resp.Code)
 
 					assert.Equal(t, "child-dash", state.dashQueries[0].Slug)
@@ -174,7 +174,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 				"/api/dashboards/uid/:uid", role, func(sc *scenarioContext) {
 					state := setUp()
 
-					callDeleteDashboardByUID(sc, &HTTPServer{Cfg: setting.NewCfg()})
+					callDeleteDashboardByUID(sc, &HTTPServer{Cfg: setting.NewCfg(), Live: &live.GrafanaLive{Cfg: setting.NewCfg()}})
 					assert.Equal(t, 403, sc.resp.Code)
 
 					assert.Equal(t, "abcdefghi", state.dashQueries[0].Uid)
@@ -229,7 +229,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 				"/api/dashboards/db/:slug", role, func(sc *scenarioContext) {
 					state := setUp()
 
-					callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg()})
+					callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg(), Live: &live.GrafanaLive{Cfg: setting.NewCfg()}})
 					assert.Equal(t, 200, sc.resp.Code)
 					assert.Equal(t, "child-dash", state.dashQueries[0].Slug)
 				})
@@ -238,7 +238,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 				"/api/dashboards/uid/:uid", role, func(sc *scenarioContext) {
 					state := setUp()
 
-					callDeleteDashboardByUID(sc, &HTTPServer{Cfg: setting.NewCfg()})
+					callDeleteDashboardByUID(sc, &HTTPServer{Cfg: setting.NewCfg(), Live: &live.GrafanaLive{Cfg: setting.NewCfg()}})
 					assert.Equal(t, 200, sc.resp.Code)
 					assert.Equal(t, "abcdefghi", state.dashQueries[0].Uid)
 				})
@@ -263,7 +263,8 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 
 	t.Run("Given a dashboard with a parent folder which has an ACL", func(t *testing.T) {
 		hs := &HTTPServer{
-			Cfg: setting.NewCfg(),
+			Cfg:  setting.NewCfg(),
+			Live: &live.GrafanaLive{Cfg: setting.NewCfg()},
 		}
 
 		setUp := func() *testState {
@@ -734,7 +735,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 
 		loggedInUserScenarioWithRole(t, "When calling DELETE on", "DELETE", "/api/dashboards/db/dash",
 			"/api/dashboards/db/:slug", role, func(sc *scenarioContext) {
-				callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg()})
+				callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg(), Live: &live.GrafanaLive{Cfg: setting.NewCfg()}})
 
 				assert.Equal(t, 412, sc.resp.Code)
 				result := sc.ToJSON()
@@ -1022,7 +1023,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 			"/api/dashboards/db/:slug", models.ROLE_EDITOR, func(sc *scenarioContext) {
 				setUp()
 
-				callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg()})
+				callDeleteDashboardBySlug(sc, &HTTPServer{Cfg: setting.NewCfg(), Live: &live.GrafanaLive{Cfg: setting.NewCfg()}})
 
 				assert.Equal(t, 400, sc.resp.Code)
 				result := sc.ToJSON()
@@ -1032,7 +1033,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 		loggedInUserScenarioWithRole(t, "When calling DELETE on", "DELETE", "/api/dashboards/db/abcdefghi", "/api/dashboards/db/:uid", models.ROLE_EDITOR, func(sc *scenarioContext) {
 			setUp()
 
-			callDeleteDashboardByUID(sc, &HTTPServer{Cfg: setting.NewCfg()})
+			callDeleteDashboardByUID(sc, &HTTPServer{Cfg: setting.NewCfg(), Live: &live.GrafanaLive{Cfg: setting.NewCfg()}})
 
 			assert.Equal(t, 400, sc.resp.Code)
 			result := sc.ToJSON()
@@ -1066,6 +1067,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 			hs := &HTTPServer{
 				Cfg:                 setting.NewCfg(),
 				ProvisioningService: mock,
+				Live:                &live.GrafanaLive{Cfg: setting.NewCfg()},
 			}
 			callGetDashboard(sc, hs)
 
@@ -1089,6 +1091,7 @@ func getDashboardShouldReturn200WithConfig(sc *scenarioContext, provisioningServ
 	hs := &HTTPServer{
 		Cfg:                 setting.NewCfg(),
 		ProvisioningService: provisioningService,
+		Live:                &live.GrafanaLive{Cfg: setting.NewCfg()},
 	}
 	callGetDashboard(sc, hs)
 
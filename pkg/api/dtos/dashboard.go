@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
 )
 
 type DashboardMeta struct {
@@ -30,6 +31,8 @@ type DashboardMeta struct {
 	FolderUrl             string    `json:"folderUrl"`
 	Provisioned           bool      `json:"provisioned"`
 	ProvisionedExternalId string    `json:"provisionedExternalId"`
+	// Presence is everyone viewing or editing the dashboard over Grafana Live.
+	Presence []*models.DashboardPresence `json:"presence,omitempty"`
 }
 
 type DashboardFullWithMeta struct {
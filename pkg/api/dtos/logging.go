@@ -0,0 +1,5 @@
+package dtos
+
+type AdminSetLogLevelForm struct {
+	Level string `json:"level" binding:"Required"`
+}
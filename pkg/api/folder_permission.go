@@ -124,6 +124,7 @@ func (hs *HTTPServer) UpdateFolderPermissions(c *models.ReqContext, apiCmd dtos.
 
 		return response.Error(500, "Failed to create permission", err)
 	}
+	auditLogger(c).Info("Folder permissions updated", "folderId", folder.Id, "permissions", len(apiCmd.Items))
 
 	return response.JSON(200, util.DynMap{
 		"message": "Folder permissions updated",
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -35,6 +36,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/plugindashboards"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/dashboardinsights"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/hooks"
@@ -65,32 +67,33 @@ type HTTPServer struct {
 	httpSrv     *http.Server
 	middlewares []macaron.Handler
 
-	RouteRegister          routing.RouteRegister                   `inject:""`
-	Bus                    bus.Bus                                 `inject:""`
-	RenderService          rendering.Service                       `inject:""`
-	Cfg                    *setting.Cfg                            `inject:""`
-	HooksService           *hooks.HooksService                     `inject:""`
-	CacheService           *localcache.CacheService                `inject:""`
-	DatasourceCache        datasources.CacheService                `inject:""`
-	AuthTokenService       models.UserTokenService                 `inject:""`
-	QuotaService           *quota.QuotaService                     `inject:""`
-	RemoteCacheService     *remotecache.RemoteCache                `inject:""`
-	ProvisioningService    provisioning.ProvisioningService        `inject:""`
-	Login                  login.Service                           `inject:""`
-	License                models.Licensing                        `inject:""`
-	BackendPluginManager   backendplugin.Manager                   `inject:""`
-	DataProxy              *datasourceproxy.DatasourceProxyService `inject:""`
-	PluginRequestValidator models.PluginRequestValidator           `inject:""`
-	PluginManager          plugins.Manager                         `inject:""`
-	SearchService          *search.SearchService                   `inject:""`
-	ShortURLService        *shorturls.ShortURLService              `inject:""`
-	Live                   *live.GrafanaLive                       `inject:""`
-	ContextHandler         *contexthandler.ContextHandler          `inject:""`
-	SQLStore               *sqlstore.SQLStore                      `inject:""`
-	LibraryPanelService    *librarypanels.LibraryPanelService      `inject:""`
-	DataService            *tsdb.Service                           `inject:""`
-	PluginDashboardService *plugindashboards.Service               `inject:""`
-	AlertEngine            *alerting.AlertEngine                   `inject:""`
+	RouteRegister          routing.RouteRegister                       `inject:""`
+	Bus                    bus.Bus                                     `inject:""`
+	RenderService          rendering.Service                           `inject:""`
+	Cfg                    *setting.Cfg                                `inject:""`
+	HooksService           *hooks.HooksService                         `inject:""`
+	CacheService           *localcache.CacheService                    `inject:""`
+	DatasourceCache        datasources.CacheService                    `inject:""`
+	AuthTokenService       models.UserTokenService                     `inject:""`
+	QuotaService           *quota.QuotaService                         `inject:""`
+	RemoteCacheService     *remotecache.RemoteCache                    `inject:""`
+	ProvisioningService    provisioning.ProvisioningService            `inject:""`
+	Login                  login.Service                               `inject:""`
+	License                models.Licensing                            `inject:""`
+	BackendPluginManager   backendplugin.Manager                       `inject:""`
+	DataProxy              *datasourceproxy.DatasourceProxyService     `inject:""`
+	PluginRequestValidator models.PluginRequestValidator               `inject:""`
+	PluginManager          plugins.Manager                             `inject:""`
+	SearchService          *search.SearchService                       `inject:""`
+	ShortURLService        *shorturls.ShortURLService                  `inject:""`
+	Live                   *live.GrafanaLive                           `inject:""`
+	ContextHandler         *contexthandler.ContextHandler              `inject:""`
+	SQLStore               *sqlstore.SQLStore                          `inject:""`
+	LibraryPanelService    *librarypanels.LibraryPanelService          `inject:""`
+	DataService            *tsdb.Service                               `inject:""`
+	PluginDashboardService *plugindashboards.Service                   `inject:""`
+	AlertEngine            *alerting.AlertEngine                       `inject:""`
+	DashboardInsights      *dashboardinsights.DashboardInsightsService `inject:""`
 	Listener               net.Listener
 }
 
@@ -438,6 +441,9 @@ func (hs *HTTPServer) apiHealthHandler(ctx *macaron.Context) {
 	}
 }
 
+// hashedAssetPattern matches the names of build assets with a content hash, like app.0c1b2e3f4a5d6c7b8a9f.js.
+var hashedAssetPattern = regexp.MustCompile(`\.[0-9a-f]{16,}\.`)
+
 func (hs *HTTPServer) mapStatic(m *macaron.Macaron, rootDir string, dir string, prefix string) {
 	headers := func(c *macaron.Context) {
 		c.Resp.Header().Set("Cache-Control", "public, max-age=3600")
@@ -445,7 +451,13 @@ func (hs *HTTPServer) mapStatic(m *macaron.Macaron, rootDir string, dir string,
 
 	if prefix == "public/build" {
 		headers = func(c *macaron.Context) {
-			c.Resp.Header().Set("Cache-Control", "public, max-age=31536000")
+			// The content of a file with a hash in its name never changes, so browsers and CDNs can keep it
+			// for good. Other build files, like web workers, change on upgrades under the same name.
+			if hashedAssetPattern.MatchString(path.Base(c.Req.URL.Path)) {
+				c.Resp.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else {
+				c.Resp.Header().Set("Cache-Control", "public, max-age=3600")
+			}
 		}
 	}
 
@@ -1,9 +1,15 @@
 package api
 
 import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
 
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -27,3 +33,27 @@ func TestHTTPServer_MetricsBasicAuth(t *testing.T) {
 		assert.False(t, ts.metricsEndpointBasicAuthEnabled())
 	})
 }
+
+func TestHTTPServer_StaticCacheHeaders(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "build"), 0750))
+	for _, name := range []string{"build/app.0c1b2e3f4a5d6c7b8a9f.js", "build/monaco-editor.worker.js", "robots.txt"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, name), []byte("content"), 0600))
+	}
+
+	hs := &HTTPServer{Cfg: setting.NewCfg()}
+	m := macaron.New()
+	hs.mapStatic(m, root, "build", "public/build")
+	hs.mapStatic(m, root, "robots.txt", "robots.txt")
+
+	for path, cacheControl := range map[string]string{
+		"/public/build/app.0c1b2e3f4a5d6c7b8a9f.js": "public, max-age=31536000, immutable",
+		"/public/build/monaco-editor.worker.js":     "public, max-age=3600",
+		"/robots.txt":                               "public, max-age=3600",
+	} {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, 200, rec.Code, path)
+		assert.Equal(t, cacheControl, rec.Header().Get("Cache-Control"), path)
+	}
+}
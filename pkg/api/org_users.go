@@ -161,17 +161,17 @@ func (hs *HTTPServer) getOrgUsersHelper(query *models.GetOrgUsersQuery, signedIn
 func UpdateOrgUserForCurrentOrg(c *models.ReqContext, cmd models.UpdateOrgUserCommand) response.Response {
 	cmd.OrgId = c.OrgId
 	cmd.UserId = c.ParamsInt64(":userId")
-	return updateOrgUserHelper(cmd)
+	return updateOrgUserHelper(c, cmd)
 }
 
 // PATCH /api/orgs/:orgId/users/:userId
 func UpdateOrgUser(c *models.ReqContext, cmd models.UpdateOrgUserCommand) response.Response {
 	cmd.OrgId = c.ParamsInt64(":orgId")
 	cmd.UserId = c.ParamsInt64(":userId")
-	return updateOrgUserHelper(cmd)
+	return updateOrgUserHelper(c, cmd)
 }
 
-func updateOrgUserHelper(cmd models.UpdateOrgUserCommand) response.Response {
+func updateOrgUserHelper(c *models.ReqContext, cmd models.UpdateOrgUserCommand) response.Response {
 	if !cmd.Role.IsValid() {
 		return response.Error(400, "Invalid role specified", nil)
 	}
@@ -182,6 +182,7 @@ func updateOrgUserHelper(cmd models.UpdateOrgUserCommand) response.Response {
 		}
 		return response.Error(500, "Failed update org user", err)
 	}
+	auditLogger(c).Info("Organization user role updated", "targetUserId", cmd.UserId, "targetOrgId", cmd.OrgId, "role", cmd.Role)
 
 	return response.Success("Organization user updated")
 }
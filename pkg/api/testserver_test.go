@@ -0,0 +1,278 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
+)
+
+// testUserHeader carries the ID the test server uses to look up the signed in user of a request.
+const testUserHeader = "X-Grafana-Test-User"
+
+// testServer serves all API routes of an HTTPServer over HTTP, with requests signed in as users
+// created by the test instead of going through authentication.
+type testServer struct {
+	hs     *HTTPServer
+	server *httptest.Server
+
+	usersMu sync.Mutex
+	users   []*models.SignedInUser
+}
+
+// testServerOption configures the HTTPServer before its routes are registered, typically by
+// replacing a service with a fake.
+type testServerOption func(hs *HTTPServer)
+
+// withBusHandler registers a bus handler for the duration of the test.
+func withBusHandler(handler bus.HandlerFunc) testServerOption {
+	return func(hs *HTTPServer) {
+		bus.AddHandler("test", handler)
+	}
+}
+
+// withBusHandlerCtx registers a bus handler for messages dispatched with a context for the duration
+// of the test.
+func withBusHandlerCtx(handler bus.HandlerFunc) testServerOption {
+	return func(hs *HTTPServer) {
+		bus.AddHandlerCtx("test", handler)
+	}
+}
+
+func setupTestServer(t *testing.T, opts ...testServerOption) *testServer {
+	t.Helper()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	cfg := setting.NewCfg()
+	hs := &HTTPServer{
+		Cfg:           cfg,
+		Bus:           bus.GetBus(),
+		RouteRegister: routing.NewRouteRegister(),
+		License:       &licensing.OSSLicensingService{Cfg: cfg},
+	}
+	for _, opt := range opts {
+		opt(hs)
+	}
+	hs.registerRoutes()
+
+	ts := &testServer{hs: hs}
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Use(ts.contextMiddleware)
+	hs.RouteRegister.Register(m)
+
+	ts.server = httptest.NewServer(m)
+	t.Cleanup(ts.server.Close)
+
+	return ts
+}
+
+// contextMiddleware initializes the request context with the user the request was made as.
+func (ts *testServer) contextMiddleware(c *macaron.Context) {
+	ctx := &models.ReqContext{
+		Context:      c,
+		SignedInUser: &models.SignedInUser{},
+		Logger:       log.New("test"),
+	}
+
+	if id, err := strconv.Atoi(c.Req.Header.Get(testUserHeader)); err == nil {
+		ts.usersMu.Lock()
+		if id >= 0 && id < len(ts.users) {
+			ctx.SignedInUser = ts.users[id]
+			ctx.IsSignedIn = true
+		}
+		ts.usersMu.Unlock()
+	}
+
+	c.Map(ctx)
+}
+
+func (ts *testServer) register(user *models.SignedInUser) string {
+	ts.usersMu.Lock()
+	defer ts.usersMu.Unlock()
+
+	ts.users = append(ts.users, user)
+	return strconv.Itoa(len(ts.users) - 1)
+}
+
+// viewer, editor and admin are signed in members of the test org with the respective role.
+func viewerUser() *models.SignedInUser { return orgUser(models.ROLE_VIEWER) }
+func editorUser() *models.SignedInUser { return orgUser(models.ROLE_EDITOR) }
+func adminUser() *models.SignedInUser  { return orgUser(models.ROLE_ADMIN) }
+
+// grafanaAdminUser is a server admin, who is also an admin of the test org.
+func grafanaAdminUser() *models.SignedInUser {
+	user := orgUser(models.ROLE_ADMIN)
+	user.IsGrafanaAdmin = true
+	return user
+}
+
+func orgUser(role models.RoleType) *models.SignedInUser {
+	return &models.SignedInUser{
+		UserId:  testUserID,
+		OrgId:   testOrgID,
+		OrgRole: role,
+		Login:   testUserLogin,
+		Email:   testUserLogin + "@localhost",
+	}
+}
+
+// serviceAccountUser is the identity of a request authenticated with an API key with role.
+func serviceAccountUser(role models.RoleType) *models.SignedInUser {
+	return &models.SignedInUser{
+		ApiKeyId: 1,
+		OrgId:    testOrgID,
+		OrgRole:  role,
+	}
+}
+
+// request makes a request as user, or anonymously if user is nil. Non-reader bodies are sent as JSON.
+func (ts *testServer) request(t *testing.T, method, path string, body interface{}, user *models.SignedInUser) *testResponse {
+	t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, ts.server.URL+path, reader)
+	require.NoError(t, err)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != nil {
+		req.Header.Set(testUserHeader, ts.register(user))
+	}
+
+	resp, err := ts.server.Client().Do(req)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, resp.Body.Close()) }()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return &testResponse{t: t, Response: resp, Body: respBody}
+}
+
+func (ts *testServer) get(t *testing.T, path string, user *models.SignedInUser) *testResponse {
+	t.Helper()
+	return ts.request(t, http.MethodGet, path, nil, user)
+}
+
+func (ts *testServer) post(t *testing.T, path string, body interface{}, user *models.SignedInUser) *testResponse {
+	t.Helper()
+	return ts.request(t, http.MethodPost, path, body, user)
+}
+
+func (ts *testServer) put(t *testing.T, path string, body interface{}, user *models.SignedInUser) *testResponse {
+	t.Helper()
+	return ts.request(t, http.MethodPut, path, body, user)
+}
+
+func (ts *testServer) delete(t *testing.T, path string, user *models.SignedInUser) *testResponse {
+	t.Helper()
+	return ts.request(t, http.MethodDelete, path, nil, user)
+}
+
+type testResponse struct {
+	t *testing.T
+	*http.Response
+	Body []byte
+}
+
+func (r *testResponse) requireStatus(code int) *testResponse {
+	r.t.Helper()
+	require.Equalf(r.t, code, r.StatusCode, "unexpected status, body: %s", r.Body)
+	return r
+}
+
+// requireJSONEq requires the body to be JSON equivalent to expected.
+func (r *testResponse) requireJSONEq(expected string) *testResponse {
+	r.t.Helper()
+	require.JSONEq(r.t, expected, string(r.Body))
+	return r
+}
+
+// decode unmarshals the JSON body into v.
+func (r *testResponse) decode(v interface{}) {
+	r.t.Helper()
+	require.NoError(r.t, json.Unmarshal(r.Body, v))
+}
+
+func TestTestServer(t *testing.T) {
+	var saved *models.SavePreferencesCommand
+	var unstarred *models.UnstarDashboardCommand
+	ts := setupTestServer(t,
+		withBusHandler(func(query *models.GetPreferencesQuery) error {
+			query.Result = &models.Preferences{Theme: "dark", Timezone: "utc"}
+			return nil
+		}),
+		withBusHandler(func(cmd *models.SavePreferencesCommand) error {
+			saved = cmd
+			return nil
+		}),
+		withBusHandler(func(cmd *models.UnstarDashboardCommand) error {
+			unstarred = cmd
+			return nil
+		}),
+		withBusHandler(func(query *models.GetOrgUsersQuery) error {
+			query.Result = []*models.OrgUserDTO{{Login: testUserLogin}}
+			return nil
+		}),
+	)
+
+	t.Run("signed in users", func(t *testing.T) {
+		for _, user := range []*models.SignedInUser{viewerUser(), serviceAccountUser(models.ROLE_VIEWER)} {
+			ts.get(t, "/api/user/preferences", user).
+				requireStatus(http.StatusOK).
+				requireJSONEq(`{"theme": "dark", "timezone": "utc", "homeDashboardId": 0}`)
+		}
+	})
+
+	t.Run("anonymous users", func(t *testing.T) {
+		ts.get(t, "/api/user/preferences", nil).requireStatus(http.StatusUnauthorized)
+	})
+
+	t.Run("roles", func(t *testing.T) {
+		ts.get(t, "/api/org/users", editorUser()).requireStatus(http.StatusForbidden)
+
+		var users []*models.OrgUserDTO
+		ts.get(t, "/api/org/users", adminUser()).requireStatus(http.StatusOK).decode(&users)
+		require.Len(t, users, 1)
+		require.Equal(t, testUserLogin, users[0].Login)
+	})
+
+	t.Run("request bodies", func(t *testing.T) {
+		ts.put(t, "/api/user/preferences", map[string]interface{}{"theme": "light"}, editorUser()).
+			requireStatus(http.StatusOK)
+		require.Equal(t, "light", saved.Theme)
+		require.Equal(t, testUserID, saved.UserId)
+
+		ts.post(t, "/api/user/preferences", nil, editorUser()).requireStatus(http.StatusNotFound)
+	})
+
+	t.Run("route parameters", func(t *testing.T) {
+		ts.delete(t, "/api/user/stars/dashboard/3", viewerUser()).requireStatus(http.StatusOK)
+		require.Equal(t, int64(3), unstarred.DashboardId)
+	})
+}
@@ -181,19 +181,11 @@ func validPackaging(packaging string) string {
 
 func listenToSystemSignals(s *server.Server) {
 	signalChan := make(chan os.Signal, 1)
-	sighupChan := make(chan os.Signal, 1)
 
-	signal.Notify(sighupChan, syscall.SIGHUP)
+	// SIGHUP is handled by the server, which reads the logging config again.
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
-	for {
-		select {
-		case <-sighupChan:
-			if err := log.Reload(); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to reload loggers: %s\n", err)
-			}
-		case sig := <-signalChan:
-			s.Shutdown(fmt.Sprintf("System signal: %s", sig))
-		}
+	for sig := range signalChan {
+		s.Shutdown(fmt.Sprintf("System signal: %s", sig))
 	}
 }
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// The policies of an AsyncHandler when its queue is full.
+const (
+	// dropNewest drops the record being logged.
+	dropNewest = "drop_newest"
+	// dropOldest drops the oldest queued record to make room for the record being logged.
+	dropOldest = "drop_oldest"
+	// block waits for room in the queue, like a synchronous handler would.
+	block = "block"
+)
+
+// AsyncHandler queues records and passes them on to a handler in the background, so logging doesn't
+// wait for slow handlers like files on busy disks or remote syslog daemons. Handlers that can be
+// flushed are flushed every flush interval instead of after every record.
+type AsyncHandler struct {
+	handler       log15.Handler
+	mode          string
+	dropPolicy    string
+	flushInterval time.Duration
+
+	records chan *log15.Record
+	quit    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	// abort stops passing on the queued records once the handler is closed, when the context of
+	// closing it is done.
+	abort     chan struct{}
+	abortOnce sync.Once
+	// dropped is the number of records dropped once closing was aborted.
+	dropped int
+}
+
+// NewAsyncHandler creates an AsyncHandler from the async settings of a mode section, and starts
+// passing records on to h.
+func NewAsyncHandler(sec *ini.Section, h log15.Handler) (*AsyncHandler, error) {
+	bufferSize := sec.Key("buffer_size").MustInt(10000)
+	if bufferSize <= 0 {
+		return nil, errors.New("buffer_size must be greater than 0")
+	}
+	flushInterval := sec.Key("flush_interval").MustDuration(time.Second)
+	if flushInterval <= 0 {
+		return nil, errors.New("flush_interval must be greater than 0")
+	}
+	dropPolicy := sec.Key("drop_policy").MustString(dropNewest)
+	switch dropPolicy {
+	case dropNewest, dropOldest, block:
+	default:
+		return nil, fmt.Errorf("unknown drop_policy %q, valid options are drop_newest, drop_oldest and block", dropPolicy)
+	}
+
+	a := &AsyncHandler{
+		handler:       h,
+		mode:          strings.TrimPrefix(sec.Name(), "log."),
+		dropPolicy:    dropPolicy,
+		flushInterval: flushInterval,
+		records:       make(chan *log15.Record, bufferSize),
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+		abort:         make(chan struct{}),
+	}
+	go a.run()
+	return a, nil
+}
+
+// Log queues a record. When the queue is full, the record is handled according to the drop policy.
+func (a *AsyncHandler) Log(r *log15.Record) error {
+	select {
+	case <-a.quit:
+		return errors.New("the async log handler is closed, dropping log record")
+	default:
+	}
+
+	select {
+	case a.records <- r:
+		return nil
+	default:
+	}
+
+	switch a.dropPolicy {
+	case block:
+		select {
+		case a.records <- r:
+			return nil
+		case <-a.quit:
+			return errors.New("the async log handler is closed, dropping log record")
+		}
+	case dropOldest:
+		for {
+			select {
+			case a.records <- r:
+				return nil
+			default:
+			}
+			select {
+			case <-a.records:
+			default:
+			}
+		}
+	default:
+		return errors.New("the async log buffer is full, dropping log record")
+	}
+}
+
+// Close passes the queued records on to the handler, flushes it, and closes it.
+func (a *AsyncHandler) Close() error {
+	return a.CloseContext(context.Background())
+}
+
+// CloseContext passes the queued records on to the handler until ctx is done, flushes it, and closes
+// it. The records that aren't passed on by then are dropped.
+func (a *AsyncHandler) CloseContext(ctx context.Context) error {
+	a.once.Do(func() {
+		close(a.quit)
+	})
+
+	var err error
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		a.abortOnce.Do(func() {
+			close(a.abort)
+		})
+		<-a.done
+		err = ctx.Err()
+	}
+
+	if closeErr := closeHandler(ctx, a.handler); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (a *AsyncHandler) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r := <-a.records:
+			a.log(r)
+		case <-ticker.C:
+			a.flush()
+		case <-a.quit:
+			for {
+				select {
+				case r := <-a.records:
+					a.log(r)
+				default:
+					a.flush()
+					if a.dropped > 0 {
+						fmt.Fprintf(os.Stderr, "Dropped %d log records of %s not written before closing\n", a.dropped, a.mode)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *AsyncHandler) log(r *log15.Record) {
+	select {
+	case <-a.abort:
+		a.dropped++
+		logWriteErrorsTotal.WithLabelValues(a.mode).Inc()
+		return
+	default:
+	}
+
+	if err := a.handler.Log(r); err != nil {
+		logWriteErrorsTotal.WithLabelValues(a.mode).Inc()
+	}
+}
+
+func (a *AsyncHandler) flush() {
+	if h, ok := a.handler.(FlushableHandler); ok {
+		h.Flush()
+	}
+}
@@ -0,0 +1,182 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+// gatedHandler records the messages of records once its gate is opened, and counts its flushes.
+type gatedHandler struct {
+	gate chan struct{}
+
+	mu       sync.Mutex
+	messages []string
+	flushes  int
+	closed   bool
+}
+
+func newGatedHandler() *gatedHandler {
+	return &gatedHandler{gate: make(chan struct{})}
+}
+
+func (h *gatedHandler) Log(r *log15.Record) error {
+	<-h.gate
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, r.Msg)
+	return nil
+}
+
+func (h *gatedHandler) Flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.flushes++
+}
+
+func (h *gatedHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	return nil
+}
+
+func (h *gatedHandler) logged() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string{}, h.messages...)
+}
+
+func newAsyncTestHandler(t *testing.T, config string, h log15.Handler) *AsyncHandler {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.file]\n" + config))
+	require.NoError(t, err)
+	handler, err := NewAsyncHandler(cfg.Section("log.file"), h)
+	require.NoError(t, err)
+	return handler
+}
+
+func TestAsyncHandler(t *testing.T) {
+	t.Run("Queued records are handled before closing", func(t *testing.T) {
+		h := newGatedHandler()
+		async := newAsyncTestHandler(t, "", h)
+
+		for _, msg := range []string{"first", "second", "third"} {
+			require.NoError(t, async.Log(&log15.Record{Msg: msg}))
+		}
+		close(h.gate)
+		require.NoError(t, async.Close())
+
+		assert.Equal(t, []string{"first", "second", "third"}, h.logged())
+		assert.True(t, h.closed)
+		assert.Equal(t, 1, h.flushes)
+		require.Error(t, async.Log(&log15.Record{Msg: "fourth"}))
+	})
+
+	t.Run("Queued records are dropped once the context of closing is done", func(t *testing.T) {
+		h := newGatedHandler()
+		async := newAsyncTestHandler(t, "", h)
+
+		for _, msg := range []string{"first", "second", "third"} {
+			require.NoError(t, async.Log(&log15.Record{Msg: msg}))
+		}
+		require.Eventually(t, func() bool { return len(async.records) == 2 }, time.Second, time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		// The record being handled when closing is aborted is waited for.
+		time.AfterFunc(50*time.Millisecond, func() {
+			close(h.gate)
+		})
+
+		require.Equal(t, context.DeadlineExceeded, async.CloseContext(ctx))
+		assert.Equal(t, []string{"first"}, h.logged())
+		assert.Eventually(t, func() bool {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			return h.closed
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("Records are dropped by the drop policy when the queue is full", func(t *testing.T) {
+		for policy, expected := range map[string][]string{
+			"drop_newest": {"first", "second", "third"},
+			"drop_oldest": {"first", "third", "fourth"},
+		} {
+			h := newGatedHandler()
+			async := newAsyncTestHandler(t, "buffer_size = 2\ndrop_policy = "+policy, h)
+
+			require.NoError(t, async.Log(&log15.Record{Msg: "first"}))
+			// The first record is being handled once the queue is empty again.
+			require.Eventually(t, func() bool {
+				return len(async.records) == 0
+			}, time.Second, time.Millisecond)
+			require.NoError(t, async.Log(&log15.Record{Msg: "second"}))
+			require.NoError(t, async.Log(&log15.Record{Msg: "third"}))
+			err := async.Log(&log15.Record{Msg: "fourth"})
+			if policy == "drop_newest" {
+				require.EqualError(t, err, "the async log buffer is full, dropping log record")
+			} else {
+				require.NoError(t, err)
+			}
+
+			close(h.gate)
+			require.NoError(t, async.Close())
+			assert.Equal(t, expected, h.logged(), policy)
+		}
+	})
+
+	t.Run("Records wait for room in the queue with the block policy", func(t *testing.T) {
+		h := newGatedHandler()
+		async := newAsyncTestHandler(t, "buffer_size = 1\ndrop_policy = block", h)
+
+		require.NoError(t, async.Log(&log15.Record{Msg: "first"}))
+		require.Eventually(t, func() bool {
+			return len(async.records) == 0
+		}, time.Second, time.Millisecond)
+		require.NoError(t, async.Log(&log15.Record{Msg: "second"}))
+
+		logged := make(chan error)
+		go func() {
+			logged <- async.Log(&log15.Record{Msg: "third"})
+		}()
+		select {
+		case <-logged:
+			t.Fatal("record was queued in a full queue")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(h.gate)
+		require.NoError(t, <-logged)
+		require.NoError(t, async.Close())
+		assert.Equal(t, []string{"first", "second", "third"}, h.logged())
+	})
+
+	t.Run("Handlers are flushed every flush interval", func(t *testing.T) {
+		h := newGatedHandler()
+		close(h.gate)
+		async := newAsyncTestHandler(t, "flush_interval = 5ms", h)
+		defer func() {
+			_ = async.Close()
+		}()
+
+		require.Eventually(t, func() bool {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			return h.flushes >= 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.file]\ndrop_policy = drop_all"))
+		require.NoError(t, err)
+		_, err = NewAsyncHandler(cfg.Section("log.file"), log15.DiscardHandler())
+		require.EqualError(t, err, `unknown drop_policy "drop_all", valid options are drop_newest, drop_oldest and block`)
+	})
+}
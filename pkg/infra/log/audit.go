@@ -0,0 +1,99 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// auditLoggers are the loggers of security relevant events, which are written to the audit log.
+var auditLoggers = []string{"audit", "audit.*"}
+
+// NewAuditLogger returns a logger of security relevant events, like logins, permission changes
+// and API key creations. When the audit log is enabled, its records are written to the audit log
+// file regardless of the levels, filters and sampling of the log modes.
+func NewAuditLogger(ctx ...interface{}) *ConcreteLogger {
+	return New("audit", ctx...)
+}
+
+// AuditFileHandler writes records to a file and syncs the file after every record, so records
+// aren't lost when the process crashes. Records are written without any buffering.
+type AuditFileHandler struct {
+	mu       sync.Mutex
+	fileName string
+	format   log15.Format
+	fd       *os.File
+}
+
+// NewAuditFileHandler returns an AuditFileHandler writing to the file, which is created if it
+// doesn't exist.
+func NewAuditFileHandler(fileName string, format log15.Format) (*AuditFileHandler, error) {
+	h := &AuditFileHandler{fileName: fileName, format: format}
+	if err := os.MkdirAll(filepath.Dir(fileName), os.ModePerm); err != nil {
+		return nil, err
+	}
+	fd, err := h.open()
+	if err != nil {
+		return nil, err
+	}
+	h.fd = fd
+	return h, nil
+}
+
+// newAuditHandler returns the handler of the audit log of the [log.audit] section, or nil if it's
+// not enabled.
+func newAuditHandler(sec *ini.Section, logsPath string) (*AuditFileHandler, error) {
+	if !sec.Key("enabled").MustBool(false) {
+		return nil, nil
+	}
+	format, err := readLogFormat(sec, "json")
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditFileHandler(sec.Key("file_name").MustString(filepath.Join(logsPath, "audit.log")), format)
+}
+
+func (h *AuditFileHandler) open() (*os.File, error) {
+	// The audit log may hold personal data of users, so it's only readable by the owner.
+	// nolint:gosec
+	return os.OpenFile(h.fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+}
+
+// Log writes the record and syncs the file, so the record is on disk once Log returns.
+func (h *AuditFileHandler) Log(r *log15.Record) error {
+	line := h.format.Format(r)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := h.fd.Write(line); err != nil {
+		return err
+	}
+	return h.fd.Sync()
+}
+
+// Reload reopens the file, so the audit log can be rotated like the log files.
+func (h *AuditFileHandler) Reload() error {
+	fd, err := h.open()
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.fd
+	h.fd = fd
+	return previous.Close()
+}
+
+// Close closes the file.
+func (h *AuditFileHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.fd.Close()
+}
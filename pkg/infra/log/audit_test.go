@@ -0,0 +1,82 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	read := func(t *testing.T, name string) string {
+		t.Helper()
+		b, err := ioutil.ReadFile(name)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("Records of the audit loggers are written to the audit log regardless of levels", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg, err := ini.Load([]byte("[log]\nmode = file\nlevel = error\nrecent_buffer_size = 0\n" +
+			"[log.file]\nfile_name = " + filepath.Join(dir, "grafana.log") + "\n" +
+			"[log.audit]\nenabled = true\nformat = text\nfile_name = " + filepath.Join(dir, "audit", "audit.log")))
+		require.NoError(t, err)
+		require.NoError(t, ReadLoggingConfig([]string{"file"}, dir, cfg))
+
+		NewAuditLogger().Info("Successful login", "uname", "admin")
+		New("audit.apikey").Info("API key created", "name", "ci")
+		New("sqlstore").Error("Query failed")
+
+		audit := read(t, filepath.Join(dir, "audit", "audit.log"))
+		assert.Contains(t, audit, "Successful login")
+		assert.Contains(t, audit, "API key created")
+		assert.NotContains(t, audit, "Query failed")
+
+		require.NoError(t, Close())
+		main := read(t, filepath.Join(dir, "grafana.log"))
+		assert.NotContains(t, main, "Successful login")
+		assert.Contains(t, main, "Query failed")
+	})
+
+	t.Run("The audit log is disabled by default", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg, err := ini.Load([]byte("[log]\nmode = file\n[log.file]\nfile_name = " + filepath.Join(dir, "grafana.log")))
+		require.NoError(t, err)
+		require.NoError(t, ReadLoggingConfig([]string{"file"}, dir, cfg))
+
+		NewAuditLogger().Info("Successful login")
+		require.NoError(t, Close())
+
+		_, err = os.Stat(filepath.Join(dir, "audit.log"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("The audit log is reopened when rotated", func(t *testing.T) {
+		dir := t.TempDir()
+		fileName := filepath.Join(dir, "audit.log")
+		h, err := NewAuditFileHandler(fileName, log15.LogfmtFormat())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, h.Close())
+		})
+
+		require.NoError(t, h.Log(&log15.Record{Lvl: log15.LvlInfo, Msg: "before rotation"}))
+		require.NoError(t, os.Rename(fileName, fileName+".1"))
+		require.NoError(t, h.Reload())
+		require.NoError(t, h.Log(&log15.Record{Lvl: log15.LvlInfo, Msg: "after rotation"}))
+
+		assert.Contains(t, read(t, fileName+".1"), "before rotation")
+		assert.NotContains(t, read(t, fileName), "before rotation")
+		assert.Contains(t, read(t, fileName), "after rotation")
+	})
+}
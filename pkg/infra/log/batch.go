@@ -0,0 +1,253 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// levelNames are the names of the levels of records, as they are named in the config.
+var levelNames = map[log15.Lvl]string{
+	log15.LvlDebug: "debug",
+	log15.LvlInfo:  "info",
+	log15.LvlWarn:  "warn",
+	log15.LvlError: "error",
+	log15.LvlCrit:  "critical",
+	lvlOff:         "off",
+}
+
+// BatchSettings are the settings of handlers pushing log records to a remote service in batches.
+type BatchSettings struct {
+	BatchSize  int
+	BatchWait  time.Duration
+	BufferSize int
+	Timeout    time.Duration
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func readBatchSettings(sec *ini.Section) BatchSettings {
+	return BatchSettings{
+		BatchSize:  sec.Key("batch_size").MustInt(100),
+		BatchWait:  sec.Key("batch_wait").MustDuration(time.Second),
+		BufferSize: sec.Key("buffer_size").MustInt(10000),
+		Timeout:    sec.Key("timeout").MustDuration(10 * time.Second),
+		MaxRetries: sec.Key("max_retries").MustInt(5),
+		MinBackoff: sec.Key("min_backoff").MustDuration(500 * time.Millisecond),
+		MaxBackoff: sec.Key("max_backoff").MustDuration(30 * time.Second),
+	}
+}
+
+func (s BatchSettings) validate() error {
+	if s.BatchSize <= 0 {
+		return errors.New("batch_size must be greater than 0")
+	}
+	if s.BatchWait <= 0 {
+		return errors.New("batch_wait must be greater than 0")
+	}
+	return nil
+}
+
+// recordBatcher queues log records and pushes them in batches, retrying failed pushes with an
+// exponential backoff. Records that don't fit in the queue are dropped so logging never blocks.
+type recordBatcher struct {
+	settings BatchSettings
+	// name is the name of the service records are pushed to.
+	name string
+	// push pushes a batch, and retryable tells whether a failed push may succeed later.
+	push      func(batch []*log15.Record) error
+	retryable func(err error) bool
+
+	records chan *log15.Record
+	quit    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	// abort stops pushing the queued records once the batcher is closed, when the context of
+	// closing it is done.
+	abort     chan struct{}
+	abortOnce sync.Once
+}
+
+// newRecordBatcher creates a recordBatcher, and starts pushing records.
+func newRecordBatcher(settings BatchSettings, name string, push func([]*log15.Record) error, retryable func(error) bool) *recordBatcher {
+	if settings.BufferSize < settings.BatchSize {
+		settings.BufferSize = settings.BatchSize
+	}
+
+	b := &recordBatcher{
+		settings:  settings,
+		name:      name,
+		push:      push,
+		retryable: retryable,
+		records:   make(chan *log15.Record, settings.BufferSize),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+		abort:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// queue queues a record to be pushed.
+func (b *recordBatcher) queue(r *log15.Record) error {
+	select {
+	case b.records <- r:
+		return nil
+	default:
+		return fmt.Errorf("the %s log buffer is full, dropping log record", b.name)
+	}
+}
+
+// close pushes the queued records, and stops pushing. Once ctx is done, the records that aren't
+// pushed yet are dropped, and close returns the error of ctx once the batch being pushed is done.
+func (b *recordBatcher) close(ctx context.Context) error {
+	b.once.Do(func() {
+		close(b.quit)
+	})
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		b.abortOnce.Do(func() {
+			close(b.abort)
+		})
+		<-b.done
+		return ctx.Err()
+	}
+}
+
+func (b *recordBatcher) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.settings.BatchWait)
+	defer ticker.Stop()
+
+	batch := make([]*log15.Record, 0, b.settings.BatchSize)
+	dropped := 0
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		select {
+		case <-b.abort:
+			dropped += len(batch)
+			logWriteErrorsTotal.WithLabelValues(strings.ToLower(b.name)).Add(float64(len(batch)))
+			batch = make([]*log15.Record, 0, b.settings.BatchSize)
+			return
+		default:
+		}
+		if err := b.pushWithRetries(batch); err != nil {
+			logWriteErrorsTotal.WithLabelValues(strings.ToLower(b.name)).Add(float64(len(batch)))
+			// Logging the error would queue yet another record.
+			fmt.Fprintf(os.Stderr, "Failed to push %d log records to %s: %s\n", len(batch), b.name, err)
+		}
+		batch = make([]*log15.Record, 0, b.settings.BatchSize)
+	}
+
+	for {
+		select {
+		case r := <-b.records:
+			batch = append(batch, r)
+			if len(batch) >= b.settings.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-b.quit:
+			for {
+				select {
+				case r := <-b.records:
+					batch = append(batch, r)
+					if len(batch) >= b.settings.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					if dropped > 0 {
+						fmt.Fprintf(os.Stderr, "Dropped %d log records not pushed to %s before closing\n", dropped, b.name)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// pushWithRetries pushes a batch, and retries with an exponential backoff when the service can't
+// take it right now. Once the batcher is closed, batches are only tried once.
+func (b *recordBatcher) pushWithRetries(batch []*log15.Record) error {
+	backoff := b.settings.MinBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = b.push(batch); err == nil || !b.retryable(err) || attempt >= b.settings.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-b.quit:
+			return err
+		}
+		backoff *= 2
+		if backoff > b.settings.MaxBackoff {
+			backoff = b.settings.MaxBackoff
+		}
+	}
+}
+
+// httpPushError is an error response of a service records are pushed to over HTTP.
+type httpPushError struct {
+	statusCode int
+	body       string
+}
+
+func (e httpPushError) Error() string {
+	return fmt.Sprintf("server returned HTTP status %d: %s", e.statusCode, e.body)
+}
+
+// isRetryableHTTPPushError returns true for network errors, rate limiting and server errors.
+func isRetryableHTTPPushError(err error) bool {
+	var pushErr httpPushError
+	if errors.As(err, &pushErr) {
+		return pushErr.statusCode == http.StatusTooManyRequests || pushErr.statusCode/100 == 5
+	}
+	return true
+}
+
+// parseKeyValues parses a setting with a list of key:value pairs, like `job:grafana,env:prod`.
+func parseKeyValues(setting string, list string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid %s %q, %s must be given as key:value", setting, pair, setting)
+		}
+		result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return result, nil
+}
+
+// recordLogger returns the name of the logger a record was logged with.
+func recordLogger(r *log15.Record) (string, bool) {
+	for i := 0; i < len(r.Ctx)-1; i += 2 {
+		if key, ok := r.Ctx[i].(string); ok && key == "logger" {
+			name, ok := r.Ctx[i+1].(string)
+			return name, ok
+		}
+	}
+	return "", false
+}
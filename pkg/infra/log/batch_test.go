@@ -0,0 +1,65 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordBatcherClose(t *testing.T) {
+	settings := BatchSettings{BatchSize: 1, BatchWait: time.Hour, BufferSize: 10, Timeout: time.Second, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	newBatcher := func(gate chan struct{}) (*recordBatcher, func() []string) {
+		var mu sync.Mutex
+		var pushed []string
+		b := newRecordBatcher(settings, "test", func(batch []*log15.Record) error {
+			<-gate
+			mu.Lock()
+			defer mu.Unlock()
+			for _, r := range batch {
+				pushed = append(pushed, r.Msg)
+			}
+			return nil
+		}, func(error) bool { return false })
+		return b, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string{}, pushed...)
+		}
+	}
+
+	t.Run("Queued records are pushed before closing", func(t *testing.T) {
+		gate := make(chan struct{})
+		b, pushed := newBatcher(gate)
+		for _, msg := range []string{"first", "second", "third"} {
+			require.NoError(t, b.queue(&log15.Record{Msg: msg}))
+		}
+		close(gate)
+
+		require.NoError(t, b.close(context.Background()))
+		assert.Equal(t, []string{"first", "second", "third"}, pushed())
+	})
+
+	t.Run("Queued records are dropped once the context of closing is done", func(t *testing.T) {
+		gate := make(chan struct{})
+		b, pushed := newBatcher(gate)
+		for _, msg := range []string{"first", "second", "third"} {
+			require.NoError(t, b.queue(&log15.Record{Msg: msg}))
+		}
+
+		require.Eventually(t, func() bool { return len(b.records) == 2 }, time.Second, time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		// The batch being pushed when closing is aborted is waited for.
+		time.AfterFunc(50*time.Millisecond, func() {
+			close(gate)
+		})
+
+		require.Equal(t, context.DeadlineExceeded, b.close(ctx))
+		assert.Equal(t, []string{"first"}, pushed())
+	})
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func setupBenchmarkLogging(b *testing.B) {
+	b.Helper()
+	dir := b.TempDir()
+	cfg, err := ini.Load([]byte("[log]\nmode = file\nlevel = info\n[log.file]\nfile_name = " + filepath.Join(dir, "grafana.log")))
+	require.NoError(b, err)
+	require.NoError(b, ReadLoggingConfig([]string{"file"}, dir, cfg))
+	b.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(b, Close())
+	})
+}
+
+// BenchmarkFilteredRecords logs records below the level of all modes, like the debug records of
+// requests.
+func BenchmarkFilteredRecords(b *testing.B) {
+	setupBenchmarkLogging(b)
+	logger := New("benchmark", "requestID", "abc")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Debug("Request handled", "path", "/api/dashboards", "status", 200)
+	}
+}
+
+// BenchmarkWrittenRecords logs records written to a file.
+func BenchmarkWrittenRecords(b *testing.B) {
+	setupBenchmarkLogging(b)
+	logger := New("benchmark", "requestID", "abc")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("Request handled", "path", "/api/dashboards", "status", 200)
+	}
+}
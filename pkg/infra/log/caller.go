@@ -0,0 +1,40 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/go-stack/stack"
+	"github.com/inconshreveable/log15"
+)
+
+// logPackagePrefix is the prefix of the names of the functions of this package, like
+// github.com/grafana/grafana/pkg/infra/log.
+var logPackagePrefix = strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(New).Pointer()).Name(), "New")
+
+// CallerHandler adds the file and line of the logging call to records, in the `caller` key,
+// before passing them on to h. Calls of the functions and methods of this package, like ErrorCtx,
+// are skipped, and depth more calls after those, for helpers wrapping loggers.
+func CallerHandler(depth int, h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		s := stack.Trace().TrimBelow(r.Call).TrimRuntime()
+		for len(s) > 0 && isLogPackageCall(s[0]) {
+			s = s[1:]
+		}
+		if depth >= len(s) {
+			return h.Log(r)
+		}
+
+		called := *r
+		called.Ctx = append(r.Ctx[:len(r.Ctx):len(r.Ctx)], "caller", fmt.Sprint(s[depth]))
+		return h.Log(&called)
+	})
+}
+
+// isLogPackageCall reports whether c is a call in a function of this package, not counting tests.
+func isLogPackageCall(c stack.Call) bool {
+	frame := c.Frame()
+	return strings.HasPrefix(frame.Function, logPackagePrefix) && !strings.HasSuffix(frame.File, "_test.go")
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerHandler(t *testing.T) {
+	var callers []string
+	useDepth := func(depth int) {
+		callers = nil
+		Root.SetHandler(CallerHandler(depth, log15.FuncHandler(func(r *log15.Record) error {
+			for i := 0; i < len(r.Ctx)-1; i += 2 {
+				if r.Ctx[i] == "caller" {
+					callers = append(callers, r.Ctx[i+1].(string))
+				}
+			}
+			return nil
+		})))
+	}
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+	})
+
+	t.Run("The caller is the call of the logger", func(t *testing.T) {
+		useDepth(0)
+		logger := New("test")
+		var iface Logger = logger
+		logger.Info("direct")
+		logger.ErrorCtx(context.Background(), "wrapped by the package")
+		iface.Warn("called through the interface")
+		Warn("logged with the root logger")
+
+		require.Len(t, callers, 4)
+		for _, caller := range callers {
+			assert.True(t, strings.HasPrefix(caller, "caller_test.go:"), caller)
+		}
+	})
+
+	t.Run("Calls of helpers are skipped by the depth", func(t *testing.T) {
+		useDepth(1)
+		logHelper := func(msg string) {
+			New("test").Info(msg)
+		}
+		logHelper("logged by a helper")
+		_, _, line, _ := runtime.Caller(0)
+
+		require.Len(t, callers, 1)
+		assert.Equal(t, fmt.Sprintf("caller_test.go:%d", line-1), callers[0])
+	})
+}
@@ -0,0 +1,111 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+)
+
+// cefSeverities are the CEF severities, from 0 to 10, of the levels of records.
+var cefSeverities = map[log15.Lvl]int{
+	log15.LvlDebug: 1,
+	log15.LvlInfo:  3,
+	log15.LvlWarn:  5,
+	log15.LvlError: 7,
+	log15.LvlCrit:  10,
+}
+
+// cefExtensions maps the keys of the key values of records to the keys of the CEF extensions
+// SIEMs know. Other keys are written as they are.
+var cefExtensions = map[string]string{
+	"uname":       "suser",
+	"user":        "suser",
+	"login":       "suser",
+	"userId":      "suid",
+	"remote_addr": "src",
+	"remoteAddr":  "src",
+	"ip":          "src",
+	"method":      "requestMethod",
+	"path":        "request",
+	"url":         "request",
+	"referer":     "requestContext",
+	"userAgent":   "requestClientApplication",
+	"status":      "outcome",
+	"error":       "reason",
+	"err":         "reason",
+	"orgId":       "cs1",
+	"requestID":   "externalId",
+	"traceID":     "cs2",
+}
+
+// cefLabels are the labels of the custom string extensions of cefExtensions, written as their
+// cs1Label and cs2Label extensions.
+var cefLabels = map[string]string{
+	"cs1": "orgId",
+	"cs2": "traceID",
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`)
+)
+
+// CEFFormat formats records as ArcSight Common Event Format lines, for SIEMs. The logger of a
+// record is its event class ID, and the message its name. The key values are extensions, of which
+// the keys of cefExtensions are mapped to the extensions SIEMs know.
+func CEFFormat(version string) log15.Format {
+	header := "CEF:0|Grafana Labs|Grafana|" + cefHeaderEscaper.Replace(version) + "|"
+	return log15.FormatFunc(func(r *log15.Record) []byte {
+		logger, _ := recordLogger(r)
+		if logger == "" {
+			logger = "grafana"
+		}
+
+		b := &bytes.Buffer{}
+		b.WriteString(header)
+		b.WriteString(cefHeaderEscaper.Replace(logger))
+		b.WriteByte('|')
+		b.WriteString(cefHeaderEscaper.Replace(r.Msg))
+		b.WriteByte('|')
+		b.WriteString(strconv.Itoa(cefSeverities[r.Lvl]))
+		b.WriteString("|rt=")
+		b.WriteString(strconv.FormatInt(r.Time.UnixNano()/1e6, 10))
+
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			key, ok := r.Ctx[i].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[i])
+			}
+			if key == "logger" {
+				continue
+			}
+			if extension, ok := cefExtensions[key]; ok {
+				key = extension
+				if label, ok := cefLabels[extension]; ok {
+					b.WriteString(" " + extension + "Label=" + label)
+				}
+			} else {
+				key = cefExtensionKey(key)
+			}
+			b.WriteByte(' ')
+			b.WriteString(key)
+			b.WriteByte('=')
+			b.WriteString(cefExtensionEscaper.Replace(fmt.Sprint(otelJSONValue(r.Ctx[i+1]))))
+		}
+		b.WriteByte('\n')
+		return b.Bytes()
+	})
+}
+
+// cefExtensionKey replaces the characters that aren't allowed in extension keys with underscores.
+func cefExtensionKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, key)
+}
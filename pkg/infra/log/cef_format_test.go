@@ -0,0 +1,40 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCEFFormat(t *testing.T) {
+	format := CEFFormat("8.0.0")
+
+	t.Run("Key values are mapped to CEF extensions", func(t *testing.T) {
+		line := format.Format(&log15.Record{
+			Time: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC),
+			Lvl:  log15.LvlWarn,
+			Msg:  "Invalid username or password",
+			Ctx: []interface{}{
+				"logger", "context", "uname", "admin", "remote_addr", "10.0.0.1", "orgId", 1,
+				"error", errors.New("invalid password"), "login attempts", 3,
+			},
+		})
+
+		assert.Equal(t, "CEF:0|Grafana Labs|Grafana|8.0.0|context|Invalid username or password|5|rt=1615377600000 "+
+			"suser=admin src=10.0.0.1 cs1Label=orgId cs1=1 reason=invalid password login_attempts=3\n", string(line))
+	})
+
+	t.Run("Header fields and extension values are escaped", func(t *testing.T) {
+		line := format.Format(&log15.Record{
+			Time: time.Unix(0, 0),
+			Lvl:  log15.LvlError,
+			Msg:  `Query | failed \ badly`,
+			Ctx:  []interface{}{"sql", "SELECT a=1\nFROM b"},
+		})
+
+		assert.Equal(t, `CEF:0|Grafana Labs|Grafana|8.0.0|grafana|Query \| failed \\ badly|7|rt=0 sql=SELECT a\=1\nFROM b`+"\n", string(line))
+	})
+}
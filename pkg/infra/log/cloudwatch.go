@@ -0,0 +1,219 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// CloudWatchHandler pushes log records to a log stream of AWS CloudWatch Logs in batches, with
+// PutLogEvents calls. Credentials are looked up with the default credential chain of the AWS SDK:
+// environment variables, the shared credentials file, and the IAM role of the EC2 instance or ECS
+// task.
+type CloudWatchHandler struct {
+	BatchSettings
+	Region    string
+	Profile   string
+	Endpoint  string
+	LogGroup  string
+	LogStream string
+	Format    log15.Format
+
+	client cloudwatchlogsiface.CloudWatchLogsAPI
+	// sequenceToken is the token of the next PutLogEvents call. It's only used by the batcher.
+	sequenceToken *string
+	batcher       *recordBatcher
+}
+
+// NewCloudWatchHandler creates a CloudWatchHandler from the settings of the `log.cloudwatch`
+// section.
+func NewCloudWatchHandler(sec *ini.Section, format log15.Format) (*CloudWatchHandler, error) {
+	handler := &CloudWatchHandler{
+		BatchSettings: readBatchSettings(sec),
+		Region:        sec.Key("region").MustString(""),
+		Profile:       sec.Key("profile").MustString(""),
+		Endpoint:      sec.Key("endpoint").MustString(""),
+		LogGroup:      sec.Key("log_group").MustString(""),
+		LogStream:     sec.Key("log_stream").MustString(""),
+		Format:        format,
+	}
+
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init validates the settings of the handler and starts pushing records.
+func (h *CloudWatchHandler) Init() error {
+	if h.LogGroup == "" {
+		return errors.New("log_group is required to push logs to CloudWatch")
+	}
+	if err := h.BatchSettings.validate(); err != nil {
+		return err
+	}
+	// PutLogEvents takes at most 10000 events.
+	if h.BatchSize > 10000 {
+		return errors.New("batch_size must be at most 10000")
+	}
+	if h.LogStream == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		h.LogStream = hostname
+	}
+	if h.Format == nil {
+		h.Format = log15.LogfmtFormat()
+	}
+
+	if h.client == nil {
+		cfg := aws.NewConfig().WithHTTPClient(&http.Client{Timeout: h.Timeout})
+		if h.Region != "" {
+			cfg = cfg.WithRegion(h.Region)
+		}
+		if h.Endpoint != "" {
+			cfg = cfg.WithEndpoint(h.Endpoint)
+		}
+		sess, err := session.NewSessionWithOptions(session.Options{
+			Config:            *cfg,
+			Profile:           h.Profile,
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return err
+		}
+		h.client = cloudwatchlogs.New(sess)
+	}
+
+	h.batcher = newRecordBatcher(h.BatchSettings, "CloudWatch", h.push, isRetryableCloudWatchError)
+	return nil
+}
+
+// Log queues a record to be pushed to CloudWatch.
+func (h *CloudWatchHandler) Log(r *log15.Record) error {
+	return h.batcher.queue(r)
+}
+
+// Close pushes the queued records, and stops the handler.
+func (h *CloudWatchHandler) Close() error {
+	return h.CloseContext(context.Background())
+}
+
+// CloseContext pushes the queued records until ctx is done, and stops the handler. The records that
+// aren't pushed by then are dropped.
+func (h *CloudWatchHandler) CloseContext(ctx context.Context) error {
+	return h.batcher.close(ctx)
+}
+
+// push puts the events of a batch to the log stream. The log stream is created when it doesn't
+// exist yet, and the call is made again with the expected sequence token when the token is stale,
+// like after another process wrote to the stream.
+func (h *CloudWatchHandler) push(batch []*log15.Record) error {
+	input := h.encodeBatch(batch)
+	createdStream := false
+	for attempt := 0; ; attempt++ {
+		input.SequenceToken = h.sequenceToken
+		output, err := h.client.PutLogEvents(input)
+		if err == nil {
+			h.sequenceToken = output.NextSequenceToken
+			return nil
+		}
+
+		var invalidToken *cloudwatchlogs.InvalidSequenceTokenException
+		var alreadyAccepted *cloudwatchlogs.DataAlreadyAcceptedException
+		var notFound *cloudwatchlogs.ResourceNotFoundException
+		switch {
+		case errors.As(err, &alreadyAccepted):
+			h.sequenceToken = alreadyAccepted.ExpectedSequenceToken
+			return nil
+		case errors.As(err, &invalidToken) && attempt == 0:
+			h.sequenceToken = invalidToken.ExpectedSequenceToken
+		case errors.As(err, &notFound) && !createdStream:
+			if err := h.createLogStream(); err != nil {
+				return err
+			}
+			createdStream = true
+			h.sequenceToken = nil
+		default:
+			return err
+		}
+	}
+}
+
+// createLogStream creates the log stream, and the log group if it doesn't exist either.
+func (h *CloudWatchHandler) createLogStream() error {
+	_, err := h.client.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(h.LogGroup),
+		LogStreamName: aws.String(h.LogStream),
+	})
+	var notFound *cloudwatchlogs.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		_, err = h.client.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{
+			LogGroupName: aws.String(h.LogGroup),
+		})
+		if err != nil && !isCloudWatchAlreadyExists(err) {
+			return err
+		}
+		_, err = h.client.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  aws.String(h.LogGroup),
+			LogStreamName: aws.String(h.LogStream),
+		})
+	}
+	if err != nil && !isCloudWatchAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// encodeBatch converts the records of a batch to log events. CloudWatch requires the events of a
+// call in chronological order, and rejects empty messages.
+func (h *CloudWatchHandler) encodeBatch(batch []*log15.Record) *cloudwatchlogs.PutLogEventsInput {
+	events := make([]*cloudwatchlogs.InputLogEvent, 0, len(batch))
+	for _, r := range batch {
+		message := strings.TrimSuffix(string(h.Format.Format(r)), "\n")
+		if message == "" {
+			message = r.Msg
+		}
+		events = append(events, &cloudwatchlogs.InputLogEvent{
+			Timestamp: aws.Int64(r.Time.UnixNano() / 1e6),
+			Message:   aws.String(message),
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return *events[i].Timestamp < *events[j].Timestamp
+	})
+
+	return &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(h.LogGroup),
+		LogStreamName: aws.String(h.LogStream),
+		LogEvents:     events,
+	}
+}
+
+func isCloudWatchAlreadyExists(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == cloudwatchlogs.ErrCodeResourceAlreadyExistsException
+}
+
+// isRetryableCloudWatchError returns true for network errors, throttling and service errors.
+func isRetryableCloudWatchError(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return true
+	}
+	return request.IsErrorRetryable(err) || request.IsErrorThrottle(err) ||
+		awsErr.Code() == cloudwatchlogs.ErrCodeServiceUnavailableException
+}
@@ -0,0 +1,177 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+type fakeCloudWatchLogs struct {
+	cloudwatchlogsiface.CloudWatchLogsAPI
+
+	mu      sync.Mutex
+	groups  map[string]bool
+	streams map[string]bool
+	token   int
+	// errors are returned by the next PutLogEvents calls.
+	errors []error
+	puts   []*cloudwatchlogs.PutLogEventsInput
+	tokens []*string
+}
+
+func (f *fakeCloudWatchLogs) PutLogEvents(input *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tokens = append(f.tokens, input.SequenceToken)
+	if len(f.errors) > 0 {
+		err := f.errors[0]
+		f.errors = f.errors[1:]
+		return nil, err
+	}
+	if !f.streams[*input.LogGroupName+"/"+*input.LogStreamName] {
+		return nil, &cloudwatchlogs.ResourceNotFoundException{Message_: aws.String("The specified log stream does not exist.")}
+	}
+	f.puts = append(f.puts, input)
+	f.token++
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String(fmt.Sprintf("token-%d", f.token))}, nil
+}
+
+func (f *fakeCloudWatchLogs) CreateLogGroup(input *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.groups[*input.LogGroupName] = true
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func (f *fakeCloudWatchLogs) CreateLogStream(input *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.groups[*input.LogGroupName] {
+		return nil, &cloudwatchlogs.ResourceNotFoundException{Message_: aws.String("The specified log group does not exist.")}
+	}
+	f.streams[*input.LogGroupName+"/"+*input.LogStreamName] = true
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func newCloudWatchTestHandler(t *testing.T, client *fakeCloudWatchLogs, config string) *CloudWatchHandler {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.cloudwatch]\nlog_group = grafana\nlog_stream = server-1\n" + config))
+	require.NoError(t, err)
+	sec := cfg.Section("log.cloudwatch")
+	handler := &CloudWatchHandler{
+		BatchSettings: readBatchSettings(sec),
+		LogGroup:      sec.Key("log_group").String(),
+		LogStream:     sec.Key("log_stream").String(),
+		Format:        log15.LogfmtFormat(),
+		client:        client,
+	}
+	require.NoError(t, handler.Init())
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func TestCloudWatchHandler(t *testing.T) {
+	t.Run("Records are put in chronological batches to a created log stream", func(t *testing.T) {
+		client := &fakeCloudWatchLogs{groups: map[string]bool{}, streams: map[string]bool{}}
+		handler := newCloudWatchTestHandler(t, client, "batch_size = 2\nbatch_wait = 1h")
+
+		later := lokiTestRecord(log15.LvlInfo, "sqlstore", "first")
+		later.Time = later.Time.Add(time.Second)
+		require.NoError(t, handler.Log(later))
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "second")))
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "server", "third")))
+		require.NoError(t, handler.Close())
+
+		assert.True(t, client.streams["grafana/server-1"])
+		require.Len(t, client.puts, 2)
+		first := client.puts[0]
+		assert.Equal(t, "grafana", *first.LogGroupName)
+		assert.Equal(t, "server-1", *first.LogStreamName)
+		require.Len(t, first.LogEvents, 2)
+		assert.Equal(t, int64(1615377600000), *first.LogEvents[0].Timestamp)
+		assert.Contains(t, *first.LogEvents[0].Message, "msg=second")
+		assert.Contains(t, *first.LogEvents[1].Message, "msg=first")
+		assert.NotContains(t, *first.LogEvents[1].Message, "\n")
+
+		// The sequence token of a put is the next token of the previous put.
+		assert.Equal(t, []*string{nil, nil, aws.String("token-1")}, client.tokens)
+	})
+
+	t.Run("Puts are made again with the expected sequence token", func(t *testing.T) {
+		client := &fakeCloudWatchLogs{
+			groups:  map[string]bool{"grafana": true},
+			streams: map[string]bool{"grafana/server-1": true},
+			errors: []error{&cloudwatchlogs.InvalidSequenceTokenException{
+				ExpectedSequenceToken: aws.String("expected"),
+				Message_:              aws.String("The given sequenceToken is invalid."),
+			}},
+		}
+		handler := newCloudWatchTestHandler(t, client, "batch_size = 1\nbatch_wait = 1h")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.NoError(t, handler.Close())
+
+		require.Len(t, client.puts, 1)
+		assert.Equal(t, []*string{nil, aws.String("expected")}, client.tokens)
+	})
+
+	t.Run("Throttled puts are retried", func(t *testing.T) {
+		client := &fakeCloudWatchLogs{
+			groups:  map[string]bool{"grafana": true},
+			streams: map[string]bool{"grafana/server-1": true},
+			errors:  []error{awserr.New("ThrottlingException", "Rate exceeded", nil)},
+		}
+		handler := newCloudWatchTestHandler(t, client, "batch_size = 1\nbatch_wait = 1h\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.Eventually(t, func() bool {
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			return len(client.puts) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Puts rejected by CloudWatch are not retried", func(t *testing.T) {
+		client := &fakeCloudWatchLogs{
+			groups:  map[string]bool{"grafana": true},
+			streams: map[string]bool{"grafana/server-1": true},
+			errors:  []error{&cloudwatchlogs.InvalidParameterException{Message_: aws.String("Log event too large")}},
+		}
+		handler := newCloudWatchTestHandler(t, client, "batch_size = 1\nbatch_wait = 1h\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.NoError(t, handler.Close())
+
+		assert.Len(t, client.tokens, 1)
+		assert.Empty(t, client.puts)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.cloudwatch]\nregion = eu-west-1"))
+		require.NoError(t, err)
+
+		_, err = NewCloudWatchHandler(cfg.Section("log.cloudwatch"), log15.LogfmtFormat())
+		require.EqualError(t, err, "log_group is required to push logs to CloudWatch")
+
+		cfg.Section("log.cloudwatch").Key("log_group").SetValue("grafana")
+		cfg.Section("log.cloudwatch").Key("batch_size").SetValue("20000")
+		_, err = NewCloudWatchHandler(cfg.Section("log.cloudwatch"), log15.LogfmtFormat())
+		require.EqualError(t, err, "batch_size must be at most 10000")
+	})
+}
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// ReadLoggingConfigDocument reads a logging config from a YAML or JSON document, like one
+// provisioned from a config map, and applies it like ReadLoggingConfig. See
+// ParseLoggingConfigDocument for the shape of the document.
+func ReadLoggingConfigDocument(data []byte, logsPath string) error {
+	cfg, err := ParseLoggingConfigDocument(data)
+	if err != nil {
+		return err
+	}
+	modes := util.SplitString(cfg.Section("log").Key("mode").MustString("console"))
+	return ReadLoggingConfig(modes, logsPath, cfg)
+}
+
+// ParseLoggingConfigDocument converts a logging config of a YAML or JSON document to the sections
+// of an ini config read by ReadLoggingConfig. The settings of the document are the ones of the
+// [log] section, except for modes, which holds the settings of the [log.<mode>] sections by mode.
+// The routes of a mode, like the [log.file.security] section, are held by the routes of the mode.
+//
+//	mode: [console, file]
+//	level: info
+//	filters: {tsdb: debug, "sqlstore.*": warn}
+//	modes:
+//	  file:
+//	    format: json
+//	    routes:
+//	      security: {loggers: [auth, context]}
+//
+// Lists are written as space separated values, and mappings as space separated key:value pairs,
+// like the filters and static_fields settings expect.
+func ParseLoggingConfigDocument(data []byte) (*ini.File, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errutil.Wrapf(err, "failed to parse logging config document")
+	}
+
+	cfg := ini.Empty()
+	modes, err := documentMapping(doc["modes"], "modes")
+	if err != nil {
+		return nil, err
+	}
+	delete(doc, "modes")
+	if err := setDocumentKeys(cfg.Section("log"), doc); err != nil {
+		return nil, err
+	}
+
+	for _, mode := range sortedDocumentKeys(modes) {
+		settings, err := documentMapping(modes[mode], "modes."+mode)
+		if err != nil {
+			return nil, err
+		}
+		routes, err := documentMapping(settings["routes"], "modes."+mode+".routes")
+		if err != nil {
+			return nil, err
+		}
+		delete(settings, "routes")
+		if err := setDocumentKeys(cfg.Section("log."+mode), settings); err != nil {
+			return nil, err
+		}
+
+		for _, output := range sortedDocumentKeys(routes) {
+			routeSettings, err := documentMapping(routes[output], "modes."+mode+".routes."+output)
+			if err != nil {
+				return nil, err
+			}
+			if err := setDocumentKeys(cfg.Section("log."+mode+"."+output), routeSettings); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// documentMapping returns a mapping of the document, with its keys converted to strings. A missing
+// mapping is empty.
+func documentMapping(value interface{}, path string) (map[string]interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return value, nil
+	case map[interface{}]interface{}:
+		mapping := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			mapping[fmt.Sprint(k)] = v
+		}
+		return mapping, nil
+	default:
+		return nil, fmt.Errorf("%s of the logging config document must be a mapping", path)
+	}
+}
+
+func sortedDocumentKeys(mapping map[string]interface{}) []string {
+	keys := make([]string, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setDocumentKeys sets the keys of an ini section to the settings of a mapping of the document.
+func setDocumentKeys(sec *ini.Section, settings map[string]interface{}) error {
+	for _, key := range sortedDocumentKeys(settings) {
+		value, err := documentValue(settings[key], sec.Name()+"."+key)
+		if err != nil {
+			return err
+		}
+		if _, err := sec.NewKey(key, value); err != nil {
+			return errutil.Wrapf(err, "failed to set %s", key)
+		}
+	}
+	return nil
+}
+
+// documentValue converts a value of the document to the value of an ini key.
+func documentValue(value interface{}, path string) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for i, item := range value {
+			v, err := documentValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return "", err
+			}
+			values = append(values, v)
+		}
+		return strings.Join(values, " "), nil
+	case map[string]interface{}, map[interface{}]interface{}:
+		mapping, _ := documentMapping(value, path)
+		pairs := make([]string, 0, len(mapping))
+		for _, key := range sortedDocumentKeys(mapping) {
+			switch mapping[key].(type) {
+			case []interface{}, map[string]interface{}, map[interface{}]interface{}:
+				return "", fmt.Errorf("%s.%s of the logging config document must be a single value", path, key)
+			}
+			pairs = append(pairs, fmt.Sprintf("%s:%v", key, mapping[key]))
+		}
+		return strings.Join(pairs, " "), nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLoggingConfigDocument(t *testing.T) {
+	t.Run("YAML documents are converted to log sections", func(t *testing.T) {
+		cfg, err := ParseLoggingConfigDocument([]byte(`
+mode: [console, file]
+level: info
+filters: {tsdb: debug, "sqlstore.*": warn}
+modes:
+  file:
+    format: json
+    log_rotate: false
+    max_days: 7
+    routes:
+      security:
+        loggers: [auth, context]
+        exclusive: true
+`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "console file", cfg.Section("log").Key("mode").String())
+		assert.Equal(t, "info", cfg.Section("log").Key("level").String())
+		assert.Equal(t, "sqlstore.*:warn tsdb:debug", cfg.Section("log").Key("filters").String())
+		assert.Equal(t, "json", cfg.Section("log.file").Key("format").String())
+		assert.False(t, cfg.Section("log.file").Key("log_rotate").MustBool(true))
+		assert.Equal(t, 7, cfg.Section("log.file").Key("max_days").MustInt(0))
+		assert.Equal(t, "auth context", cfg.Section("log.file.security").Key("loggers").String())
+		assert.True(t, cfg.Section("log.file.security").Key("exclusive").MustBool(false))
+	})
+
+	t.Run("JSON documents are converted to log sections", func(t *testing.T) {
+		cfg, err := ParseLoggingConfigDocument([]byte(`{"mode": "console", "modes": {"console": {"level": "debug", "format": "logstash"}}}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "console", cfg.Section("log").Key("mode").String())
+		assert.Equal(t, "debug", cfg.Section("log.console").Key("level").String())
+		assert.Equal(t, "logstash", cfg.Section("log.console").Key("format").String())
+	})
+
+	t.Run("Invalid documents are rejected", func(t *testing.T) {
+		for doc, expected := range map[string]string{
+			"modes: [console]":                             "modes of the logging config document must be a mapping",
+			"modes: {file: json}":                          "modes.file of the logging config document must be a mapping",
+			"modes: {file: {routes: [security]}}":          "modes.file.routes of the logging config document must be a mapping",
+			"filters: {tsdb: [debug]}":                     "log.filters.tsdb of the logging config document must be a single value",
+			"modes: {file: {routes: {security: loggers}}}": "modes.file.routes.security of the logging config document must be a mapping",
+		} {
+			_, err := ParseLoggingConfigDocument([]byte(doc))
+			require.EqualError(t, err, expected, doc)
+		}
+
+		_, err := ParseLoggingConfigDocument([]byte("mode: [console"))
+		require.Error(t, err)
+	})
+}
+
+func TestReadLoggingConfigDocument(t *testing.T) {
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, ReadLoggingConfigDocument([]byte(`
+mode: file
+recent_buffer_size: 0
+filters: {tsdb: error}
+modes:
+  file:
+    file_name: `+filepath.Join(dir, "grafana.log")+`
+    format: logstash
+`), dir))
+
+	New("tsdb").Info("Query")
+	New("sqlstore").Info("Migration")
+	require.NoError(t, Close())
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "grafana.log"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "Query")
+	assert.Contains(t, string(b), `"message":"Migration"`)
+}
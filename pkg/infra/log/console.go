@@ -0,0 +1,115 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// terminalColors are the ANSI colors of the levels in the log15 terminal format.
+var terminalColors = map[log15.Lvl]int{
+	log15.LvlCrit:  35,
+	log15.LvlError: 31,
+	log15.LvlWarn:  33,
+	log15.LvlInfo:  32,
+	log15.LvlDebug: 36,
+}
+
+// colorNames are the names of the colors of the console_level_colors setting.
+var colorNames = map[string]int{
+	"black":   30,
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"white":   37,
+}
+
+// ansiColor matches the color escape sequences of the log15 terminal format.
+var ansiColor = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// readConsoleColors reads the console_colors and console_level_colors settings of the console
+// mode. Colors are disabled by console_colors = false, and by the NO_COLOR environment variable.
+// It returns nil colors when colors are disabled.
+func readConsoleColors(sec *ini.Section) (map[log15.Lvl]int, error) {
+	if !sec.Key("console_colors").MustBool(true) || os.Getenv("NO_COLOR") != "" {
+		return nil, nil
+	}
+
+	colors := make(map[log15.Lvl]int, len(terminalColors))
+	for lvl, color := range terminalColors {
+		colors[lvl] = color
+	}
+	for _, levelColor := range strings.Fields(sec.Key("console_level_colors").String()) {
+		parts := strings.SplitN(levelColor, ":", 2)
+		lvl, ok := logLevels[strings.ToLower(parts[0])]
+		if len(parts) != 2 || !ok || lvl == lvlOff {
+			return nil, fmt.Errorf("invalid console level color %q, level colors must be given as level:color", levelColor)
+		}
+		color, ok := colorNames[strings.ToLower(parts[1])]
+		if !ok {
+			code, err := strconv.Atoi(parts[1])
+			if err != nil || code < 0 || code > 255 {
+				return nil, fmt.Errorf("invalid color %q of console level color %q, valid options are black, red, green, yellow, blue, magenta, cyan, white and ANSI codes", parts[1], levelColor)
+			}
+			color = code
+		}
+		colors[lvl] = color
+	}
+	return colors, nil
+}
+
+// consoleColorFormat replaces the colors of the levels in the lines of the terminal format with
+// colors, or removes them when colors is nil.
+func consoleColorFormat(format log15.Format, colors map[log15.Lvl]int) log15.Format {
+	return log15.FormatFunc(func(r *log15.Record) []byte {
+		b := format.Format(r)
+		if colors == nil {
+			return ansiColor.ReplaceAll(b, nil)
+		}
+		if colors[r.Lvl] == terminalColors[r.Lvl] {
+			return b
+		}
+		return bytes.ReplaceAll(b, []byte(fmt.Sprintf("\x1b[%dm", terminalColors[r.Lvl])), []byte(fmt.Sprintf("\x1b[%dm", colors[r.Lvl])))
+	})
+}
+
+// readStderrLevel reads the stderr_level setting of the console mode, the level from which records
+// are written to stderr instead of stdout. Without it, all records are written to stdout, which is
+// the same as a level of off.
+func readStderrLevel(sec *ini.Section) (log15.Lvl, error) {
+	levelName := strings.ToLower(sec.Key("stderr_level").MustString(""))
+	if levelName == "" {
+		return lvlOff, nil
+	}
+	level, ok := logLevels[levelName]
+	if !ok {
+		return 0, fmt.Errorf("unknown stderr_level %q", levelName)
+	}
+	return level, nil
+}
+
+// consoleStreamHandler writes records of stderrLevel or more severe to stderr, and other records to
+// stdout, so container orchestrators can tell the severity of records by their stream.
+func consoleStreamHandler(stdout io.Writer, stderr io.Writer, stderrLevel log15.Lvl, format log15.Format) log15.Handler {
+	stdoutHandler := log15.StreamHandler(stdout, format)
+	if stderrLevel == lvlOff {
+		return stdoutHandler
+	}
+	stderrHandler := log15.StreamHandler(stderr, format)
+	return log15.FuncHandler(func(r *log15.Record) error {
+		if r.Lvl <= stderrLevel {
+			return stderrHandler.Log(r)
+		}
+		return stdoutHandler.Log(r)
+	})
+}
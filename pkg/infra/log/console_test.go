@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestConsoleColors(t *testing.T) {
+	readColors := func(t *testing.T, config string) (map[log15.Lvl]int, error) {
+		t.Helper()
+		cfg, err := ini.Load([]byte("[log.console]\n" + config))
+		require.NoError(t, err)
+		return readConsoleColors(cfg.Section("log.console"))
+	}
+	record := &log15.Record{
+		Time: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC),
+		Lvl:  log15.LvlError,
+		Msg:  "Query failed",
+		Ctx:  []interface{}{"logger", "sqlstore"},
+	}
+
+	t.Run("Level colors are replaced", func(t *testing.T) {
+		colors, err := readColors(t, "console_level_colors = error:blue warn:91")
+		require.NoError(t, err)
+		assert.Equal(t, 34, colors[log15.LvlError])
+		assert.Equal(t, 91, colors[log15.LvlWarn])
+		assert.Equal(t, 32, colors[log15.LvlInfo])
+
+		line := string(consoleColorFormat(log15.TerminalFormat(), colors).Format(record))
+		assert.Equal(t, "\x1b[34mEROR\x1b[0m[03-10|12:00:00] Query failed                             \x1b[34mlogger\x1b[0m=sqlstore\n", line)
+	})
+
+	t.Run("Colors are disabled by the setting and NO_COLOR", func(t *testing.T) {
+		colors, err := readColors(t, "console_colors = false")
+		require.NoError(t, err)
+		assert.Nil(t, colors)
+
+		require.NoError(t, os.Setenv("NO_COLOR", "1"))
+		t.Cleanup(func() {
+			require.NoError(t, os.Unsetenv("NO_COLOR"))
+		})
+		colors, err = readColors(t, "")
+		require.NoError(t, err)
+		assert.Nil(t, colors)
+
+		line := string(consoleColorFormat(log15.TerminalFormat(), colors).Format(record))
+		assert.Equal(t, "EROR[03-10|12:00:00] Query failed                             logger=sqlstore\n", line)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		_, err := readColors(t, "console_level_colors = error:pink")
+		require.EqualError(t, err, `invalid color "pink" of console level color "error:pink", valid options are black, red, green, yellow, blue, magenta, cyan, white and ANSI codes`)
+		_, err = readColors(t, "console_level_colors = red")
+		require.EqualError(t, err, `invalid console level color "red", level colors must be given as level:color`)
+	})
+}
+
+func TestConsoleStreams(t *testing.T) {
+	readLevel := func(t *testing.T, config string) (log15.Lvl, error) {
+		t.Helper()
+		cfg, err := ini.Load([]byte("[log.console]\n" + config))
+		require.NoError(t, err)
+		return readStderrLevel(cfg.Section("log.console"))
+	}
+	logRecords := func(t *testing.T, config string) (string, string) {
+		t.Helper()
+		level, err := readLevel(t, config)
+		require.NoError(t, err)
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		handler := consoleStreamHandler(stdout, stderr, level, log15.LogfmtFormat())
+		for _, lvl := range []log15.Lvl{log15.LvlDebug, log15.LvlInfo, log15.LvlWarn, log15.LvlError, log15.LvlCrit} {
+			require.NoError(t, handler.Log(&log15.Record{Time: time.Unix(0, 0), Lvl: lvl, Msg: levelNames[lvl] + " message"}))
+		}
+		return stdout.String(), stderr.String()
+	}
+
+	t.Run("Records of the stderr level or more severe are written to stderr", func(t *testing.T) {
+		stdout, stderr := logRecords(t, "stderr_level = warn")
+
+		assert.Contains(t, stdout, "debug message")
+		assert.Contains(t, stdout, "info message")
+		assert.NotContains(t, stdout, "warn message")
+		assert.Contains(t, stderr, "warn message")
+		assert.Contains(t, stderr, "error message")
+		assert.Contains(t, stderr, "critical message")
+		assert.NotContains(t, stderr, "info message")
+	})
+
+	t.Run("All records are written to stdout by default", func(t *testing.T) {
+		stdout, stderr := logRecords(t, "")
+
+		assert.Contains(t, stdout, "debug message")
+		assert.Contains(t, stdout, "critical message")
+		assert.Empty(t, stderr)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		_, err := readLevel(t, "stderr_level = warning")
+		require.EqualError(t, err, `unknown stderr_level "warning"`)
+	})
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"context"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+type loggerContextKey struct{}
+
+// ToContext returns a copy of ctx carrying logger, so functions called with the context can log
+// with the same context values, like the request ID and user of an HTTP request.
+func ToContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the root logger if ctx doesn't carry one.
+func FromContext(ctx context.Context) *ConcreteLogger {
+	if ctx != nil {
+		switch logger := ctx.Value(loggerContextKey{}).(type) {
+		case *ConcreteLogger:
+			return logger
+		case Logger:
+			return &ConcreteLogger{Logger: logger}
+		}
+	}
+	return &ConcreteLogger{Logger: Root}
+}
+
+// DebugCtx logs a message at the debug level, with the IDs of the tracing span of ctx.
+func (cl *ConcreteLogger) DebugCtx(ctx context.Context, msg string, args ...interface{}) {
+	cl.Debug(msg, withContextValues(ctx, args)...)
+}
+
+// InfoCtx logs a message at the info level, with the IDs of the tracing span of ctx.
+func (cl *ConcreteLogger) InfoCtx(ctx context.Context, msg string, args ...interface{}) {
+	cl.Info(msg, withContextValues(ctx, args)...)
+}
+
+// WarnCtx logs a message at the warn level, with the IDs of the tracing span of ctx.
+func (cl *ConcreteLogger) WarnCtx(ctx context.Context, msg string, args ...interface{}) {
+	cl.Warn(msg, withContextValues(ctx, args)...)
+}
+
+// ErrorCtx logs a message at the error level, with the IDs of the tracing span of ctx.
+func (cl *ConcreteLogger) ErrorCtx(ctx context.Context, msg string, args ...interface{}) {
+	cl.Error(msg, withContextValues(ctx, args)...)
+}
+
+// ContextualLogProvider returns the key values of a context, like the org, user and request IDs
+// middleware adds to the context of a request, or nil if ctx doesn't have any.
+type ContextualLogProvider func(ctx context.Context) []interface{}
+
+var (
+	contextualLogProvidersMu sync.RWMutex
+	contextualLogProviders   []ContextualLogProvider
+)
+
+// RegisterContextualLogProvider registers a provider of key values that the Ctx methods of loggers
+// append to their records, in the order providers are registered, so all records logged within the
+// context of a request have the values middleware knows of the request.
+func RegisterContextualLogProvider(provider ContextualLogProvider) {
+	contextualLogProvidersMu.Lock()
+	defer contextualLogProvidersMu.Unlock()
+	contextualLogProviders = append(contextualLogProviders, provider)
+}
+
+// withContextValues appends the key values of the registered providers and the IDs of the tracing
+// span of ctx to args.
+func withContextValues(ctx context.Context, args []interface{}) []interface{} {
+	if ctx == nil {
+		return args
+	}
+	contextualLogProvidersMu.RLock()
+	providers := contextualLogProviders
+	contextualLogProvidersMu.RUnlock()
+
+	for _, provider := range providers {
+		if keyvals := provider(ctx); len(keyvals) > 0 {
+			args = append(args[:len(args):len(args)], keyvals...)
+		}
+	}
+	return withTraceIDs(ctx, args)
+}
+
+// withTraceIDs appends the traceID and spanID of the active span of ctx to args. Spans that
+// aren't sampled by the Jaeger tracer are not appended, since they can't be looked up.
+func withTraceIDs(ctx context.Context, args []interface{}) []interface{} {
+	if ctx == nil {
+		return args
+	}
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return args
+	}
+	spanCtx, ok := span.Context().(jaeger.SpanContext)
+	if !ok || !spanCtx.IsValid() || !spanCtx.IsSampled() {
+		return args
+	}
+	return append(args[:len(args):len(args)], "traceID", spanCtx.TraceID().String(), "spanID", spanCtx.SpanID().String())
+}
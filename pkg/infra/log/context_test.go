@@ -0,0 +1,113 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestLoggerContext(t *testing.T) {
+	t.Run("Without a logger in the context the root logger is returned", func(t *testing.T) {
+		assert.Equal(t, Root, FromContext(context.Background()).Logger)
+	})
+
+	t.Run("The logger of the context logs with its context values", func(t *testing.T) {
+		var records []*log15.Record
+		logger := New("context", "requestId", "abc")
+		logger.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+			records = append(records, r)
+			return nil
+		}))
+
+		ctx := ToContext(context.Background(), logger)
+		FromContext(ctx).Info("hello", "orgId", 1)
+
+		require.Len(t, records, 1)
+		assert.Equal(t, []interface{}{"logger", "context", "requestId", "abc", "orgId", 1}, records[0].Ctx)
+	})
+	t.Run("Ctx methods log the IDs of the sampled span of the context", func(t *testing.T) {
+		var records []*log15.Record
+		logger := New("context")
+		logger.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+			records = append(records, r)
+			return nil
+		}))
+
+		logger.InfoCtx(context.Background(), "without span", "orgId", 1)
+		require.Len(t, records, 1)
+		assert.Equal(t, []interface{}{"logger", "context", "orgId", 1}, records[0].Ctx)
+
+		sampled, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+		defer func() {
+			_ = closer.Close()
+		}()
+		span := sampled.StartSpan("request")
+		spanCtx := span.Context().(jaeger.SpanContext)
+		ctx := opentracing.ContextWithSpan(context.Background(), span)
+
+		logger.ErrorCtx(ctx, "with span", "orgId", 1)
+		require.Len(t, records, 2)
+		assert.Equal(t, log15.LvlError, records[1].Lvl)
+		assert.Equal(t, []interface{}{"logger", "context", "orgId", 1, "traceID", spanCtx.TraceID().String(), "spanID", spanCtx.SpanID().String()}, records[1].Ctx)
+
+		// Loggers created from the logger keep logging the span IDs.
+		child, ok := logger.New("child", true).(*ConcreteLogger)
+		require.True(t, ok)
+		child.WarnCtx(ctx, "from child")
+		require.Len(t, records, 3)
+		assert.Equal(t, []interface{}{"logger", "context", "child", true, "traceID", spanCtx.TraceID().String(), "spanID", spanCtx.SpanID().String()}, records[2].Ctx)
+
+		unsampled, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(false), jaeger.NewNullReporter())
+		defer func() {
+			_ = closer.Close()
+		}()
+		ctx = opentracing.ContextWithSpan(context.Background(), unsampled.StartSpan("request"))
+		logger.DebugCtx(ctx, "unsampled span")
+		require.Len(t, records, 4)
+		assert.Equal(t, []interface{}{"logger", "context"}, records[3].Ctx)
+	})
+}
+
+func TestContextualLogProviders(t *testing.T) {
+	t.Cleanup(func() {
+		contextualLogProvidersMu.Lock()
+		contextualLogProviders = nil
+		contextualLogProvidersMu.Unlock()
+	})
+
+	type requestIDKey struct{}
+	RegisterContextualLogProvider(func(ctx context.Context) []interface{} {
+		if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"requestID", requestID}
+		}
+		return nil
+	})
+	RegisterContextualLogProvider(func(ctx context.Context) []interface{} {
+		return []interface{}{"orgId", 1}
+	})
+
+	var records []*log15.Record
+	logger := New("context")
+	logger.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+
+	args := make([]interface{}, 2, 8)
+	args[0], args[1] = "userId", 2
+	logger.InfoCtx(context.WithValue(context.Background(), requestIDKey{}, "abc"), "with request", args...)
+	logger.InfoCtx(context.Background(), "without request", args...)
+	logger.Info("without context", args...)
+
+	require.Len(t, records, 3)
+	assert.Equal(t, []interface{}{"logger", "context", "userId", 2, "requestID", "abc", "orgId", 1}, records[0].Ctx)
+	assert.Equal(t, []interface{}{"logger", "context", "userId", 2, "orgId", 1}, records[1].Ctx)
+	assert.Equal(t, []interface{}{"logger", "context", "userId", 2}, records[2].Ctx)
+	// The key values are appended to a copy of the arguments.
+	assert.Nil(t, args[:4][2])
+}
@@ -0,0 +1,93 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/inconshreveable/log15"
+)
+
+// dedupHandler collapses identical consecutive records within a window. The first record is passed
+// at once, the repeats are counted and passed as a single record with a `repeated` key value once
+// another record is logged or the window ends.
+type dedupHandler struct {
+	window time.Duration
+	h      log15.Handler
+
+	mu       sync.Mutex
+	first    *log15.Record
+	key      string
+	last     *log15.Record
+	repeated int
+	timer    *clock.Timer
+}
+
+// DedupHandler collapses identical consecutive records logged within window into a single record
+// with a `repeated` key value, the number of repeats. A window of 0 disables it.
+func DedupHandler(window time.Duration, h log15.Handler) log15.Handler {
+	if window <= 0 {
+		return h
+	}
+	return &dedupHandler{window: window, h: h}
+}
+
+func (d *dedupHandler) Log(r *log15.Record) error {
+	key := dedupKey(r)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.first != nil && key == d.key && r.Time.Sub(d.first.Time) < d.window {
+		d.last = r
+		d.repeated++
+		if d.repeated == 1 {
+			d.timer = afterFunc(d.window-r.Time.Sub(d.first.Time), d.flush)
+		}
+		return nil
+	}
+
+	if err := d.logRepeated(); err != nil {
+		return err
+	}
+	d.first, d.key = r, key
+	return d.h.Log(r)
+}
+
+// flush passes the repeats when the window ends without another record being logged.
+func (d *dedupHandler) flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.repeated == 0 {
+		return
+	}
+	if err := d.logRepeated(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to log repeated records: %s\n", err)
+	}
+	d.first = nil
+}
+
+// logRepeated passes the last repeat with the number of repeats, if there are any.
+func (d *dedupHandler) logRepeated() error {
+	if d.repeated == 0 {
+		return nil
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	repeated := *d.last
+	repeated.Ctx = append(d.last.Ctx[:len(d.last.Ctx):len(d.last.Ctx)], "repeated", d.repeated)
+	d.last = nil
+	d.repeated = 0
+	return d.h.Log(&repeated)
+}
+
+// dedupKey returns a key that's the same for records with the same level, message and key values.
+func dedupKey(r *log15.Record) string {
+	return fmt.Sprintf("%d %q %v", r.Lvl, r.Msg, r.Ctx)
+}
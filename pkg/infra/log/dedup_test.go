@@ -0,0 +1,62 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupHandler(t *testing.T) {
+	mock := clock.NewMock()
+	mock.Set(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+	SetClock(mock)
+	t.Cleanup(func() { SetClock(clock.New()) })
+
+	var records []*log15.Record
+	handler := DedupHandler(time.Minute, log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	logRecord := func(msg string, ctx ...interface{}) {
+		require.NoError(t, handler.Log(&log15.Record{Time: mock.Now(), Lvl: log15.LvlError, Msg: msg, Ctx: ctx}))
+	}
+
+	t.Run("Repeats are logged once another record is logged", func(t *testing.T) {
+		logRecord("Failed to query", "logger", "sqlstore")
+		logRecord("Failed to query", "logger", "sqlstore")
+		logRecord("Failed to query", "logger", "sqlstore")
+		require.Len(t, records, 1)
+
+		logRecord("Failed to query", "logger", "tsdb")
+		require.Len(t, records, 3)
+		assert.Equal(t, []interface{}{"logger", "sqlstore", "repeated", 2}, records[1].Ctx)
+		assert.Equal(t, []interface{}{"logger", "tsdb"}, records[2].Ctx)
+	})
+
+	t.Run("Repeats are logged when the window ends", func(t *testing.T) {
+		records = nil
+		mock.Add(10 * time.Second)
+		logRecord("Failed to query", "logger", "tsdb")
+		require.Empty(t, records)
+
+		mock.Add(time.Minute)
+		require.Len(t, records, 1)
+		assert.Equal(t, []interface{}{"logger", "tsdb", "repeated", 1}, records[0].Ctx)
+
+		// The window ended, so the next record is logged again.
+		logRecord("Failed to query", "logger", "tsdb")
+		require.Len(t, records, 2)
+		assert.Equal(t, []interface{}{"logger", "tsdb"}, records[1].Ctx)
+	})
+
+	t.Run("Records after the window are not collapsed", func(t *testing.T) {
+		records = nil
+		mock.Add(2 * time.Minute)
+		logRecord("Failed to query", "logger", "tsdb")
+		require.Len(t, records, 1)
+	})
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// deltaTimeKey is the key of the time since the previous record of the same logger.
+const deltaTimeKey = "Δt"
+
+// DeltaTimeHandler adds the milliseconds since the previous record of the same logger to records,
+// in the `Δt` key, before passing them on to h, to tell how long the phases of startup or
+// migrations take from the logs alone. The first record of a logger has no `Δt`.
+func DeltaTimeHandler(enabled bool, h log15.Handler) log15.Handler {
+	if !enabled {
+		return h
+	}
+
+	var mu sync.Mutex
+	previous := map[string]time.Time{}
+	return log15.FuncHandler(func(r *log15.Record) error {
+		loggerName, _ := recordLogger(r)
+
+		mu.Lock()
+		last, ok := previous[loggerName]
+		if !ok || r.Time.After(last) {
+			previous[loggerName] = r.Time
+		}
+		mu.Unlock()
+
+		if !ok {
+			return h.Log(r)
+		}
+		// Records logged concurrently may be passed in a different order than they're timestamped.
+		elapsed := r.Time.Sub(last)
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		delta := *r
+		delta.Ctx = append(r.Ctx[:len(r.Ctx):len(r.Ctx)], deltaTimeKey, float64(elapsed)/float64(time.Millisecond))
+		return h.Log(&delta)
+	})
+}
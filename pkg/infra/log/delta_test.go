@@ -0,0 +1,53 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaTimeHandler(t *testing.T) {
+	t.Run("The time since the previous record of the same logger is added to records", func(t *testing.T) {
+		var logged []*log15.Record
+		h := DeltaTimeHandler(true, log15.FuncHandler(func(r *log15.Record) error {
+			logged = append(logged, r)
+			return nil
+		}))
+		start := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+		record := func(logger string, elapsed time.Duration) *log15.Record {
+			return &log15.Record{Time: start.Add(elapsed), Msg: "migrating", Ctx: []interface{}{"logger", logger}}
+		}
+
+		first := record("migrator", 0)
+		require.NoError(t, h.Log(first))
+		require.NoError(t, h.Log(record("server", 100*time.Millisecond)))
+		require.NoError(t, h.Log(record("migrator", 1500*time.Microsecond)))
+		require.NoError(t, h.Log(record("server", 2*time.Second)))
+		// Records passed out of order have no negative time.
+		require.NoError(t, h.Log(record("server", time.Second)))
+
+		require.Len(t, logged, 5)
+		assert.Equal(t, []interface{}{"logger", "migrator"}, logged[0].Ctx)
+		assert.Equal(t, []interface{}{"logger", "server"}, logged[1].Ctx)
+		assert.Equal(t, []interface{}{"logger", "migrator", "Δt", 1.5}, logged[2].Ctx)
+		assert.Equal(t, []interface{}{"logger", "server", "Δt", 1900.0}, logged[3].Ctx)
+		assert.Equal(t, []interface{}{"logger", "server", "Δt", 0.0}, logged[4].Ctx)
+		assert.Equal(t, []interface{}{"logger", "migrator"}, first.Ctx)
+	})
+
+	t.Run("Records are passed on unchanged when disabled", func(t *testing.T) {
+		var logged []*log15.Record
+		h := DeltaTimeHandler(false, log15.FuncHandler(func(r *log15.Record) error {
+			logged = append(logged, r)
+			return nil
+		}))
+
+		require.NoError(t, h.Log(&log15.Record{Time: time.Now(), Ctx: []interface{}{"logger", "server"}}))
+		require.NoError(t, h.Log(&log15.Record{Time: time.Now(), Ctx: []interface{}{"logger", "server"}}))
+		require.Len(t, logged, 2)
+		assert.Equal(t, []interface{}{"logger", "server"}, logged[1].Ctx)
+	})
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/inconshreveable/log15"
+)
+
+const (
+	// levelEnvVar overrides the level of all modes.
+	levelEnvVar = "GF_LOG_LEVEL"
+	// filtersEnvVar overrides the filters of all modes.
+	filtersEnvVar = "GF_LOG_FILTERS"
+)
+
+// envOverrides are the level and filters of the environment, which replace the levels and filters
+// of the config in all modes, so containers can log at another level without changing the config.
+type envOverrides struct {
+	level      log15.Lvl
+	hasLevel   bool
+	filters    map[string]log15.Lvl
+	hasFilters bool
+}
+
+// readEnvOverrides reads the GF_LOG_LEVEL and GF_LOG_FILTERS environment variables. Unset or empty
+// variables don't override the config.
+func readEnvOverrides() (envOverrides, error) {
+	var overrides envOverrides
+	if levelName := strings.TrimSpace(os.Getenv(levelEnvVar)); levelName != "" {
+		level, ok := logLevels[strings.ToLower(levelName)]
+		if !ok {
+			return envOverrides{}, fmt.Errorf("unknown log level %q of %s", levelName, levelEnvVar)
+		}
+		overrides.level, overrides.hasLevel = level, true
+	}
+	if filters := strings.TrimSpace(os.Getenv(filtersEnvVar)); filters != "" {
+		overrides.filters, overrides.hasFilters = getFilters(util.SplitString(filters)), true
+	}
+	return overrides, nil
+}
+
+// apply overrides the level and filters of a mode.
+func (o envOverrides) apply(level log15.Lvl, filters map[string]log15.Lvl) (log15.Lvl, map[string]log15.Lvl) {
+	if o.hasLevel {
+		level = o.level
+	}
+	if o.hasFilters {
+		filters = make(map[string]log15.Lvl, len(o.filters))
+		for pattern, filterLevel := range o.filters {
+			filters[pattern] = filterLevel
+		}
+	}
+	return level, filters
+}
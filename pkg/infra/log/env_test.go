@@ -0,0 +1,59 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	setEnv := func(t *testing.T, key string, value string) {
+		t.Helper()
+		require.NoError(t, os.Setenv(key, value))
+		t.Cleanup(func() {
+			require.NoError(t, os.Unsetenv(key))
+		})
+	}
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	t.Run("The environment overrides the level and filters of all modes", func(t *testing.T) {
+		setEnv(t, "GF_LOG_LEVEL", "DEBUG")
+		setEnv(t, "GF_LOG_FILTERS", "sqlstore:error")
+
+		dir := t.TempDir()
+		cfg, err := ini.Load([]byte("[log]\nlevel = warn\nfilters = tsdb:error\nrecent_buffer_size = 0\n" +
+			"[log.file]\nlevel = error\nfilters = server:critical\nfile_name = " + filepath.Join(dir, "grafana.log")))
+		require.NoError(t, err)
+		require.NoError(t, ReadLoggingConfig([]string{"file"}, dir, cfg))
+
+		New("tsdb").Debug("Query started")
+		New("server").Info("Server started")
+		New("sqlstore").Warn("Slow query")
+		assert.Equal(t, map[string]string{"sqlstore": "error"}, GetLevels())
+		require.NoError(t, Close())
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "grafana.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(b), "Query started")
+		assert.Contains(t, string(b), "Server started")
+		assert.NotContains(t, string(b), "Slow query")
+	})
+
+	t.Run("Invalid levels are rejected", func(t *testing.T) {
+		setEnv(t, "GF_LOG_LEVEL", "verbose")
+
+		cfg, err := ini.Load([]byte("[log.file]\nfile_name = " + filepath.Join(t.TempDir(), "grafana.log")))
+		require.NoError(t, err)
+		require.EqualError(t, ReadLoggingConfig([]string{"file"}, t.TempDir(), cfg),
+			`failed to read environment overrides of log: unknown log level "verbose" of GF_LOG_LEVEL`)
+	})
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/inconshreveable/log15"
+)
+
+// errorIDVariables matches the parts of error messages that vary between occurrences of the same
+// error, like quoted values, UUIDs, hexadecimal IDs, addresses and numbers.
+var errorIDVariables = regexp.MustCompile(`"[^"]*"|'[^']*'|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|0x[0-9a-fA-F]+|\b[0-9a-fA-F]*[0-9][0-9a-fA-F]*\b`)
+
+// errorID returns a fingerprint of an error, a hash of the type of the innermost error it wraps
+// and its message without the parts that vary between occurrences, so occurrences of the same
+// error get the same ID.
+func errorID(err error) string {
+	cause := err
+	for unwrapped := errors.Unwrap(cause); unwrapped != nil; unwrapped = errors.Unwrap(cause) {
+		cause = unwrapped
+	}
+	message := errorIDVariables.ReplaceAllString(err.Error(), "?")
+
+	sum := sha1.Sum([]byte(fmt.Sprintf("%T\x00%s", cause, message)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ErrorIDHandler adds the fingerprint of the first error value of records, in the `errorId` key,
+// before passing them on to h, so occurrences of the same error can be grouped by log stores.
+// Records that already have an errorId are passed on unchanged.
+func ErrorIDHandler(enabled bool, h log15.Handler) log15.Handler {
+	if !enabled {
+		return h
+	}
+
+	return log15.FuncHandler(func(r *log15.Record) error {
+		var err error
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			if key, ok := r.Ctx[i].(string); ok && key == "errorId" {
+				return h.Log(r)
+			}
+			if e, ok := r.Ctx[i+1].(error); ok && e != nil && err == nil {
+				err = e
+			}
+		}
+		if err == nil {
+			return h.Log(r)
+		}
+
+		identified := *r
+		identified.Ctx = append(r.Ctx[:len(r.Ctx):len(r.Ctx)], "errorId", errorID(err))
+		return h.Log(&identified)
+	})
+}
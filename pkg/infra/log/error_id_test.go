@@ -0,0 +1,51 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorID(t *testing.T) {
+	t.Run("Occurrences of the same error get the same ID", func(t *testing.T) {
+		first := fmt.Errorf("failed to query datasource %d: %w", 12, errors.New(`user "admin" not found`))
+		second := fmt.Errorf("failed to query datasource %d: %w", 345, errors.New(`user "editor" not found`))
+		assert.Equal(t, errorID(first), errorID(second))
+		assert.Len(t, errorID(first), 16)
+
+		assert.Equal(t,
+			errorID(fmt.Errorf("dashboard 5d2c8a5e-1b2a-4c3d-9e8f-0a1b2c3d4e5f not found")),
+			errorID(fmt.Errorf("dashboard 0f9e8d7c-6b5a-4c3d-2e1f-0a9b8c7d6e5f not found")))
+	})
+
+	t.Run("Different errors get different IDs", func(t *testing.T) {
+		assert.NotEqual(t, errorID(errors.New("user not found")), errorID(errors.New("dashboard not found")))
+		// The same message of a different type of error.
+		assert.NotEqual(t, errorID(errors.New("file does not exist")), errorID(&os.PathError{Op: "open", Path: "", Err: errors.New("file does not exist")}))
+	})
+
+	t.Run("The ID of the first error is added to records", func(t *testing.T) {
+		var logged []*log15.Record
+		h := ErrorIDHandler(true, log15.FuncHandler(func(r *log15.Record) error {
+			logged = append(logged, r)
+			return nil
+		}))
+		err := errors.New("query failed")
+		record := &log15.Record{Msg: "Failed", Ctx: []interface{}{"logger", "tsdb", "error", err, "cause", errors.New("timeout")}}
+
+		require.NoError(t, h.Log(record))
+		require.NoError(t, h.Log(&log15.Record{Msg: "Started", Ctx: []interface{}{"logger", "tsdb"}}))
+		require.NoError(t, h.Log(&log15.Record{Msg: "Failed", Ctx: []interface{}{"error", err, "errorId", "custom"}}))
+
+		require.Len(t, logged, 3)
+		assert.Equal(t, []interface{}{"logger", "tsdb", "error", err, "cause", errors.New("timeout"), "errorId", errorID(err)}, logged[0].Ctx)
+		assert.Equal(t, []interface{}{"logger", "tsdb", "error", err, "cause", errors.New("timeout")}, record.Ctx)
+		assert.Equal(t, []interface{}{"logger", "tsdb"}, logged[1].Ctx)
+		assert.Equal(t, []interface{}{"error", err, "errorId", "custom"}, logged[2].Ctx)
+	})
+}
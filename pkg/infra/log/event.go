@@ -0,0 +1,82 @@
+package log
+
+import (
+	"context"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// Attr is a key value of an event. Attributes are made by the typed helpers, like Str and Int, so
+// events can't have a key without a value or a value of an unexpected type.
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+// Str returns a string attribute.
+func Str(key string, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Int64 returns a 64-bit integer attribute.
+func Int64(key string, value int64) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Float64 returns a floating point attribute.
+func Float64(key string, value float64) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Duration returns a duration attribute.
+func Duration(key string, value time.Duration) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Err returns an attribute of an error, in the error key. Events with a non-nil error are logged
+// at the error level.
+func Err(err error) Attr {
+	return Attr{Key: "error", Value: err}
+}
+
+// Any returns an attribute of a value of any other type.
+func Any(key string, value interface{}) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Event logs an event with the logger of ctx, see FromContext.
+func Event(ctx context.Context, name string, attrs ...Attr) {
+	FromContext(ctx).Event(ctx, name, attrs...)
+}
+
+// Event logs an event, with the name as the message and the attributes as key values, like the
+// Ctx methods with the values of ctx. Events are logged at the info level, or at the error level
+// if they have a non-nil error attribute.
+func (cl *ConcreteLogger) Event(ctx context.Context, name string, attrs ...Attr) {
+	lvl := log15.LvlInfo
+	args := make([]interface{}, 0, 2*len(attrs))
+	for _, attr := range attrs {
+		if err, ok := attr.Value.(error); ok && err != nil {
+			lvl = log15.LvlError
+		}
+		args = append(args, attr.Key, attr.Value)
+	}
+	args = withContextValues(ctx, args)
+
+	if lvl == log15.LvlError {
+		cl.Error(name, args...)
+	} else {
+		cl.Info(name, args...)
+	}
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent(t *testing.T) {
+	var records []*log15.Record
+	logger := New("events")
+	logger.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+
+	t.Run("Events are logged with their typed attributes", func(t *testing.T) {
+		records = nil
+		logger.Event(context.Background(), "dashboard saved", Str("uid", "abc"), Int("version", 2),
+			Int64("orgId", 1), Float64("ratio", 0.5), Bool("overwrite", true), Duration("took", time.Second),
+			Any("tags", []string{"a"}), Err(nil))
+
+		require.Len(t, records, 1)
+		assert.Equal(t, log15.LvlInfo, records[0].Lvl)
+		assert.Equal(t, "dashboard saved", records[0].Msg)
+		assert.Equal(t, []interface{}{"logger", "events", "uid", "abc", "version", 2, "orgId", int64(1),
+			"ratio", 0.5, "overwrite", true, "took", time.Second, "tags", []string{"a"}, "error", nil}, records[0].Ctx)
+	})
+
+	t.Run("Events with an error are logged at the error level", func(t *testing.T) {
+		records = nil
+		err := errors.New("database is locked")
+		logger.Event(context.Background(), "dashboard save failed", Str("uid", "abc"), Err(err))
+
+		require.Len(t, records, 1)
+		assert.Equal(t, log15.LvlError, records[0].Lvl)
+		assert.Equal(t, []interface{}{"logger", "events", "uid", "abc", "error", err}, records[0].Ctx)
+	})
+
+	t.Run("Events are logged with the logger and values of the context", func(t *testing.T) {
+		records = nil
+		t.Cleanup(func() {
+			contextualLogProvidersMu.Lock()
+			contextualLogProviders = nil
+			contextualLogProvidersMu.Unlock()
+		})
+		RegisterContextualLogProvider(func(ctx context.Context) []interface{} {
+			return []interface{}{"requestId", "req-1"}
+		})
+
+		ctx := ToContext(context.Background(), logger)
+		Event(ctx, "user invited", Int64("userId", 3))
+
+		require.Len(t, records, 1)
+		assert.Equal(t, []interface{}{"logger", "events", "userId", int64(3), "requestId", "req-1"}, records[0].Ctx)
+	})
+}
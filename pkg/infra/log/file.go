@@ -5,12 +5,15 @@
 package log
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -37,32 +40,77 @@ type FileLogWriter struct {
 	Maxdays       int64
 	dailyOpendate int
 
+	// Maximum combined size in bytes of the log file and the rotated log files, 0 means no limit
+	MaxTotalSize int64
+
 	Rotate    bool
 	startLock sync.Mutex
+
+	// BufferSize is the size in bytes of the buffer of writes to the file, 0 means writes aren't
+	// buffered. Buffered writes are flushed every FlushInterval, and after error and critical
+	// records.
+	BufferSize    int
+	FlushInterval time.Duration
+	quit          chan struct{}
+	done          chan struct{}
 }
 
 // an *os.File writer with locker.
 type MuxWriter struct {
 	sync.Mutex
 	fd *os.File
+	// buf buffers the writes to fd, when the file handler has a buffer.
+	buf *bufio.Writer
 }
 
 // write to os.File.
 func (l *MuxWriter) Write(b []byte) (int, error) {
 	l.Lock()
 	defer l.Unlock()
+	if l.buf != nil {
+		return l.buf.Write(b)
+	}
 	return l.fd.Write(b)
 }
 
+// flush writes the buffered writes to the file.
+func (l *MuxWriter) flush() error {
+	l.Lock()
+	defer l.Unlock()
+	return l.flushLocked()
+}
+
+func (l *MuxWriter) flushLocked() error {
+	if l.buf == nil {
+		return nil
+	}
+	return l.buf.Flush()
+}
+
+// closeFD writes the buffered writes to the file and closes it. The lock must be held.
+func (l *MuxWriter) closeFD() error {
+	flushErr := l.flushLocked()
+	if err := l.fd.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
 // set os.File in writer.
 func (l *MuxWriter) setFD(fd *os.File) error {
 	if l.fd != nil {
+		if err := l.flushLocked(); err != nil && !errors.Is(err, os.ErrClosed) {
+			fmt.Fprintf(os.Stderr, "Flushing old file in MuxWriter failed: %s\n", err)
+		}
 		if err := l.fd.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
 			return fmt.Errorf("closing old file in MuxWriter failed: %w", err)
 		}
 	}
 
 	l.fd = fd
+	if l.buf != nil {
+		l.buf.Reset(fd)
+	}
 	return nil
 }
 
@@ -76,6 +124,8 @@ func NewFileWriter() *FileLogWriter {
 		Daily:    true,
 		Maxdays:  7,
 		Rotate:   true,
+
+		FlushInterval: time.Second,
 	}
 	// use MuxWriter instead direct use os.File for lock write when rotate
 	w.mw = new(MuxWriter)
@@ -85,15 +135,54 @@ func NewFileWriter() *FileLogWriter {
 func (w *FileLogWriter) Log(r *log15.Record) error {
 	data := w.Format.Format(r)
 	w.docheck(len(data))
-	_, err := w.mw.Write(data)
-	return err
+	if _, err := w.mw.Write(data); err != nil {
+		return err
+	}
+	// Errors are written right away, since they may precede a crash.
+	if r.Lvl <= log15.LvlError {
+		return w.mw.flush()
+	}
+	return nil
 }
 
 func (w *FileLogWriter) Init() error {
 	if len(w.Filename) == 0 {
 		return errors.New("config must have filename")
 	}
-	return w.StartLogger()
+	if w.BufferSize > 0 {
+		if w.FlushInterval <= 0 {
+			return errors.New("flush interval must be greater than 0")
+		}
+		w.mw.buf = bufio.NewWriterSize(nil, w.BufferSize)
+	}
+	if err := w.StartLogger(); err != nil {
+		return err
+	}
+	if w.BufferSize > 0 {
+		w.quit = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.flushPeriodically()
+	}
+	return nil
+}
+
+// flushPeriodically writes the buffered writes to the file every flush interval, until the file
+// logger is closed.
+func (w *FileLogWriter) flushPeriodically() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.mw.flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.Filename, err)
+			}
+		case <-w.quit:
+			return
+		}
+	}
 }
 
 // start file logger. create log file and set to locker-inside file writer.
@@ -199,8 +288,7 @@ func (w *FileLogWriter) DoRotate() error {
 		w.mw.Lock()
 		defer w.mw.Unlock()
 
-		fd := w.mw.fd
-		if err := fd.Close(); err != nil {
+		if err := w.mw.closeFD(); err != nil {
 			return err
 		}
 
@@ -240,17 +328,75 @@ func (w *FileLogWriter) deleteOldLog() {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.Filename, err)
 	}
+
+	if err := w.deleteOverTotalSize(); err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.Filename, err)
+	}
+}
+
+// deleteOverTotalSize deletes the oldest rotated log files until the combined size of the log files
+// is within MaxTotalSize. The current log file is never deleted.
+func (w *FileLogWriter) deleteOverTotalSize() error {
+	if w.MaxTotalSize <= 0 {
+		return nil
+	}
+
+	dir := filepath.Dir(w.Filename)
+	base := filepath.Base(w.Filename)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list log files: %w", err)
+	}
+
+	var total int64
+	var rotated []os.FileInfo
+	for _, file := range files {
+		if file.IsDir() || (file.Name() != base && !strings.HasPrefix(file.Name(), base+".")) {
+			continue
+		}
+		total += file.Size()
+		if file.Name() != base {
+			rotated = append(rotated, file)
+		}
+	}
+
+	sort.Slice(rotated, func(i, j int) bool {
+		if !rotated[i].ModTime().Equal(rotated[j].ModTime()) {
+			return rotated[i].ModTime().Before(rotated[j].ModTime())
+		}
+		return rotated[i].Name() < rotated[j].Name()
+	})
+	for _, file := range rotated {
+		if total <= w.MaxTotalSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, file.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to delete old log '%s', error: %w", file.Name(), err)
+		}
+		total -= file.Size()
+	}
+	return nil
 }
 
 // destroy file logger, close file writer.
 func (w *FileLogWriter) Close() error {
-	return w.mw.fd.Close()
+	if w.quit != nil {
+		close(w.quit)
+		<-w.done
+		w.quit = nil
+	}
+
+	w.mw.Lock()
+	defer w.mw.Unlock()
+	return w.mw.closeFD()
 }
 
 // flush file logger.
-// there are no buffering messages in file logger in memory.
-// flush file means sync file from disk.
+// flush file means writing the buffered messages and syncing the file to disk.
 func (w *FileLogWriter) Flush() {
+	if err := w.mw.flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.Filename, err)
+	}
 	if err := w.mw.fd.Sync(); err != nil {
 		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.Filename, err)
 	}
@@ -263,8 +409,7 @@ func (w *FileLogWriter) Reload() error {
 	defer w.mw.Unlock()
 
 	// Close
-	fd := w.mw.fd
-	if err := fd.Close(); err != nil {
+	if err := w.mw.closeFD(); err != nil {
 		return err
 	}
 
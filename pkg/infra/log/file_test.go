@@ -1,9 +1,13 @@
 package log
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/inconshreveable/log15"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
@@ -46,3 +50,99 @@ func TestLogFile(t *testing.T) {
 		assert.Equal(t, 3, fileLogWrite.maxlinesCurlines)
 	})
 }
+
+func TestLogFileMaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, size int, age time.Duration) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, make([]byte, size), 0600))
+		modTime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	writeFile("grafana.log", 400, 0)
+	writeFile("grafana.log.2021-03-08.001", 300, 3*time.Hour)
+	writeFile("grafana.log.2021-03-09.001", 300, 2*time.Hour)
+	writeFile("grafana.log.2021-03-10.001", 300, time.Hour)
+	writeFile("other.log", 1000, 4*time.Hour)
+
+	fileLogWrite := NewFileWriter()
+	fileLogWrite.Filename = filepath.Join(dir, "grafana.log")
+	fileLogWrite.MaxTotalSize = 900
+	require.NoError(t, fileLogWrite.deleteOverTotalSize())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	// The two oldest rotated files are deleted, files of other loggers are kept.
+	assert.Equal(t, []string{"grafana.log", "grafana.log.2021-03-10.001", "other.log"}, names)
+
+	// The current log file is kept even when it exceeds the limit on its own.
+	fileLogWrite.MaxTotalSize = 100
+	require.NoError(t, fileLogWrite.deleteOverTotalSize())
+	_, err = os.Stat(fileLogWrite.Filename)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "grafana.log.2021-03-10.001"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestLogFileBufferedWrites(t *testing.T) {
+	readLog := func(t *testing.T, w *FileLogWriter) string {
+		t.Helper()
+		data, err := ioutil.ReadFile(w.Filename)
+		require.NoError(t, err)
+		return string(data)
+	}
+	newWriter := func(t *testing.T, flushInterval time.Duration) *FileLogWriter {
+		t.Helper()
+		w := NewFileWriter()
+		w.Filename = filepath.Join(t.TempDir(), "grafana.log")
+		w.Format = log15.FormatFunc(func(r *log15.Record) []byte { return []byte(r.Msg + "\n") })
+		w.BufferSize = 1 << 10
+		w.FlushInterval = flushInterval
+		require.NoError(t, w.Init())
+		return w
+	}
+
+	t.Run("Lines are buffered until flushed", func(t *testing.T) {
+		w := newWriter(t, time.Hour)
+		t.Cleanup(func() { _ = w.Close() })
+
+		require.NoError(t, w.Log(&log15.Record{Msg: "first", Lvl: log15.LvlInfo}))
+		assert.Empty(t, readLog(t, w))
+
+		w.Flush()
+		assert.Equal(t, "first\n", readLog(t, w))
+	})
+
+	t.Run("Error lines are written right away", func(t *testing.T) {
+		w := newWriter(t, time.Hour)
+		t.Cleanup(func() { _ = w.Close() })
+
+		require.NoError(t, w.Log(&log15.Record{Msg: "first", Lvl: log15.LvlInfo}))
+		require.NoError(t, w.Log(&log15.Record{Msg: "failed", Lvl: log15.LvlError}))
+		assert.Equal(t, "first\nfailed\n", readLog(t, w))
+	})
+
+	t.Run("Lines are flushed periodically", func(t *testing.T) {
+		w := newWriter(t, 10*time.Millisecond)
+		t.Cleanup(func() { _ = w.Close() })
+
+		require.NoError(t, w.Log(&log15.Record{Msg: "first", Lvl: log15.LvlInfo}))
+		assert.Eventually(t, func() bool { return readLog(t, w) == "first\n" }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Lines are flushed on reload and close", func(t *testing.T) {
+		w := newWriter(t, time.Hour)
+
+		require.NoError(t, w.Log(&log15.Record{Msg: "first", Lvl: log15.LvlInfo}))
+		require.NoError(t, w.Reload())
+		assert.Equal(t, "first\n", readLog(t, w))
+
+		require.NoError(t, w.Log(&log15.Record{Msg: "second", Lvl: log15.LvlInfo}))
+		require.NoError(t, w.Close())
+		assert.Equal(t, "first\nsecond\n", readLog(t, w))
+	})
+}
@@ -0,0 +1,313 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// errFluentdAuth is returned when Fluentd rejects the shared key or credentials of the handler,
+// retrying the batch won't succeed.
+var errFluentdAuth = errors.New("fluentd authentication failed")
+
+// FluentdHandler sends log records to Fluentd or Fluent Bit in batches with the forward protocol.
+// Each batch is sent as a message in forward mode, and is acknowledged by the server when
+// RequireAck is set.
+type FluentdHandler struct {
+	BatchSettings
+	// Address is the host:port address of the forward input.
+	Address string
+	// Tag is the tag of the sent events, which Fluentd routes them by.
+	Tag string
+	// TLS enables TLS, configured by the TLSSettings.
+	TLS bool
+	TLSSettings
+	// SharedKey enables the authentication of the forward protocol. Username and Password are only
+	// sent when the server requires user authentication.
+	SharedKey string
+	Username  string
+	Password  string
+	// Hostname is the name the handler authenticates with.
+	Hostname   string
+	RequireAck bool
+
+	batcher   *recordBatcher
+	tlsConfig *tls.Config
+
+	// The connection, only used by the batcher.
+	conn net.Conn
+	dec  *msgpackDecoder
+}
+
+// NewFluentdHandler creates a FluentdHandler from the settings of the `log.fluentd` section.
+func NewFluentdHandler(sec *ini.Section) (*FluentdHandler, error) {
+	hostname, _ := os.Hostname()
+	handler := &FluentdHandler{
+		BatchSettings: readBatchSettings(sec),
+		Address:       sec.Key("address").MustString("localhost:24224"),
+		Tag:           sec.Key("tag").MustString("grafana"),
+		TLS:           sec.Key("tls").MustBool(false),
+		TLSSettings:   readTLSSettings(sec),
+		SharedKey:     sec.Key("shared_key").MustString(""),
+		Username:      sec.Key("username").MustString(""),
+		Password:      sec.Key("password").MustString(""),
+		Hostname:      sec.Key("hostname").MustString(hostname),
+		RequireAck:    sec.Key("require_ack").MustBool(true),
+	}
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init validates the settings of the handler and starts sending records.
+func (h *FluentdHandler) Init() error {
+	if h.Address == "" {
+		return errors.New("address is required to send logs to Fluentd")
+	}
+	if h.Tag == "" {
+		return errors.New("tag is required to send logs to Fluentd")
+	}
+	if err := h.BatchSettings.validate(); err != nil {
+		return err
+	}
+
+	if h.TLS {
+		tlsConfig, err := h.TLSSettings.config(h.Address)
+		if err != nil {
+			return fmt.Errorf("invalid Fluentd TLS settings: %w", err)
+		}
+		h.tlsConfig = tlsConfig
+	}
+
+	h.batcher = newRecordBatcher(h.BatchSettings, "Fluentd", h.send, isRetryableFluentdError)
+	return nil
+}
+
+// Log queues a record to be sent to Fluentd.
+func (h *FluentdHandler) Log(r *log15.Record) error {
+	return h.batcher.queue(r)
+}
+
+// Close sends the queued records, and stops the handler.
+func (h *FluentdHandler) Close() error {
+	return h.CloseContext(context.Background())
+}
+
+// CloseContext sends the queued records until ctx is done, and stops the handler. The records that
+// aren't sent by then are dropped.
+func (h *FluentdHandler) CloseContext(ctx context.Context) error {
+	err := h.batcher.close(ctx)
+	h.closeConn()
+	return err
+}
+
+func (h *FluentdHandler) send(batch []*log15.Record) error {
+	if h.conn == nil {
+		if err := h.connect(); err != nil {
+			h.closeConn()
+			return err
+		}
+	}
+
+	chunk, err := randomFluentdString(16)
+	if err != nil {
+		return err
+	}
+	if err := h.conn.SetDeadline(time.Now().Add(h.Timeout)); err != nil {
+		h.closeConn()
+		return err
+	}
+	if _, err := h.conn.Write(h.encodeForward(batch, chunk)); err != nil {
+		h.closeConn()
+		return err
+	}
+	if !h.RequireAck {
+		return nil
+	}
+
+	if err := h.readAck(chunk); err != nil {
+		h.closeConn()
+		return err
+	}
+	return nil
+}
+
+// encodeForward encodes a batch as a message in forward mode, [tag, [[time, record]...], option].
+func (h *FluentdHandler) encodeForward(batch []*log15.Record, chunk string) []byte {
+	var e msgpackEncoder
+	e.writeArrayLen(3)
+	e.writeString(h.Tag)
+	e.writeArrayLen(len(batch))
+	for _, r := range batch {
+		e.writeArrayLen(2)
+		e.writeEventTime(r.Time)
+		e.writeMapLen(2 + len(r.Ctx)/2)
+		e.writeString("level")
+		e.writeString(levelNames[r.Lvl])
+		e.writeString("msg")
+		e.writeString(r.Msg)
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			key, ok := r.Ctx[i].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[i])
+			}
+			e.writeString(key)
+			e.writeValue(r.Ctx[i+1])
+		}
+	}
+
+	if h.RequireAck {
+		e.writeMapLen(2)
+		e.writeString("size")
+		e.writeInt(int64(len(batch)))
+		e.writeString("chunk")
+		e.writeString(chunk)
+	} else {
+		e.writeMapLen(1)
+		e.writeString("size")
+		e.writeInt(int64(len(batch)))
+	}
+	return e.Bytes()
+}
+
+func (h *FluentdHandler) readAck(chunk string) error {
+	resp, err := h.dec.readValue()
+	if err != nil {
+		return err
+	}
+	m, ok := resp.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected Fluentd response %v", resp)
+	}
+	if ack := m["ack"]; ack != chunk {
+		return fmt.Errorf("fluentd acknowledged chunk %v, expected %s", ack, chunk)
+	}
+	return nil
+}
+
+func (h *FluentdHandler) connect() error {
+	dialer := &net.Dialer{Timeout: h.Timeout}
+	var err error
+	if h.tlsConfig != nil {
+		h.conn, err = tls.DialWithDialer(dialer, "tcp", h.Address, h.tlsConfig)
+	} else {
+		h.conn, err = dialer.Dial("tcp", h.Address)
+	}
+	if err != nil {
+		return err
+	}
+	h.dec = &msgpackDecoder{r: bufio.NewReader(h.conn)}
+
+	if h.SharedKey == "" {
+		return nil
+	}
+	if err := h.conn.SetDeadline(time.Now().Add(h.Timeout)); err != nil {
+		return err
+	}
+	return h.authenticate()
+}
+
+// authenticate performs the handshake of the forward protocol: the server sends a HELO with a
+// nonce, the handler answers with a PING proving it knows the shared key, and the server answers
+// with a PONG proving it knows it too.
+func (h *FluentdHandler) authenticate() error {
+	helo, err := h.readHandshakeMessage("HELO", 2)
+	if err != nil {
+		return err
+	}
+	options, ok := helo[1].(map[string]interface{})
+	if !ok {
+		return errors.New("fluentd HELO has no options")
+	}
+	nonce, _ := options["nonce"].(string)
+	authSalt, _ := options["auth"].(string)
+
+	salt, err := randomFluentdString(16)
+	if err != nil {
+		return err
+	}
+	username, passwordDigest := "", ""
+	if authSalt != "" {
+		username = h.Username
+		passwordDigest = sha512Hex(authSalt, h.Username, h.Password)
+	}
+
+	var e msgpackEncoder
+	e.writeArrayLen(6)
+	e.writeString("PING")
+	e.writeString(h.Hostname)
+	e.writeString(salt)
+	e.writeString(sha512Hex(salt, h.Hostname, nonce, h.SharedKey))
+	e.writeString(username)
+	e.writeString(passwordDigest)
+	if _, err := h.conn.Write(e.Bytes()); err != nil {
+		return err
+	}
+
+	pong, err := h.readHandshakeMessage("PONG", 5)
+	if err != nil {
+		return err
+	}
+	if result, _ := pong[1].(bool); !result {
+		return fmt.Errorf("%w: %v", errFluentdAuth, pong[2])
+	}
+	serverHostname, _ := pong[3].(string)
+	if pong[4] != sha512Hex(salt, serverHostname, nonce, h.SharedKey) {
+		return fmt.Errorf("%w: the server doesn't have the same shared key", errFluentdAuth)
+	}
+	return nil
+}
+
+func (h *FluentdHandler) readHandshakeMessage(msgType string, size int) ([]interface{}, error) {
+	v, err := h.dec.readValue()
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := v.([]interface{})
+	if !ok || len(msg) < size || msg[0] != msgType {
+		return nil, fmt.Errorf("expected Fluentd %s message, got %v", msgType, v)
+	}
+	return msg, nil
+}
+
+func (h *FluentdHandler) closeConn() {
+	if h.conn != nil {
+		_ = h.conn.Close()
+		h.conn = nil
+		h.dec = nil
+	}
+}
+
+// isRetryableFluentdError returns true for all errors but authentication failures.
+func isRetryableFluentdError(err error) bool {
+	return !errors.Is(err, errFluentdAuth)
+}
+
+func randomFluentdString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func sha512Hex(values ...string) string {
+	hash := sha512.New()
+	for _, v := range values {
+		hash.Write([]byte(v))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,272 @@
+package log
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+// fakeFluentd is a forward input that records the events sent to it, and authenticates clients
+// when it has a shared key.
+type fakeFluentd struct {
+	t         *testing.T
+	listener  net.Listener
+	sharedKey string
+
+	mu       sync.Mutex
+	tags     []string
+	events   []map[string]interface{}
+	times    []time.Time
+	hostname string
+	// skipAcks is the number of chunks that aren't acknowledged, closing the connection instead.
+	skipAcks int
+}
+
+func newFakeFluentd(t *testing.T, sharedKey string, tlsConfig *tls.Config) *fakeFluentd {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	f := &fakeFluentd{t: t, listener: listener, sharedKey: sharedKey}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go f.serve()
+	return f
+}
+
+func (f *fakeFluentd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeFluentd) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	dec := &msgpackDecoder{r: bufio.NewReader(conn)}
+	if f.sharedKey != "" && !f.authenticate(conn, dec) {
+		return
+	}
+
+	for {
+		v, err := dec.readValue()
+		if err != nil {
+			return
+		}
+		msg := v.([]interface{})
+		option := msg[2].(map[string]interface{})
+
+		f.mu.Lock()
+		if f.skipAcks > 0 {
+			f.skipAcks--
+			f.mu.Unlock()
+			return
+		}
+		for _, entry := range msg[1].([]interface{}) {
+			entry := entry.([]interface{})
+			f.tags = append(f.tags, msg[0].(string))
+			f.times = append(f.times, entry[0].(time.Time))
+			f.events = append(f.events, entry[1].(map[string]interface{}))
+		}
+		f.mu.Unlock()
+
+		if chunk, ok := option["chunk"]; ok {
+			var e msgpackEncoder
+			e.writeMapLen(1)
+			e.writeString("ack")
+			e.writeString(chunk.(string))
+			if _, err := conn.Write(e.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeFluentd) authenticate(conn net.Conn, dec *msgpackDecoder) bool {
+	var helo msgpackEncoder
+	helo.writeArrayLen(2)
+	helo.writeString("HELO")
+	helo.writeMapLen(3)
+	helo.writeString("nonce")
+	helo.writeString("nonce")
+	helo.writeString("auth")
+	helo.writeString("")
+	helo.writeString("keepalive")
+	helo.writeBool(true)
+	if _, err := conn.Write(helo.Bytes()); err != nil {
+		return false
+	}
+
+	v, err := dec.readValue()
+	if err != nil {
+		return false
+	}
+	ping := v.([]interface{})
+	require.Equal(f.t, "PING", ping[0])
+	hostname, salt := ping[1].(string), ping[2].(string)
+	ok := ping[3] == sha512Hex(salt, hostname, "nonce", f.sharedKey)
+
+	f.mu.Lock()
+	f.hostname = hostname
+	f.mu.Unlock()
+
+	var pong msgpackEncoder
+	pong.writeArrayLen(5)
+	pong.writeString("PONG")
+	pong.writeBool(ok)
+	if ok {
+		pong.writeString("")
+	} else {
+		pong.writeString("shared key mismatch")
+	}
+	pong.writeString("fluentd")
+	pong.writeString(sha512Hex(salt, "fluentd", "nonce", f.sharedKey))
+	if _, err := conn.Write(pong.Bytes()); err != nil {
+		return false
+	}
+	return ok
+}
+
+func (f *fakeFluentd) received() []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]interface{}{}, f.events...)
+}
+
+func newFluentdTestHandler(t *testing.T, config string) *FluentdHandler {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.fluentd]\n" + config))
+	require.NoError(t, err)
+	handler, err := NewFluentdHandler(cfg.Section("log.fluentd"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func fluentdTestRecord(msg string) *log15.Record {
+	return &log15.Record{
+		Time: time.Date(2021, 3, 10, 12, 0, 0, 500, time.UTC),
+		Lvl:  log15.LvlWarn,
+		Msg:  msg,
+		Ctx:  []interface{}{"logger", "sqlstore", "orgId", int64(2)},
+	}
+}
+
+func TestFluentdHandler(t *testing.T) {
+	t.Run("Batches are sent in forward mode and acknowledged", func(t *testing.T) {
+		fluentd := newFakeFluentd(t, "", nil)
+		handler := newFluentdTestHandler(t, "address = "+fluentd.listener.Addr().String()+"\ntag = app.grafana\nbatch_size = 2\nbatch_wait = 1h")
+
+		for _, msg := range []string{"first", "second", "third"} {
+			require.NoError(t, handler.Log(fluentdTestRecord(msg)))
+		}
+		require.NoError(t, handler.Close())
+
+		events := fluentd.received()
+		require.Len(t, events, 3)
+		assert.Equal(t, map[string]interface{}{
+			"level":  "warn",
+			"msg":    "first",
+			"logger": "sqlstore",
+			"orgId":  int64(2),
+		}, events[0])
+		assert.Equal(t, "third", events[2]["msg"])
+		assert.Equal(t, []string{"app.grafana", "app.grafana", "app.grafana"}, fluentd.tags)
+		assert.True(t, fluentd.times[0].Equal(fluentdTestRecord("").Time))
+	})
+
+	t.Run("Batches that aren't acknowledged are sent again", func(t *testing.T) {
+		fluentd := newFakeFluentd(t, "", nil)
+		fluentd.skipAcks = 1
+		handler := newFluentdTestHandler(t, "address = "+fluentd.listener.Addr().String()+"\nbatch_size = 1\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(fluentdTestRecord("first")))
+		require.Eventually(t, func() bool {
+			return len(fluentd.received()) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("The handler authenticates with the shared key", func(t *testing.T) {
+		fluentd := newFakeFluentd(t, "secret", nil)
+		handler := newFluentdTestHandler(t, "address = "+fluentd.listener.Addr().String()+"\nshared_key = secret\nhostname = grafana-1\nbatch_size = 1")
+
+		require.NoError(t, handler.Log(fluentdTestRecord("first")))
+		require.NoError(t, handler.Close())
+
+		assert.Len(t, fluentd.received(), 1)
+		assert.Equal(t, "grafana-1", fluentd.hostname)
+	})
+
+	t.Run("Batches aren't sent again when authentication fails", func(t *testing.T) {
+		fluentd := newFakeFluentd(t, "secret", nil)
+		handler := newFluentdTestHandler(t, "address = "+fluentd.listener.Addr().String()+"\nshared_key = wrong\nbatch_size = 1\nmin_backoff = 1h")
+
+		require.NoError(t, handler.Log(fluentdTestRecord("first")))
+		require.NoError(t, handler.Close())
+
+		assert.Empty(t, fluentd.received())
+	})
+
+	t.Run("Batches are sent over TLS", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		tlsConfig := &tls.Config{Certificates: server.TLS.Certificates}
+		server.Close()
+		fluentd := newFakeFluentd(t, "", tlsConfig)
+		handler := newFluentdTestHandler(t, "address = "+fluentd.listener.Addr().String()+"\ntls = true\ntls_skip_verify = true\nbatch_size = 1")
+
+		require.NoError(t, handler.Log(fluentdTestRecord("first")))
+		require.NoError(t, handler.Close())
+
+		assert.Len(t, fluentd.received(), 1)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		for config, expected := range map[string]string{
+			"batch_size = 0":                     "batch_size must be greater than 0",
+			"address = localhost\ntls = true":    `invalid Fluentd TLS settings: invalid address "localhost": address localhost: missing port in address`,
+			"tls = true\ntls_ca_cert = /missing": "invalid Fluentd TLS settings: failed to read CA certificate: open /missing: no such file or directory",
+		} {
+			cfg, err := ini.Load([]byte("[log.fluentd]\n" + config))
+			require.NoError(t, err)
+			_, err = NewFluentdHandler(cfg.Section("log.fluentd"))
+			require.EqualError(t, err, expected)
+		}
+	})
+}
+
+func TestMsgpack(t *testing.T) {
+	var e msgpackEncoder
+	values := []interface{}{
+		int64(-40000), int64(-1), int64(200), int64(1 << 40), "",
+		string(make([]byte, 300)), 1.5, true, nil,
+	}
+	e.writeArrayLen(len(values))
+	for _, v := range values {
+		e.writeValue(v)
+	}
+
+	dec := &msgpackDecoder{r: &e}
+	decoded, err := dec.readValue()
+	require.NoError(t, err)
+	assert.Equal(t, values, decoded)
+}
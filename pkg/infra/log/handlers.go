@@ -1,9 +1,23 @@
 package log
 
+import "context"
+
 type DisposableHandler interface {
 	Close() error
 }
 
+// ContextDisposableHandler is a handler that writes buffered records when it's closed, like the
+// handlers of network services, which drops the records that aren't written once ctx is done.
+type ContextDisposableHandler interface {
+	CloseContext(ctx context.Context) error
+}
+
 type ReloadableHandler interface {
 	Reload() error
 }
+
+// FlushableHandler is a handler that buffers writes, like the file handler, which an AsyncHandler
+// flushes periodically.
+type FlushableHandler interface {
+	Flush()
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"sync"
+
+	"github.com/inconshreveable/log15"
+)
+
+// Hook is called with the key values of every record, and returns the key values the record is
+// logged with. Hooks may add, change or remove key values, or return them unchanged to only
+// observe records.
+type Hook func(keyvals []interface{}) []interface{}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []Hook
+)
+
+// RegisterHook registers a hook that's called for the records of all loggers, in the order hooks
+// are registered, before values are redacted and records are passed on to the log modes.
+func RegisterHook(hook Hook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, hook)
+}
+
+// hasHooks reports whether any hooks are registered.
+func hasHooks() bool {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return len(hooks) > 0
+}
+
+// hooksHandler calls the registered hooks with the key values of records before passing them on
+// to h.
+func hooksHandler(h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		hooksMu.RLock()
+		registered := hooks
+		hooksMu.RUnlock()
+		if len(registered) == 0 {
+			return h.Log(r)
+		}
+
+		hooked := *r
+		// Hooks get a copy, so changing key values doesn't change the context of the logger.
+		hooked.Ctx = append(make([]interface{}, 0, len(r.Ctx)), r.Ctx...)
+		for _, hook := range registered {
+			hooked.Ctx = hook(hooked.Ctx)
+		}
+		return h.Log(&hooked)
+	})
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	t.Cleanup(func() {
+		hooksMu.Lock()
+		hooks = nil
+		hooksMu.Unlock()
+	})
+
+	var observed [][]interface{}
+	RegisterHook(func(keyvals []interface{}) []interface{} {
+		for i := 0; i < len(keyvals)-1; i += 2 {
+			if keyvals[i] == "email" {
+				keyvals[i+1] = "[scrubbed]"
+			}
+		}
+		return append(keyvals, "traceID", "abc")
+	})
+	RegisterHook(func(keyvals []interface{}) []interface{} {
+		observed = append(observed, keyvals)
+		return keyvals
+	})
+
+	var logged []*log15.Record
+	handler := hooksHandler(log15.FuncHandler(func(r *log15.Record) error {
+		logged = append(logged, r)
+		return nil
+	}))
+	record := &log15.Record{Msg: "User created", Ctx: []interface{}{"logger", "users", "email", "admin@example.com"}}
+	require.NoError(t, handler.Log(record))
+
+	expected := []interface{}{"logger", "users", "email", "[scrubbed]", "traceID", "abc"}
+	require.Len(t, logged, 1)
+	assert.Equal(t, expected, logged[0].Ctx)
+	assert.Equal(t, [][]interface{}{expected}, observed)
+	assert.Equal(t, []interface{}{"logger", "users", "email", "admin@example.com"}, record.Ctx)
+}
@@ -0,0 +1,156 @@
+//+build linux
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"unicode"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// JournaldHandler writes log records to the systemd journal with its native protocol. The message,
+// level and key values of records are written as fields of the journal entries, so the logger and
+// orgId of a record are the LOGGER and ORG_ID fields.
+type JournaldHandler struct {
+	Identifier string
+	Socket     string
+
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// NewJournaldHandler creates a JournaldHandler from the settings of the `log.journald` section.
+func NewJournaldHandler(sec *ini.Section) (*JournaldHandler, error) {
+	handler := &JournaldHandler{
+		Identifier: sec.Key("identifier").MustString("grafana"),
+		Socket:     sec.Key("socket").MustString("/run/systemd/journal/socket"),
+	}
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init checks the journal socket exists, and opens the connection records are written to.
+func (h *JournaldHandler) Init() error {
+	if _, err := os.Stat(h.Socket); err != nil {
+		return fmt.Errorf("journald socket %q not found: %w", h.Socket, err)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "", Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	h.conn = conn
+	h.addr = &net.UnixAddr{Name: h.Socket, Net: "unixgram"}
+	return nil
+}
+
+func (h *JournaldHandler) Log(r *log15.Record) error {
+	entry := h.encodeRecord(r)
+	_, _, err := h.conn.WriteMsgUnix(entry, nil, h.addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	// Entries that don't fit in a datagram are passed to journald as a file descriptor.
+	return h.writeLargeEntry(entry)
+}
+
+func (h *JournaldHandler) Close() error {
+	return h.conn.Close()
+}
+
+func (h *JournaldHandler) writeLargeEntry(entry []byte) error {
+	file, err := ioutil.TempFile("/dev/shm", "grafana-journal-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	if err := os.Remove(file.Name()); err != nil {
+		return err
+	}
+	if _, err := file.Write(entry); err != nil {
+		return err
+	}
+
+	_, _, err = h.conn.WriteMsgUnix([]byte{}, syscall.UnixRights(int(file.Fd())), h.addr)
+	return err
+}
+
+// encodeRecord encodes a record as a journal entry.
+func (h *JournaldHandler) encodeRecord(r *log15.Record) []byte {
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", r.Msg)
+	writeJournaldField(&buf, "PRIORITY", fmt.Sprint(syslogSeverities[r.Lvl]))
+	writeJournaldField(&buf, "SYSLOG_IDENTIFIER", h.Identifier)
+	for i := 0; i < len(r.Ctx)-1; i += 2 {
+		key, ok := r.Ctx[i].(string)
+		if !ok {
+			key = fmt.Sprint(r.Ctx[i])
+		}
+		writeJournaldField(&buf, journaldFieldName(key), syslogValue(r.Ctx[i+1]))
+	}
+	return buf.Bytes()
+}
+
+// writeJournaldField writes a field of an entry. Values with new lines are written with their
+// length, as the native protocol requires.
+func writeJournaldField(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldFieldName converts a key to a journal field name, which may only have upper case
+// letters, digits and underscores, and may not start with an underscore. Camel case keys are
+// separated by underscores, so orgId is ORG_ID.
+func journaldFieldName(key string) string {
+	var name strings.Builder
+	var prev rune
+	for _, c := range key {
+		switch {
+		case c >= unicode.MaxASCII || !(unicode.IsLetter(c) || unicode.IsDigit(c)):
+			name.WriteByte('_')
+		case unicode.IsUpper(c) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			name.WriteByte('_')
+			name.WriteRune(c)
+		default:
+			name.WriteRune(unicode.ToUpper(c))
+		}
+		prev = c
+	}
+
+	field := strings.TrimLeft(name.String(), "_")
+	if field == "" || unicode.IsDigit(rune(field[0])) {
+		field = "FIELD_" + field
+	}
+	if len(field) > 64 {
+		field = field[:64]
+	}
+	return field
+}
@@ -0,0 +1,26 @@
+//+build !linux
+
+package log
+
+import (
+	"errors"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// JournaldHandler writes log records to the systemd journal, which is only available on Linux.
+type JournaldHandler struct {
+}
+
+func NewJournaldHandler(sec *ini.Section) (*JournaldHandler, error) {
+	return nil, errors.New("journald is only supported on Linux")
+}
+
+func (h *JournaldHandler) Log(r *log15.Record) error {
+	return nil
+}
+
+func (h *JournaldHandler) Close() error {
+	return nil
+}
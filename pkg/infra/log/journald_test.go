@@ -0,0 +1,131 @@
+//go:build linux
+// +build linux
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+// readJournaldEntry reads an entry sent to the fake journal socket, either in a datagram or in the
+// file of a file descriptor.
+func readJournaldEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, 1024)
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.NoError(t, err)
+	entry := buf[:n]
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		require.NoError(t, err)
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		require.NoError(t, err)
+		file := os.NewFile(uintptr(fds[0]), "entry")
+		defer func() {
+			_ = file.Close()
+		}()
+		_, err = file.Seek(0, 0)
+		require.NoError(t, err)
+		var content bytes.Buffer
+		_, err = content.ReadFrom(file)
+		require.NoError(t, err)
+		entry = content.Bytes()
+	}
+
+	fields := map[string]string{}
+	for len(entry) > 0 {
+		end := bytes.IndexByte(entry, '\n')
+		require.NotEqual(t, -1, end)
+		line := string(entry[:end])
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			fields[line[:i]] = line[i+1:]
+			entry = entry[end+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(entry[end+1 : end+9])
+		fields[line] = string(entry[end+9 : end+9+int(size)])
+		entry = entry[end+9+int(size)+1:]
+	}
+	return fields
+}
+
+func TestJournaldHandler(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	cfg, err := ini.Load([]byte("[log.journald]\nsocket = " + socket))
+	require.NoError(t, err)
+	handler, err := NewJournaldHandler(cfg.Section("log.journald"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+
+	t.Run("Records are written with their key values as fields", func(t *testing.T) {
+		require.NoError(t, handler.Log(&log15.Record{
+			Lvl: log15.LvlWarn,
+			Msg: "Request failed",
+			Ctx: []interface{}{"logger", "context", "orgId", 1, "err", errors.New("first\nsecond"), "_hidden", true},
+		}))
+
+		assert.Equal(t, map[string]string{
+			"MESSAGE":           "Request failed",
+			"PRIORITY":          "4",
+			"SYSLOG_IDENTIFIER": "grafana",
+			"LOGGER":            "context",
+			"ORG_ID":            "1",
+			"ERR":               "first\nsecond",
+			"HIDDEN":            "true",
+		}, readJournaldEntry(t, conn))
+	})
+
+	t.Run("Records too large for a datagram are written as a file", func(t *testing.T) {
+		msg := strings.Repeat("a", 1<<20)
+		require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlInfo, Msg: msg}))
+
+		fields := readJournaldEntry(t, conn)
+		assert.Equal(t, msg, fields["MESSAGE"])
+		assert.Equal(t, "6", fields["PRIORITY"])
+	})
+
+	t.Run("The socket must exist", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.journald]\nsocket = " + filepath.Join(t.TempDir(), "missing.socket")))
+		require.NoError(t, err)
+		_, err = NewJournaldHandler(cfg.Section("log.journald"))
+		require.Error(t, err)
+	})
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	for key, field := range map[string]string{
+		"logger":        "LOGGER",
+		"orgId":         "ORG_ID",
+		"userId":        "USER_ID",
+		"dashboard-uid": "DASHBOARD_UID",
+		"HTTPStatus":    "HTTPSTATUS",
+		"_private":      "PRIVATE",
+		"2fa":           "FIELD_2FA",
+		"ünicode":       "NICODE",
+	} {
+		assert.Equal(t, field, journaldFieldName(key), key)
+	}
+}
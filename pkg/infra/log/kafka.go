@@ -0,0 +1,206 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// KafkaHandler produces log records to a Kafka topic in batches. Each batch is produced to the next
+// partition of the topic, and failed batches are retried with the metadata of the topic fetched
+// again, in case the leader of the partition changed.
+type KafkaHandler struct {
+	BatchSettings
+	// Brokers are the host:port addresses of the brokers the metadata of the topic is fetched from.
+	Brokers  []string
+	Topic    string
+	ClientID string
+	// Compression is the codec of the produced record batches, either "none" or "gzip".
+	Compression string
+	// RequiredAcks is the number of acknowledgements the leader waits for, -1 waits for all in
+	// sync replicas, 0 doesn't wait for a response.
+	RequiredAcks int16
+	Format       log15.Format
+
+	batcher *recordBatcher
+
+	// The state of the producer, only used by the batcher.
+	codec     int16
+	metadata  *kafkaMetadata
+	conns     map[int32]*kafkaConn
+	partition int
+}
+
+// NewKafkaHandler creates a KafkaHandler from the settings of the `log.kafka` section.
+func NewKafkaHandler(sec *ini.Section, format log15.Format) (*KafkaHandler, error) {
+	handler := &KafkaHandler{
+		BatchSettings: readBatchSettings(sec),
+		Brokers:       util.SplitString(sec.Key("brokers").MustString("")),
+		Topic:         sec.Key("topic").MustString("grafana"),
+		ClientID:      sec.Key("client_id").MustString("grafana"),
+		Compression:   strings.ToLower(sec.Key("compression").MustString("gzip")),
+		RequiredAcks:  int16(sec.Key("required_acks").MustInt(-1)),
+		Format:        format,
+	}
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init validates the settings of the handler and starts producing records.
+func (h *KafkaHandler) Init() error {
+	if len(h.Brokers) == 0 {
+		return errors.New("brokers are required to produce logs to Kafka")
+	}
+	if h.Topic == "" {
+		return errors.New("topic is required to produce logs to Kafka")
+	}
+	codec, ok := kafkaCompressionCodecs[h.Compression]
+	if !ok {
+		return fmt.Errorf("unknown Kafka compression %q, valid options are none and gzip", h.Compression)
+	}
+	if h.RequiredAcks < -1 || h.RequiredAcks > 1 {
+		return fmt.Errorf("invalid required_acks %d, valid options are -1, 0 and 1", h.RequiredAcks)
+	}
+	if err := h.BatchSettings.validate(); err != nil {
+		return err
+	}
+	if h.Format == nil {
+		h.Format = log15.JsonFormat()
+	}
+
+	h.codec = codec
+	h.conns = map[int32]*kafkaConn{}
+	h.batcher = newRecordBatcher(h.BatchSettings, "Kafka", h.produce, isRetryableKafkaError)
+	return nil
+}
+
+// Log queues a record to be produced to Kafka.
+func (h *KafkaHandler) Log(r *log15.Record) error {
+	return h.batcher.queue(r)
+}
+
+// Close produces the queued records, and stops the handler.
+func (h *KafkaHandler) Close() error {
+	return h.CloseContext(context.Background())
+}
+
+// CloseContext produces the queued records until ctx is done, and stops the handler. The records that
+// aren't produced by then are dropped.
+func (h *KafkaHandler) CloseContext(ctx context.Context) error {
+	err := h.batcher.close(ctx)
+	h.closeConns()
+	return err
+}
+
+func (h *KafkaHandler) produce(batch []*log15.Record) error {
+	records := make([]kafkaRecord, len(batch))
+	for i, r := range batch {
+		value := h.Format.Format(r)
+		if n := len(value); n > 0 && value[n-1] == '\n' {
+			value = value[:n-1]
+		}
+		records[i] = kafkaRecord{timestamp: r.Time, value: value}
+	}
+	recordBatch, err := encodeKafkaRecordBatch(records, h.codec)
+	if err != nil {
+		return err
+	}
+
+	if h.metadata == nil {
+		md, err := h.fetchMetadata()
+		if err != nil {
+			return err
+		}
+		h.metadata = &md
+	}
+
+	partition := h.partition % len(h.metadata.leaders)
+	h.partition++
+	leader := h.metadata.leaders[partition]
+	conn, err := h.conn(leader)
+	if err == nil {
+		req := encodeKafkaProduceRequest(h.Topic, int32(partition), h.RequiredAcks, h.Timeout, recordBatch)
+		var resp []byte
+		resp, err = conn.request(kafkaProduceKey, kafkaProduceVersion, req, h.RequiredAcks != 0)
+		if err == nil && resp != nil {
+			err = decodeKafkaProduceResponse(resp)
+		}
+	}
+	if err != nil {
+		// The leaders may have changed, so the metadata is fetched again before retrying.
+		h.metadata = nil
+		var kafkaErr kafkaError
+		if !errors.As(err, &kafkaErr) {
+			h.closeConn(leader)
+		}
+		return err
+	}
+	return nil
+}
+
+// fetchMetadata fetches the metadata of the topic from the first broker that returns it.
+func (h *KafkaHandler) fetchMetadata() (kafkaMetadata, error) {
+	var lastErr error
+	for _, addr := range h.Brokers {
+		conn, err := dialKafka(addr, h.ClientID, h.Timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := conn.request(kafkaMetadataKey, kafkaMetadataVersion, encodeKafkaMetadataRequest(h.Topic), true)
+		_ = conn.close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return decodeKafkaMetadataResponse(resp, h.Topic)
+	}
+	return kafkaMetadata{}, fmt.Errorf("failed to fetch metadata of Kafka topic %q: %w", h.Topic, lastErr)
+}
+
+// conn returns the connection to a broker, and connects to it if it isn't connected yet.
+func (h *KafkaHandler) conn(nodeID int32) (*kafkaConn, error) {
+	if conn, ok := h.conns[nodeID]; ok {
+		return conn, nil
+	}
+	addr, ok := h.metadata.brokers[nodeID]
+	if !ok {
+		return nil, kafkaError(8)
+	}
+	conn, err := dialKafka(addr, h.ClientID, h.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	h.conns[nodeID] = conn
+	return conn, nil
+}
+
+func (h *KafkaHandler) closeConn(nodeID int32) {
+	if conn, ok := h.conns[nodeID]; ok {
+		_ = conn.close()
+		delete(h.conns, nodeID)
+	}
+}
+
+func (h *KafkaHandler) closeConns() {
+	for nodeID := range h.conns {
+		h.closeConn(nodeID)
+	}
+}
+
+// isRetryableKafkaError returns true for network errors, and the errors of Kafka that may be gone
+// when the batch is produced again.
+func isRetryableKafkaError(err error) bool {
+	var kafkaErr kafkaError
+	if errors.As(err, &kafkaErr) {
+		return kafkaErr.retriable()
+	}
+	return true
+}
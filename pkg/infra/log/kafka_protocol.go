@@ -0,0 +1,391 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"time"
+)
+
+// The Kafka protocol requests the Kafka handler sends, with the versions it sends them with.
+const (
+	kafkaProduceKey      int16 = 0
+	kafkaProduceVersion  int16 = 3
+	kafkaMetadataKey     int16 = 3
+	kafkaMetadataVersion int16 = 1
+)
+
+// kafkaCompressionCodecs are the compression codecs of record batches the Kafka handler supports.
+var kafkaCompressionCodecs = map[string]int16{
+	"none": 0,
+	"gzip": 1,
+}
+
+var kafkaCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaError is an error code of a Kafka response.
+type kafkaError int16
+
+// kafkaRetriableErrors are the error codes of errors that may be gone when the request is retried.
+var kafkaRetriableErrors = map[kafkaError]string{
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_FOR_PARTITION",
+	7:  "REQUEST_TIMED_OUT",
+	8:  "BROKER_NOT_AVAILABLE",
+	13: "NETWORK_EXCEPTION",
+	14: "COORDINATOR_LOAD_IN_PROGRESS",
+	19: "NOT_ENOUGH_REPLICAS",
+	20: "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+	56: "KAFKA_STORAGE_ERROR",
+}
+
+func (e kafkaError) Error() string {
+	if name, ok := kafkaRetriableErrors[e]; ok {
+		return fmt.Sprintf("kafka error %d (%s)", int16(e), name)
+	}
+	return fmt.Sprintf("kafka error %d", int16(e))
+}
+
+func (e kafkaError) retriable() bool {
+	_, ok := kafkaRetriableErrors[e]
+	return ok
+}
+
+// kafkaEncoder encodes the fields of Kafka requests.
+type kafkaEncoder struct {
+	bytes.Buffer
+}
+
+func (e *kafkaEncoder) int8(v int8) {
+	e.WriteByte(byte(v))
+}
+
+func (e *kafkaEncoder) int16(v int16) {
+	_ = binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *kafkaEncoder) int32(v int32) {
+	_ = binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *kafkaEncoder) int64(v int64) {
+	_ = binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *kafkaEncoder) varint(v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	e.Write(buf[:binary.PutVarint(buf[:], v)])
+}
+
+func (e *kafkaEncoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.WriteString(v)
+}
+
+func (e *kafkaEncoder) bytes(v []byte) {
+	e.int32(int32(len(v)))
+	e.Write(v)
+}
+
+// kafkaDecoder decodes the fields of Kafka responses. The first error is kept, and the fields read
+// after it are zero.
+type kafkaDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = errors.New("kafka response is too short")
+		return make([]byte, n)
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *kafkaDecoder) bool() bool {
+	return d.next(1)[0] != 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	return int16(binary.BigEndian.Uint16(d.next(2)))
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	return int32(binary.BigEndian.Uint32(d.next(4)))
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	return int64(binary.BigEndian.Uint64(d.next(8)))
+}
+
+func (d *kafkaDecoder) string() string {
+	n := int(d.int16())
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(n))
+}
+
+func (d *kafkaDecoder) arrayLen() int {
+	n := int(d.int32())
+	if n < 0 {
+		return 0
+	}
+	if d.err == nil && n > len(d.buf) {
+		d.err = errors.New("kafka response has an invalid array length")
+		return 0
+	}
+	return n
+}
+
+// kafkaConn is a connection to a Kafka broker.
+type kafkaConn struct {
+	conn          net.Conn
+	clientID      string
+	timeout       time.Duration
+	correlationID int32
+}
+
+func dialKafka(addr string, clientID string, timeout time.Duration) (*kafkaConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaConn{conn: conn, clientID: clientID, timeout: timeout}, nil
+}
+
+// request sends a request, and returns the body of its response. Requests that don't expect a
+// response, like produce requests without acks, return a nil body.
+func (c *kafkaConn) request(key int16, version int16, body []byte, expectResponse bool) ([]byte, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	c.correlationID++
+	var req kafkaEncoder
+	req.int32(0) // the size is set below
+	req.int16(key)
+	req.int16(version)
+	req.int32(c.correlationID)
+	req.string(c.clientID)
+	req.Write(body)
+	msg := req.Bytes()
+	binary.BigEndian.PutUint32(msg, uint32(len(msg)-4))
+	if _, err := c.conn.Write(msg); err != nil {
+		return nil, err
+	}
+	if !expectResponse {
+		return nil, nil
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		return nil, err
+	}
+	size := int32(binary.BigEndian.Uint32(header[:4]))
+	if correlationID := int32(binary.BigEndian.Uint32(header[4:])); correlationID != c.correlationID {
+		return nil, fmt.Errorf("kafka response has correlation ID %d, expected %d", correlationID, c.correlationID)
+	}
+	if size < 4 {
+		return nil, fmt.Errorf("kafka response has an invalid size %d", size)
+	}
+	resp := make([]byte, size-4)
+	if _, err := io.ReadFull(c.conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *kafkaConn) close() error {
+	return c.conn.Close()
+}
+
+// kafkaMetadata is the metadata of a topic, the addresses of the brokers and the broker that's the
+// leader of each partition.
+type kafkaMetadata struct {
+	brokers map[int32]string
+	leaders []int32
+}
+
+func encodeKafkaMetadataRequest(topic string) []byte {
+	var e kafkaEncoder
+	e.int32(1)
+	e.string(topic)
+	return e.Bytes()
+}
+
+func decodeKafkaMetadataResponse(resp []byte, topic string) (kafkaMetadata, error) {
+	d := &kafkaDecoder{buf: resp}
+	md := kafkaMetadata{brokers: map[int32]string{}}
+
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		nodeID := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		md.brokers[nodeID] = net.JoinHostPort(host, fmt.Sprint(port))
+	}
+	d.int32() // controller ID
+
+	var topicErr kafkaError
+	found := false
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		errCode := kafkaError(d.int16())
+		name := d.string()
+		d.bool() // is internal
+		partitions := map[int32]int32{}
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			d.int16() // error code
+			index := d.int32()
+			leader := d.int32()
+			for k, l := 0, d.arrayLen(); k < l; k++ {
+				d.int32() // replica
+			}
+			for k, l := 0, d.arrayLen(); k < l; k++ {
+				d.int32() // in sync replica
+			}
+			partitions[index] = leader
+		}
+		if name != topic {
+			continue
+		}
+		found, topicErr = true, errCode
+		md.leaders = make([]int32, len(partitions))
+		for index, leader := range partitions {
+			if index < 0 || int(index) >= len(partitions) {
+				return kafkaMetadata{}, fmt.Errorf("kafka topic %q has an unexpected partition %d", topic, index)
+			}
+			md.leaders[index] = leader
+		}
+	}
+
+	if d.err != nil {
+		return kafkaMetadata{}, d.err
+	}
+	if !found {
+		return kafkaMetadata{}, fmt.Errorf("kafka topic %q not found", topic)
+	}
+	if topicErr != 0 {
+		return kafkaMetadata{}, topicErr
+	}
+	if len(md.leaders) == 0 {
+		return kafkaMetadata{}, kafkaError(5)
+	}
+	return md, nil
+}
+
+// kafkaRecord is a record of a record batch.
+type kafkaRecord struct {
+	timestamp time.Time
+	value     []byte
+}
+
+// encodeKafkaRecordBatch encodes records as a record batch of the version 2 message format.
+func encodeKafkaRecordBatch(records []kafkaRecord, codec int16) ([]byte, error) {
+	if len(records) == 0 {
+		return nil, errors.New("a record batch must have records")
+	}
+
+	first := records[0].timestamp.UnixNano() / int64(time.Millisecond)
+	maxTimestamp := first
+	var encoded kafkaEncoder
+	for i, r := range records {
+		timestamp := r.timestamp.UnixNano() / int64(time.Millisecond)
+		if timestamp > maxTimestamp {
+			maxTimestamp = timestamp
+		}
+
+		var record kafkaEncoder
+		record.int8(0) // attributes
+		record.varint(timestamp - first)
+		record.varint(int64(i))
+		record.varint(-1) // null key
+		record.varint(int64(len(r.value)))
+		record.Write(r.value)
+		record.varint(0) // headers
+
+		encoded.varint(int64(record.Len()))
+		encoded.Write(record.Bytes())
+	}
+
+	payload := encoded.Bytes()
+	if codec == kafkaCompressionCodecs["gzip"] {
+		var compressed bytes.Buffer
+		w := gzip.NewWriter(&compressed)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		payload = compressed.Bytes()
+	}
+
+	// The fields after the CRC, which is the CRC-32C of them.
+	var body kafkaEncoder
+	body.int16(codec)
+	body.int32(int32(len(records) - 1))
+	body.int64(first)
+	body.int64(maxTimestamp)
+	body.int64(-1) // producer ID
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(records)))
+	body.Write(payload)
+
+	var batch kafkaEncoder
+	batch.int64(0) // base offset
+	batch.int32(int32(4 + 1 + 4 + body.Len()))
+	batch.int32(-1) // partition leader epoch
+	batch.int8(2)   // magic
+	batch.int32(int32(crc32.Checksum(body.Bytes(), kafkaCRCTable)))
+	batch.Write(body.Bytes())
+	return batch.Bytes(), nil
+}
+
+func encodeKafkaProduceRequest(topic string, partition int32, acks int16, timeout time.Duration, batch []byte) []byte {
+	var e kafkaEncoder
+	e.int16(-1) // null transactional ID
+	e.int16(acks)
+	e.int32(int32(timeout / time.Millisecond))
+	e.int32(1)
+	e.string(topic)
+	e.int32(1)
+	e.int32(partition)
+	e.bytes(batch)
+	return e.Bytes()
+}
+
+func decodeKafkaProduceResponse(resp []byte) error {
+	d := &kafkaDecoder{buf: resp}
+	var errCode kafkaError
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string() // topic
+		for j, m := 0, d.arrayLen(); j < m; j++ {
+			d.int32() // partition
+			if code := kafkaError(d.int16()); code != 0 {
+				errCode = code
+			}
+			d.int64() // base offset
+			d.int64() // log append time
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if errCode != 0 {
+		return errCode
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+// fakeKafka is a broker that's the leader of all partitions of its topics, and records the values
+// of the records produced to it.
+type fakeKafka struct {
+	t          *testing.T
+	listener   net.Listener
+	partitions int
+
+	mu               sync.Mutex
+	metadataRequests int
+	produced         map[int32][]string
+	codecs           []int16
+	errs             []int16
+}
+
+func newFakeKafka(t *testing.T, partitions int) *fakeKafka {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakeKafka{t: t, listener: listener, partitions: partitions, produced: map[int32][]string{}}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go f.serve()
+	return f
+}
+
+func (f *fakeKafka) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeKafka) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	for {
+		var size int32
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		d := &kafkaDecoder{buf: msg}
+		key := d.int16()
+		d.int16() // version
+		correlationID := d.int32()
+		d.string() // client ID
+
+		var resp kafkaEncoder
+		resp.int32(correlationID)
+		switch key {
+		case kafkaMetadataKey:
+			f.metadata(d, &resp)
+		case kafkaProduceKey:
+			if !f.produce(d, &resp) {
+				continue
+			}
+		}
+
+		out := resp.Bytes()
+		if err := binary.Write(conn, binary.BigEndian, int32(len(out))); err != nil {
+			return
+		}
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeKafka) metadata(d *kafkaDecoder, resp *kafkaEncoder) {
+	d.arrayLen()
+	topic := d.string()
+
+	f.mu.Lock()
+	f.metadataRequests++
+	f.mu.Unlock()
+
+	host, port, err := net.SplitHostPort(f.listener.Addr().String())
+	require.NoError(f.t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(f.t, err)
+
+	resp.int32(1)
+	resp.int32(1)
+	resp.string(host)
+	resp.int32(int32(portNum))
+	resp.int16(-1) // rack
+	resp.int32(1)  // controller
+	resp.int32(1)
+	resp.int16(0)
+	resp.string(topic)
+	resp.int8(0)
+	resp.int32(int32(f.partitions))
+	for i := 0; i < f.partitions; i++ {
+		resp.int16(0)
+		resp.int32(int32(i))
+		resp.int32(1) // leader
+		resp.int32(1)
+		resp.int32(1)
+		resp.int32(1)
+		resp.int32(1)
+	}
+}
+
+// produce decodes a produce request, and returns whether a response is expected.
+func (f *fakeKafka) produce(d *kafkaDecoder, resp *kafkaEncoder) bool {
+	d.int16() // transactional ID
+	acks := d.int16()
+	d.int32() // timeout
+	d.arrayLen()
+	topic := d.string()
+	d.arrayLen()
+	partition := d.int32()
+	batch := d.next(int(d.int32()))
+	require.NoError(f.t, d.err)
+
+	codec, values := decodeTestRecordBatch(f.t, batch)
+
+	f.mu.Lock()
+	var errCode int16
+	if len(f.errs) > 0 {
+		errCode, f.errs = f.errs[0], f.errs[1:]
+	} else {
+		f.produced[partition] = append(f.produced[partition], values...)
+		f.codecs = append(f.codecs, codec)
+	}
+	f.mu.Unlock()
+
+	if acks == 0 {
+		return false
+	}
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(1)
+	resp.int32(partition)
+	resp.int16(errCode)
+	resp.int64(0)
+	resp.int64(-1)
+	resp.int32(0) // throttle time
+	return true
+}
+
+func (f *fakeKafka) values() map[int32][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := map[int32][]string{}
+	for partition, v := range f.produced {
+		values[partition] = append([]string{}, v...)
+	}
+	return values
+}
+
+func decodeTestRecordBatch(t *testing.T, batch []byte) (int16, []string) {
+	d := &kafkaDecoder{buf: batch}
+	d.int64() // base offset
+	length := d.int32()
+	require.Equal(t, int(length), len(d.buf))
+	d.int32() // partition leader epoch
+	require.Equal(t, byte(2), d.next(1)[0])
+	crc := uint32(d.int32())
+	require.Equal(t, crc32.Checksum(d.buf, kafkaCRCTable), crc)
+	codec := d.int16()
+	d.next(4 + 8 + 8 + 8 + 2 + 4)
+	count := int(d.int32())
+	payload := d.buf
+	require.NoError(t, d.err)
+
+	if codec == kafkaCompressionCodecs["gzip"] {
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		require.NoError(t, err)
+		payload, err = ioutil.ReadAll(r)
+		require.NoError(t, err)
+	}
+
+	varint := func() int64 {
+		v, n := binary.Varint(payload)
+		require.Greater(t, n, 0)
+		payload = payload[n:]
+		return v
+	}
+	values := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		varint()              // length
+		payload = payload[1:] // attributes
+		varint()              // timestamp delta
+		require.Equal(t, int64(i), varint())
+		require.Equal(t, int64(-1), varint())
+		n := varint()
+		values = append(values, string(payload[:n]))
+		payload = payload[n:]
+		require.Equal(t, int64(0), varint())
+	}
+	require.Empty(t, payload)
+	return codec, values
+}
+
+func newKafkaTestHandler(t *testing.T, config string) *KafkaHandler {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.kafka]\n" + config))
+	require.NoError(t, err)
+	handler, err := NewKafkaHandler(cfg.Section("log.kafka"), log15.JsonFormat())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func kafkaTestRecord(msg string) *log15.Record {
+	return &log15.Record{
+		Time: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC),
+		Lvl:  log15.LvlInfo,
+		Msg:  msg,
+		Ctx:  []interface{}{"logger", "sqlstore"},
+		KeyNames: log15.RecordKeyNames{
+			Time: "t",
+			Msg:  "msg",
+			Lvl:  "lvl",
+		},
+	}
+}
+
+func TestKafkaHandler(t *testing.T) {
+	t.Run("Batches are produced as JSON to the partitions of the topic in turn", func(t *testing.T) {
+		kafka := newFakeKafka(t, 2)
+		handler := newKafkaTestHandler(t, "brokers = 127.0.0.1:1, "+kafka.listener.Addr().String()+"\ntopic = logs\nbatch_size = 2\nbatch_wait = 1h")
+
+		for _, msg := range []string{"first", "second", "third"} {
+			require.NoError(t, handler.Log(kafkaTestRecord(msg)))
+		}
+		require.NoError(t, handler.Close())
+
+		values := kafka.values()
+		require.Len(t, values[0], 2)
+		require.Len(t, values[1], 1)
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(values[0][0]), &record))
+		assert.Equal(t, "first", record["msg"])
+		assert.Equal(t, "sqlstore", record["logger"])
+		assert.Contains(t, values[1][0], `"msg":"third"`)
+		assert.Equal(t, []int16{1, 1}, kafka.codecs)
+	})
+
+	t.Run("Batches are produced again with fresh metadata on retriable errors", func(t *testing.T) {
+		kafka := newFakeKafka(t, 1)
+		kafka.errs = []int16{6}
+		handler := newKafkaTestHandler(t, "brokers = "+kafka.listener.Addr().String()+"\ncompression = none\nbatch_size = 1\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(kafkaTestRecord("first")))
+		require.Eventually(t, func() bool {
+			return len(kafka.values()[0]) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, handler.Close())
+
+		assert.Equal(t, []int16{0}, kafka.codecs)
+		assert.Equal(t, 2, kafka.metadataRequests)
+	})
+
+	t.Run("Batches aren't produced again on other errors", func(t *testing.T) {
+		kafka := newFakeKafka(t, 1)
+		kafka.errs = []int16{10}
+		handler := newKafkaTestHandler(t, "brokers = "+kafka.listener.Addr().String()+"\nbatch_size = 1\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(kafkaTestRecord("first")))
+		require.NoError(t, handler.Close())
+
+		assert.Empty(t, kafka.values())
+		assert.Equal(t, 1, kafka.metadataRequests)
+	})
+
+	t.Run("Batches are produced without waiting for a response without acks", func(t *testing.T) {
+		kafka := newFakeKafka(t, 1)
+		handler := newKafkaTestHandler(t, "brokers = "+kafka.listener.Addr().String()+"\nrequired_acks = 0\nbatch_size = 1")
+
+		require.NoError(t, handler.Log(kafkaTestRecord("first")))
+		require.NoError(t, handler.Log(kafkaTestRecord("second")))
+		require.Eventually(t, func() bool {
+			return len(kafka.values()[0]) == 2
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		for config, expected := range map[string]string{
+			"": "brokers are required to produce logs to Kafka",
+			"brokers = localhost:9092\ncompression = zstd": `unknown Kafka compression "zstd", valid options are none and gzip`,
+			"brokers = localhost:9092\nrequired_acks = 2":  "invalid required_acks 2, valid options are -1, 0 and 1",
+		} {
+			cfg, err := ini.Load([]byte("[log.kafka]\n" + config))
+			require.NoError(t, err)
+			_, err = NewKafkaHandler(cfg.Section("log.kafka"), log15.JsonFormat())
+			require.EqualError(t, err, expected)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/inconshreveable/log15"
+)
+
+// LazyHandler evaluates the lazy values of records before passing them on to h, if logged reports
+// that they are written by any mode. Lazy values are functions without arguments returning the
+// value, like `func() interface{}` and log15.Lazy, so expensive values, like marshaled payloads,
+// are only computed for records that are written. Records that aren't written are passed on as
+// they are.
+func LazyHandler(logged func(r *log15.Record) bool, h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		lazy := false
+		for i := 1; i < len(r.Ctx); i += 2 {
+			if isLazyValue(r.Ctx[i]) {
+				lazy = true
+				break
+			}
+		}
+		if !lazy || !logged(r) {
+			return h.Log(r)
+		}
+
+		evaluated := *r
+		evaluated.Ctx = make([]interface{}, len(r.Ctx))
+		copy(evaluated.Ctx, r.Ctx)
+		for i := 1; i < len(evaluated.Ctx); i += 2 {
+			if isLazyValue(evaluated.Ctx[i]) {
+				evaluated.Ctx[i] = evaluateLazyValue(evaluated.Ctx[i])
+			}
+		}
+		return h.Log(&evaluated)
+	})
+}
+
+func isLazyValue(v interface{}) bool {
+	switch v.(type) {
+	case func() interface{}, func() string, log15.Lazy:
+		return true
+	default:
+		return false
+	}
+}
+
+// evaluateLazyValue calls the function of a lazy value. Functions of log15.Lazy values that can't
+// be called without arguments are replaced by an error.
+func evaluateLazyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case func() interface{}:
+		return v()
+	case func() string:
+		return v()
+	case log15.Lazy:
+		fn := reflect.ValueOf(v.Fn)
+		if fn.Kind() != reflect.Func || fn.Type().NumIn() > 0 || fn.Type().NumOut() == 0 {
+			return fmt.Errorf("invalid lazy value %T, it must be a function without arguments returning a value", v.Fn)
+		}
+		return fn.Call(nil)[0].Interface()
+	default:
+		return v
+	}
+}
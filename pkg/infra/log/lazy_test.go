@@ -0,0 +1,50 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyHandler(t *testing.T) {
+	var logged []*log15.Record
+	handler := LazyHandler(func(r *log15.Record) bool {
+		return r.Lvl <= log15.LvlInfo
+	}, log15.FuncHandler(func(r *log15.Record) error {
+		logged = append(logged, r)
+		return nil
+	}))
+
+	evaluations := 0
+	payload := func() interface{} {
+		evaluations++
+		return map[string]int{"panels": 3}
+	}
+
+	t.Run("Lazy values are evaluated for records that are written", func(t *testing.T) {
+		logged = nil
+		record := &log15.Record{Lvl: log15.LvlInfo, Msg: "Saved", Ctx: []interface{}{
+			"payload", payload, "uid", func() string { return "abc" }, "count", log15.Lazy{Fn: func() int { return 2 }},
+			"invalid", log15.Lazy{Fn: func(int) int { return 0 }},
+		}}
+		require.NoError(t, handler.Log(record))
+
+		require.Len(t, logged, 1)
+		assert.Equal(t, 1, evaluations)
+		assert.Equal(t, map[string]int{"panels": 3}, logged[0].Ctx[1])
+		assert.Equal(t, "abc", logged[0].Ctx[3])
+		assert.Equal(t, 2, logged[0].Ctx[5])
+		assert.EqualError(t, logged[0].Ctx[7].(error), "invalid lazy value func(int) int, it must be a function without arguments returning a value")
+	})
+
+	t.Run("Lazy values of records that aren't written aren't evaluated", func(t *testing.T) {
+		logged = nil
+		evaluations = 0
+		require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlDebug, Msg: "Saving", Ctx: []interface{}{"payload", payload}}))
+
+		require.Len(t, logged, 1)
+		assert.Equal(t, 0, evaluations)
+	})
+}
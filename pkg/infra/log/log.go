@@ -5,11 +5,17 @@
 package log
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/go-stack/stack"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -23,6 +29,24 @@ var loggersToClose []DisposableHandler
 var loggersToReload []ReloadableHandler
 var filters map[string]log15.Lvl
 
+// handlersMu guards the handlers of the logging config, which are replaced when it's read again.
+var handlersMu sync.Mutex
+
+var (
+	levelsMu sync.RWMutex
+	// levelOverrides are the levels of loggers set at runtime with SetLevel. They take precedence
+	// over the levels and filters of the config, in all modes.
+	levelOverrides = map[string]log15.Lvl{}
+	// filterOverrides are the filters set at runtime with SetFilters. Unless nil, they replace the
+	// filters of the config in all modes.
+	filterOverrides map[string]log15.Lvl
+)
+
+var (
+	clockMu  sync.RWMutex
+	logClock = clock.New()
+)
+
 func init() {
 	loggersToClose = make([]DisposableHandler, 0)
 	loggersToReload = make([]ReloadableHandler, 0)
@@ -31,9 +55,78 @@ func init() {
 	Root.SetHandler(log15.DiscardHandler())
 }
 
-func New(logger string, ctx ...interface{}) Logger {
+// SetClock replaces the clock that timestamps log records, so log output can be asserted on in tests.
+func SetClock(c clock.Clock) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	logClock = c
+}
+
+func now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return logClock.Now()
+}
+
+func afterFunc(d time.Duration, f func()) *clock.Timer {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return logClock.AfterFunc(d, f)
+}
+
+// timestampHandler stamps records with the time of the log clock before passing them on to h.
+func timestampHandler(h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		r.Time = now()
+		return h.Log(r)
+	})
+}
+
+// skipUnloggedHandler drops the records that aren't written by any mode, unless hooks are
+// registered to observe them, so records filtered by the level of all modes, like the debug
+// records of requests, don't go through the handlers adding and redacting values.
+func skipUnloggedHandler(logged func(r *log15.Record) bool, h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		if !logged(r) && !hasHooks() {
+			return nil
+		}
+		return h.Log(r)
+	})
+}
+
+// ConcreteLogger is the Logger returned by New. Besides the methods of Logger, it has methods
+// logging with the tracing span of a context.
+type ConcreteLogger struct {
+	log15.Logger
+}
+
+func New(logger string, ctx ...interface{}) *ConcreteLogger {
 	params := append([]interface{}{"logger", logger}, ctx...)
-	return Root.New(params...)
+	return &ConcreteLogger{Logger: Root.New(params...)}
+}
+
+// New returns a new ConcreteLogger that has this logger's context plus the given context. The
+// child logger doesn't share any state with this logger that either of them changes, so loggers
+// can be derived from a shared logger concurrently.
+func (cl *ConcreteLogger) New(ctx ...interface{}) log15.Logger {
+	// log15 appends to the context when it has an odd number of values, which must not write to
+	// the spare capacity of a slice of the caller.
+	return &ConcreteLogger{Logger: cl.Logger.New(ctx[:len(ctx):len(ctx)]...)}
+}
+
+// Fatal logs a message at the critical level, closes the handlers so buffered records are
+// written, and exits with status 1.
+func (cl *ConcreteLogger) Fatal(msg string, ctx ...interface{}) {
+	cl.Crit(msg, ctx...)
+	closeAndExit()
+}
+
+// Panic logs a message at the critical level, flushes the handlers that buffer writes, and panics
+// with the message.
+func (cl *ConcreteLogger) Panic(msg string, ctx ...interface{}) {
+	cl.Crit(msg, ctx...)
+	flushHandlers()
+	panic(msg)
 }
 
 func Tracef(format string, v ...interface{}) {
@@ -94,26 +187,107 @@ func Errorf(skip int, format string, v ...interface{}) {
 
 func Fatalf(skip int, format string, v ...interface{}) {
 	Root.Crit(fmt.Sprintf(format, v...))
+	closeAndExit()
+}
+
+// exit exits the process. It's replaced in tests.
+var exit = os.Exit
+
+// closeAndExit closes the handlers, so buffered records are written, and exits with status 1.
+func closeAndExit() {
 	if err := Close(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to close log: %s\n", err)
 	}
-	os.Exit(1)
+	exit(1)
 }
 
+// defaultCloseTimeout is the default time handlers may take to write their buffered records when
+// they're closed.
+const defaultCloseTimeout = 10 * time.Second
+
+// closeTimeout is the time handlers may take to write their buffered records when they're closed,
+// set by the close_timeout setting.
+var closeTimeout = defaultCloseTimeout
+
+// Close closes the handlers, which write their buffered records for up to the close_timeout of the
+// logging config.
 func Close() error {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	return closeLocked(ctx)
+}
+
+// CloseContext closes the handlers, which write their buffered records until ctx is done, like the
+// context of shutting down the server. The records that aren't written by then are dropped.
+func CloseContext(ctx context.Context) error {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	return closeLocked(ctx)
+}
+
+// closeLocked closes the handlers. handlersMu must be held.
+func closeLocked(ctx context.Context) error {
+	err := closeHandlers(ctx, loggersToClose)
+	loggersToClose = make([]DisposableHandler, 0)
+	loggersToReload = make([]ReloadableHandler, 0)
+
+	return err
+}
+
+// flushHandlers flushes the handlers that buffer writes, without closing them.
+func flushHandlers() {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	for _, handler := range loggersToClose {
+		if h, ok := handler.(FlushableHandler); ok {
+			h.Flush()
+		}
+	}
+}
+
+func closeHandlers(ctx context.Context, handlers []DisposableHandler) error {
 	var err error
-	for _, logger := range loggersToClose {
-		if e := logger.Close(); e != nil && err == nil {
+	for _, handler := range handlers {
+		if e := closeHandler(ctx, handler); e != nil && err == nil {
 			err = e
 		}
 	}
-	loggersToClose = make([]DisposableHandler, 0)
-
 	return err
 }
 
+// closeHandler closes a handler, if it can be closed. Handlers that can't be closed with a context
+// are closed in the background, and aren't waited for once ctx is done, so a handler blocked on a
+// stalled connection doesn't block closing forever.
+func closeHandler(ctx context.Context, h interface{}) error {
+	switch h := h.(type) {
+	case ContextDisposableHandler:
+		return h.CloseContext(ctx)
+	case DisposableHandler:
+		closed := make(chan error, 1)
+		go func() {
+			closed <- h.Close()
+		}()
+		select {
+		case err := <-closed:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	default:
+		return nil
+	}
+}
+
 // Reload reloads all loggers.
 func Reload() error {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
 	for _, logger := range loggersToReload {
 		if err := logger.Reload(); err != nil {
 			return err
@@ -123,6 +297,113 @@ func Reload() error {
 	return nil
 }
 
+// Rotate reopens the files of the file modes, so the records are written to new files once an
+// external tool like logrotate has renamed the files, instead of to the renamed files. All files are
+// reopened, even when one of them can't be, of which the first error is returned.
+func Rotate() error {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	var err error
+	for _, logger := range loggersToReload {
+		if reopenErr := logger.Reload(); reopenErr != nil && err == nil {
+			err = reopenErr
+		}
+	}
+	return err
+}
+
+// SetLevel sets the level of the named logger in all modes, without reading the logging config
+// again. The level is kept until it's reset with ResetLevel.
+func SetLevel(loggerName string, levelName string) error {
+	if loggerName == "" {
+		return errors.New("logger name is required")
+	}
+	level, ok := logLevels[strings.ToLower(levelName)]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", levelName)
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	levelOverrides[loggerName] = level
+	return nil
+}
+
+// ResetLevel reverts the level of the named logger to the level of the config.
+func ResetLevel(loggerName string) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	delete(levelOverrides, loggerName)
+}
+
+// SetFilters replaces the filters of the config in all modes, like `filters = sqlstore:debug
+// tsdb.*:warn`, with filters of logger names or glob patterns to level names, without reading the
+// logging config again. The filters apply to the loggers created already, and are kept until
+// they're reset by calling SetFilters with nil filters. Levels set with SetLevel take precedence.
+func SetFilters(newFilters map[string]string) error {
+	var levels map[string]log15.Lvl
+	if newFilters != nil {
+		levels = make(map[string]log15.Lvl, len(newFilters))
+		for pattern, levelName := range newFilters {
+			if pattern == "" {
+				return errors.New("logger name is required")
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid log filter pattern %q: %w", pattern, err)
+			}
+			level, ok := logLevels[strings.ToLower(levelName)]
+			if !ok {
+				return fmt.Errorf("unknown log level %q", levelName)
+			}
+			levels[pattern] = level
+		}
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	filterOverrides = levels
+	return nil
+}
+
+// GetLevels returns the levels of the loggers that have a level of their own, either filtered by the
+// config or SetFilters, or set with SetLevel.
+func GetLevels() map[string]string {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	currentFilters := filters
+	if filterOverrides != nil {
+		currentFilters = filterOverrides
+	}
+	levels := make(map[string]string, len(currentFilters)+len(levelOverrides))
+	for name, level := range currentFilters {
+		levels[name] = levelNames[level]
+	}
+	for name, level := range levelOverrides {
+		levels[name] = levelNames[level]
+	}
+	return levels
+}
+
+func levelOverride(loggerName string) (log15.Lvl, bool) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	level, ok := levelOverrides[loggerName]
+	return level, ok
+}
+
+// currentFilterOverrides returns the filters set with SetFilters, or nil if the filters of the
+// config apply. The filters are replaced, never changed, so they can be read without the lock.
+func currentFilterOverrides() map[string]log15.Lvl {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return filterOverrides
+}
+
+// lvlOff is the level of silenced loggers, which no record passes.
+const lvlOff log15.Lvl = -1
+
 var logLevels = map[string]log15.Lvl{
 	"trace":    log15.LvlDebug,
 	"debug":    log15.LvlDebug,
@@ -130,6 +411,8 @@ var logLevels = map[string]log15.Lvl{
 	"warn":     log15.LvlWarn,
 	"error":    log15.LvlError,
 	"critical": log15.LvlCrit,
+	"off":      lvlOff,
+	"none":     lvlOff,
 }
 
 func getLogLevelFromConfig(key string, defaultName string, cfg *ini.File) (string, log15.Lvl) {
@@ -156,6 +439,10 @@ func getFilters(filterStrArray []string) map[string]log15.Lvl {
 	for _, filterStr := range filterStrArray {
 		parts := strings.Split(filterStr, ":")
 		if len(parts) > 1 {
+			if _, err := path.Match(parts[0], ""); err != nil {
+				Root.Error("Invalid log filter pattern", "pattern", parts[0], "err", err)
+				continue
+			}
 			filterMap[parts[0]] = getLogLevelFromString(parts[1])
 		}
 	}
@@ -163,6 +450,28 @@ func getFilters(filterStrArray []string) map[string]log15.Lvl {
 	return filterMap
 }
 
+// filterLevel returns the level of the filter of a logger. Filters of the name of the logger take
+// precedence over filters of glob patterns matching it, like `tsdb.*`, of which the longest
+// matching pattern is used, or the first in alphabetical order of patterns as long.
+func filterLevel(filters map[string]log15.Lvl, loggerName string) (log15.Lvl, bool) {
+	if level, ok := filters[loggerName]; ok {
+		return level, true
+	}
+
+	var level log15.Lvl
+	matched := ""
+	for pattern, patternLevel := range filters {
+		if !strings.ContainsAny(pattern, "*?[") || len(pattern) < len(matched) ||
+			(len(pattern) == len(matched) && pattern > matched) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, loggerName); ok {
+			level, matched = patternLevel, pattern
+		}
+	}
+	return level, matched != ""
+}
+
 func getLogFormat(format string) log15.Format {
 	switch format {
 	case "console":
@@ -174,42 +483,159 @@ func getLogFormat(format string) log15.Format {
 		return log15.LogfmtFormat()
 	case "json":
 		return log15.JsonFormat()
+	case "otel_json":
+		return OTelJSONFormat("grafana")
+	case "cef":
+		return CEFFormat(buildVersion)
+	case "logstash":
+		return LogstashFormat()
 	default:
 		return log15.LogfmtFormat()
 	}
 }
 
-func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) error {
-	if err := Close(); err != nil {
-		return err
-	}
+// ReadLoggingConfig creates the handlers of the log modes of the config, and replaces the handlers
+// of the root logger with them. The handlers of the previous config are closed once replaced, so
+// the config can be read again on a running server.
+func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) (err error) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	toClose := make([]DisposableHandler, 0)
+	toReload := make([]ReloadableHandler, 0)
+	newFilters := map[string]log15.Lvl{}
+	defer func() {
+		if err != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+			defer cancel()
+			_ = closeHandlers(ctx, toClose)
+		}
+	}()
 
-	defaultLevelName, _ := getLogLevelFromConfig("log", "info", cfg)
+	envOverrides, err := readEnvOverrides()
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read environment overrides of log")
+	}
+	defaultLevelName, defaultLevel := getLogLevelFromConfig("log", "info", cfg)
 	defaultFilters := getFilters(util.SplitString(cfg.Section("log").Key("filters").String()))
+	defaultLevel, defaultFilters = envOverrides.apply(defaultLevel, defaultFilters)
+	defaultDedupWindow := cfg.Section("log").Key("dedup_window").MustDuration(0)
+	redactKeys := defaultRedactKeys
+	if key, err := cfg.Section("log").GetKey("redact_keys"); err == nil {
+		redactKeys = util.SplitString(key.String())
+	}
+	redactSettings, err := getRedactSettings(redactKeys, cfg.Section("log").Key("redact_pattern").String())
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read redact settings of log")
+	}
+	defaultSampling, err := getSamplingRules(util.SplitString(cfg.Section("log").Key("sampling").String()))
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read sampling rules of log")
+	}
+	staticFields, err := getStaticFields(util.SplitString(cfg.Section("log").Key("static_fields").String()), cfg)
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read static fields of log")
+	}
+	stackSettings, err := readStackSettings(cfg.Section("log"))
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read stack trace settings of log")
+	}
+	maxFieldLength := cfg.Section("log").Key("max_field_length").MustInt(0)
+	errorIDs := cfg.Section("log").Key("error_id").MustBool(false)
+	deltaTime := cfg.Section("log").Key("delta_time").MustBool(false)
+	newCloseTimeout := cfg.Section("log").Key("close_timeout").MustDuration(defaultCloseTimeout)
+	if newCloseTimeout <= 0 {
+		return errors.New("failed to read close timeout of log: close_timeout must be greater than 0")
+	}
+	callerDepth := cfg.Section("log").Key("caller_depth").MustInt(0)
+	if callerDepth < 0 {
+		return errors.New("failed to read caller settings of log: caller_depth must not be negative")
+	}
+	recentBufferSize := cfg.Section("log").Key("recent_buffer_size").MustInt(defaultRecentBufferSize)
+	if recentBufferSize < 0 {
+		return errors.New("failed to read recent buffer size of log: recent_buffer_size must not be negative")
+	}
 
-	handlers := make([]log15.Handler, 0)
+	routes, err := getLogRoutes(cfg)
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read log routes")
+	}
+	routesByName := make(map[string]logRoute, len(routes))
+	names := append([]string{}, modes...)
+	var exclusiveLoggers []string
+	for _, route := range routes {
+		routesByName[route.name] = route
+		names = append(names, route.name)
+		if route.exclusive {
+			exclusiveLoggers = append(exclusiveLoggers, route.loggers...)
+		}
+	}
 
-	for _, mode := range modes {
-		mode = strings.TrimSpace(mode)
-		sec, err := cfg.GetSection("log." + mode)
+	handlers := make([]log15.Handler, 0)
+	routeHandlers := make([]log15.Handler, 0)
+	// logged tells whether a record passes the level and filters of any mode.
+	var logged []func(r *log15.Record) bool
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		mode := name
+		route, isRoute := routesByName[name]
+		if isRoute {
+			mode = route.mode
+		}
+		sec, err := cfg.GetSection("log." + name)
 		if err != nil {
-			Root.Error("Unknown log mode", "mode", mode)
-			return errutil.Wrapf(err, "failed to get config section log.%s", mode)
+			Root.Error("Unknown log mode", "mode", name)
+			return errutil.Wrapf(err, "failed to get config section log.%s", name)
 		}
 
 		// Log level.
-		_, level := getLogLevelFromConfig("log."+mode, defaultLevelName, cfg)
+		_, level := getLogLevelFromConfig("log."+name, defaultLevelName, cfg)
 		modeFilters := getFilters(util.SplitString(sec.Key("filters").String()))
-		format := getLogFormat(sec.Key("format").MustString(""))
+		level, modeFilters = envOverrides.apply(level, modeFilters)
+		modeSampling, err := getSamplingRules(util.SplitString(sec.Key("sampling").String()))
+		if err != nil {
+			return errutil.Wrapf(err, "failed to read sampling rules of log.%s", name)
+		}
+		dropMatchers, err := getMatchers(util.SplitString(sec.Key("drop_matching").String()))
+		if err != nil {
+			return errutil.Wrapf(err, "failed to read drop_matching of log.%s", name)
+		}
+		keepMatchers, err := getMatchers(util.SplitString(sec.Key("keep_matching").String()))
+		if err != nil {
+			return errutil.Wrapf(err, "failed to read keep_matching of log.%s", name)
+		}
+		format, err := readLogFormat(sec, "")
+		if err != nil {
+			return errutil.Wrapf(err, "failed to read format of log.%s", name)
+		}
+		multiline, err := readMultilinePolicy(sec)
+		if err != nil {
+			return errutil.Wrapf(err, "failed to read multiline policy of log.%s", name)
+		}
 
 		var handler log15.Handler
 
 		// Generate log configuration.
 		switch mode {
 		case "console":
-			handler = log15.StreamHandler(os.Stdout, format)
+			if sec.Key("format").MustString("") == "console" {
+				colors, err := readConsoleColors(sec)
+				if err != nil {
+					return errutil.Wrapf(err, "failed to read colors of log.%s", name)
+				}
+				format = consoleColorFormat(format, colors)
+			}
+			stderrLevel, err := readStderrLevel(sec)
+			if err != nil {
+				return errutil.Wrapf(err, "failed to read stderr level of log.%s", name)
+			}
+			handler = consoleStreamHandler(os.Stdout, os.Stderr, stderrLevel, format)
 		case "file":
 			fileName := sec.Key("file_name").MustString(filepath.Join(logsPath, "grafana.log"))
+			if isRoute {
+				fileName = route.fileName(sec, logsPath)
+			}
 			dpath := filepath.Dir(fileName)
 			if err := os.MkdirAll(dpath, os.ModePerm); err != nil {
 				Root.Error("Failed to create directory", "dpath", dpath, "err", err)
@@ -223,24 +649,137 @@ func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) error {
 			fileHandler.Maxsize = 1 << uint(sec.Key("max_size_shift").MustInt(28))
 			fileHandler.Daily = sec.Key("daily_rotate").MustBool(true)
 			fileHandler.Maxdays = sec.Key("max_days").MustInt64(7)
+			fileHandler.MaxTotalSize = sec.Key("max_total_size").MustInt64(0) << 20
+			fileHandler.BufferSize = sec.Key("write_buffer_size").MustInt(0) << 10
+			fileHandler.FlushInterval = sec.Key("write_flush_interval").MustDuration(time.Second)
 			if err := fileHandler.Init(); err != nil {
 				Root.Error("Failed to initialize file handler", "dpath", dpath, "err", err)
 				return errutil.Wrapf(err, "failed to initialize file handler")
 			}
 
-			loggersToClose = append(loggersToClose, fileHandler)
-			loggersToReload = append(loggersToReload, fileHandler)
+			toClose = append(toClose, fileHandler)
+			toReload = append(toReload, fileHandler)
 			handler = fileHandler
 		case "syslog":
 			sysLogHandler := NewSyslog(sec, format)
 
-			loggersToClose = append(loggersToClose, sysLogHandler)
+			toClose = append(toClose, sysLogHandler)
 			handler = sysLogHandler
+		case "loki":
+			lokiHandler, err := NewLokiHandler(sec, format)
+			if err != nil {
+				Root.Error("Failed to initialize Loki handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize Loki handler")
+			}
+
+			toClose = append(toClose, lokiHandler)
+			handler = lokiHandler
+		case "kafka":
+			kafkaFormat, err := readLogFormat(sec, "json")
+			if err != nil {
+				return errutil.Wrapf(err, "failed to read format of log.%s", name)
+			}
+			kafkaHandler, err := NewKafkaHandler(sec, kafkaFormat)
+			if err != nil {
+				Root.Error("Failed to initialize Kafka handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize Kafka handler")
+			}
+
+			toClose = append(toClose, kafkaHandler)
+			handler = kafkaHandler
+		case "fluentd":
+			fluentdHandler, err := NewFluentdHandler(sec)
+			if err != nil {
+				Root.Error("Failed to initialize Fluentd handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize Fluentd handler")
+			}
+
+			toClose = append(toClose, fluentdHandler)
+			handler = fluentdHandler
+		case "network":
+			networkHandler, err := NewNetworkHandler(sec, format)
+			if err != nil {
+				Root.Error("Failed to initialize network handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize network handler")
+			}
+
+			toClose = append(toClose, networkHandler)
+			handler = networkHandler
+		case "journald":
+			journaldHandler, err := NewJournaldHandler(sec)
+			if err != nil {
+				Root.Error("Failed to initialize journald handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize journald handler")
+			}
+
+			toClose = append(toClose, journaldHandler)
+			handler = journaldHandler
+		case "otlp":
+			otlpHandler, err := NewOTLPHandler(sec)
+			if err != nil {
+				Root.Error("Failed to initialize OTLP handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize OTLP handler")
+			}
+
+			toClose = append(toClose, otlpHandler)
+			handler = otlpHandler
+		case "cloudwatch":
+			cloudWatchHandler, err := NewCloudWatchHandler(sec, format)
+			if err != nil {
+				Root.Error("Failed to initialize CloudWatch handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize CloudWatch handler")
+			}
+
+			toClose = append(toClose, cloudWatchHandler)
+			handler = cloudWatchHandler
+		case "stackdriver":
+			stackdriverHandler, err := NewStackdriverHandler(sec)
+			if err != nil {
+				Root.Error("Failed to initialize Cloud Logging handler", "err", err)
+				return errutil.Wrapf(err, "failed to initialize Cloud Logging handler")
+			}
+
+			toClose = append(toClose, stackdriverHandler)
+			handler = stackdriverHandler
+		default:
+			factory := registeredHandler(mode)
+			if factory == nil {
+				break
+			}
+			registered, err := factory(sec, format)
+			if err != nil {
+				Root.Error("Failed to initialize handler", "mode", mode, "err", err)
+				return errutil.Wrapf(err, "failed to initialize handler of log.%s", name)
+			}
+
+			if h, ok := registered.(DisposableHandler); ok {
+				toClose = append(toClose, h)
+			}
+			if h, ok := registered.(ReloadableHandler); ok {
+				toReload = append(toReload, h)
+			}
+			handler = registered
 		}
 		if handler == nil {
 			panic(fmt.Sprintf("Handler is uninitialized for mode %q", mode))
 		}
 
+		if sec.Key("async").MustBool(false) {
+			asyncHandler, err := NewAsyncHandler(sec, handler)
+			if err != nil {
+				return errutil.Wrapf(err, "failed to initialize async handler of log.%s", name)
+			}
+
+			// The async handler closes the handler once its queue is drained.
+			if n := len(toClose); n > 0 && interface{}(toClose[n-1]) == interface{}(handler) {
+				toClose = toClose[:n-1]
+			}
+			toClose = append(toClose, asyncHandler)
+			handler = asyncHandler
+		}
+		handler = writeErrorsHandler(name, handler)
+		handler = MultilineHandler(multiline, handler)
+
 		for key, value := range defaultFilters {
 			if _, exist := modeFilters[key]; !exist {
 				modeFilters[key] = value
@@ -248,37 +787,115 @@ func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) error {
 		}
 
 		for key, value := range modeFilters {
-			if _, exist := filters[key]; !exist {
-				filters[key] = value
+			if _, exist := newFilters[key]; !exist {
+				newFilters[key] = value
 			}
 		}
 
-		handler = LogFilterHandler(level, modeFilters, handler)
+		for key, value := range defaultSampling {
+			if _, exist := modeSampling[key]; !exist {
+				modeSampling[key] = value
+			}
+		}
+
+		dedupWindow := sec.Key("dedup_window").MustDuration(defaultDedupWindow)
+		handler = DedupHandler(dedupWindow, handler)
+		handler = LogFilterHandler(level, modeFilters, MatchFilterHandler(dropMatchers, keepMatchers, SamplingHandler(modeSampling, handler)))
+		modeLevel, modeLevelFilters := level, modeFilters
+		logged = append(logged, func(r *log15.Record) bool {
+			return passesLogFilter(modeLevel, modeLevelFilters, r)
+		})
+		if isRoute {
+			routeHandlers = append(routeHandlers, RouteHandler(route.loggers, false, handler))
+			continue
+		}
 		handlers = append(handlers, handler)
 	}
 
-	Root.SetHandler(log15.MultiHandler(handlers...))
-	return nil
+	if len(exclusiveLoggers) > 0 {
+		handlers = []log15.Handler{RouteHandler(exclusiveLoggers, true, log15.MultiHandler(handlers...))}
+	}
+	handlers = append(handlers, routeHandlers...)
+
+	// The records of the audit loggers are written to the audit log regardless of the levels and
+	// filters of the modes.
+	auditHandler, err := newAuditHandler(cfg.Section("log.audit"), logsPath)
+	if err != nil {
+		return errutil.Wrapf(err, "failed to initialize audit log")
+	}
+	if auditHandler != nil {
+		toClose = append(toClose, auditHandler)
+		toReload = append(toReload, auditHandler)
+		handlers = append(handlers, RouteHandler(auditLoggers, false, writeErrorsHandler("audit", auditHandler)))
+		logged = append(logged, func(r *log15.Record) bool {
+			logger, _ := recordLogger(r)
+			return matchLogger(auditLoggers, logger)
+		})
+	}
+
+	levelsMu.Lock()
+	filters = newFilters
+	levelsMu.Unlock()
+
+	recentRecords.resize(recentBufferSize)
+	if recentBufferSize > 0 {
+		handlers = append(handlers, LogFilterHandler(defaultLevel, defaultFilters, recentRecords.handler()))
+		logged = append(logged, func(r *log15.Record) bool {
+			return passesLogFilter(defaultLevel, defaultFilters, r)
+		})
+	}
+
+	isLogged := func(r *log15.Record) bool {
+		for _, passes := range logged {
+			if passes(r) {
+				return true
+			}
+		}
+		return false
+	}
+	var handler log15.Handler = StackHandler(stackSettings, StaticFieldsHandler(staticFields, LazyHandler(isLogged, ErrorIDHandler(errorIDs, DeltaTimeHandler(deltaTime, hooksHandler(RedactHandler(redactSettings, TruncateHandler(maxFieldLength, log15.MultiHandler(handlers...)))))))))
+	if cfg.Section("log").Key("include_caller").MustBool(false) {
+		handler = CallerHandler(callerDepth, handler)
+	}
+	Root.SetHandler(countingHandler(skipUnloggedHandler(isLogged, timestampHandler(handler))))
+
+	previous := loggersToClose
+	loggersToClose, loggersToReload = toClose, toReload
+	toClose = nil
+	closeTimeout = newCloseTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	return closeHandlers(ctx, previous)
 }
 
 func LogFilterHandler(maxLevel log15.Lvl, filters map[string]log15.Lvl, h log15.Handler) log15.Handler {
 	return log15.FilterHandler(func(r *log15.Record) (pass bool) {
-		if len(filters) > 0 {
-			for i := 0; i < len(r.Ctx); i += 2 {
-				key, ok := r.Ctx[i].(string)
-				if ok && key == "logger" {
-					loggerName, strOk := r.Ctx[i+1].(string)
-					if strOk {
-						if filterLevel, ok := filters[loggerName]; ok {
-							return r.Lvl <= filterLevel
-						}
-					}
+		return passesLogFilter(maxLevel, filters, r)
+	}, h)
+}
+
+// passesLogFilter reports whether a record passes the level and the filters of a mode, or the
+// filters set with SetFilters instead, or the level of its logger set with SetLevel.
+func passesLogFilter(maxLevel log15.Lvl, filters map[string]log15.Lvl, r *log15.Record) bool {
+	for i := 0; i < len(r.Ctx)-1; i += 2 {
+		key, ok := r.Ctx[i].(string)
+		if ok && key == "logger" {
+			loggerName, strOk := r.Ctx[i+1].(string)
+			if strOk {
+				if overrideLevel, ok := levelOverride(loggerName); ok {
+					return r.Lvl <= overrideLevel
+				}
+				if overrides := currentFilterOverrides(); overrides != nil {
+					filters = overrides
+				}
+				if filterLevel, ok := filterLevel(filters, loggerName); ok {
+					return r.Lvl <= filterLevel
 				}
 			}
 		}
+	}
 
-		return r.Lvl <= maxLevel
-	}, h)
+	return r.Lvl <= maxLevel
 }
 
 func Stack(skip int) string {
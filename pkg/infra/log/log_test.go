@@ -0,0 +1,284 @@
+package log
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampHandler(t *testing.T) {
+	mock := clock.NewMock()
+	mock.Set(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC))
+	SetClock(mock)
+	t.Cleanup(func() { SetClock(clock.New()) })
+
+	var records []*log15.Record
+	logger := log15.New()
+	logger.SetHandler(timestampHandler(log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	})))
+
+	logger.Info("first")
+	mock.Add(time.Minute)
+	logger.Info("second")
+
+	require.Len(t, records, 2)
+	require.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), records[0].Time)
+	require.Equal(t, time.Date(2021, 3, 4, 5, 7, 7, 0, time.UTC), records[1].Time)
+}
+
+func TestSetLevel(t *testing.T) {
+	var records []*log15.Record
+	handler := LogFilterHandler(log15.LvlInfo, map[string]log15.Lvl{"sqlstore": log15.LvlError}, log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	logger := log15.New("logger", "tsdb.prometheus")
+	logger.SetHandler(handler)
+	other := log15.New("logger", "sqlstore")
+	other.SetHandler(handler)
+	t.Cleanup(func() {
+		ResetLevel("tsdb.prometheus")
+		ResetLevel("sqlstore")
+	})
+
+	logger.Debug("filtered by the level of the mode")
+	require.Empty(t, records)
+
+	require.NoError(t, SetLevel("tsdb.prometheus", "DEBUG"))
+	logger.Debug("passed by the level of the logger")
+	require.Len(t, records, 1)
+	require.Equal(t, "debug", GetLevels()["tsdb.prometheus"])
+
+	// The level set at runtime takes precedence over the filters of the config.
+	other.Warn("filtered by the filters of the config")
+	require.NoError(t, SetLevel("sqlstore", "warn"))
+	other.Warn("passed by the level of the logger")
+	require.Len(t, records, 2)
+
+	ResetLevel("tsdb.prometheus")
+	logger.Debug("filtered by the level of the mode again")
+	require.Len(t, records, 2)
+	require.NotContains(t, GetLevels(), "tsdb.prometheus")
+
+	require.EqualError(t, SetLevel("tsdb.prometheus", "verbose"), `unknown log level "verbose"`)
+	require.EqualError(t, SetLevel("", "debug"), "logger name is required")
+}
+
+func TestSetFilters(t *testing.T) {
+	var records []*log15.Record
+	handler := LogFilterHandler(log15.LvlInfo, map[string]log15.Lvl{"sqlstore": log15.LvlError}, log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	sqlstore := log15.New("logger", "sqlstore")
+	sqlstore.SetHandler(handler)
+	prometheus := log15.New("logger", "tsdb.prometheus")
+	prometheus.SetHandler(handler)
+	t.Cleanup(func() {
+		require.NoError(t, SetFilters(nil))
+		ResetLevel("tsdb.prometheus")
+	})
+
+	sqlstore.Warn("filtered by the filters of the config")
+	prometheus.Debug("filtered by the level of the mode")
+	require.Empty(t, records)
+
+	// The filters replace the filters of the config of loggers created already.
+	require.NoError(t, SetFilters(map[string]string{"tsdb.*": "DEBUG"}))
+	sqlstore.Warn("passed by the level of the mode")
+	prometheus.Debug("passed by the filters")
+	require.Len(t, records, 2)
+	require.Equal(t, "debug", GetLevels()["tsdb.*"])
+	require.NotContains(t, GetLevels(), "sqlstore")
+
+	// Levels set with SetLevel take precedence over the filters.
+	require.NoError(t, SetLevel("tsdb.prometheus", "error"))
+	prometheus.Warn("filtered by the level of the logger")
+	require.Len(t, records, 2)
+	ResetLevel("tsdb.prometheus")
+
+	require.NoError(t, SetFilters(nil))
+	sqlstore.Warn("filtered by the filters of the config again")
+	require.Len(t, records, 2)
+
+	require.EqualError(t, SetFilters(map[string]string{"sqlstore": "verbose"}), `unknown log level "verbose"`)
+	require.EqualError(t, SetFilters(map[string]string{"tsdb.[": "debug"}), `invalid log filter pattern "tsdb.[": syntax error in pattern`)
+	require.EqualError(t, SetFilters(map[string]string{"": "debug"}), "logger name is required")
+}
+
+func TestFatalAndPanic(t *testing.T) {
+	var records []*log15.Record
+	Root.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	var exitCode int
+	exit = func(code int) { exitCode = code }
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		exit = os.Exit
+	})
+	useHandler := func() *gatedHandler {
+		h := newGatedHandler()
+		handlersMu.Lock()
+		loggersToClose = []DisposableHandler{h}
+		handlersMu.Unlock()
+		return h
+	}
+	logger := New("server")
+
+	h := useHandler()
+	logger.Fatal("Failed to start", "error", "port in use")
+	require.Len(t, records, 1)
+	require.Equal(t, log15.LvlCrit, records[0].Lvl)
+	require.True(t, h.closed)
+	require.Equal(t, 1, exitCode)
+
+	h = useHandler()
+	require.PanicsWithValue(t, "Invalid state", func() {
+		logger.Panic("Invalid state")
+	})
+	require.Len(t, records, 2)
+	require.Equal(t, 1, h.flushes)
+	require.False(t, h.closed)
+	require.NoError(t, Close())
+}
+
+func TestFilterPatterns(t *testing.T) {
+	filters := getFilters([]string{"tsdb.*:debug", "tsdb.prometheus.*:warn", "tsdb.loki:error", "plugins.[:info"})
+	require.NotContains(t, filters, "plugins.[")
+
+	var records []*log15.Record
+	handler := LogFilterHandler(log15.LvlInfo, filters, log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	for _, logger := range []string{"tsdb.prometheus", "tsdb.prometheus.client", "tsdb.loki", "tsdb", "sqlstore"} {
+		for _, level := range []log15.Lvl{log15.LvlDebug, log15.LvlInfo, log15.LvlWarn, log15.LvlError} {
+			require.NoError(t, handler.Log(&log15.Record{Lvl: level, Msg: logger, Ctx: []interface{}{"logger", logger}}))
+		}
+	}
+
+	passed := map[string]int{}
+	for _, r := range records {
+		passed[r.Msg]++
+	}
+	require.Equal(t, map[string]int{
+		// Matched by tsdb.*.
+		"tsdb.prometheus": 4,
+		// Matched by the longer tsdb.prometheus.* pattern.
+		"tsdb.prometheus.client": 2,
+		// The name of the logger takes precedence over patterns.
+		"tsdb.loki": 1,
+		"tsdb":      3,
+		"sqlstore":  3,
+	}, passed)
+}
+
+func TestFilterOff(t *testing.T) {
+	var records []*log15.Record
+	handler := LogFilterHandler(log15.LvlInfo, getFilters([]string{"rendering:off", "plugins.*:none"}), log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	for _, logger := range []string{"rendering", "plugins.loki", "server"} {
+		require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlCrit, Msg: logger, Ctx: []interface{}{"logger", logger}}))
+	}
+
+	require.Len(t, records, 1)
+	require.Equal(t, "server", records[0].Msg)
+
+	t.Cleanup(func() {
+		ResetLevel("server")
+	})
+	require.NoError(t, SetLevel("server", "off"))
+	require.Equal(t, "off", GetLevels()["server"])
+	require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlCrit, Msg: "server", Ctx: []interface{}{"logger", "server"}}))
+	require.Len(t, records, 1)
+}
+
+func TestConcreteLoggerNew(t *testing.T) {
+	var mu sync.Mutex
+	var records []*log15.Record
+	Root.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, r)
+		return nil
+	}))
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+	})
+
+	parent := New("test", "parent", 1)
+	// An odd number of values, with spare capacity the children must not write to.
+	shared := make([]interface{}, 1, 16)
+	shared[0] = "shared"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := parent.New("child", i)
+			child.New(shared...).Info("child message")
+			child.Info("child message")
+		}(i)
+	}
+	wg.Wait()
+	parent.Info("parent message")
+
+	require.Len(t, records, 101)
+	children := map[interface{}]int{}
+	for _, r := range records[:100] {
+		require.Equal(t, []interface{}{"logger", "test", "parent", 1, "child"}, r.Ctx[:5])
+		children[r.Ctx[5]]++
+		if len(r.Ctx) > 6 {
+			require.Equal(t, "shared", r.Ctx[6])
+			require.Nil(t, r.Ctx[7])
+		}
+	}
+	require.Len(t, children, 50)
+	require.Equal(t, []interface{}{"logger", "test", "parent", 1}, records[100].Ctx)
+	require.Equal(t, []interface{}{"shared"}, shared)
+	require.Equal(t, []interface{}{nil}, shared[1:2])
+}
+
+// blockedHandler is a handler of which Close blocks until its gate is opened.
+type blockedHandler struct {
+	log15.Handler
+	gate chan struct{}
+}
+
+func (h blockedHandler) Close() error {
+	<-h.gate
+	return nil
+}
+
+func TestCloseContext(t *testing.T) {
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+	})
+
+	blocked := blockedHandler{Handler: log15.DiscardHandler(), gate: make(chan struct{})}
+	defer close(blocked.gate)
+	handlersMu.Lock()
+	loggersToClose = []DisposableHandler{blocked}
+	handlersMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, CloseContext(ctx))
+
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	require.Empty(t, loggersToClose)
+}
@@ -0,0 +1,67 @@
+package log
+
+// LogrAdapter logs the messages of libraries logging with the logr API, like the Kubernetes client
+// libraries, with a logger of Grafana, so they're written to the log modes with the levels and
+// filters of the logging config instead of to stderr. It has the methods of logr.Logger, with the
+// V, WithValues and WithName methods returning a *LogrAdapter.
+type LogrAdapter struct {
+	logger *ConcreteLogger
+	// name is the logr name, set with WithName.
+	name string
+	// verbosity is the V level of the adapter. Messages of level 0 are logged at the info level, and
+	// messages of higher levels at the debug level, like klog does.
+	verbosity int
+}
+
+// NewLogrAdapter returns a LogrAdapter logging with a logger of the given name.
+func NewLogrAdapter(name string) *LogrAdapter {
+	return &LogrAdapter{logger: New(name)}
+}
+
+// Enabled reports that all levels are enabled, since the levels of loggers are checked by the
+// handlers of the log modes.
+func (l *LogrAdapter) Enabled() bool {
+	return true
+}
+
+// Info logs a message at the info level, or at the debug level for V levels above 0.
+func (l *LogrAdapter) Info(msg string, keysAndValues ...interface{}) {
+	if l.verbosity > 0 {
+		l.logger.Debug(msg, l.withName(keysAndValues)...)
+		return
+	}
+	l.logger.Info(msg, l.withName(keysAndValues)...)
+}
+
+// Error logs a message with an error at the error level, regardless of the V level.
+func (l *LogrAdapter) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.logger.Error(msg, l.withName(append([]interface{}{"error", err}, keysAndValues...))...)
+}
+
+// V returns an adapter logging the messages of Info at the given V level, added to the V level of
+// this adapter.
+func (l *LogrAdapter) V(level int) *LogrAdapter {
+	return &LogrAdapter{logger: l.logger, name: l.name, verbosity: l.verbosity + level}
+}
+
+// WithValues returns an adapter logging with the given key values added.
+func (l *LogrAdapter) WithValues(keysAndValues ...interface{}) *LogrAdapter {
+	return &LogrAdapter{logger: &ConcreteLogger{Logger: l.logger.Logger.New(keysAndValues...)}, name: l.name, verbosity: l.verbosity}
+}
+
+// WithName returns an adapter logging with the name appended to the logr name of this adapter,
+// separated by a period, in the `name` key.
+func (l *LogrAdapter) WithName(name string) *LogrAdapter {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return &LogrAdapter{logger: l.logger, name: name, verbosity: l.verbosity}
+}
+
+// withName prepends the logr name to the key values of a message, if it's set.
+func (l *LogrAdapter) withName(keysAndValues []interface{}) []interface{} {
+	if l.name == "" {
+		return keysAndValues
+	}
+	return append([]interface{}{"name", l.name}, keysAndValues...)
+}
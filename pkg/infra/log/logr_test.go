@@ -0,0 +1,33 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogrAdapter(t *testing.T) {
+	var logged []*log15.Record
+	Root.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+		logged = append(logged, r)
+		return nil
+	}))
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+	})
+
+	logger := NewLogrAdapter("k8s.client").WithValues("namespace", "default").WithName("informer").WithName("pods")
+	logger.Info("Watch started", "resource", "pods")
+	logger.V(2).Info("Event received")
+	logger.V(2).Error(errors.New("connection refused"), "Watch failed")
+
+	require.Len(t, logged, 3)
+	assert.Equal(t, log15.LvlInfo, logged[0].Lvl)
+	assert.Equal(t, []interface{}{"logger", "k8s.client", "namespace", "default", "name", "informer.pods", "resource", "pods"}, logged[0].Ctx)
+	assert.Equal(t, log15.LvlDebug, logged[1].Lvl)
+	assert.Equal(t, log15.LvlError, logged[2].Lvl)
+	assert.Equal(t, []interface{}{"logger", "k8s.client", "namespace", "default", "name", "informer.pods", "error", errors.New("connection refused")}, logged[2].Ctx)
+}
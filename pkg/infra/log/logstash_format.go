@@ -0,0 +1,58 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/inconshreveable/log15"
+)
+
+// logstashTimeLayout is the layout of the @timestamp of logstash records, in UTC with milliseconds
+// like the timestamps of Logstash.
+const logstashTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// logstashRecord is a record in the shape of the events of Logstash, with the key values nested in
+// fields.
+type logstashRecord struct {
+	Timestamp string                 `json:"@timestamp"`
+	Version   string                 `json:"@version"`
+	Message   string                 `json:"message"`
+	Level     string                 `json:"level"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogstashFormat formats records as JSON lines in the shape of Logstash events, with the
+// @timestamp, @version, message and level fields and the key values nested in fields, for
+// pipelines expecting that shape rather than the flat key values of the json format.
+func LogstashFormat() log15.Format {
+	return log15.FormatFunc(func(r *log15.Record) []byte {
+		record := logstashRecord{
+			Timestamp: r.Time.UTC().Format(logstashTimeLayout),
+			Version:   "1",
+			Message:   r.Msg,
+			Level:     levelNames[r.Lvl],
+		}
+
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			key, ok := r.Ctx[i].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[i])
+			}
+			if record.Fields == nil {
+				record.Fields = map[string]interface{}{}
+			}
+			record.Fields[key] = otelJSONValue(r.Ctx[i+1])
+		}
+
+		b, err := json.Marshal(record)
+		if err != nil {
+			b, _ = json.Marshal(logstashRecord{
+				Timestamp: record.Timestamp,
+				Version:   record.Version,
+				Message:   "Failed to format log record as JSON: " + err.Error(),
+				Level:     levelNames[log15.LvlError],
+			})
+		}
+		return append(b, '\n')
+	})
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogstashFormat(t *testing.T) {
+	format := LogstashFormat()
+
+	t.Run("Key values are nested in fields", func(t *testing.T) {
+		line := format.Format(&log15.Record{
+			Time: time.Date(2021, 3, 10, 13, 0, 0, 250000000, time.FixedZone("CET", 3600)),
+			Lvl:  log15.LvlWarn,
+			Msg:  "Query failed",
+			Ctx:  []interface{}{"logger", "tsdb", "orgId", 1, "error", errors.New("timeout"), "duration", time.Second},
+		})
+
+		assert.JSONEq(t, `{
+			"@timestamp": "2021-03-10T12:00:00.250Z",
+			"@version": "1",
+			"message": "Query failed",
+			"level": "warn",
+			"fields": {"logger": "tsdb", "orgId": 1, "error": "timeout", "duration": "1s"}
+		}`, string(line))
+	})
+
+	t.Run("Records without key values have no fields", func(t *testing.T) {
+		line := format.Format(&log15.Record{Time: time.Unix(0, 0), Lvl: log15.LvlInfo, Msg: "Started"})
+
+		assert.Equal(t, `{"@timestamp":"1970-01-01T00:00:00.000Z","@version":"1","message":"Started","level":"info"}`+"\n", string(line))
+	})
+}
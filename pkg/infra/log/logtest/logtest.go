@@ -0,0 +1,95 @@
+// Package logtest provides a logger recording the messages logged with it, to assert on them in
+// unit tests of services.
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/inconshreveable/log15"
+)
+
+// Entry is a message logged with a Logger.
+type Entry struct {
+	Level   log.Lvl
+	Message string
+	// Ctx are the key values of the message, including the context of the logger.
+	Ctx []interface{}
+}
+
+// Value returns the value of a key of the entry, and whether the entry has the key.
+func (e Entry) Value(key string) (interface{}, bool) {
+	for i := 0; i < len(e.Ctx)-1; i += 2 {
+		if k, ok := e.Ctx[i].(string); ok && k == key {
+			return e.Ctx[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// Logger is a log.Logger recording the messages logged with it, and with the loggers created with
+// its New method.
+type Logger struct {
+	log15.Logger
+	recorder *recorder
+}
+
+// New returns a Logger with the given context, like log.New would.
+func New(ctx ...interface{}) *Logger {
+	r := &recorder{}
+	logger := log15.New(ctx...)
+	logger.SetHandler(r)
+	return &Logger{Logger: logger, recorder: r}
+}
+
+// Entries returns the recorded messages, in the order they were logged.
+func (l *Logger) Entries() []Entry {
+	l.recorder.mu.Lock()
+	defer l.recorder.mu.Unlock()
+	return append([]Entry{}, l.recorder.entries...)
+}
+
+// HasMessage reports whether a message of the given level that contains substr was logged.
+func (l *Logger) HasMessage(level log.Lvl, substr string) bool {
+	for _, e := range l.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset discards the recorded messages.
+func (l *Logger) Reset() {
+	l.recorder.mu.Lock()
+	defer l.recorder.mu.Unlock()
+	l.recorder.entries = nil
+}
+
+// String returns the recorded messages, one per line, to be printed by failing assertions.
+func (l *Logger) String() string {
+	var b strings.Builder
+	for _, e := range l.Entries() {
+		fmt.Fprintf(&b, "%d %s %v\n", e.Level, e.Message, e.Ctx)
+	}
+	return b.String()
+}
+
+// recorder is the handler recording the messages of a Logger.
+type recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (r *recorder) Log(record *log15.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, Entry{
+		Level:   log.Lvl(record.Lvl),
+		Message: record.Msg,
+		Ctx:     append([]interface{}{}, record.Ctx...),
+	})
+	return nil
+}
@@ -0,0 +1,30 @@
+package logtest
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	var logger log.Logger = New("logger", "test")
+	fake := logger.(*Logger)
+
+	logger.Info("Server started", "port", 3000)
+	logger.New("user", "admin").Error("Login failed")
+
+	entries := fake.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, Entry{Level: log.LvlInfo, Message: "Server started", Ctx: []interface{}{"logger", "test", "port", 3000}}, entries[0])
+	user, ok := entries[1].Value("user")
+	require.True(t, ok)
+	assert.Equal(t, "admin", user)
+
+	assert.True(t, fake.HasMessage(log.LvlError, "Login"))
+	assert.False(t, fake.HasMessage(log.LvlInfo, "Login"))
+
+	fake.Reset()
+	assert.Empty(t, fake.Entries())
+}
@@ -0,0 +1,171 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// lokiStream is a stream of the Loki push API.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiPushRequest is the JSON body of the Loki push API.
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+// LokiHandler pushes log records to Loki in batches. Records are labeled with the logger and level
+// they are logged with, and records that don't fit in the buffer while Loki is unavailable are
+// dropped so logging never blocks.
+type LokiHandler struct {
+	BatchSettings
+	URL      string
+	Username string
+	Password string
+	TenantID string
+	Labels   map[string]string
+	Format   log15.Format
+
+	client  *http.Client
+	batcher *recordBatcher
+}
+
+// NewLokiHandler creates a LokiHandler from the settings of the `log.loki` section.
+func NewLokiHandler(sec *ini.Section, format log15.Format) (*LokiHandler, error) {
+	labels, err := parseKeyValues("labels", sec.Key("labels").MustString(""))
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &LokiHandler{
+		BatchSettings: readBatchSettings(sec),
+		URL:           sec.Key("url").MustString(""),
+		Username:      sec.Key("username").MustString(""),
+		Password:      sec.Key("password").MustString(""),
+		TenantID:      sec.Key("tenant_id").MustString(""),
+		Labels:        labels,
+		Format:        format,
+	}
+
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init validates the settings of the handler and starts pushing records.
+func (h *LokiHandler) Init() error {
+	if h.URL == "" {
+		return errors.New("url is required to push logs to Loki")
+	}
+	if err := h.BatchSettings.validate(); err != nil {
+		return err
+	}
+	if h.Format == nil {
+		h.Format = log15.LogfmtFormat()
+	}
+
+	h.client = &http.Client{Timeout: h.Timeout}
+	h.batcher = newRecordBatcher(h.BatchSettings, "Loki", h.push, isRetryableHTTPPushError)
+	return nil
+}
+
+// Log queues a record to be pushed to Loki.
+func (h *LokiHandler) Log(r *log15.Record) error {
+	return h.batcher.queue(r)
+}
+
+// Close pushes the queued records, and stops the handler.
+func (h *LokiHandler) Close() error {
+	return h.CloseContext(context.Background())
+}
+
+// CloseContext pushes the queued records until ctx is done, and stops the handler. The records that
+// aren't pushed by then are dropped.
+func (h *LokiHandler) CloseContext(ctx context.Context) error {
+	return h.batcher.close(ctx)
+}
+
+func (h *LokiHandler) push(batch []*log15.Record) error {
+	body, err := json.Marshal(h.encodeBatch(batch))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.Username != "" || h.Password != "" {
+		req.SetBasicAuth(h.Username, h.Password)
+	}
+	if h.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", h.TenantID)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return httpPushError{statusCode: resp.StatusCode, body: strings.TrimSpace(string(msg))}
+	}
+	return nil
+}
+
+// encodeBatch groups the records of a batch by their labels into streams, keeping the order of
+// the records within each stream.
+func (h *LokiHandler) encodeBatch(batch []*log15.Record) lokiPushRequest {
+	req := lokiPushRequest{Streams: []lokiStream{}}
+	streams := map[string]int{}
+	for _, r := range batch {
+		labels := h.recordLabels(r)
+		key := lokiLabelsKey(labels)
+		i, ok := streams[key]
+		if !ok {
+			i = len(req.Streams)
+			streams[key] = i
+			req.Streams = append(req.Streams, lokiStream{Stream: labels})
+		}
+		line := strings.TrimSuffix(string(h.Format.Format(r)), "\n")
+		req.Streams[i].Values = append(req.Streams[i].Values, [2]string{strconv.FormatInt(r.Time.UnixNano(), 10), line})
+	}
+	return req
+}
+
+// recordLabels returns the labels of the stream of a record.
+func (h *LokiHandler) recordLabels(r *log15.Record) map[string]string {
+	labels := make(map[string]string, len(h.Labels)+2)
+	for k, v := range h.Labels {
+		labels[k] = v
+	}
+	labels["level"] = levelNames[r.Lvl]
+	if name, ok := recordLogger(r); ok {
+		labels["logger"] = name
+	}
+	return labels
+}
+
+func lokiLabelsKey(labels map[string]string) string {
+	// Sorted JSON object keys make the encoding a stable key of the label set.
+	key, _ := json.Marshal(labels)
+	return string(key)
+}
@@ -0,0 +1,159 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+type fakeLoki struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	pushes   []lokiPushRequest
+	statuses []int
+}
+
+func (f *fakeLoki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r)
+	if len(f.statuses) > 0 {
+		status := f.statuses[0]
+		f.statuses = f.statuses[1:]
+		if status != http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
+	var push lokiPushRequest
+	if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.pushes = append(f.pushes, push)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newLokiTestHandler(t *testing.T, loki *fakeLoki, config string) *LokiHandler {
+	t.Helper()
+
+	server := httptest.NewServer(loki)
+	t.Cleanup(server.Close)
+
+	cfg, err := ini.Load([]byte("[log.loki]\nurl = " + server.URL + "\n" + config))
+	require.NoError(t, err)
+	handler, err := NewLokiHandler(cfg.Section("log.loki"), log15.LogfmtFormat())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func lokiTestRecord(lvl log15.Lvl, logger string, msg string) *log15.Record {
+	return &log15.Record{
+		Time: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC),
+		Lvl:  lvl,
+		Msg:  msg,
+		Ctx:  []interface{}{"logger", logger},
+		KeyNames: log15.RecordKeyNames{
+			Time: "t",
+			Msg:  "msg",
+			Lvl:  "lvl",
+		},
+	}
+}
+
+func TestLokiHandler(t *testing.T) {
+	t.Run("Records are pushed in batches with labels of their logger and level", func(t *testing.T) {
+		loki := &fakeLoki{}
+		handler := newLokiTestHandler(t, loki, "batch_size = 3\nbatch_wait = 1h\nlabels = job:grafana, env:test\ntenant_id = tenant\nusername = user\npassword = pass")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlError, "sqlstore", "second")))
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "third")))
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "server", "fourth")))
+		require.NoError(t, handler.Close())
+
+		require.Len(t, loki.pushes, 2)
+		first := loki.pushes[0]
+		require.Len(t, first.Streams, 2)
+		assert.Equal(t, map[string]string{"job": "grafana", "env": "test", "logger": "sqlstore", "level": "info"}, first.Streams[0].Stream)
+		require.Len(t, first.Streams[0].Values, 2)
+		assert.Equal(t, "1615377600000000000", first.Streams[0].Values[0][0])
+		assert.Contains(t, first.Streams[0].Values[0][1], "msg=first")
+		assert.Contains(t, first.Streams[0].Values[1][1], "msg=third")
+		assert.Equal(t, "error", first.Streams[1].Stream["level"])
+
+		// The last record is pushed when the handler is closed.
+		require.Len(t, loki.pushes[1].Streams, 1)
+		assert.Equal(t, "server", loki.pushes[1].Streams[0].Stream["logger"])
+
+		req := loki.requests[0]
+		assert.Equal(t, "tenant", req.Header.Get("X-Scope-OrgID"))
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+	})
+
+	t.Run("Records are pushed after the batch wait", func(t *testing.T) {
+		loki := &fakeLoki{}
+		handler := newLokiTestHandler(t, loki, "batch_wait = 10ms")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.Eventually(t, func() bool {
+			loki.mu.Lock()
+			defer loki.mu.Unlock()
+			return len(loki.pushes) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Pushes are retried on server errors", func(t *testing.T) {
+		loki := &fakeLoki{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests}}
+		handler := newLokiTestHandler(t, loki, "batch_size = 1\nbatch_wait = 1h\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.Eventually(t, func() bool {
+			loki.mu.Lock()
+			defer loki.mu.Unlock()
+			return len(loki.pushes) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, handler.Close())
+
+		assert.Len(t, loki.requests, 3)
+	})
+
+	t.Run("Pushes rejected by Loki are not retried", func(t *testing.T) {
+		loki := &fakeLoki{statuses: []int{http.StatusBadRequest}}
+		handler := newLokiTestHandler(t, loki, "batch_size = 1\nbatch_wait = 1h\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.NoError(t, handler.Close())
+
+		assert.Len(t, loki.requests, 1)
+		assert.Empty(t, loki.pushes)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.loki]\nlabels = job"))
+		require.NoError(t, err)
+
+		_, err = NewLokiHandler(cfg.Section("log.loki"), log15.LogfmtFormat())
+		require.Error(t, err)
+
+		cfg.Section("log.loki").Key("labels").SetValue("job:grafana")
+		_, err = NewLokiHandler(cfg.Section("log.loki"), log15.LogfmtFormat())
+		require.EqualError(t, err, "url is required to push logs to Loki")
+	})
+}
@@ -0,0 +1,181 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// matchExpression matches the expressions of matchers, like `http.server:status<500`, of an
+// optional logger, a key, an operator and a value.
+var matchExpression = regexp.MustCompile(`^(?:([^:=<>!]+):)?([^:=<>!]+)(=|!=|<=|>=|<|>)(.*)$`)
+
+// Matcher matches the records of loggers by the value of a key.
+type Matcher struct {
+	// Logger is the name or glob pattern of the loggers the matcher applies to, or empty for all
+	// loggers.
+	Logger   string
+	Key      string
+	Operator string
+	Value    string
+}
+
+// getMatchers parses the expressions of matchers, like `http.server:status<500` or `orgId=1`.
+// Values are compared as numbers or durations if both are, and only equality is supported for
+// other values.
+func getMatchers(expressions []string) ([]Matcher, error) {
+	matchers := make([]Matcher, 0, len(expressions))
+	for _, expression := range expressions {
+		parts := matchExpression.FindStringSubmatch(expression)
+		if parts == nil {
+			return nil, fmt.Errorf("invalid matcher %q, matchers must be given as [logger:]key<operator>value with an operator of =, !=, <, <=, > or >=", expression)
+		}
+		if _, err := path.Match(parts[1], ""); err != nil {
+			return nil, fmt.Errorf("invalid logger pattern of matcher %q: %w", expression, err)
+		}
+		matchers = append(matchers, Matcher{Logger: parts[1], Key: parts[2], Operator: parts[3], Value: parts[4]})
+	}
+	return matchers, nil
+}
+
+// appliesTo reports whether the matcher applies to the records of a logger.
+func (m Matcher) appliesTo(loggerName string) bool {
+	return m.Logger == "" || matchLogger([]string{m.Logger}, loggerName)
+}
+
+// Match reports whether the value of the key of a record matches. Records without the key don't
+// match.
+func (m Matcher) Match(r *log15.Record) bool {
+	for i := 0; i < len(r.Ctx)-1; i += 2 {
+		if key, ok := r.Ctx[i].(string); ok && key == m.Key {
+			return m.matchValue(r.Ctx[i+1])
+		}
+	}
+	return false
+}
+
+func (m Matcher) matchValue(value interface{}) bool {
+	if cmp, ok := compareMatchValues(value, m.Value); ok {
+		switch m.Operator {
+		case "=":
+			return cmp == 0
+		case "!=":
+			return cmp != 0
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		}
+	}
+
+	s := fmt.Sprint(otelJSONValue(value))
+	switch m.Operator {
+	case "=":
+		return s == m.Value
+	case "!=":
+		return s != m.Value
+	default:
+		return false
+	}
+}
+
+// compareMatchValues compares the value of a record with the value of a matcher, as numbers or
+// durations. It returns false if they aren't both numbers or durations.
+func compareMatchValues(value interface{}, matchValue string) (int, bool) {
+	if d, ok := value.(time.Duration); ok {
+		other, err := time.ParseDuration(matchValue)
+		if err != nil {
+			return 0, false
+		}
+		return compareFloats(float64(d), float64(other)), true
+	}
+
+	var f float64
+	switch v := value.(type) {
+	case int:
+		f = float64(v)
+	case int8:
+		f = float64(v)
+	case int16:
+		f = float64(v)
+	case int32:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case uint:
+		f = float64(v)
+	case uint8:
+		f = float64(v)
+	case uint16:
+		f = float64(v)
+	case uint32:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case float32:
+		f = float64(v)
+	case float64:
+		f = v
+	case string:
+		var err error
+		if f, err = strconv.ParseFloat(v, 64); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	other, err := strconv.ParseFloat(matchValue, 64)
+	if err != nil {
+		return 0, false
+	}
+	return compareFloats(f, other), true
+}
+
+func compareFloats(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MatchFilterHandler drops the records matching any of the drop matchers that apply to their
+// logger. Of the loggers any keep matchers apply to, only records matching one of them are passed
+// on to h.
+func MatchFilterHandler(drop []Matcher, keep []Matcher, h log15.Handler) log15.Handler {
+	if len(drop) == 0 && len(keep) == 0 {
+		return h
+	}
+	return log15.FilterHandler(func(r *log15.Record) bool {
+		loggerName, _ := recordLogger(r)
+		for _, m := range drop {
+			if m.appliesTo(loggerName) && m.Match(r) {
+				return false
+			}
+		}
+
+		kept, applied := false, false
+		for _, m := range keep {
+			if !m.appliesTo(loggerName) {
+				continue
+			}
+			applied = true
+			if m.Match(r) {
+				kept = true
+				break
+			}
+		}
+		return kept || !applied
+	}, h)
+}
@@ -0,0 +1,104 @@
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestMatchFilterHandler(t *testing.T) {
+	logRecords := func(t *testing.T, drop []string, keep []string, records ...[]interface{}) []string {
+		t.Helper()
+		dropMatchers, err := getMatchers(drop)
+		require.NoError(t, err)
+		keepMatchers, err := getMatchers(keep)
+		require.NoError(t, err)
+
+		var messages []string
+		h := MatchFilterHandler(dropMatchers, keepMatchers, log15.FuncHandler(func(r *log15.Record) error {
+			messages = append(messages, r.Msg)
+			return nil
+		}))
+		for i, ctx := range records {
+			require.NoError(t, h.Log(&log15.Record{Msg: string(rune('a' + i)), Ctx: ctx}))
+		}
+		return messages
+	}
+
+	t.Run("Records matching drop matchers of their logger are dropped", func(t *testing.T) {
+		messages := logRecords(t, []string{"http.server:status<500", "duration<=10ms"}, nil,
+			[]interface{}{"logger", "http.server", "status", 200},
+			[]interface{}{"logger", "http.server", "status", "503"},
+			[]interface{}{"logger", "http.server"},
+			[]interface{}{"logger", "proxy", "status", 200},
+			[]interface{}{"logger", "tsdb", "duration", 5 * time.Millisecond},
+			[]interface{}{"logger", "tsdb", "duration", time.Second},
+		)
+
+		assert.Equal(t, []string{"b", "c", "d", "f"}, messages)
+	})
+
+	t.Run("Only records matching keep matchers of their logger are kept", func(t *testing.T) {
+		messages := logRecords(t, nil, []string{"orgId=1", "orgId=2", "context:uname!=admin"},
+			[]interface{}{"logger", "tsdb", "orgId", 1},
+			[]interface{}{"logger", "tsdb", "orgId", int64(2)},
+			[]interface{}{"logger", "tsdb", "orgId", 3},
+			[]interface{}{"logger", "tsdb"},
+			[]interface{}{"logger", "context", "uname", "admin"},
+			[]interface{}{"logger", "context", "uname", "editor"},
+		)
+
+		assert.Equal(t, []string{"a", "b", "f"}, messages)
+	})
+
+	t.Run("Keep matchers only apply to the records of their loggers", func(t *testing.T) {
+		messages := logRecords(t, nil, []string{"tsdb.*:orgId=1"},
+			[]interface{}{"logger", "tsdb.prometheus", "orgId", 2},
+			[]interface{}{"logger", "tsdb.loki", "orgId", 1},
+			[]interface{}{"logger", "sqlstore", "orgId", 2},
+		)
+
+		assert.Equal(t, []string{"b", "c"}, messages)
+	})
+
+	t.Run("Invalid matchers are rejected", func(t *testing.T) {
+		_, err := getMatchers([]string{"status"})
+		require.EqualError(t, err, `invalid matcher "status", matchers must be given as [logger:]key<operator>value with an operator of =, !=, <, <=, > or >=`)
+
+		_, err = getMatchers([]string{"[:status=1"})
+		require.Error(t, err)
+	})
+}
+
+func TestMatchFilterConfig(t *testing.T) {
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	dir := t.TempDir()
+	cfg, err := ini.Load([]byte("[log]\nmode = file\nrecent_buffer_size = 0\ndrop_matching = http.server:status<500\n" +
+		"[log.file]\nfile_name = " + filepath.Join(dir, "grafana.log")))
+	require.NoError(t, err)
+	require.NoError(t, ReadLoggingConfig([]string{"file"}, dir, cfg))
+
+	New("http.server").Info("Request completed", "status", 200)
+	New("http.server").Info("Request failed", "status", 502)
+	require.NoError(t, Close())
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "grafana.log"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "Request completed")
+	assert.Contains(t, string(b), "Request failed")
+
+	cfg, err = ini.Load([]byte("[log]\nmode = file\n[log.file]\nkeep_matching = orgId~1\nfile_name = " + filepath.Join(dir, "grafana.log")))
+	require.NoError(t, err)
+	require.EqualError(t, ReadLoggingConfig([]string{"file"}, dir, cfg),
+		`failed to read keep_matching of log.file: invalid matcher "orgId~1", matchers must be given as [logger:]key<operator>value with an operator of =, !=, <, <=, > or >=`)
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"sync"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	logMessagesTotal    *prometheus.CounterVec
+	logWriteErrorsTotal *prometheus.CounterVec
+)
+
+func init() {
+	logMessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "log_messages_total",
+		Help:      "Number of log messages by level and logger, including messages below the level of all modes",
+	}, []string{"level", "logger"})
+
+	logWriteErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "log_write_errors_total",
+		Help:      "Number of log messages that failed to be written, or were dropped, by mode",
+	}, []string{"handler"})
+
+	prometheus.MustRegister(logMessagesTotal, logWriteErrorsTotal)
+}
+
+// messageCounterKey is the key of the counters of log messages of a level and logger.
+type messageCounterKey struct {
+	lvl    log15.Lvl
+	logger string
+}
+
+// messageCounters caches the counters of logMessagesTotal, since looking them up by their labels
+// allocates on every record.
+var messageCounters sync.Map
+
+// countingHandler counts records by level and logger before passing them on to h.
+func countingHandler(h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		logger, _ := recordLogger(r)
+		key := messageCounterKey{lvl: r.Lvl, logger: logger}
+		counter, ok := messageCounters.Load(key)
+		if !ok {
+			counter, _ = messageCounters.LoadOrStore(key, logMessagesTotal.WithLabelValues(levelNames[r.Lvl], logger))
+		}
+		counter.(prometheus.Counter).Inc()
+		return h.Log(r)
+	})
+}
+
+// writeErrorsHandler counts the errors of the handler of a mode.
+func writeErrorsHandler(mode string, h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		err := h.Log(r)
+		if err != nil {
+			logWriteErrorsTotal.WithLabelValues(mode).Inc()
+		}
+		return err
+	})
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMetrics(t *testing.T) {
+	t.Run("Messages are counted by level and logger", func(t *testing.T) {
+		counter := logMessagesTotal.WithLabelValues("error", "metrics.test")
+		before := testutil.ToFloat64(counter)
+
+		handler := countingHandler(log15.DiscardHandler())
+		for i := 0; i < 2; i++ {
+			require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlError, Msg: "Failed", Ctx: []interface{}{"logger", "metrics.test"}}))
+		}
+
+		assert.Equal(t, before+2, testutil.ToFloat64(counter))
+	})
+
+	t.Run("Write errors are counted by mode", func(t *testing.T) {
+		counter := logWriteErrorsTotal.WithLabelValues("metrics.test")
+		before := testutil.ToFloat64(counter)
+
+		handler := writeErrorsHandler("metrics.test", log15.FuncHandler(func(r *log15.Record) error {
+			if r.Msg == "fail" {
+				return errors.New("disk full")
+			}
+			return nil
+		}))
+		require.NoError(t, handler.Log(&log15.Record{Msg: "ok"}))
+		require.Error(t, handler.Log(&log15.Record{Msg: "fail"}))
+
+		assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	})
+}
@@ -0,0 +1,311 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// msgpackEncoder encodes the MessagePack values of the Fluentd forward protocol.
+type msgpackEncoder struct {
+	bytes.Buffer
+}
+
+func (e *msgpackEncoder) writeNil() {
+	e.WriteByte(0xc0)
+}
+
+func (e *msgpackEncoder) writeBool(v bool) {
+	if v {
+		e.WriteByte(0xc3)
+	} else {
+		e.WriteByte(0xc2)
+	}
+}
+
+func (e *msgpackEncoder) writeInt(v int64) {
+	switch {
+	case v >= 0:
+		e.writeUint(uint64(v))
+	case v >= -32:
+		e.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		e.WriteByte(0xd0)
+		e.WriteByte(byte(v))
+	case v >= math.MinInt16:
+		e.WriteByte(0xd1)
+		_ = binary.Write(e, binary.BigEndian, int16(v))
+	case v >= math.MinInt32:
+		e.WriteByte(0xd2)
+		_ = binary.Write(e, binary.BigEndian, int32(v))
+	default:
+		e.WriteByte(0xd3)
+		_ = binary.Write(e, binary.BigEndian, v)
+	}
+}
+
+func (e *msgpackEncoder) writeUint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		e.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		e.WriteByte(0xcc)
+		e.WriteByte(byte(v))
+	case v <= math.MaxUint16:
+		e.WriteByte(0xcd)
+		_ = binary.Write(e, binary.BigEndian, uint16(v))
+	case v <= math.MaxUint32:
+		e.WriteByte(0xce)
+		_ = binary.Write(e, binary.BigEndian, uint32(v))
+	default:
+		e.WriteByte(0xcf)
+		_ = binary.Write(e, binary.BigEndian, v)
+	}
+}
+
+func (e *msgpackEncoder) writeFloat(v float64) {
+	e.WriteByte(0xcb)
+	_ = binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *msgpackEncoder) writeString(v string) {
+	n := len(v)
+	switch {
+	case n <= 31:
+		e.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.WriteByte(0xd9)
+		e.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		e.WriteByte(0xda)
+		_ = binary.Write(e, binary.BigEndian, uint16(n))
+	default:
+		e.WriteByte(0xdb)
+		_ = binary.Write(e, binary.BigEndian, uint32(n))
+	}
+	e.WriteString(v)
+}
+
+func (e *msgpackEncoder) writeArrayLen(n int) {
+	switch {
+	case n <= 15:
+		e.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		e.WriteByte(0xdc)
+		_ = binary.Write(e, binary.BigEndian, uint16(n))
+	default:
+		e.WriteByte(0xdd)
+		_ = binary.Write(e, binary.BigEndian, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) writeMapLen(n int) {
+	switch {
+	case n <= 15:
+		e.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		e.WriteByte(0xde)
+		_ = binary.Write(e, binary.BigEndian, uint16(n))
+	default:
+		e.WriteByte(0xdf)
+		_ = binary.Write(e, binary.BigEndian, uint32(n))
+	}
+}
+
+// writeEventTime writes a time as the EventTime extension type of the forward protocol, which has
+// nanosecond precision.
+func (e *msgpackEncoder) writeEventTime(t time.Time) {
+	e.WriteByte(0xd7) // fixext 8
+	e.WriteByte(0x00) // EventTime
+	_ = binary.Write(e, binary.BigEndian, uint32(t.Unix()))
+	_ = binary.Write(e, binary.BigEndian, uint32(t.Nanosecond()))
+}
+
+// writeValue writes a key value of a log record. Values without a MessagePack type are written as
+// strings.
+func (e *msgpackEncoder) writeValue(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.writeNil()
+	case string:
+		e.writeString(v)
+	case bool:
+		e.writeBool(v)
+	case int:
+		e.writeInt(int64(v))
+	case int32:
+		e.writeInt(int64(v))
+	case int64:
+		e.writeInt(v)
+	case uint:
+		e.writeUint(uint64(v))
+	case uint32:
+		e.writeUint(uint64(v))
+	case uint64:
+		e.writeUint(v)
+	case float32:
+		e.writeFloat(float64(v))
+	case float64:
+		e.writeFloat(v)
+	case time.Duration:
+		e.writeString(v.String())
+	case time.Time:
+		e.writeString(v.Format(time.RFC3339Nano))
+	case error:
+		e.writeString(v.Error())
+	default:
+		e.writeString(fmt.Sprintf("%+v", v))
+	}
+}
+
+// msgpackDecoder decodes the MessagePack values of the Fluentd forward protocol. Strings and
+// binaries are decoded as strings, integers as int64, arrays as []interface{} and maps as
+// map[string]interface{}.
+type msgpackDecoder struct {
+	r io.Reader
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(d.r, buf)
+	return buf, err
+}
+
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	buf, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) readValue() (interface{}, error) {
+	head, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	b := head[0]
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xe0 == 0xa0:
+		return d.readString(int(b & 0x1f))
+	case b&0xf0 == 0x90:
+		return d.readArray(int(b & 0x0f))
+	case b&0xf0 == 0x80:
+		return d.readMap(int(b & 0x0f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.readUint(1 << (b - 0xcc))
+		return int64(v), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		v, err := d.readUint(size)
+		shift := uint(64 - 8*size)
+		return int64(v<<shift) >> shift, err
+	case 0xca:
+		v, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.readUint(8)
+		return math.Float64frombits(v), err
+	case 0xc4, 0xd9:
+		return d.readLenAndString(1)
+	case 0xc5, 0xda:
+		return d.readLenAndString(2)
+	case 0xc6, 0xdb:
+		return d.readLenAndString(4)
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.readArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.readMap(int(n))
+	case 0xd7:
+		return d.readEventTime()
+	default:
+		return nil, fmt.Errorf("unsupported MessagePack type 0x%x", b)
+	}
+}
+
+// readEventTime reads the fixext 8 EventTime extension type, the only extension type supported.
+func (d *msgpackDecoder) readEventTime() (time.Time, error) {
+	buf, err := d.read(9)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if buf[0] != 0x00 {
+		return time.Time{}, fmt.Errorf("unsupported MessagePack extension type %d", int8(buf[0]))
+	}
+	sec := binary.BigEndian.Uint32(buf[1:5])
+	nsec := binary.BigEndian.Uint32(buf[5:])
+	return time.Unix(int64(sec), int64(nsec)), nil
+}
+
+func (d *msgpackDecoder) readLenAndString(size int) (string, error) {
+	n, err := d.readUint(size)
+	if err != nil {
+		return "", err
+	}
+	return d.readString(int(n))
+}
+
+func (d *msgpackDecoder) readString(n int) (string, error) {
+	buf, err := d.read(n)
+	return string(buf), err
+}
+
+func (d *msgpackDecoder) readArray(n int) ([]interface{}, error) {
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func (d *msgpackDecoder) readMap(n int) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, errors.New("MessagePack map keys must be strings")
+		}
+		if values[key], err = d.readValue(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// The policies of the multiline setting of modes, for messages and values spanning multiple lines.
+const (
+	// multilineKeep writes them as they are.
+	multilineKeep = "keep"
+	// multilineEscape replaces line breaks with `\n`.
+	multilineEscape = "escape"
+	// multilineFold joins the trimmed non-empty lines with ` | `.
+	multilineFold = "fold"
+)
+
+// readMultilinePolicy reads the multiline setting of a mode section.
+func readMultilinePolicy(sec *ini.Section) (string, error) {
+	policy := sec.Key("multiline").MustString(multilineKeep)
+	switch policy {
+	case multilineKeep, multilineEscape, multilineFold:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown multiline policy %q, valid options are keep, escape and fold", policy)
+	}
+}
+
+// MultilineHandler escapes or folds messages and values spanning multiple lines, like stack traces
+// and SQL queries, according to the policy, before passing records on to h. It keeps log
+// collectors from splitting a record into many.
+func MultilineHandler(policy string, h log15.Handler) log15.Handler {
+	var convert func(s string) string
+	switch policy {
+	case multilineEscape:
+		convert = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace
+	case multilineFold:
+		convert = foldLines
+	default:
+		return h
+	}
+
+	return log15.FuncHandler(func(r *log15.Record) error {
+		converted := *r
+		converted.Msg = convertMultiline(r.Msg, convert)
+		converted.Ctx = make([]interface{}, len(r.Ctx))
+		copy(converted.Ctx, r.Ctx)
+
+		for i := 1; i < len(converted.Ctx); i += 2 {
+			switch v := converted.Ctx[i].(type) {
+			case string:
+				converted.Ctx[i] = convertMultiline(v, convert)
+			case error:
+				// Errors are only replaced by their message when it spans multiple lines.
+				if s := convertMultiline(v.Error(), convert); s != v.Error() {
+					converted.Ctx[i] = s
+				}
+			case fmt.Stringer:
+				if s := convertMultiline(v.String(), convert); s != v.String() {
+					converted.Ctx[i] = s
+				}
+			}
+		}
+
+		return h.Log(&converted)
+	})
+}
+
+func convertMultiline(s string, convert func(string) string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	return convert(s)
+}
+
+// foldLines joins the trimmed non-empty lines of s with ` | `.
+func foldLines(s string) string {
+	lines := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\n' || r == '\r'
+	})
+	folded := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			folded = append(folded, line)
+		}
+	}
+	return strings.Join(folded, " | ")
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestMultilineHandler(t *testing.T) {
+	newHandler := func(t *testing.T, config string) (log15.Handler, *[]*log15.Record) {
+		t.Helper()
+		cfg, err := ini.Load([]byte("[log.file]\n" + config))
+		require.NoError(t, err)
+		policy, err := readMultilinePolicy(cfg.Section("log.file"))
+		require.NoError(t, err)
+
+		var logged []*log15.Record
+		return MultilineHandler(policy, log15.FuncHandler(func(r *log15.Record) error {
+			logged = append(logged, r)
+			return nil
+		})), &logged
+	}
+	newRecord := func() *log15.Record {
+		return &log15.Record{Msg: "Query failed\nretrying", Ctx: []interface{}{
+			"sql", "SELECT *\r\n  FROM dashboard\n\n  WHERE id = 1", "error", errors.New("locked\n  at sqlite"), "id", 1,
+		}}
+	}
+
+	t.Run("Line breaks are escaped", func(t *testing.T) {
+		handler, logged := newHandler(t, "multiline = escape")
+		record := newRecord()
+		require.NoError(t, handler.Log(record))
+
+		require.Len(t, *logged, 1)
+		assert.Equal(t, `Query failed\nretrying`, (*logged)[0].Msg)
+		assert.Equal(t, []interface{}{
+			"sql", `SELECT *\n  FROM dashboard\n\n  WHERE id = 1`, "error", `locked\n  at sqlite`, "id", 1,
+		}, (*logged)[0].Ctx)
+		assert.Equal(t, newRecord().Ctx[1], record.Ctx[1])
+	})
+
+	t.Run("Lines are folded", func(t *testing.T) {
+		handler, logged := newHandler(t, "multiline = fold")
+		require.NoError(t, handler.Log(newRecord()))
+
+		require.Len(t, *logged, 1)
+		assert.Equal(t, "Query failed | retrying", (*logged)[0].Msg)
+		assert.Equal(t, []interface{}{
+			"sql", "SELECT * | FROM dashboard | WHERE id = 1", "error", "locked | at sqlite", "id", 1,
+		}, (*logged)[0].Ctx)
+	})
+
+	t.Run("Records are kept by default", func(t *testing.T) {
+		handler, logged := newHandler(t, "")
+		record := newRecord()
+		require.NoError(t, handler.Log(record))
+
+		require.Len(t, *logged, 1)
+		assert.Same(t, record, (*logged)[0])
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.file]\nmultiline = join"))
+		require.NoError(t, err)
+		_, err = readMultilinePolicy(cfg.Section("log.file"))
+		require.EqualError(t, err, `unknown multiline policy "join", valid options are keep, escape and fold`)
+	})
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// NetworkHandler streams log records as lines to a remote collector over TCP or UDP. Records are
+// written in batches, and are buffered while the collector is disconnected, reconnecting with an
+// exponential backoff.
+type NetworkHandler struct {
+	BatchSettings
+	// Address is the host:port address of the collector.
+	Address string
+	// Protocol is either "tcp" or "udp". Over UDP each record is written as a datagram.
+	Protocol string
+	Format   log15.Format
+
+	batcher *recordBatcher
+
+	// The connection, only used by the batcher.
+	conn net.Conn
+}
+
+// NewNetworkHandler creates a NetworkHandler from the settings of the `log.network` section.
+func NewNetworkHandler(sec *ini.Section, format log15.Format) (*NetworkHandler, error) {
+	handler := &NetworkHandler{
+		BatchSettings: readBatchSettings(sec),
+		Address:       sec.Key("address").MustString(""),
+		Protocol:      sec.Key("protocol").MustString("tcp"),
+		Format:        format,
+	}
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init validates the settings of the handler and starts writing records.
+func (h *NetworkHandler) Init() error {
+	if h.Address == "" {
+		return errors.New("address is required to stream logs over the network")
+	}
+	if h.Protocol != "tcp" && h.Protocol != "udp" {
+		return fmt.Errorf("unknown network protocol %q, valid options are tcp and udp", h.Protocol)
+	}
+	if err := h.BatchSettings.validate(); err != nil {
+		return err
+	}
+	if h.Format == nil {
+		h.Format = log15.LogfmtFormat()
+	}
+
+	h.batcher = newRecordBatcher(h.BatchSettings, "network", h.write, func(error) bool {
+		return true
+	})
+	return nil
+}
+
+// Log queues a record to be written to the collector.
+func (h *NetworkHandler) Log(r *log15.Record) error {
+	return h.batcher.queue(r)
+}
+
+// Close writes the queued records, and stops the handler.
+func (h *NetworkHandler) Close() error {
+	return h.CloseContext(context.Background())
+}
+
+// CloseContext writes the queued records until ctx is done, and stops the handler. The records that
+// aren't written by then are dropped.
+func (h *NetworkHandler) CloseContext(ctx context.Context) error {
+	err := h.batcher.close(ctx)
+	h.closeConn()
+	return err
+}
+
+func (h *NetworkHandler) write(batch []*log15.Record) error {
+	if h.conn == nil {
+		conn, err := net.DialTimeout(h.Protocol, h.Address, h.Timeout)
+		if err != nil {
+			return err
+		}
+		h.conn = conn
+	}
+	if err := h.conn.SetWriteDeadline(time.Now().Add(h.Timeout)); err != nil {
+		h.closeConn()
+		return err
+	}
+
+	var err error
+	if h.Protocol == "udp" {
+		for _, r := range batch {
+			if _, err = h.conn.Write(h.Format.Format(r)); err != nil {
+				break
+			}
+		}
+	} else {
+		var buf bytes.Buffer
+		for _, r := range batch {
+			buf.Write(h.Format.Format(r))
+		}
+		_, err = h.conn.Write(buf.Bytes())
+	}
+	if err != nil {
+		// The batch is written again on a new connection.
+		h.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (h *NetworkHandler) closeConn() {
+	if h.conn != nil {
+		_ = h.conn.Close()
+		h.conn = nil
+	}
+}
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+// lineCollector records the lines written to a TCP listener.
+type lineCollector struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *lineCollector) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer func() {
+				_ = conn.Close()
+			}()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				c.mu.Lock()
+				c.lines = append(c.lines, scanner.Text())
+				c.mu.Unlock()
+			}
+		}()
+	}
+}
+
+func (c *lineCollector) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.lines...)
+}
+
+func newNetworkTestHandler(t *testing.T, config string) *NetworkHandler {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.network]\n" + config))
+	require.NoError(t, err)
+	handler, err := NewNetworkHandler(cfg.Section("log.network"), log15.LogfmtFormat())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func networkTestRecord(msg string) *log15.Record {
+	return &log15.Record{
+		Time: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC),
+		Lvl:  log15.LvlInfo,
+		Msg:  msg,
+		Ctx:  []interface{}{"logger", "sqlstore"},
+		KeyNames: log15.RecordKeyNames{
+			Time: "t",
+			Msg:  "msg",
+			Lvl:  "lvl",
+		},
+	}
+}
+
+func TestNetworkHandler(t *testing.T) {
+	t.Run("Records are streamed as lines over TCP", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = listener.Close()
+		})
+		collector := &lineCollector{}
+		go collector.serve(listener)
+		handler := newNetworkTestHandler(t, "address = "+listener.Addr().String()+"\nbatch_size = 2")
+
+		for _, msg := range []string{"first", "second", "third"} {
+			require.NoError(t, handler.Log(networkTestRecord(msg)))
+		}
+		require.NoError(t, handler.Close())
+
+		require.Eventually(t, func() bool {
+			return len(collector.received()) == 3
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "t=2021-03-10T12:00:00+0000 lvl=info msg=first logger=sqlstore", collector.received()[0])
+	})
+
+	t.Run("Records are buffered until the collector is reachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		require.NoError(t, listener.Close())
+		handler := newNetworkTestHandler(t, "address = "+addr+"\nbatch_size = 1\nmin_backoff = 10ms\nmax_retries = 100")
+
+		require.NoError(t, handler.Log(networkTestRecord("first")))
+		time.Sleep(30 * time.Millisecond)
+
+		listener, err = net.Listen("tcp", addr)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = listener.Close()
+		})
+		collector := &lineCollector{}
+		go collector.serve(listener)
+
+		require.Eventually(t, func() bool {
+			return len(collector.received()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Records are written as datagrams over UDP", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+		handler := newNetworkTestHandler(t, "address = "+conn.LocalAddr().String()+"\nprotocol = udp\nbatch_size = 2")
+
+		require.NoError(t, handler.Log(networkTestRecord("first")))
+		require.NoError(t, handler.Log(networkTestRecord("second")))
+
+		buf := make([]byte, 1024)
+		for _, msg := range []string{"first", "second"} {
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			assert.Contains(t, string(buf[:n]), "msg="+msg)
+		}
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		for config, expected := range map[string]string{
+			"": "address is required to stream logs over the network",
+			"address = localhost:514\nprotocol = unix": `unknown network protocol "unix", valid options are tcp and udp`,
+		} {
+			cfg, err := ini.Load([]byte("[log.network]\n" + config))
+			require.NoError(t, err)
+			_, err = NewNetworkHandler(cfg.Section("log.network"), nil)
+			require.EqualError(t, err, expected)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// otelJSONRecord is a record with the field names of the OpenTelemetry log data model.
+type otelJSONRecord struct {
+	Timestamp      string                 `json:"Timestamp"`
+	SeverityText   string                 `json:"SeverityText"`
+	SeverityNumber int32                  `json:"SeverityNumber"`
+	TraceID        string                 `json:"TraceId,omitempty"`
+	SpanID         string                 `json:"SpanId,omitempty"`
+	Body           string                 `json:"Body"`
+	Attributes     map[string]interface{} `json:"Attributes,omitempty"`
+	Resource       map[string]string      `json:"Resource"`
+}
+
+// OTelJSONFormat formats records as JSON lines with the field names of the OpenTelemetry log data
+// model. The message is the body, the key values are attributes, and the traceID and spanID added
+// by the context loggers are the TraceId and SpanId, so collectors can correlate records with
+// traces.
+func OTelJSONFormat(serviceName string) log15.Format {
+	resource := map[string]string{"service.name": serviceName}
+	return log15.FormatFunc(func(r *log15.Record) []byte {
+		record := otelJSONRecord{
+			Timestamp:      strconv.FormatInt(r.Time.UnixNano(), 10),
+			SeverityText:   levelNames[r.Lvl],
+			SeverityNumber: int32(otlpSeverities[r.Lvl]),
+			Body:           r.Msg,
+			Resource:       resource,
+		}
+
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			key, ok := r.Ctx[i].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[i])
+			}
+			value := otelJSONValue(r.Ctx[i+1])
+			switch key {
+			case "traceID":
+				record.TraceID = fmt.Sprint(value)
+			case "spanID":
+				record.SpanID = fmt.Sprint(value)
+			default:
+				if record.Attributes == nil {
+					record.Attributes = map[string]interface{}{}
+				}
+				record.Attributes[key] = value
+			}
+		}
+
+		b, err := json.Marshal(record)
+		if err != nil {
+			b, _ = json.Marshal(otelJSONRecord{
+				Timestamp:      record.Timestamp,
+				SeverityText:   levelNames[log15.LvlError],
+				SeverityNumber: int32(otlpSeverities[log15.LvlError]),
+				Body:           "Failed to format log record as JSON: " + err.Error(),
+				Resource:       resource,
+			})
+		}
+		return append(b, '\n')
+	})
+}
+
+// otelJSONValue converts the value of a key value of a record to a value that's marshaled as a JSON
+// string, number, boolean or null.
+func otelJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
@@ -0,0 +1,52 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTelJSONFormat(t *testing.T) {
+	format := OTelJSONFormat("grafana")
+
+	t.Run("Records are formatted with the field names of the log data model", func(t *testing.T) {
+		line := format.Format(&log15.Record{
+			Time: time.Unix(1615377600, 500),
+			Lvl:  log15.LvlWarn,
+			Msg:  "Request failed",
+			Ctx: []interface{}{
+				"logger", "context", "orgId", 1, "err", errors.New("timeout"), "duration", time.Second,
+				"traceID", "4bf92f3577b34da6", "spanID", "00f067aa0ba902b7",
+			},
+		})
+		require.Equal(t, byte('\n'), line[len(line)-1])
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &record))
+		assert.Equal(t, map[string]interface{}{
+			"Timestamp":      "1615377600000000500",
+			"SeverityText":   "warn",
+			"SeverityNumber": float64(13),
+			"TraceId":        "4bf92f3577b34da6",
+			"SpanId":         "00f067aa0ba902b7",
+			"Body":           "Request failed",
+			"Attributes": map[string]interface{}{
+				"logger":   "context",
+				"orgId":    float64(1),
+				"err":      "timeout",
+				"duration": "1s",
+			},
+			"Resource": map[string]interface{}{"service.name": "grafana"},
+		}, record)
+	})
+
+	t.Run("Records without key values have no attributes or trace", func(t *testing.T) {
+		line := format.Format(&log15.Record{Time: time.Unix(0, 0), Lvl: log15.LvlCrit, Msg: "Stopped"})
+		assert.JSONEq(t, `{"Timestamp":"0","SeverityText":"critical","SeverityNumber":21,"Body":"Stopped","Resource":{"service.name":"grafana"}}`, string(line))
+	})
+}
@@ -0,0 +1,284 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// otlpExportMethod is the gRPC method of the OTLP logs service.
+	otlpExportMethod = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+	// otlpLibraryName is the name of the instrumentation library of the exported records.
+	otlpLibraryName = "github.com/grafana/grafana/pkg/infra/log"
+)
+
+var otlpSeverities = map[log15.Lvl]pdata.SeverityNumber{
+	log15.LvlDebug: pdata.SeverityNumberDEBUG,
+	log15.LvlInfo:  pdata.SeverityNumberINFO,
+	log15.LvlWarn:  pdata.SeverityNumberWARN,
+	log15.LvlError: pdata.SeverityNumberERROR,
+	log15.LvlCrit:  pdata.SeverityNumberFATAL,
+}
+
+// OTLPHandler exports log records with the OpenTelemetry logs protocol, over gRPC or HTTP, in
+// batches. The logger and the key values of records are exported as attributes.
+type OTLPHandler struct {
+	BatchSettings
+	// Protocol is either "grpc" or "http".
+	Protocol string
+	// Endpoint is the host:port of the gRPC receiver, or the URL of the HTTP receiver.
+	Endpoint string
+	// Insecure disables TLS for gRPC, HTTP uses TLS for https endpoints.
+	Insecure    bool
+	Headers     map[string]string
+	ServiceName string
+
+	client  *http.Client
+	conn    *grpc.ClientConn
+	batcher *recordBatcher
+}
+
+// NewOTLPHandler creates an OTLPHandler from the settings of the `log.otlp` section.
+func NewOTLPHandler(sec *ini.Section) (*OTLPHandler, error) {
+	headers, err := parseKeyValues("headers", sec.Key("headers").MustString(""))
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &OTLPHandler{
+		BatchSettings: readBatchSettings(sec),
+		Protocol:      sec.Key("protocol").MustString("grpc"),
+		Endpoint:      sec.Key("endpoint").MustString(""),
+		Insecure:      sec.Key("insecure").MustBool(false),
+		Headers:       headers,
+		ServiceName:   sec.Key("service_name").MustString("grafana"),
+	}
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init validates the settings of the handler and starts exporting records.
+func (h *OTLPHandler) Init() error {
+	if err := h.BatchSettings.validate(); err != nil {
+		return err
+	}
+
+	var push func([]*log15.Record) error
+	switch h.Protocol {
+	case "grpc":
+		if h.Endpoint == "" {
+			h.Endpoint = "localhost:4317"
+		}
+		creds := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+		if h.Insecure {
+			creds = grpc.WithInsecure()
+		}
+		// Dialing doesn't block, the connection is established when records are exported.
+		conn, err := grpc.Dial(h.Endpoint, creds)
+		if err != nil {
+			return fmt.Errorf("failed to dial OTLP endpoint %q: %w", h.Endpoint, err)
+		}
+		h.conn = conn
+		push = h.pushGRPC
+	case "http":
+		if h.Endpoint == "" {
+			h.Endpoint = "http://localhost:4318/v1/logs"
+		}
+		h.client = &http.Client{Timeout: h.Timeout}
+		push = h.pushHTTP
+	default:
+		return fmt.Errorf("unknown OTLP protocol %q, valid options are grpc and http", h.Protocol)
+	}
+
+	h.batcher = newRecordBatcher(h.BatchSettings, "OTLP", push, isRetryableOTLPError)
+	return nil
+}
+
+// Log queues a record to be exported.
+func (h *OTLPHandler) Log(r *log15.Record) error {
+	return h.batcher.queue(r)
+}
+
+// Close exports the queued records, and stops the handler.
+func (h *OTLPHandler) Close() error {
+	return h.CloseContext(context.Background())
+}
+
+// CloseContext exports the queued records until ctx is done, and stops the handler. The records that
+// aren't exported by then are dropped.
+func (h *OTLPHandler) CloseContext(ctx context.Context) error {
+	err := h.batcher.close(ctx)
+	if h.conn != nil {
+		if closeErr := h.conn.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (h *OTLPHandler) pushGRPC(batch []*log15.Record) error {
+	body, err := h.encodeBatch(batch).ToOtlpProtoBytes()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+	if len(h.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(h.Headers))
+	}
+
+	var resp []byte
+	return h.conn.Invoke(ctx, otlpExportMethod, &body, &resp, grpc.ForceCodec(otlpRawCodec{}))
+}
+
+func (h *OTLPHandler) pushHTTP(batch []*log15.Record) error {
+	body, err := h.encodeBatch(batch).ToOtlpProtoBytes()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, h.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return httpPushError{statusCode: resp.StatusCode, body: strings.TrimSpace(string(msg))}
+	}
+	return nil
+}
+
+// encodeBatch converts the records of a batch to OTLP logs of a resource named after the service.
+func (h *OTLPHandler) encodeBatch(batch []*log15.Record) pdata.Logs {
+	logs := pdata.NewLogs()
+	logs.ResourceLogs().Resize(1)
+	rl := logs.ResourceLogs().At(0)
+	rl.Resource().Attributes().InsertString("service.name", h.ServiceName)
+
+	rl.InstrumentationLibraryLogs().Resize(1)
+	ill := rl.InstrumentationLibraryLogs().At(0)
+	ill.InstrumentationLibrary().SetName(otlpLibraryName)
+
+	records := ill.Logs()
+	records.Resize(len(batch))
+	for i, r := range batch {
+		lr := records.At(i)
+		lr.SetTimestamp(pdata.TimestampFromTime(r.Time))
+		lr.SetSeverityNumber(otlpSeverities[r.Lvl])
+		lr.SetSeverityText(levelNames[r.Lvl])
+		lr.Body().SetStringVal(r.Msg)
+
+		attrs := lr.Attributes()
+		for j := 0; j < len(r.Ctx)-1; j += 2 {
+			key, ok := r.Ctx[j].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[j])
+			}
+			attrs.Upsert(key, otlpAttributeValue(r.Ctx[j+1]))
+		}
+	}
+	return logs
+}
+
+// otlpAttributeValue converts the value of a key value of a record to an attribute value.
+func otlpAttributeValue(v interface{}) pdata.AttributeValue {
+	switch v := v.(type) {
+	case string:
+		return pdata.NewAttributeValueString(v)
+	case bool:
+		return pdata.NewAttributeValueBool(v)
+	case int:
+		return pdata.NewAttributeValueInt(int64(v))
+	case int32:
+		return pdata.NewAttributeValueInt(int64(v))
+	case int64:
+		return pdata.NewAttributeValueInt(v)
+	case uint32:
+		return pdata.NewAttributeValueInt(int64(v))
+	case float32:
+		return pdata.NewAttributeValueDouble(float64(v))
+	case float64:
+		return pdata.NewAttributeValueDouble(v)
+	case time.Duration:
+		return pdata.NewAttributeValueString(v.String())
+	case time.Time:
+		return pdata.NewAttributeValueString(v.Format(time.RFC3339Nano))
+	case error:
+		return pdata.NewAttributeValueString(v.Error())
+	case nil:
+		return pdata.NewAttributeValueNull()
+	default:
+		return pdata.NewAttributeValueString(fmt.Sprintf("%+v", v))
+	}
+}
+
+// isRetryableOTLPError returns true for errors the OTLP specification allows to retry.
+func isRetryableOTLPError(err error) bool {
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		switch s.Code() {
+		case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+			codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+			return true
+		default:
+			return false
+		}
+	}
+	return isRetryableHTTPPushError(err)
+}
+
+// otlpRawCodec is a gRPC codec for messages that are already encoded as protobuf.
+type otlpRawCodec struct{}
+
+func (otlpRawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal %T, only *[]byte is supported", v)
+	}
+	return *b, nil
+}
+
+func (otlpRawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return errors.New("failed to unmarshal, only *[]byte is supported")
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (otlpRawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,215 @@
+package log
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/ini.v1"
+)
+
+// fakeOTLPReceiver records the logs exported to it.
+type fakeOTLPReceiver struct {
+	mu      sync.Mutex
+	logs    []pdata.Logs
+	headers []string
+	errs    []error
+}
+
+func (f *fakeOTLPReceiver) receive(body []byte, header string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.headers = append(f.headers, header)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+
+	logs := pdata.NewLogs()
+	if err := logs.FromOtlpProtoBytes(body); err != nil {
+		return err
+	}
+	f.logs = append(f.logs, logs)
+	return nil
+}
+
+func (f *fakeOTLPReceiver) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.logs)
+}
+
+func newOTLPTestHandler(t *testing.T, config string) *OTLPHandler {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.otlp]\n" + config))
+	require.NoError(t, err)
+	handler, err := NewOTLPHandler(cfg.Section("log.otlp"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func otlpTestRecord(msg string, ctx ...interface{}) *log15.Record {
+	return &log15.Record{
+		Time: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC),
+		Lvl:  log15.LvlWarn,
+		Msg:  msg,
+		Ctx:  ctx,
+	}
+}
+
+func TestOTLPHandler(t *testing.T) {
+	t.Run("Records are exported over HTTP with their key values as attributes", func(t *testing.T) {
+		receiver := &fakeOTLPReceiver{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+			if err := receiver.receive(body, r.Header.Get("Authorization")); err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		t.Cleanup(server.Close)
+		receiver.errs = []error{errors.New("unavailable")}
+
+		handler := newOTLPTestHandler(t, "protocol = http\nendpoint = "+server.URL+"/v1/logs\nheaders = Authorization:Bearer token\nbatch_size = 2\nbatch_wait = 1h\nmin_backoff = 1ms")
+		require.NoError(t, handler.Log(otlpTestRecord("first", "logger", "sqlstore", "count", 3, "duration", time.Second, "err", errors.New("failed"))))
+		require.NoError(t, handler.Log(otlpTestRecord("second", "logger", "server", "ok", true, "ratio", 0.5)))
+		require.Eventually(t, func() bool { return receiver.count() == 1 }, time.Second, 10*time.Millisecond)
+
+		// The first export failed and was retried.
+		assert.Equal(t, []string{"Bearer token", "Bearer token"}, receiver.headers)
+
+		rl := receiver.logs[0].ResourceLogs().At(0)
+		serviceName, ok := rl.Resource().Attributes().Get("service.name")
+		require.True(t, ok)
+		assert.Equal(t, "grafana", serviceName.StringVal())
+
+		ill := rl.InstrumentationLibraryLogs().At(0)
+		assert.Equal(t, otlpLibraryName, ill.InstrumentationLibrary().Name())
+		require.Equal(t, 2, ill.Logs().Len())
+
+		first := ill.Logs().At(0)
+		assert.Equal(t, "first", first.Body().StringVal())
+		assert.Equal(t, pdata.SeverityNumberWARN, first.SeverityNumber())
+		assert.Equal(t, "warn", first.SeverityText())
+		assert.Equal(t, time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC), first.Timestamp().AsTime().UTC())
+		assert.Equal(t, map[string]interface{}{
+			"logger":   "sqlstore",
+			"count":    int64(3),
+			"duration": "1s",
+			"err":      "failed",
+		}, otlpTestAttributes(first.Attributes()))
+
+		second := ill.Logs().At(1)
+		assert.Equal(t, map[string]interface{}{
+			"logger": "server",
+			"ok":     true,
+			"ratio":  0.5,
+		}, otlpTestAttributes(second.Attributes()))
+	})
+
+	t.Run("Records are exported over gRPC", func(t *testing.T) {
+		receiver := &fakeOTLPReceiver{errs: []error{status.Error(codes.Unavailable, "unavailable")}}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		//nolint:staticcheck
+		server := grpc.NewServer(grpc.CustomCodec(otlpRawServerCodec{}), grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			if method != otlpExportMethod {
+				return status.Error(codes.Unimplemented, method)
+			}
+			var body []byte
+			if err := stream.RecvMsg(&body); err != nil {
+				return err
+			}
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			if err := receiver.receive(body, firstValue(md.Get("x-scope-orgid"))); err != nil {
+				return err
+			}
+			return stream.SendMsg(&[]byte{})
+		}))
+		go func() {
+			_ = server.Serve(listener)
+		}()
+		t.Cleanup(server.Stop)
+
+		handler := newOTLPTestHandler(t, "endpoint = "+listener.Addr().String()+"\ninsecure = true\nheaders = X-Scope-OrgID:tenant\nbatch_size = 1\nmin_backoff = 1ms")
+		require.NoError(t, handler.Log(otlpTestRecord("first", "logger", "sqlstore")))
+		require.Eventually(t, func() bool { return receiver.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, []string{"tenant", "tenant"}, receiver.headers)
+		record := receiver.logs[0].ResourceLogs().At(0).InstrumentationLibraryLogs().At(0).Logs().At(0)
+		assert.Equal(t, "first", record.Body().StringVal())
+	})
+
+	t.Run("Only errors the receiver may recover from are retried", func(t *testing.T) {
+		assert.True(t, isRetryableOTLPError(status.Error(codes.Unavailable, "")))
+		assert.True(t, isRetryableOTLPError(status.Error(codes.ResourceExhausted, "")))
+		assert.False(t, isRetryableOTLPError(status.Error(codes.InvalidArgument, "")))
+		assert.False(t, isRetryableOTLPError(status.Error(codes.Unauthenticated, "")))
+		assert.True(t, isRetryableOTLPError(httpPushError{statusCode: http.StatusBadGateway}))
+		assert.False(t, isRetryableOTLPError(httpPushError{statusCode: http.StatusBadRequest}))
+		assert.True(t, isRetryableOTLPError(errors.New("connection refused")))
+	})
+
+	t.Run("Unknown protocols are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.otlp]\nprotocol = udp"))
+		require.NoError(t, err)
+
+		_, err = NewOTLPHandler(cfg.Section("log.otlp"))
+		require.EqualError(t, err, `unknown OTLP protocol "udp", valid options are grpc and http`)
+	})
+}
+
+func otlpTestAttributes(attrs pdata.AttributeMap) map[string]interface{} {
+	result := map[string]interface{}{}
+	attrs.ForEach(func(k string, v pdata.AttributeValue) {
+		switch v.Type() {
+		case pdata.AttributeValueSTRING:
+			result[k] = v.StringVal()
+		case pdata.AttributeValueINT:
+			result[k] = v.IntVal()
+		case pdata.AttributeValueDOUBLE:
+			result[k] = v.DoubleVal()
+		case pdata.AttributeValueBOOL:
+			result[k] = v.BoolVal()
+		}
+	})
+	return result
+}
+
+// otlpRawServerCodec lets the test server receive the raw messages of the OTLP logs service.
+type otlpRawServerCodec struct {
+	otlpRawCodec
+}
+
+func (otlpRawServerCodec) String() string {
+	return "proto"
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
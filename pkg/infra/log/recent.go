@@ -0,0 +1,142 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// defaultRecentBufferSize is the default number of records kept for Recent.
+const defaultRecentBufferSize = 1000
+
+// RecentRecord is a log record kept in the buffer of recent records, as returned by Recent.
+type RecentRecord struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Message string                 `json:"message"`
+	Context map[string]interface{} `json:"context,omitempty"`
+
+	lvl log15.Lvl
+}
+
+// recentBuffer is a ring buffer of the most recent records.
+type recentBuffer struct {
+	mu      sync.RWMutex
+	records []RecentRecord
+	// next is the index the next record is written to, and count the number of records kept.
+	next  int
+	count int
+}
+
+// recentRecords keeps the records passed on to the handlers of the log modes, up to the
+// recent_buffer_size of the logging config.
+var recentRecords = &recentBuffer{}
+
+// resize changes the number of records kept, keeping the most recent ones.
+func (b *recentBuffer) resize(size int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if size == len(b.records) {
+		return
+	}
+	kept := b.newest(size)
+	records := make([]RecentRecord, size)
+	for i := range kept {
+		records[len(kept)-1-i] = kept[i]
+	}
+	b.records = records
+	b.count = len(kept)
+	b.next = 0
+	if size > 0 {
+		b.next = b.count % size
+	}
+}
+
+func (b *recentBuffer) add(r RecentRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.records) == 0 {
+		return
+	}
+	b.records[b.next] = r
+	b.next = (b.next + 1) % len(b.records)
+	if b.count < len(b.records) {
+		b.count++
+	}
+}
+
+// newest returns up to limit records, newest first. The lock must be held.
+func (b *recentBuffer) newest(limit int) []RecentRecord {
+	if limit > b.count {
+		limit = b.count
+	}
+	records := make([]RecentRecord, 0, limit)
+	for i := 1; i <= limit; i++ {
+		records = append(records, b.records[(b.next-i+len(b.records))%len(b.records)])
+	}
+	return records
+}
+
+// handler returns a handler that adds records to the buffer.
+func (b *recentBuffer) handler() log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		record := RecentRecord{
+			Time:    r.Time,
+			Level:   levelNames[r.Lvl],
+			Message: r.Msg,
+			lvl:     r.Lvl,
+		}
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			key, ok := r.Ctx[i].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[i])
+			}
+			if key == "logger" {
+				record.Logger = fmt.Sprint(r.Ctx[i+1])
+				continue
+			}
+			if record.Context == nil {
+				record.Context = map[string]interface{}{}
+			}
+			record.Context[key] = otelJSONValue(r.Ctx[i+1])
+		}
+		b.add(record)
+		return nil
+	})
+}
+
+// Recent returns up to limit of the most recent log records, newest first, so they can be shown
+// without access to the log outputs. Only records of the given level or more severe are returned,
+// and only records of the given logger, unless level or logger is empty. A limit of 0 or less
+// returns all kept records.
+func Recent(level string, logger string, limit int) ([]RecentRecord, error) {
+	maxLevel := log15.LvlDebug
+	if level != "" {
+		var ok bool
+		maxLevel, ok = logLevels[strings.ToLower(level)]
+		if !ok {
+			return nil, fmt.Errorf("unknown log level %q", level)
+		}
+	}
+
+	recentRecords.mu.RLock()
+	defer recentRecords.mu.RUnlock()
+
+	records := make([]RecentRecord, 0)
+	for _, r := range recentRecords.newest(recentRecords.count) {
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+		if r.lvl > maxLevel || (logger != "" && r.Logger != logger) {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
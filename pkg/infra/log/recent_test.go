@@ -0,0 +1,94 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecent(t *testing.T) {
+	t.Cleanup(func() {
+		recentRecords.resize(0)
+	})
+
+	logRecords := func(t *testing.T) {
+		t.Helper()
+		handler := recentRecords.handler()
+		for _, r := range []*log15.Record{
+			{Lvl: log15.LvlInfo, Msg: "Started", Ctx: []interface{}{"logger", "server"}},
+			{Lvl: log15.LvlError, Msg: "Query failed", Ctx: []interface{}{"logger", "sqlstore", "table", "user"}},
+			{Lvl: log15.LvlWarn, Msg: "Slow query", Ctx: []interface{}{"logger", "sqlstore"}},
+			{Lvl: log15.LvlError, Msg: "Request failed", Ctx: []interface{}{"logger", "server"}},
+		} {
+			require.NoError(t, handler.Log(r))
+		}
+	}
+	messages := func(records []RecentRecord) []string {
+		result := make([]string, 0, len(records))
+		for _, r := range records {
+			result = append(result, r.Message)
+		}
+		return result
+	}
+
+	t.Run("Recent records are returned newest first", func(t *testing.T) {
+		recentRecords.resize(0)
+		recentRecords.resize(10)
+		logRecords(t)
+
+		records, err := Recent("", "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Request failed", "Slow query", "Query failed", "Started"}, messages(records))
+		assert.Equal(t, RecentRecord{
+			Time:    records[2].Time,
+			Level:   "error",
+			Logger:  "sqlstore",
+			Message: "Query failed",
+			Context: map[string]interface{}{"table": "user"},
+			lvl:     log15.LvlError,
+		}, records[2])
+	})
+
+	t.Run("Recent records are filtered by level, logger and limit", func(t *testing.T) {
+		recentRecords.resize(0)
+		recentRecords.resize(10)
+		logRecords(t)
+
+		records, err := Recent("warn", "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Request failed", "Slow query", "Query failed"}, messages(records))
+
+		records, err = Recent("error", "sqlstore", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Query failed"}, messages(records))
+
+		records, err = Recent("", "", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Request failed", "Slow query"}, messages(records))
+
+		_, err = Recent("fatal", "", 0)
+		require.EqualError(t, err, `unknown log level "fatal"`)
+	})
+
+	t.Run("Only the most recent records are kept", func(t *testing.T) {
+		recentRecords.resize(0)
+		recentRecords.resize(3)
+		logRecords(t)
+
+		records, err := Recent("", "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Request failed", "Slow query", "Query failed"}, messages(records))
+
+		recentRecords.resize(2)
+		records, err = Recent("", "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Request failed", "Slow query"}, messages(records))
+
+		recentRecords.resize(0)
+		records, err = Recent("", "", 0)
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+}
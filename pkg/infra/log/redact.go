@@ -0,0 +1,105 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+)
+
+// redactedValue replaces the redacted values of records.
+const redactedValue = "[redacted]"
+
+// defaultRedactKeys are the keys of the values that are redacted when the config doesn't set any.
+var defaultRedactKeys = []string{"password", "token", "api_key", "secureJsonData"}
+
+// RedactSettings are the key values and the pattern that are redacted from records.
+type RedactSettings struct {
+	// Keys are the keys of the values to redact. They are matched regardless of case, `_` and `-`,
+	// so `api_key` matches `apiKey` too.
+	Keys []string
+	// Pattern is replaced in messages and string values, if set.
+	Pattern *regexp.Regexp
+}
+
+// getRedactSettings parses the keys and the pattern to redact.
+func getRedactSettings(keys []string, pattern string) (RedactSettings, error) {
+	settings := RedactSettings{Keys: keys}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return RedactSettings{}, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		settings.Pattern = re
+	}
+	return settings, nil
+}
+
+// RedactHandler redacts the values of the keys, and the pattern of the settings from records
+// before passing them to h, so secrets aren't written to any log output.
+func RedactHandler(settings RedactSettings, h log15.Handler) log15.Handler {
+	if len(settings.Keys) == 0 && settings.Pattern == nil {
+		return h
+	}
+
+	keys := make(map[string]struct{}, len(settings.Keys))
+	for _, key := range settings.Keys {
+		keys[normalizeRedactKey(key)] = struct{}{}
+	}
+
+	redactString := func(s string) string {
+		if settings.Pattern == nil {
+			return s
+		}
+		return settings.Pattern.ReplaceAllString(s, redactedValue)
+	}
+
+	return log15.FuncHandler(func(r *log15.Record) error {
+		redacted := *r
+		redacted.Msg = redactString(r.Msg)
+		// The key values are only copied once a value is redacted, since most records have
+		// nothing to redact.
+		copied := false
+		set := func(i int, value interface{}) {
+			if !copied {
+				redacted.Ctx = make([]interface{}, len(r.Ctx))
+				copy(redacted.Ctx, r.Ctx)
+				copied = true
+			}
+			redacted.Ctx[i] = value
+		}
+
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			if key, ok := r.Ctx[i].(string); ok {
+				if _, ok := keys[normalizeRedactKey(key)]; ok {
+					set(i+1, redactedValue)
+					continue
+				}
+			}
+			if settings.Pattern == nil {
+				continue
+			}
+			switch v := r.Ctx[i+1].(type) {
+			case string:
+				if s := redactString(v); s != v {
+					set(i+1, s)
+				}
+			case error:
+				// Errors are only replaced by their message when it has to be redacted.
+				if s := redactString(v.Error()); s != v.Error() {
+					set(i+1, s)
+				}
+			}
+		}
+
+		return h.Log(&redacted)
+	})
+}
+
+// redactKeyReplacer removes the characters that are ignored when matching keys.
+var redactKeyReplacer = strings.NewReplacer("_", "", "-", "")
+
+func normalizeRedactKey(key string) string {
+	return strings.ToLower(redactKeyReplacer.Replace(key))
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactHandler(t *testing.T) {
+	settings, err := getRedactSettings(defaultRedactKeys, `Bearer [A-Za-z0-9._-]+`)
+	require.NoError(t, err)
+
+	var records []*log15.Record
+	handler := RedactHandler(settings, log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+
+	ctx := []interface{}{
+		"logger", "datasource",
+		"Password", "secret",
+		"apiKey", "key",
+		"secure_json_data", map[string]string{"token": "secret"},
+		"header", "Authorization: Bearer abc.def",
+		"err", errors.New("request with Bearer abc.def failed"),
+		"status", 401,
+	}
+	require.NoError(t, handler.Log(&log15.Record{Msg: "Sent Bearer abc.def", Ctx: ctx}))
+
+	require.Len(t, records, 1)
+	assert.Equal(t, "Sent [redacted]", records[0].Msg)
+	assert.Equal(t, []interface{}{
+		"logger", "datasource",
+		"Password", "[redacted]",
+		"apiKey", "[redacted]",
+		"secure_json_data", "[redacted]",
+		"header", "Authorization: [redacted]",
+		"err", "request with [redacted] failed",
+		"status", 401,
+	}, records[0].Ctx)
+
+	// The key values of the logged record are not changed.
+	assert.Equal(t, "secret", ctx[3])
+
+	_, err = getRedactSettings(nil, "(")
+	require.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// HandlerFactory creates the handler of a mode registered with RegisterHandler, from the section
+// of the mode, like [log.custom], and the log line format of its format setting. Handlers that
+// implement DisposableHandler are closed when the logging config is read again or closed, and
+// handlers that implement ReloadableHandler are reloaded with the file handlers.
+type HandlerFactory func(sec *ini.Section, format log15.Format) (log15.Handler, error)
+
+var (
+	handlerFactoriesMu sync.RWMutex
+	handlerFactories   = map[string]HandlerFactory{}
+)
+
+// builtinModes are the modes of the handlers of the log package, which can't be registered. Like
+// registered modes, sections like [log.file.security] route the records of loggers to an output of
+// their own.
+var builtinModes = map[string]bool{
+	"console": true, "file": true, "syslog": true, "journald": true, "loki": true, "otlp": true,
+	"kafka": true, "fluentd": true, "network": true, "cloudwatch": true, "stackdriver": true,
+}
+
+// RegisterHandler registers the factory of the handler of a mode, so builds with additional log
+// sinks can add modes ReadLoggingConfig creates the handlers of. Like the built-in modes, the
+// records of a registered mode go through the level, filters, sampling and async settings of the
+// mode, and sections like [log.<mode>.<name>] route records to outputs of the mode. It panics when
+// the mode is a built-in mode or registered already.
+func RegisterHandler(mode string, factory HandlerFactory) {
+	handlerFactoriesMu.Lock()
+	defer handlerFactoriesMu.Unlock()
+
+	if builtinModes[mode] {
+		panic(fmt.Sprintf("log mode %q is a built-in mode", mode))
+	}
+	if _, exists := handlerFactories[mode]; exists {
+		panic(fmt.Sprintf("log mode %q is registered already", mode))
+	}
+	handlerFactories[mode] = factory
+}
+
+// registeredHandler returns the factory of a mode registered with RegisterHandler, or nil.
+func registeredHandler(mode string) HandlerFactory {
+	handlerFactoriesMu.RLock()
+	defer handlerFactoriesMu.RUnlock()
+	return handlerFactories[mode]
+}
+
+// isMode reports whether a mode is a built-in mode or registered with RegisterHandler.
+func isMode(mode string) bool {
+	return builtinModes[mode] || registeredHandler(mode) != nil
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+// memoryHandler keeps the messages of records in memory.
+type memoryHandler struct {
+	mu     sync.Mutex
+	msgs   []string
+	closed bool
+}
+
+func (h *memoryHandler) Log(r *log15.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgs = append(h.msgs, r.Msg)
+	return nil
+}
+
+func (h *memoryHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	return nil
+}
+
+func TestRegisterHandler(t *testing.T) {
+	created := map[string]*memoryHandler{}
+	RegisterHandler("memory", func(sec *ini.Section, format log15.Format) (log15.Handler, error) {
+		if sec.Key("fail").MustBool(false) {
+			return nil, errors.New("memory is full")
+		}
+		h := &memoryHandler{}
+		created[sec.Name()] = h
+		return h, nil
+	})
+	t.Cleanup(func() {
+		handlerFactoriesMu.Lock()
+		delete(handlerFactories, "memory")
+		handlerFactoriesMu.Unlock()
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	t.Run("Handlers of registered modes are created by their factory", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log]\nrecent_buffer_size = 0\n[log.memory]\nlevel = info\n[log.memory.security]\nloggers = auth"))
+		require.NoError(t, err)
+		require.NoError(t, ReadLoggingConfig([]string{"memory"}, t.TempDir(), cfg))
+
+		New("auth").Info("Login attempt")
+		New("tsdb").Debug("Query")
+		require.NoError(t, Close())
+
+		require.Contains(t, created, "log.memory")
+		require.Contains(t, created, "log.memory.security")
+		assert.Equal(t, []string{"Login attempt"}, created["log.memory"].msgs)
+		assert.Equal(t, []string{"Login attempt"}, created["log.memory.security"].msgs)
+		assert.True(t, created["log.memory"].closed)
+	})
+
+	t.Run("Errors of factories are returned", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.memory]\nfail = true"))
+		require.NoError(t, err)
+		require.EqualError(t, ReadLoggingConfig([]string{"memory"}, t.TempDir(), cfg), "failed to initialize handler of log.memory: memory is full")
+	})
+
+	t.Run("Built-in and registered modes can't be registered", func(t *testing.T) {
+		factory := func(*ini.Section, log15.Format) (log15.Handler, error) { return log15.DiscardHandler(), nil }
+		assert.PanicsWithValue(t, `log mode "file" is a built-in mode`, func() { RegisterHandler("file", factory) })
+		assert.PanicsWithValue(t, `log mode "memory" is registered already`, func() { RegisterHandler("memory", factory) })
+	})
+}
@@ -0,0 +1,85 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// logRoute is an output of a mode the records of specific loggers are routed to, configured by a
+// section like [log.file.security]. The settings of the section default to the ones of the mode.
+type logRoute struct {
+	// name is the name of the section without the log prefix, like file.security, and mode and
+	// output the parts of it.
+	name   string
+	mode   string
+	output string
+	// loggers are the names or glob patterns of the loggers routed to the output. With exclusive,
+	// their records aren't written to the outputs of the modes anymore.
+	loggers   []string
+	exclusive bool
+}
+
+// getLogRoutes returns the routes of the sections of the config like [log.file.security].
+func getLogRoutes(cfg *ini.File) ([]logRoute, error) {
+	var routes []logRoute
+	for _, sec := range cfg.Sections() {
+		parts := strings.SplitN(sec.Name(), ".", 3)
+		if len(parts) != 3 || parts[0] != "log" || parts[2] == "" || !isMode(parts[1]) {
+			continue
+		}
+
+		route := logRoute{
+			name:      parts[1] + "." + parts[2],
+			mode:      parts[1],
+			output:    parts[2],
+			loggers:   util.SplitString(sec.Key("loggers").String()),
+			exclusive: sec.Key("exclusive").MustBool(false),
+		}
+		if len(route.loggers) == 0 {
+			return nil, fmt.Errorf("loggers of log.%s must be set", route.name)
+		}
+		for _, pattern := range route.loggers {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid logger pattern %q of log.%s: %w", pattern, route.name, err)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// fileName returns the file the file output of the route writes to, which is only read from the
+// section of the route, so routes don't write to the file of the file mode.
+func (r logRoute) fileName(sec *ini.Section, logsPath string) string {
+	for _, key := range sec.KeyStrings() {
+		if key == "file_name" {
+			return sec.Key(key).String()
+		}
+	}
+	return filepath.Join(logsPath, "grafana-"+r.output+".log")
+}
+
+// matchLogger reports whether the name of a logger matches one of the names or glob patterns.
+func matchLogger(patterns []string, loggerName string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, loggerName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteHandler passes the records of the loggers matching the names or glob patterns on to h.
+// Records of other loggers are passed on to h too with exclude, instead of those of the loggers.
+func RouteHandler(loggers []string, exclude bool, h log15.Handler) log15.Handler {
+	return log15.FilterHandler(func(r *log15.Record) bool {
+		name, _ := recordLogger(r)
+		return matchLogger(loggers, name) != exclude
+	}, h)
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestLogRoutes(t *testing.T) {
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	logMessages := func(t *testing.T, config string) (string, string) {
+		t.Helper()
+		dir := t.TempDir()
+		cfg, err := ini.Load([]byte("[log]\nmode = file\nrecent_buffer_size = 0\n[log.file]\nfile_name = " + filepath.Join(dir, "grafana.log") + "\n" + config))
+		require.NoError(t, err)
+		require.NoError(t, ReadLoggingConfig([]string{"file"}, dir, cfg))
+
+		New("auth").Info("Login attempt")
+		New("login.ldap").Info("LDAP login")
+		New("sqlstore").Info("Query")
+		require.NoError(t, Close())
+
+		read := func(name string) string {
+			b, err := ioutil.ReadFile(filepath.Join(dir, name))
+			require.NoError(t, err)
+			return string(b)
+		}
+		return read("grafana.log"), read("grafana-security.log")
+	}
+
+	t.Run("Records of the loggers are routed to the route in addition to the modes", func(t *testing.T) {
+		main, security := logMessages(t, "[log.file.security]\nloggers = auth login.*")
+
+		assert.Equal(t, 3, strings.Count(main, "\n"), main)
+		assert.Contains(t, security, "Login attempt")
+		assert.Contains(t, security, "LDAP login")
+		assert.NotContains(t, security, "Query")
+	})
+
+	t.Run("Records of the loggers are only routed to exclusive routes", func(t *testing.T) {
+		main, security := logMessages(t, "[log.file.security]\nloggers = auth login.*\nexclusive = true")
+
+		assert.Equal(t, 1, strings.Count(main, "\n"), main)
+		assert.Contains(t, main, "Query")
+		assert.Equal(t, 2, strings.Count(security, "\n"), security)
+	})
+
+	t.Run("Invalid routes are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.file.security]\nexclusive = true"))
+		require.NoError(t, err)
+		_, err = getLogRoutes(cfg)
+		require.EqualError(t, err, "loggers of log.file.security must be set")
+	})
+}
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// SamplingRule limits the records of a logger. The first Burst records of each second are passed,
+// after that only every SampleRate-th record is passed.
+type SamplingRule struct {
+	SampleRate int
+	Burst      int
+}
+
+// defaultSamplingBurst is the burst of sampling rules that don't set one.
+const defaultSamplingBurst = 10
+
+// getSamplingRules parses sampling rules, like `tsdb.prometheus:100:10`, of a logger, a sample rate
+// and an optional burst.
+func getSamplingRules(ruleStrArray []string) (map[string]SamplingRule, error) {
+	rules := make(map[string]SamplingRule)
+
+	for _, ruleStr := range ruleStrArray {
+		parts := strings.Split(ruleStr, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid sampling rule %q, rules must be given as logger:sample_rate[:burst]", ruleStr)
+		}
+
+		rule := SamplingRule{Burst: defaultSamplingBurst}
+		var err error
+		if rule.SampleRate, err = strconv.Atoi(parts[1]); err != nil || rule.SampleRate < 1 {
+			return nil, fmt.Errorf("invalid sample rate of sampling rule %q, it must be a number greater than 0", ruleStr)
+		}
+		if len(parts) == 3 {
+			if rule.Burst, err = strconv.Atoi(parts[2]); err != nil || rule.Burst < 0 {
+				return nil, fmt.Errorf("invalid burst of sampling rule %q, it must be a number of at least 0", ruleStr)
+			}
+		}
+		rules[parts[0]] = rule
+	}
+
+	return rules, nil
+}
+
+// loggerSample is the state of the sampling of a logger in the current second.
+type loggerSample struct {
+	second  int64
+	count   int
+	dropped int
+}
+
+// SamplingHandler samples the records of the loggers that have a sampling rule, so extremely chatty
+// loggers don't flood h. Passed records that follow dropped records get a `dropped` key value with
+// the number of records dropped since the previous passed record of the logger.
+func SamplingHandler(rules map[string]SamplingRule, h log15.Handler) log15.Handler {
+	if len(rules) == 0 {
+		return h
+	}
+
+	var mu sync.Mutex
+	samples := make(map[string]*loggerSample, len(rules))
+	return log15.FuncHandler(func(r *log15.Record) error {
+		name, ok := recordLogger(r)
+		if !ok {
+			return h.Log(r)
+		}
+		rule, ok := rules[name]
+		if !ok {
+			return h.Log(r)
+		}
+
+		mu.Lock()
+		sample, ok := samples[name]
+		if !ok {
+			sample = &loggerSample{}
+			samples[name] = sample
+		}
+		second := r.Time.Truncate(time.Second).Unix()
+		if second != sample.second {
+			sample.second = second
+			sample.count = 0
+		}
+		sample.count++
+		if sample.count > rule.Burst && (sample.count-rule.Burst)%rule.SampleRate != 0 {
+			sample.dropped++
+			mu.Unlock()
+			return nil
+		}
+		dropped := sample.dropped
+		sample.dropped = 0
+		mu.Unlock()
+
+		if dropped > 0 {
+			sampled := *r
+			sampled.Ctx = append(r.Ctx[:len(r.Ctx):len(r.Ctx)], "dropped", dropped)
+			return h.Log(&sampled)
+		}
+		return h.Log(r)
+	})
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var records []*log15.Record
+	handler := SamplingHandler(map[string]SamplingRule{"tsdb.prometheus": {SampleRate: 3, Burst: 2}}, log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	start := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	logRecord := func(logger string, at time.Time) {
+		require.NoError(t, handler.Log(&log15.Record{Time: at, Lvl: log15.LvlInfo, Msg: "query", Ctx: []interface{}{"logger", logger}}))
+	}
+
+	t.Run("Records after the burst are sampled with the number of dropped records", func(t *testing.T) {
+		for i := 0; i < 8; i++ {
+			logRecord("tsdb.prometheus", start)
+		}
+		// The 2 records of the burst, then every 3rd record.
+		require.Len(t, records, 4)
+		assert.Equal(t, []interface{}{"logger", "tsdb.prometheus"}, records[1].Ctx)
+		assert.Equal(t, []interface{}{"logger", "tsdb.prometheus", "dropped", 2}, records[2].Ctx)
+		assert.Equal(t, []interface{}{"logger", "tsdb.prometheus", "dropped", 2}, records[3].Ctx)
+	})
+
+	t.Run("The burst starts again each second", func(t *testing.T) {
+		records = nil
+		logRecord("tsdb.prometheus", start.Add(500*time.Millisecond))
+		logRecord("tsdb.prometheus", start.Add(time.Second))
+		logRecord("tsdb.prometheus", start.Add(time.Second))
+
+		require.Len(t, records, 2)
+		assert.Equal(t, []interface{}{"logger", "tsdb.prometheus", "dropped", 1}, records[0].Ctx)
+		assert.Equal(t, []interface{}{"logger", "tsdb.prometheus"}, records[1].Ctx)
+	})
+
+	t.Run("Records of other loggers are not sampled", func(t *testing.T) {
+		records = nil
+		for i := 0; i < 20; i++ {
+			logRecord("sqlstore", start)
+		}
+		require.Len(t, records, 20)
+	})
+}
+
+func TestGetSamplingRules(t *testing.T) {
+	rules, err := getSamplingRules([]string{"tsdb.prometheus:100:5", "sqlstore:10"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]SamplingRule{
+		"tsdb.prometheus": {SampleRate: 100, Burst: 5},
+		"sqlstore":        {SampleRate: 10, Burst: defaultSamplingBurst},
+	}, rules)
+
+	for _, invalid := range []string{"sqlstore", "sqlstore:0", "sqlstore:ten", "sqlstore:10:-1", ":10", "sqlstore:10:5:1"} {
+		_, err := getSamplingRules([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
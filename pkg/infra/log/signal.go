@@ -0,0 +1,69 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ListenForReloadSignal reads the logging config again when the process receives SIGHUP, until ctx
+// is done. readConfig reads the config files again and applies their logging config with
+// ReadLoggingConfig, so operators can change levels, filters and files on a running server. When
+// the config can't be read, the current handlers are kept and their files are reopened.
+func ListenForReloadSignal(ctx context.Context, readConfig func() error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	reloadOnSignal(ctx, signals, readConfig)
+}
+
+func reloadOnSignal(ctx context.Context, signals <-chan os.Signal, readConfig func() error) {
+	logger := New("log")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := readConfig(); err != nil {
+				logger.Error("Failed to read logging config, reopening the current log files", "err", err)
+				if err := Reload(); err != nil {
+					// The handlers may not be able to log the error.
+					fmt.Fprintf(os.Stderr, "Failed to reload loggers: %s\n", err)
+				}
+				continue
+			}
+			logger.Info("Logging config reloaded")
+		}
+	}
+}
+
+// ListenForRotateSignal reopens the log files when the process receives one of rotateSignals, like
+// SIGUSR1 and SIGUSR2, until ctx is done, so external tools like logrotate can rotate the files
+// without copytruncate.
+func ListenForRotateSignal(ctx context.Context) {
+	if len(rotateSignals) == 0 {
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, rotateSignals...)
+	defer signal.Stop(signals)
+
+	rotateOnSignal(ctx, signals)
+}
+
+func rotateOnSignal(ctx context.Context, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := Rotate(); err != nil {
+				// The handlers may not be able to log the error.
+				fmt.Fprintf(os.Stderr, "Failed to reopen log files: %s\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestReloadOnSignal(t *testing.T) {
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	dir := t.TempDir()
+	readConfig := func(fileName string) func() error {
+		return func() error {
+			cfg, err := ini.Load([]byte("[log]\nmode = file\n[log.file]\nfile_name = " + filepath.Join(dir, fileName)))
+			if err != nil {
+				return err
+			}
+			return ReadLoggingConfig([]string{"file"}, dir, cfg)
+		}
+	}
+	require.NoError(t, readConfig("first.log")())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal)
+	done := make(chan struct{})
+	configs := make(chan func() error, 2)
+	go func() {
+		defer close(done)
+		reloadOnSignal(ctx, signals, func() error {
+			return (<-configs)()
+		})
+	}()
+
+	configs <- readConfig("second.log")
+	signals <- syscall.SIGHUP
+	configs <- func() error { return errors.New("invalid config") }
+	signals <- syscall.SIGHUP
+	cancel()
+	<-done
+
+	New("test").Info("after reload")
+	first, err := ioutil.ReadFile(filepath.Join(dir, "first.log"))
+	require.NoError(t, err)
+	require.NotContains(t, string(first), "after reload")
+
+	// The config read on the first signal is kept when the config can't be read.
+	second, err := ioutil.ReadFile(filepath.Join(dir, "second.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(second), "after reload")
+}
+
+func TestRotateOnSignal(t *testing.T) {
+	t.Cleanup(func() {
+		Root.SetHandler(log15.DiscardHandler())
+		require.NoError(t, Close())
+	})
+
+	dir := t.TempDir()
+	cfg, err := ini.Load([]byte("[log]\nmode = file\n[log.file]\nlog_rotate = false\nfile_name = " + filepath.Join(dir, "grafana.log")))
+	require.NoError(t, err)
+	require.NoError(t, ReadLoggingConfig([]string{"file"}, dir, cfg))
+
+	New("test").Info("before rotation")
+	// Rotated like logrotate does without copytruncate.
+	require.NoError(t, os.Rename(filepath.Join(dir, "grafana.log"), filepath.Join(dir, "grafana.log.1")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rotateOnSignal(ctx, signals)
+	}()
+	// Any signal of the channel reopens the files, and SIGUSR1 doesn't exist on Windows.
+	signals <- syscall.SIGHUP
+	cancel()
+	<-done
+
+	New("test").Info("after rotation")
+	require.NoError(t, Close())
+
+	rotated, err := ioutil.ReadFile(filepath.Join(dir, "grafana.log.1"))
+	require.NoError(t, err)
+	require.Contains(t, string(rotated), "before rotation")
+	require.NotContains(t, string(rotated), "after rotation")
+
+	current, err := ioutil.ReadFile(filepath.Join(dir, "grafana.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(current), "after rotation")
+	require.NotContains(t, string(current), "before rotation")
+}
@@ -0,0 +1,11 @@
+//+build !windows
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// rotateSignals are the signals the log files are reopened on.
+var rotateSignals = []os.Signal{syscall.SIGUSR1, syscall.SIGUSR2}
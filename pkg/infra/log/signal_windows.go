@@ -0,0 +1,8 @@
+//+build windows
+
+package log
+
+import "os"
+
+// rotateSignals are the signals the log files are reopened on, of which there are none on Windows.
+var rotateSignals []os.Signal
@@ -0,0 +1,115 @@
+//+build go1.21
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/inconshreveable/log15"
+)
+
+// NewSlogLogger returns a *slog.Logger that logs with a logger of the given name, so libraries
+// logging with log/slog write to the log modes of Grafana, with the levels and filters of the
+// logging config.
+func NewSlogLogger(name string, ctx ...interface{}) *slog.Logger {
+	return slog.New(&slogHandler{logger: New(name, ctx...)})
+}
+
+// slogHandler is a slog.Handler logging with a ConcreteLogger. The attributes of groups are
+// logged with keys prefixed with the group names, like `request.method`.
+type slogHandler struct {
+	logger *ConcreteLogger
+	// prefix is the prefix of the keys of the attributes, of the groups opened with WithGroup.
+	prefix string
+}
+
+// Enabled reports that all levels are enabled, since the levels of loggers are checked by the
+// handlers of the log modes.
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	args := make([]interface{}, 0, 2*r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		args = appendSlogAttr(args, h.prefix, a)
+		return true
+	})
+
+	switch {
+	case r.Level < slog.LevelInfo:
+		h.logger.DebugCtx(ctx, r.Message, args...)
+	case r.Level < slog.LevelWarn:
+		h.logger.InfoCtx(ctx, r.Message, args...)
+	case r.Level < slog.LevelError:
+		h.logger.WarnCtx(ctx, r.Message, args...)
+	default:
+		h.logger.ErrorCtx(ctx, r.Message, args...)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	args := make([]interface{}, 0, 2*len(attrs))
+	for _, a := range attrs {
+		args = appendSlogAttr(args, h.prefix, a)
+	}
+	return &slogHandler{logger: &ConcreteLogger{Logger: h.logger.Logger.New(args...)}, prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// appendSlogAttr appends the key value of an attribute to args, or the key values of the
+// attributes of a group.
+func appendSlogAttr(args []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return args
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, attr := range a.Value.Group() {
+			args = appendSlogAttr(args, prefix, attr)
+		}
+		return args
+	}
+	return append(args, prefix+a.Key, a.Value.Any())
+}
+
+// SlogHandler passes records on to a slog.Handler, so the log records of Grafana can be written by
+// handlers of libraries using log/slog. The key values of records are passed on as attributes.
+func SlogHandler(h slog.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		level := slogLevels[r.Lvl]
+		if !h.Enabled(context.Background(), level) {
+			return nil
+		}
+
+		record := slog.NewRecord(r.Time, level, r.Msg, 0)
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			key, ok := r.Ctx[i].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[i])
+			}
+			record.AddAttrs(slog.Any(key, r.Ctx[i+1]))
+		}
+		return h.Handle(context.Background(), record)
+	})
+}
+
+var slogLevels = map[log15.Lvl]slog.Level{
+	log15.LvlDebug: slog.LevelDebug,
+	log15.LvlInfo:  slog.LevelInfo,
+	log15.LvlWarn:  slog.LevelWarn,
+	log15.LvlError: slog.LevelError,
+	log15.LvlCrit:  slog.LevelError + 4,
+}
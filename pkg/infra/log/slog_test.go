@@ -0,0 +1,57 @@
+//+build go1.21
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlog(t *testing.T) {
+	t.Run("Records of slog loggers are logged with the logger name", func(t *testing.T) {
+		var logged []*log15.Record
+		Root.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+			logged = append(logged, r)
+			return nil
+		}))
+		t.Cleanup(func() {
+			Root.SetHandler(log15.DiscardHandler())
+		})
+
+		logger := NewSlogLogger("plugins.client").With("pluginID", "loki").WithGroup("request")
+		logger.Debug("Sending request", "method", "GET")
+		logger.Warn("Slow request", slog.Group("timing", "ms", 1200))
+		logger.Error("Request failed", "error", errors.New("timeout"))
+
+		require.Len(t, logged, 3)
+		assert.Equal(t, []log15.Lvl{log15.LvlDebug, log15.LvlWarn, log15.LvlError}, []log15.Lvl{logged[0].Lvl, logged[1].Lvl, logged[2].Lvl})
+		assert.Equal(t, "Sending request", logged[0].Msg)
+		assert.Equal(t, []interface{}{"logger", "plugins.client", "pluginID", "loki", "request.method", "GET"}, logged[0].Ctx)
+		assert.Equal(t, []interface{}{"logger", "plugins.client", "pluginID", "loki", "request.timing.ms", int64(1200)}, logged[1].Ctx)
+		assert.Equal(t, "timeout", logged[2].Ctx[5].(error).Error())
+	})
+
+	t.Run("Records are passed on to slog handlers", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := SlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelInfo,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+
+		require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlDebug, Msg: "Query", Ctx: []interface{}{"logger", "sqlstore"}}))
+		require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlError, Msg: "Query failed", Ctx: []interface{}{"logger", "sqlstore", "table", "user"}}))
+
+		assert.Equal(t, "level=ERROR msg=\"Query failed\" logger=sqlstore table=user\n", buf.String())
+	})
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"errors"
+
+	"github.com/go-stack/stack"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// StackSettings are the settings of the stack traces added to error records.
+type StackSettings struct {
+	// All adds stack traces to the error records of all loggers, and Loggers to the error records of
+	// the named loggers only.
+	All     bool
+	Loggers map[string]bool
+	// Skip is the number of calls skipped at the top of the stack, like those of logging helpers,
+	// and Depth the number of calls of the stack trace after that.
+	Skip  int
+	Depth int
+}
+
+// readStackSettings reads the error_stack settings of the [log] section.
+func readStackSettings(sec *ini.Section) (StackSettings, error) {
+	settings := StackSettings{
+		All:     sec.Key("error_stack").MustBool(false),
+		Loggers: map[string]bool{},
+		Skip:    sec.Key("error_stack_skip").MustInt(0),
+		Depth:   sec.Key("error_stack_depth").MustInt(10),
+	}
+	for _, name := range util.SplitString(sec.Key("error_stack_loggers").String()) {
+		settings.Loggers[name] = true
+	}
+	if settings.Skip < 0 {
+		return StackSettings{}, errors.New("error_stack_skip must not be negative")
+	}
+	if settings.Depth <= 0 {
+		return StackSettings{}, errors.New("error_stack_depth must be greater than 0")
+	}
+	return settings, nil
+}
+
+// StackHandler adds the stack trace of the logging call to the error and critical records of the
+// loggers of the settings, in the `stack` key, before passing them on to h.
+func StackHandler(settings StackSettings, h log15.Handler) log15.Handler {
+	if !settings.All && len(settings.Loggers) == 0 {
+		return h
+	}
+
+	return log15.FuncHandler(func(r *log15.Record) error {
+		if r.Lvl > log15.LvlError {
+			return h.Log(r)
+		}
+		if !settings.All {
+			if logger, _ := recordLogger(r); !settings.Loggers[logger] {
+				return h.Log(r)
+			}
+		}
+
+		s := stack.Trace().TrimBelow(r.Call).TrimRuntime()
+		if settings.Skip >= len(s) {
+			return h.Log(r)
+		}
+		s = s[settings.Skip:]
+		if len(s) > settings.Depth {
+			s = s[:settings.Depth]
+		}
+
+		traced := *r
+		traced.Ctx = append(r.Ctx[:len(r.Ctx):len(r.Ctx)], "stack", s.String())
+		return h.Log(&traced)
+	})
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestStackHandler(t *testing.T) {
+	readSettings := func(t *testing.T, config string) StackSettings {
+		t.Helper()
+		cfg, err := ini.Load([]byte("[log]\n" + config))
+		require.NoError(t, err)
+		settings, err := readStackSettings(cfg.Section("log"))
+		require.NoError(t, err)
+		return settings
+	}
+	newLogger := func(settings StackSettings, name string) (log15.Logger, *[]*log15.Record) {
+		var records []*log15.Record
+		logger := log15.New("logger", name)
+		logger.SetHandler(StackHandler(settings, log15.FuncHandler(func(r *log15.Record) error {
+			records = append(records, r)
+			return nil
+		})))
+		return logger, &records
+	}
+	stackOf := func(r *log15.Record) string {
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			if r.Ctx[i] == "stack" {
+				return r.Ctx[i+1].(string)
+			}
+		}
+		return ""
+	}
+
+	t.Run("Stack traces are added to error records", func(t *testing.T) {
+		logger, records := newLogger(readSettings(t, "error_stack = true\nerror_stack_depth = 1"), "sqlstore")
+		logger.Info("Query")
+		logger.Error("Query failed")
+
+		require.Len(t, *records, 2)
+		assert.Empty(t, stackOf((*records)[0]))
+		stack := stackOf((*records)[1])
+		assert.True(t, strings.HasPrefix(stack, "[stack_test.go:"), stack)
+		assert.Equal(t, 1, strings.Count(stack, ".go:"), stack)
+	})
+
+	t.Run("Stack traces are added to the error records of the given loggers", func(t *testing.T) {
+		settings := readSettings(t, "error_stack_loggers = sqlstore")
+		logger, records := newLogger(settings, "sqlstore")
+		other, otherRecords := newLogger(settings, "server")
+		logger.Crit("Database is gone")
+		other.Error("Request failed")
+
+		require.Len(t, *records, 1)
+		assert.NotEmpty(t, stackOf((*records)[0]))
+		require.Len(t, *otherRecords, 1)
+		assert.Empty(t, stackOf((*otherRecords)[0]))
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log]\nerror_stack_depth = 0"))
+		require.NoError(t, err)
+		_, err = readStackSettings(cfg.Section("log"))
+		require.EqualError(t, err, "error_stack_depth must be greater than 0")
+	})
+}
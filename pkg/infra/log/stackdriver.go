@@ -0,0 +1,209 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+	"github.com/inconshreveable/log15"
+	"google.golang.org/api/googleapi"
+	logging "google.golang.org/api/logging/v2"
+	"google.golang.org/api/option"
+	"gopkg.in/ini.v1"
+)
+
+// stackdriverSeverities are the Cloud Logging severities of the levels of records.
+var stackdriverSeverities = map[log15.Lvl]string{
+	log15.LvlDebug: "DEBUG",
+	log15.LvlInfo:  "INFO",
+	log15.LvlWarn:  "WARNING",
+	log15.LvlError: "ERROR",
+	log15.LvlCrit:  "CRITICAL",
+}
+
+// gceMetadata returns the project ID, instance ID and zone of the Compute Engine instance Grafana
+// runs on, or false if it doesn't run on Compute Engine.
+// Stubbable by tests.
+var gceMetadata = func() (projectID string, instanceID string, zone string, ok bool) {
+	if !metadata.OnGCE() {
+		return "", "", "", false
+	}
+	var err error
+	if projectID, err = metadata.ProjectID(); err != nil {
+		return "", "", "", false
+	}
+	if instanceID, err = metadata.InstanceID(); err != nil {
+		return "", "", "", false
+	}
+	if zone, err = metadata.Zone(); err != nil {
+		return "", "", "", false
+	}
+	return projectID, instanceID, zone, true
+}
+
+// StackdriverHandler writes log records to Google Cloud Logging in batches, as structured entries
+// with the message and key values of records as JSON payload. The levels of records are mapped to
+// Cloud Logging severities, and on Compute Engine entries are attached to the gce_instance
+// resource of the instance, with its instance_id and zone labels.
+type StackdriverHandler struct {
+	BatchSettings
+	ProjectID       string
+	LogName         string
+	CredentialsFile string
+	Endpoint        string
+	Labels          map[string]string
+
+	service  *logging.Service
+	resource *logging.MonitoredResource
+	batcher  *recordBatcher
+}
+
+// NewStackdriverHandler creates a StackdriverHandler from the settings of the `log.stackdriver`
+// section.
+func NewStackdriverHandler(sec *ini.Section) (*StackdriverHandler, error) {
+	labels, err := parseKeyValues("labels", sec.Key("labels").MustString(""))
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &StackdriverHandler{
+		BatchSettings:   readBatchSettings(sec),
+		ProjectID:       sec.Key("project_id").MustString(""),
+		LogName:         sec.Key("log_name").MustString("grafana"),
+		CredentialsFile: sec.Key("credentials_file").MustString(""),
+		Endpoint:        sec.Key("endpoint").MustString(""),
+		Labels:          labels,
+	}
+
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// Init validates the settings of the handler, looks up the resource entries are attached to, and
+// starts writing records.
+func (h *StackdriverHandler) Init() error {
+	if h.LogName == "" {
+		return errors.New("log_name is required to write logs to Cloud Logging")
+	}
+	if err := h.BatchSettings.validate(); err != nil {
+		return err
+	}
+
+	h.resource = &logging.MonitoredResource{Type: "global", Labels: map[string]string{}}
+	if projectID, instanceID, zone, ok := gceMetadata(); ok {
+		if h.ProjectID == "" {
+			h.ProjectID = projectID
+		}
+		h.resource = &logging.MonitoredResource{
+			Type:   "gce_instance",
+			Labels: map[string]string{"instance_id": instanceID, "zone": zone},
+		}
+	}
+	if h.ProjectID == "" {
+		return errors.New("project_id is required to write logs to Cloud Logging outside of Compute Engine")
+	}
+	h.resource.Labels["project_id"] = h.ProjectID
+
+	if h.service == nil {
+		opts := []option.ClientOption{option.WithScopes(logging.LoggingWriteScope)}
+		if h.CredentialsFile != "" {
+			opts = append(opts, option.WithCredentialsFile(h.CredentialsFile))
+		}
+		if h.Endpoint != "" {
+			opts = append(opts, option.WithEndpoint(h.Endpoint))
+		}
+		service, err := logging.NewService(context.Background(), opts...)
+		if err != nil {
+			return err
+		}
+		h.service = service
+	}
+
+	h.batcher = newRecordBatcher(h.BatchSettings, "Cloud Logging", h.write, isRetryableStackdriverError)
+	return nil
+}
+
+// Log queues a record to be written to Cloud Logging.
+func (h *StackdriverHandler) Log(r *log15.Record) error {
+	return h.batcher.queue(r)
+}
+
+// Close writes the queued records, and stops the handler.
+func (h *StackdriverHandler) Close() error {
+	return h.CloseContext(context.Background())
+}
+
+// CloseContext writes the queued records until ctx is done, and stops the handler. The records
+// that aren't written by then are dropped.
+func (h *StackdriverHandler) CloseContext(ctx context.Context) error {
+	return h.batcher.close(ctx)
+}
+
+func (h *StackdriverHandler) write(batch []*log15.Record) error {
+	req, err := h.encodeBatch(batch)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+	_, err = h.service.Entries.Write(req).Context(ctx).Do()
+	return err
+}
+
+// encodeBatch converts the records of a batch to log entries. The traceID and spanID added by the
+// context loggers are the trace and span of entries, so Cloud Logging links them to Cloud Trace.
+func (h *StackdriverHandler) encodeBatch(batch []*log15.Record) (*logging.WriteLogEntriesRequest, error) {
+	req := &logging.WriteLogEntriesRequest{
+		LogName:  fmt.Sprintf("projects/%s/logs/%s", h.ProjectID, url.PathEscape(h.LogName)),
+		Resource: h.resource,
+		Labels:   h.Labels,
+		Entries:  make([]*logging.LogEntry, 0, len(batch)),
+	}
+	for _, r := range batch {
+		entry := &logging.LogEntry{
+			Timestamp: r.Time.UTC().Format(time.RFC3339Nano),
+			Severity:  stackdriverSeverities[r.Lvl],
+		}
+
+		payload := map[string]interface{}{"message": r.Msg}
+		for i := 0; i < len(r.Ctx)-1; i += 2 {
+			key, ok := r.Ctx[i].(string)
+			if !ok {
+				key = fmt.Sprint(r.Ctx[i])
+			}
+			value := otelJSONValue(r.Ctx[i+1])
+			switch key {
+			case "traceID":
+				entry.Trace = fmt.Sprintf("projects/%s/traces/%v", h.ProjectID, value)
+			case "spanID":
+				entry.SpanId = fmt.Sprint(value)
+			default:
+				payload[key] = value
+			}
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		entry.JsonPayload = data
+		req.Entries = append(req.Entries, entry)
+	}
+	return req, nil
+}
+
+// isRetryableStackdriverError returns true for network errors, rate limiting and server errors.
+func isRetryableStackdriverError(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code/100 == 5
+	}
+	return true
+}
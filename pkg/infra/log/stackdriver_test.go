@@ -0,0 +1,177 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	logging "google.golang.org/api/logging/v2"
+	"google.golang.org/api/option"
+	"gopkg.in/ini.v1"
+)
+
+type fakeCloudLogging struct {
+	mu       sync.Mutex
+	paths    []string
+	writes   []logging.WriteLogEntriesRequest
+	statuses []int
+}
+
+func (f *fakeCloudLogging) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.paths = append(f.paths, r.URL.Path)
+	if len(f.statuses) > 0 {
+		status := f.statuses[0]
+		f.statuses = f.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
+	var write logging.WriteLogEntriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&write); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.writes = append(f.writes, write)
+	_, _ = w.Write([]byte("{}"))
+}
+
+func stubGCEMetadata(t *testing.T, onGCE bool) {
+	t.Helper()
+
+	previous := gceMetadata
+	gceMetadata = func() (string, string, string, bool) {
+		if !onGCE {
+			return "", "", "", false
+		}
+		return "gce-project", "1234", "europe-west1-b", true
+	}
+	t.Cleanup(func() {
+		gceMetadata = previous
+	})
+}
+
+func newStackdriverTestHandler(t *testing.T, cloudLogging *fakeCloudLogging, config string) *StackdriverHandler {
+	t.Helper()
+
+	server := httptest.NewServer(cloudLogging)
+	t.Cleanup(server.Close)
+	service, err := logging.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	require.NoError(t, err)
+
+	cfg, err := ini.Load([]byte("[log.stackdriver]\n" + config))
+	require.NoError(t, err)
+	sec := cfg.Section("log.stackdriver")
+	labels, err := parseKeyValues("labels", sec.Key("labels").String())
+	require.NoError(t, err)
+	handler := &StackdriverHandler{
+		BatchSettings: readBatchSettings(sec),
+		ProjectID:     sec.Key("project_id").String(),
+		LogName:       sec.Key("log_name").MustString("grafana"),
+		Labels:        labels,
+		service:       service,
+	}
+	require.NoError(t, handler.Init())
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func TestStackdriverHandler(t *testing.T) {
+	t.Run("Records are written as structured entries with severities", func(t *testing.T) {
+		stubGCEMetadata(t, false)
+		cloudLogging := &fakeCloudLogging{}
+		handler := newStackdriverTestHandler(t, cloudLogging, "project_id = my-project\nbatch_size = 2\nbatch_wait = 1h\nlabels = env:test")
+
+		record := lokiTestRecord(log15.LvlWarn, "sqlstore", "first")
+		record.Ctx = append(record.Ctx, "took", time.Second, "traceID", "abc", "spanID", "def")
+		require.NoError(t, handler.Log(record))
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlCrit, "server", "second")))
+		require.NoError(t, handler.Close())
+
+		require.Len(t, cloudLogging.writes, 1)
+		assert.Equal(t, "/v2/entries:write", cloudLogging.paths[0])
+		write := cloudLogging.writes[0]
+		assert.Equal(t, "projects/my-project/logs/grafana", write.LogName)
+		assert.Equal(t, &logging.MonitoredResource{Type: "global", Labels: map[string]string{"project_id": "my-project"}}, write.Resource)
+		assert.Equal(t, map[string]string{"env": "test"}, write.Labels)
+
+		require.Len(t, write.Entries, 2)
+		first := write.Entries[0]
+		assert.Equal(t, "WARNING", first.Severity)
+		assert.Equal(t, "2021-03-10T12:00:00Z", first.Timestamp)
+		assert.Equal(t, "projects/my-project/traces/abc", first.Trace)
+		assert.Equal(t, "def", first.SpanId)
+		assert.JSONEq(t, `{"message":"first","logger":"sqlstore","took":"1s"}`, string(first.JsonPayload))
+		assert.Equal(t, "CRITICAL", write.Entries[1].Severity)
+	})
+
+	t.Run("Entries are attached to the Compute Engine instance", func(t *testing.T) {
+		stubGCEMetadata(t, true)
+		cloudLogging := &fakeCloudLogging{}
+		handler := newStackdriverTestHandler(t, cloudLogging, "batch_wait = 1h")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.NoError(t, handler.Close())
+
+		require.Len(t, cloudLogging.writes, 1)
+		assert.Equal(t, "projects/gce-project/logs/grafana", cloudLogging.writes[0].LogName)
+		assert.Equal(t, &logging.MonitoredResource{
+			Type:   "gce_instance",
+			Labels: map[string]string{"project_id": "gce-project", "instance_id": "1234", "zone": "europe-west1-b"},
+		}, cloudLogging.writes[0].Resource)
+	})
+
+	t.Run("Writes are retried on server errors", func(t *testing.T) {
+		stubGCEMetadata(t, false)
+		cloudLogging := &fakeCloudLogging{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+		handler := newStackdriverTestHandler(t, cloudLogging, "project_id = my-project\nbatch_size = 1\nbatch_wait = 1h\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.Eventually(t, func() bool {
+			cloudLogging.mu.Lock()
+			defer cloudLogging.mu.Unlock()
+			return len(cloudLogging.writes) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, handler.Close())
+
+		assert.Len(t, cloudLogging.paths, 3)
+	})
+
+	t.Run("Writes rejected by Cloud Logging are not retried", func(t *testing.T) {
+		stubGCEMetadata(t, false)
+		cloudLogging := &fakeCloudLogging{statuses: []int{http.StatusForbidden}}
+		handler := newStackdriverTestHandler(t, cloudLogging, "project_id = my-project\nbatch_size = 1\nbatch_wait = 1h\nmin_backoff = 1ms")
+
+		require.NoError(t, handler.Log(lokiTestRecord(log15.LvlInfo, "sqlstore", "first")))
+		require.NoError(t, handler.Close())
+
+		assert.Len(t, cloudLogging.paths, 1)
+		assert.Empty(t, cloudLogging.writes)
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		stubGCEMetadata(t, false)
+		cfg, err := ini.Load([]byte("[log.stackdriver]\nlabels = env"))
+		require.NoError(t, err)
+
+		_, err = NewStackdriverHandler(cfg.Section("log.stackdriver"))
+		require.Error(t, err)
+
+		cfg.Section("log.stackdriver").Key("labels").SetValue("env:test")
+		_, err = NewStackdriverHandler(cfg.Section("log.stackdriver"))
+		require.EqualError(t, err, "project_id is required to write logs to Cloud Logging outside of Compute Engine")
+	})
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// buildVersion is the Grafana version added to records by the version static field.
+var buildVersion = "unknown"
+
+// SetVersion sets the Grafana version added to records by the version static field. It applies
+// the next time the logging config is read.
+func SetVersion(version string) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	buildVersion = version
+}
+
+// buildCommit and buildStamp are the git commit and the build time, as seconds since the epoch,
+// added to records by the commit and build_date static fields.
+var (
+	buildCommit = "unknown"
+	buildStamp  int64
+)
+
+// SetBuildInfo sets the git commit and the build time, as seconds since the epoch, added to records
+// by the commit and build_date static fields. It applies the next time the logging config is read.
+func SetBuildInfo(commit string, stamp int64) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	buildCommit, buildStamp = commit, stamp
+}
+
+// getStaticFields returns the key values of the static_fields setting. Fields are either one of
+// hostname, pid, version, commit, build_date, goroutine and instance_name, or a constant key:value
+// pair.
+func getStaticFields(names []string, cfg *ini.File) ([]interface{}, error) {
+	fields := make([]interface{}, 0, 2*len(names))
+	for _, name := range names {
+		switch name {
+		case "hostname":
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get hostname: %w", err)
+			}
+			fields = append(fields, "hostname", hostname)
+		case "pid":
+			fields = append(fields, "pid", os.Getpid())
+		case "version":
+			fields = append(fields, "version", buildVersion)
+		case "commit":
+			fields = append(fields, "commit", buildCommit)
+		case "build_date":
+			fields = append(fields, "build_date", time.Unix(buildStamp, 0).UTC().Format(time.RFC3339))
+		case "goroutine":
+			// A lazy value, so it's the ID of the goroutine logging the record.
+			fields = append(fields, "goroutine", goroutineID)
+		case "instance_name":
+			fields = append(fields, "instance_name", cfg.Section("").Key("instance_name").MustString("unknown_instance_name"))
+		default:
+			parts := strings.SplitN(name, ":", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return nil, fmt.Errorf("invalid static field %q, valid options are hostname, pid, version, commit, build_date, goroutine, instance_name and key:value", name)
+			}
+			fields = append(fields, parts[0], parts[1])
+		}
+	}
+	return fields, nil
+}
+
+// StaticFieldsHandler adds the key values of fields to all records before passing them on to h.
+func StaticFieldsHandler(fields []interface{}, h log15.Handler) log15.Handler {
+	if len(fields) == 0 {
+		return h
+	}
+
+	return log15.FuncHandler(func(r *log15.Record) error {
+		static := *r
+		static.Ctx = append(r.Ctx[:len(r.Ctx):len(r.Ctx)], fields...)
+		return h.Log(&static)
+	})
+}
+
+// goroutineID returns the ID of the calling goroutine, from the header of its stack trace, like
+// `goroutine 42 [running]:`.
+func goroutineID() interface{} {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, err := strconv.ParseUint(string(buf), 10, 64)
+	if err != nil {
+		return "unknown"
+	}
+	return id
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestStaticFields(t *testing.T) {
+	t.Run("Static fields are added to all records", func(t *testing.T) {
+		SetVersion("8.0.0")
+		t.Cleanup(func() {
+			SetVersion("unknown")
+		})
+		cfg, err := ini.Load([]byte("instance_name = grafana-1"))
+		require.NoError(t, err)
+		fields, err := getStaticFields([]string{"hostname", "pid", "version", "instance_name", "env:prod"}, cfg)
+		require.NoError(t, err)
+
+		var logged []*log15.Record
+		handler := StaticFieldsHandler(fields, log15.FuncHandler(func(r *log15.Record) error {
+			logged = append(logged, r)
+			return nil
+		}))
+		record := &log15.Record{Msg: "Started", Ctx: []interface{}{"logger", "server"}}
+		require.NoError(t, handler.Log(record))
+
+		hostname, err := os.Hostname()
+		require.NoError(t, err)
+		require.Len(t, logged, 1)
+		assert.Equal(t, []interface{}{
+			"logger", "server", "hostname", hostname, "pid", os.Getpid(), "version", "8.0.0",
+			"instance_name", "grafana-1", "env", "prod",
+		}, logged[0].Ctx)
+		assert.Equal(t, []interface{}{"logger", "server"}, record.Ctx)
+	})
+
+	t.Run("Build info fields are added to all records", func(t *testing.T) {
+		SetBuildInfo("abc1234", 1615377600)
+		t.Cleanup(func() {
+			SetBuildInfo("unknown", 0)
+		})
+		fields, err := getStaticFields([]string{"commit", "build_date"}, ini.Empty())
+		require.NoError(t, err)
+
+		assert.Equal(t, []interface{}{"commit", "abc1234", "build_date", "2021-03-10T12:00:00Z"}, fields)
+	})
+
+	t.Run("The goroutine field is the ID of the goroutine logging the record", func(t *testing.T) {
+		fields, err := getStaticFields([]string{"goroutine"}, ini.Empty())
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		ids := map[interface{}]bool{}
+		handler := StaticFieldsHandler(fields, LazyHandler(func(*log15.Record) bool { return true }, log15.FuncHandler(func(r *log15.Record) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ids[r.Ctx[1]] = true
+			return nil
+		})))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, handler.Log(&log15.Record{Msg: "Started"}))
+			}()
+		}
+		wg.Wait()
+
+		require.Len(t, ids, 3)
+		for id := range ids {
+			assert.IsType(t, uint64(0), id)
+		}
+	})
+
+	t.Run("Unknown static fields are rejected", func(t *testing.T) {
+		_, err := getStaticFields([]string{"uptime"}, ini.Empty())
+		require.EqualError(t, err, `invalid static field "uptime", valid options are hostname, pid, version, commit, build_date, goroutine, instance_name and key:value`)
+	})
+}
@@ -3,7 +3,9 @@
 package log
 
 import (
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"log/syslog"
 	"os"
 
@@ -13,11 +15,18 @@ import (
 
 type SysLogHandler struct {
 	syslog   *syslog.Writer
+	rfc5424  *rfc5424Writer
 	Network  string
 	Address  string
 	Facility string
 	Tag      string
 	Format   log15.Format
+	// Protocol is the format of the messages, either "rfc3164" or "rfc5424". RFC 5424 messages
+	// have the key values of records as structured data, with the ID StructuredDataID.
+	Protocol         string
+	StructuredDataID string
+	// TLSSettings configure the connection when Network is "tls", which requires RFC 5424.
+	TLSSettings
 }
 
 func NewSyslog(sec *ini.Section, format log15.Format) *SysLogHandler {
@@ -30,6 +39,9 @@ func NewSyslog(sec *ini.Section, format log15.Format) *SysLogHandler {
 	handler.Address = sec.Key("address").MustString("")
 	handler.Facility = sec.Key("facility").MustString("local7")
 	handler.Tag = sec.Key("tag").MustString("")
+	handler.Protocol = sec.Key("protocol").MustString("rfc3164")
+	handler.StructuredDataID = sec.Key("structured_data_id").MustString("grafana@32473")
+	handler.TLSSettings = readTLSSettings(sec)
 
 	if err := handler.Init(); err != nil {
 		Root.Error("Failed to init syslog log handler", "error", err)
@@ -45,6 +57,17 @@ func (sw *SysLogHandler) Init() error {
 		return err
 	}
 
+	switch sw.Protocol {
+	case "rfc5424":
+		return sw.initRFC5424(prio)
+	case "rfc3164":
+	default:
+		return fmt.Errorf("unknown syslog protocol %q, valid options are rfc3164 and rfc5424", sw.Protocol)
+	}
+	if sw.Network == "tls" {
+		return errors.New("the tls syslog network requires the rfc5424 protocol")
+	}
+
 	w, err := syslog.Dial(sw.Network, sw.Address, prio, sw.Tag)
 	if err != nil {
 		return err
@@ -54,7 +77,33 @@ func (sw *SysLogHandler) Init() error {
 	return nil
 }
 
+func (sw *SysLogHandler) initRFC5424(facility syslog.Priority) error {
+	if sw.StructuredDataID == "" || syslogParamName(sw.StructuredDataID) != sw.StructuredDataID {
+		return fmt.Errorf("invalid syslog structured_data_id %q", sw.StructuredDataID)
+	}
+
+	var tlsConfig *tls.Config
+	if sw.Network == "tls" {
+		var err error
+		if tlsConfig, err = sw.TLSSettings.config(sw.Address); err != nil {
+			return fmt.Errorf("invalid syslog TLS settings: %w", err)
+		}
+	}
+
+	w, err := newRFC5424Writer(sw.Network, sw.Address, tlsConfig, facility, sw.Tag, sw.StructuredDataID)
+	if err != nil {
+		return err
+	}
+
+	sw.rfc5424 = w
+	return nil
+}
+
 func (sw *SysLogHandler) Log(r *log15.Record) error {
+	if sw.rfc5424 != nil {
+		return sw.rfc5424.write(r)
+	}
+
 	var err error
 
 	msg := string(sw.Format.Format(r))
@@ -78,6 +127,9 @@ func (sw *SysLogHandler) Log(r *log15.Record) error {
 }
 
 func (sw *SysLogHandler) Close() error {
+	if sw.rfc5424 != nil {
+		return sw.rfc5424.close()
+	}
 	return sw.syslog.Close()
 }
 
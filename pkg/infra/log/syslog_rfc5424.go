@@ -0,0 +1,231 @@
+//+build !windows,!nacl,!plan9
+
+package log
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/syslog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// syslogSeverities are the syslog severities of the levels of records.
+var syslogSeverities = map[log15.Lvl]int{
+	log15.LvlDebug: 7,
+	log15.LvlInfo:  6,
+	log15.LvlWarn:  4,
+	log15.LvlError: 3,
+	log15.LvlCrit:  2,
+}
+
+// syslogLocalEndpoints are the sockets of the local syslog daemon, tried in order when no network
+// is configured.
+var syslogLocalEndpoints = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// rfc5424Writer writes records as RFC 5424 syslog messages, with the key values of records as the
+// parameters of a structured data element. Messages are framed with their length over stream
+// connections, as RFC 5425 requires over TLS, and sent as one datagram each otherwise.
+type rfc5424Writer struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	facility  syslog.Priority
+	hostname  string
+	appName   string
+	sdID      string
+
+	mu   sync.Mutex
+	conn net.Conn
+	// connNetwork is the network of the connection, which tells how messages are framed.
+	connNetwork string
+}
+
+func newRFC5424Writer(network, address string, tlsConfig *tls.Config, facility syslog.Priority, tag, sdID string) (*rfc5424Writer, error) {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+
+	w := &rfc5424Writer{
+		network:   network,
+		address:   address,
+		tlsConfig: tlsConfig,
+		facility:  facility,
+		hostname:  hostname,
+		appName:   syslogHeaderField(tag, 48),
+		sdID:      sdID,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rfc5424Writer) connect() error {
+	if w.network == "" {
+		for _, network := range []string{"unixgram", "unix"} {
+			for _, path := range syslogLocalEndpoints {
+				if conn, err := net.Dial(network, path); err == nil {
+					w.conn, w.connNetwork = conn, network
+					return nil
+				}
+			}
+		}
+		return errors.New("unix syslog delivery error")
+	}
+
+	var err error
+	if w.tlsConfig != nil {
+		w.conn, err = tls.Dial("tcp", w.address, w.tlsConfig)
+	} else {
+		w.conn, err = net.Dial(w.network, w.address)
+	}
+	w.connNetwork = w.network
+	return err
+}
+
+// write writes a record, and connects again to retry once when writing fails, like log/syslog does.
+func (w *rfc5424Writer) write(r *log15.Record) error {
+	msg := w.format(r)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if err := w.send(msg); err == nil {
+			return nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return err
+	}
+	return w.send(msg)
+}
+
+func (w *rfc5424Writer) send(msg []byte) error {
+	switch w.connNetwork {
+	case "tcp", "tcp4", "tcp6", "tls":
+		msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+	case "unix":
+		// Local daemons split the messages of stream sockets by lines.
+		msg = append(msg, '\n')
+	}
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+func (w *rfc5424Writer) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// format formats a record as `<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG`.
+func (w *rfc5424Writer) format(r *log15.Record) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d - ",
+		int(w.facility)|syslogSeverities[r.Lvl],
+		r.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname,
+		w.appName,
+		os.Getpid(),
+	)
+	w.writeStructuredData(&buf, r.Ctx)
+	if r.Msg != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(r.Msg)
+	}
+	return buf.Bytes()
+}
+
+func (w *rfc5424Writer) writeStructuredData(buf *bytes.Buffer, ctx []interface{}) {
+	if len(ctx) < 2 {
+		buf.WriteByte('-')
+		return
+	}
+
+	buf.WriteByte('[')
+	buf.WriteString(w.sdID)
+	for i := 0; i < len(ctx)-1; i += 2 {
+		key, ok := ctx[i].(string)
+		if !ok {
+			key = fmt.Sprint(ctx[i])
+		}
+		name := syslogParamName(key)
+		if name == "" {
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(name)
+		buf.WriteString(`="`)
+		buf.WriteString(syslogParamValueReplacer.Replace(syslogValue(ctx[i+1])))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+}
+
+// syslogParamValueReplacer escapes the characters RFC 5424 requires escaping in parameter values.
+var syslogParamValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogParamName converts a key to a parameter name, which may only have printable ASCII
+// characters other than '=', ' ', ']' and '"', and at most 32 of them.
+func syslogParamName(key string) string {
+	var name strings.Builder
+	for i := 0; i < len(key) && name.Len() < 32; i++ {
+		c := key[i]
+		if c > ' ' && c < 127 && c != '=' && c != ']' && c != '"' {
+			name.WriteByte(c)
+		}
+	}
+	return name.String()
+}
+
+// syslogHeaderField converts a value to a header field, which may only have printable ASCII
+// characters, and is "-" when empty.
+func syslogHeaderField(value string, maxLen int) string {
+	var field strings.Builder
+	for i := 0; i < len(value) && field.Len() < maxLen; i++ {
+		if c := value[i]; c > ' ' && c < 127 {
+			field.WriteByte(c)
+		}
+	}
+	if field.Len() == 0 {
+		return "-"
+	}
+	return field.String()
+}
+
+func syslogValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
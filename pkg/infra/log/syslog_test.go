@@ -0,0 +1,199 @@
+//+build !windows,!nacl,!plan9
+
+package log
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func newSyslogTestHandler(t *testing.T, config string) *SysLogHandler {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.syslog]\n" + config))
+	require.NoError(t, err)
+	handler := NewSyslog(cfg.Section("log.syslog"), log15.LogfmtFormat())
+	t.Cleanup(func() {
+		_ = handler.Close()
+	})
+	return handler
+}
+
+func syslogTestRecord() *log15.Record {
+	return &log15.Record{
+		Time: time.Date(2021, 3, 10, 12, 0, 0, 123456000, time.UTC),
+		Lvl:  log15.LvlWarn,
+		Msg:  "Request failed",
+		Ctx:  []interface{}{"logger", "context", "path", `/api/"x"]`, "orgId", 1},
+	}
+}
+
+// readOctetCounted reads a message framed with its length.
+func readOctetCounted(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+
+	size, err := r.ReadString(' ')
+	require.NoError(t, err)
+	n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+	require.NoError(t, err)
+	msg := make([]byte, n)
+	_, err = io.ReadFull(r, msg)
+	require.NoError(t, err)
+	return string(msg)
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 usable by servers and
+// clients, and returns the paths of the certificate and its key.
+func writeTestCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPath, keyPath
+}
+
+func TestSyslogRFC5424(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	expected := fmt.Sprintf(`<188>1 2021-03-10T12:00:00.123456Z %s grafana-server %d - `+
+		`[grafana@32473 logger="context" path="/api/\"x\"\]" orgId="1"] Request failed`, hostname, os.Getpid())
+
+	t.Run("Messages are written over TCP framed with their length", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() {
+			_ = listener.Close()
+		}()
+		handler := newSyslogTestHandler(t, "network = tcp\naddress = "+listener.Addr().String()+"\nprotocol = rfc5424\ntag = grafana-server")
+
+		conn, err := listener.Accept()
+		require.NoError(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+		require.NoError(t, handler.Log(syslogTestRecord()))
+		require.NoError(t, handler.Log(&log15.Record{Time: time.Now(), Lvl: log15.LvlInfo, Msg: "Done"}))
+
+		r := bufio.NewReader(conn)
+		assert.Equal(t, expected, readOctetCounted(t, r))
+		assert.Contains(t, readOctetCounted(t, r), "<190>1 ")
+	})
+
+	t.Run("Messages are written as datagrams over UDP", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+		handler := newSyslogTestHandler(t, "network = udp\naddress = "+conn.LocalAddr().String()+"\nprotocol = rfc5424\ntag = grafana-server\nfacility = local7")
+
+		require.NoError(t, handler.Log(syslogTestRecord()))
+
+		buf := make([]byte, 1024)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(buf[:n]))
+	})
+
+	t.Run("Messages are written over TLS with a client certificate", func(t *testing.T) {
+		certPath, keyPath := writeTestCertificate(t)
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		require.NoError(t, err)
+		pool := x509.NewCertPool()
+		pool.AddCert(func() *x509.Certificate {
+			c, err := x509.ParseCertificate(cert.Certificate[0])
+			require.NoError(t, err)
+			return c
+		}())
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		})
+		require.NoError(t, err)
+		defer func() {
+			_ = listener.Close()
+		}()
+
+		received := make(chan string, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+			received <- readOctetCounted(t, bufio.NewReader(conn))
+		}()
+
+		handler := newSyslogTestHandler(t, "network = tls\naddress = "+listener.Addr().String()+"\nprotocol = rfc5424\ntag = grafana-server\n"+
+			"tls_ca_cert = "+certPath+"\ntls_cert = "+certPath+"\ntls_key = "+keyPath)
+		require.NoError(t, handler.Log(syslogTestRecord()))
+
+		select {
+		case msg := <-received:
+			assert.Equal(t, expected, msg)
+		case <-time.After(time.Second):
+			t.Fatal("no message received")
+		}
+	})
+
+	t.Run("Records without key values have no structured data", func(t *testing.T) {
+		w := &rfc5424Writer{facility: 0, hostname: "host", appName: "grafana", sdID: "grafana@32473"}
+		msg := w.format(&log15.Record{Time: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC), Lvl: log15.LvlError, Msg: "Failed"})
+		assert.Equal(t, fmt.Sprintf("<3>1 2021-03-10T12:00:00.000000Z host grafana %d - - Failed", os.Getpid()), string(msg))
+	})
+
+	t.Run("Invalid settings are rejected", func(t *testing.T) {
+		for sw, expected := range map[*SysLogHandler]string{
+			{Facility: "local7", Protocol: "rfc6587"}:                                                                `unknown syslog protocol "rfc6587", valid options are rfc3164 and rfc5424`,
+			{Facility: "local7", Protocol: "rfc3164", Network: "tls"}:                                                "the tls syslog network requires the rfc5424 protocol",
+			{Facility: "local7", Protocol: "rfc5424", StructuredDataID: "grafana 1"}:                                 `invalid syslog structured_data_id "grafana 1"`,
+			{Facility: "local7", Protocol: "rfc5424", StructuredDataID: "x@1", Network: "tls", Address: "localhost"}: `invalid syslog TLS settings: invalid address "localhost": address localhost: missing port in address`,
+		} {
+			require.EqualError(t, sw.Init(), expected)
+		}
+	})
+}
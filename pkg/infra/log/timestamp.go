@@ -0,0 +1,129 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"gopkg.in/ini.v1"
+)
+
+// The layouts of the timestamps of the log15 text formats, which are replaced when the timestamp
+// format is configured.
+const (
+	logfmtTimeLayout   = "2006-01-02T15:04:05-0700"
+	terminalTimeLayout = "01-02|15:04:05"
+)
+
+// timestampFormats are the named values of the timestamp_format setting. Other values are layouts
+// of the time package.
+var timestampFormats = map[string]func(t time.Time) interface{}{
+	"rfc3339": func(t time.Time) interface{} {
+		return t.Format(time.RFC3339)
+	},
+	"rfc3339nano": func(t time.Time) interface{} {
+		return t.Format(time.RFC3339Nano)
+	},
+	"epoch": func(t time.Time) interface{} {
+		return t.Unix()
+	},
+	"epoch_millis": func(t time.Time) interface{} {
+		return t.UnixNano() / int64(time.Millisecond)
+	},
+}
+
+// readLogFormat reads the format of the log lines of a mode, with the timestamp_format and
+// timestamp_utc settings applied to it.
+func readLogFormat(sec *ini.Section, defaultFormat string) (log15.Format, error) {
+	name := sec.Key("format").MustString(defaultFormat)
+	format := getLogFormat(name)
+
+	stamp, err := getTimestampFunc(sec.Key("timestamp_format").MustString(""))
+	if err != nil {
+		return nil, err
+	}
+	utc := sec.Key("timestamp_utc").MustBool(false)
+	if stamp == nil && !utc {
+		return format, nil
+	}
+
+	switch name {
+	case "otel_json", "cef", "logstash":
+		// The timestamps of the log data model and of CEF are always since the epoch, and those of
+		// Logstash are always in UTC.
+		return format, nil
+	case "json":
+		return jsonTimestampFormat(format, stamp, utc), nil
+	default:
+		return textTimestampFormat(format, stamp, utc), nil
+	}
+}
+
+func getTimestampFunc(timestampFormat string) (func(time.Time) interface{}, error) {
+	if timestampFormat == "" {
+		return nil, nil
+	}
+	if stamp, ok := timestampFormats[strings.ToLower(timestampFormat)]; ok {
+		return stamp, nil
+	}
+
+	// A layout without any element of the reference time is most likely a typo of a named format.
+	if time.Unix(0, 0).UTC().Format(timestampFormat) == timestampFormat {
+		return nil, fmt.Errorf("invalid timestamp_format %q, valid options are rfc3339, rfc3339nano, epoch, epoch_millis and layouts of the reference time", timestampFormat)
+	}
+	return func(t time.Time) interface{} {
+		return t.Format(timestampFormat)
+	}, nil
+}
+
+// jsonTimestampFormat formats the timestamps of JSON lines with stamp, in UTC if utc is set.
+func jsonTimestampFormat(format log15.Format, stamp func(time.Time) interface{}, utc bool) log15.Format {
+	return log15.FormatFunc(func(r *log15.Record) []byte {
+		stamped := *r
+		if utc {
+			stamped.Time = r.Time.UTC()
+		}
+		if stamp != nil {
+			// Key values are written after the timestamp, and replace it.
+			stamped.Ctx = append(r.Ctx[:len(r.Ctx):len(r.Ctx)], r.KeyNames.Time, stamp(stamped.Time))
+		}
+		return format.Format(&stamped)
+	})
+}
+
+// textTimestampFormat formats the timestamps of logfmt and terminal lines with stamp, in UTC if utc
+// is set. Both write the timestamp before anything else, so it's the first occurrence of the
+// record time formatted with their layout.
+func textTimestampFormat(format log15.Format, stamp func(time.Time) interface{}, utc bool) log15.Format {
+	return log15.FormatFunc(func(r *log15.Record) []byte {
+		stamped := *r
+		if utc {
+			stamped.Time = r.Time.UTC()
+		}
+		line := format.Format(&stamped)
+		if stamp == nil {
+			return line
+		}
+
+		for _, layout := range []string{logfmtTimeLayout, terminalTimeLayout} {
+			old := []byte(stamped.Time.Format(layout))
+			i := bytes.Index(line, old)
+			if i < 0 {
+				continue
+			}
+
+			value := fmt.Sprint(stamp(stamped.Time))
+			if i > 0 && line[i-1] == '=' && strings.ContainsAny(value, " =\"") {
+				value = strconv.Quote(value)
+			}
+			replaced := make([]byte, 0, len(line)-len(old)+len(value))
+			replaced = append(replaced, line[:i]...)
+			replaced = append(replaced, value...)
+			return append(replaced, line[i+len(old):]...)
+		}
+		return line
+	})
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func timestampTestRecord() *log15.Record {
+	return &log15.Record{
+		Time: time.Date(2021, 3, 10, 13, 0, 0, 123456789, time.FixedZone("CET", 3600)),
+		Lvl:  log15.LvlInfo,
+		Msg:  "Started",
+		Ctx:  []interface{}{"logger", "server"},
+		KeyNames: log15.RecordKeyNames{
+			Time: "t",
+			Msg:  "msg",
+			Lvl:  "lvl",
+		},
+	}
+}
+
+func readTestLogFormat(t *testing.T, config string) log15.Format {
+	t.Helper()
+
+	cfg, err := ini.Load([]byte("[log.console]\n" + config))
+	require.NoError(t, err)
+	format, err := readLogFormat(cfg.Section("log.console"), "")
+	require.NoError(t, err)
+	return format
+}
+
+func TestReadLogFormat(t *testing.T) {
+	t.Run("Text timestamps are formatted with the timestamp format", func(t *testing.T) {
+		for config, expected := range map[string]string{
+			"format = text":                       "t=2021-03-10T13:00:00+0100 lvl=info msg=Started logger=server\n",
+			"format = text\ntimestamp_utc = true": "t=2021-03-10T12:00:00+0000 lvl=info msg=Started logger=server\n",
+			"format = text\ntimestamp_format = rfc3339nano\ntimestamp_utc = true": "t=2021-03-10T12:00:00.123456789Z lvl=info msg=Started logger=server\n",
+			"format = text\ntimestamp_format = epoch_millis":                      "t=1615377600123 lvl=info msg=Started logger=server\n",
+			"format = text\ntimestamp_format = 2006-01-02 15:04:05":               `t="2021-03-10 13:00:00" lvl=info msg=Started logger=server` + "\n",
+		} {
+			assert.Equal(t, expected, string(readTestLogFormat(t, config).Format(timestampTestRecord())), config)
+		}
+	})
+
+	t.Run("JSON timestamps are formatted with the timestamp format", func(t *testing.T) {
+		for config, expected := range map[string]interface{}{
+			"format = json":                                                   "2021-03-10T13:00:00.123456789+01:00",
+			"format = json\ntimestamp_utc = true":                             "2021-03-10T12:00:00.123456789Z",
+			"format = json\ntimestamp_format = epoch_millis":                  float64(1615377600123),
+			"format = json\ntimestamp_format = rfc3339\ntimestamp_utc = true": "2021-03-10T12:00:00Z",
+		} {
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(readTestLogFormat(t, config).Format(timestampTestRecord()), &line))
+			assert.Equal(t, expected, line["t"], config)
+			assert.Equal(t, "server", line["logger"])
+		}
+	})
+
+	t.Run("Invalid timestamp formats are rejected", func(t *testing.T) {
+		cfg, err := ini.Load([]byte("[log.console]\ntimestamp_format = epoch_ms"))
+		require.NoError(t, err)
+		_, err = readLogFormat(cfg.Section("log.console"), "")
+		require.EqualError(t, err, `invalid timestamp_format "epoch_ms", valid options are rfc3339, rfc3339nano, epoch, epoch_millis and layouts of the reference time`)
+	})
+}
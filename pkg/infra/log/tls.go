@@ -0,0 +1,60 @@
+package log
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"gopkg.in/ini.v1"
+)
+
+// TLSSettings are the settings of handlers connecting to a remote service with TLS.
+type TLSSettings struct {
+	// CACert is the path of the CA certificate the server certificate is verified against, the
+	// system certificates are used when it's empty.
+	CACert string
+	// Cert and Key are the paths of the client certificate and its key, if the service requires one.
+	Cert       string
+	Key        string
+	SkipVerify bool
+}
+
+func readTLSSettings(sec *ini.Section) TLSSettings {
+	return TLSSettings{
+		CACert:     sec.Key("tls_ca_cert").MustString(""),
+		Cert:       sec.Key("tls_cert").MustString(""),
+		Key:        sec.Key("tls_key").MustString(""),
+		SkipVerify: sec.Key("tls_skip_verify").MustBool(false),
+	}
+}
+
+// config creates the TLS config of connections to a host:port address.
+func (s TLSSettings) config(addr string) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	config := &tls.Config{ServerName: host, InsecureSkipVerify: s.SkipVerify}
+
+	if s.CACert != "" {
+		pem, err := ioutil.ReadFile(s.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA certificate %q", s.CACert)
+		}
+	}
+
+	if s.Cert != "" || s.Key != "" {
+		cert, err := tls.LoadX509KeyPair(s.Cert, s.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
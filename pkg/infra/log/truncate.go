@@ -0,0 +1,68 @@
+package log
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/inconshreveable/log15"
+)
+
+// truncatedSuffix is appended to truncated messages and values.
+const truncatedSuffix = "..."
+
+// TruncateHandler truncates messages and values longer than maxLength bytes before passing records
+// on to h, so single records don't blow up the parsers of log collectors. Values other than
+// strings are truncated as formatted, and records with truncated values get a `truncated` value.
+func TruncateHandler(maxLength int, h log15.Handler) log15.Handler {
+	if maxLength <= 0 {
+		return h
+	}
+
+	return log15.FuncHandler(func(r *log15.Record) error {
+		truncated := *r
+		msg, isTruncated := truncateString(r.Msg, maxLength)
+		truncated.Msg = msg
+		truncated.Ctx = make([]interface{}, len(r.Ctx), len(r.Ctx)+2)
+		copy(truncated.Ctx, r.Ctx)
+
+		for i := 1; i < len(truncated.Ctx); i += 2 {
+			var s string
+			switch v := truncated.Ctx[i].(type) {
+			case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, time.Time, time.Duration:
+				continue
+			case string:
+				s = v
+			case error:
+				s = v.Error()
+			case fmt.Stringer:
+				s = v.String()
+			default:
+				s = fmt.Sprintf("%+v", v)
+			}
+			if value, ok := truncateString(s, maxLength); ok {
+				truncated.Ctx[i] = value
+				isTruncated = true
+			}
+		}
+
+		if !isTruncated {
+			return h.Log(r)
+		}
+		truncated.Ctx = append(truncated.Ctx, "truncated", true)
+		return h.Log(&truncated)
+	})
+}
+
+// truncateString truncates s to at most maxLength bytes, without splitting UTF-8 characters, and
+// appends the truncated suffix. It reports whether s was truncated.
+func truncateString(s string, maxLength int) (string, bool) {
+	if len(s) <= maxLength {
+		return s, false
+	}
+	end := maxLength
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + truncatedSuffix, true
+}
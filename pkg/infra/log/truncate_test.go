@@ -0,0 +1,44 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateHandler(t *testing.T) {
+	var logged []*log15.Record
+	handler := TruncateHandler(8, log15.FuncHandler(func(r *log15.Record) error {
+		logged = append(logged, r)
+		return nil
+	}))
+
+	t.Run("Long messages and values are truncated", func(t *testing.T) {
+		logged = nil
+		record := &log15.Record{Msg: "Query failed badly", Ctx: []interface{}{
+			"sql", "SELECT * FROM dashboard", "error", errors.New("database is locked"), "rows", 1234567890123,
+			"payload", map[string]string{"key": "value"}, "name", "nnnnnnnüber",
+		}}
+		require.NoError(t, handler.Log(record))
+
+		require.Len(t, logged, 1)
+		assert.Equal(t, "Query fa...", logged[0].Msg)
+		assert.Equal(t, []interface{}{
+			"sql", "SELECT *...", "error", "database...", "rows", 1234567890123,
+			"payload", "map[key:...", "name", "nnnnnnn...", "truncated", true,
+		}, logged[0].Ctx)
+		assert.Equal(t, "SELECT * FROM dashboard", record.Ctx[1])
+	})
+
+	t.Run("Short records are passed on unchanged", func(t *testing.T) {
+		logged = nil
+		record := &log15.Record{Msg: "Started", Ctx: []interface{}{"port", 3000}}
+		require.NoError(t, handler.Log(record))
+
+		require.Len(t, logged, 1)
+		assert.Same(t, record, logged[0])
+	})
+}
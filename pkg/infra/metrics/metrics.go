@@ -135,6 +135,15 @@ var (
 	// MStatActiveUsers is a metric number of active users
 	MStatActiveUsers prometheus.Gauge
 
+	// MStatDailyActiveUsers is a metric number of users seen in the last day
+	MStatDailyActiveUsers prometheus.Gauge
+
+	// MStatWeeklyActiveUsers is a metric number of users seen in the last 7 days
+	MStatWeeklyActiveUsers prometheus.Gauge
+
+	// MStatMonthlyActiveUsers is a metric number of users seen in the last 30 days
+	MStatMonthlyActiveUsers prometheus.Gauge
+
 	// MStatTotalOrgs is a metric total amount of orgs
 	MStatTotalOrgs prometheus.Gauge
 
@@ -433,6 +442,24 @@ func init() {
 		Namespace: ExporterName,
 	})
 
+	MStatDailyActiveUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      "stat_daily_active_users",
+		Help:      "number of users seen in the last day",
+		Namespace: ExporterName,
+	})
+
+	MStatWeeklyActiveUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      "stat_weekly_active_users",
+		Help:      "number of users seen in the last 7 days",
+		Namespace: ExporterName,
+	})
+
+	MStatMonthlyActiveUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      "stat_monthly_active_users",
+		Help:      "number of users seen in the last 30 days",
+		Namespace: ExporterName,
+	})
+
 	MStatTotalOrgs = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name:      "stat_total_orgs",
 		Help:      "total amount of orgs",
@@ -587,6 +614,9 @@ func initMetricVars() {
 		MStatTotalFolders,
 		MStatTotalUsers,
 		MStatActiveUsers,
+		MStatDailyActiveUsers,
+		MStatWeeklyActiveUsers,
+		MStatMonthlyActiveUsers,
 		MStatTotalOrgs,
 		MStatTotalPlaylists,
 		StatsTotalViewers,
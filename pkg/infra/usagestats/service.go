@@ -54,6 +54,7 @@ func (uss *UsageStatsService) Init() error {
 }
 
 func (uss *UsageStatsService) Run(ctx context.Context) error {
+	uss.updateActiveUserStats(ctx)
 	uss.updateTotalStats()
 
 	sendReportTicker := time.NewTicker(time.Hour * 24)
@@ -68,6 +69,7 @@ func (uss *UsageStatsService) Run(ctx context.Context) error {
 				metricsLogger.Warn("Failed to send usage stats", "err", err)
 			}
 		case <-updateStatsTicker.C:
+			uss.updateActiveUserStats(ctx)
 			uss.updateTotalStats()
 		case <-ctx.Done():
 			return ctx.Err()
@@ -60,6 +60,9 @@ func (uss *UsageStatsService) GetUsageReport(ctx context.Context) (UsageReport,
 	metrics["stats.plugins.datasources.count"] = uss.PluginManager.DataSourceCount()
 	metrics["stats.alerts.count"] = statsQuery.Result.Alerts
 	metrics["stats.active_users.count"] = statsQuery.Result.ActiveUsers
+	metrics["stats.daily_active_users.count"] = statsQuery.Result.DailyActiveUsers
+	metrics["stats.weekly_active_users.count"] = statsQuery.Result.WeeklyActiveUsers
+	metrics["stats.monthly_active_users.count"] = statsQuery.Result.MonthlyActiveUsers
 	metrics["stats.datasources.count"] = statsQuery.Result.Datasources
 	metrics["stats.stars.count"] = statsQuery.Result.Stars
 	metrics["stats.folders.count"] = statsQuery.Result.Folders
@@ -290,6 +293,14 @@ var sendUsageStats = func(data *bytes.Buffer) {
 	}()
 }
 
+// updateActiveUserStats saves the active user counts of the current day, whether or not they are
+// reported or exposed as metrics, so the server stats show the history of every day.
+func (uss *UsageStatsService) updateActiveUserStats(ctx context.Context) {
+	if err := uss.Bus.DispatchCtx(ctx, &models.UpdateActiveUserStatsCommand{}); err != nil {
+		metricsLogger.Error("Failed to update active user stats", "error", err)
+	}
+}
+
 func (uss *UsageStatsService) updateTotalStats() {
 	if !uss.Cfg.MetricsEndpointEnabled || uss.Cfg.MetricsEndpointDisableTotalStats {
 		return
@@ -305,6 +316,9 @@ func (uss *UsageStatsService) updateTotalStats() {
 	metrics.MStatTotalFolders.Set(float64(statsQuery.Result.Folders))
 	metrics.MStatTotalUsers.Set(float64(statsQuery.Result.Users))
 	metrics.MStatActiveUsers.Set(float64(statsQuery.Result.ActiveUsers))
+	metrics.MStatDailyActiveUsers.Set(float64(statsQuery.Result.DailyActiveUsers))
+	metrics.MStatWeeklyActiveUsers.Set(float64(statsQuery.Result.WeeklyActiveUsers))
+	metrics.MStatMonthlyActiveUsers.Set(float64(statsQuery.Result.MonthlyActiveUsers))
 	metrics.MStatTotalPlaylists.Set(float64(statsQuery.Result.Playlists))
 	metrics.MStatTotalOrgs.Set(float64(statsQuery.Result.Orgs))
 	metrics.StatsTotalViewers.Set(float64(statsQuery.Result.Viewers))
@@ -49,6 +49,9 @@ func TestMetrics(t *testing.T) {
 				Datasources:           2,
 				Users:                 3,
 				ActiveUsers:           4,
+				DailyActiveUsers:      2,
+				WeeklyActiveUsers:     3,
+				MonthlyActiveUsers:    4,
 				Orgs:                  5,
 				Playlists:             6,
 				Alerts:                7,
@@ -299,6 +302,9 @@ func TestMetrics(t *testing.T) {
 			assert.Equal(t, uss.PluginManager.DataSourceCount(), metrics.Get("stats.plugins.datasources.count").MustInt())
 			assert.Equal(t, getSystemStatsQuery.Result.Alerts, metrics.Get("stats.alerts.count").MustInt64())
 			assert.Equal(t, getSystemStatsQuery.Result.ActiveUsers, metrics.Get("stats.active_users.count").MustInt64())
+			assert.Equal(t, getSystemStatsQuery.Result.DailyActiveUsers, metrics.Get("stats.daily_active_users.count").MustInt64())
+			assert.Equal(t, getSystemStatsQuery.Result.WeeklyActiveUsers, metrics.Get("stats.weekly_active_users.count").MustInt64())
+			assert.Equal(t, getSystemStatsQuery.Result.MonthlyActiveUsers, metrics.Get("stats.monthly_active_users.count").MustInt64())
 			assert.Equal(t, getSystemStatsQuery.Result.Datasources, metrics.Get("stats.datasources.count").MustInt64())
 			assert.Equal(t, getSystemStatsQuery.Result.Stars, metrics.Get("stats.stars.count").MustInt64())
 			assert.Equal(t, getSystemStatsQuery.Result.Folders, metrics.Get("stats.folders.count").MustInt64())
@@ -80,6 +80,25 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.NotNil(t, sc.context)
 	})
 
+	middlewareScenario(t, "middleware should add request logger with request ID to request context", func(t *testing.T, sc *scenarioContext) {
+		sc.fakeReq("GET", "/").exec()
+		requestID := sc.resp.Header().Get("X-Request-Id")
+		assert.NotEmpty(t, requestID)
+		assert.Same(t, sc.context.Logger, log.FromContext(sc.context.Req.Context()))
+	})
+
+	middlewareScenario(t, "middleware should reuse a valid incoming request ID", func(t *testing.T, sc *scenarioContext) {
+		sc.fakeReq("GET", "/")
+		sc.req.Header.Set("X-Request-Id", "abc-123")
+		sc.exec()
+		assert.Equal(t, "abc-123", sc.resp.Header().Get("X-Request-Id"))
+
+		sc.fakeReq("GET", "/")
+		sc.req.Header.Set("X-Request-Id", "not a valid id")
+		sc.exec()
+		assert.NotEqual(t, "not a valid id", sc.resp.Header().Get("X-Request-Id"))
+	})
+
 	middlewareScenario(t, "Default middleware should allow get request", func(t *testing.T, sc *scenarioContext) {
 		sc.fakeReq("GET", "/").exec()
 		assert.Equal(t, 200, sc.resp.Code)
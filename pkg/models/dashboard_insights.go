@@ -0,0 +1,17 @@
+package models
+
+// DashboardUsage is the usage of a dashboard over a number of days.
+type DashboardUsage struct {
+	Views   int64 `json:"views"`
+	Queries int64 `json:"queries"`
+	Errors  int64 `json:"errors"`
+}
+
+// GetDashboardsUsageQuery gets the usage of dashboards over the last Days days, including today.
+type GetDashboardsUsageQuery struct {
+	OrgId        int64
+	DashboardIds []int64
+	Days         int
+
+	Result map[int64]*DashboardUsage
+}
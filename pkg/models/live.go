@@ -1,6 +1,10 @@
 package models
 
-import "github.com/centrifugal/centrifuge"
+import (
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+)
 
 // ChannelPublisher writes data into a channel. Note that pemissions are not checked.
 type ChannelPublisher func(channel string, data []byte) error
@@ -14,6 +18,12 @@ type ChannelHandler interface {
 	OnPublish(c *centrifuge.Client, e centrifuge.PublishEvent) (centrifuge.PublishReply, error)
 }
 
+// ChannelUnsubscribeHandler can be implemented by a ChannelHandler that keeps track of its subscribers.
+type ChannelUnsubscribeHandler interface {
+	// OnUnsubscribe is called when a client unsubscribes from a channel, or disconnects.
+	OnUnsubscribe(c *centrifuge.Client, e centrifuge.UnsubscribeEvent)
+}
+
 // ChannelHandlerFactory should be implemented by all core features.
 type ChannelHandlerFactory interface {
 	// GetHandlerForPath gets a ChannelHandler for a path.
@@ -25,4 +35,16 @@ type ChannelHandlerFactory interface {
 type DashboardActivityChannel interface {
 	DashboardSaved(uid string, userID int64) error
 	DashboardDeleted(uid string, userID int64) error
+	// DashboardPresence returns the sessions currently viewing or editing a dashboard.
+	DashboardPresence(orgID int64, uid string) []*DashboardPresence
+}
+
+// DashboardPresence is a session viewing or editing a dashboard over Grafana Live.
+type DashboardPresence struct {
+	UserID    int64     `json:"userId"`
+	Login     string    `json:"login"`
+	Name      string    `json:"name"`
+	SessionID string    `json:"sessionId,omitempty"`
+	Editing   bool      `json:"editing"`
+	Since     time.Time `json:"since"`
 }
@@ -19,8 +19,11 @@ type ShortUrl struct {
 	LastSeenAt int64
 }
 
+// DeleteShortUrlCommand deletes short URLs that were never visited and were created before
+// OlderThan, and, unless LastSeenBefore is zero, visited short URLs last seen before LastSeenBefore.
 type DeleteShortUrlCommand struct {
-	OlderThan time.Time
+	OlderThan      time.Time
+	LastSeenBefore time.Time
 
 	NumDeleted int64
 }
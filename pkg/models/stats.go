@@ -1,10 +1,15 @@
 package models
 
+import "time"
+
 type SystemStats struct {
 	Dashboards            int64
 	Datasources           int64
 	Users                 int64
 	ActiveUsers           int64
+	DailyActiveUsers      int64
+	WeeklyActiveUsers     int64
+	MonthlyActiveUsers    int64
 	Orgs                  int64
 	Playlists             int64
 	Alerts                int64
@@ -61,23 +66,27 @@ type GetAlertNotifierUsageStatsQuery struct {
 }
 
 type AdminStats struct {
-	Orgs           int `json:"orgs"`
-	Dashboards     int `json:"dashboards"`
-	Snapshots      int `json:"snapshots"`
-	Tags           int `json:"tags"`
-	Datasources    int `json:"datasources"`
-	Playlists      int `json:"playlists"`
-	Stars          int `json:"stars"`
-	Alerts         int `json:"alerts"`
-	Users          int `json:"users"`
-	Admins         int `json:"admins"`
-	Editors        int `json:"editors"`
-	Viewers        int `json:"viewers"`
-	ActiveUsers    int `json:"activeUsers"`
-	ActiveAdmins   int `json:"activeAdmins"`
-	ActiveEditors  int `json:"activeEditors"`
-	ActiveViewers  int `json:"activeViewers"`
-	ActiveSessions int `json:"activeSessions"`
+	Orgs               int    `json:"orgs"`
+	Dashboards         int    `json:"dashboards"`
+	Snapshots          int    `json:"snapshots"`
+	Tags               int    `json:"tags"`
+	Datasources        int    `json:"datasources"`
+	Playlists          int    `json:"playlists"`
+	Stars              int    `json:"stars"`
+	Alerts             int    `json:"alerts"`
+	Users              int    `json:"users"`
+	Admins             int    `json:"admins"`
+	Editors            int    `json:"editors"`
+	Viewers            int    `json:"viewers"`
+	ActiveUsers        int    `json:"activeUsers"`
+	ActiveAdmins       int    `json:"activeAdmins"`
+	ActiveEditors      int    `json:"activeEditors"`
+	ActiveViewers      int    `json:"activeViewers"`
+	ActiveSessions     int    `json:"activeSessions"`
+	DailyActiveUsers   int    `json:"dailyActiveUsers"`
+	WeeklyActiveUsers  int    `json:"weeklyActiveUsers"`
+	MonthlyActiveUsers int    `json:"monthlyActiveUsers"`
+	ActiveUserWindow   string `json:"activeUserWindow"`
 }
 
 type GetAdminStatsQuery struct {
@@ -88,6 +97,29 @@ type SystemUserCountStats struct {
 	Count int64
 }
 
+// ActiveUserStats is the number of users seen over the last day, week, month and active user
+// window on a day. The counts of the current day are updated until the day is over.
+type ActiveUserStats struct {
+	Day                string    `json:"day"`
+	DailyActiveUsers   int64     `json:"dailyActiveUsers"`
+	WeeklyActiveUsers  int64     `json:"weeklyActiveUsers"`
+	MonthlyActiveUsers int64     `json:"monthlyActiveUsers"`
+	ActiveUsers        int64     `json:"activeUsers"`
+	ActiveUserWindow   int64     `json:"activeUserWindowSeconds"`
+	Updated            time.Time `json:"updated"`
+}
+
+// UpdateActiveUserStatsCommand counts the active users and saves the counts for the current day.
+type UpdateActiveUserStatsCommand struct {
+	Result *ActiveUserStats
+}
+
+// GetActiveUserStatsQuery gets the active user counts of the last days, oldest first.
+type GetActiveUserStatsQuery struct {
+	Days   int
+	Result []*ActiveUserStats
+}
+
 type GetSystemUserCountStatsQuery struct {
 	Result *SystemUserCountStats
 }
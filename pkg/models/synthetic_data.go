@@ -0,0 +1,27 @@
+package models
+
+// GenerateSyntheticDataCommand generates folders, dashboards, users, teams and annotations in an
+// org, for load testing and reproducing bugs that only show at scale.
+type GenerateSyntheticDataCommand struct {
+	Folders     int `json:"folders"`
+	Dashboards  int `json:"dashboards"`
+	Users       int `json:"users"`
+	Teams       int `json:"teams"`
+	Annotations int `json:"annotations"`
+	// Seed seeds the random distributions, so the same command generates the same data shape.
+	Seed int64 `json:"seed"`
+
+	OrgId  int64 `json:"-"`
+	Result *SyntheticDataResult
+}
+
+// SyntheticDataResult counts what a GenerateSyntheticDataCommand created.
+type SyntheticDataResult struct {
+	Prefix      string `json:"prefix"`
+	Folders     int    `json:"folders"`
+	Dashboards  int    `json:"dashboards"`
+	Users       int    `json:"users"`
+	Teams       int    `json:"teams"`
+	TeamMembers int    `json:"teamMembers"`
+	Annotations int    `json:"annotations"`
+}
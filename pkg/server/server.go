@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/api"
@@ -40,6 +41,7 @@ import (
 	_ "github.com/grafana/grafana/pkg/services/notifications"
 	_ "github.com/grafana/grafana/pkg/services/provisioning"
 	_ "github.com/grafana/grafana/pkg/services/rendering"
+	_ "github.com/grafana/grafana/pkg/services/reports"
 	_ "github.com/grafana/grafana/pkg/services/search"
 	_ "github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
@@ -156,6 +158,11 @@ func (s *Server) Run() (err error) {
 
 	services := registry.GetServices()
 
+	// Read the logging config again when the process receives SIGHUP.
+	go log.ListenForReloadSignal(s.context, s.cfg.ReloadLogging)
+	// Reopen the log files when the process receives SIGUSR1 or SIGUSR2, after they're rotated.
+	go log.ListenForRotateSignal(s.context)
+
 	// Start background services.
 	for _, svc := range services {
 		service, ok := svc.Instance.(registry.BackgroundService)
@@ -268,6 +275,7 @@ func (s *Server) buildServiceGraph(services []*registry.Descriptor) error {
 		s.cfg,
 		routing.NewRouteRegister(middleware.RequestTracing, middleware.RequestMetrics(s.cfg)),
 		localcache.New(5*time.Minute, 10*time.Minute),
+		clock.New(),
 		s,
 	}
 	return registry.BuildServiceGraph(objs, services)
@@ -28,6 +28,7 @@ type AlertEngine struct {
 	Bus              bus.Bus                       `inject:""`
 	RequestValidator models.PluginRequestValidator `inject:""`
 	DataService      plugins.DataRequestHandler    `inject:""`
+	Clock            clock.Clock                   `inject:""`
 
 	execQueue     chan *Job
 	ticker        *Ticker
@@ -49,7 +50,10 @@ func (e *AlertEngine) IsDisabled() bool {
 
 // Init initializes the AlertingService.
 func (e *AlertEngine) Init() error {
-	e.ticker = NewTicker(time.Now(), time.Second*0, clock.New(), 1)
+	if e.Clock == nil {
+		e.Clock = clock.New()
+	}
+	e.ticker = NewTicker(e.Clock.Now(), time.Second*0, e.Clock, 1)
 	e.execQueue = make(chan *Job, 1000)
 	e.scheduler = newScheduler()
 	e.evalHandler = NewEvalHandler(e.DataService)
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -28,19 +29,21 @@ func init() {
 	})
 }
 
-var getTime = time.Now
-
 const urgentRotateTime = 1 * time.Minute
 
 type UserAuthTokenService struct {
 	SQLStore          *sqlstore.SQLStore            `inject:""`
 	ServerLockService *serverlock.ServerLockService `inject:""`
 	Cfg               *setting.Cfg                  `inject:""`
+	Clock             clock.Clock                   `inject:""`
 	log               log.Logger
 }
 
 func (s *UserAuthTokenService) Init() error {
 	s.log = log.New("auth")
+	if s.Clock == nil {
+		s.Clock = clock.New()
+	}
 	return nil
 }
 
@@ -68,7 +71,7 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *models.Use
 
 	hashedToken := hashToken(token)
 
-	now := getTime().Unix()
+	now := s.Clock.Now().Unix()
 	clientIPStr := clientIP.String()
 	if len(clientIP) == 0 {
 		clientIPStr = ""
@@ -145,7 +148,7 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 	if model.AuthToken != hashedToken && model.PrevAuthToken == hashedToken && model.AuthTokenSeen {
 		modelCopy := model
 		modelCopy.AuthTokenSeen = false
-		expireBefore := getTime().Add(-urgentRotateTime).Unix()
+		expireBefore := s.Clock.Now().Add(-urgentRotateTime).Unix()
 
 		var affectedRows int64
 		err = s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
@@ -172,7 +175,7 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 	if !model.AuthTokenSeen && model.AuthToken == hashedToken {
 		modelCopy := model
 		modelCopy.AuthTokenSeen = true
-		modelCopy.SeenAt = getTime().Unix()
+		modelCopy.SeenAt = s.Clock.Now().Unix()
 
 		var affectedRows int64
 		err = s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
@@ -218,7 +221,7 @@ func (s *UserAuthTokenService) TryRotateToken(ctx context.Context, token *models
 		return false, err
 	}
 
-	now := getTime()
+	now := s.Clock.Now()
 
 	var needsRotation bool
 	rotatedAt := time.Unix(model.RotatedAt, 0)
@@ -299,7 +302,7 @@ func (s *UserAuthTokenService) RevokeToken(ctx context.Context, token *models.Us
 	var rowsAffected int64
 
 	if soft {
-		model.RevokedAt = getTime().Unix()
+		model.RevokedAt = s.Clock.Now().Unix()
 		err = s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
 			rowsAffected, err = dbSession.ID(model.Id).Update(model)
 			return err
@@ -444,11 +447,11 @@ func (s *UserAuthTokenService) GetUserRevokedTokens(ctx context.Context, userId
 }
 
 func (s *UserAuthTokenService) createdAfterParam() int64 {
-	return getTime().Add(-s.Cfg.LoginMaxLifetime).Unix()
+	return s.Clock.Now().Add(-s.Cfg.LoginMaxLifetime).Unix()
 }
 
 func (s *UserAuthTokenService) rotatedAfterParam() int64 {
-	return getTime().Add(-s.Cfg.LoginMaxInactiveLifetime).Unix()
+	return s.Clock.Now().Add(-s.Cfg.LoginMaxInactiveLifetime).Unix()
 }
 
 func hashToken(token string) string {
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/setting"
 
@@ -24,9 +25,7 @@ func TestUserAuthToken(t *testing.T) {
 		userID := user.Id
 
 		t := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
-		getTime = func() time.Time {
-			return t
-		}
+		ctx.clock.Set(t)
 
 		Convey("When creating token", func() {
 			userToken, err := userAuthTokenService.CreateToken(context.Background(), user,
@@ -163,9 +162,7 @@ func TestUserAuthToken(t *testing.T) {
 			userToken, err = userAuthTokenService.LookupToken(context.Background(), userToken.UnhashedToken)
 			So(err, ShouldBeNil)
 
-			getTime = func() time.Time {
-				return t.Add(time.Hour)
-			}
+			ctx.clock.Set(t.Add(time.Hour))
 
 			rotated, err := userAuthTokenService.TryRotateToken(context.Background(), userToken,
 				net.ParseIP("192.168.10.11"), "some user agent")
@@ -183,9 +180,7 @@ func TestUserAuthToken(t *testing.T) {
 			So(err, ShouldBeNil)
 
 			Convey("when rotated_at is 6:59:59 ago should find token", func() {
-				getTime = func() time.Time {
-					return time.Unix(model.RotatedAt, 0).Add(24 * 7 * time.Hour).Add(-time.Second)
-				}
+				ctx.clock.Set(time.Unix(model.RotatedAt, 0).Add(24 * 7 * time.Hour).Add(-time.Second))
 
 				stillGood, err = userAuthTokenService.LookupToken(context.Background(), stillGood.UnhashedToken)
 				So(err, ShouldBeNil)
@@ -193,9 +188,7 @@ func TestUserAuthToken(t *testing.T) {
 			})
 
 			Convey("when rotated_at is 7:00:00 ago should return token expired error", func() {
-				getTime = func() time.Time {
-					return time.Unix(model.RotatedAt, 0).Add(24 * 7 * time.Hour)
-				}
+				ctx.clock.Set(time.Unix(model.RotatedAt, 0).Add(24 * 7 * time.Hour))
 
 				notGood, err := userAuthTokenService.LookupToken(context.Background(), userToken.UnhashedToken)
 				So(err, ShouldHaveSameTypeAs, &models.TokenExpiredError{})
@@ -213,9 +206,7 @@ func TestUserAuthToken(t *testing.T) {
 				So(err, ShouldBeNil)
 				So(updated, ShouldBeTrue)
 
-				getTime = func() time.Time {
-					return time.Unix(model.CreatedAt, 0).Add(24 * 30 * time.Hour).Add(-time.Second)
-				}
+				ctx.clock.Set(time.Unix(model.CreatedAt, 0).Add(24 * 30 * time.Hour).Add(-time.Second))
 
 				stillGood, err = userAuthTokenService.LookupToken(context.Background(), stillGood.UnhashedToken)
 				So(err, ShouldBeNil)
@@ -227,9 +218,7 @@ func TestUserAuthToken(t *testing.T) {
 				So(err, ShouldBeNil)
 				So(updated, ShouldBeTrue)
 
-				getTime = func() time.Time {
-					return time.Unix(model.CreatedAt, 0).Add(24 * 30 * time.Hour)
-				}
+				ctx.clock.Set(time.Unix(model.CreatedAt, 0).Add(24 * 30 * time.Hour))
 
 				notGood, err := userAuthTokenService.LookupToken(context.Background(), userToken.UnhashedToken)
 				So(err, ShouldHaveSameTypeAs, &models.TokenExpiredError{})
@@ -261,9 +250,7 @@ func TestUserAuthToken(t *testing.T) {
 			err = model.toUserToken(&tok)
 			So(err, ShouldBeNil)
 
-			getTime = func() time.Time {
-				return t.Add(time.Hour)
-			}
+			ctx.clock.Set(t.Add(time.Hour))
 
 			rotated, err = userAuthTokenService.TryRotateToken(context.Background(), &tok,
 				net.ParseIP("192.168.10.12"), "a new user agent")
@@ -276,7 +263,7 @@ func TestUserAuthToken(t *testing.T) {
 			So(err, ShouldBeNil)
 			model.UnhashedToken = unhashedToken
 
-			So(model.RotatedAt, ShouldEqual, getTime().Unix())
+			So(model.RotatedAt, ShouldEqual, ctx.clock.Now().Unix())
 			So(model.ClientIp, ShouldEqual, "192.168.10.12")
 			So(model.UserAgent, ShouldEqual, "a new user agent")
 			So(model.AuthTokenSeen, ShouldBeFalse)
@@ -289,7 +276,7 @@ func TestUserAuthToken(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(lookedUpUserToken, ShouldNotBeNil)
 			So(lookedUpUserToken.AuthTokenSeen, ShouldBeTrue)
-			So(lookedUpUserToken.SeenAt, ShouldEqual, getTime().Unix())
+			So(lookedUpUserToken.SeenAt, ShouldEqual, ctx.clock.Now().Unix())
 
 			lookedUpUserToken, err = userAuthTokenService.LookupToken(context.Background(), unhashedPrev)
 			So(err, ShouldBeNil)
@@ -297,9 +284,7 @@ func TestUserAuthToken(t *testing.T) {
 			So(lookedUpUserToken.Id, ShouldEqual, model.Id)
 			So(lookedUpUserToken.AuthTokenSeen, ShouldBeTrue)
 
-			getTime = func() time.Time {
-				return t.Add(time.Hour + (2 * time.Minute))
-			}
+			ctx.clock.Set(t.Add(time.Hour + (2 * time.Minute)))
 
 			lookedUpUserToken, err = userAuthTokenService.LookupToken(context.Background(), unhashedPrev)
 			So(err, ShouldBeNil)
@@ -332,9 +317,7 @@ func TestUserAuthToken(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(lookedUpUserToken, ShouldNotBeNil)
 
-			getTime = func() time.Time {
-				return t.Add(10 * time.Minute)
-			}
+			ctx.clock.Set(t.Add(10 * time.Minute))
 
 			prevToken := userToken.UnhashedToken
 			rotated, err := userAuthTokenService.TryRotateToken(context.Background(), userToken,
@@ -342,9 +325,7 @@ func TestUserAuthToken(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(rotated, ShouldBeTrue)
 
-			getTime = func() time.Time {
-				return t.Add(20 * time.Minute)
-			}
+			ctx.clock.Set(t.Add(20 * time.Minute))
 
 			currentUserToken, err := userAuthTokenService.LookupToken(context.Background(), userToken.UnhashedToken)
 			So(err, ShouldBeNil)
@@ -388,9 +369,7 @@ func TestUserAuthToken(t *testing.T) {
 				So(err, ShouldBeNil)
 				So(updated, ShouldBeTrue)
 
-				getTime = func() time.Time {
-					return t.Add(10 * time.Minute)
-				}
+				ctx.clock.Set(t.Add(10 * time.Minute))
 
 				rotated, err := userAuthTokenService.TryRotateToken(context.Background(), userToken,
 					net.ParseIP("1.1.1.1"), "firefox")
@@ -410,9 +389,7 @@ func TestUserAuthToken(t *testing.T) {
 				So(err, ShouldBeNil)
 				So(updated, ShouldBeTrue)
 
-				getTime = func() time.Time {
-					return t.Add(20 * time.Minute)
-				}
+				ctx.clock.Set(t.Add(20 * time.Minute))
 
 				rotated, err = userAuthTokenService.TryRotateToken(context.Background(), userToken,
 					net.ParseIP("1.1.1.1"), "firefox")
@@ -428,11 +405,9 @@ func TestUserAuthToken(t *testing.T) {
 			})
 
 			Convey("Should rotate current token, but keep previous token when auth token not seen", func() {
-				userToken.RotatedAt = getTime().Add(-2 * time.Minute).Unix()
+				userToken.RotatedAt = ctx.clock.Now().Add(-2 * time.Minute).Unix()
 
-				getTime = func() time.Time {
-					return t.Add(2 * time.Minute)
-				}
+				ctx.clock.Set(t.Add(2 * time.Minute))
 
 				rotated, err := userAuthTokenService.TryRotateToken(context.Background(), userToken,
 					net.ParseIP("1.1.1.1"), "firefox")
@@ -513,10 +488,6 @@ func TestUserAuthToken(t *testing.T) {
 
 			So(utMap, ShouldResemble, uatMap)
 		})
-
-		Reset(func() {
-			getTime = time.Now
-		})
 	})
 }
 
@@ -525,6 +496,7 @@ func createTestContext(t *testing.T) *testContext {
 	maxInactiveDurationVal, _ := time.ParseDuration("168h")
 	maxLifetimeDurationVal, _ := time.ParseDuration("720h")
 	sqlstore := sqlstore.InitTestDB(t)
+	mockClock := clock.NewMock()
 	tokenService := &UserAuthTokenService{
 		SQLStore: sqlstore,
 		Cfg: &setting.Cfg{
@@ -532,18 +504,21 @@ func createTestContext(t *testing.T) *testContext {
 			LoginMaxLifetime:             maxLifetimeDurationVal,
 			TokenRotationIntervalMinutes: 10,
 		},
-		log: log.New("test-logger"),
+		Clock: mockClock,
+		log:   log.New("test-logger"),
 	}
 
 	return &testContext{
 		sqlstore:     sqlstore,
 		tokenService: tokenService,
+		clock:        mockClock,
 	}
 }
 
 type testContext struct {
 	sqlstore     *sqlstore.SQLStore
 	tokenService *UserAuthTokenService
+	clock        *clock.Mock
 }
 
 func (c *testContext) getAuthTokenByID(id int64) (*userAuthToken, error) {
@@ -8,7 +8,7 @@ import (
 )
 
 func (s *UserAuthTokenService) Run(ctx context.Context) error {
-	ticker := time.NewTicker(time.Hour)
+	ticker := s.Clock.Ticker(time.Hour)
 	maxInactiveLifetime := s.Cfg.LoginMaxInactiveLifetime
 	maxLifetime := s.Cfg.LoginMaxLifetime
 
@@ -40,8 +40,8 @@ func (s *UserAuthTokenService) Run(ctx context.Context) error {
 }
 
 func (s *UserAuthTokenService) deleteExpiredTokens(ctx context.Context, maxInactiveLifetime, maxLifetime time.Duration) (int64, error) {
-	createdBefore := getTime().Add(-maxLifetime)
-	rotatedBefore := getTime().Add(-maxInactiveLifetime)
+	createdBefore := s.Clock.Now().Add(-maxLifetime)
+	rotatedBefore := s.Clock.Now().Add(-maxInactiveLifetime)
 
 	s.log.Debug("starting cleanup of expired auth tokens", "createdBefore", createdBefore, "rotatedBefore", rotatedBefore)
 
@@ -24,9 +24,7 @@ func TestUserAuthTokenCleanup(t *testing.T) {
 		}
 
 		t := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
-		getTime = func() time.Time {
-			return t
-		}
+		ctx.clock.Set(t)
 
 		Convey("should delete tokens where token rotation age is older than or equal 7 days", func() {
 			from := t.Add(-168 * time.Hour)
@@ -7,6 +7,9 @@ import (
 	"path"
 	"time"
 
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana/pkg/services/dashboardinsights"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -20,9 +23,12 @@ import (
 
 type CleanUpService struct {
 	log               log.Logger
-	Cfg               *setting.Cfg                  `inject:""`
-	ServerLockService *serverlock.ServerLockService `inject:""`
-	ShortURLService   *shorturls.ShortURLService    `inject:""`
+	Cfg               *setting.Cfg                                `inject:""`
+	ServerLockService *serverlock.ServerLockService               `inject:""`
+	ShortURLService   *shorturls.ShortURLService                  `inject:""`
+	DashboardInsights *dashboardinsights.DashboardInsightsService `inject:""`
+	QueryHistory      *queryhistory.QueryHistoryService           `inject:""`
+	Clock             clock.Clock                                 `inject:""`
 }
 
 func init() {
@@ -31,13 +37,16 @@ func init() {
 
 func (srv *CleanUpService) Init() error {
 	srv.log = log.New("cleanup")
+	if srv.Clock == nil {
+		srv.Clock = clock.New()
+	}
 	return nil
 }
 
 func (srv *CleanUpService) Run(ctx context.Context) error {
 	srv.cleanUpTmpFiles()
 
-	ticker := time.NewTicker(time.Minute * 10)
+	ticker := srv.Clock.Ticker(time.Minute * 10)
 	for {
 		select {
 		case <-ticker.C:
@@ -50,6 +59,8 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			srv.cleanUpOldAnnotations(ctxWithTimeout)
 			srv.expireOldUserInvites()
 			srv.deleteStaleShortURLs()
+			srv.deleteOldDashboardUsage(ctxWithTimeout)
+			srv.deleteOldQueryHistory(ctxWithTimeout)
 			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
 				time.Minute*10, func() {
 					srv.deleteOldLoginAttempts()
@@ -85,7 +96,7 @@ func (srv *CleanUpService) cleanUpTmpFiles() {
 	}
 
 	var toDelete []os.FileInfo
-	var now = time.Now()
+	var now = srv.Clock.Now()
 
 	for _, file := range files {
 		if srv.shouldCleanupTempFile(file.ModTime(), now) {
@@ -136,7 +147,7 @@ func (srv *CleanUpService) deleteOldLoginAttempts() {
 	}
 
 	cmd := models.DeleteOldLoginAttemptsCommand{
-		OlderThan: time.Now().Add(time.Minute * -10),
+		OlderThan: srv.Clock.Now().Add(time.Minute * -10),
 	}
 	if err := bus.Dispatch(&cmd); err != nil {
 		srv.log.Error("Problem deleting expired login attempts", "error", err.Error())
@@ -149,7 +160,7 @@ func (srv *CleanUpService) expireOldUserInvites() {
 	maxInviteLifetime := srv.Cfg.UserInviteMaxLifetime
 
 	cmd := models.ExpireTempUsersCommand{
-		OlderThan: time.Now().Add(-maxInviteLifetime),
+		OlderThan: srv.Clock.Now().Add(-maxInviteLifetime),
 	}
 	if err := bus.Dispatch(&cmd); err != nil {
 		srv.log.Error("Problem expiring user invites", "error", err.Error())
@@ -158,9 +169,38 @@ func (srv *CleanUpService) expireOldUserInvites() {
 	}
 }
 
+func (srv *CleanUpService) deleteOldDashboardUsage(ctx context.Context) {
+	if !srv.Cfg.IsDashboardInsightsEnabled() {
+		return
+	}
+
+	if affected, err := srv.DashboardInsights.DeleteOldUsage(ctx); err != nil {
+		srv.log.Error("Problem deleting old dashboard usage", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted old dashboard usage", "rows affected", affected)
+	}
+}
+
+func (srv *CleanUpService) deleteOldQueryHistory(ctx context.Context) {
+	if !srv.Cfg.IsQueryHistoryEnabled() {
+		return
+	}
+
+	if affected, err := srv.QueryHistory.DeleteOldQueries(ctx); err != nil {
+		srv.log.Error("Problem deleting old query history", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted old query history", "rows affected", affected)
+	}
+}
+
 func (srv *CleanUpService) deleteStaleShortURLs() {
-	cmd := models.DeleteShortUrlCommand{
-		OlderThan: time.Now().Add(-time.Hour * 24 * 7),
+	now := srv.Clock.Now()
+	cmd := models.DeleteShortUrlCommand{}
+	if srv.Cfg.ShortLinkUnvisitedLifetime > 0 {
+		cmd.OlderThan = now.Add(-srv.Cfg.ShortLinkUnvisitedLifetime)
+	}
+	if srv.Cfg.ShortLinkMaxIdleTime > 0 {
+		cmd.LastSeenBefore = now.Add(-srv.Cfg.ShortLinkMaxIdleTime)
 	}
 	if err := srv.ShortURLService.DeleteStaleShortURLs(context.Background(), &cmd); err != nil {
 		srv.log.Error("Problem deleting stale short urls", "error", err.Error())
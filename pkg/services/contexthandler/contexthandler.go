@@ -4,6 +4,7 @@ package contexthandler
 import (
 	"context"
 	"errors"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -53,6 +54,19 @@ type ContextHandler struct {
 	GetTime func() time.Time
 }
 
+// requestIDHeader is the header with the ID of a request, which is added to its log records.
+const requestIDHeader = "X-Request-Id"
+
+var validRequestID = regexp.MustCompile(`^[a-zA-Z0-9\-_.:]{1,64}$`)
+
+// getRequestID returns the ID a proxy gave a request, or a new ID if it has none.
+func getRequestID(header string) string {
+	if validRequestID.MatchString(header) {
+		return header
+	}
+	return util.GenerateShortUID()
+}
+
 // Init initializes the service.
 func (h *ContextHandler) Init() error {
 	return nil
@@ -95,7 +109,11 @@ func (h *ContextHandler) Middleware(c *macaron.Context) {
 	case h.initContextWithAnonymousUser(ctx):
 	}
 
-	ctx.Logger = log.New("context", "userId", ctx.UserId, "orgId", ctx.OrgId, "uname", ctx.Login)
+	requestID := getRequestID(ctx.Req.Header.Get(requestIDHeader))
+	ctx.Resp.Header().Set(requestIDHeader, requestID)
+	ctx.Logger = log.New("context", "userId", ctx.UserId, "orgId", ctx.OrgId, "uname", ctx.Login, "requestId", requestID)
+	// Services log with the request scoped logger through log.FromContext
+	ctx.Req.Request = ctx.Req.WithContext(log.ToContext(ctx.Req.Context(), ctx.Logger))
 	ctx.Data["ctx"] = ctx
 
 	c.Map(ctx)
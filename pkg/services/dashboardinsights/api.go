@@ -0,0 +1,79 @@
+package dashboardinsights
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	defaultDays  = 30
+	maxDays      = retentionDays
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+func (s *DashboardInsightsService) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/dashboard-insights", func(insights routing.RouteRegister) {
+		insights.Get("/dashboards/:uid", middleware.ReqSignedIn, routing.Wrap(s.getDashboardInsightsHandler))
+		insights.Get("/least-used", middleware.ReqOrgAdmin, routing.Wrap(s.getLeastUsedHandler))
+	})
+}
+
+// getDashboardInsightsHandler handles GET /api/dashboard-insights/dashboards/:uid.
+func (s *DashboardInsightsService) getDashboardInsightsHandler(c *models.ReqContext) response.Response {
+	days, ok := queryIntInRange(c, "days", defaultDays, maxDays)
+	if !ok {
+		return response.Error(400, "days must be between 1 and 365", nil)
+	}
+
+	insights, err := s.getDashboardInsights(c, c.Params(":uid"), days)
+	if err != nil {
+		return toDashboardInsightsError(err, "Failed to get dashboard insights")
+	}
+
+	return response.JSON(200, util.DynMap{"result": insights})
+}
+
+// getLeastUsedHandler handles GET /api/dashboard-insights/least-used.
+func (s *DashboardInsightsService) getLeastUsedHandler(c *models.ReqContext) response.Response {
+	days, ok := queryIntInRange(c, "days", defaultDays, maxDays)
+	if !ok {
+		return response.Error(400, "days must be between 1 and 365", nil)
+	}
+	limit, ok := queryIntInRange(c, "limit", defaultLimit, maxLimit)
+	if !ok {
+		return response.Error(400, "limit must be between 1 and 1000", nil)
+	}
+
+	dashboards, err := s.getLeastUsedDashboards(c, days, limit)
+	if err != nil {
+		return toDashboardInsightsError(err, "Failed to get least used dashboards")
+	}
+
+	return response.JSON(200, util.DynMap{"result": dashboards})
+}
+
+// queryIntInRange returns the value of an integer query parameter, or fallback if it's not set,
+// and whether it's between 1 and max.
+func queryIntInRange(c *models.ReqContext, name string, fallback int, max int) (int, bool) {
+	if c.Query(name) == "" {
+		return fallback, true
+	}
+	value := c.QueryInt(name)
+	return value, value >= 1 && value <= max
+}
+
+func toDashboardInsightsError(err error, message string) response.Response {
+	if errors.Is(err, errDashboardNotFound) {
+		return response.Error(404, errDashboardNotFound.Error(), err)
+	}
+	if errors.Is(err, errDashboardAccessDenied) {
+		return response.Error(403, errDashboardAccessDenied.Error(), err)
+	}
+	return response.Error(500, message, err)
+}
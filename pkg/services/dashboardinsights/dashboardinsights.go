@@ -0,0 +1,183 @@
+package dashboardinsights
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// flushInterval is how often the usage counted in memory is written to the database.
+	flushInterval = time.Minute
+	// retentionDays is how many days of daily usage are kept.
+	retentionDays = 365
+	// dayFormat is the format of the day of the daily usage rows.
+	dayFormat = "2006-01-02"
+)
+
+// DashboardInsightsService counts dashboard views, data queries and query errors in daily
+// aggregates, and serves them to search and the dashboard insights API.
+type DashboardInsightsService struct {
+	Cfg           *setting.Cfg          `inject:""`
+	Bus           bus.Bus               `inject:""`
+	SQLStore      *sqlstore.SQLStore    `inject:""`
+	RouteRegister routing.RouteRegister `inject:""`
+	Clock         clock.Clock           `inject:""`
+	log           log.Logger
+
+	pendingMu sync.Mutex
+	pending   map[usageKey]*usageCounts
+}
+
+func init() {
+	registry.RegisterService(&DashboardInsightsService{})
+}
+
+// Init initializes the dashboard insights service.
+func (s *DashboardInsightsService) Init() error {
+	s.log = log.New("dashboardinsights")
+	s.pending = map[usageKey]*usageCounts{}
+	if s.Clock == nil {
+		s.Clock = clock.New()
+	}
+
+	if !s.IsDisabled() {
+		s.Bus.AddHandlerCtx(s.getDashboardsUsageHandler)
+		s.registerAPIEndpoints()
+	}
+
+	return nil
+}
+
+// IsDisabled returns true if the dashboard insights feature is disabled for this instance.
+func (s *DashboardInsightsService) IsDisabled() bool {
+	if s.Cfg == nil {
+		return true
+	}
+
+	return !s.Cfg.IsDashboardInsightsEnabled()
+}
+
+// Run writes the counted usage to the database until ctx is done.
+func (s *DashboardInsightsService) Run(ctx context.Context) error {
+	ticker := s.Clock.Ticker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flush(ctx); err != nil {
+				s.log.Error("Failed to save dashboard usage", "error", err)
+			}
+		case <-ctx.Done():
+			// Save what was counted since the last flush, as ctx can't be used for it anymore.
+			if err := s.flush(context.Background()); err != nil {
+				s.log.Error("Failed to save dashboard usage", "error", err)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// RecordView counts a view of a dashboard.
+func (s *DashboardInsightsService) RecordView(orgID, dashboardID int64) {
+	s.record(orgID, dashboardID, func(counts *usageCounts) {
+		counts.views++
+	})
+}
+
+// RecordQuery counts a data request made by a panel of a dashboard.
+func (s *DashboardInsightsService) RecordQuery(orgID, dashboardID int64, failed bool) {
+	s.record(orgID, dashboardID, func(counts *usageCounts) {
+		counts.queries++
+		if failed {
+			counts.errors++
+		}
+	})
+}
+
+func (s *DashboardInsightsService) record(orgID, dashboardID int64, update func(counts *usageCounts)) {
+	key := usageKey{orgID: orgID, dashboardID: dashboardID, day: s.Clock.Now().UTC().Format(dayFormat)}
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	counts, ok := s.pending[key]
+	if !ok {
+		counts = &usageCounts{}
+		s.pending[key] = counts
+	}
+	update(counts)
+}
+
+// flush writes the usage counted since the last flush to the database. Usage that fails to be
+// written is counted again, so it's retried by the next flush.
+func (s *DashboardInsightsService) flush(ctx context.Context) error {
+	s.pendingMu.Lock()
+	pending := s.pending
+	s.pending = map[usageKey]*usageCounts{}
+	s.pendingMu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := s.saveUsage(ctx, pending)
+	if err != nil {
+		s.pendingMu.Lock()
+		for key, counts := range pending {
+			if current, ok := s.pending[key]; ok {
+				current.add(counts)
+			} else {
+				s.pending[key] = counts
+			}
+		}
+		s.pendingMu.Unlock()
+	}
+	return err
+}
+
+// DeleteOldUsage deletes the daily usage older than the retention period.
+func (s *DashboardInsightsService) DeleteOldUsage(ctx context.Context) (int64, error) {
+	before := s.Clock.Now().UTC().AddDate(0, 0, -retentionDays).Format(dayFormat)
+	return s.deleteUsageBefore(ctx, before)
+}
+
+// AddMigration defines database migrations.
+// If dashboard insights are not enabled does nothing.
+func (s *DashboardInsightsService) AddMigration(mg *migrator.Migrator) {
+	if s.IsDisabled() {
+		return
+	}
+
+	dashboardUsageDailyV1 := migrator.Table{
+		Name: "dashboard_usage_daily",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "day", Type: migrator.DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "views", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "queries", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "errors", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "dashboard_id", "day"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"day"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_usage_daily table v1", migrator.NewAddTableMigration(dashboardUsageDailyV1))
+	mg.AddMigration("add unique index dashboard_usage_daily org_id & dashboard_id & day", migrator.NewAddIndexMigration(dashboardUsageDailyV1, dashboardUsageDailyV1.Indices[0]))
+	mg.AddMigration("add index dashboard_usage_daily day", migrator.NewAddIndexMigration(dashboardUsageDailyV1, dashboardUsageDailyV1.Indices[1]))
+}
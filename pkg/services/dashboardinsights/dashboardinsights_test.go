@@ -0,0 +1,190 @@
+package dashboardinsights
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestDashboardInsights(t *testing.T) {
+	t.Run("usage is counted per day and added to what was saved before", func(t *testing.T) {
+		service, mock := setupTestService(t)
+		first := createTestDashboard(t, service, "first")
+		second := createTestDashboard(t, service, "second")
+
+		service.RecordView(1, first.Id)
+		service.RecordQuery(1, first.Id, false)
+		service.RecordQuery(1, first.Id, true)
+		service.RecordView(1, second.Id)
+		require.NoError(t, service.flush(context.Background()))
+
+		mock.Add(24 * time.Hour)
+		service.RecordView(1, first.Id)
+		require.NoError(t, service.flush(context.Background()))
+		service.RecordView(1, first.Id)
+		require.NoError(t, service.flush(context.Background()))
+
+		query := &models.GetDashboardsUsageQuery{OrgId: 1, DashboardIds: []int64{first.Id, second.Id}, Days: 30}
+		require.NoError(t, service.getDashboardsUsageHandler(context.Background(), query))
+		require.Equal(t, map[int64]*models.DashboardUsage{
+			first.Id:  {Views: 3, Queries: 2, Errors: 1},
+			second.Id: {Views: 1},
+		}, query.Result)
+
+		query = &models.GetDashboardsUsageQuery{OrgId: 1, DashboardIds: []int64{first.Id, second.Id}, Days: 1}
+		require.NoError(t, service.getDashboardsUsageHandler(context.Background(), query))
+		require.Equal(t, map[int64]*models.DashboardUsage{first.Id: {Views: 2}}, query.Result)
+
+		query = &models.GetDashboardsUsageQuery{OrgId: 2, DashboardIds: []int64{first.Id, second.Id}, Days: 30}
+		require.NoError(t, service.getDashboardsUsageHandler(context.Background(), query))
+		require.Empty(t, query.Result)
+	})
+
+	t.Run("dashboard insights have the usage of every day", func(t *testing.T) {
+		service, mock := setupTestService(t)
+		dash := createTestDashboard(t, service, "dashboard")
+
+		service.RecordView(1, dash.Id)
+		mock.Add(24 * time.Hour)
+		service.RecordQuery(1, dash.Id, true)
+		require.NoError(t, service.flush(context.Background()))
+
+		insights, err := service.getDashboardInsights(orgAdminRequest("/"), dash.Uid, 30)
+		require.NoError(t, err)
+		require.Equal(t, DashboardInsightsDTO{
+			DashboardID: dash.Id,
+			UID:         dash.Uid,
+			Days:        30,
+			Views:       1,
+			Queries:     1,
+			Errors:      1,
+			Daily: []DailyUsageDTO{
+				{Day: "2021-03-10", Views: 1},
+				{Day: "2021-03-11", Queries: 1, Errors: 1},
+			},
+		}, insights)
+
+		_, err = service.getDashboardInsights(orgAdminRequest("/"), "unknown", 30)
+		require.Equal(t, errDashboardNotFound, err)
+	})
+
+	t.Run("least used dashboards start with the ones that weren't used", func(t *testing.T) {
+		service, _ := setupTestService(t)
+		busy := createTestDashboard(t, service, "busy")
+		quiet := createTestDashboard(t, service, "quiet")
+		unused := createTestDashboard(t, service, "unused")
+
+		service.RecordView(1, busy.Id)
+		service.RecordView(1, busy.Id)
+		service.RecordView(1, quiet.Id)
+		require.NoError(t, service.flush(context.Background()))
+
+		dashboards, err := service.getLeastUsedDashboards(orgAdminRequest("/"), 30, 100)
+		require.NoError(t, err)
+		require.Len(t, dashboards, 3)
+		require.Equal(t, unused.Id, dashboards[0].ID)
+		require.Equal(t, int64(0), dashboards[0].Views)
+		require.Equal(t, "General", dashboards[0].FolderTitle)
+		require.Equal(t, quiet.Id, dashboards[1].ID)
+		require.Equal(t, int64(1), dashboards[1].Views)
+		require.Equal(t, busy.Id, dashboards[2].ID)
+		require.Equal(t, int64(2), dashboards[2].Views)
+
+		dashboards, err = service.getLeastUsedDashboards(orgAdminRequest("/"), 30, 1)
+		require.NoError(t, err)
+		require.Len(t, dashboards, 1)
+	})
+
+	t.Run("old usage is deleted", func(t *testing.T) {
+		service, mock := setupTestService(t)
+		dash := createTestDashboard(t, service, "dashboard")
+
+		service.RecordView(1, dash.Id)
+		require.NoError(t, service.flush(context.Background()))
+
+		mock.Add(retentionDays * 24 * time.Hour)
+		deleted, err := service.DeleteOldUsage(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(0), deleted)
+
+		mock.Add(24 * time.Hour)
+		deleted, err = service.DeleteOldUsage(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+	})
+
+	t.Run("query parameters are validated", func(t *testing.T) {
+		service, _ := setupTestService(t)
+
+		for _, url := range []string{"/?days=0", "/?days=366", "/?limit=1001"} {
+			resp := service.getLeastUsedHandler(orgAdminRequest(url))
+			require.Equal(t, 400, resp.Status(), url)
+		}
+
+		resp := service.getLeastUsedHandler(orgAdminRequest("/"))
+		require.Equal(t, 200, resp.Status())
+	})
+}
+
+// setupTestService returns a service with the dashboardInsights feature toggle enabled and no
+// usage saved, whose clock is on 2021-03-10.
+func setupTestService(t *testing.T) (*DashboardInsightsService, *clock.Mock) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.FeatureToggles = map[string]bool{"dashboardInsights": true}
+	mock := clock.NewMock()
+	mock.Set(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+	service := &DashboardInsightsService{
+		Cfg:     cfg,
+		Clock:   mock,
+		log:     log.New("dashboardinsights.test"),
+		pending: map[usageKey]*usageCounts{},
+	}
+
+	// The usage table is only migrated if the service in the registry is enabled.
+	registry.RegisterOverride(func(d registry.Descriptor) (*registry.Descriptor, bool) {
+		if _, ok := d.Instance.(*DashboardInsightsService); !ok {
+			return nil, false
+		}
+		return &registry.Descriptor{Name: d.Name, Instance: service, InitPriority: d.InitPriority}, true
+	})
+	t.Cleanup(registry.ClearOverrides)
+
+	service.SQLStore = sqlstore.InitTestDB(t)
+	_, err := service.SQLStore.NewSession().Exec("DELETE FROM dashboard_usage_daily")
+	require.NoError(t, err)
+
+	return service, mock
+}
+
+func createTestDashboard(t *testing.T, service *DashboardInsightsService, title string) *models.Dashboard {
+	t.Helper()
+
+	dash, err := service.SQLStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title}),
+	})
+	require.NoError(t, err)
+	return dash
+}
+
+// orgAdminRequest returns the context of a request of an admin of org 1.
+func orgAdminRequest(url string) *models.ReqContext {
+	return &models.ReqContext{
+		Context:      &macaron.Context{Req: macaron.Request{Request: httptest.NewRequest("GET", url, nil)}},
+		SignedInUser: &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN},
+	}
+}
@@ -0,0 +1,196 @@
+package dashboardinsights
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// sinceDay returns the first day of a period of days ending today.
+func (s *DashboardInsightsService) sinceDay(days int) string {
+	return s.Clock.Now().UTC().AddDate(0, 0, 1-days).Format(dayFormat)
+}
+
+// saveUsage adds counted usage to the daily usage in the database.
+func (s *DashboardInsightsService) saveUsage(ctx context.Context, usage map[usageKey]*usageCounts) error {
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		for key, counts := range usage {
+			result, err := session.Exec("UPDATE dashboard_usage_daily SET views = views + ?, queries = queries + ?, errors = errors + ? WHERE org_id = ? AND dashboard_id = ? AND day = ?",
+				counts.views, counts.queries, counts.errors, key.orgID, key.dashboardID, key.day)
+			if err != nil {
+				return err
+			}
+			if rowsAffected, err := result.RowsAffected(); err != nil {
+				return err
+			} else if rowsAffected > 0 {
+				continue
+			}
+
+			daily := dashboardUsageDaily{
+				OrgID:       key.orgID,
+				DashboardID: key.dashboardID,
+				Day:         key.day,
+				Views:       counts.views,
+				Queries:     counts.queries,
+				Errors:      counts.errors,
+			}
+			if _, err := session.Insert(&daily); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteUsageBefore deletes the daily usage of the days before a day.
+func (s *DashboardInsightsService) deleteUsageBefore(ctx context.Context, day string) (int64, error) {
+	var affected int64
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		result, err := session.Exec("DELETE FROM dashboard_usage_daily WHERE day < ?", day)
+		if err != nil {
+			return err
+		}
+		affected, err = result.RowsAffected()
+		return err
+	})
+	return affected, err
+}
+
+// getDashboardInsights gets the daily usage of a dashboard the user can view.
+func (s *DashboardInsightsService) getDashboardInsights(c *models.ReqContext, uid string, days int) (DashboardInsightsDTO, error) {
+	query := models.GetDashboardQuery{Uid: uid, OrgId: c.SignedInUser.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDashboardNotFound {
+			return DashboardInsightsDTO{}, errDashboardNotFound
+		}
+		return DashboardInsightsDTO{}, err
+	}
+	dash := query.Result
+	if dash.IsFolder {
+		return DashboardInsightsDTO{}, errDashboardNotFound
+	}
+
+	g := guardian.New(dash.Id, c.SignedInUser.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil {
+		return DashboardInsightsDTO{}, err
+	} else if !canView {
+		return DashboardInsightsDTO{}, errDashboardAccessDenied
+	}
+
+	insights := DashboardInsightsDTO{
+		DashboardID: dash.Id,
+		UID:         dash.Uid,
+		Days:        days,
+		Daily:       make([]DailyUsageDTO, 0),
+	}
+	err := s.SQLStore.WithDbSession(c.Req.Context(), func(session *sqlstore.DBSession) error {
+		var daily []dashboardUsageDaily
+		err := session.Where("org_id = ? AND dashboard_id = ? AND day >= ?", dash.OrgId, dash.Id, s.sinceDay(days)).
+			Asc("day").
+			Find(&daily)
+		if err != nil {
+			return err
+		}
+
+		for _, usage := range daily {
+			insights.Views += usage.Views
+			insights.Queries += usage.Queries
+			insights.Errors += usage.Errors
+			insights.Daily = append(insights.Daily, DailyUsageDTO{
+				Day:     usage.Day,
+				Views:   usage.Views,
+				Queries: usage.Queries,
+				Errors:  usage.Errors,
+			})
+		}
+		return nil
+	})
+
+	return insights, err
+}
+
+// getLeastUsedDashboards gets the dashboards of the user's organization with the fewest views, and
+// then queries, over a number of days. Dashboards that weren't used at all come first.
+func (s *DashboardInsightsService) getLeastUsedDashboards(c *models.ReqContext, days int, limit int) ([]LeastUsedDashboardDTO, error) {
+	result := make([]LeastUsedDashboardDTO, 0)
+	err := s.SQLStore.WithDbSession(c.Req.Context(), func(session *sqlstore.DBSession) error {
+		sql := `SELECT d.id, d.uid, d.title, d.slug, d.folder_id, f.uid AS folder_uid, f.title AS folder_title, d.created, d.updated,
+	COALESCE(SUM(u.views), 0) AS views, COALESCE(SUM(u.queries), 0) AS queries, COALESCE(SUM(u.errors), 0) AS errors
+FROM dashboard AS d
+	LEFT JOIN dashboard AS f ON f.id = d.folder_id
+	LEFT JOIN dashboard_usage_daily AS u ON u.org_id = d.org_id AND u.dashboard_id = d.id AND u.day >= ?
+WHERE d.org_id = ? AND d.is_folder = ?
+GROUP BY d.id, d.uid, d.title, d.slug, d.folder_id, f.uid, f.title, d.created, d.updated
+ORDER BY views ASC, queries ASC, d.updated ASC
+` + s.SQLStore.Dialect.Limit(int64(limit))
+
+		var dashboards []leastUsedDashboard
+		err := session.SQL(sql, s.sinceDay(days), c.SignedInUser.OrgId, s.SQLStore.Dialect.BooleanStr(false)).Find(&dashboards)
+		if err != nil {
+			return err
+		}
+
+		for _, dash := range dashboards {
+			dto := LeastUsedDashboardDTO{
+				ID:          dash.ID,
+				UID:         dash.UID,
+				Title:       dash.Title,
+				URL:         models.GetDashboardUrl(dash.UID, dash.Slug),
+				FolderID:    dash.FolderID,
+				FolderUID:   dash.FolderUID,
+				FolderTitle: dash.FolderTitle,
+				Created:     dash.Created,
+				Updated:     dash.Updated,
+				Views:       dash.Views,
+				Queries:     dash.Queries,
+				Errors:      dash.Errors,
+			}
+			if dash.FolderID == 0 {
+				dto.FolderTitle = "General"
+			}
+			result = append(result, dto)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// getDashboardsUsageHandler handles GetDashboardsUsageQuery, which search uses to add the usage
+// of dashboards to its results.
+func (s *DashboardInsightsService) getDashboardsUsageHandler(ctx context.Context, query *models.GetDashboardsUsageQuery) error {
+	query.Result = map[int64]*models.DashboardUsage{}
+	if len(query.DashboardIds) == 0 {
+		return nil
+	}
+
+	return s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		var usages []struct {
+			DashboardID int64 `xorm:"dashboard_id"`
+			Views       int64
+			Queries     int64
+			Errors      int64
+		}
+		err := session.Table("dashboard_usage_daily").
+			Select("dashboard_id, SUM(views) AS views, SUM(queries) AS queries, SUM(errors) AS errors").
+			Where("org_id = ? AND day >= ?", query.OrgId, s.sinceDay(query.Days)).
+			In("dashboard_id", query.DashboardIds).
+			GroupBy("dashboard_id").
+			Find(&usages)
+		if err != nil {
+			return err
+		}
+
+		for _, usage := range usages {
+			query.Result[usage.DashboardID] = &models.DashboardUsage{
+				Views:   usage.Views,
+				Queries: usage.Queries,
+				Errors:  usage.Errors,
+			}
+		}
+		return nil
+	})
+}
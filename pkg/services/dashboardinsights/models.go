@@ -0,0 +1,95 @@
+package dashboardinsights
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// errDashboardNotFound is an error for an unknown dashboard.
+	errDashboardNotFound = errors.New("dashboard not found")
+	// errDashboardAccessDenied is an error for a dashboard the user can't view.
+	errDashboardAccessDenied = errors.New("access denied to dashboard")
+)
+
+// usageKey identifies the usage of a dashboard on a day.
+type usageKey struct {
+	orgID       int64
+	dashboardID int64
+	day         string
+}
+
+// usageCounts is the usage of a dashboard on a day counted since the last flush.
+type usageCounts struct {
+	views   int64
+	queries int64
+	errors  int64
+}
+
+func (c *usageCounts) add(other *usageCounts) {
+	c.views += other.views
+	c.queries += other.queries
+	c.errors += other.errors
+}
+
+// dashboardUsageDaily is the model for the daily usage of a dashboard.
+type dashboardUsageDaily struct {
+	ID          int64 `xorm:"pk autoincr 'id'"`
+	OrgID       int64 `xorm:"org_id"`
+	DashboardID int64 `xorm:"dashboard_id"`
+	Day         string
+	Views       int64
+	Queries     int64
+	Errors      int64
+}
+
+// DailyUsageDTO is the frontend DTO for the usage of a dashboard on a day.
+type DailyUsageDTO struct {
+	Day     string `json:"day"`
+	Views   int64  `json:"views"`
+	Queries int64  `json:"queries"`
+	Errors  int64  `json:"errors"`
+}
+
+// DashboardInsightsDTO is the frontend DTO for the usage of a dashboard over a number of days.
+type DashboardInsightsDTO struct {
+	DashboardID int64           `json:"dashboardId"`
+	UID         string          `json:"uid"`
+	Days        int             `json:"days"`
+	Views       int64           `json:"views"`
+	Queries     int64           `json:"queries"`
+	Errors      int64           `json:"errors"`
+	Daily       []DailyUsageDTO `json:"daily"`
+}
+
+// leastUsedDashboard is the model used to retrieve the dashboards used the least.
+type leastUsedDashboard struct {
+	ID          int64  `xorm:"id"`
+	UID         string `xorm:"uid"`
+	Title       string
+	Slug        string
+	FolderID    int64  `xorm:"folder_id"`
+	FolderUID   string `xorm:"folder_uid"`
+	FolderTitle string
+	Created     time.Time
+	Updated     time.Time
+	Views       int64
+	Queries     int64
+	Errors      int64
+}
+
+// LeastUsedDashboardDTO is the frontend DTO for the report of the dashboards used the least.
+type LeastUsedDashboardDTO struct {
+	ID          int64     `json:"id"`
+	UID         string    `json:"uid"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	FolderID    int64     `json:"folderId"`
+	FolderUID   string    `json:"folderUid,omitempty"`
+	FolderTitle string    `json:"folderTitle"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Views       int64     `json:"views"`
+	Queries     int64     `json:"queries"`
+	Errors      int64     `json:"errors"`
+}
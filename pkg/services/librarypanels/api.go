@@ -25,6 +25,7 @@ func (lps *LibraryPanelService) registerAPIEndpoints() {
 		libraryPanels.Get("/", middleware.ReqSignedIn, routing.Wrap(lps.getAllHandler))
 		libraryPanels.Get("/:uid", middleware.ReqSignedIn, routing.Wrap(lps.getHandler))
 		libraryPanels.Get("/:uid/dashboards/", middleware.ReqSignedIn, routing.Wrap(lps.getConnectedDashboardsHandler))
+		libraryPanels.Get("/:uid/versions", middleware.ReqSignedIn, routing.Wrap(lps.getVersionsHandler))
 		libraryPanels.Patch("/:uid", middleware.ReqSignedIn, binding.Bind(patchLibraryPanelCommand{}), routing.Wrap(lps.patchHandler))
 	})
 }
@@ -99,6 +100,16 @@ func (lps *LibraryPanelService) getConnectedDashboardsHandler(c *models.ReqConte
 	return response.JSON(200, util.DynMap{"result": dashboardIDs})
 }
 
+// getVersionsHandler handles GET /api/library-panels/:uid/versions.
+func (lps *LibraryPanelService) getVersionsHandler(c *models.ReqContext) response.Response {
+	versions, err := lps.getLibraryPanelVersions(c, c.Params(":uid"))
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel versions")
+	}
+
+	return response.JSON(200, util.DynMap{"result": versions})
+}
+
 // patchHandler handles PATCH /api/library-panels/:uid
 func (lps *LibraryPanelService) patchHandler(c *models.ReqContext, cmd patchLibraryPanelCommand) response.Response {
 	libraryPanel, err := lps.patchLibraryPanel(c, cmd, c.Params(":uid"))
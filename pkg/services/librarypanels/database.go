@@ -76,7 +76,7 @@ func (lps *LibraryPanelService) createLibraryPanel(c *models.ReqContext, cmd cre
 			}
 			return err
 		}
-		return nil
+		return saveLibraryPanelVersion(session, libraryPanel)
 	})
 
 	dto := LibraryPanelDTO{
@@ -176,6 +176,9 @@ func (lps *LibraryPanelService) deleteLibraryPanel(c *models.ReqContext, uid str
 		if _, err := session.Exec("DELETE FROM library_panel_dashboard WHERE librarypanel_id=?", panel.ID); err != nil {
 			return err
 		}
+		if _, err := session.Exec("DELETE FROM library_panel_version WHERE librarypanel_id=?", panel.ID); err != nil {
+			return err
+		}
 
 		result, err := session.Exec("DELETE FROM library_panel WHERE id=?", panel.ID)
 		if err != nil {
@@ -278,6 +281,9 @@ func (lps *LibraryPanelService) deleteLibraryPanelsInFolder(c *models.ReqContext
 			if err != nil {
 				return err
 			}
+			if _, err := session.Exec("DELETE FROM library_panel_version WHERE librarypanel_id=?", panelID.ID); err != nil {
+				return err
+			}
 		}
 		if _, err := session.Exec("DELETE FROM library_panel WHERE folder_id=? AND org_id=?", folderID, c.SignedInUser.OrgId); err != nil {
 			return err
@@ -463,6 +469,62 @@ func (lps *LibraryPanelService) getAllLibraryPanels(c *models.ReqContext, perPag
 	return result, err
 }
 
+// saveLibraryPanelVersion saves the current state of a Library Panel as a version.
+func saveLibraryPanelVersion(session *sqlstore.DBSession, libraryPanel LibraryPanel) error {
+	version := libraryPanelVersion{
+		LibraryPanelID: libraryPanel.ID,
+		FolderID:       libraryPanel.FolderID,
+		Version:        libraryPanel.Version,
+		Name:           libraryPanel.Name,
+		Model:          libraryPanel.Model,
+		Created:        libraryPanel.Updated,
+		CreatedBy:      libraryPanel.UpdatedBy,
+	}
+	_, err := session.Insert(&version)
+	return err
+}
+
+// getLibraryPanelVersions gets the saved versions of a Library Panel, latest first.
+func (lps *LibraryPanelService) getLibraryPanelVersions(c *models.ReqContext, uid string) ([]LibraryPanelVersionDTO, error) {
+	// getLibraryPanel checks that the user can view the library panel.
+	panel, err := lps.getLibraryPanel(c, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]LibraryPanelVersionDTO, 0)
+	err = lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		var libraryPanelVersions []libraryPanelVersionWithMeta
+		sql := `SELECT lpv.*, u.login AS created_by_name, u.email AS created_by_email
+FROM library_panel_version AS lpv
+	LEFT JOIN ` + lps.SQLStore.Dialect.Quote("user") + ` AS u ON lpv.created_by = u.id
+WHERE lpv.librarypanel_id=?
+ORDER BY lpv.version DESC`
+		if err := session.SQL(sql, panel.ID).Find(&libraryPanelVersions); err != nil {
+			return err
+		}
+
+		for _, version := range libraryPanelVersions {
+			versions = append(versions, LibraryPanelVersionDTO{
+				Version:  version.Version,
+				FolderID: version.FolderID,
+				Name:     version.Name,
+				Model:    version.Model,
+				Created:  version.Created,
+				CreatedBy: LibraryPanelDTOMetaUser{
+					ID:        version.CreatedBy,
+					Name:      version.CreatedByName,
+					AvatarUrl: dtos.GetGravatarUrl(version.CreatedByEmail),
+				},
+			})
+		}
+
+		return nil
+	})
+
+	return versions, err
+}
+
 // getConnectedDashboards gets all dashboards connected to a Library Panel.
 func (lps *LibraryPanelService) getConnectedDashboards(c *models.ReqContext, uid string) ([]int64, error) {
 	connectedDashboardIDs := make([]int64, 0)
@@ -608,6 +670,9 @@ func (lps *LibraryPanelService) patchLibraryPanel(c *models.ReqContext, cmd patc
 		} else if rowsAffected != 1 {
 			return errLibraryPanelNotFound
 		}
+		if err := saveLibraryPanelVersion(session, libraryPanel); err != nil {
+			return err
+		}
 
 		dto = LibraryPanelDTO{
 			ID:       libraryPanel.ID,
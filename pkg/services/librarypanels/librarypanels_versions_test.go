@@ -0,0 +1,83 @@
+package librarypanels
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestGetLibraryPanelVersions(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin tries to get the versions of a library panel that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": "unknown"})
+			resp := sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to get the versions of a library panel that was just created, it should return the first version",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			versions := unmarshalVersionsResponse(t, resp)
+			require.Len(t, versions, 1)
+			require.Equal(t, int64(1), versions[0].Version)
+			require.Equal(t, sc.initialResult.Result.Name, versions[0].Name)
+			require.Equal(t, int64(1), versions[0].CreatedBy.ID)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to get the versions of a patched library panel, it should return all versions latest first",
+		func(t *testing.T, sc scenarioContext) {
+			cmd := patchLibraryPanelCommand{
+				Name:    "Panel - New name",
+				Version: 1,
+			}
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.patchHandler(sc.reqContext, cmd)
+			require.Equal(t, 200, resp.Status())
+
+			resp = sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			versions := unmarshalVersionsResponse(t, resp)
+			require.Len(t, versions, 2)
+			require.Equal(t, int64(2), versions[0].Version)
+			require.Equal(t, "Panel - New name", versions[0].Name)
+			require.Equal(t, int64(1), versions[1].Version)
+			require.Equal(t, sc.initialResult.Result.Name, versions[1].Name)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to get the versions of a deleted library panel, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.deleteHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			resp = sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to get the versions of a library panel in another org, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.reqContext.SignedInUser.OrgId = 2
+			sc.reqContext.SignedInUser.OrgRole = models.ROLE_ADMIN
+			resp := sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+}
+
+func unmarshalVersionsResponse(t *testing.T, resp response.Response) []LibraryPanelVersionDTO {
+	t.Helper()
+
+	var result struct {
+		Result []LibraryPanelVersionDTO `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body(), &result))
+	return result.Result
+}
@@ -85,6 +85,44 @@ type LibraryPanelDTOMetaUser struct {
 	AvatarUrl string `json:"avatarUrl"`
 }
 
+// libraryPanelVersion is the model for the saved versions of a library panel.
+type libraryPanelVersion struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	FolderID       int64 `xorm:"folder_id"`
+	Version        int64
+	Name           string
+	Model          json.RawMessage
+
+	Created   time.Time
+	CreatedBy int64
+}
+
+// libraryPanelVersionWithMeta is the model used to retrieve library panel versions with the user that saved them.
+type libraryPanelVersionWithMeta struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	FolderID       int64 `xorm:"folder_id"`
+	Version        int64
+	Name           string
+	Model          json.RawMessage
+
+	Created        time.Time
+	CreatedBy      int64
+	CreatedByName  string
+	CreatedByEmail string
+}
+
+// LibraryPanelVersionDTO is the frontend DTO for a saved version of a library panel.
+type LibraryPanelVersionDTO struct {
+	Version   int64                   `json:"version"`
+	FolderID  int64                   `json:"folderId"`
+	Name      string                  `json:"name"`
+	Model     json.RawMessage         `json:"model"`
+	Created   time.Time               `json:"created"`
+	CreatedBy LibraryPanelDTOMetaUser `json:"createdBy"`
+}
+
 // libraryPanelDashboard is the model for library panel connections.
 type libraryPanelDashboard struct {
 	ID             int64 `xorm:"pk autoincr 'id'"`
@@ -2,9 +2,26 @@ package features
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/centrifugal/centrifuge"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+)
+
+const (
+	dashboardActionSaved            = "saved"
+	dashboardActionDeleted          = "deleted"
+	dashboardActionEditingStarted   = "editing-started"
+	dashboardActionEditingCancelled = "editing-cancelled"
+	// dashboardActionPresence is sent when someone starts or stops viewing a dashboard.
+	dashboardActionPresence = "presence"
 )
 
 // DashboardEvent events related to dashboards
@@ -13,11 +30,20 @@ type dashboardEvent struct {
 	Action    string `json:"action"` // saved, editing
 	UserID    int64  `json:"userId,omitempty"`
 	SessionID string `json:"sessionId,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+
+	// Presence is everyone viewing or editing the dashboard after the event.
+	Presence []*models.DashboardPresence `json:"presence,omitempty"`
 }
 
 // DashboardHandler manages all the `grafana/dashboard/*` channels
 type DashboardHandler struct {
 	Publisher models.ChannelPublisher
+
+	presenceMu sync.Mutex
+	// presence has the sessions on each dashboard by presence key and client ID.
+	presence map[string]map[string]*models.DashboardPresence
 }
 
 // GetHandlerForPath called on init
@@ -25,23 +51,123 @@ func (h *DashboardHandler) GetHandlerForPath(path string) (models.ChannelHandler
 	return h, nil // all dashboards share the same handler
 }
 
-// OnSubscribe for now allows anyone to subscribe to any dashboard
+// OnSubscribe allows anyone to subscribe to the changes of all dashboards, and users who can view
+// a dashboard to subscribe to the channel of the dashboard.
 func (h *DashboardHandler) OnSubscribe(c *centrifuge.Client, e centrifuge.SubscribeEvent) (centrifuge.SubscribeReply, error) {
-	return centrifuge.SubscribeReply{
+	reply := centrifuge.SubscribeReply{
 		Options: centrifuge.SubscribeOptions{
 			Presence:  true,
 			JoinLeave: true,
 		},
-	}, nil
+	}
+
+	uid, ok := dashboardUIDFromChannel(e.Channel)
+	if !ok {
+		return reply, nil
+	}
+
+	user, ok := livecontext.GetContextSignedUser(c.Context())
+	if !ok {
+		return centrifuge.SubscribeReply{}, centrifuge.ErrorUnauthorized
+	}
+	if _, err := getViewableDashboard(user, uid); err != nil {
+		return centrifuge.SubscribeReply{}, err
+	}
+
+	key := dashboardPresenceKey(user.OrgId, uid)
+	h.join(key, c.ID(), &models.DashboardPresence{
+		UserID: user.UserId,
+		Login:  user.Login,
+		Name:   user.Name,
+		Since:  time.Now(),
+	})
+	h.publishPresence(key, dashboardEvent{UID: uid, Action: dashboardActionPresence, UserID: user.UserId})
+	return reply, nil
 }
 
-// OnPublish is called when someone begins to edit a dashoard
+// OnPublish is called when someone begins or stops to edit a dashboard. The event is published
+// with the user and who is editing the dashboard filled in by the server.
 func (h *DashboardHandler) OnPublish(c *centrifuge.Client, e centrifuge.PublishEvent) (centrifuge.PublishReply, error) {
+	uid, ok := dashboardUIDFromChannel(e.Channel)
+	if !ok {
+		return centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied
+	}
+
+	user, ok := livecontext.GetContextSignedUser(c.Context())
+	if !ok {
+		return centrifuge.PublishReply{}, centrifuge.ErrorUnauthorized
+	}
+
+	var event dashboardEvent
+	if err := json.Unmarshal(e.Data, &event); err != nil {
+		return centrifuge.PublishReply{}, centrifuge.ErrorBadRequest
+	}
+
+	switch event.Action {
+	case dashboardActionEditingStarted:
+		dash, err := getViewableDashboard(user, uid)
+		if err != nil {
+			return centrifuge.PublishReply{}, err
+		}
+		if canSave, err := guardian.New(dash.Id, user.OrgId, user).CanSave(); err != nil {
+			return centrifuge.PublishReply{}, centrifuge.ErrorInternal
+		} else if !canSave {
+			return centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied
+		}
+	case dashboardActionEditingCancelled:
+	default:
+		return centrifuge.PublishReply{}, centrifuge.ErrorBadRequest
+	}
+
+	key := dashboardPresenceKey(user.OrgId, uid)
+	if !h.setEditing(key, c.ID(), event.SessionID, event.Action == dashboardActionEditingStarted) {
+		// Only subscribers of the dashboard channel can tell they are editing it.
+		return centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied
+	}
+
+	event.UID = uid
+	event.UserID = user.UserId
+	if err := h.publishPresence(key, event); err != nil {
+		return centrifuge.PublishReply{}, centrifuge.ErrorInternal
+	}
+
+	// The event was published above, so centrifuge must not publish the original.
 	return centrifuge.PublishReply{
-		Options: centrifuge.PublishOptions{},
+		Result: &centrifuge.PublishResult{},
 	}, nil
 }
 
+// OnUnsubscribe is called when someone leaves a dashboard, which cancels editing it.
+func (h *DashboardHandler) OnUnsubscribe(c *centrifuge.Client, e centrifuge.UnsubscribeEvent) {
+	uid, ok := dashboardUIDFromChannel(e.Channel)
+	if !ok {
+		return
+	}
+
+	user, ok := livecontext.GetContextSignedUser(c.Context())
+	if !ok {
+		return
+	}
+
+	key := dashboardPresenceKey(user.OrgId, uid)
+	left := h.leave(key, c.ID())
+	if left == nil {
+		return
+	}
+
+	action := dashboardActionPresence
+	if left.Editing {
+		action = dashboardActionEditingCancelled
+	}
+	_ = h.publishPresence(key, dashboardEvent{UID: uid, Action: action, UserID: user.UserId, SessionID: left.SessionID})
+}
+
+// DashboardPresence returns the sessions currently viewing or editing a dashboard, the longest
+// present first.
+func (h *DashboardHandler) DashboardPresence(orgID int64, uid string) []*models.DashboardPresence {
+	return h.getPresence(dashboardPresenceKey(orgID, uid))
+}
+
 // DashboardSaved should broadcast to the appropriate stream
 func (h *DashboardHandler) publish(event dashboardEvent) error {
 	msg, err := json.Marshal(event)
@@ -55,11 +181,25 @@ func (h *DashboardHandler) publish(event dashboardEvent) error {
 	return h.Publisher("grafana/dashboard/changes", msg)
 }
 
+// publishPresence publishes an event with the presence of a dashboard to its channel.
+func (h *DashboardHandler) publishPresence(key string, event dashboardEvent) error {
+	event.Presence = h.getPresence(key)
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return h.Publisher("grafana/dashboard/uid/"+event.UID, msg)
+}
+
 // DashboardSaved will broadcast to all connected dashboards
 func (h *DashboardHandler) DashboardSaved(uid string, userID int64) error {
 	return h.publish(dashboardEvent{
 		UID:    uid,
-		Action: "saved",
+		Action: dashboardActionSaved,
 		UserID: userID,
 	})
 }
@@ -68,7 +208,100 @@ func (h *DashboardHandler) DashboardSaved(uid string, userID int64) error {
 func (h *DashboardHandler) DashboardDeleted(uid string, userID int64) error {
 	return h.publish(dashboardEvent{
 		UID:    uid,
-		Action: "deleted",
+		Action: dashboardActionDeleted,
 		UserID: userID,
 	})
 }
+
+func (h *DashboardHandler) join(key string, clientID string, p *models.DashboardPresence) {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	if h.presence == nil {
+		h.presence = make(map[string]map[string]*models.DashboardPresence)
+	}
+	if h.presence[key] == nil {
+		h.presence[key] = make(map[string]*models.DashboardPresence)
+	}
+	h.presence[key][clientID] = p
+}
+
+// setEditing sets whether a client edits a dashboard, and returns false if the client isn't on it.
+func (h *DashboardHandler) setEditing(key string, clientID string, sessionID string, editing bool) bool {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	p, ok := h.presence[key][clientID]
+	if !ok {
+		return false
+	}
+	p.Editing = editing
+	if sessionID != "" {
+		p.SessionID = sessionID
+	}
+	return true
+}
+
+// leave removes a client from a dashboard and returns its presence, or nil if it wasn't on it.
+func (h *DashboardHandler) leave(key string, clientID string) *models.DashboardPresence {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	p, ok := h.presence[key][clientID]
+	if !ok {
+		return nil
+	}
+	delete(h.presence[key], clientID)
+	if len(h.presence[key]) == 0 {
+		delete(h.presence, key)
+	}
+	return p
+}
+
+func (h *DashboardHandler) getPresence(key string) []*models.DashboardPresence {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	result := make([]*models.DashboardPresence, 0, len(h.presence[key]))
+	for _, p := range h.presence[key] {
+		copied := *p
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Since.Before(result[j].Since)
+	})
+	return result
+}
+
+// dashboardUIDFromChannel returns the dashboard UID of a `grafana/dashboard/uid/${uid}` channel.
+func dashboardUIDFromChannel(channel string) (string, bool) {
+	uid := strings.TrimPrefix(channel, "grafana/dashboard/uid/")
+	if uid == channel || uid == "" {
+		return "", false
+	}
+	return uid, true
+}
+
+// dashboardPresenceKey returns the key of the presence of a dashboard. Dashboard UIDs are only
+// unique within an organization.
+func dashboardPresenceKey(orgID int64, uid string) string {
+	return fmt.Sprintf("%d/%s", orgID, uid)
+}
+
+// getViewableDashboard gets a dashboard, or returns a centrifuge error if the user can't view it.
+func getViewableDashboard(user *models.SignedInUser, uid string) (*models.Dashboard, error) {
+	query := models.GetDashboardQuery{Uid: uid, OrgId: user.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDashboardNotFound {
+			return nil, centrifuge.ErrorUnknownChannel
+		}
+		return nil, centrifuge.ErrorInternal
+	}
+
+	if canView, err := guardian.New(query.Result.Id, user.OrgId, user).CanView(); err != nil {
+		return nil, centrifuge.ErrorInternal
+	} else if !canView {
+		return nil, centrifuge.ErrorPermissionDenied
+	}
+	return query.Result, nil
+}
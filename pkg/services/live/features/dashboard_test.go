@@ -0,0 +1,114 @@
+package features
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type publication struct {
+	channel string
+	event   dashboardEvent
+}
+
+func newTestDashboardHandler(t *testing.T) (*DashboardHandler, *[]publication) {
+	t.Helper()
+
+	published := []publication{}
+	h := &DashboardHandler{
+		Publisher: func(channel string, data []byte) error {
+			var event dashboardEvent
+			require.NoError(t, json.Unmarshal(data, &event))
+			published = append(published, publication{channel: channel, event: event})
+			return nil
+		},
+	}
+	return h, &published
+}
+
+func TestDashboardHandler_Presence(t *testing.T) {
+	h, published := newTestDashboardHandler(t)
+	since := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	key := dashboardPresenceKey(1, "abc")
+	h.join(key, "client-2", &models.DashboardPresence{UserID: 2, Login: "editor", Since: since.Add(time.Minute)})
+	h.join(key, "client-1", &models.DashboardPresence{UserID: 1, Login: "admin", Since: since})
+	h.join(dashboardPresenceKey(2, "abc"), "client-3", &models.DashboardPresence{UserID: 3, Since: since})
+
+	t.Run("Presence is ordered by arrival and scoped to the organization", func(t *testing.T) {
+		presence := h.DashboardPresence(1, "abc")
+		require.Len(t, presence, 2)
+		assert.Equal(t, "admin", presence[0].Login)
+		assert.Equal(t, "editor", presence[1].Login)
+
+		require.Len(t, h.DashboardPresence(2, "abc"), 1)
+		require.Empty(t, h.DashboardPresence(1, "unknown"))
+	})
+
+	t.Run("Only clients on a dashboard can start editing it", func(t *testing.T) {
+		require.True(t, h.setEditing(key, "client-2", "session", true))
+		require.False(t, h.setEditing(key, "client-3", "session", true))
+
+		presence := h.DashboardPresence(1, "abc")
+		assert.False(t, presence[0].Editing)
+		assert.True(t, presence[1].Editing)
+		assert.Equal(t, "session", presence[1].SessionID)
+	})
+
+	t.Run("Returned presence can't change the tracked presence", func(t *testing.T) {
+		h.DashboardPresence(1, "abc")[0].Editing = true
+		assert.False(t, h.DashboardPresence(1, "abc")[0].Editing)
+	})
+
+	t.Run("Presence events are published to the dashboard channel", func(t *testing.T) {
+		require.NoError(t, h.publishPresence(key, dashboardEvent{UID: "abc", Action: dashboardActionEditingStarted, UserID: 2}))
+
+		require.Len(t, *published, 1)
+		p := (*published)[0]
+		assert.Equal(t, "grafana/dashboard/uid/abc", p.channel)
+		assert.Equal(t, dashboardActionEditingStarted, p.event.Action)
+		assert.NotZero(t, p.event.Timestamp)
+		require.Len(t, p.event.Presence, 2)
+		assert.True(t, p.event.Presence[1].Editing)
+	})
+
+	t.Run("Leaving a dashboard returns whether the client was editing it", func(t *testing.T) {
+		left := h.leave(key, "client-2")
+		require.NotNil(t, left)
+		assert.True(t, left.Editing)
+
+		assert.Nil(t, h.leave(key, "client-2"))
+		require.Len(t, h.DashboardPresence(1, "abc"), 1)
+
+		require.NotNil(t, h.leave(key, "client-1"))
+		assert.Empty(t, h.DashboardPresence(1, "abc"))
+	})
+}
+
+func TestDashboardHandler_DashboardSaved(t *testing.T) {
+	h, published := newTestDashboardHandler(t)
+
+	require.NoError(t, h.DashboardSaved("abc", 2))
+
+	require.Len(t, *published, 2)
+	assert.Equal(t, "grafana/dashboard/uid/abc", (*published)[0].channel)
+	assert.Equal(t, "grafana/dashboard/changes", (*published)[1].channel)
+	for _, p := range *published {
+		assert.Equal(t, dashboardEvent{UID: "abc", Action: dashboardActionSaved, UserID: 2}, p.event)
+	}
+}
+
+func TestDashboardUIDFromChannel(t *testing.T) {
+	uid, ok := dashboardUIDFromChannel("grafana/dashboard/uid/abc")
+	require.True(t, ok)
+	assert.Equal(t, "abc", uid)
+
+	_, ok = dashboardUIDFromChannel("grafana/dashboard/changes")
+	assert.False(t, ok)
+	_, ok = dashboardUIDFromChannel("grafana/dashboard/uid/")
+	assert.False(t, ok)
+}
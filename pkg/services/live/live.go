@@ -11,8 +11,10 @@ import (
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/live/features"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/loki"
 )
 
 var (
@@ -43,9 +45,13 @@ type GrafanaLive struct {
 	Cfg           *setting.Cfg            `inject:""`
 	RouteRegister routing.RouteRegister   `inject:""`
 	LogsService   *cloudwatch.LogsService `inject:""`
+	LokiService   *loki.Service           `inject:""`
 	PluginManager plugins.Manager         `inject:""`
 	node          *centrifuge.Node
 
+	// lokiTails runs the Loki live tails, it's kept so subscribers of a tail share its runner.
+	lokiTails *loki.TailRunnerSupplier
+
 	// The websocket handler
 	WebsocketHandler interface{}
 
@@ -97,6 +103,11 @@ func (g *GrafanaLive) Init() error {
 	g.GrafanaScope.Features["broadcast"] = &features.BroadcastRunner{}
 	g.GrafanaScope.Features["measurements"] = &features.MeasurementsRunner{}
 
+	g.lokiTails = &loki.TailRunnerSupplier{
+		Publisher: g.Publish,
+		Service:   g.LokiService,
+	}
+
 	// Set ConnectHandler called when client successfully connected to Node. Your code
 	// inside handler must be synchronized since it will be called concurrently from
 	// different goroutines (belonging to different client connections). This is also
@@ -124,6 +135,19 @@ func (g *GrafanaLive) Init() error {
 				cb(handler.OnPublish(client, e))
 			}
 		})
+
+		// Called when a client unsubscribes from a channel, including when it disconnects.
+		client.OnUnsubscribe(func(e centrifuge.UnsubscribeEvent) {
+			g.channelsMu.RLock()
+			handler, ok := g.channels[e.Channel]
+			g.channelsMu.RUnlock()
+			if !ok {
+				return
+			}
+			if h, ok := handler.(models.ChannelUnsubscribeHandler); ok {
+				h.OnUnsubscribe(client, e)
+			}
+		})
 	})
 
 	// Run node. This method does not block.
@@ -149,6 +173,8 @@ func (g *GrafanaLive) Init() error {
 			UserID: fmt.Sprintf("%d", user.UserId),
 		}
 		newCtx := centrifuge.SetCredentials(ctx.Req.Context(), cred)
+		// Channel handlers check permissions with the signed in user.
+		newCtx = livecontext.SetContextSignedUser(newCtx, user)
 
 		r := ctx.Req.Request
 		r = r.WithContext(newCtx) // Set a user ID.
@@ -222,6 +248,9 @@ func (g *GrafanaLive) GetChannelHandlerFactory(scope string, name string) (model
 				Service:   g.LogsService,
 			}, nil
 		}
+		if name == "loki" {
+			return g.lokiTails, nil
+		}
 
 		p := g.PluginManager.GetPlugin(name)
 		if p != nil {
@@ -0,0 +1,25 @@
+package livecontext
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+type signedUserContextKeyType int
+
+var signedUserContextKey signedUserContextKeyType
+
+// SetContextSignedUser returns a copy of ctx with the user of a Grafana Live connection.
+func SetContextSignedUser(ctx context.Context, user *models.SignedInUser) context.Context {
+	return context.WithValue(ctx, signedUserContextKey, user)
+}
+
+// GetContextSignedUser returns the user of a Grafana Live connection, if ctx has one.
+func GetContextSignedUser(ctx context.Context) (*models.SignedInUser, bool) {
+	if val := ctx.Value(signedUserContextKey); val != nil {
+		user, ok := val.(*models.SignedInUser)
+		return user, ok
+	}
+	return nil, false
+}
@@ -43,6 +43,7 @@ type AlertNG struct {
 	SQLStore        *sqlstore.SQLStore                      `inject:""`
 	DataService     *tsdb.Service                           `inject:""`
 	DataProxy       *datasourceproxy.DatasourceProxyService `inject:""`
+	Clock           clock.Clock                             `inject:""`
 	Log             log.Logger
 	schedule        schedule.ScheduleService
 }
@@ -55,12 +56,16 @@ func init() {
 func (ng *AlertNG) Init() error {
 	ng.Log = log.New("ngalert")
 
+	if ng.Clock == nil {
+		ng.Clock = clock.New()
+	}
+
 	baseInterval := baseIntervalSeconds * time.Second
 
 	store := store.DBstore{BaseInterval: baseInterval, DefaultIntervalSeconds: defaultIntervalSeconds, SQLStore: ng.SQLStore}
 
 	schedCfg := schedule.SchedulerCfg{
-		C:            clock.New(),
+		C:            ng.Clock,
 		BaseInterval: baseInterval,
 		Logger:       ng.Log,
 		MaxAttempts:  maxAttempts,
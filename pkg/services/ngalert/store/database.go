@@ -10,7 +10,6 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 
 	"github.com/grafana/grafana/pkg/services/sqlstore"
-	"github.com/grafana/grafana/pkg/util"
 )
 
 // TimeNow makes it possible to test usage of time
@@ -107,7 +106,7 @@ func (st DBstore) SaveAlertDefinition(cmd *models.SaveAlertDefinitionCommand) er
 
 		var initialVersion int64 = 1
 
-		uid, err := generateNewAlertDefinitionUID(sess, cmd.OrgID)
+		uid, err := st.generateNewAlertDefinitionUID(sess, cmd.OrgID)
 		if err != nil {
 			return fmt.Errorf("failed to generate UID for alert definition %q: %w", cmd.Title, err)
 		}
@@ -299,9 +298,9 @@ func (st DBstore) UpdateAlertDefinitionPaused(cmd *models.UpdateAlertDefinitionP
 	})
 }
 
-func generateNewAlertDefinitionUID(sess *sqlstore.DBSession, orgID int64) (string, error) {
+func (st DBstore) generateNewAlertDefinitionUID(sess *sqlstore.DBSession, orgID int64) (string, error) {
 	for i := 0; i < 3; i++ {
-		uid := util.GenerateShortUID()
+		uid := st.SQLStore.UIDGenerator.GenerateUID()
 
 		exists, err := sess.Where("org_id=? AND uid=?", orgID, uid).Get(&models.AlertDefinition{})
 		if err != nil {
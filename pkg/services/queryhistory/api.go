@@ -0,0 +1,90 @@
+package queryhistory
+
+import (
+	"errors"
+
+	"github.com/go-macaron/binding"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	defaultPerPage = 100
+	maxPerPage     = 1000
+	maxPage        = 10000
+)
+
+func (s *QueryHistoryService) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/query-history", func(entities routing.RouteRegister) {
+		entities.Post("/", middleware.ReqSignedIn, binding.Bind(CreateQueryCommand{}), routing.Wrap(s.createHandler))
+		entities.Get("/", middleware.ReqSignedIn, routing.Wrap(s.searchHandler))
+		entities.Delete("/", middleware.ReqSignedIn, routing.Wrap(s.clearHandler))
+		entities.Delete("/:uid", middleware.ReqSignedIn, routing.Wrap(s.deleteHandler))
+	})
+}
+
+// createHandler handles POST /api/query-history.
+func (s *QueryHistoryService) createHandler(c *models.ReqContext, cmd CreateQueryCommand) response.Response {
+	query, err := s.createQuery(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return response.Error(500, "Failed to add query to query history", err)
+	}
+
+	return response.JSON(200, util.DynMap{"result": query})
+}
+
+// searchHandler handles GET /api/query-history.
+func (s *QueryHistoryService) searchHandler(c *models.ReqContext) response.Response {
+	page, ok := queryIntInRange(c, "page", 1, maxPage)
+	if !ok {
+		return response.Error(400, "page must be between 1 and 10000", nil)
+	}
+	perPage, ok := queryIntInRange(c, "perPage", defaultPerPage, maxPerPage)
+	if !ok {
+		return response.Error(400, "perPage must be between 1 and 1000", nil)
+	}
+
+	result, err := s.searchQueries(c.Req.Context(), c.SignedInUser, c.Query("datasourceUid"), page, perPage)
+	if err != nil {
+		return response.Error(500, "Failed to get query history", err)
+	}
+
+	return response.JSON(200, util.DynMap{"result": result})
+}
+
+// deleteHandler handles DELETE /api/query-history/:uid.
+func (s *QueryHistoryService) deleteHandler(c *models.ReqContext) response.Response {
+	err := s.deleteQuery(c.Req.Context(), c.SignedInUser, c.Params(":uid"))
+	if err != nil {
+		if errors.Is(err, errQueryNotFound) {
+			return response.Error(404, errQueryNotFound.Error(), err)
+		}
+		return response.Error(500, "Failed to delete query from query history", err)
+	}
+
+	return response.Success("Query deleted from query history")
+}
+
+// clearHandler handles DELETE /api/query-history.
+func (s *QueryHistoryService) clearHandler(c *models.ReqContext) response.Response {
+	deleted, err := s.clearQueries(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return response.Error(500, "Failed to clear query history", err)
+	}
+
+	return response.JSON(200, util.DynMap{"message": "Query history cleared", "deleted": deleted})
+}
+
+// queryIntInRange returns the value of an integer query parameter, or fallback if it's not set,
+// and whether it's between 1 and max.
+func queryIntInRange(c *models.ReqContext, name string, fallback int, max int) (int, bool) {
+	if c.Query(name) == "" {
+		return fallback, true
+	}
+	value := c.QueryInt(name)
+	return value, value >= 1 && value <= max
+}
@@ -0,0 +1,153 @@
+package queryhistory
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// createQuery adds a query to the user's query history.
+func (s *QueryHistoryService) createQuery(ctx context.Context, user *models.SignedInUser, cmd CreateQueryCommand) (QueryHistoryDTO, error) {
+	queries := cmd.Queries
+	if queries == nil {
+		queries = simplejson.NewFromAny([]interface{}{})
+	}
+
+	query := QueryHistory{
+		UID:           util.GenerateShortUID(),
+		OrgID:         user.OrgId,
+		DatasourceUID: cmd.DatasourceUID,
+		CreatedBy:     user.UserId,
+		CreatedAt:     s.Clock.Now().Unix(),
+		Comment:       cmd.Comment,
+		Queries:       queries,
+	}
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		_, err := session.Insert(&query)
+		return err
+	})
+
+	return query.toDTO(), err
+}
+
+// searchQueries gets a page of the user's query history, newest first, optionally only the
+// queries of a data source.
+func (s *QueryHistoryService) searchQueries(ctx context.Context, user *models.SignedInUser, datasourceUID string, page int, perPage int) (QueryHistorySearchResult, error) {
+	result := QueryHistorySearchResult{
+		QueryHistory: make([]QueryHistoryDTO, 0),
+		Page:         page,
+		PerPage:      perPage,
+	}
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		where := "org_id = ? AND created_by = ?"
+		params := []interface{}{user.OrgId, user.UserId}
+		if datasourceUID != "" {
+			where += " AND datasource_uid = ?"
+			params = append(params, datasourceUID)
+		}
+
+		var queries []QueryHistory
+		err := session.Where(where, params...).Desc("created_at", "id").Limit(perPage, (page-1)*perPage).Find(&queries)
+		if err != nil {
+			return err
+		}
+		for _, query := range queries {
+			result.QueryHistory = append(result.QueryHistory, query.toDTO())
+		}
+
+		result.TotalCount, err = session.Where(where, params...).Count(&QueryHistory{})
+		return err
+	})
+
+	return result, err
+}
+
+// deleteQuery deletes a query from the user's query history.
+func (s *QueryHistoryService) deleteQuery(ctx context.Context, user *models.SignedInUser, uid string) error {
+	return s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		result, err := session.Exec("DELETE FROM query_history WHERE org_id = ? AND created_by = ? AND uid = ?", user.OrgId, user.UserId, uid)
+		if err != nil {
+			return err
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rowsAffected == 0 {
+			return errQueryNotFound
+		}
+		return nil
+	})
+}
+
+// clearQueries deletes the user's whole query history.
+func (s *QueryHistoryService) clearQueries(ctx context.Context, user *models.SignedInUser) (int64, error) {
+	var affected int64
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		result, err := session.Exec("DELETE FROM query_history WHERE org_id = ? AND created_by = ?", user.OrgId, user.UserId)
+		if err != nil {
+			return err
+		}
+		affected, err = result.RowsAffected()
+		return err
+	})
+	return affected, err
+}
+
+// deleteQueriesBefore deletes the queries created before a unix time.
+func (s *QueryHistoryService) deleteQueriesBefore(ctx context.Context, before int64) (int64, error) {
+	var affected int64
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		result, err := session.Exec("DELETE FROM query_history WHERE created_at < ?", before)
+		if err != nil {
+			return err
+		}
+		affected, err = result.RowsAffected()
+		return err
+	})
+	return affected, err
+}
+
+// deleteQueriesOverLimit deletes the oldest queries of every user with more than limit queries,
+// keeping their newest limit queries.
+func (s *QueryHistoryService) deleteQueriesOverLimit(ctx context.Context, limit int) (int64, error) {
+	var affected int64
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		var users []struct {
+			OrgID     int64 `xorm:"org_id"`
+			CreatedBy int64
+		}
+		err := session.SQL("SELECT org_id, created_by FROM query_history GROUP BY org_id, created_by HAVING COUNT(*) > ?", limit).
+			Find(&users)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			// The ID of the oldest query to keep, as queries are created in the order of their IDs.
+			var keepFrom []int64
+			err := session.SQL("SELECT id FROM query_history WHERE org_id = ? AND created_by = ? ORDER BY id DESC "+
+				s.SQLStore.Dialect.LimitOffset(1, int64(limit-1)), user.OrgID, user.CreatedBy).
+				Find(&keepFrom)
+			if err != nil {
+				return err
+			}
+			if len(keepFrom) == 0 {
+				continue
+			}
+
+			result, err := session.Exec("DELETE FROM query_history WHERE org_id = ? AND created_by = ? AND id < ?", user.OrgID, user.CreatedBy, keepFrom[0])
+			if err != nil {
+				return err
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			affected += rowsAffected
+		}
+		return nil
+	})
+	return affected, err
+}
@@ -0,0 +1,60 @@
+package queryhistory
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var (
+	// errQueryNotFound is an error for a query that isn't in the user's query history.
+	errQueryNotFound = errors.New("query not found")
+)
+
+// QueryHistory is the model for a query run by a user.
+type QueryHistory struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	UID           string `xorm:"uid"`
+	OrgID         int64  `xorm:"org_id"`
+	DatasourceUID string `xorm:"datasource_uid"`
+	CreatedBy     int64
+	CreatedAt     int64
+	Comment       string
+	Queries       *simplejson.Json
+}
+
+// CreateQueryCommand is the command for adding a query to the query history.
+type CreateQueryCommand struct {
+	DatasourceUID string           `json:"datasourceUid"`
+	Queries       *simplejson.Json `json:"queries"`
+	Comment       string           `json:"comment"`
+}
+
+// QueryHistoryDTO is the frontend DTO for a query of the query history.
+type QueryHistoryDTO struct {
+	UID           string           `json:"uid"`
+	DatasourceUID string           `json:"datasourceUid"`
+	CreatedBy     int64            `json:"createdBy"`
+	CreatedAt     int64            `json:"createdAt"`
+	Comment       string           `json:"comment"`
+	Queries       *simplejson.Json `json:"queries"`
+}
+
+func (q QueryHistory) toDTO() QueryHistoryDTO {
+	return QueryHistoryDTO{
+		UID:           q.UID,
+		DatasourceUID: q.DatasourceUID,
+		CreatedBy:     q.CreatedBy,
+		CreatedAt:     q.CreatedAt,
+		Comment:       q.Comment,
+		Queries:       q.Queries,
+	}
+}
+
+// QueryHistorySearchResult is the frontend DTO for a page of the user's query history.
+type QueryHistorySearchResult struct {
+	TotalCount   int64             `json:"totalCount"`
+	QueryHistory []QueryHistoryDTO `json:"queryHistory"`
+	Page         int               `json:"page"`
+	PerPage      int               `json:"perPage"`
+}
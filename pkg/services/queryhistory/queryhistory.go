@@ -0,0 +1,107 @@
+package queryhistory
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// QueryHistoryService stores the queries users run in Explore, and deletes the ones older than
+// the configured maximum age and the ones beyond the configured maximum number per user.
+type QueryHistoryService struct {
+	Cfg           *setting.Cfg          `inject:""`
+	SQLStore      *sqlstore.SQLStore    `inject:""`
+	RouteRegister routing.RouteRegister `inject:""`
+	Clock         clock.Clock           `inject:""`
+	log           log.Logger
+}
+
+func init() {
+	registry.RegisterService(&QueryHistoryService{})
+}
+
+// Init initializes the query history service.
+func (s *QueryHistoryService) Init() error {
+	s.log = log.New("query-history")
+	if s.Clock == nil {
+		s.Clock = clock.New()
+	}
+
+	if !s.IsDisabled() {
+		s.registerAPIEndpoints()
+	}
+
+	return nil
+}
+
+// IsDisabled returns true if the query history feature is disabled for this instance.
+func (s *QueryHistoryService) IsDisabled() bool {
+	if s.Cfg == nil {
+		return true
+	}
+
+	return !s.Cfg.IsQueryHistoryEnabled()
+}
+
+// DeleteOldQueries deletes the queries older than the maximum age, and then the oldest queries of
+// the users who have more than the maximum number of queries. It returns how many were deleted.
+func (s *QueryHistoryService) DeleteOldQueries(ctx context.Context) (int64, error) {
+	var deleted int64
+	if s.Cfg.QueryHistoryMaxAge > 0 {
+		before := s.Clock.Now().Add(-s.Cfg.QueryHistoryMaxAge).Unix()
+		affected, err := s.deleteQueriesBefore(ctx, before)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += affected
+	}
+
+	if s.Cfg.QueryHistoryMaxEntriesPerUser > 0 {
+		affected, err := s.deleteQueriesOverLimit(ctx, s.Cfg.QueryHistoryMaxEntriesPerUser)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += affected
+	}
+
+	return deleted, nil
+}
+
+// AddMigration defines database migrations.
+// If query history is not enabled does nothing.
+func (s *QueryHistoryService) AddMigration(mg *migrator.Migrator) {
+	if s.IsDisabled() {
+		return
+	}
+
+	queryHistoryV1 := migrator.Table{
+		Name: "query_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "datasource_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "created_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "comment", Type: migrator.DB_Text, Nullable: false},
+			{Name: "queries", Type: migrator.DB_Text, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "uid"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"org_id", "created_by"}},
+			{Cols: []string{"created_at"}},
+		},
+	}
+
+	mg.AddMigration("create query_history table v1", migrator.NewAddTableMigration(queryHistoryV1))
+	mg.AddMigration("add unique index query_history org_id & uid", migrator.NewAddIndexMigration(queryHistoryV1, queryHistoryV1.Indices[0]))
+	mg.AddMigration("add index query_history org_id & created_by", migrator.NewAddIndexMigration(queryHistoryV1, queryHistoryV1.Indices[1]))
+	mg.AddMigration("add index query_history created_at", migrator.NewAddIndexMigration(queryHistoryV1, queryHistoryV1.Indices[2]))
+}
@@ -0,0 +1,182 @@
+package queryhistory
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestQueryHistory(t *testing.T) {
+	t.Run("users search their own queries, newest first", func(t *testing.T) {
+		service, mock := newTestService(t)
+
+		first := createTestQuery(t, service, testUser, "loki")
+		mock.Add(time.Minute)
+		second := createTestQuery(t, service, testUser, "prometheus")
+		createTestQuery(t, service, otherUser, "loki")
+
+		result, err := service.searchQueries(context.Background(), testUser, "", 1, 100)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), result.TotalCount)
+		require.Equal(t, []string{second.UID, first.UID}, queryUIDs(result))
+		require.Equal(t, "expr", result.QueryHistory[0].Queries.GetIndex(0).Get("refId").MustString())
+
+		result, err = service.searchQueries(context.Background(), testUser, "loki", 1, 100)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.TotalCount)
+		require.Equal(t, []string{first.UID}, queryUIDs(result))
+
+		result, err = service.searchQueries(context.Background(), testUser, "", 2, 1)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), result.TotalCount)
+		require.Equal(t, []string{first.UID}, queryUIDs(result))
+	})
+
+	t.Run("users delete and clear their own queries only", func(t *testing.T) {
+		service, _ := newTestService(t)
+
+		first := createTestQuery(t, service, testUser, "loki")
+		createTestQuery(t, service, testUser, "loki")
+		others := createTestQuery(t, service, otherUser, "loki")
+
+		require.Equal(t, errQueryNotFound, service.deleteQuery(context.Background(), testUser, others.UID))
+		require.NoError(t, service.deleteQuery(context.Background(), testUser, first.UID))
+		require.Equal(t, errQueryNotFound, service.deleteQuery(context.Background(), testUser, first.UID))
+
+		deleted, err := service.clearQueries(context.Background(), testUser)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+
+		result, err := service.searchQueries(context.Background(), otherUser, "", 1, 100)
+		require.NoError(t, err)
+		require.Equal(t, []string{others.UID}, queryUIDs(result))
+	})
+
+	t.Run("queries older than the maximum age are deleted", func(t *testing.T) {
+		service, mock := newTestService(t)
+		service.Cfg.QueryHistoryMaxAge = 24 * time.Hour
+
+		createTestQuery(t, service, testUser, "loki")
+		mock.Add(time.Hour)
+		kept := createTestQuery(t, service, testUser, "loki")
+
+		mock.Add(23 * time.Hour)
+		deleted, err := service.DeleteOldQueries(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(0), deleted)
+
+		mock.Add(time.Minute)
+		deleted, err = service.DeleteOldQueries(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+
+		result, err := service.searchQueries(context.Background(), testUser, "", 1, 100)
+		require.NoError(t, err)
+		require.Equal(t, []string{kept.UID}, queryUIDs(result))
+	})
+
+	t.Run("only the newest queries of each user are kept", func(t *testing.T) {
+		service, _ := newTestService(t)
+		service.Cfg.QueryHistoryMaxEntriesPerUser = 2
+
+		var uids []string
+		for i := 0; i < 4; i++ {
+			uids = append(uids, createTestQuery(t, service, testUser, "loki").UID)
+		}
+		othersFirst := createTestQuery(t, service, otherUser, "loki")
+		othersSecond := createTestQuery(t, service, otherUser, "loki")
+
+		deleted, err := service.DeleteOldQueries(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		result, err := service.searchQueries(context.Background(), testUser, "", 1, 100)
+		require.NoError(t, err)
+		require.Equal(t, []string{uids[3], uids[2]}, queryUIDs(result))
+
+		result, err = service.searchQueries(context.Background(), otherUser, "", 1, 100)
+		require.NoError(t, err)
+		require.Equal(t, []string{othersSecond.UID, othersFirst.UID}, queryUIDs(result))
+	})
+
+	t.Run("query parameters are validated", func(t *testing.T) {
+		service, _ := newTestService(t)
+
+		for _, url := range []string{"/?page=0", "/?perPage=0", "/?perPage=1001"} {
+			resp := service.searchHandler(searchRequest(url))
+			require.Equal(t, 400, resp.Status(), url)
+		}
+
+		resp := service.searchHandler(searchRequest("/"))
+		require.Equal(t, 200, resp.Status())
+	})
+}
+
+var (
+	testUser  = &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+	otherUser = &models.SignedInUser{UserId: 2, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+)
+
+// newTestService returns a service with the queryHistory feature toggle enabled and an empty
+// query history.
+func newTestService(t *testing.T) (*QueryHistoryService, *clock.Mock) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.FeatureToggles = map[string]bool{"queryHistory": true}
+	mock := clock.NewMock()
+	service := &QueryHistoryService{Cfg: cfg, Clock: mock, log: log.New("query-history.test")}
+
+	registry.RegisterOverride(func(d registry.Descriptor) (*registry.Descriptor, bool) {
+		if _, ok := d.Instance.(*QueryHistoryService); !ok {
+			return nil, false
+		}
+		return &registry.Descriptor{Name: d.Name, Instance: service, InitPriority: d.InitPriority}, true
+	})
+	t.Cleanup(registry.ClearOverrides)
+
+	service.SQLStore = sqlstore.InitTestDB(t)
+	_, err := service.SQLStore.NewSession().Exec("DELETE FROM query_history")
+	require.NoError(t, err)
+
+	return service, mock
+}
+
+func createTestQuery(t *testing.T, service *QueryHistoryService, user *models.SignedInUser, datasourceUID string) QueryHistoryDTO {
+	t.Helper()
+
+	query, err := service.createQuery(context.Background(), user, CreateQueryCommand{
+		DatasourceUID: datasourceUID,
+		Queries:       simplejson.NewFromAny([]interface{}{map[string]interface{}{"refId": "expr"}}),
+	})
+	require.NoError(t, err)
+	return query
+}
+
+// searchRequest returns the context of a search of testUser.
+func searchRequest(url string) *models.ReqContext {
+	return &models.ReqContext{
+		Context:      &macaron.Context{Req: macaron.Request{Request: httptest.NewRequest("GET", url, nil)}},
+		SignedInUser: testUser,
+	}
+}
+
+func queryUIDs(result QueryHistorySearchResult) []string {
+	uids := make([]string, 0, len(result.QueryHistory))
+	for _, query := range result.QueryHistory {
+		uids = append(uids, query.UID)
+	}
+	return uids
+}
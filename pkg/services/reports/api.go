@@ -0,0 +1,127 @@
+package reports
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-macaron/binding"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *ReportService) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/reports", func(reports routing.RouteRegister) {
+		reports.Get("/", routing.Wrap(s.getReportsHandler))
+		reports.Post("/", binding.Bind(saveReportCommand{}), routing.Wrap(s.createHandler))
+		reports.Post("/email", binding.Bind(sendReportCommand{}), routing.Wrap(s.sendHandler))
+		reports.Get("/:id", routing.Wrap(s.getHandler))
+		reports.Put("/:id", binding.Bind(saveReportCommand{}), routing.Wrap(s.updateHandler))
+		reports.Delete("/:id", routing.Wrap(s.deleteHandler))
+	}, middleware.ReqEditorRole)
+}
+
+// getReportsHandler handles GET /api/reports.
+func (s *ReportService) getReportsHandler(c *models.ReqContext) response.Response {
+	reports, err := s.getReports(c)
+	if err != nil {
+		return toReportError(err, "Failed to get reports")
+	}
+
+	return response.JSON(200, util.DynMap{"result": reports})
+}
+
+// createHandler handles POST /api/reports.
+func (s *ReportService) createHandler(c *models.ReqContext, cmd saveReportCommand) response.Response {
+	report, err := s.createReport(c, cmd)
+	if err != nil {
+		return toReportError(err, "Failed to create report")
+	}
+
+	return response.JSON(200, util.DynMap{"result": report})
+}
+
+// getHandler handles GET /api/reports/:id.
+func (s *ReportService) getHandler(c *models.ReqContext) response.Response {
+	report, err := s.getReportByID(c, c.ParamsInt64(":id"))
+	if err != nil {
+		return toReportError(err, "Failed to get report")
+	}
+	if err := s.requireDashboardAccess(c, report.DashboardUID); err != nil {
+		return toReportError(err, "Failed to get report")
+	}
+
+	return response.JSON(200, util.DynMap{"result": toReportDTO(report)})
+}
+
+// updateHandler handles PUT /api/reports/:id.
+func (s *ReportService) updateHandler(c *models.ReqContext, cmd saveReportCommand) response.Response {
+	report, err := s.updateReport(c, c.ParamsInt64(":id"), cmd)
+	if err != nil {
+		return toReportError(err, "Failed to update report")
+	}
+
+	return response.JSON(200, util.DynMap{"result": report})
+}
+
+// deleteHandler handles DELETE /api/reports/:id.
+func (s *ReportService) deleteHandler(c *models.ReqContext) response.Response {
+	if err := s.deleteReport(c, c.ParamsInt64(":id")); err != nil {
+		return toReportError(err, "Failed to delete report")
+	}
+
+	return response.Success("Report deleted")
+}
+
+// sendHandler handles POST /api/reports/email, which sends a report right away without changing
+// when it's sent next.
+func (s *ReportService) sendHandler(c *models.ReqContext, cmd sendReportCommand) response.Response {
+	id, err := strconv.ParseInt(cmd.ID, 10, 64)
+	if err != nil {
+		return response.Error(400, "id must be the id of a report", err)
+	}
+
+	report, err := s.getReportByID(c, id)
+	if err != nil {
+		return toReportError(err, "Failed to send report")
+	}
+	if err := s.requireDashboardAccess(c, report.DashboardUID); err != nil {
+		return toReportError(err, "Failed to send report")
+	}
+
+	recipients := splitEmails(cmd.Emails)
+	if cmd.UseEmailsFromReport {
+		recipients = append(recipients, splitEmails(report.Recipients)...)
+	}
+	// Reports sent on demand are rendered as the user sending them, who chooses their recipients.
+	if err := s.sendReportAs(c.Req.Context(), report, c.SignedInUser, recipients); err != nil {
+		return toReportError(err, "Failed to send report")
+	}
+
+	return response.Success("Report was sent")
+}
+
+func toReportError(err error, message string) response.Response {
+	if errors.Is(err, errInvalidReport) || errors.Is(err, errNoRecipients) {
+		return response.Error(400, err.Error(), err)
+	}
+	if errors.Is(err, errReportNotFound) {
+		return response.Error(404, errReportNotFound.Error(), err)
+	}
+	if errors.Is(err, errDashboardNotFound) {
+		return response.Error(404, errDashboardNotFound.Error(), err)
+	}
+	if errors.Is(err, errDashboardAccessDenied) {
+		return response.Error(403, errDashboardAccessDenied.Error(), err)
+	}
+	if errors.Is(err, errRendererNotAvailable) {
+		return response.Error(503, errRendererNotAvailable.Error(), err)
+	}
+	if errors.Is(err, models.ErrSmtpNotEnabled) {
+		return response.Error(412, models.ErrSmtpNotEnabled.Error(), err)
+	}
+	return response.Error(500, message, err)
+}
@@ -0,0 +1,280 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// applySaveCommand validates a command for creating or updating a report, and applies it to r.
+func (s *ReportService) applySaveCommand(c *models.ReqContext, cmd saveReportCommand, r *report) error {
+	cmd.Name = strings.TrimSpace(cmd.Name)
+	if cmd.Name == "" {
+		return fmt.Errorf("%w: name is required", errInvalidReport)
+	}
+
+	recipients := splitEmails(cmd.Recipients)
+	if len(recipients) == 0 {
+		return fmt.Errorf("%w: at least one recipient is required", errInvalidReport)
+	}
+	replyTo := splitEmails(cmd.ReplyTo)
+	for _, email := range append(recipients, replyTo...) {
+		if _, err := mail.ParseAddress(email); err != nil {
+			return fmt.Errorf("%w: invalid email address %q", errInvalidReport, email)
+		}
+	}
+
+	switch cmd.Frequency {
+	case FrequencyOnce, FrequencyHourly, FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyNever:
+	case "":
+		cmd.Frequency = FrequencyWeekly
+	default:
+		return fmt.Errorf("%w: unknown frequency %q", errInvalidReport, cmd.Frequency)
+	}
+
+	switch cmd.Orientation {
+	case OrientationPortrait, OrientationLandscape:
+	case "":
+		cmd.Orientation = OrientationPortrait
+	default:
+		return fmt.Errorf("%w: unknown orientation %q", errInvalidReport, cmd.Orientation)
+	}
+
+	if (cmd.TimeFrom == "") != (cmd.TimeTo == "") {
+		return fmt.Errorf("%w: both or none of timeFrom and timeTo are required", errInvalidReport)
+	}
+
+	if err := s.requireDashboardAccess(c, cmd.DashboardUID); err != nil {
+		return err
+	}
+
+	startDate := s.Clock.Now()
+	if cmd.StartDate != nil {
+		startDate = *cmd.StartDate
+	}
+
+	r.Name = cmd.Name
+	r.DashboardUID = cmd.DashboardUID
+	r.Recipients = strings.Join(recipients, ",")
+	r.ReplyTo = strings.Join(replyTo, ",")
+	r.Message = cmd.Message
+	r.Frequency = cmd.Frequency
+	r.StartDate = startDate
+	r.TimeFrom = cmd.TimeFrom
+	r.TimeTo = cmd.TimeTo
+	r.Orientation = cmd.Orientation
+	r.NextSendAt = nextSendAt(r, s.Clock.Now())
+	return nil
+}
+
+// requireDashboardAccess returns an error unless the user can view a dashboard.
+func (s *ReportService) requireDashboardAccess(c *models.ReqContext, uid string) error {
+	if uid == "" {
+		return fmt.Errorf("%w: dashboardUid is required", errInvalidReport)
+	}
+
+	query := models.GetDashboardQuery{Uid: uid, OrgId: c.SignedInUser.OrgId}
+	if err := s.Bus.Dispatch(&query); err != nil {
+		if err == models.ErrDashboardNotFound {
+			return errDashboardNotFound
+		}
+		return err
+	}
+	if query.Result.IsFolder {
+		return errDashboardNotFound
+	}
+
+	g := guardian.New(query.Result.Id, c.SignedInUser.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil {
+		return err
+	} else if !canView {
+		return errDashboardAccessDenied
+	}
+	return nil
+}
+
+// createReport creates a report in the user's organization.
+func (s *ReportService) createReport(c *models.ReqContext, cmd saveReportCommand) (ReportDTO, error) {
+	now := s.Clock.Now()
+	r := report{
+		OrgID:     c.SignedInUser.OrgId,
+		Created:   now,
+		Updated:   now,
+		CreatedBy: c.SignedInUser.UserId,
+		UpdatedBy: c.SignedInUser.UserId,
+	}
+	if err := s.applySaveCommand(c, cmd, &r); err != nil {
+		return ReportDTO{}, err
+	}
+
+	err := s.SQLStore.WithTransactionalDbSession(c.Req.Context(), func(session *sqlstore.DBSession) error {
+		_, err := session.Insert(&r)
+		return err
+	})
+	if err != nil {
+		return ReportDTO{}, err
+	}
+
+	return toReportDTO(&r), nil
+}
+
+// updateReport replaces the definition of a report in the user's organization.
+func (s *ReportService) updateReport(c *models.ReqContext, id int64, cmd saveReportCommand) (ReportDTO, error) {
+	r, err := s.getReportByID(c, id)
+	if err != nil {
+		return ReportDTO{}, err
+	}
+	if err := s.requireDashboardAccess(c, r.DashboardUID); err != nil {
+		return ReportDTO{}, err
+	}
+
+	if err := s.applySaveCommand(c, cmd, r); err != nil {
+		return ReportDTO{}, err
+	}
+	r.Updated = s.Clock.Now()
+	r.UpdatedBy = c.SignedInUser.UserId
+	// A changed report is sent with the permissions of the user who changed it.
+	r.CreatedBy = c.SignedInUser.UserId
+	r.LastError = ""
+
+	err = s.SQLStore.WithTransactionalDbSession(c.Req.Context(), func(session *sqlstore.DBSession) error {
+		_, err := session.ID(r.ID).AllCols().Update(r)
+		return err
+	})
+	if err != nil {
+		return ReportDTO{}, err
+	}
+
+	return toReportDTO(r), nil
+}
+
+// deleteReport deletes a report in the user's organization.
+func (s *ReportService) deleteReport(c *models.ReqContext, id int64) error {
+	return s.SQLStore.WithTransactionalDbSession(c.Req.Context(), func(session *sqlstore.DBSession) error {
+		result, err := session.Exec("DELETE FROM report WHERE id = ? AND org_id = ?", id, c.SignedInUser.OrgId)
+		if err != nil {
+			return err
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rowsAffected != 1 {
+			return errReportNotFound
+		}
+		return nil
+	})
+}
+
+// getReportByID gets a report in the user's organization.
+func (s *ReportService) getReportByID(c *models.ReqContext, id int64) (*report, error) {
+	var r *report
+	err := s.SQLStore.WithDbSession(c.Req.Context(), func(session *sqlstore.DBSession) error {
+		var err error
+		r, err = getReport(session, c.SignedInUser.OrgId, id)
+		return err
+	})
+	return r, err
+}
+
+// getReports gets the reports in the user's organization of the dashboards the user can view.
+// Reports of deleted dashboards are included, so they can be deleted.
+func (s *ReportService) getReports(c *models.ReqContext) ([]ReportDTO, error) {
+	var reports []report
+	err := s.SQLStore.WithDbSession(c.Req.Context(), func(session *sqlstore.DBSession) error {
+		return session.Where("org_id = ?", c.SignedInUser.OrgId).Asc("name").Find(&reports)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ReportDTO, 0, len(reports))
+	for i := range reports {
+		if err := s.requireDashboardAccess(c, reports[i].DashboardUID); err != nil {
+			if errors.Is(err, errDashboardAccessDenied) {
+				continue
+			}
+			if !errors.Is(err, errDashboardNotFound) {
+				return nil, err
+			}
+		}
+		result = append(result, toReportDTO(&reports[i]))
+	}
+	return result, nil
+}
+
+// getDueReports gets the reports of all organizations that are due to be sent at now.
+func (s *ReportService) getDueReports(ctx context.Context, now time.Time) ([]*report, error) {
+	var reports []*report
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		return session.Where("next_send_at > 0 AND next_send_at <= ?", now.Unix()).Asc("next_send_at").Find(&reports)
+	})
+	return reports, err
+}
+
+// saveSendResult saves the outcome of sending a scheduled report at now, and when it's sent next.
+func (s *ReportService) saveSendResult(ctx context.Context, r *report, now time.Time, sendErr error) error {
+	if sendErr == nil {
+		r.LastSentAt = now.Unix()
+		r.LastError = ""
+	} else {
+		r.LastError = sendErr.Error()
+	}
+	r.NextSendAt = nextSendAt(r, now)
+	// Reports to send once aren't retried every minute when they fail, the error is shown instead.
+	if sendErr != nil && r.Frequency == FrequencyOnce {
+		r.NextSendAt = 0
+	}
+
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		_, err := session.ID(r.ID).Cols("next_send_at", "last_sent_at", "last_error").Update(r)
+		return err
+	})
+}
+
+func getReport(session *sqlstore.DBSession, orgID int64, id int64) (*report, error) {
+	var r report
+	found, err := session.Where("id = ? AND org_id = ?", id, orgID).Get(&r)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errReportNotFound
+	}
+	return &r, nil
+}
+
+func toReportDTO(r *report) ReportDTO {
+	dto := ReportDTO{
+		ID:           r.ID,
+		OrgID:        r.OrgID,
+		Name:         r.Name,
+		DashboardUID: r.DashboardUID,
+		Recipients:   r.Recipients,
+		ReplyTo:      r.ReplyTo,
+		Message:      r.Message,
+		Frequency:    r.Frequency,
+		StartDate:    r.StartDate,
+		TimeFrom:     r.TimeFrom,
+		TimeTo:       r.TimeTo,
+		Orientation:  r.Orientation,
+		LastError:    r.LastError,
+		Created:      r.Created,
+		Updated:      r.Updated,
+		CreatedBy:    r.CreatedBy,
+	}
+	if r.NextSendAt != 0 {
+		next := time.Unix(r.NextSendAt, 0)
+		dto.NextSendAt = &next
+	}
+	if r.LastSentAt != 0 {
+		last := time.Unix(r.LastSentAt, 0)
+		dto.LastSentAt = &last
+	}
+	return dto
+}
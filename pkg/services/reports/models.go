@@ -0,0 +1,111 @@
+package reports
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// errReportNotFound is an error for an unknown report.
+	errReportNotFound = errors.New("report not found")
+	// errInvalidReport is an error for a report definition that can't be saved.
+	errInvalidReport = errors.New("invalid report")
+	// errDashboardNotFound is an error for an unknown dashboard.
+	errDashboardNotFound = errors.New("dashboard not found")
+	// errDashboardAccessDenied is an error for a dashboard the user can't view.
+	errDashboardAccessDenied = errors.New("access denied to dashboard")
+	// errRendererNotAvailable is an error for reports sent without an image renderer to render them.
+	errRendererNotAvailable = errors.New("image renderer is not available, install the image renderer plugin to send reports")
+	// errNoRecipients is an error for reports sent to nobody.
+	errNoRecipients = errors.New("report has no recipients")
+)
+
+// Frequency is how often a report is sent.
+type Frequency string
+
+const (
+	FrequencyOnce    Frequency = "once"
+	FrequencyHourly  Frequency = "hourly"
+	FrequencyDaily   Frequency = "daily"
+	FrequencyWeekly  Frequency = "weekly"
+	FrequencyMonthly Frequency = "monthly"
+	// FrequencyNever is for reports that are only sent on demand.
+	FrequencyNever Frequency = "never"
+)
+
+// Orientation is the page orientation of the PDF of a report.
+type Orientation string
+
+const (
+	OrientationPortrait  Orientation = "portrait"
+	OrientationLandscape Orientation = "landscape"
+)
+
+// report is the model for a report definition.
+type report struct {
+	ID           int64 `xorm:"pk autoincr 'id'"`
+	OrgID        int64 `xorm:"org_id"`
+	Name         string
+	DashboardUID string `xorm:"dashboard_uid"`
+	Recipients   string
+	ReplyTo      string
+	Message      string
+	Frequency    Frequency
+	StartDate    time.Time
+	TimeFrom     string
+	TimeTo       string
+	Orientation  Orientation
+	// NextSendAt is when the report is sent next in unix seconds, or 0 if it isn't scheduled.
+	NextSendAt int64
+	// LastSentAt is when the report was last sent in unix seconds, or 0 if it never was.
+	LastSentAt int64
+	LastError  string
+
+	Created   time.Time
+	Updated   time.Time
+	CreatedBy int64
+	UpdatedBy int64
+}
+
+// ReportDTO is the frontend DTO for a report definition.
+type ReportDTO struct {
+	ID           int64       `json:"id"`
+	OrgID        int64       `json:"orgId"`
+	Name         string      `json:"name"`
+	DashboardUID string      `json:"dashboardUid"`
+	Recipients   string      `json:"recipients"`
+	ReplyTo      string      `json:"replyTo"`
+	Message      string      `json:"message"`
+	Frequency    Frequency   `json:"frequency"`
+	StartDate    time.Time   `json:"startDate"`
+	TimeFrom     string      `json:"timeFrom"`
+	TimeTo       string      `json:"timeTo"`
+	Orientation  Orientation `json:"orientation"`
+	NextSendAt   *time.Time  `json:"nextSendAt,omitempty"`
+	LastSentAt   *time.Time  `json:"lastSentAt,omitempty"`
+	LastError    string      `json:"lastError,omitempty"`
+	Created      time.Time   `json:"created"`
+	Updated      time.Time   `json:"updated"`
+	CreatedBy    int64       `json:"createdBy"`
+}
+
+// saveReportCommand is the command for creating or updating a report.
+type saveReportCommand struct {
+	Name         string      `json:"name"`
+	DashboardUID string      `json:"dashboardUid"`
+	Recipients   string      `json:"recipients"`
+	ReplyTo      string      `json:"replyTo"`
+	Message      string      `json:"message"`
+	Frequency    Frequency   `json:"frequency"`
+	StartDate    *time.Time  `json:"startDate"`
+	TimeFrom     string      `json:"timeFrom"`
+	TimeTo       string      `json:"timeTo"`
+	Orientation  Orientation `json:"orientation"`
+}
+
+// sendReportCommand is the command for sending a report right away.
+type sendReportCommand struct {
+	ID                  string `json:"id"`
+	Emails              string `json:"emails"`
+	UseEmailsFromReport bool   `json:"useEmailsFromReport"`
+}
@@ -0,0 +1,88 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	// pdfMargin is the margin of the pages of a report in millimeters.
+	pdfMargin = 10.0
+	// pdfPanelSpacing is the space between panels in millimeters.
+	pdfPanelSpacing = 5.0
+)
+
+// pdfHeader is the text at the top of the first page of a report.
+type pdfHeader struct {
+	Title     string
+	Dashboard string
+	TimeRange string
+	Generated string
+}
+
+// buildPDF lays out the images of the panels of a dashboard on A4 pages below a header, as many
+// on a page as fit, and returns the PDF document.
+func buildPDF(header pdfHeader, orientation Orientation, images []string) ([]byte, error) {
+	orientationStr := "P"
+	if orientation == OrientationLandscape {
+		orientationStr = "L"
+	}
+
+	pdf := gofpdf.New(orientationStr, "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	// The core fonts only support cp1252, which titles have to be translated to.
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s - page %d", tr(header.Title), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	contentWidth := pageWidth - 2*pdfMargin
+	// Leave room for the footer at the bottom of every page.
+	contentBottom := pageHeight - 2*pdfMargin
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.MultiCell(contentWidth, 8, tr(header.Title), "", "L", false)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(80, 80, 80)
+	pdf.MultiCell(contentWidth, 5, tr(header.Dashboard), "", "L", false)
+	pdf.MultiCell(contentWidth, 5, tr(header.TimeRange), "", "L", false)
+	pdf.MultiCell(contentWidth, 5, tr(header.Generated), "", "L", false)
+	pdf.Ln(pdfPanelSpacing)
+
+	for _, image := range images {
+		options := gofpdf.ImageOptions{ImageType: "PNG"}
+		info := pdf.RegisterImageOptions(image, options)
+		if pdf.Err() {
+			return nil, pdf.Error()
+		}
+
+		width := contentWidth
+		height := width * info.Height() / info.Width()
+		// Panels taller than a page are shrunk to fit on one.
+		if maxHeight := contentBottom - pdfMargin; height > maxHeight {
+			width = width * maxHeight / height
+			height = maxHeight
+		}
+		if pdf.GetY()+height > contentBottom {
+			pdf.AddPage()
+		}
+
+		pdf.ImageOptions(image, pdfMargin+(contentWidth-width)/2, pdf.GetY(), width, height, false, options, 0, "")
+		pdf.SetY(pdf.GetY() + height + pdfPanelSpacing)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
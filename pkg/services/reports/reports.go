@@ -0,0 +1,150 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// scheduleInterval is how often reports that are due are sent.
+const scheduleInterval = time.Minute
+
+// ReportService stores report definitions, and sends PDFs of the panels of their dashboards to their
+// recipients on a schedule or on demand.
+type ReportService struct {
+	Cfg               *setting.Cfg                  `inject:""`
+	Bus               bus.Bus                       `inject:""`
+	SQLStore          *sqlstore.SQLStore            `inject:""`
+	RouteRegister     routing.RouteRegister         `inject:""`
+	RenderService     rendering.Service             `inject:""`
+	ServerLockService *serverlock.ServerLockService `inject:""`
+	Clock             clock.Clock                   `inject:""`
+	log               log.Logger
+}
+
+func init() {
+	registry.RegisterService(&ReportService{})
+}
+
+// Init initializes the report service.
+func (s *ReportService) Init() error {
+	s.log = log.New("reports")
+	if s.Clock == nil {
+		s.Clock = clock.New()
+	}
+
+	if !s.IsDisabled() {
+		s.registerAPIEndpoints()
+	}
+
+	return nil
+}
+
+// IsDisabled returns true if the reporting feature is disabled for this instance.
+func (s *ReportService) IsDisabled() bool {
+	if s.Cfg == nil {
+		return true
+	}
+
+	return !s.Cfg.IsReportingEnabled()
+}
+
+// AddMigration defines database migrations.
+// If reporting is not enabled does nothing.
+func (s *ReportService) AddMigration(mg *migrator.Migrator) {
+	if s.IsDisabled() {
+		return
+	}
+
+	reportV1 := migrator.Table{
+		Name: "report",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "dashboard_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "recipients", Type: migrator.DB_Text, Nullable: false},
+			{Name: "reply_to", Type: migrator.DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "message", Type: migrator.DB_Text, Nullable: true},
+			{Name: "frequency", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "start_date", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "time_from", Type: migrator.DB_NVarchar, Length: 100, Nullable: true},
+			{Name: "time_to", Type: migrator.DB_NVarchar, Length: 100, Nullable: true},
+			{Name: "orientation", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "next_send_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "last_sent_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "last_error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "updated_by", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id"}},
+			{Cols: []string{"next_send_at"}},
+		},
+	}
+
+	mg.AddMigration("create report table v1", migrator.NewAddTableMigration(reportV1))
+	mg.AddMigration("add index report org_id", migrator.NewAddIndexMigration(reportV1, reportV1.Indices[0]))
+	mg.AddMigration("add index report next_send_at", migrator.NewAddIndexMigration(reportV1, reportV1.Indices[1]))
+}
+
+// Run sends the reports that are due until ctx is done.
+func (s *ReportService) Run(ctx context.Context) error {
+	ticker := s.Clock.Ticker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Only one server of a high availability setup sends the reports that are due.
+			err := s.ServerLockService.LockAndExecute(ctx, "send scheduled reports", scheduleInterval, func() {
+				s.sendDueReports(ctx)
+			})
+			if err != nil {
+				s.log.Error("Failed to lock sending scheduled reports", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sendDueReports sends the reports that are due and schedules them again.
+func (s *ReportService) sendDueReports(ctx context.Context) {
+	now := s.Clock.Now()
+	due, err := s.getDueReports(ctx, now)
+	if err != nil {
+		s.log.Error("Failed to get the reports that are due", "error", err)
+		return
+	}
+
+	for _, r := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := s.sendReport(ctx, r, splitEmails(r.Recipients))
+		if err != nil {
+			s.log.Error("Failed to send scheduled report", "id", r.ID, "orgId", r.OrgID, "error", err)
+		} else {
+			s.log.Info("Sent scheduled report", "id", r.ID, "orgId", r.OrgID)
+		}
+
+		if err := s.saveSendResult(ctx, r, s.Clock.Now(), err); err != nil {
+			s.log.Error("Failed to schedule report", "id", r.ID, "orgId", r.OrgID, "error", err)
+		}
+	}
+}
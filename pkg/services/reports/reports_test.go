@@ -0,0 +1,381 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestReports(t *testing.T) {
+	t.Run("reports are created, listed, updated and deleted", func(t *testing.T) {
+		service, _, mock := newTestReportService(t)
+		creator := createTestUser(t, service, 0, "creator", models.ROLE_ADMIN)
+		dash := createTestDashboard(t, service, creator.OrgId, "dashboard")
+
+		created, err := service.createReport(creator, saveReportCommand{
+			Name:         "Weekly",
+			DashboardUID: dash.Uid,
+			Recipients:   "a@example.com; b@example.com",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "a@example.com,b@example.com", created.Recipients)
+		require.Equal(t, FrequencyWeekly, created.Frequency)
+		require.Equal(t, OrientationPortrait, created.Orientation)
+		require.Equal(t, mock.Now().Add(7*24*time.Hour).Unix(), created.NextSendAt.Unix())
+
+		reports, err := service.getReports(creator)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		require.Equal(t, created.ID, reports[0].ID)
+
+		updated, err := service.updateReport(creator, created.ID, saveReportCommand{
+			Name:         "Daily",
+			DashboardUID: dash.Uid,
+			Recipients:   "a@example.com",
+			Frequency:    FrequencyDaily,
+			Orientation:  OrientationLandscape,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Daily", updated.Name)
+		require.Equal(t, mock.Now().Add(24*time.Hour).Unix(), updated.NextSendAt.Unix())
+
+		report, err := service.getReportByID(creator, created.ID)
+		require.NoError(t, err)
+		require.Equal(t, OrientationLandscape, report.Orientation)
+
+		require.NoError(t, service.deleteReport(creator, created.ID))
+		_, err = service.getReportByID(creator, created.ID)
+		require.Equal(t, errReportNotFound, err)
+		require.Equal(t, errReportNotFound, service.deleteReport(creator, created.ID))
+	})
+
+	t.Run("invalid reports are rejected", func(t *testing.T) {
+		service, _, _ := newTestReportService(t)
+		creator := createTestUser(t, service, 0, "creator", models.ROLE_ADMIN)
+		dash := createTestDashboard(t, service, creator.OrgId, "dashboard")
+
+		for name, cmd := range map[string]saveReportCommand{
+			"no name":         {DashboardUID: dash.Uid, Recipients: "a@example.com"},
+			"no recipients":   {Name: "report", DashboardUID: dash.Uid, Recipients: " , "},
+			"invalid email":   {Name: "report", DashboardUID: dash.Uid, Recipients: "not an email"},
+			"frequency":       {Name: "report", DashboardUID: dash.Uid, Recipients: "a@example.com", Frequency: "yearly"},
+			"orientation":     {Name: "report", DashboardUID: dash.Uid, Recipients: "a@example.com", Orientation: "upside-down"},
+			"half time range": {Name: "report", DashboardUID: dash.Uid, Recipients: "a@example.com", TimeFrom: "now-1d"},
+			"no dashboard":    {Name: "report", Recipients: "a@example.com"},
+		} {
+			resp := service.createHandler(creator, cmd)
+			require.Equal(t, 400, resp.Status(), name)
+		}
+
+		resp := service.createHandler(creator, saveReportCommand{Name: "report", DashboardUID: "unknown", Recipients: "a@example.com"})
+		require.Equal(t, 404, resp.Status())
+	})
+
+	t.Run("due reports are sent as PDFs and scheduled again", func(t *testing.T) {
+		service, renderer, mock := newTestReportService(t)
+		creator := createTestUser(t, service, 0, "creator", models.ROLE_ADMIN)
+		dash := createTestDashboard(t, service, creator.OrgId, "dashboard")
+		emails := captureEmails(t)
+
+		start := mock.Now().Add(time.Hour)
+		created, err := service.createReport(creator, saveReportCommand{
+			Name:         "Daily report",
+			DashboardUID: dash.Uid,
+			Recipients:   "a@example.com",
+			Frequency:    FrequencyDaily,
+			StartDate:    &start,
+			TimeFrom:     "now-1d",
+			TimeTo:       "now",
+		})
+		require.NoError(t, err)
+
+		service.sendDueReports(context.Background())
+		require.Empty(t, *emails)
+
+		mock.Add(time.Hour)
+		service.sendDueReports(context.Background())
+		require.Len(t, *emails, 1)
+		email := (*emails)[0]
+		require.Equal(t, []string{"a@example.com"}, email.To)
+		require.Equal(t, "Daily report", email.Subject)
+		require.Len(t, email.AttachedFiles, 1)
+		require.Equal(t, "daily-report-2021-03-10.pdf", email.AttachedFiles[0].Name)
+		require.True(t, bytes.HasPrefix(email.AttachedFiles[0].Content, []byte("%PDF")))
+
+		// The panels of collapsed rows are rendered too, but rows aren't.
+		require.Len(t, renderer.rendered, 3)
+		require.Equal(t, fmt.Sprintf("d-solo/%s/dashboard?from=now-1d&orgId=%d&panelId=1&to=now", dash.Uid, dash.OrgId), renderer.rendered[0].Path)
+		require.Equal(t, 8*gridCellHeight+7*gridCellMargin, renderer.rendered[0].Height)
+		require.Equal(t, models.ROLE_ADMIN, renderer.rendered[0].OrgRole)
+
+		report, err := service.getReportByID(creator, created.ID)
+		require.NoError(t, err)
+		require.Equal(t, mock.Now().Unix(), report.LastSentAt)
+		require.Equal(t, start.AddDate(0, 0, 1).Unix(), report.NextSendAt)
+
+		service.sendDueReports(context.Background())
+		require.Len(t, *emails, 1)
+	})
+
+	t.Run("failures are saved and reports to send once aren't retried", func(t *testing.T) {
+		service, renderer, _ := newTestReportService(t)
+		creator := createTestUser(t, service, 0, "creator", models.ROLE_ADMIN)
+		dash := createTestDashboard(t, service, creator.OrgId, "dashboard")
+		emails := captureEmails(t)
+		renderer.available = false
+
+		created, err := service.createReport(creator, saveReportCommand{
+			Name:         "Once",
+			DashboardUID: dash.Uid,
+			Recipients:   "a@example.com",
+			Frequency:    FrequencyOnce,
+		})
+		require.NoError(t, err)
+
+		service.sendDueReports(context.Background())
+		require.Empty(t, *emails)
+
+		report, err := service.getReportByID(creator, created.ID)
+		require.NoError(t, err)
+		require.Equal(t, errRendererNotAvailable.Error(), report.LastError)
+		require.Equal(t, int64(0), report.NextSendAt)
+		require.Equal(t, int64(0), report.LastSentAt)
+	})
+
+	t.Run("reports of dashboards the user can't view are hidden and can't be sent", func(t *testing.T) {
+		service, renderer, _ := newTestReportService(t)
+		creator := createTestUser(t, service, 0, "creator", models.ROLE_ADMIN)
+		editor := createTestUser(t, service, creator.OrgId, "editor", models.ROLE_EDITOR)
+		emails := captureEmails(t)
+
+		open := createTestDashboard(t, service, creator.OrgId, "open")
+		restricted := createTestDashboard(t, service, creator.OrgId, "restricted")
+		require.NoError(t, service.SQLStore.UpdateDashboardACL(restricted.Id, nil))
+
+		openReport, err := service.createReport(creator, saveReportCommand{Name: "Open", DashboardUID: open.Uid, Recipients: "a@example.com"})
+		require.NoError(t, err)
+		restrictedReport, err := service.createReport(creator, saveReportCommand{Name: "Restricted", DashboardUID: restricted.Uid, Recipients: "a@example.com"})
+		require.NoError(t, err)
+
+		reports, err := service.getReports(editor)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		require.Equal(t, openReport.ID, reports[0].ID)
+
+		editor.ReplaceAllParams(map[string]string{":id": strconv.FormatInt(restrictedReport.ID, 10)})
+		require.Equal(t, 403, service.getHandler(editor).Status())
+		_, err = service.updateReport(editor, restrictedReport.ID, saveReportCommand{Name: "Mine", DashboardUID: open.Uid, Recipients: "b@example.com"})
+		require.Equal(t, errDashboardAccessDenied, err)
+
+		resp := service.sendHandler(editor, sendReportCommand{ID: strconv.FormatInt(restrictedReport.ID, 10), Emails: "b@example.com"})
+		require.Equal(t, 403, resp.Status())
+		require.Empty(t, *emails)
+		require.Empty(t, renderer.rendered)
+
+		// Reports sent on demand are rendered as the user sending them.
+		resp = service.sendHandler(editor, sendReportCommand{ID: strconv.FormatInt(openReport.ID, 10), Emails: "b@example.com"})
+		require.Equal(t, 200, resp.Status(), string(resp.Body()))
+		require.Len(t, *emails, 1)
+		require.Equal(t, editor.UserId, renderer.rendered[0].UserId)
+		require.Equal(t, models.ROLE_EDITOR, renderer.rendered[0].OrgRole)
+	})
+
+	t.Run("reports are sent on demand to other recipients", func(t *testing.T) {
+		service, _, _ := newTestReportService(t)
+		creator := createTestUser(t, service, 0, "creator", models.ROLE_ADMIN)
+		dash := createTestDashboard(t, service, creator.OrgId, "dashboard")
+		emails := captureEmails(t)
+
+		created, err := service.createReport(creator, saveReportCommand{
+			Name:         "On demand",
+			DashboardUID: dash.Uid,
+			Recipients:   "a@example.com",
+			Frequency:    FrequencyNever,
+		})
+		require.NoError(t, err)
+		require.Nil(t, created.NextSendAt)
+
+		resp := service.sendHandler(creator, sendReportCommand{ID: "1000", Emails: "b@example.com"})
+		require.Equal(t, 404, resp.Status())
+
+		resp = service.sendHandler(creator, sendReportCommand{ID: strconv.FormatInt(created.ID, 10), Emails: "b@example.com", UseEmailsFromReport: true})
+		require.Equal(t, 200, resp.Status(), string(resp.Body()))
+		require.Len(t, *emails, 1)
+		require.Equal(t, []string{"b@example.com", "a@example.com"}, (*emails)[0].To)
+
+		report, err := service.getReportByID(creator, created.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), report.LastSentAt)
+	})
+}
+
+func TestNextOccurrence(t *testing.T) {
+	start := time.Date(2021, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		frequency Frequency
+		after     time.Time
+		expected  time.Time
+	}{
+		{FrequencyHourly, start.Add(-time.Minute), start},
+		{FrequencyHourly, start, start.Add(time.Hour)},
+		{FrequencyHourly, start.Add(1000*time.Hour + time.Minute), start.Add(1001 * time.Hour)},
+		{FrequencyDaily, start.AddDate(0, 0, 400), start.AddDate(0, 0, 401)},
+		{FrequencyWeekly, start.AddDate(0, 0, 10), start.AddDate(0, 0, 14)},
+		{FrequencyMonthly, start, time.Date(2021, 2, 28, 9, 30, 0, 0, time.UTC)},
+		{FrequencyMonthly, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 31, 9, 30, 0, 0, time.UTC)},
+		{FrequencyMonthly, time.Date(2031, 4, 30, 12, 0, 0, 0, time.UTC), time.Date(2031, 5, 31, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.expected, nextOccurrence(start, tc.frequency, tc.after), "%s after %s", tc.frequency, tc.after)
+	}
+}
+
+type fakeRenderer struct {
+	available bool
+	image     string
+	rendered  []rendering.Opts
+}
+
+func (r *fakeRenderer) IsAvailable() bool {
+	return r.available
+}
+
+func (r *fakeRenderer) Render(ctx context.Context, opts rendering.Opts) (*rendering.RenderResult, error) {
+	r.rendered = append(r.rendered, opts)
+	return &rendering.RenderResult{FilePath: r.image}, nil
+}
+
+func (r *fakeRenderer) RenderErrorImage(err error) (*rendering.RenderResult, error) {
+	return &rendering.RenderResult{FilePath: r.image}, nil
+}
+
+func (r *fakeRenderer) GetRenderUser(key string) (*rendering.RenderUser, bool) {
+	return nil, false
+}
+
+// newTestReportService returns a service with the reporting feature toggle enabled and no reports,
+// whose panels are rendered by a fake renderer and whose clock is on 2021-03-10.
+func newTestReportService(t *testing.T) (*ReportService, *fakeRenderer, *clock.Mock) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.FeatureToggles = map[string]bool{"reporting": true}
+	renderer := &fakeRenderer{available: true, image: writeTestImage(t)}
+	mock := clock.NewMock()
+	mock.Set(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+	service := &ReportService{
+		Cfg:           cfg,
+		Bus:           bus.GetBus(),
+		RenderService: renderer,
+		Clock:         mock,
+		log:           log.New("reports.test"),
+	}
+
+	registry.RegisterOverride(func(d registry.Descriptor) (*registry.Descriptor, bool) {
+		if _, ok := d.Instance.(*ReportService); !ok {
+			return nil, false
+		}
+		return &registry.Descriptor{Name: d.Name, Instance: service, InitPriority: d.InitPriority}, true
+	})
+	t.Cleanup(registry.ClearOverrides)
+
+	service.SQLStore = sqlstore.InitTestDB(t)
+	_, err := service.SQLStore.NewSession().Exec("DELETE FROM report")
+	require.NoError(t, err)
+
+	return service, renderer, mock
+}
+
+// createTestUser creates a user with a role in an organization, or in a new one if orgID is 0, and
+// returns the context of its requests.
+func createTestUser(t *testing.T, service *ReportService, orgID int64, login string, role models.RoleType) *models.ReqContext {
+	t.Helper()
+
+	user, err := service.SQLStore.CreateUser(context.Background(), models.CreateUserCommand{
+		Login:          login,
+		Email:          login + "@example.com",
+		DefaultOrgRole: string(role),
+	})
+	require.NoError(t, err)
+
+	if orgID == 0 {
+		orgID = user.OrgId
+	} else {
+		err := service.Bus.Dispatch(&models.AddOrgUserCommand{OrgId: orgID, UserId: user.Id, Role: role})
+		require.NoError(t, err)
+	}
+
+	return &models.ReqContext{
+		Context:      &macaron.Context{Req: macaron.Request{Request: httptest.NewRequest("GET", "/", nil)}},
+		SignedInUser: &models.SignedInUser{UserId: user.Id, OrgId: orgID, OrgRole: role},
+	}
+}
+
+// createTestDashboard creates a dashboard with a panel, a row and a collapsed row with two panels.
+func createTestDashboard(t *testing.T, service *ReportService, orgID int64, title string) *models.Dashboard {
+	t.Helper()
+
+	dash, err := service.SQLStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId: orgID,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": title,
+			"panels": []interface{}{
+				map[string]interface{}{"id": 1, "type": "graph", "gridPos": map[string]interface{}{"h": 8, "w": 12}},
+				map[string]interface{}{"id": 2, "type": "row", "collapsed": false, "panels": []interface{}{}},
+				map[string]interface{}{"id": 3, "type": "row", "collapsed": true, "panels": []interface{}{
+					map[string]interface{}{"id": 4, "type": "stat"},
+					map[string]interface{}{"id": 5, "type": "table", "gridPos": map[string]interface{}{"h": 20, "w": 24}},
+				}},
+			},
+		}),
+	})
+	require.NoError(t, err)
+	return dash
+}
+
+// captureEmails captures the emails sent instead of sending them.
+func captureEmails(t *testing.T) *[]*models.SendEmailCommandSync {
+	t.Helper()
+
+	emails := []*models.SendEmailCommandSync{}
+	bus.AddHandlerCtx("test", func(ctx context.Context, cmd *models.SendEmailCommandSync) error {
+		emails = append(emails, cmd)
+		return nil
+	})
+	return &emails
+}
+
+// writeTestImage writes a PNG the size of a rendered panel.
+func writeTestImage(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "panel.png")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, file.Close()) }()
+
+	require.NoError(t, png.Encode(file, image.NewRGBA(image.Rect(0, 0, panelWidth, 300))))
+	return path
+}
@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"time"
+)
+
+// nextSendAt returns when a report is sent next in unix seconds, after it was last sent or changed
+// at now, or 0 if it isn't sent on a schedule anymore.
+func nextSendAt(r *report, now time.Time) int64 {
+	switch r.Frequency {
+	case FrequencyOnce:
+		if r.LastSentAt != 0 {
+			return 0
+		}
+		// A report to send once with a start date in the past is sent right away.
+		return r.StartDate.Unix()
+	case FrequencyHourly, FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return nextOccurrence(r.StartDate, r.Frequency, now).Unix()
+	default:
+		return 0
+	}
+}
+
+// nextOccurrence returns the first time after after that a schedule starting at start with a
+// frequency occurs.
+func nextOccurrence(start time.Time, frequency Frequency, after time.Time) time.Time {
+	if start.After(after) {
+		return start
+	}
+
+	// Skip most occurrences with a period that is never longer than the actual one, so the number
+	// of occurrences is never overestimated, then step to the first occurrence after after.
+	period := time.Hour
+	switch frequency {
+	case FrequencyDaily:
+		period = 25 * time.Hour
+	case FrequencyWeekly:
+		period = 7*24*time.Hour + time.Hour
+	case FrequencyMonthly:
+		period = 31*24*time.Hour + time.Hour
+	}
+
+	n := int(after.Sub(start) / period)
+	for {
+		if t := occurrence(start, frequency, n); t.After(after) {
+			return t
+		}
+		n++
+	}
+}
+
+// occurrence returns the nth occurrence of a schedule starting at start with a frequency.
+func occurrence(start time.Time, frequency Frequency, n int) time.Time {
+	switch frequency {
+	case FrequencyDaily:
+		return start.AddDate(0, 0, n)
+	case FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case FrequencyMonthly:
+		return addMonths(start, n)
+	default:
+		return start.Add(time.Duration(n) * time.Hour)
+	}
+}
+
+// addMonths adds months to t, on the last day of the month if the day of t is past it.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,181 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+const (
+	// renderTimeout is how long rendering a panel of a report may take.
+	renderTimeout = time.Minute
+	// panelWidth is the width in pixels panels are rendered with.
+	panelWidth = 1000
+	// Panels are rendered as high as they are on the dashboard, which positions them on a grid of
+	// rows of gridCellHeight pixels with gridCellMargin pixels between them.
+	gridCellHeight = 30
+	gridCellMargin = 8
+	// defaultPanelGridHeight is the height in grid rows of panels without a position.
+	defaultPanelGridHeight = 9
+)
+
+// reportPanel is a panel of a dashboard rendered in reports.
+type reportPanel struct {
+	id     int64
+	height int
+}
+
+// sendReport renders the panels of the dashboard of a report into a PDF as the user who created
+// the report, and emails it to recipients.
+func (s *ReportService) sendReport(ctx context.Context, r *report, recipients []string) error {
+	userQuery := models.GetSignedInUserQuery{OrgId: r.OrgID, UserId: r.CreatedBy}
+	if err := s.SQLStore.GetSignedInUserWithCache(&userQuery); err != nil {
+		return fmt.Errorf("failed to get the user who created the report: %w", err)
+	}
+	return s.sendReportAs(ctx, r, userQuery.Result, recipients)
+}
+
+// sendReportAs renders the panels of the dashboard of a report into a PDF as user, and emails it
+// to recipients.
+func (s *ReportService) sendReportAs(ctx context.Context, r *report, user *models.SignedInUser, recipients []string) error {
+	if len(recipients) == 0 {
+		return errNoRecipients
+	}
+	if !s.RenderService.IsAvailable() {
+		return errRendererNotAvailable
+	}
+
+	dashQuery := models.GetDashboardQuery{Uid: r.DashboardUID, OrgId: r.OrgID}
+	if err := s.Bus.Dispatch(&dashQuery); err != nil {
+		if err == models.ErrDashboardNotFound {
+			return errDashboardNotFound
+		}
+		return err
+	}
+	dash := dashQuery.Result
+
+	// Reports never show more than their user can see, so permissions are checked on every send.
+	if canView, err := guardian.New(dash.Id, r.OrgID, user).CanView(); err != nil {
+		return err
+	} else if !canView {
+		return errDashboardAccessDenied
+	}
+
+	from, to := r.TimeFrom, r.TimeTo
+	if from == "" || to == "" {
+		from = dash.Data.GetPath("time", "from").MustString("now-6h")
+		to = dash.Data.GetPath("time", "to").MustString("now")
+	}
+
+	images := []string{}
+	for _, panel := range reportPanels(dash.Data) {
+		result, err := s.RenderService.Render(ctx, rendering.Opts{
+			Width:           panelWidth,
+			Height:          panel.height,
+			Timeout:         renderTimeout,
+			OrgId:           r.OrgID,
+			UserId:          user.UserId,
+			OrgRole:         user.OrgRole,
+			Path:            panelPath(dash, panel.id, from, to),
+			Timezone:        dash.Data.Get("timezone").MustString(),
+			ConcurrentLimit: s.Cfg.RendererConcurrentRequestLimit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to render panel %d: %w", panel.id, err)
+		}
+		images = append(images, result.FilePath)
+	}
+
+	now := s.Clock.Now()
+	pdf, err := buildPDF(pdfHeader{
+		Title:     r.Name,
+		Dashboard: dash.Title,
+		TimeRange: fmt.Sprintf("Time range: %s to %s", from, to),
+		Generated: "Generated " + now.UTC().Format("2006-01-02 15:04 MST"),
+	}, r.Orientation, images)
+	if err != nil {
+		return fmt.Errorf("failed to build PDF: %w", err)
+	}
+
+	cmd := &models.SendEmailCommandSync{
+		SendEmailCommand: models.SendEmailCommand{
+			To:       recipients,
+			Template: "report.html",
+			Subject:  r.Name,
+			ReplyTo:  splitEmails(r.ReplyTo),
+			Data: map[string]interface{}{
+				"ReportName":     r.Name,
+				"Message":        r.Message,
+				"DashboardTitle": dash.Title,
+				"DashboardUrl":   models.GetFullDashboardUrl(dash.Uid, dash.Slug),
+			},
+			AttachedFiles: []*models.SendEmailAttachFile{
+				{Name: pdfFileName(r.Name, now), Content: pdf},
+			},
+		},
+	}
+	return s.Bus.DispatchCtx(ctx, cmd)
+}
+
+// reportPanels returns the panels of a dashboard in the order they are shown, including the
+// panels of collapsed rows but not the rows themselves.
+func reportPanels(data *simplejson.Json) []reportPanel {
+	panels := []reportPanel{}
+	for _, item := range data.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(item)
+		if panel.Get("type").MustString() == "row" {
+			for _, collapsed := range panel.Get("panels").MustArray() {
+				panels = appendReportPanel(panels, simplejson.NewFromAny(collapsed))
+			}
+			continue
+		}
+		panels = appendReportPanel(panels, panel)
+	}
+	return panels
+}
+
+func appendReportPanel(panels []reportPanel, panel *simplejson.Json) []reportPanel {
+	id := panel.Get("id").MustInt64()
+	if id == 0 {
+		return panels
+	}
+
+	rows := panel.GetPath("gridPos", "h").MustInt(defaultPanelGridHeight)
+	if rows <= 0 {
+		rows = defaultPanelGridHeight
+	}
+	return append(panels, reportPanel{id: id, height: rows*gridCellHeight + (rows-1)*gridCellMargin})
+}
+
+// panelPath returns the path the renderer renders a panel of a dashboard over a time range from.
+func panelPath(dash *models.Dashboard, panelID int64, from, to string) string {
+	params := url.Values{}
+	params.Set("orgId", fmt.Sprint(dash.OrgId))
+	params.Set("panelId", fmt.Sprint(panelID))
+	params.Set("from", from)
+	params.Set("to", to)
+	return fmt.Sprintf("d-solo/%s/%s?%s", dash.Uid, dash.Slug, params.Encode())
+}
+
+// pdfFileName returns the name of the PDF of a report generated at a time.
+func pdfFileName(name string, generated time.Time) string {
+	return fmt.Sprintf("%s-%s.pdf", models.SlugifyTitle(name), generated.UTC().Format("2006-01-02"))
+}
+
+// splitEmails splits a list of email addresses separated by commas or semicolons.
+func splitEmails(emails string) []string {
+	result := []string{}
+	for _, email := range strings.FieldsFunc(emails, func(r rune) bool { return r == ',' || r == ';' }) {
+		if email = strings.TrimSpace(email); email != "" {
+			result = append(result, email)
+		}
+	}
+	return result
+}
@@ -1,6 +1,10 @@
 package search
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
 
 type HitType string
 
@@ -26,6 +30,8 @@ type Hit struct {
 	FolderURL    string   `json:"folderUrl,omitempty"`
 	SortMeta     int64    `json:"sortMeta"`
 	SortMetaName string   `json:"sortMetaName,omitempty"`
+
+	Usage *models.DashboardUsage `json:"usage,omitempty"`
 }
 
 type HitList []*Hit
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"sort"
 
 	"github.com/grafana/grafana/pkg/setting"
@@ -98,6 +99,12 @@ func (s *SearchService) searchHandler(query *Query) error {
 		return err
 	}
 
+	if s.Cfg != nil && s.Cfg.IsDashboardInsightsEnabled() {
+		if err := setDashboardsUsage(query.SignedInUser.OrgId, hits); err != nil {
+			return err
+		}
+	}
+
 	query.Result = hits
 
 	return nil
@@ -116,6 +123,38 @@ func sortedHits(unsorted HitList) HitList {
 	return hits
 }
 
+// usageDays is the number of days over which the usage of dashboards in search results is counted.
+const usageDays = 30
+
+func setDashboardsUsage(orgID int64, hits []*Hit) error {
+	query := models.GetDashboardsUsageQuery{OrgId: orgID, Days: usageDays}
+	for _, hit := range hits {
+		if hit.Type == DashHitDB {
+			query.DashboardIds = append(query.DashboardIds, hit.ID)
+		}
+	}
+	if len(query.DashboardIds) == 0 {
+		return nil
+	}
+
+	if err := bus.DispatchCtx(context.Background(), &query); err != nil {
+		return err
+	}
+
+	for _, hit := range hits {
+		if hit.Type != DashHitDB {
+			continue
+		}
+		if usage, ok := query.Result[hit.ID]; ok {
+			hit.Usage = usage
+		} else {
+			hit.Usage = &models.DashboardUsage{}
+		}
+	}
+
+	return nil
+}
+
 func setStarredDashboards(userID int64, hits []*Hit) error {
 	query := models.GetUserStarsQuery{
 		UserId: userID,
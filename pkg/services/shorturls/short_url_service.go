@@ -79,9 +79,14 @@ func (s ShortURLService) CreateShortURL(ctx context.Context, user *models.Signed
 
 func (s ShortURLService) DeleteStaleShortURLs(ctx context.Context, cmd *models.DeleteShortUrlCommand) error {
 	return s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
-		var rawSql = "DELETE FROM short_url WHERE created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0)"
+		var rawSql = "DELETE FROM short_url WHERE (created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0))"
+		params := []interface{}{cmd.OlderThan.Unix()}
+		if !cmd.LastSeenBefore.IsZero() {
+			rawSql += " OR (last_seen_at > 0 AND last_seen_at <= ?)"
+			params = append(params, cmd.LastSeenBefore.Unix())
+		}
 
-		if result, err := session.Exec(rawSql, cmd.OlderThan.Unix()); err != nil {
+		if result, err := session.Exec(append([]interface{}{rawSql}, params...)...); err != nil {
 			return err
 		} else if cmd.NumDeleted, err = result.RowsAffected(); err != nil {
 			return err
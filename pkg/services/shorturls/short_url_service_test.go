@@ -74,6 +74,29 @@ func TestShortURLService(t *testing.T) {
 		})
 	})
 
+	t.Run("Short URLs idle for too long can be deleted", func(t *testing.T) {
+		service := ShortURLService{SQLStore: sqlStore}
+
+		idleShortURL, err := service.CreateShortURL(context.Background(), user, "mock/path?idle=true")
+		require.NoError(t, err)
+		idleShortURL.LastSeenAt = time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+		_, err = sqlStore.NewSession().ID(idleShortURL.Id).Update(idleShortURL)
+		require.NoError(t, err)
+
+		activeShortURL, err := service.CreateShortURL(context.Background(), user, "mock/path?active=true")
+		require.NoError(t, err)
+		require.NoError(t, service.UpdateLastSeenAt(context.Background(), activeShortURL))
+
+		cmd := models.DeleteShortUrlCommand{LastSeenBefore: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)}
+		require.NoError(t, service.DeleteStaleShortURLs(context.Background(), &cmd))
+		require.Equal(t, int64(1), cmd.NumDeleted)
+
+		_, err = service.GetShortURLByUID(context.Background(), user, idleShortURL.Uid)
+		require.Equal(t, models.ErrShortURLNotFound, err)
+		_, err = service.GetShortURLByUID(context.Background(), user, activeShortURL.Uid)
+		require.NoError(t, err)
+	})
+
 	t.Run("User cannot look up nonexistent short URLs", func(t *testing.T) {
 		service := ShortURLService{SQLStore: sqlStore}
 
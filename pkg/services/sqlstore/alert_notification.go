@@ -11,7 +11,6 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/util"
 )
 
 func init() {
@@ -357,7 +356,7 @@ func CreateAlertNotificationCommand(cmd *models.CreateAlertNotificationCommand)
 
 func generateNewAlertNotificationUid(sess *DBSession, orgId int64) (string, error) {
 	for i := 0; i < 3; i++ {
-		uid := util.GenerateShortUID()
+		uid := uidGenerator.GenerateUID()
 		exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&models.AlertNotification{})
 		if err != nil {
 			return "", err
@@ -0,0 +1,286 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// The benchmark dataset is large enough for missing indexes and per row queries to show up,
+// while still seeding SQLite in a few seconds. Run with `make bench-go`.
+const (
+	benchFolders             = 50
+	benchDashboardsPerFolder = 40
+	benchUsers               = 500
+	benchTeams               = 50
+	benchTeamsPerUser        = 3
+	benchAnnotations         = 20000
+	benchInsertBatchSize     = 500
+)
+
+type benchmarkData struct {
+	orgID      int64
+	users      []*models.User
+	folders    []*models.Dashboard
+	dashboards []*models.Dashboard
+	from, to   time.Time
+}
+
+func BenchmarkSearchDashboards(b *testing.B) {
+	data := seedBenchmarkData(b)
+	user := data.signedInUser(b, 0)
+
+	b.Run("as viewer", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			query := &search.FindPersistedDashboardsQuery{
+				OrgId:        data.orgID,
+				SignedInUser: user,
+				Limit:        1000,
+				Permission:   models.PERMISSION_VIEW,
+			}
+			require.NoError(b, SearchDashboards(query))
+		}
+	})
+
+	b.Run("as viewer by tag", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			query := &search.FindPersistedDashboardsQuery{
+				OrgId:        data.orgID,
+				SignedInUser: user,
+				Tags:         []string{"tag-1"},
+				Limit:        1000,
+				Permission:   models.PERMISSION_VIEW,
+			}
+			require.NoError(b, SearchDashboards(query))
+		}
+	})
+
+	b.Run("by title", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			query := &search.FindPersistedDashboardsQuery{
+				OrgId:        data.orgID,
+				SignedInUser: user,
+				Title:        "dashboard 1",
+				Limit:        1000,
+				Permission:   models.PERMISSION_VIEW,
+			}
+			require.NoError(b, SearchDashboards(query))
+		}
+	})
+}
+
+func BenchmarkGetDashboardAclInfoList(b *testing.B) {
+	data := seedBenchmarkData(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dash := data.dashboards[i%len(data.dashboards)]
+		query := &models.GetDashboardAclInfoListQuery{OrgID: data.orgID, DashboardID: dash.Id}
+		require.NoError(b, GetDashboardAclInfoList(query))
+	}
+}
+
+func BenchmarkGetSignedInUser(b *testing.B) {
+	data := seedBenchmarkData(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		user := data.users[i%len(data.users)]
+		query := &models.GetSignedInUserQuery{OrgId: data.orgID, UserId: user.Id}
+		require.NoError(b, GetSignedInUser(query))
+	}
+}
+
+func BenchmarkFindAnnotations(b *testing.B) {
+	data := seedBenchmarkData(b)
+	repo := SQLAnnotationRepo{}
+
+	b.Run("org time range", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := repo.Find(&annotations.ItemQuery{
+				OrgId: data.orgID,
+				From:  data.from.UnixNano() / int64(time.Millisecond),
+				To:    data.to.UnixNano() / int64(time.Millisecond),
+				Limit: 100,
+			})
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("dashboard time range", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := repo.Find(&annotations.ItemQuery{
+				OrgId:       data.orgID,
+				DashboardId: data.dashboards[i%len(data.dashboards)].Id,
+				From:        data.from.UnixNano() / int64(time.Millisecond),
+				To:          data.to.UnixNano() / int64(time.Millisecond),
+				Limit:       100,
+			})
+			require.NoError(b, err)
+		}
+	})
+}
+
+func (d *benchmarkData) signedInUser(b *testing.B, index int) *models.SignedInUser {
+	b.Helper()
+
+	query := &models.GetSignedInUserQuery{OrgId: d.orgID, UserId: d.users[index].Id}
+	require.NoError(b, GetSignedInUser(query))
+	return query.Result
+}
+
+// seedBenchmarkData seeds an org with viewers in teams, and folders of dashboards with team and
+// user permissions and annotations.
+func seedBenchmarkData(b *testing.B) *benchmarkData {
+	b.Helper()
+	InitTestDB(b)
+
+	now := time.Now()
+	data := &benchmarkData{from: now.Add(-24 * time.Hour), to: now}
+
+	org := &models.Org{Name: "bench", Created: now, Updated: now}
+	_, err := x.Insert(org)
+	require.NoError(b, err)
+	data.orgID = org.Id
+
+	var beans []interface{}
+	for i := 0; i < benchUsers; i++ {
+		user := &models.User{
+			Login:   fmt.Sprintf("user%d", i),
+			Email:   fmt.Sprintf("user%d@example.com", i),
+			OrgId:   org.Id,
+			Created: now,
+			Updated: now,
+		}
+		data.users = append(data.users, user)
+		beans = append(beans, user)
+	}
+	insertBenchmarkBeans(b, beans)
+
+	beans = nil
+	teams := make([]*models.Team, 0, benchTeams)
+	for i := 0; i < benchTeams; i++ {
+		team := &models.Team{Name: fmt.Sprintf("team%d", i), OrgId: org.Id, Created: now, Updated: now}
+		teams = append(teams, team)
+		beans = append(beans, team)
+	}
+	insertBenchmarkBeans(b, beans)
+
+	beans = nil
+	for i, user := range data.users {
+		beans = append(beans, &models.OrgUser{OrgId: org.Id, UserId: user.Id, Role: models.ROLE_VIEWER, Created: now, Updated: now})
+		for j := 0; j < benchTeamsPerUser; j++ {
+			team := teams[(i+j)%len(teams)]
+			beans = append(beans, &models.TeamMember{OrgId: org.Id, TeamId: team.Id, UserId: user.Id, Created: now, Updated: now})
+		}
+	}
+	insertBenchmarkBeans(b, beans)
+
+	beans = nil
+	for i := 0; i < benchFolders; i++ {
+		folder := models.NewDashboardFolder(fmt.Sprintf("folder %d", i))
+		folder.OrgId = org.Id
+		folder.SetUid(util.GenerateShortUID())
+		data.folders = append(data.folders, folder)
+		beans = append(beans, folder)
+	}
+	insertBenchmarkBeans(b, beans)
+
+	beans = nil
+	for i, folder := range data.folders {
+		for j := 0; j < benchDashboardsPerFolder; j++ {
+			dash := models.NewDashboard(fmt.Sprintf("dashboard %d-%d", i, j))
+			dash.OrgId = org.Id
+			dash.FolderId = folder.Id
+			dash.SetUid(util.GenerateShortUID())
+			data.dashboards = append(data.dashboards, dash)
+			beans = append(beans, dash)
+		}
+	}
+	insertBenchmarkBeans(b, beans)
+
+	beans = nil
+	for i, dash := range data.dashboards {
+		beans = append(beans,
+			&DashboardTag{DashboardId: dash.Id, Term: fmt.Sprintf("tag-%d", i%10)},
+			&DashboardTag{DashboardId: dash.Id, Term: "bench"},
+		)
+	}
+	for i, folder := range data.folders {
+		beans = append(beans,
+			&models.DashboardAcl{
+				OrgID:       org.Id,
+				DashboardID: folder.Id,
+				TeamID:      teams[i%len(teams)].Id,
+				Permission:  models.PERMISSION_VIEW,
+				Created:     now,
+				Updated:     now,
+			},
+			&models.DashboardAcl{
+				OrgID:       org.Id,
+				DashboardID: folder.Id,
+				UserID:      data.users[i%len(data.users)].Id,
+				Permission:  models.PERMISSION_EDIT,
+				Created:     now,
+				Updated:     now,
+			},
+		)
+	}
+	insertBenchmarkBeans(b, beans)
+
+	items := make([]interface{}, 0, benchAnnotations)
+	span := data.to.Sub(data.from).Milliseconds()
+	from := data.from.UnixNano() / int64(time.Millisecond)
+	for i := 0; i < benchAnnotations; i++ {
+		epoch := from + int64(i)*span/benchAnnotations
+		items = append(items, &annotations.Item{
+			OrgId:       org.Id,
+			DashboardId: data.dashboards[i%len(data.dashboards)].Id,
+			PanelId:     int64(i % 8),
+			Text:        fmt.Sprintf("annotation %d", i),
+			Epoch:       epoch,
+			EpochEnd:    epoch,
+			Created:     epoch,
+			Updated:     epoch,
+		})
+	}
+	insertBenchmarkBeans(b, items, "annotation")
+
+	b.ResetTimer()
+	return data
+}
+
+// insertBenchmarkBeans inserts beans in batched transactions, which is a lot faster than one by one.
+func insertBenchmarkBeans(b *testing.B, beans []interface{}, table ...string) {
+	b.Helper()
+
+	for start := 0; start < len(beans); start += benchInsertBatchSize {
+		end := start + benchInsertBatchSize
+		if end > len(beans) {
+			end = len(beans)
+		}
+
+		err := inTransactionCtx(context.Background(), func(sess *DBSession) error {
+			for _, bean := range beans[start:end] {
+				s := sess.Session
+				if len(table) > 0 {
+					s = s.Table(table[0])
+				}
+				if _, err := s.Insert(bean); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(b, err)
+	}
+}
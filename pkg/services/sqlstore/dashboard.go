@@ -14,7 +14,6 @@ import (
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/search"
-	"github.com/grafana/grafana/pkg/util"
 )
 
 var shadowSearchCounter = prometheus.NewCounterVec(
@@ -42,8 +41,6 @@ func init() {
 	prometheus.MustRegister(shadowSearchCounter)
 }
 
-var generateNewUid func() string = util.GenerateShortUID
-
 func (ss *SQLStore) SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
 	err := ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
 		return saveDashboard(sess, &cmd)
@@ -168,7 +165,7 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 
 func generateNewDashboardUid(sess *DBSession, orgId int64) (string, error) {
 	for i := 0; i < 3; i++ {
-		uid := generateNewUid()
+		uid := uidGenerator.GenerateUID()
 
 		exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&models.Dashboard{})
 		if err != nil {
@@ -115,13 +115,13 @@ func TestDashboardDataAccess(t *testing.T) {
 
 			Convey("Should retry generation of uid once if it fails.", func() {
 				timesCalled := 0
-				generateNewUid = func() string {
+				uidGenerator = util.UIDGeneratorFunc(func() string {
 					timesCalled += 1
 					if timesCalled <= 2 {
 						return savedDash.Uid
 					}
 					return util.GenerateShortUID()
-				}
+				})
 				cmd := models.SaveDashboardCommand{
 					OrgId: 1,
 					Dashboard: simplejson.NewFromAny(map[string]interface{}{
@@ -132,7 +132,7 @@ func TestDashboardDataAccess(t *testing.T) {
 				_, err := sqlStore.SaveDashboard(cmd)
 				So(err, ShouldBeNil)
 
-				generateNewUid = util.GenerateShortUID
+				uidGenerator = util.DefaultUIDGenerator
 			})
 
 			Convey("Should be able to create dashboard", func() {
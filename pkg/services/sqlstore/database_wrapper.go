@@ -13,7 +13,6 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/lib/pq"
-	"github.com/mattn/go-sqlite3"
 	"github.com/prometheus/client_golang/prometheus"
 	"xorm.io/core"
 )
@@ -31,25 +30,53 @@ func init() {
 	}, []string{"status"})
 
 	prometheus.MustRegister(databaseQueryHistogram)
+
+	// CockroachDB is reached with the Postgres driver, and xorm uses its Postgres dialect for it.
+	sql.Register(migrator.Cockroach, &queryTimeoutDriver{Driver: &pq.Driver{}})
+	core.RegisterDriver(migrator.Cockroach, &databaseQueryWrapperDriver{dbType: migrator.Postgres})
+
+	for _, dbType := range []string{migrator.MySQL, migrator.Postgres} {
+		sql.Register(dbType+queryTimeoutDriverSuffix, &queryTimeoutDriver{Driver: baseDrivers[dbType]})
+		core.RegisterDriver(dbType+queryTimeoutDriverSuffix, &databaseQueryWrapperDriver{dbType: dbType})
+	}
+}
+
+// queryTimeoutDriverSuffix is the suffix of the names of the MySQL and Postgres drivers applying
+// the query timeout of sessions, see queryTimeoutDriver. The SQLite and CockroachDB drivers
+// registered by Grafana apply it under their own names.
+const queryTimeoutDriverSuffix = "WithQueryTimeout"
+
+// baseDrivers are the drivers of the supported databases, by database type.
+var baseDrivers = map[string]driver.Driver{
+	migrator.SQLite:    &sqliteDriver{},
+	migrator.MySQL:     &mysql.MySQLDriver{},
+	migrator.Postgres:  &pq.Driver{},
+	migrator.Cockroach: &pq.Driver{},
+}
+
+// driverName returns the name of the driver to open databases of a type with.
+func driverName(dbType string) string {
+	switch dbType {
+	case migrator.SQLite:
+		// sets the cache size, which go-sqlite3 doesn't
+		return sqliteDriverName
+	case migrator.MySQL, migrator.Postgres:
+		return dbType + queryTimeoutDriverSuffix
+	}
+	return dbType
 }
 
 // WrapDatabaseDriverWithHooks creates a fake database driver that
 // executes pre and post functions which we use to gather metrics about
 // database queries. It also registers the metrics.
 func WrapDatabaseDriverWithHooks(dbType string) string {
-	drivers := map[string]driver.Driver{
-		migrator.SQLite:   &sqlite3.SQLiteDriver{},
-		migrator.MySQL:    &mysql.MySQLDriver{},
-		migrator.Postgres: &pq.Driver{},
-	}
-
-	d, exist := drivers[dbType]
+	d, exist := baseDrivers[dbType]
 	if !exist {
 		return dbType
 	}
 
 	driverWithHooks := dbType + "WithHooks"
-	sql.Register(driverWithHooks, sqlhooks.Wrap(d, &databaseQueryWrapper{log: log.New("sqlstore.metrics")}))
+	sql.Register(driverWithHooks, sqlhooks.Wrap(&queryTimeoutDriver{Driver: d}, &databaseQueryWrapper{log: log.New("sqlstore.metrics")}))
 	core.RegisterDriver(driverWithHooks, &databaseQueryWrapperDriver{dbType: dbType})
 	return driverWithHooks
 }
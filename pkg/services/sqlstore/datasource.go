@@ -286,7 +286,7 @@ func UpdateDataSource(cmd *models.UpdateDataSourceCommand) error {
 
 func generateNewDatasourceUid(sess *DBSession, orgId int64) (string, error) {
 	for i := 0; i < 3; i++ {
-		uid := generateNewUid()
+		uid := uidGenerator.GenerateUID()
 
 		exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&models.DataSource{})
 		if err != nil {
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -75,6 +76,12 @@ func TestDataAccess(t *testing.T) {
 			require.NotEmpty(t, ds.Uid)
 		})
 
+		t.Run("generates uid with the configured generator", func(t *testing.T) {
+			InitTestDB(t, InitTestDBOpt{UIDGenerator: util.NewSequentialUIDGenerator("ds")})
+			ds := initDatasource()
+			require.Equal(t, "ds-1", ds.Uid)
+		})
+
 		t.Run("fails to insert ds with same uid", func(t *testing.T) {
 			InitTestDB(t)
 			cmd1 := defaultAddDatasourceCommand
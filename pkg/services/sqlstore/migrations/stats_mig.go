@@ -35,6 +35,28 @@ import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 //	mg.AddMigration("create stat_value table", NewAddTableMigration(statValue))
 // }
 
+func addActiveUserStatsMigrations(mg *Migrator) {
+	activeUserStatsV1 := Table{
+		Name: "active_user_stats",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "day", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "daily_active_users", Type: DB_BigInt, Nullable: false},
+			{Name: "weekly_active_users", Type: DB_BigInt, Nullable: false},
+			{Name: "monthly_active_users", Type: DB_BigInt, Nullable: false},
+			{Name: "active_users", Type: DB_BigInt, Nullable: false},
+			{Name: "active_user_window", Type: DB_BigInt, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"day"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create active_user_stats table v1", NewAddTableMigration(activeUserStatsV1))
+	mg.AddMigration("add unique index active_user_stats.day", NewAddIndexMigration(activeUserStatsV1, activeUserStatsV1.Indices[0]))
+}
+
 func addTestDataMigrations(mg *Migrator) {
 	testData := Table{
 		Name: "test_data",
@@ -0,0 +1,122 @@
+package migrator
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"xorm.io/xorm"
+)
+
+// CockroachDialect is the dialect of CockroachDB, which speaks the Postgres wire protocol and most of
+// its SQL. The SERIAL columns of the tables are backed by sequences only if the sessions set
+// serial_normalization to sql_sequence, which the connection string built from the database
+// settings does, otherwise their values are too large for JavaScript numbers.
+type CockroachDialect struct {
+	PostgresDialect
+}
+
+func NewCockroachDialect(engine *xorm.Engine) Dialect {
+	d := CockroachDialect{}
+	d.BaseDialect.dialect = &d
+	d.BaseDialect.engine = engine
+	d.BaseDialect.driverName = Cockroach
+	return &d
+}
+
+// UpdateTableSQL doesn't change the tables, like for SQLite. The migrations updating tables
+// convert MySQL tables to utf8mb4 and shorten the columns MySQL indexes, and CockroachDB can't
+// shorten columns in the transactions of migrations, nor change the type of a column together with
+// other changes of its table like Postgres does.
+func (db *CockroachDialect) UpdateTableSQL(tableName string, columns []*Column) string {
+	return db.BaseDialect.UpdateTableSQL(tableName, columns)
+}
+
+// DropIndexSQL returns the statement dropping the index, qualified by its table as CockroachDB
+// requires for index names that aren't unique in the database.
+func (db *CockroachDialect) DropIndexSQL(tableName string, index *Index) string {
+	quote := db.Quote
+	idxName := index.XName(tableName)
+	return fmt.Sprintf("DROP INDEX %v@%v CASCADE", quote(tableName), quote(idxName))
+}
+
+// CleanDB drops the tables of the public schema one by one, as CockroachDB can't drop the public
+// schema.
+func (db *CockroachDialect) CleanDB() error {
+	sess := db.engine.NewSession()
+	defer sess.Close()
+
+	var tables []string
+	if err := sess.SQL("SELECT table_name FROM information_schema.tables WHERE table_schema = 'public' AND table_type = 'BASE TABLE'").
+		Find(&tables); err != nil {
+		return errutil.Wrap("failed to list tables", err)
+	}
+
+	for _, table := range tables {
+		if _, err := sess.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %v CASCADE;", db.Quote(table))); err != nil {
+			return errutil.Wrapf(err, "failed to drop table %q", table)
+		}
+	}
+
+	return nil
+}
+
+// TruncateDBTables truncates all the tables and restarts the sequences of their IDs, as CockroachDB
+// doesn't support TRUNCATE ... RESTART IDENTITY.
+// A special case is the dashboard_acl table where we keep the default permissions.
+func (db *CockroachDialect) TruncateDBTables() error {
+	sess := db.engine.NewSession()
+	defer sess.Close()
+
+	for _, table := range db.engine.Tables {
+		switch table.Name {
+		case "":
+			continue
+		case "migration_log":
+		case "dashboard_acl":
+			// keep default dashboard permissions
+			if _, err := sess.Exec(fmt.Sprintf("DELETE FROM %v WHERE dashboard_id != -1 AND org_id != -1;", db.Quote(table.Name))); err != nil {
+				return errutil.Wrapf(err, "failed to truncate table %q", table.Name)
+			}
+			if _, err := sess.Exec(fmt.Sprintf("SELECT setval('%v_id_seq', 3, false);", table.Name)); err != nil {
+				return errutil.Wrapf(err, "failed to reset table %q", table.Name)
+			}
+		default:
+			if _, err := sess.Exec(fmt.Sprintf("TRUNCATE TABLE %v CASCADE;", db.Quote(table.Name))); err != nil {
+				if db.isUndefinedTable(err) {
+					continue
+				}
+				return errutil.Wrapf(err, "failed to truncate table %q", table.Name)
+			}
+			if table.AutoIncrement == "" {
+				continue
+			}
+			if _, err := sess.Exec(fmt.Sprintf("SELECT setval('%v_%v_seq', 1, false);", table.Name, table.AutoIncrement)); err != nil {
+				return errutil.Wrapf(err, "failed to reset table %q", table.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsDeadlock returns false, as CockroachDB resolves deadlocks by aborting a transaction with a
+// serialization failure.
+func (db *CockroachDialect) IsDeadlock(err error) bool {
+	return false
+}
+
+// IsRetryableError returns whether err is a serialization failure, with which CockroachDB asks
+// clients to retry transactions that conflicted with others.
+func (db *CockroachDialect) IsRetryableError(err error) bool {
+	return db.isThisError(err, "40001")
+}
+
+// TryLockSQL returns an empty string, as CockroachDB has no advisory locks. The migrator locks
+// CockroachDB databases through a table instead.
+func (db *CockroachDialect) TryLockSQL(key string) (string, []interface{}) {
+	return "", nil
+}
+
+func (db *CockroachDialect) UnlockSQL(key string) (string, []interface{}) {
+	return "", nil
+}
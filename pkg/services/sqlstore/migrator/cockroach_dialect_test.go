@@ -0,0 +1,48 @@
+package migrator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCockroachDialect(t *testing.T) {
+	d := NewCockroachDialect(nil)
+
+	t.Run("retries serialization failures only", func(t *testing.T) {
+		require.True(t, d.IsRetryableError(fmt.Errorf("commit: %w", &pq.Error{Code: "40001"})))
+		require.False(t, d.IsRetryableError(&pq.Error{Code: "40P01"}))
+		require.False(t, d.IsRetryableError(&pq.Error{Code: "23505"}))
+		require.False(t, d.IsDeadlock(&pq.Error{Code: "40P01"}))
+		require.True(t, d.IsUniqueConstraintViolation(&pq.Error{Code: "23505"}))
+	})
+
+	t.Run("drops indexes of their table", func(t *testing.T) {
+		index := &Index{Cols: []string{"org_id"}}
+		require.Equal(t, `DROP INDEX "dashboard"@"IDX_dashboard_org_id" CASCADE`, d.DropIndexSQL("dashboard", index))
+	})
+
+	t.Run("doesn't update tables", func(t *testing.T) {
+		sql := d.UpdateTableSQL("dashboard", []*Column{{Name: "title", Type: DB_NVarchar, Length: 189, Nullable: false}})
+		require.Equal(t, "-- NOT REQUIRED", sql)
+	})
+
+	t.Run("has no advisory locks", func(t *testing.T) {
+		sql, _ := d.TryLockSQL("migrations")
+		require.Empty(t, sql)
+	})
+
+	t.Run("runs the Postgres SQL of raw migrations", func(t *testing.T) {
+		m := NewRawSQLMigration("").
+			Postgres("UPDATE t SET id = id::text").
+			Mysql("UPDATE t SET id = CAST(id AS CHAR)").
+			SetRollback(Postgres, "UPDATE t SET id = 0")
+		require.Equal(t, "UPDATE t SET id = id::text", m.SQL(d))
+		require.Equal(t, "UPDATE t SET id = 0", m.RollbackSQL(d))
+
+		m.Cockroach("UPDATE t SET id = id::STRING")
+		require.Equal(t, "UPDATE t SET id = id::STRING", m.SQL(d))
+	})
+}
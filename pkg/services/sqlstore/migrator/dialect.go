@@ -36,6 +36,11 @@ type Dialect interface {
 	ColumnCheckSQL(tableName, columnName string) (string, []interface{})
 	// UpsertSQL returns the upsert sql statement for a dialect
 	UpsertSQL(tableName string, keyCols, updateCols []string) string
+	// TryLockSQL returns the statement trying to acquire the lock named key for the connection,
+	// selecting whether it was acquired, or an empty string if the dialect has no locks.
+	TryLockSQL(key string) (string, []interface{})
+	// UnlockSQL returns the statement releasing the lock named key acquired with TryLockSQL.
+	UnlockSQL(key string) (string, []interface{})
 
 	ColString(*Column) string
 	ColStringNoPk(*Column) string
@@ -53,17 +58,25 @@ type Dialect interface {
 	IsUniqueConstraintViolation(err error) bool
 	ErrorMessage(err error) string
 	IsDeadlock(err error) bool
+	// IsRetryableError returns whether err is a deadlock, serialization or lock failure, after
+	// which the transaction can be retried.
+	IsRetryableError(err error) bool
 }
 
 type dialectFunc func(*xorm.Engine) Dialect
 
 var supportedDialects = map[string]dialectFunc{
-	MySQL:                  NewMysqlDialect,
-	SQLite:                 NewSQLite3Dialect,
-	Postgres:               NewPostgresDialect,
-	MySQL + "WithHooks":    NewMysqlDialect,
-	SQLite + "WithHooks":   NewSQLite3Dialect,
-	Postgres + "WithHooks": NewPostgresDialect,
+	MySQL:                         NewMysqlDialect,
+	SQLite:                        NewSQLite3Dialect,
+	Postgres:                      NewPostgresDialect,
+	Cockroach:                     NewCockroachDialect,
+	SQLite + "WithPragmas":        NewSQLite3Dialect,
+	MySQL + "WithQueryTimeout":    NewMysqlDialect,
+	Postgres + "WithQueryTimeout": NewPostgresDialect,
+	MySQL + "WithHooks":           NewMysqlDialect,
+	SQLite + "WithHooks":          NewSQLite3Dialect,
+	Postgres + "WithHooks":        NewPostgresDialect,
+	Cockroach + "WithHooks":       NewCockroachDialect,
 }
 
 func NewDialect(engine *xorm.Engine) Dialect {
@@ -288,3 +301,13 @@ func (b *BaseDialect) TruncateDBTables() error {
 func (b *BaseDialect) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	return ""
 }
+
+// TryLockSQL returns an empty string, as databases have no locks by default.
+func (b *BaseDialect) TryLockSQL(key string) (string, []interface{}) {
+	return "", nil
+}
+
+// UnlockSQL returns an empty string, as databases have no locks by default.
+func (b *BaseDialect) UnlockSQL(key string) (string, []interface{}) {
+	return "", nil
+}
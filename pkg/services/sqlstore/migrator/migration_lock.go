@@ -0,0 +1,132 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// migrationLockTable is the table the migration lock is held through on databases without locks,
+// like CockroachDB.
+const migrationLockTable = "migration_lock"
+
+// migrationLockTTL is how long the migration lock held through migrationLockTable is kept once its
+// instance stops refreshing it, for example because it crashed.
+var migrationLockTTL = time.Minute
+
+// migrationLock is a lock held by a connection of its own.
+type migrationLock interface {
+	// tryLock tries to acquire the lock without waiting and returns whether it was acquired.
+	tryLock(ctx context.Context, conn *sql.Conn) (bool, error)
+	// unlock releases the lock acquired with tryLock.
+	unlock(ctx context.Context, conn *sql.Conn) error
+}
+
+// advisoryLock is a lock of the database, see Dialect.TryLockSQL. The database releases it when
+// its connection is lost.
+type advisoryLock struct {
+	mg *Migrator
+	// lockSQL and lockArgs are the statement of the next attempt, if it was already built.
+	lockSQL  string
+	lockArgs []interface{}
+}
+
+func (l *advisoryLock) tryLock(ctx context.Context, conn *sql.Conn) (bool, error) {
+	lockSQL, lockArgs := l.lockSQL, l.lockArgs
+	if lockSQL == "" {
+		lockSQL, lockArgs = l.mg.Dialect.TryLockSQL(migrationLockKey)
+	}
+	l.lockSQL, l.lockArgs = "", nil
+
+	var locked bool
+	err := conn.QueryRowContext(ctx, l.mg.rawSQL(lockSQL), lockArgs...).Scan(&locked)
+	return locked, err
+}
+
+func (l *advisoryLock) unlock(ctx context.Context, conn *sql.Conn) error {
+	unlockSQL, unlockArgs := l.mg.Dialect.UnlockSQL(migrationLockKey)
+	_, err := conn.ExecContext(ctx, l.mg.rawSQL(unlockSQL), unlockArgs...)
+	return err
+}
+
+// tableLock is a lock held by inserting a row into migrationLockTable, for databases without
+// locks. The row expires after ttl, so a lock whose instance was lost is eventually taken over,
+// and it's refreshed while the lock is held.
+type tableLock struct {
+	mg     *Migrator
+	ttl    time.Duration
+	holder string
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func newTableLock(mg *Migrator, ttl time.Duration) *tableLock {
+	return &tableLock{mg: mg, ttl: ttl, holder: util.GenerateShortUID()}
+}
+
+func (l *tableLock) tryLock(ctx context.Context, conn *sql.Conn) (bool, error) {
+	table := &Table{
+		Name: migrationLockTable,
+		Columns: []*Column{
+			{Name: "lock_key", Type: DB_NVarchar, Length: 190, IsPrimaryKey: true},
+			{Name: "holder", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "expires", Type: DB_BigInt, Nullable: false},
+		},
+		PrimaryKeys: []string{"lock_key"},
+	}
+	if _, err := conn.ExecContext(ctx, l.mg.Dialect.CreateTableSQL(table)); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if _, err := conn.ExecContext(ctx, l.mg.rawSQL("DELETE FROM migration_lock WHERE lock_key = ? AND expires < ?"),
+		migrationLockKey, unixMillis(now)); err != nil {
+		return false, err
+	}
+	if _, err := conn.ExecContext(ctx, l.mg.rawSQL("INSERT INTO migration_lock (lock_key, holder, expires) VALUES (?, ?, ?)"),
+		migrationLockKey, l.holder, unixMillis(now.Add(l.ttl))); err != nil {
+		if l.mg.Dialect.IsUniqueConstraintViolation(err) || l.mg.Dialect.IsRetryableError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.refresh(ctx, conn)
+	return true, nil
+}
+
+// refresh extends the expiry of the lock until it's released.
+func (l *tableLock) refresh(ctx context.Context, conn *sql.Conn) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			if _, err := conn.ExecContext(ctx, l.mg.rawSQL("UPDATE migration_lock SET expires = ? WHERE lock_key = ? AND holder = ?"),
+				unixMillis(now.Add(l.ttl)), migrationLockKey, l.holder); err != nil {
+				l.mg.Logger.Error("Failed to refresh migration lock", "error", err)
+			}
+		}
+	}
+}
+
+func (l *tableLock) unlock(ctx context.Context, conn *sql.Conn) error {
+	close(l.stop)
+	<-l.done
+
+	_, err := conn.ExecContext(ctx, l.mg.rawSQL("DELETE FROM migration_lock WHERE lock_key = ? AND holder = ?"),
+		migrationLockKey, l.holder)
+	return err
+}
+
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
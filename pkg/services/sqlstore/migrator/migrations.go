@@ -24,7 +24,8 @@ func (m *MigrationBase) GetCondition() MigrationCondition {
 type RawSQLMigration struct {
 	MigrationBase
 
-	sql map[string]string
+	sql         map[string]string
+	rollbackSQL map[string]string
 }
 
 func NewRawSQLMigration(sql string) *RawSQLMigration {
@@ -36,17 +37,53 @@ func NewRawSQLMigration(sql string) *RawSQLMigration {
 }
 
 func (m *RawSQLMigration) SQL(dialect Dialect) string {
-	if m.sql != nil {
-		if val := m.sql[dialect.DriverName()]; val != "" {
-			return val
-		}
+	if val := dialectSQL(m.sql, dialect.DriverName()); val != "" {
+		return val
+	}
+
+	return dialect.NoOpSQL()
+}
+
+// RollbackSQL returns the SQL reverting the migration for the dialect, or an empty string if it
+// can't be rolled back.
+func (m *RawSQLMigration) RollbackSQL(dialect Dialect) string {
+	return dialectSQL(m.rollbackSQL, dialect.DriverName())
+}
+
+// compatibleDialects are the dialects whose SQL is used by the dialect of the key when a raw SQL
+// migration has none for it, as CockroachDB runs most of the SQL written for Postgres.
+var compatibleDialects = map[string]string{
+	Cockroach: Postgres,
+}
 
-		if val := m.sql["default"]; val != "" {
+// dialectSQL returns the SQL of sqls for the driver, or of the dialect it's compatible with, or the
+// default SQL.
+func dialectSQL(sqls map[string]string, driverName string) string {
+	if val := sqls[driverName]; val != "" {
+		return val
+	}
+	if compatible, ok := compatibleDialects[driverName]; ok {
+		if val := sqls[compatible]; val != "" {
 			return val
 		}
 	}
+	return sqls["default"]
+}
 
-	return dialect.NoOpSQL()
+// SetRollback sets the SQL reverting the migration for the dialect, "default" for every dialect
+// without its own.
+func (m *RawSQLMigration) SetRollback(dialect string, sql string) *RawSQLMigration {
+	if m.rollbackSQL == nil {
+		m.rollbackSQL = make(map[string]string)
+	}
+
+	m.rollbackSQL[dialect] = sql
+	return m
+}
+
+// RollbackDefault sets the SQL reverting the migration for every dialect without its own.
+func (m *RawSQLMigration) RollbackDefault(sql string) *RawSQLMigration {
+	return m.SetRollback("default", sql)
 }
 
 func (m *RawSQLMigration) Set(dialect string, sql string) *RawSQLMigration {
@@ -74,6 +111,11 @@ func (m *RawSQLMigration) Postgres(sql string) *RawSQLMigration {
 	return m.Set(Postgres, sql)
 }
 
+// Cockroach sets the SQL of the migration for CockroachDB, which otherwise runs the Postgres SQL.
+func (m *RawSQLMigration) Cockroach(sql string) *RawSQLMigration {
+	return m.Set(Cockroach, sql)
+}
+
 func (m *RawSQLMigration) Mssql(sql string) *RawSQLMigration {
 	return m.Set(MSSQL, sql)
 }
@@ -125,6 +167,11 @@ func (m *AddIndexMigration) SQL(dialect Dialect) string {
 	return dialect.CreateIndexSQL(m.tableName, m.index)
 }
 
+// RollbackSQL returns the SQL dropping the index.
+func (m *AddIndexMigration) RollbackSQL(dialect Dialect) string {
+	return dialect.DropIndexSQL(m.tableName, m.index)
+}
+
 type DropIndexMigration struct {
 	MigrationBase
 	tableName string
@@ -162,6 +209,11 @@ func (m *AddTableMigration) SQL(d Dialect) string {
 	return d.CreateTableSQL(&m.table)
 }
 
+// RollbackSQL returns the SQL dropping the table.
+func (m *AddTableMigration) RollbackSQL(d Dialect) string {
+	return d.DropTable(m.table.Name)
+}
+
 type DropTableMigration struct {
 	MigrationBase
 	tableName string
@@ -195,6 +247,11 @@ func (m *RenameTableMigration) SQL(d Dialect) string {
 	return d.RenameTable(m.oldName, m.newName)
 }
 
+// RollbackSQL returns the SQL renaming the table back.
+func (m *RenameTableMigration) RollbackSQL(d Dialect) string {
+	return d.RenameTable(m.newName, m.oldName)
+}
+
 type CopyTableDataMigration struct {
 	MigrationBase
 	sourceTable string
@@ -1,6 +1,10 @@
 package migrator
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -11,11 +15,32 @@ import (
 	"xorm.io/xorm"
 )
 
+// migrationLockKey is the name of the lock held while running migrations.
+const migrationLockKey = "grafana_migrations"
+
+// migrationLockRetryInterval is the time between the attempts to acquire the migration lock.
+var migrationLockRetryInterval = time.Second
+
+// ErrMigrationNotReversible is returned by Rollback when a migration to roll back doesn't declare
+// how to revert it.
+var ErrMigrationNotReversible = errors.New("migration can't be rolled back")
+
+// ErrMigrationLockTimeout is returned by Start when the migration lock wasn't acquired within the
+// lock timeout.
+var ErrMigrationLockTimeout = errors.New("timed out waiting for the migration lock")
+
 type Migrator struct {
 	x          *xorm.Engine
 	Dialect    Dialect
 	migrations []Migration
 	Logger     log.Logger
+
+	// LockMigrations makes Start hold a database lock while running migrations, so when several
+	// instances start against the same database, one runs the migrations and the others wait for it.
+	// SQLite has no locks, so it's ignored for SQLite databases.
+	LockMigrations bool
+	// LockTimeout is how long Start waits for the lock of another instance, 0 meaning forever.
+	LockTimeout time.Duration
 }
 
 type MigrationLog struct {
@@ -74,6 +99,14 @@ func (mg *Migrator) GetMigrationLog() (map[string]MigrationLog, error) {
 func (mg *Migrator) Start() error {
 	mg.Logger.Info("Starting DB migrations")
 
+	unlock, err := mg.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// The migration log is read after acquiring the lock, so the migrations run by the instance
+	// holding it before are skipped.
 	logMap, err := mg.GetMigrationLog()
 	if err != nil {
 		return err
@@ -127,6 +160,54 @@ func (mg *Migrator) Start() error {
 	return mg.x.Sync2()
 }
 
+// Rollback reverts the last n migrations run, newest first, and removes them from the migration
+// log, so a failed upgrade can be reverted to the schema of the previous version. It fails without
+// reverting any migration if one of them can't be rolled back. Each migration is reverted in a
+// transaction of its own, like when it was run.
+func (mg *Migrator) Rollback(n int) error {
+	unlock, err := mg.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return err
+	}
+
+	var rollbacks []Migration
+	for i := len(mg.migrations) - 1; i >= 0 && len(rollbacks) < n; i-- {
+		m := mg.migrations[i]
+		if _, exists := logMap[m.Id()]; !exists {
+			continue
+		}
+		if reversible, ok := m.(ReversibleMigration); !ok || reversible.RollbackSQL(mg.Dialect) == "" {
+			return fmt.Errorf("%w: %s", ErrMigrationNotReversible, m.Id())
+		}
+		rollbacks = append(rollbacks, m)
+	}
+
+	for _, m := range rollbacks {
+		sql := m.(ReversibleMigration).RollbackSQL(mg.Dialect)
+		mg.Logger.Info("Rolling back migration", "id", m.Id())
+		err := mg.inTransaction(func(sess *xorm.Session) error {
+			if _, err := sess.Exec(sql); err != nil {
+				mg.Logger.Error("Rolling back migration failed", "id", m.Id(), "error", err, "sql", sql)
+				return err
+			}
+			_, err := sess.Where("migration_id = ?", m.Id()).Delete(&MigrationLog{})
+			return err
+		})
+		if err != nil {
+			return errutil.Wrapf(err, "rolling back migration %s failed", m.Id())
+		}
+	}
+
+	mg.Logger.Info("Rolled back migrations", "count", len(rollbacks))
+	return nil
+}
+
 func (mg *Migrator) exec(m Migration, sess *xorm.Session) error {
 	mg.Logger.Info("Executing migration", "id", m.Id())
 
@@ -167,6 +248,70 @@ func (mg *Migrator) exec(m Migration, sess *xorm.Session) error {
 	return nil
 }
 
+// lock waits until it acquires the migration lock, if LockMigrations is set, and returns the
+// function releasing it. The lock is held by a connection of its own, opened outside of the
+// connection pool of the engine so the migrations can run while it's held even if the pool is
+// limited to a single connection. Databases without locks other than SQLite are locked through a
+// table, see tableLock.
+func (mg *Migrator) lock() (func(), error) {
+	if !mg.LockMigrations {
+		return func() {}, nil
+	}
+	var lock migrationLock
+	if lockSQL, lockArgs := mg.Dialect.TryLockSQL(migrationLockKey); lockSQL != "" {
+		lock = &advisoryLock{mg: mg, lockSQL: lockSQL, lockArgs: lockArgs}
+	} else if mg.Dialect.DriverName() != SQLite {
+		lock = newTableLock(mg, migrationLockTTL)
+	} else {
+		return func() {}, nil
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open(mg.x.DriverName(), mg.x.DataSourceName())
+	if err != nil {
+		return nil, errutil.Wrap("failed to acquire migration lock", err)
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		_ = db.Close()
+		return nil, errutil.Wrap("failed to acquire migration lock", err)
+	}
+	release := func() {
+		if err := conn.Close(); err != nil {
+			mg.Logger.Error("Failed to close migration lock connection", "error", err)
+		}
+		if err := db.Close(); err != nil {
+			mg.Logger.Error("Failed to close migration lock connection", "error", err)
+		}
+	}
+
+	start := time.Now()
+	for {
+		locked, err := lock.tryLock(ctx, conn)
+		if err != nil {
+			release()
+			return nil, errutil.Wrap("failed to acquire migration lock", err)
+		}
+		if locked {
+			break
+		}
+		if mg.LockTimeout > 0 && time.Since(start) >= mg.LockTimeout {
+			release()
+			return nil, ErrMigrationLockTimeout
+		}
+		mg.Logger.Info("Waiting for the migrations of another instance")
+		time.Sleep(migrationLockRetryInterval)
+	}
+	mg.Logger.Debug("Acquired migration lock", "waited", time.Since(start))
+
+	return func() {
+		if err := lock.unlock(ctx, conn); err != nil {
+			mg.Logger.Error("Failed to release migration lock", "error", err)
+		}
+		release()
+	}, nil
+}
+
 type dbTransactionFunc func(sess *xorm.Session) error
 
 func (mg *Migrator) inTransaction(callback dbTransactionFunc) error {
@@ -191,3 +336,13 @@ func (mg *Migrator) inTransaction(callback dbTransactionFunc) error {
 
 	return nil
 }
+
+// rawSQL returns query as xorm would run it, with the placeholders of the dialect, for running it
+// on a connection outside of the engine.
+func (mg *Migrator) rawSQL(query string) string {
+	dialect := mg.x.Dialect()
+	for _, filter := range dialect.Filters() {
+		query = filter.Do(query, dialect, nil)
+	}
+	return query
+}
@@ -0,0 +1,236 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func newTestMigrator(t *testing.T) *Migrator {
+	x, err := xorm.NewEngine(SQLite, filepath.Join(t.TempDir(), "grafana.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = x.Close() })
+	return NewMigrator(x)
+}
+
+func addMigrationLogMigration(mg *Migrator) {
+	mg.AddMigration("create migration_log table", NewAddTableMigration(Table{
+		Name: "migration_log",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "migration_id", Type: DB_NVarchar, Length: 255},
+			{Name: "sql", Type: DB_Text},
+			{Name: "success", Type: DB_Bool},
+			{Name: "error", Type: DB_Text},
+			{Name: "timestamp", Type: DB_DateTime},
+		},
+	}))
+}
+
+// lockingDialect is a SQLite dialect with a lock acquired after failedAttempts attempts.
+type lockingDialect struct {
+	Dialect
+	failedAttempts int
+	attempts       int
+	unlocks        int
+}
+
+func (d *lockingDialect) TryLockSQL(key string) (string, []interface{}) {
+	d.attempts++
+	return "SELECT ?", []interface{}{d.attempts > d.failedAttempts}
+}
+
+func (d *lockingDialect) UnlockSQL(key string) (string, []interface{}) {
+	d.unlocks++
+	return "SELECT 1", nil
+}
+
+func TestMigratorLock(t *testing.T) {
+	prevInterval := migrationLockRetryInterval
+	migrationLockRetryInterval = time.Millisecond
+	t.Cleanup(func() { migrationLockRetryInterval = prevInterval })
+
+	newMigrator := func(t *testing.T, d *lockingDialect) *Migrator {
+		mg := newTestMigrator(t)
+		d.Dialect = mg.Dialect
+		mg.Dialect = d
+		mg.LockMigrations = true
+		addMigrationLogMigration(mg)
+		mg.AddMigration("create table", NewAddTableMigration(Table{
+			Name:    "locked",
+			Columns: []*Column{{Name: "id", Type: DB_BigInt, IsPrimaryKey: true}},
+		}))
+		return mg
+	}
+
+	t.Run("migrations wait for the lock", func(t *testing.T) {
+		d := &lockingDialect{failedAttempts: 2}
+		mg := newMigrator(t, d)
+		require.NoError(t, mg.Start())
+		require.Equal(t, 3, d.attempts)
+		require.Equal(t, 1, d.unlocks)
+
+		logs, err := mg.GetMigrationLog()
+		require.NoError(t, err)
+		require.Contains(t, logs, "create table")
+	})
+
+	t.Run("waiting times out", func(t *testing.T) {
+		d := &lockingDialect{failedAttempts: 1000}
+		mg := newMigrator(t, d)
+		mg.LockTimeout = 5 * time.Millisecond
+		require.Equal(t, ErrMigrationLockTimeout, mg.Start())
+		require.Equal(t, 0, d.unlocks)
+	})
+
+	t.Run("migrations run while locked with a single connection", func(t *testing.T) {
+		d := &lockingDialect{}
+		mg := newMigrator(t, d)
+		mg.x.SetMaxOpenConns(1)
+
+		done := make(chan error, 1)
+		go func() { done <- mg.Start() }()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("migrations are blocked by the migration lock")
+		}
+		require.Equal(t, 1, d.unlocks)
+	})
+
+	t.Run("migrations aren't locked unless enabled", func(t *testing.T) {
+		d := &lockingDialect{failedAttempts: 1000}
+		mg := newMigrator(t, d)
+		mg.LockMigrations = false
+		require.NoError(t, mg.Start())
+	})
+
+	t.Run("lock statements use the placeholders of the dialect", func(t *testing.T) {
+		x, err := xorm.NewEngine(Postgres, "postgres://grafana@localhost/grafana?sslmode=disable")
+		require.NoError(t, err)
+		mg := NewMigrator(x)
+
+		lockSQL, _ := mg.Dialect.TryLockSQL(migrationLockKey)
+		require.Equal(t, "SELECT pg_try_advisory_lock($1)", mg.rawSQL(lockSQL))
+	})
+}
+
+func TestMigratorTableLock(t *testing.T) {
+	ctx := context.Background()
+	mg := newTestMigrator(t)
+	db, err := sql.Open(mg.x.DriverName(), mg.x.DataSourceName())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	newConn := func(t *testing.T) *sql.Conn {
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+
+	t.Run("is held by a single instance", func(t *testing.T) {
+		conn1, conn2 := newConn(t), newConn(t)
+		l1, l2 := newTableLock(mg, time.Minute), newTableLock(mg, time.Minute)
+
+		locked, err := l1.tryLock(ctx, conn1)
+		require.NoError(t, err)
+		require.True(t, locked)
+
+		locked, err = l2.tryLock(ctx, conn2)
+		require.NoError(t, err)
+		require.False(t, locked)
+
+		require.NoError(t, l1.unlock(ctx, conn1))
+		locked, err = l2.tryLock(ctx, conn2)
+		require.NoError(t, err)
+		require.True(t, locked)
+		require.NoError(t, l2.unlock(ctx, conn2))
+	})
+
+	t.Run("is refreshed while held and taken over once expired", func(t *testing.T) {
+		conn1, conn2 := newConn(t), newConn(t)
+		l1, l2 := newTableLock(mg, 60*time.Millisecond), newTableLock(mg, time.Minute)
+
+		locked, err := l1.tryLock(ctx, conn1)
+		require.NoError(t, err)
+		require.True(t, locked)
+
+		time.Sleep(150 * time.Millisecond)
+		locked, err = l2.tryLock(ctx, conn2)
+		require.NoError(t, err)
+		require.False(t, locked)
+
+		// The instance holding the lock is lost without releasing it.
+		close(l1.stop)
+		<-l1.done
+		time.Sleep(150 * time.Millisecond)
+		locked, err = l2.tryLock(ctx, conn2)
+		require.NoError(t, err)
+		require.True(t, locked)
+		require.NoError(t, l2.unlock(ctx, conn2))
+	})
+}
+
+func TestMigratorRollback(t *testing.T) {
+	table := Table{
+		Name:    "widget",
+		Columns: []*Column{{Name: "id", Type: DB_BigInt, IsPrimaryKey: true}, {Name: "name", Type: DB_NVarchar, Length: 40}},
+	}
+	newMigrator := func(mg *Migrator) *Migrator {
+		m := NewMigrator(mg.x)
+		addMigrationLogMigration(m)
+		m.AddMigration("create widget table", NewAddTableMigration(table))
+		m.AddMigration("add widget name index", NewAddIndexMigration(table, &Index{Cols: []string{"name"}}))
+		m.AddMigration("add default widget", NewRawSQLMigration("INSERT INTO widget (id, name) VALUES (1, 'default')").
+			RollbackDefault("DELETE FROM widget WHERE id = 1"))
+		return m
+	}
+	count := func(t *testing.T, mg *Migrator) int64 {
+		count, err := mg.x.Table("widget").Count()
+		require.NoError(t, err)
+		return count
+	}
+
+	mg := newMigrator(newTestMigrator(t))
+	require.NoError(t, mg.Start())
+	require.Equal(t, int64(1), count(t, mg))
+
+	t.Run("the last migrations are reverted", func(t *testing.T) {
+		require.NoError(t, mg.Rollback(2))
+		require.Equal(t, int64(0), count(t, mg))
+		exists, err := mg.x.IsTableExist("widget")
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		logs, err := mg.GetMigrationLog()
+		require.NoError(t, err)
+		require.Contains(t, logs, "create widget table")
+		require.NotContains(t, logs, "add widget name index")
+		require.NotContains(t, logs, "add default widget")
+
+		// The reverted migrations are run again by the next start.
+		require.NoError(t, newMigrator(mg).Start())
+		require.Equal(t, int64(1), count(t, mg))
+	})
+
+	t.Run("migrations without rollback aren't reverted", func(t *testing.T) {
+		mg := newMigrator(mg)
+		mg.AddMigration("rename widgets", NewRawSQLMigration("UPDATE widget SET name = 'renamed'"))
+		require.NoError(t, mg.Start())
+
+		err := mg.Rollback(2)
+		require.True(t, errors.Is(err, ErrMigrationNotReversible))
+		logs, err := mg.GetMigrationLog()
+		require.NoError(t, err)
+		require.Contains(t, logs, "add default widget")
+		require.Equal(t, int64(1), count(t, mg))
+	})
+}
@@ -200,6 +200,20 @@ func (db *MySQLDialect) IsDeadlock(err error) bool {
 	return db.isThisError(err, mysqlerr.ER_LOCK_DEADLOCK)
 }
 
+// IsRetryableError returns whether err is a deadlock.
+func (db *MySQLDialect) IsRetryableError(err error) bool {
+	return db.IsDeadlock(err)
+}
+
+// TryLockSQL returns the statement acquiring the named lock key without waiting.
+func (db *MySQLDialect) TryLockSQL(key string) (string, []interface{}) {
+	return "SELECT GET_LOCK(?, 0)", []interface{}{key}
+}
+
+func (db *MySQLDialect) UnlockSQL(key string) (string, []interface{}) {
+	return "SELECT RELEASE_LOCK(?)", []interface{}{key}
+}
+
 // UpsertSQL returns the upsert sql statement for PostgreSQL dialect
 func (db *MySQLDialect) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	columnsStr := strings.Builder{}
@@ -3,6 +3,7 @@ package migrator
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"strconv"
 	"strings"
 
@@ -204,6 +205,28 @@ func (db *PostgresDialect) IsDeadlock(err error) bool {
 	return db.isThisError(err, "40P01")
 }
 
+// IsRetryableError returns whether err is a deadlock or a serialization failure.
+func (db *PostgresDialect) IsRetryableError(err error) bool {
+	return db.IsDeadlock(err) || db.isThisError(err, "40001")
+}
+
+// TryLockSQL returns the statement acquiring the advisory lock of the hash of key without waiting.
+func (db *PostgresDialect) TryLockSQL(key string) (string, []interface{}) {
+	return "SELECT pg_try_advisory_lock(?)", []interface{}{advisoryLockID(key)}
+}
+
+func (db *PostgresDialect) UnlockSQL(key string) (string, []interface{}) {
+	return "SELECT pg_advisory_unlock(?)", []interface{}{advisoryLockID(key)}
+}
+
+// advisoryLockID returns the ID of the advisory lock named key, as Postgres identifies advisory
+// locks by integers.
+func advisoryLockID(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
 func (db *PostgresDialect) PostInsertId(table string, sess *xorm.Session) error {
 	if table != "org" {
 		return nil
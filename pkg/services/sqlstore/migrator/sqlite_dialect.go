@@ -141,14 +141,26 @@ func (db *SQLite3) ErrorMessage(err error) string {
 	return ""
 }
 
+// IsUniqueConstraintViolation returns whether err is caused by a duplicate value of a unique index
+// or of a primary key, which SQLite reports with different codes.
 func (db *SQLite3) IsUniqueConstraintViolation(err error) bool {
-	return db.isThisError(err, int(sqlite3.ErrConstraintUnique))
+	return db.isThisError(err, int(sqlite3.ErrConstraintUnique)) || db.isThisError(err, int(sqlite3.ErrConstraintPrimaryKey))
 }
 
 func (db *SQLite3) IsDeadlock(err error) bool {
 	return false // No deadlock
 }
 
+// IsRetryableError returns whether err is caused by the database being locked by another
+// connection.
+func (db *SQLite3) IsRetryableError(err error) bool {
+	var driverErr sqlite3.Error
+	if errors.As(err, &driverErr) {
+		return driverErr.Code == sqlite3.ErrBusy || driverErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
 // UpsertSQL returns the upsert sql statement for SQLite dialect
 func (db *SQLite3) UpsertSQL(tableName string, keyCols, updateCols []string) string {
 	columnsStr := strings.Builder{}
@@ -8,10 +8,11 @@ import (
 )
 
 const (
-	Postgres = "postgres"
-	SQLite   = "sqlite3"
-	MySQL    = "mysql"
-	MSSQL    = "mssql"
+	Postgres  = "postgres"
+	SQLite    = "sqlite3"
+	MySQL     = "mysql"
+	MSSQL     = "mssql"
+	Cockroach = "cockroach"
 )
 
 type Migration interface {
@@ -21,6 +22,13 @@ type Migration interface {
 	GetCondition() MigrationCondition
 }
 
+// ReversibleMigration is a migration declaring the SQL reverting it, so it can be rolled back with
+// Migrator.Rollback. Migrations whose RollbackSQL is empty can't be rolled back.
+type ReversibleMigration interface {
+	Migration
+	RollbackSQL(dialect Dialect) string
+}
+
 type CodeMigration interface {
 	Migration
 	Exec(sess *xorm.Session, migrator *Migrator) error
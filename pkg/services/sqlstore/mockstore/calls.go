@@ -0,0 +1,49 @@
+package mockstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Call is a call of a method of the mock, with the command, query and other arguments it was
+// called with.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+func (c Call) String() string {
+	args := make([]string, 0, len(c.Args))
+	for _, a := range c.Args {
+		args = append(args, fmt.Sprintf("%#v", a))
+	}
+	return fmt.Sprintf("%s(%s)", c.Method, strings.Join(args, ", "))
+}
+
+// Calls returns the calls of method, in the order they were made.
+func (m *SQLStoreMock) Calls(method string) []Call {
+	m.expectations.mu.Lock()
+	defer m.expectations.mu.Unlock()
+
+	var calls []Call
+	for _, c := range m.expectations.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// CallCount returns the number of calls of method.
+func (m *SQLStoreMock) CallCount(method string) int {
+	return len(m.Calls(method))
+}
+
+// LastCall returns the last call of method, or false if it wasn't called.
+func (m *SQLStoreMock) LastCall(method string) (Call, bool) {
+	calls := m.Calls(method)
+	if len(calls) == 0 {
+		return Call{}, false
+	}
+	return calls[len(calls)-1], true
+}
@@ -0,0 +1,58 @@
+package mockstore
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalls(t *testing.T) {
+	ctx := context.Background()
+	m := NewSQLStoreMock()
+
+	_, err := m.CreateUser(ctx, models.CreateUserCommand{Login: "bob"})
+	require.NoError(t, err)
+	require.NoError(t, m.AddTeamMember(1, 2, 3, false, models.PERMISSION_VIEW))
+	_, err = m.CreateUser(ctx, models.CreateUserCommand{Login: "alice"})
+	require.NoError(t, err)
+
+	require.Equal(t, []Call{
+		{Method: "CreateUser", Args: []interface{}{ctx, models.CreateUserCommand{Login: "bob"}}},
+		{Method: "CreateUser", Args: []interface{}{ctx, models.CreateUserCommand{Login: "alice"}}},
+	}, m.Calls("CreateUser"))
+	require.Equal(t, 2, m.CallCount("CreateUser"))
+	require.Equal(t, 1, m.CallCount("AddTeamMember"))
+	require.Zero(t, m.CallCount("CreateTeam"))
+	require.Empty(t, m.Calls("CreateTeam"))
+
+	last, ok := m.LastCall("CreateUser")
+	require.True(t, ok)
+	require.Equal(t, models.CreateUserCommand{Login: "alice"}, last.Args[1])
+	require.Equal(t, `AddTeamMember(1, 2, 3, false, 1)`, m.Calls("AddTeamMember")[0].String())
+
+	_, ok = m.LastCall("CreateTeam")
+	require.False(t, ok)
+}
+
+func TestCallsConcurrently(t *testing.T) {
+	m := NewSQLStoreMock()
+	m.Expect("GetOrgByName", Any())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.GetOrgByName("Main Org.")
+			require.NoError(t, err)
+			m.CallCount("GetOrgByName")
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 10, m.CallCount("GetOrgByName"))
+	require.True(t, m.AssertExpectations(t))
+}
@@ -0,0 +1,313 @@
+package mockstore
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// ErrInjected is the default error returned by calls failed by a ChaosStore.
+var ErrInjected = errors.New("injected store error")
+
+// Fault configures what a ChaosStore does to calls of a method.
+type Fault struct {
+	// Latency is added to every call.
+	Latency time.Duration
+	// Jitter adds a random latency of up to Jitter on top of Latency.
+	Jitter time.Duration
+	// ErrorRate is the probability, between 0 and 1, of a call failing with Err.
+	ErrorRate float64
+	// Err is the error injected calls fail with, ErrInjected if nil.
+	Err error
+	// CancelRate is the probability, between 0 and 1, of a call failing as if its context was canceled.
+	CancelRate float64
+}
+
+// ChaosStore wraps a store and injects latency, errors and context cancellations into its calls,
+// so retry and timeout behavior can be tested. Faults are drawn from a random source seeded at
+// construction, so the same sequence of calls always sees the same faults.
+type ChaosStore struct {
+	store sqlstore.Store
+
+	faultsMu sync.RWMutex
+	faults   map[string]Fault
+	fallback Fault
+
+	rndMu sync.Mutex
+	rnd   *rand.Rand
+}
+
+var _ sqlstore.Store = (*ChaosStore)(nil)
+
+func NewChaosStore(store sqlstore.Store, seed int64) *ChaosStore {
+	return &ChaosStore{
+		store:  store,
+		faults: map[string]Fault{},
+		rnd:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// WithFault configures the faults injected into calls of method.
+func (s *ChaosStore) WithFault(method string, fault Fault) *ChaosStore {
+	s.faultsMu.Lock()
+	defer s.faultsMu.Unlock()
+
+	s.faults[method] = fault
+	return s
+}
+
+// WithDefaultFault configures the faults injected into calls of methods without their own.
+func (s *ChaosStore) WithDefaultFault(fault Fault) *ChaosStore {
+	s.faultsMu.Lock()
+	defer s.faultsMu.Unlock()
+
+	s.fallback = fault
+	return s
+}
+
+func (s *ChaosStore) fault(method string) Fault {
+	s.faultsMu.RLock()
+	defer s.faultsMu.RUnlock()
+
+	if fault, ok := s.faults[method]; ok {
+		return fault
+	}
+	return s.fallback
+}
+
+// inject applies the faults configured for method and returns the error the call should fail with.
+// Latency is cut short if ctx is done.
+func (s *ChaosStore) inject(ctx context.Context, method string) error {
+	fault := s.fault(method)
+
+	// Draw every decision up front, so the sequence doesn't depend on which faults are enabled.
+	s.rndMu.Lock()
+	jitter, failure, cancel := s.rnd.Float64(), s.rnd.Float64(), s.rnd.Float64()
+	s.rndMu.Unlock()
+
+	if latency := fault.Latency + time.Duration(jitter*float64(fault.Jitter)); latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	if cancel < fault.CancelRate {
+		return context.Canceled
+	}
+	if failure < fault.ErrorRate {
+		if fault.Err != nil {
+			return fault.Err
+		}
+		return ErrInjected
+	}
+	return nil
+}
+
+func (s *ChaosStore) SaveAlerts(dashID int64, alerts []*models.Alert) error {
+	if err := s.inject(context.Background(), "SaveAlerts"); err != nil {
+		return err
+	}
+	return s.store.SaveAlerts(dashID, alerts)
+}
+
+func (s *ChaosStore) GetAlertNotificationUidWithId(query *models.GetAlertNotificationUidQuery) error {
+	if err := s.inject(context.Background(), "GetAlertNotificationUidWithId"); err != nil {
+		return err
+	}
+	return s.store.GetAlertNotificationUidWithId(query)
+}
+
+func (s *ChaosStore) SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
+	if err := s.inject(context.Background(), "SaveDashboard"); err != nil {
+		return nil, err
+	}
+	return s.store.SaveDashboard(cmd)
+}
+
+func (s *ChaosStore) GetDashboard(id, orgID int64, uid, slug string) (*models.Dashboard, error) {
+	if err := s.inject(context.Background(), "GetDashboard"); err != nil {
+		return nil, err
+	}
+	return s.store.GetDashboard(id, orgID, uid, slug)
+}
+
+func (s *ChaosStore) ValidateDashboardBeforeSave(dashboard *models.Dashboard, overwrite bool) (bool, error) {
+	if err := s.inject(context.Background(), "ValidateDashboardBeforeSave"); err != nil {
+		return false, err
+	}
+	return s.store.ValidateDashboardBeforeSave(dashboard, overwrite)
+}
+
+func (s *ChaosStore) UpdateDashboardACL(dashboardID int64, items []*models.DashboardAcl) error {
+	if err := s.inject(context.Background(), "UpdateDashboardACL"); err != nil {
+		return err
+	}
+	return s.store.UpdateDashboardACL(dashboardID, items)
+}
+
+func (s *ChaosStore) GetProvisionedDataByDashboardID(dashboardID int64) (*models.DashboardProvisioning, error) {
+	if err := s.inject(context.Background(), "GetProvisionedDataByDashboardID"); err != nil {
+		return nil, err
+	}
+	return s.store.GetProvisionedDataByDashboardID(dashboardID)
+}
+
+func (s *ChaosStore) SaveProvisionedDashboard(cmd models.SaveDashboardCommand, provisioning *models.DashboardProvisioning) (*models.Dashboard, error) {
+	if err := s.inject(context.Background(), "SaveProvisionedDashboard"); err != nil {
+		return nil, err
+	}
+	return s.store.SaveProvisionedDashboard(cmd, provisioning)
+}
+
+func (s *ChaosStore) GetProvisionedDashboardData(name string) ([]*models.DashboardProvisioning, error) {
+	if err := s.inject(context.Background(), "GetProvisionedDashboardData"); err != nil {
+		return nil, err
+	}
+	return s.store.GetProvisionedDashboardData(name)
+}
+
+func (s *ChaosStore) GetDataSource(uid string, id int64, name string, orgID int64) (*models.DataSource, error) {
+	if err := s.inject(context.Background(), "GetDataSource"); err != nil {
+		return nil, err
+	}
+	return s.store.GetDataSource(uid, id, name, orgID)
+}
+
+func (s *ChaosStore) DeleteDataSource(uid string, id int64, name string, orgID int64) (int64, error) {
+	if err := s.inject(context.Background(), "DeleteDataSource"); err != nil {
+		return 0, err
+	}
+	return s.store.DeleteDataSource(uid, id, name, orgID)
+}
+
+func (s *ChaosStore) GetOrgByName(name string) (*models.Org, error) {
+	if err := s.inject(context.Background(), "GetOrgByName"); err != nil {
+		return nil, err
+	}
+	return s.store.GetOrgByName(name)
+}
+
+func (s *ChaosStore) CreateOrgWithMember(name string, userID int64) (models.Org, error) {
+	if err := s.inject(context.Background(), "CreateOrgWithMember"); err != nil {
+		return models.Org{}, err
+	}
+	return s.store.CreateOrgWithMember(name, userID)
+}
+
+func (s *ChaosStore) GetPluginSettings(orgID int64) ([]*models.PluginSettingInfoDTO, error) {
+	if err := s.inject(context.Background(), "GetPluginSettings"); err != nil {
+		return nil, err
+	}
+	return s.store.GetPluginSettings(orgID)
+}
+
+func (s *ChaosStore) GetPreferencesWithDefaults(query *models.GetPreferencesWithDefaultsQuery) error {
+	if err := s.inject(context.Background(), "GetPreferencesWithDefaults"); err != nil {
+		return err
+	}
+	return s.store.GetPreferencesWithDefaults(query)
+}
+
+func (s *ChaosStore) CreateTeam(name, email string, orgID int64) (models.Team, error) {
+	if err := s.inject(context.Background(), "CreateTeam"); err != nil {
+		return models.Team{}, err
+	}
+	return s.store.CreateTeam(name, email, orgID)
+}
+
+func (s *ChaosStore) AddTeamMember(userID, orgID, teamID int64, isExternal bool, permission models.PermissionType) error {
+	if err := s.inject(context.Background(), "AddTeamMember"); err != nil {
+		return err
+	}
+	return s.store.AddTeamMember(userID, orgID, teamID, isExternal, permission)
+}
+
+func (s *ChaosStore) CreateUser(ctx context.Context, cmd models.CreateUserCommand) (*models.User, error) {
+	if err := s.inject(ctx, "CreateUser"); err != nil {
+		return nil, err
+	}
+	return s.store.CreateUser(ctx, cmd)
+}
+
+func (s *ChaosStore) GetSignedInUserWithCache(query *models.GetSignedInUserQuery) error {
+	if err := s.inject(context.Background(), "GetSignedInUserWithCache"); err != nil {
+		return err
+	}
+	return s.store.GetSignedInUserWithCache(query)
+}
+
+func (s *ChaosStore) UpdateUserPermissions(userID int64, isAdmin bool) error {
+	if err := s.inject(context.Background(), "UpdateUserPermissions"); err != nil {
+		return err
+	}
+	return s.store.UpdateUserPermissions(userID, isAdmin)
+}
+
+func (s *ChaosStore) GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
+	if err := s.inject(context.Background(), "GetOrgQuotaByTarget"); err != nil {
+		return err
+	}
+	return s.store.GetOrgQuotaByTarget(query)
+}
+
+func (s *ChaosStore) GetOrgQuotas(query *models.GetOrgQuotasQuery) error {
+	if err := s.inject(context.Background(), "GetOrgQuotas"); err != nil {
+		return err
+	}
+	return s.store.GetOrgQuotas(query)
+}
+
+func (s *ChaosStore) GetUserQuotaByTarget(query *models.GetUserQuotaByTargetQuery) error {
+	if err := s.inject(context.Background(), "GetUserQuotaByTarget"); err != nil {
+		return err
+	}
+	return s.store.GetUserQuotaByTarget(query)
+}
+
+func (s *ChaosStore) GetUserQuotas(query *models.GetUserQuotasQuery) error {
+	if err := s.inject(context.Background(), "GetUserQuotas"); err != nil {
+		return err
+	}
+	return s.store.GetUserQuotas(query)
+}
+
+func (s *ChaosStore) GetGlobalQuotaByTarget(query *models.GetGlobalQuotaByTargetQuery) error {
+	if err := s.inject(context.Background(), "GetGlobalQuotaByTarget"); err != nil {
+		return err
+	}
+	return s.store.GetGlobalQuotaByTarget(query)
+}
+
+func (s *ChaosStore) NewSession() *sqlstore.DBSession {
+	return s.store.NewSession()
+}
+
+func (s *ChaosStore) WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	if err := s.inject(ctx, "WithDbSession"); err != nil {
+		return err
+	}
+	return s.store.WithDbSession(ctx, callback)
+}
+
+func (s *ChaosStore) WithTransactionalDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	if err := s.inject(ctx, "WithTransactionalDbSession"); err != nil {
+		return err
+	}
+	return s.store.WithTransactionalDbSession(ctx, callback)
+}
+
+func (s *ChaosStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.inject(ctx, "InTransaction"); err != nil {
+		return err
+	}
+	return s.store.InTransaction(ctx, fn)
+}
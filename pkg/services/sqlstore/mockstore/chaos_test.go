@@ -0,0 +1,61 @@
+package mockstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosStore(t *testing.T) {
+	errorsFor := func(seed int64) []error {
+		s := NewChaosStore(NewSQLStoreMock(), seed).WithFault("GetOrgByName", Fault{ErrorRate: 0.5})
+
+		var errs []error
+		for i := 0; i < 20; i++ {
+			_, err := s.GetOrgByName("acme")
+			errs = append(errs, err)
+		}
+		return errs
+	}
+
+	t.Run("faults are deterministic for a seed", func(t *testing.T) {
+		errs := errorsFor(42)
+		require.Equal(t, errs, errorsFor(42))
+		require.Contains(t, errs, ErrInjected)
+		require.Contains(t, errs, nil)
+	})
+
+	t.Run("faults are per method", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		s := NewChaosStore(NewSQLStoreMock(), 1).
+			WithFault("CreateTeam", Fault{ErrorRate: 1, Err: errBoom}).
+			WithDefaultFault(Fault{CancelRate: 1})
+
+		_, err := s.CreateTeam("ops", "", 1)
+		require.Equal(t, errBoom, err)
+		require.Equal(t, context.Canceled, s.UpdateUserPermissions(1, true))
+	})
+
+	t.Run("latency honors the context", func(t *testing.T) {
+		s := NewChaosStore(NewSQLStoreMock(), 1).WithFault("CreateUser", Fault{Latency: time.Minute})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.CreateUser(ctx, models.CreateUserCommand{})
+		require.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("calls without faults reach the store", func(t *testing.T) {
+		mock := NewSQLStoreMock()
+		mock.ExpectedUser = &models.User{Login: "bob"}
+		s := NewChaosStore(mock, 1)
+
+		user, err := s.CreateUser(context.Background(), models.CreateUserCommand{})
+		require.NoError(t, err)
+		require.Equal(t, mock.ExpectedUser, user)
+	})
+}
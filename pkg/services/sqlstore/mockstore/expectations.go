@@ -0,0 +1,245 @@
+package mockstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Matcher matches a single argument of a store call.
+type Matcher interface {
+	Matches(arg interface{}) bool
+	String() string
+}
+
+type matcherFunc struct {
+	fn   func(arg interface{}) bool
+	desc string
+}
+
+func (m matcherFunc) Matches(arg interface{}) bool { return m.fn(arg) }
+func (m matcherFunc) String() string               { return m.desc }
+
+// Any matches any argument.
+func Any() Matcher {
+	return matcherFunc{fn: func(interface{}) bool { return true }, desc: "any"}
+}
+
+// AnyContext matches any context.Context argument.
+func AnyContext() Matcher {
+	return matcherFunc{
+		fn: func(arg interface{}) bool {
+			_, ok := arg.(context.Context)
+			return ok
+		},
+		desc: "any context",
+	}
+}
+
+// Eq matches arguments deeply equal to value.
+func Eq(value interface{}) Matcher {
+	return matcherFunc{
+		fn:   func(arg interface{}) bool { return reflect.DeepEqual(arg, value) },
+		desc: fmt.Sprintf("%#v", value),
+	}
+}
+
+// CommandWith matches command and query arguments, or pointers to them, whose field
+// is deeply equal to value.
+func CommandWith(field string, value interface{}) Matcher {
+	return matcherFunc{
+		fn: func(arg interface{}) bool {
+			v := reflect.ValueOf(arg)
+			for v.Kind() == reflect.Ptr && !v.IsNil() {
+				v = v.Elem()
+			}
+			if v.Kind() != reflect.Struct {
+				return false
+			}
+			f := v.FieldByName(field)
+			return f.IsValid() && f.CanInterface() && reflect.DeepEqual(f.Interface(), value)
+		},
+		desc: fmt.Sprintf("command with %s=%#v", field, value),
+	}
+}
+
+// Expectation is a call the mock expects, registered with SQLStoreMock.Expect.
+type Expectation struct {
+	method  string
+	args    []Matcher
+	err     error
+	matched bool
+}
+
+// Return makes calls matching the expectation fail with err instead of ExpectedError.
+func (e *Expectation) Return(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) matches(method string, args []interface{}) bool {
+	if e.method != method || len(e.args) != len(args) {
+		return false
+	}
+	for i, m := range e.args {
+		if !m.Matches(args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) String() string {
+	args := make([]string, 0, len(e.args))
+	for _, m := range e.args {
+		args = append(args, m.String())
+	}
+	return fmt.Sprintf("%s(%s)", e.method, strings.Join(args, ", "))
+}
+
+// expectations are the expectations and recorded calls of a mock. They are guarded by mu, so the
+// mock can be called from several goroutines.
+type expectations struct {
+	mu        sync.Mutex
+	expected  []*Expectation
+	calls     []Call
+	inOrder   bool
+	responses map[string][][]interface{}
+	handlers  map[string]reflect.Value
+	// strict reports the calls that aren't expected, if not nil.
+	strict TestingT
+}
+
+// called records a call and returns the expectation it satisfies, if any, and the next response
+// queued for method or else its handler. In order, a call can only satisfy the next expectation. Otherwise it satisfies
+// the first unsatisfied expectation matching it, or the first one that was already satisfied if
+// there's none left.
+func (e *expectations) called(method string, args []interface{}) (*Expectation, []interface{}, reflect.Value) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.calls = append(e.calls, Call{Method: method, Args: args})
+
+	var response []interface{}
+	if queued := e.responses[method]; len(queued) > 0 {
+		response = queued[0]
+		e.responses[method] = queued[1:]
+	}
+	var handler reflect.Value
+	if response == nil {
+		handler = e.handlers[method]
+	}
+	exp := e.satisfied(method, args)
+	if e.strict != nil && exp == nil && response == nil && !handler.IsValid() {
+		e.strict.Helper()
+		e.strict.Errorf("Unexpected call: %s", e.calls[len(e.calls)-1])
+	}
+	return exp, response, handler
+}
+
+// satisfied returns the expectation satisfied by the last recorded call. The lock must be held.
+func (e *expectations) satisfied(method string, args []interface{}) *Expectation {
+
+	if e.inOrder {
+		next := len(e.calls) - 1
+		if next < len(e.expected) && e.expected[next].matches(method, args) {
+			e.expected[next].matched = true
+			return e.expected[next]
+		}
+		return nil
+	}
+
+	var found *Expectation
+	for _, exp := range e.expected {
+		if !exp.matches(method, args) {
+			continue
+		}
+		if !exp.matched {
+			exp.matched = true
+			return exp
+		}
+		if found == nil {
+			found = exp
+		}
+	}
+	return found
+}
+
+// TestingT is the subset of testing.TB used to report unmet expectations.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Expect registers an expected call of method. Arguments that aren't a Matcher are matched with Eq.
+func (m *SQLStoreMock) Expect(method string, args ...interface{}) *Expectation {
+	exp := &Expectation{method: method}
+	for _, arg := range args {
+		matcher, ok := arg.(Matcher)
+		if !ok {
+			matcher = Eq(arg)
+		}
+		exp.args = append(exp.args, matcher)
+	}
+
+	m.expectations.mu.Lock()
+	defer m.expectations.mu.Unlock()
+	m.expectations.expected = append(m.expectations.expected, exp)
+	return exp
+}
+
+// InOrder makes AssertExpectations require the store to have been called exactly in the order
+// the expectations were registered, without any other calls.
+func (m *SQLStoreMock) InOrder() *SQLStoreMock {
+	m.expectations.mu.Lock()
+	defer m.expectations.mu.Unlock()
+	m.expectations.inOrder = true
+	return m
+}
+
+// Strict makes the mock report every call to t that doesn't satisfy an expectation, and doesn't have
+// a response or a handler registered with OnCall, so tests fail when services make calls the test
+// doesn't expect. The calls are still answered as usual.
+func (m *SQLStoreMock) Strict(t TestingT) *SQLStoreMock {
+	m.expectations.mu.Lock()
+	defer m.expectations.mu.Unlock()
+	m.expectations.strict = t
+	return m
+}
+
+// AssertExpectations reports every expectation that wasn't met, and for strict ordering any
+// call that didn't happen in sequence.
+func (m *SQLStoreMock) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	e := &m.expectations
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ok := true
+	if e.inOrder {
+		for i, c := range e.calls {
+			if i >= len(e.expected) {
+				t.Errorf("Unexpected call #%d: %s", i+1, c)
+				ok = false
+			} else if !e.expected[i].matches(c.Method, c.Args) {
+				t.Errorf("Call #%d: expected %s, got %s", i+1, e.expected[i], c)
+				ok = false
+			}
+		}
+		for i := len(e.calls); i < len(e.expected); i++ {
+			t.Errorf("Missing call #%d: %s", i+1, e.expected[i])
+			ok = false
+		}
+		return ok
+	}
+
+	for _, exp := range e.expected {
+		if !exp.matched {
+			t.Errorf("Expected call not made: %s", exp)
+			ok = false
+		}
+	}
+	return ok
+}
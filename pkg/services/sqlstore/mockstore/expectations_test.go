@@ -0,0 +1,133 @@
+package mockstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMatchers(t *testing.T) {
+	cmd := models.CreateUserCommand{Login: "bob", OrgId: 2}
+
+	require.True(t, Any().Matches(nil))
+	require.True(t, AnyContext().Matches(context.Background()))
+	require.False(t, AnyContext().Matches(cmd))
+	require.True(t, Eq(int64(1)).Matches(int64(1)))
+	require.False(t, Eq(1).Matches(int64(1)))
+	require.True(t, CommandWith("Login", "bob").Matches(cmd))
+	require.True(t, CommandWith("OrgId", int64(2)).Matches(&cmd))
+	require.False(t, CommandWith("Login", "alice").Matches(cmd))
+	require.False(t, CommandWith("Unknown", "bob").Matches(cmd))
+	require.False(t, CommandWith("Login", "bob").Matches("bob"))
+}
+
+func TestExpectations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("met expectations in any order", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.Expect("CreateUser", AnyContext(), CommandWith("Login", "bob"))
+		m.Expect("CreateTeam", "ops", Any(), int64(1))
+
+		_, err := m.CreateTeam("ops", "ops@example.com", 1)
+		require.NoError(t, err)
+		_, err = m.CreateUser(ctx, models.CreateUserCommand{Login: "bob"})
+		require.NoError(t, err)
+
+		ft := &fakeT{}
+		require.True(t, m.AssertExpectations(ft))
+		require.Empty(t, ft.errors)
+	})
+
+	t.Run("unmet expectations", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.Expect("CreateUser", AnyContext(), CommandWith("Login", "bob"))
+
+		_, err := m.CreateUser(ctx, models.CreateUserCommand{Login: "alice"})
+		require.NoError(t, err)
+
+		ft := &fakeT{}
+		require.False(t, m.AssertExpectations(ft))
+		require.Equal(t, []string{`Expected call not made: CreateUser(any context, command with Login="bob")`}, ft.errors)
+	})
+
+	t.Run("matching expectations override the error", func(t *testing.T) {
+		errTaken := errors.New("name taken")
+		m := NewSQLStoreMock()
+		m.ExpectedError = errors.New("default")
+		m.Expect("CreateTeam", "ops", Any(), Any()).Return(errTaken)
+
+		_, err := m.CreateTeam("ops", "", 1)
+		require.Equal(t, errTaken, err)
+		_, err = m.CreateTeam("dev", "", 1)
+		require.Equal(t, m.ExpectedError, err)
+	})
+
+	t.Run("strict order", func(t *testing.T) {
+		newMock := func() *SQLStoreMock {
+			m := NewSQLStoreMock().InOrder()
+			m.Expect("CreateUser", AnyContext(), CommandWith("Login", "bob"))
+			m.Expect("AddTeamMember", Any(), Any(), Any(), false, Any())
+			return m
+		}
+
+		m := newMock()
+		_, err := m.CreateUser(ctx, models.CreateUserCommand{Login: "bob"})
+		require.NoError(t, err)
+		require.NoError(t, m.AddTeamMember(1, 1, 1, false, models.PERMISSION_VIEW))
+		require.True(t, m.AssertExpectations(&fakeT{}))
+
+		m = newMock()
+		require.NoError(t, m.AddTeamMember(1, 1, 1, false, models.PERMISSION_VIEW))
+		_, err = m.CreateUser(ctx, models.CreateUserCommand{Login: "bob"})
+		require.NoError(t, err)
+		ft := &fakeT{}
+		require.False(t, m.AssertExpectations(ft))
+		require.Len(t, ft.errors, 2)
+
+		m = newMock()
+		_, err = m.CreateUser(ctx, models.CreateUserCommand{Login: "bob"})
+		require.NoError(t, err)
+		require.NoError(t, m.AddTeamMember(1, 1, 1, false, models.PERMISSION_VIEW))
+		require.NoError(t, m.UpdateUserPermissions(1, true))
+		ft = &fakeT{}
+		require.False(t, m.AssertExpectations(ft))
+		require.Equal(t, []string{"Unexpected call #3: UpdateUserPermissions(1, true)"}, ft.errors)
+	})
+}
+
+func TestStrict(t *testing.T) {
+	ft := &fakeT{}
+	m := NewSQLStoreMock().Strict(ft)
+	m.Expect("GetOrgByName", "Main Org.")
+	m.OnCall("CreateTeam").ReturnOnce(models.Team{Id: 1}, nil)
+
+	_, err := m.GetOrgByName("Main Org.")
+	require.NoError(t, err)
+	_, err = m.CreateTeam("editors", "", 1)
+	require.NoError(t, err)
+	require.Empty(t, ft.errors)
+
+	_, err = m.GetOrgByName("Other Org.")
+	require.NoError(t, err)
+	_, err = m.CreateTeam("editors", "", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`Unexpected call: GetOrgByName("Other Org.")`,
+		`Unexpected call: CreateTeam("editors", "", 1)`,
+	}, ft.errors)
+}
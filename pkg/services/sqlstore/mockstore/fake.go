@@ -0,0 +1,1101 @@
+package mockstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// FakeSQLStore is an in-memory implementation of sqlstore.Store. Unlike SQLStoreMock it keeps the
+// state written by commands, so a user created with CreateUser is returned by GetUserByLogin and a
+// dashboard saved with SaveDashboard by GetDashboard, and tests of services built on several calls
+// don't need a database. Commands fail with the same errors as the SQL store where the fake keeps
+// enough state to tell. Methods return copies of the stored values, so changing them doesn't change
+// the store.
+type FakeSQLStore struct {
+	// CommitError, if not nil, makes the transactions of InTransaction fail with it when committed,
+	// after their function succeeded. Their writes are rolled back.
+	CommitError error
+
+	mu sync.Mutex
+	fakeTables
+	sessions sessionEngine
+}
+
+// fakeTables are the tables of a FakeSQLStore.
+type fakeTables struct {
+	lastID int64
+
+	users              map[int64]*models.User
+	orgs               map[int64]*models.Org
+	orgUsers           []*models.OrgUser
+	teams              map[int64]*models.Team
+	teamMembers        []*models.TeamMember
+	dashboards         map[int64]*models.Dashboard
+	dashboardACLs      map[int64][]*models.DashboardAcl
+	provisioning       map[int64]*models.DashboardProvisioning
+	alerts             map[int64][]*models.Alert
+	alertNotifications map[int64]*models.AlertNotification
+	datasources        map[int64]*models.DataSource
+	pluginSettings     []*models.PluginSetting
+	preferences        []*models.Preferences
+}
+
+var _ sqlstore.Store = (*FakeSQLStore)(nil)
+
+func NewFakeSQLStore() *FakeSQLStore {
+	return &FakeSQLStore{fakeTables: fakeTables{
+		users:              map[int64]*models.User{},
+		orgs:               map[int64]*models.Org{},
+		teams:              map[int64]*models.Team{},
+		dashboards:         map[int64]*models.Dashboard{},
+		dashboardACLs:      map[int64][]*models.DashboardAcl{},
+		provisioning:       map[int64]*models.DashboardProvisioning{},
+		alerts:             map[int64][]*models.Alert{},
+		alertNotifications: map[int64]*models.AlertNotification{},
+		datasources:        map[int64]*models.DataSource{},
+	}}
+}
+
+// nextID returns a new ID. IDs are unique across all the tables of the store.
+func (s *FakeSQLStore) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *FakeSQLStore) SaveAlerts(dashID int64, alerts []*models.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := make([]*models.Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.Id == 0 {
+			alert.Id = s.nextID()
+		}
+		alert.DashboardId = dashID
+		a := *alert
+		saved = append(saved, &a)
+	}
+	s.alerts[dashID] = saved
+	return nil
+}
+
+// GetAlerts returns the alerts of a dashboard saved with SaveAlerts.
+func (s *FakeSQLStore) GetAlerts(dashID int64) []*models.Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alerts := make([]*models.Alert, 0, len(s.alerts[dashID]))
+	for _, alert := range s.alerts[dashID] {
+		a := *alert
+		alerts = append(alerts, &a)
+	}
+	return alerts
+}
+
+// CreateAlertNotification stores an alert notification, so its UID can be looked up with
+// GetAlertNotificationUidWithId.
+func (s *FakeSQLStore) CreateAlertNotification(cmd *models.CreateAlertNotificationCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cmd.Uid == "" {
+		cmd.Uid = util.GenerateShortUID()
+	}
+	now := time.Now()
+	notification := &models.AlertNotification{
+		Id:                    s.nextID(),
+		Uid:                   cmd.Uid,
+		OrgId:                 cmd.OrgId,
+		Name:                  cmd.Name,
+		Type:                  cmd.Type,
+		SendReminder:          cmd.SendReminder,
+		DisableResolveMessage: cmd.DisableResolveMessage,
+		IsDefault:             cmd.IsDefault,
+		Settings:              cmd.Settings,
+		Created:               now,
+		Updated:               now,
+	}
+	s.alertNotifications[notification.Id] = notification
+	result := *notification
+	cmd.Result = &result
+	return nil
+}
+
+func (s *FakeSQLStore) GetAlertNotificationUidWithId(query *models.GetAlertNotificationUidQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification, ok := s.alertNotifications[query.Id]
+	if !ok || notification.OrgId != query.OrgId {
+		return models.ErrAlertNotificationFailedTranslateUniqueID
+	}
+	query.Result = notification.Uid
+	return nil
+}
+
+func (s *FakeSQLStore) SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.saveDashboard(cmd)
+}
+
+// saveDashboard inserts or updates the dashboard of cmd. The lock must be held.
+func (s *FakeSQLStore) saveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error) {
+	dash := cmd.GetDashboardModel()
+
+	if _, err := s.validateDashboard(dash, cmd.Overwrite); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if dash.Id == 0 {
+		dash.SetVersion(1)
+		dash.Created = now
+		dash.CreatedBy = dash.UpdatedBy
+		dash.SetId(s.nextID())
+	} else {
+		existing := s.dashboards[dash.Id]
+		dash.SetVersion(existing.Version + 1)
+		dash.Created = existing.Created
+		dash.CreatedBy = existing.CreatedBy
+		dash.HasAcl = existing.HasAcl
+	}
+	if dash.Uid == "" {
+		dash.SetUid(util.GenerateShortUID())
+	}
+	dash.Updated = now
+	if !cmd.UpdatedAt.IsZero() {
+		dash.Updated = cmd.UpdatedAt
+	}
+
+	s.dashboards[dash.Id] = dash
+	result := *dash
+	return &result, nil
+}
+
+// validateDashboard checks that dash can be saved, like the SQL store does, and sets its ID and UID
+// when it updates an existing dashboard. The lock must be held.
+func (s *FakeSQLStore) validateDashboard(dash *models.Dashboard, overwrite bool) (bool, error) {
+	isParentFolderChanged := false
+
+	var existing *models.Dashboard
+	if dash.Id > 0 {
+		byID, ok := s.dashboards[dash.Id]
+		if !ok || byID.OrgId != dash.OrgId {
+			return false, models.ErrDashboardNotFound
+		}
+		if dash.Uid == "" {
+			dash.SetUid(byID.Uid)
+		}
+		existing = byID
+	}
+	if dash.Uid != "" {
+		byUID := s.findDashboard(func(d *models.Dashboard) bool {
+			return d.OrgId == dash.OrgId && d.Uid == dash.Uid
+		})
+		switch {
+		case byUID == nil:
+		case existing != nil && existing.Id != byUID.Id:
+			return false, models.ErrDashboardWithSameUIDExists
+		case existing == nil:
+			dash.SetId(byUID.Id)
+			existing = byUID
+			isParentFolderChanged = !dash.IsFolder
+		}
+	}
+	if dash.FolderId > 0 {
+		folder, ok := s.dashboards[dash.FolderId]
+		if !ok || folder.OrgId != dash.OrgId || !folder.IsFolder {
+			return false, models.ErrDashboardFolderNotFound
+		}
+	}
+
+	if existing != nil {
+		if existing.IsFolder != dash.IsFolder {
+			return false, models.ErrDashboardTypeMismatch
+		}
+		if !dash.IsFolder && dash.FolderId != existing.FolderId {
+			isParentFolderChanged = true
+		}
+		if dash.Version != existing.Version {
+			if !overwrite {
+				return false, models.ErrDashboardVersionMismatch
+			}
+			dash.SetVersion(existing.Version)
+		}
+		if existing.PluginId != "" && !overwrite {
+			return false, models.UpdatePluginDashboardError{PluginId: existing.PluginId}
+		}
+	}
+
+	sameTitle := s.findDashboard(func(d *models.Dashboard) bool {
+		return d.OrgId == dash.OrgId && d.Slug == dash.Slug && (d.IsFolder || d.FolderId == dash.FolderId) &&
+			d.Id != dash.Id
+	})
+	if sameTitle != nil {
+		switch {
+		case sameTitle.IsFolder && !dash.IsFolder:
+			return false, models.ErrDashboardWithSameNameAsFolder
+		case !sameTitle.IsFolder && dash.IsFolder:
+			return false, models.ErrDashboardFolderWithSameNameAsDashboard
+		}
+		if !dash.IsFolder && (dash.FolderId != sameTitle.FolderId || dash.Id == 0) {
+			isParentFolderChanged = true
+		}
+		if !overwrite {
+			return isParentFolderChanged, models.ErrDashboardWithSameNameInFolderExists
+		}
+		if dash.Id == 0 {
+			dash.SetId(sameTitle.Id)
+			dash.SetUid(sameTitle.Uid)
+			dash.SetVersion(sameTitle.Version)
+		}
+	}
+
+	return isParentFolderChanged, nil
+}
+
+// findDashboard returns the first stored dashboard, by ID, that match returns true for. The lock
+// must be held.
+func (s *FakeSQLStore) findDashboard(match func(*models.Dashboard) bool) *models.Dashboard {
+	ids := make([]int64, 0, len(s.dashboards))
+	for id := range s.dashboards {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if match(s.dashboards[id]) {
+			return s.dashboards[id]
+		}
+	}
+	return nil
+}
+
+func (s *FakeSQLStore) GetDashboard(id, orgID int64, uid, slug string) (*models.Dashboard, error) {
+	if id == 0 && slug == "" && uid == "" {
+		return nil, models.ErrDashboardIdentifierNotSet
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dash := s.findDashboard(func(d *models.Dashboard) bool {
+		return (id == 0 || d.Id == id) && (orgID == 0 || d.OrgId == orgID) &&
+			(uid == "" || d.Uid == uid) && (slug == "" || d.Slug == slug)
+	})
+	if dash == nil {
+		return nil, models.ErrDashboardNotFound
+	}
+	result := *dash
+	return &result, nil
+}
+
+func (s *FakeSQLStore) ValidateDashboardBeforeSave(dashboard *models.Dashboard, overwrite bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.validateDashboard(dashboard, overwrite)
+}
+
+func (s *FakeSQLStore) UpdateDashboardACL(dashboardID int64, items []*models.DashboardAcl) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acl := make([]*models.DashboardAcl, 0, len(items))
+	for _, item := range items {
+		if item.UserID == 0 && item.TeamID == 0 && (item.Role == nil || !item.Role.IsValid()) {
+			return models.ErrDashboardAclInfoMissing
+		}
+		if item.DashboardID == 0 {
+			return models.ErrDashboardPermissionDashboardEmpty
+		}
+		i := *item
+		i.Id = s.nextID()
+		acl = append(acl, &i)
+	}
+	s.dashboardACLs[dashboardID] = acl
+
+	if dash, ok := s.dashboards[dashboardID]; ok {
+		dash.HasAcl = len(acl) > 0
+	}
+	return nil
+}
+
+// GetDashboardACL returns the permissions of a dashboard set with UpdateDashboardACL.
+func (s *FakeSQLStore) GetDashboardACL(dashboardID int64) []*models.DashboardAcl {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acl := make([]*models.DashboardAcl, 0, len(s.dashboardACLs[dashboardID]))
+	for _, item := range s.dashboardACLs[dashboardID] {
+		i := *item
+		acl = append(acl, &i)
+	}
+	return acl
+}
+
+func (s *FakeSQLStore) GetProvisionedDataByDashboardID(dashboardID int64) (*models.DashboardProvisioning, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	provisioning, ok := s.provisioning[dashboardID]
+	if !ok {
+		return nil, nil
+	}
+	result := *provisioning
+	return &result, nil
+}
+
+func (s *FakeSQLStore) SaveProvisionedDashboard(cmd models.SaveDashboardCommand, provisioning *models.DashboardProvisioning) (*models.Dashboard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dash, err := s.saveDashboard(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	provisioning.DashboardId = dash.Id
+	if provisioning.Updated == 0 {
+		provisioning.Updated = dash.Updated.Unix()
+	}
+	if existing, ok := s.provisioning[dash.Id]; ok {
+		provisioning.Id = existing.Id
+	} else {
+		provisioning.Id = s.nextID()
+	}
+	p := *provisioning
+	s.provisioning[dash.Id] = &p
+	return dash, nil
+}
+
+func (s *FakeSQLStore) GetProvisionedDashboardData(name string) ([]*models.DashboardProvisioning, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []*models.DashboardProvisioning{}
+	for _, provisioning := range s.provisioning {
+		if provisioning.Name == name {
+			p := *provisioning
+			result = append(result, &p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result, nil
+}
+
+// AddDataSource stores a data source, so it can be looked up with GetDataSource.
+func (s *FakeSQLStore) AddDataSource(cmd *models.AddDataSourceCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ds := range s.datasources {
+		if ds.OrgId != cmd.OrgId {
+			continue
+		}
+		if ds.Name == cmd.Name {
+			return models.ErrDataSourceNameExists
+		}
+		if cmd.Uid != "" && ds.Uid == cmd.Uid {
+			return models.ErrDataSourceUidExists
+		}
+	}
+
+	if cmd.Uid == "" {
+		cmd.Uid = util.GenerateShortUID()
+	}
+	now := time.Now()
+	ds := &models.DataSource{
+		Id:                s.nextID(),
+		OrgId:             cmd.OrgId,
+		Version:           1,
+		Name:              cmd.Name,
+		Type:              cmd.Type,
+		Access:            cmd.Access,
+		Url:               cmd.Url,
+		User:              cmd.User,
+		Password:          cmd.Password,
+		Database:          cmd.Database,
+		BasicAuth:         cmd.BasicAuth,
+		BasicAuthUser:     cmd.BasicAuthUser,
+		BasicAuthPassword: cmd.BasicAuthPassword,
+		WithCredentials:   cmd.WithCredentials,
+		IsDefault:         cmd.IsDefault,
+		JsonData:          cmd.JsonData,
+		ReadOnly:          cmd.ReadOnly,
+		Uid:               cmd.Uid,
+		Created:           now,
+		Updated:           now,
+	}
+	s.datasources[ds.Id] = ds
+	result := *ds
+	cmd.Result = &result
+	return nil
+}
+
+// matchDataSource returns true if ds has the UID, or the ID, or the name, in this order of
+// preference, like the SQL store looks up data sources.
+func matchDataSource(ds *models.DataSource, uid string, id int64, name string, orgID int64) bool {
+	if ds.OrgId != orgID {
+		return false
+	}
+	switch {
+	case uid != "":
+		return ds.Uid == uid
+	case id != 0:
+		return ds.Id == id
+	default:
+		return ds.Name == name
+	}
+}
+
+func (s *FakeSQLStore) GetDataSource(uid string, id int64, name string, orgID int64) (*models.DataSource, error) {
+	if orgID == 0 || (id == 0 && name == "" && uid == "") {
+		return nil, models.ErrDataSourceIdentifierNotSet
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ds := range s.datasources {
+		if matchDataSource(ds, uid, id, name, orgID) {
+			result := *ds
+			return &result, nil
+		}
+	}
+	return nil, models.ErrDataSourceNotFound
+}
+
+func (s *FakeSQLStore) DeleteDataSource(uid string, id int64, name string, orgID int64) (int64, error) {
+	if orgID == 0 || (id == 0 && name == "" && uid == "") {
+		return 0, models.ErrDataSourceIdentifierNotSet
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for dsID, ds := range s.datasources {
+		if matchDataSource(ds, uid, id, name, orgID) {
+			delete(s.datasources, dsID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *FakeSQLStore) GetOrgByName(name string) (*models.Org, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org := s.findOrgByName(name)
+	if org == nil {
+		return nil, models.ErrOrgNotFound
+	}
+	result := *org
+	return &result, nil
+}
+
+// findOrgByName returns the org with the name, or nil. The lock must be held.
+func (s *FakeSQLStore) findOrgByName(name string) *models.Org {
+	for _, org := range s.orgs {
+		if org.Name == name {
+			return org
+		}
+	}
+	return nil
+}
+
+func (s *FakeSQLStore) CreateOrgWithMember(name string, userID int64) (models.Org, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findOrgByName(name) != nil {
+		return models.Org{}, models.ErrOrgNameTaken
+	}
+	org := s.createOrg(name)
+	s.addOrgUser(org.Id, userID, models.ROLE_ADMIN)
+	return *org, nil
+}
+
+// createOrg stores a new org. The lock must be held.
+func (s *FakeSQLStore) createOrg(name string) *models.Org {
+	now := time.Now()
+	org := &models.Org{Id: s.nextID(), Version: 1, Name: name, Created: now, Updated: now}
+	s.orgs[org.Id] = org
+	return org
+}
+
+// addOrgUser adds a user to an org. The lock must be held.
+func (s *FakeSQLStore) addOrgUser(orgID, userID int64, role models.RoleType) {
+	now := time.Now()
+	s.orgUsers = append(s.orgUsers, &models.OrgUser{
+		Id:      s.nextID(),
+		OrgId:   orgID,
+		UserId:  userID,
+		Role:    role,
+		Created: now,
+		Updated: now,
+	})
+}
+
+// findOrgUser returns the membership of a user in an org, or nil. The lock must be held.
+func (s *FakeSQLStore) findOrgUser(orgID, userID int64) *models.OrgUser {
+	for _, orgUser := range s.orgUsers {
+		if orgUser.OrgId == orgID && orgUser.UserId == userID {
+			return orgUser
+		}
+	}
+	return nil
+}
+
+// UpdatePluginSetting stores the settings of a plugin in an org, so they are returned by
+// GetPluginSettings.
+func (s *FakeSQLStore) UpdatePluginSetting(cmd *models.UpdatePluginSettingCmd) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, setting := range s.pluginSettings {
+		if setting.OrgId == cmd.OrgId && setting.PluginId == cmd.PluginId {
+			setting.Enabled = cmd.Enabled
+			setting.Pinned = cmd.Pinned
+			setting.JsonData = cmd.JsonData
+			setting.PluginVersion = cmd.PluginVersion
+			setting.Updated = now
+			return nil
+		}
+	}
+	s.pluginSettings = append(s.pluginSettings, &models.PluginSetting{
+		Id:            s.nextID(),
+		PluginId:      cmd.PluginId,
+		OrgId:         cmd.OrgId,
+		Enabled:       cmd.Enabled,
+		Pinned:        cmd.Pinned,
+		JsonData:      cmd.JsonData,
+		PluginVersion: cmd.PluginVersion,
+		Created:       now,
+		Updated:       now,
+	})
+	return nil
+}
+
+func (s *FakeSQLStore) GetPluginSettings(orgID int64) ([]*models.PluginSettingInfoDTO, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []*models.PluginSettingInfoDTO{}
+	for _, setting := range s.pluginSettings {
+		if orgID != 0 && setting.OrgId != orgID {
+			continue
+		}
+		result = append(result, &models.PluginSettingInfoDTO{
+			OrgId:         setting.OrgId,
+			PluginId:      setting.PluginId,
+			Enabled:       setting.Enabled,
+			Pinned:        setting.Pinned,
+			PluginVersion: setting.PluginVersion,
+		})
+	}
+	return result, nil
+}
+
+// SavePreferences stores the preferences of an org, a team or a user, so they are merged by
+// GetPreferencesWithDefaults.
+func (s *FakeSQLStore) SavePreferences(cmd *models.SavePreferencesCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, prefs := range s.preferences {
+		if prefs.OrgId == cmd.OrgId && prefs.UserId == cmd.UserId && prefs.TeamId == cmd.TeamId {
+			prefs.HomeDashboardId = cmd.HomeDashboardId
+			prefs.Timezone = cmd.Timezone
+			prefs.Theme = cmd.Theme
+			prefs.Version++
+			prefs.Updated = now
+			return nil
+		}
+	}
+	s.preferences = append(s.preferences, &models.Preferences{
+		Id:              s.nextID(),
+		OrgId:           cmd.OrgId,
+		UserId:          cmd.UserId,
+		TeamId:          cmd.TeamId,
+		HomeDashboardId: cmd.HomeDashboardId,
+		Timezone:        cmd.Timezone,
+		Theme:           cmd.Theme,
+		Created:         now,
+		Updated:         now,
+	})
+	return nil
+}
+
+// GetPreferencesWithDefaults merges the preferences of the org of the user, of their teams and of
+// the user, the last set one winning.
+func (s *FakeSQLStore) GetPreferencesWithDefaults(query *models.GetPreferencesWithDefaultsQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	teams := map[int64]bool{}
+	for _, teamID := range query.User.Teams {
+		teams[teamID] = true
+	}
+	levels := []func(*models.Preferences) bool{
+		func(p *models.Preferences) bool { return p.UserId == 0 && p.TeamId == 0 },
+		func(p *models.Preferences) bool { return p.UserId == 0 && teams[p.TeamId] },
+		func(p *models.Preferences) bool { return p.UserId == query.User.UserId && p.TeamId == 0 },
+	}
+
+	result := &models.Preferences{}
+	for _, level := range levels {
+		for _, prefs := range s.preferences {
+			if prefs.OrgId != query.User.OrgId || !level(prefs) {
+				continue
+			}
+			if prefs.Theme != "" {
+				result.Theme = prefs.Theme
+			}
+			if prefs.Timezone != "" {
+				result.Timezone = prefs.Timezone
+			}
+			if prefs.HomeDashboardId != 0 {
+				result.HomeDashboardId = prefs.HomeDashboardId
+			}
+		}
+	}
+	query.Result = result
+	return nil
+}
+
+func (s *FakeSQLStore) CreateTeam(name, email string, orgID int64) (models.Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, team := range s.teams {
+		if team.OrgId == orgID && team.Name == name {
+			return models.Team{}, models.ErrTeamNameTaken
+		}
+	}
+	now := time.Now()
+	team := &models.Team{Id: s.nextID(), OrgId: orgID, Name: name, Email: email, Created: now, Updated: now}
+	s.teams[team.Id] = team
+	return *team, nil
+}
+
+func (s *FakeSQLStore) AddTeamMember(userID, orgID, teamID int64, isExternal bool, permission models.PermissionType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if team, ok := s.teams[teamID]; !ok || team.OrgId != orgID {
+		return models.ErrTeamNotFound
+	}
+	for _, member := range s.teamMembers {
+		if member.OrgId == orgID && member.TeamId == teamID && member.UserId == userID {
+			return models.ErrTeamMemberAlreadyAdded
+		}
+	}
+	now := time.Now()
+	s.teamMembers = append(s.teamMembers, &models.TeamMember{
+		Id:         s.nextID(),
+		OrgId:      orgID,
+		TeamId:     teamID,
+		UserId:     userID,
+		External:   isExternal,
+		Permission: permission,
+		Created:    now,
+		Updated:    now,
+	})
+	return nil
+}
+
+// GetTeamMembers returns the members added with AddTeamMember, filtered by the org, team, user and
+// external flag of the query.
+func (s *FakeSQLStore) GetTeamMembers(query *models.GetTeamMembersQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query.Result = []*models.TeamMemberDTO{}
+	for _, member := range s.teamMembers {
+		if member.OrgId != query.OrgId ||
+			(query.TeamId != 0 && member.TeamId != query.TeamId) ||
+			(query.UserId != 0 && member.UserId != query.UserId) ||
+			(query.External && !member.External) {
+			continue
+		}
+		dto := &models.TeamMemberDTO{
+			OrgId:      member.OrgId,
+			TeamId:     member.TeamId,
+			UserId:     member.UserId,
+			External:   member.External,
+			Permission: member.Permission,
+		}
+		if user, ok := s.users[member.UserId]; ok {
+			dto.Email = user.Email
+			dto.Name = user.Name
+			dto.Login = user.Login
+		}
+		query.Result = append(query.Result, dto)
+	}
+	return nil
+}
+
+func (s *FakeSQLStore) CreateUser(ctx context.Context, cmd models.CreateUserCommand) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cmd.Email == "" {
+		cmd.Email = cmd.Login
+	}
+	if s.findUser(cmd.Login) != nil || s.findUser(cmd.Email) != nil {
+		return nil, models.ErrUserAlreadyExists
+	}
+
+	orgID := cmd.OrgId
+	if !cmd.SkipOrgSetup {
+		if orgID != 0 {
+			if _, ok := s.orgs[orgID]; !ok {
+				return nil, models.ErrOrgNotFound
+			}
+		} else {
+			orgName := cmd.OrgName
+			if orgName == "" {
+				orgName = util.StringsFallback2(cmd.Email, cmd.Login)
+			}
+			org := s.findOrgByName(orgName)
+			if org == nil {
+				org = s.createOrg(orgName)
+			}
+			orgID = org.Id
+		}
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Id:            s.nextID(),
+		Email:         cmd.Email,
+		Name:          cmd.Name,
+		Login:         cmd.Login,
+		Company:       cmd.Company,
+		EmailVerified: cmd.EmailVerified,
+		IsDisabled:    cmd.IsDisabled,
+		IsAdmin:       cmd.IsAdmin,
+		OrgId:         orgID,
+		Created:       now,
+		Updated:       now,
+		LastSeenAt:    now.AddDate(-10, 0, 0),
+	}
+	s.users[user.Id] = user
+
+	if !cmd.SkipOrgSetup {
+		role := models.ROLE_ADMIN
+		if cmd.DefaultOrgRole != "" {
+			role = models.RoleType(cmd.DefaultOrgRole)
+		}
+		s.addOrgUser(orgID, user.Id, role)
+	}
+
+	result := *user
+	return &result, nil
+}
+
+// findUser returns the user with the login or email, or nil. The lock must be held.
+func (s *FakeSQLStore) findUser(loginOrEmail string) *models.User {
+	for _, user := range s.users {
+		if strings.EqualFold(user.Login, loginOrEmail) || strings.EqualFold(user.Email, loginOrEmail) {
+			return user
+		}
+	}
+	return nil
+}
+
+// GetUserByLogin looks up a user created with CreateUser by login or email.
+func (s *FakeSQLStore) GetUserByLogin(query *models.GetUserByLoginQuery) error {
+	if query.LoginOrEmail == "" {
+		return models.ErrUserNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.findUser(query.LoginOrEmail)
+	if user == nil {
+		return models.ErrUserNotFound
+	}
+	result := *user
+	query.Result = &result
+	return nil
+}
+
+// GetUserById looks up a user created with CreateUser by ID.
+func (s *FakeSQLStore) GetUserById(query *models.GetUserByIdQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[query.Id]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+	result := *user
+	query.Result = &result
+	return nil
+}
+
+func (s *FakeSQLStore) GetSignedInUserWithCache(query *models.GetSignedInUserQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var user *models.User
+	switch {
+	case query.UserId > 0:
+		user = s.users[query.UserId]
+	case query.Login != "":
+		user = s.findUser(query.Login)
+	case query.Email != "":
+		user = s.findUser(query.Email)
+	}
+	if user == nil {
+		return models.ErrUserNotFound
+	}
+
+	signedInUser := &models.SignedInUser{
+		UserId:         user.Id,
+		OrgId:          user.OrgId,
+		Login:          user.Login,
+		Name:           user.Name,
+		Email:          user.Email,
+		IsGrafanaAdmin: user.IsAdmin,
+		HelpFlags1:     user.HelpFlags1,
+		LastSeenAt:     user.LastSeenAt,
+		Teams:          []int64{},
+	}
+	if query.OrgId > 0 {
+		signedInUser.OrgId = query.OrgId
+	}
+	if orgUser := s.findOrgUser(signedInUser.OrgId, user.Id); orgUser != nil {
+		signedInUser.OrgRole = orgUser.Role
+		signedInUser.OrgName = s.orgs[orgUser.OrgId].Name
+	} else {
+		signedInUser.OrgId = -1
+		signedInUser.OrgName = "Org missing"
+	}
+	for _, orgUser := range s.orgUsers {
+		if orgUser.UserId == user.Id {
+			signedInUser.OrgCount++
+		}
+	}
+	for _, member := range s.teamMembers {
+		if member.OrgId == signedInUser.OrgId && member.UserId == user.Id {
+			signedInUser.Teams = append(signedInUser.Teams, member.TeamId)
+		}
+	}
+
+	query.Result = signedInUser
+	return nil
+}
+
+func (s *FakeSQLStore) UpdateUserPermissions(userID int64, isAdmin bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+	if !isAdmin && user.IsAdmin {
+		admins := 0
+		for _, u := range s.users {
+			if u.IsAdmin {
+				admins++
+			}
+		}
+		if admins <= 1 {
+			return models.ErrLastGrafanaAdmin
+		}
+	}
+	user.IsAdmin = isAdmin
+	return nil
+}
+
+// NewSession returns a session of an in-memory database, see sessionEngine. The rows written with
+// sessions aren't returned by the other methods of the store.
+func (s *FakeSQLStore) NewSession() *sqlstore.DBSession {
+	return s.sessions.newSession()
+}
+
+// WithDbSession calls callback with a session of the in-memory database of the store.
+func (s *FakeSQLStore) WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	return s.sessions.withDbSession(callback)
+}
+
+// WithTransactionalDbSession calls callback with a session of the in-memory database of the store
+// in a transaction, and rolls it back if callback fails, or if committing fails with CommitError.
+func (s *FakeSQLStore) WithTransactionalDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	return s.sessions.withTransactionalDbSession(callback, s.CommitError)
+}
+
+// InTransaction calls fn, and rolls back its writes if it fails, or if committing fails with
+// CommitError. The writes of other goroutines while fn runs are rolled back too.
+func (s *FakeSQLStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.mu.Lock()
+	snapshot := s.fakeTables.clone()
+	s.mu.Unlock()
+
+	err := fn(ctx)
+	if err == nil && s.CommitError == nil {
+		return nil
+	}
+	if err == nil {
+		err = s.CommitError
+	}
+
+	s.mu.Lock()
+	s.fakeTables = snapshot
+	s.mu.Unlock()
+	return err
+}
+
+// countRows returns the number of rows of the table target matching the org and user IDs, like
+// the COUNT queries of the quotas. IDs of 0 match every row, and tables the fake doesn't keep have
+// no rows.
+func (s *FakeSQLStore) countRows(target string, orgID, userID int64) int64 {
+	var count int64
+	match := func(rowOrgID, rowUserID int64) {
+		if (orgID == 0 || rowOrgID == orgID) && (userID == 0 || rowUserID == userID) {
+			count++
+		}
+	}
+
+	switch target {
+	case "user":
+		for _, user := range s.users {
+			match(user.OrgId, 0)
+		}
+	case "org":
+		for range s.orgs {
+			match(0, 0)
+		}
+	case "org_user":
+		for _, orgUser := range s.orgUsers {
+			match(orgUser.OrgId, orgUser.UserId)
+		}
+	case "team":
+		for _, team := range s.teams {
+			match(team.OrgId, 0)
+		}
+	case "dashboard":
+		for _, dash := range s.dashboards {
+			match(dash.OrgId, 0)
+		}
+	case "data_source":
+		for _, ds := range s.datasources {
+			match(ds.OrgId, 0)
+		}
+	case "alert":
+		for _, alerts := range s.alerts {
+			for _, alert := range alerts {
+				match(alert.OrgId, 0)
+			}
+		}
+	}
+	return count
+}
+
+// GetOrgQuotaByTarget returns the number of rows of the target in the org, with the default limit,
+// as the fake doesn't store quotas.
+func (s *FakeSQLStore) GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query.Result = &models.OrgQuotaDTO{
+		OrgId:  query.OrgId,
+		Target: query.Target,
+		Limit:  query.Default,
+		Used:   s.countRows(query.Target, query.OrgId, 0),
+	}
+	return nil
+}
+
+func (s *FakeSQLStore) GetOrgQuotas(query *models.GetOrgQuotasQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quotas := setting.Quota.Org.ToMap()
+	targets := make([]string, 0, len(quotas))
+	for target := range quotas {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	query.Result = make([]*models.OrgQuotaDTO, 0, len(targets))
+	for _, target := range targets {
+		query.Result = append(query.Result, &models.OrgQuotaDTO{
+			OrgId:  query.OrgId,
+			Target: target,
+			Limit:  quotas[target],
+			Used:   s.countRows(target, query.OrgId, 0),
+		})
+	}
+	return nil
+}
+
+// GetUserQuotaByTarget returns the number of rows of the target of the user, with the default
+// limit, as the fake doesn't store quotas.
+func (s *FakeSQLStore) GetUserQuotaByTarget(query *models.GetUserQuotaByTargetQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query.Result = &models.UserQuotaDTO{
+		UserId: query.UserId,
+		Target: query.Target,
+		Limit:  query.Default,
+		Used:   s.countRows(query.Target, 0, query.UserId),
+	}
+	return nil
+}
+
+func (s *FakeSQLStore) GetUserQuotas(query *models.GetUserQuotasQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quotas := setting.Quota.User.ToMap()
+	targets := make([]string, 0, len(quotas))
+	for target := range quotas {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	query.Result = make([]*models.UserQuotaDTO, 0, len(targets))
+	for _, target := range targets {
+		query.Result = append(query.Result, &models.UserQuotaDTO{
+			UserId: query.UserId,
+			Target: target,
+			Limit:  quotas[target],
+			Used:   s.countRows(target, 0, query.UserId),
+		})
+	}
+	return nil
+}
+
+// GetGlobalQuotaByTarget returns the number of rows of the target, with the default limit, as the
+// fake doesn't store quotas.
+func (s *FakeSQLStore) GetGlobalQuotaByTarget(query *models.GetGlobalQuotaByTargetQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query.Result = &models.GlobalQuotaDTO{
+		Target: query.Target,
+		Limit:  query.Default,
+		Used:   s.countRows(query.Target, 0, 0),
+	}
+	return nil
+}
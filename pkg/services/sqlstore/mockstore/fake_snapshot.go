@@ -0,0 +1,184 @@
+package mockstore
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// FakeSnapshot is the state of a FakeSQLStore, see FakeSQLStore.Export. Its rows are sorted by ID,
+// so snapshots marshaled to JSON can be compared with golden files.
+type FakeSnapshot struct {
+	Users                 []*models.User                  `json:"users,omitempty"`
+	Orgs                  []*models.Org                   `json:"orgs,omitempty"`
+	OrgUsers              []*models.OrgUser               `json:"orgUsers,omitempty"`
+	Teams                 []*models.Team                  `json:"teams,omitempty"`
+	TeamMembers           []*models.TeamMember            `json:"teamMembers,omitempty"`
+	Dashboards            []*models.Dashboard             `json:"dashboards,omitempty"`
+	DashboardACLs         []*models.DashboardAcl          `json:"dashboardAcls,omitempty"`
+	DashboardProvisioning []*models.DashboardProvisioning `json:"dashboardProvisioning,omitempty"`
+	Alerts                []*models.Alert                 `json:"alerts,omitempty"`
+	AlertNotifications    []*models.AlertNotification     `json:"alertNotifications,omitempty"`
+	DataSources           []*models.DataSource            `json:"dataSources,omitempty"`
+	PluginSettings        []*models.PluginSetting         `json:"pluginSettings,omitempty"`
+	Preferences           []*models.Preferences           `json:"preferences,omitempty"`
+}
+
+// Export returns the state of the store, to assert the state after a service operation.
+func (s *FakeSQLStore) Export() *FakeSnapshot {
+	s.mu.Lock()
+	t := s.fakeTables.clone()
+	s.mu.Unlock()
+
+	snapshot := &FakeSnapshot{
+		OrgUsers:       t.orgUsers,
+		TeamMembers:    t.teamMembers,
+		PluginSettings: t.pluginSettings,
+		Preferences:    t.preferences,
+	}
+	for _, user := range t.users {
+		snapshot.Users = append(snapshot.Users, user)
+	}
+	for _, org := range t.orgs {
+		snapshot.Orgs = append(snapshot.Orgs, org)
+	}
+	for _, team := range t.teams {
+		snapshot.Teams = append(snapshot.Teams, team)
+	}
+	for _, dash := range t.dashboards {
+		snapshot.Dashboards = append(snapshot.Dashboards, dash)
+	}
+	for _, acl := range t.dashboardACLs {
+		snapshot.DashboardACLs = append(snapshot.DashboardACLs, acl...)
+	}
+	for _, provisioning := range t.provisioning {
+		snapshot.DashboardProvisioning = append(snapshot.DashboardProvisioning, provisioning)
+	}
+	for _, alerts := range t.alerts {
+		snapshot.Alerts = append(snapshot.Alerts, alerts...)
+	}
+	for _, notification := range t.alertNotifications {
+		snapshot.AlertNotifications = append(snapshot.AlertNotifications, notification)
+	}
+	for _, ds := range t.datasources {
+		snapshot.DataSources = append(snapshot.DataSources, ds)
+	}
+
+	sort.Slice(snapshot.Users, func(i, j int) bool { return snapshot.Users[i].Id < snapshot.Users[j].Id })
+	sort.Slice(snapshot.Orgs, func(i, j int) bool { return snapshot.Orgs[i].Id < snapshot.Orgs[j].Id })
+	sort.Slice(snapshot.OrgUsers, func(i, j int) bool { return snapshot.OrgUsers[i].Id < snapshot.OrgUsers[j].Id })
+	sort.Slice(snapshot.Teams, func(i, j int) bool { return snapshot.Teams[i].Id < snapshot.Teams[j].Id })
+	sort.Slice(snapshot.TeamMembers, func(i, j int) bool { return snapshot.TeamMembers[i].Id < snapshot.TeamMembers[j].Id })
+	sort.Slice(snapshot.Dashboards, func(i, j int) bool { return snapshot.Dashboards[i].Id < snapshot.Dashboards[j].Id })
+	sort.Slice(snapshot.DashboardACLs, func(i, j int) bool { return snapshot.DashboardACLs[i].Id < snapshot.DashboardACLs[j].Id })
+	sort.Slice(snapshot.DashboardProvisioning, func(i, j int) bool {
+		return snapshot.DashboardProvisioning[i].Id < snapshot.DashboardProvisioning[j].Id
+	})
+	sort.Slice(snapshot.Alerts, func(i, j int) bool { return snapshot.Alerts[i].Id < snapshot.Alerts[j].Id })
+	sort.Slice(snapshot.AlertNotifications, func(i, j int) bool {
+		return snapshot.AlertNotifications[i].Id < snapshot.AlertNotifications[j].Id
+	})
+	sort.Slice(snapshot.DataSources, func(i, j int) bool { return snapshot.DataSources[i].Id < snapshot.DataSources[j].Id })
+	sort.Slice(snapshot.PluginSettings, func(i, j int) bool { return snapshot.PluginSettings[i].Id < snapshot.PluginSettings[j].Id })
+	sort.Slice(snapshot.Preferences, func(i, j int) bool { return snapshot.Preferences[i].Id < snapshot.Preferences[j].Id })
+	return snapshot
+}
+
+// Import replaces the state of the store with a snapshot, to set up the orgs, users, teams and
+// dashboards a test needs at once. The IDs of the rows created afterwards are greater than the IDs
+// of the snapshot.
+func (s *FakeSQLStore) Import(snapshot *FakeSnapshot) {
+	t := NewFakeSQLStore().fakeTables
+	maxID := func(id int64) {
+		if id > t.lastID {
+			t.lastID = id
+		}
+	}
+
+	for _, user := range snapshot.Users {
+		u := *user
+		t.users[u.Id] = &u
+		maxID(u.Id)
+	}
+	for _, org := range snapshot.Orgs {
+		o := *org
+		t.orgs[o.Id] = &o
+		maxID(o.Id)
+	}
+	for _, orgUser := range snapshot.OrgUsers {
+		ou := *orgUser
+		t.orgUsers = append(t.orgUsers, &ou)
+		maxID(ou.Id)
+	}
+	for _, team := range snapshot.Teams {
+		tm := *team
+		t.teams[tm.Id] = &tm
+		maxID(tm.Id)
+	}
+	for _, member := range snapshot.TeamMembers {
+		m := *member
+		t.teamMembers = append(t.teamMembers, &m)
+		maxID(m.Id)
+	}
+	for _, dash := range snapshot.Dashboards {
+		d := *dash
+		t.dashboards[d.Id] = &d
+		maxID(d.Id)
+	}
+	for _, item := range snapshot.DashboardACLs {
+		i := *item
+		t.dashboardACLs[i.DashboardID] = append(t.dashboardACLs[i.DashboardID], &i)
+		maxID(i.Id)
+	}
+	for _, provisioning := range snapshot.DashboardProvisioning {
+		p := *provisioning
+		t.provisioning[p.DashboardId] = &p
+		maxID(p.Id)
+	}
+	for _, alert := range snapshot.Alerts {
+		a := *alert
+		t.alerts[a.DashboardId] = append(t.alerts[a.DashboardId], &a)
+		maxID(a.Id)
+	}
+	for _, notification := range snapshot.AlertNotifications {
+		n := *notification
+		t.alertNotifications[n.Id] = &n
+		maxID(n.Id)
+	}
+	for _, ds := range snapshot.DataSources {
+		d := *ds
+		t.datasources[d.Id] = &d
+		maxID(d.Id)
+	}
+	for _, setting := range snapshot.PluginSettings {
+		ps := *setting
+		t.pluginSettings = append(t.pluginSettings, &ps)
+		maxID(ps.Id)
+	}
+	for _, prefs := range snapshot.Preferences {
+		p := *prefs
+		t.preferences = append(t.preferences, &p)
+		maxID(p.Id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fakeTables = t
+}
+
+// ExportJSON returns the state of the store as indented JSON, see Export.
+func (s *FakeSQLStore) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(s.Export(), "", "  ")
+}
+
+// ImportJSON replaces the state of the store with a snapshot in JSON, like a golden file written by
+// ExportJSON, see Import.
+func (s *FakeSQLStore) ImportJSON(data []byte) error {
+	var snapshot FakeSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	s.Import(&snapshot)
+	return nil
+}
@@ -0,0 +1,53 @@
+package mockstore
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeSQLStoreSnapshot(t *testing.T) {
+	golden, err := ioutil.ReadFile("testdata/fake_snapshot.json")
+	require.NoError(t, err)
+
+	s := NewFakeSQLStore()
+	require.NoError(t, s.ImportJSON(golden))
+
+	query := &models.GetSignedInUserQuery{Login: "bob"}
+	require.NoError(t, s.GetSignedInUserWithCache(query))
+	require.Equal(t, models.ROLE_EDITOR, query.Result.OrgRole)
+	require.Equal(t, "Main Org.", query.Result.OrgName)
+	require.Equal(t, []int64{6}, query.Result.Teams)
+
+	dash, err := s.GetDashboard(0, 1, "home", "")
+	require.NoError(t, err)
+	require.Equal(t, int64(8), dash.FolderId)
+
+	// Rows created after the import don't reuse the IDs of the snapshot.
+	user, err := s.CreateUser(context.Background(), models.CreateUserCommand{Login: "alice", OrgId: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), user.Id)
+	_, err = s.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		FolderId:  8,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"uid": "home", "title": "Home", "version": 1}),
+	})
+	require.NoError(t, err)
+
+	snapshot := s.Export()
+	require.Len(t, snapshot.Users, 3)
+	require.Equal(t, "alice", snapshot.Users[2].Login)
+	require.Equal(t, 2, snapshot.Dashboards[1].Version)
+
+	exported, err := s.ExportJSON()
+	require.NoError(t, err)
+	copied := NewFakeSQLStore()
+	require.NoError(t, copied.ImportJSON(exported))
+	reexported, err := copied.ExportJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, string(exported), string(reexported))
+}
@@ -0,0 +1,82 @@
+package mockstore
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// clone returns a copy of the tables, sharing no rows with them, to roll back transactions.
+func (t fakeTables) clone() fakeTables {
+	c := fakeTables{
+		lastID:             t.lastID,
+		users:              make(map[int64]*models.User, len(t.users)),
+		orgs:               make(map[int64]*models.Org, len(t.orgs)),
+		teams:              make(map[int64]*models.Team, len(t.teams)),
+		dashboards:         make(map[int64]*models.Dashboard, len(t.dashboards)),
+		dashboardACLs:      make(map[int64][]*models.DashboardAcl, len(t.dashboardACLs)),
+		provisioning:       make(map[int64]*models.DashboardProvisioning, len(t.provisioning)),
+		alerts:             make(map[int64][]*models.Alert, len(t.alerts)),
+		alertNotifications: make(map[int64]*models.AlertNotification, len(t.alertNotifications)),
+		datasources:        make(map[int64]*models.DataSource, len(t.datasources)),
+	}
+	for id, user := range t.users {
+		u := *user
+		c.users[id] = &u
+	}
+	for id, org := range t.orgs {
+		o := *org
+		c.orgs[id] = &o
+	}
+	for _, orgUser := range t.orgUsers {
+		ou := *orgUser
+		c.orgUsers = append(c.orgUsers, &ou)
+	}
+	for id, team := range t.teams {
+		tm := *team
+		c.teams[id] = &tm
+	}
+	for _, member := range t.teamMembers {
+		m := *member
+		c.teamMembers = append(c.teamMembers, &m)
+	}
+	for id, dash := range t.dashboards {
+		d := *dash
+		c.dashboards[id] = &d
+	}
+	for id, acl := range t.dashboardACLs {
+		items := make([]*models.DashboardAcl, 0, len(acl))
+		for _, item := range acl {
+			i := *item
+			items = append(items, &i)
+		}
+		c.dashboardACLs[id] = items
+	}
+	for id, provisioning := range t.provisioning {
+		p := *provisioning
+		c.provisioning[id] = &p
+	}
+	for id, alerts := range t.alerts {
+		list := make([]*models.Alert, 0, len(alerts))
+		for _, alert := range alerts {
+			a := *alert
+			list = append(list, &a)
+		}
+		c.alerts[id] = list
+	}
+	for id, notification := range t.alertNotifications {
+		n := *notification
+		c.alertNotifications[id] = &n
+	}
+	for id, ds := range t.datasources {
+		d := *ds
+		c.datasources[id] = &d
+	}
+	for _, setting := range t.pluginSettings {
+		ps := *setting
+		c.pluginSettings = append(c.pluginSettings, &ps)
+	}
+	for _, prefs := range t.preferences {
+		p := *prefs
+		c.preferences = append(c.preferences, &p)
+	}
+	return c
+}
@@ -0,0 +1,165 @@
+package mockstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeSQLStore(t *testing.T) {
+	t.Run("users round-trip", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		user, err := s.CreateUser(context.Background(), models.CreateUserCommand{Login: "bob", Name: "Bob"})
+		require.NoError(t, err)
+		require.Equal(t, "bob", user.Email)
+
+		_, err = s.CreateUser(context.Background(), models.CreateUserCommand{Login: "BOB"})
+		require.Equal(t, models.ErrUserAlreadyExists, err)
+
+		query := &models.GetUserByLoginQuery{LoginOrEmail: "bob"}
+		require.NoError(t, s.GetUserByLogin(query))
+		require.Equal(t, user.Id, query.Result.Id)
+		require.Equal(t, "Bob", query.Result.Name)
+
+		org, err := s.GetOrgByName("bob")
+		require.NoError(t, err)
+		require.Equal(t, org.Id, user.OrgId)
+
+		signedIn := &models.GetSignedInUserQuery{Login: "bob"}
+		require.NoError(t, s.GetSignedInUserWithCache(signedIn))
+		require.Equal(t, models.ROLE_ADMIN, signedIn.Result.OrgRole)
+		require.Equal(t, "bob", signedIn.Result.OrgName)
+		require.Equal(t, 1, signedIn.Result.OrgCount)
+
+		require.Equal(t, models.ErrUserNotFound, s.GetUserByLogin(&models.GetUserByLoginQuery{LoginOrEmail: "alice"}))
+	})
+
+	t.Run("dashboards round-trip", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		saved, err := s.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "My Dashboard"}),
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, saved.Version)
+		require.NotEmpty(t, saved.Uid)
+
+		dash, err := s.GetDashboard(0, 1, saved.Uid, "")
+		require.NoError(t, err)
+		require.Equal(t, saved.Id, dash.Id)
+		require.Equal(t, "my-dashboard", dash.Slug)
+
+		_, err = s.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"id": saved.Id, "title": "Renamed", "version": 0}),
+		})
+		require.Equal(t, models.ErrDashboardVersionMismatch, err)
+
+		updated, err := s.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"id": saved.Id, "title": "Renamed", "version": 1}),
+		})
+		require.NoError(t, err)
+		require.Equal(t, saved.Uid, updated.Uid)
+		require.Equal(t, 2, updated.Version)
+
+		_, err = s.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": "Renamed"}),
+		})
+		require.Equal(t, models.ErrDashboardWithSameNameInFolderExists, err)
+
+		_, err = s.GetDashboard(0, 2, saved.Uid, "")
+		require.Equal(t, models.ErrDashboardNotFound, err)
+		_, err = s.GetDashboard(0, 1, "", "")
+		require.Equal(t, models.ErrDashboardIdentifierNotSet, err)
+	})
+
+	t.Run("team membership is tracked", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		user, err := s.CreateUser(context.Background(), models.CreateUserCommand{Login: "bob"})
+		require.NoError(t, err)
+		team, err := s.CreateTeam("editors", "", user.OrgId)
+		require.NoError(t, err)
+		_, err = s.CreateTeam("editors", "", user.OrgId)
+		require.Equal(t, models.ErrTeamNameTaken, err)
+
+		require.NoError(t, s.AddTeamMember(user.Id, user.OrgId, team.Id, false, models.PERMISSION_ADMIN))
+		require.Equal(t, models.ErrTeamMemberAlreadyAdded,
+			s.AddTeamMember(user.Id, user.OrgId, team.Id, false, models.PERMISSION_ADMIN))
+		require.Equal(t, models.ErrTeamNotFound, s.AddTeamMember(user.Id, user.OrgId, 1000, false, 0))
+
+		members := &models.GetTeamMembersQuery{OrgId: user.OrgId, TeamId: team.Id}
+		require.NoError(t, s.GetTeamMembers(members))
+		require.Len(t, members.Result, 1)
+		require.Equal(t, "bob", members.Result[0].Login)
+
+		signedIn := &models.GetSignedInUserQuery{UserId: user.Id}
+		require.NoError(t, s.GetSignedInUserWithCache(signedIn))
+		require.Equal(t, []int64{team.Id}, signedIn.Result.Teams)
+	})
+
+	t.Run("data sources round-trip", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		cmd := &models.AddDataSourceCommand{OrgId: 1, Name: "prometheus", Type: "prometheus"}
+		require.NoError(t, s.AddDataSource(cmd))
+
+		ds, err := s.GetDataSource("", 0, "prometheus", 1)
+		require.NoError(t, err)
+		require.Equal(t, cmd.Result.Uid, ds.Uid)
+
+		deleted, err := s.DeleteDataSource(ds.Uid, 0, "", 1)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+		_, err = s.GetDataSource("", ds.Id, "", 1)
+		require.Equal(t, models.ErrDataSourceNotFound, err)
+	})
+
+	t.Run("preferences are merged", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		require.NoError(t, s.SavePreferences(&models.SavePreferencesCommand{OrgId: 1, Theme: "dark", Timezone: "utc"}))
+		require.NoError(t, s.SavePreferences(&models.SavePreferencesCommand{OrgId: 1, TeamId: 2, Timezone: "browser"}))
+		require.NoError(t, s.SavePreferences(&models.SavePreferencesCommand{OrgId: 1, UserId: 3, Theme: "light"}))
+
+		query := &models.GetPreferencesWithDefaultsQuery{User: &models.SignedInUser{OrgId: 1, UserId: 3, Teams: []int64{2}}}
+		require.NoError(t, s.GetPreferencesWithDefaults(query))
+		require.Equal(t, "light", query.Result.Theme)
+		require.Equal(t, "browser", query.Result.Timezone)
+	})
+
+	t.Run("last admin can't be removed", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		user, err := s.CreateUser(context.Background(), models.CreateUserCommand{Login: "admin", IsAdmin: true})
+		require.NoError(t, err)
+		require.Equal(t, models.ErrLastGrafanaAdmin, s.UpdateUserPermissions(user.Id, false))
+	})
+
+	t.Run("quotas count rows", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		user, err := s.CreateUser(context.Background(), models.CreateUserCommand{Login: "bob"})
+		require.NoError(t, err)
+		for _, title := range []string{"one", "two"} {
+			_, err := s.SaveDashboard(models.SaveDashboardCommand{
+				OrgId:     user.OrgId,
+				Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title}),
+			})
+			require.NoError(t, err)
+		}
+
+		orgQuery := &models.GetOrgQuotaByTargetQuery{OrgId: user.OrgId, Target: "dashboard", Default: 10}
+		require.NoError(t, s.GetOrgQuotaByTarget(orgQuery))
+		require.Equal(t, int64(2), orgQuery.Result.Used)
+		require.Equal(t, int64(10), orgQuery.Result.Limit)
+
+		userQuery := &models.GetUserQuotaByTargetQuery{UserId: user.Id, Target: "org_user", Default: 10}
+		require.NoError(t, s.GetUserQuotaByTarget(userQuery))
+		require.Equal(t, int64(1), userQuery.Result.Used)
+
+		globalQuery := &models.GetGlobalQuotaByTargetQuery{Target: "dashboard"}
+		require.NoError(t, s.GetGlobalQuotaByTarget(globalQuery))
+		require.Equal(t, int64(2), globalQuery.Result.Used)
+	})
+}
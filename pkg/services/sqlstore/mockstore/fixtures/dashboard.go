@@ -0,0 +1,111 @@
+package fixtures
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// DashboardBuilder builds dashboards and folders, see Dashboard.
+type DashboardBuilder struct {
+	dash models.Dashboard
+	tags []string
+}
+
+// Dashboard returns a builder of a dashboard of org 1 with the title "Dashboard", in the General
+// folder.
+func Dashboard() *DashboardBuilder {
+	return &DashboardBuilder{dash: models.Dashboard{Title: "Dashboard", OrgId: 1}}
+}
+
+// Folder returns a builder of a folder of org 1 with the title "Folder".
+func Folder() *DashboardBuilder {
+	return &DashboardBuilder{dash: models.Dashboard{Title: "Folder", OrgId: 1, IsFolder: true}}
+}
+
+func (b *DashboardBuilder) WithID(id int64) *DashboardBuilder {
+	b.dash.Id = id
+	return b
+}
+
+func (b *DashboardBuilder) WithUID(uid string) *DashboardBuilder {
+	b.dash.Uid = uid
+	return b
+}
+
+func (b *DashboardBuilder) WithTitle(title string) *DashboardBuilder {
+	b.dash.Title = title
+	return b
+}
+
+func (b *DashboardBuilder) WithOrg(orgID int64) *DashboardBuilder {
+	b.dash.OrgId = orgID
+	return b
+}
+
+func (b *DashboardBuilder) WithVersion(version int) *DashboardBuilder {
+	b.dash.Version = version
+	return b
+}
+
+func (b *DashboardBuilder) WithTags(tags ...string) *DashboardBuilder {
+	b.tags = tags
+	return b
+}
+
+// InFolder puts the dashboard in the folder with the ID.
+func (b *DashboardBuilder) InFolder(folderID int64) *DashboardBuilder {
+	b.dash.FolderId = folderID
+	return b
+}
+
+// FromPlugin makes the dashboard a dashboard of the plugin.
+func (b *DashboardBuilder) FromPlugin(pluginID string) *DashboardBuilder {
+	b.dash.PluginId = pluginID
+	return b
+}
+
+// Build returns the dashboard, with its JSON model set from its fields.
+func (b *DashboardBuilder) Build() *models.Dashboard {
+	dash := b.dash
+	dash.Data = b.data()
+	dash.UpdateSlug()
+	dash.Created = time.Now()
+	dash.Updated = dash.Created
+	return &dash
+}
+
+// SaveCommand returns the command saving the dashboard.
+func (b *DashboardBuilder) SaveCommand() models.SaveDashboardCommand {
+	return models.SaveDashboardCommand{
+		Dashboard: b.data(),
+		OrgId:     b.dash.OrgId,
+		FolderId:  b.dash.FolderId,
+		IsFolder:  b.dash.IsFolder,
+		PluginId:  b.dash.PluginId,
+	}
+}
+
+func (b *DashboardBuilder) data() *simplejson.Json {
+	data := simplejson.New()
+	data.Set("title", b.dash.Title)
+	data.Set("version", b.dash.Version)
+	if b.dash.Id != 0 {
+		data.Set("id", b.dash.Id)
+	}
+	if b.dash.Uid != "" {
+		data.Set("uid", b.dash.Uid)
+	}
+	if len(b.tags) > 0 {
+		tags := make([]interface{}, 0, len(b.tags))
+		for _, tag := range b.tags {
+			tags = append(tags, tag)
+		}
+		data.Set("tags", tags)
+	}
+	if b.dash.IsFolder {
+		data.Set("schemaVersion", 17)
+	}
+	return data
+}
@@ -0,0 +1,92 @@
+package fixtures
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// DataSourceBuilder builds data sources, see DataSource.
+type DataSourceBuilder struct {
+	ds models.DataSource
+}
+
+// DataSource returns a builder of a Prometheus data source of org 1, named "prometheus" and proxied
+// by the server.
+func DataSource() *DataSourceBuilder {
+	return &DataSourceBuilder{ds: models.DataSource{
+		Id:       1,
+		OrgId:    1,
+		Version:  1,
+		Name:     "prometheus",
+		Type:     models.DS_PROMETHEUS,
+		Access:   models.DS_ACCESS_PROXY,
+		Url:      "http://localhost:9090",
+		JsonData: simplejson.New(),
+	}}
+}
+
+func (b *DataSourceBuilder) WithID(id int64) *DataSourceBuilder {
+	b.ds.Id = id
+	return b
+}
+
+func (b *DataSourceBuilder) WithUID(uid string) *DataSourceBuilder {
+	b.ds.Uid = uid
+	return b
+}
+
+func (b *DataSourceBuilder) WithName(name string) *DataSourceBuilder {
+	b.ds.Name = name
+	return b
+}
+
+func (b *DataSourceBuilder) WithType(dsType string) *DataSourceBuilder {
+	b.ds.Type = dsType
+	return b
+}
+
+func (b *DataSourceBuilder) WithURL(url string) *DataSourceBuilder {
+	b.ds.Url = url
+	return b
+}
+
+func (b *DataSourceBuilder) WithOrg(orgID int64) *DataSourceBuilder {
+	b.ds.OrgId = orgID
+	return b
+}
+
+// WithJSONData sets a key of the JSON data of the data source.
+func (b *DataSourceBuilder) WithJSONData(key string, value interface{}) *DataSourceBuilder {
+	data := simplejson.New()
+	for k, v := range b.ds.JsonData.MustMap() {
+		data.Set(k, v)
+	}
+	data.Set(key, value)
+	b.ds.JsonData = data
+	return b
+}
+
+// Default makes the data source the default of its org.
+func (b *DataSourceBuilder) Default() *DataSourceBuilder {
+	b.ds.IsDefault = true
+	return b
+}
+
+func (b *DataSourceBuilder) Build() *models.DataSource {
+	ds := b.ds
+	return &ds
+}
+
+// AddCommand returns the command adding the data source.
+func (b *DataSourceBuilder) AddCommand() *models.AddDataSourceCommand {
+	return &models.AddDataSourceCommand{
+		Name:      b.ds.Name,
+		Type:      b.ds.Type,
+		Access:    b.ds.Access,
+		Url:       b.ds.Url,
+		IsDefault: b.ds.IsDefault,
+		JsonData:  b.ds.JsonData,
+		Uid:       b.ds.Uid,
+		OrgId:     b.ds.OrgId,
+	}
+}
@@ -0,0 +1,9 @@
+// Package fixtures provides builders of the models used with mockstore, so tests build users,
+// dashboards and other models with consistent defaults and only set what they test:
+//
+//	user := fixtures.User().WithOrg(2).Admin().Build()
+//	dash := fixtures.Dashboard().WithTitle("Home").InFolder(folder.Id).Build()
+//
+// The builders of the models created by commands also build the commands, to create the models in
+// a mockstore.FakeSQLStore.
+package fixtures
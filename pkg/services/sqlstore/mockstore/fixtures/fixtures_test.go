@@ -0,0 +1,54 @@
+package fixtures
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/mockstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser(t *testing.T) {
+	user := User().WithID(2).WithLogin("bob").WithOrg(2).Admin().Build()
+	require.Equal(t, &models.User{Id: 2, Login: "bob", Email: "bob@example.com", Name: "User", OrgId: 2, IsAdmin: true}, user)
+
+	signedIn := User().WithOrg(2).WithRole(models.ROLE_EDITOR).BuildSignedIn()
+	require.Equal(t, int64(2), signedIn.OrgId)
+	require.Equal(t, models.ROLE_EDITOR, signedIn.OrgRole)
+}
+
+func TestDashboard(t *testing.T) {
+	dash := Dashboard().WithID(3).WithUID("abc").WithTitle("My Dashboard").InFolder(2).WithTags("a", "b").Build()
+	require.Equal(t, int64(3), dash.Id)
+	require.Equal(t, "my-dashboard", dash.Slug)
+	require.Equal(t, int64(2), dash.FolderId)
+	require.Equal(t, "abc", dash.Data.Get("uid").MustString())
+	require.Equal(t, []string{"a", "b"}, dash.GetTags())
+
+	require.True(t, Folder().Build().IsFolder)
+}
+
+func TestCommands(t *testing.T) {
+	store := mockstore.NewFakeSQLStore()
+
+	org, err := store.CreateOrgWithMember("Main Org.", 0)
+	require.NoError(t, err)
+	user, err := store.CreateUser(context.Background(), User().WithLogin("bob").WithOrg(org.Id).WithRole(models.ROLE_EDITOR).CreateCommand())
+	require.NoError(t, err)
+	query := &models.GetSignedInUserQuery{UserId: user.Id}
+	require.NoError(t, store.GetSignedInUserWithCache(query))
+	require.Equal(t, models.ROLE_EDITOR, query.Result.OrgRole)
+
+	folder, err := store.SaveDashboard(Folder().WithOrg(org.Id).SaveCommand())
+	require.NoError(t, err)
+	dash, err := store.SaveDashboard(Dashboard().WithOrg(org.Id).InFolder(folder.Id).SaveCommand())
+	require.NoError(t, err)
+	require.Equal(t, folder.Id, dash.FolderId)
+
+	cmd := DataSource().WithOrg(org.Id).WithJSONData("httpMethod", "POST").AddCommand()
+	require.NoError(t, store.AddDataSource(cmd))
+	ds, err := store.GetDataSource("", 0, "prometheus", org.Id)
+	require.NoError(t, err)
+	require.Equal(t, "POST", ds.JsonData.Get("httpMethod").MustString())
+}
@@ -0,0 +1,30 @@
+package fixtures
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// OrgBuilder builds orgs, see Org.
+type OrgBuilder struct {
+	org models.Org
+}
+
+// Org returns a builder of the org 1, named "Main Org.".
+func Org() *OrgBuilder {
+	return &OrgBuilder{org: models.Org{Id: 1, Version: 1, Name: "Main Org."}}
+}
+
+func (b *OrgBuilder) WithID(id int64) *OrgBuilder {
+	b.org.Id = id
+	return b
+}
+
+func (b *OrgBuilder) WithName(name string) *OrgBuilder {
+	b.org.Name = name
+	return b
+}
+
+func (b *OrgBuilder) Build() *models.Org {
+	org := b.org
+	return &org
+}
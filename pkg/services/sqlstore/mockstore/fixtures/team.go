@@ -0,0 +1,40 @@
+package fixtures
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// TeamBuilder builds teams, see Team.
+type TeamBuilder struct {
+	team models.Team
+}
+
+// Team returns a builder of the team 1 of org 1, named "team".
+func Team() *TeamBuilder {
+	return &TeamBuilder{team: models.Team{Id: 1, OrgId: 1, Name: "team"}}
+}
+
+func (b *TeamBuilder) WithID(id int64) *TeamBuilder {
+	b.team.Id = id
+	return b
+}
+
+func (b *TeamBuilder) WithName(name string) *TeamBuilder {
+	b.team.Name = name
+	return b
+}
+
+func (b *TeamBuilder) WithEmail(email string) *TeamBuilder {
+	b.team.Email = email
+	return b
+}
+
+func (b *TeamBuilder) WithOrg(orgID int64) *TeamBuilder {
+	b.team.OrgId = orgID
+	return b
+}
+
+func (b *TeamBuilder) Build() *models.Team {
+	team := b.team
+	return &team
+}
@@ -0,0 +1,95 @@
+package fixtures
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// UserBuilder builds users, see User.
+type UserBuilder struct {
+	user models.User
+	role models.RoleType
+}
+
+// User returns a builder of a viewer of org 1 with the login "user".
+func User() *UserBuilder {
+	return &UserBuilder{
+		user: models.User{Id: 1, Login: "user", Email: "user@example.com", Name: "User", OrgId: 1},
+		role: models.ROLE_VIEWER,
+	}
+}
+
+func (b *UserBuilder) WithID(id int64) *UserBuilder {
+	b.user.Id = id
+	return b
+}
+
+// WithLogin sets the login of the user, and the email to the login at example.com.
+func (b *UserBuilder) WithLogin(login string) *UserBuilder {
+	b.user.Login = login
+	b.user.Email = login + "@example.com"
+	return b
+}
+
+func (b *UserBuilder) WithEmail(email string) *UserBuilder {
+	b.user.Email = email
+	return b
+}
+
+func (b *UserBuilder) WithName(name string) *UserBuilder {
+	b.user.Name = name
+	return b
+}
+
+func (b *UserBuilder) WithOrg(orgID int64) *UserBuilder {
+	b.user.OrgId = orgID
+	return b
+}
+
+// WithRole sets the role of the user in their org.
+func (b *UserBuilder) WithRole(role models.RoleType) *UserBuilder {
+	b.role = role
+	return b
+}
+
+// Admin makes the user a Grafana admin.
+func (b *UserBuilder) Admin() *UserBuilder {
+	b.user.IsAdmin = true
+	return b
+}
+
+func (b *UserBuilder) Disabled() *UserBuilder {
+	b.user.IsDisabled = true
+	return b
+}
+
+func (b *UserBuilder) Build() *models.User {
+	user := b.user
+	return &user
+}
+
+// BuildSignedIn returns the signed in user of the user, with their role in their org.
+func (b *UserBuilder) BuildSignedIn() *models.SignedInUser {
+	return &models.SignedInUser{
+		UserId:         b.user.Id,
+		OrgId:          b.user.OrgId,
+		OrgRole:        b.role,
+		Login:          b.user.Login,
+		Name:           b.user.Name,
+		Email:          b.user.Email,
+		IsGrafanaAdmin: b.user.IsAdmin,
+		Teams:          []int64{},
+	}
+}
+
+// CreateCommand returns the command creating the user in their org, with their role.
+func (b *UserBuilder) CreateCommand() models.CreateUserCommand {
+	return models.CreateUserCommand{
+		Login:          b.user.Login,
+		Email:          b.user.Email,
+		Name:           b.user.Name,
+		OrgId:          b.user.OrgId,
+		IsAdmin:        b.user.IsAdmin,
+		IsDisabled:     b.user.IsDisabled,
+		DefaultOrgRole: string(b.role),
+	}
+}
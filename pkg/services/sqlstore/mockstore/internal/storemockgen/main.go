@@ -0,0 +1,420 @@
+// Command storemockgen generates the methods of mockstore.SQLStoreMock from the sqlstore.Store
+// interface and the interfaces it embeds. Every method records its call and returns the error of
+// the call, and the results and query results of methods are the Expected* fields of SQLStoreMock
+// of the same type, or zero values when the mock has no field of the type. Calls answered by a
+// response queued with OnCall return its values, and calls delegated to the Fallback store return
+// its results instead.
+//
+// Run it with go generate in the mockstore package.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options are the inputs and output of the generator.
+type Options struct {
+	// StoreFile is the file declaring the Store interface.
+	StoreFile string
+	// MockFile is the file declaring the SQLStoreMock struct.
+	MockFile string
+	// ModelsDir is the directory of the models package, for the Result fields of queries.
+	ModelsDir string
+}
+
+func main() {
+	var opts Options
+	var output string
+	flag.StringVar(&opts.StoreFile, "store", "../store.go", "file declaring the Store interface")
+	flag.StringVar(&opts.MockFile, "mock", "mockstore.go", "file declaring the SQLStoreMock struct")
+	flag.StringVar(&opts.ModelsDir, "models", "../../../models", "directory of the models package")
+	flag.StringVar(&output, "output", "mockstore_gen.go", "file to write the generated methods to")
+	flag.Parse()
+
+	src, err := Generate(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "storemockgen: %s\n", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(output, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "storemockgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Generate returns the source of the SQLStoreMock methods.
+func Generate(opts Options) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	storeFile, err := parser.ParseFile(fset, opts.StoreFile, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	store := findType(storeFile, "Store")
+	if store == nil {
+		return nil, fmt.Errorf("%s doesn't declare the Store type", opts.StoreFile)
+	}
+	iface, ok := store.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil, fmt.Errorf("Store isn't an interface")
+	}
+
+	mockFile, err := parser.ParseFile(fset, opts.MockFile, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := expectedFields(mockFile)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResults, err := queryResultTypes(fset, opts.ModelsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		pkg:          storeFile.Name.Name,
+		expected:     expected,
+		queryResults: queryResults,
+		imports:      map[string]string{},
+	}
+	for _, imp := range storeFile.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := filepath.Base(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		g.imports[name] = path
+	}
+
+	methods, err := interfaceMethods(storeFile, iface)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	for _, method := range methods {
+		g.writeMethod(&body, method.Names[0].Name, method.Type.(*ast.FuncType))
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by storemockgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", mockFile.Name.Name)
+	names := make([]string, 0, len(g.used))
+	for name := range g.used {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return g.imports[names[i]] < g.imports[names[j]] })
+	for i, name := range names {
+		path := g.imports[name]
+		// Standard library packages are grouped first, like goimports does.
+		if i > 0 && isStdlib(g.imports[names[i-1]]) && !isStdlib(path) {
+			out.WriteString("\n")
+		}
+		if filepath.Base(path) == name {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&out, "\t%s %q\n", name, path)
+		}
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+func isStdlib(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// interfaceMethods returns the methods of an interface, including the methods of the interfaces
+// it embeds, in order. Embedded interfaces must be declared in file.
+func interfaceMethods(file *ast.File, iface *ast.InterfaceType) ([]*ast.Field, error) {
+	var methods []*ast.Field
+	for _, method := range iface.Methods.List {
+		if _, ok := method.Type.(*ast.FuncType); ok {
+			methods = append(methods, method)
+			continue
+		}
+		ident, ok := method.Type.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("Store embeds an interface of another package, which isn't supported")
+		}
+		embedded := findType(file, ident.Name)
+		if embedded == nil {
+			return nil, fmt.Errorf("%s isn't declared", ident.Name)
+		}
+		embeddedIface, ok := embedded.Type.(*ast.InterfaceType)
+		if !ok {
+			return nil, fmt.Errorf("%s isn't an interface", ident.Name)
+		}
+		embeddedMethods, err := interfaceMethods(file, embeddedIface)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, embeddedMethods...)
+	}
+	return methods, nil
+}
+
+func findType(file *ast.File, name string) *ast.TypeSpec {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+				return ts
+			}
+		}
+	}
+	return nil
+}
+
+// expectedFields returns the Expected* fields of SQLStoreMock by type.
+func expectedFields(file *ast.File) (map[string]string, error) {
+	mock := findType(file, "SQLStoreMock")
+	if mock == nil {
+		return nil, fmt.Errorf("SQLStoreMock isn't declared")
+	}
+	st, ok := mock.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("SQLStoreMock isn't a struct")
+	}
+
+	fields := map[string]string{}
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if !strings.HasPrefix(name.Name, "Expected") || name.Name == "ExpectedError" {
+				continue
+			}
+			typ := exprString(field.Type)
+			if other, ok := fields[typ]; ok {
+				return nil, fmt.Errorf("%s and %s have the same type %s", other, name.Name, typ)
+			}
+			fields[typ] = name.Name
+		}
+	}
+	return fields, nil
+}
+
+// queryResultTypes returns the types of the Result fields of the structs of the models package.
+func queryResultTypes(fset *token.FileSet, dir string) (map[string]string, error) {
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]string{}
+	for name, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					for _, field := range st.Fields.List {
+						for _, fieldName := range field.Names {
+							if fieldName.Name == "Result" {
+								results[name+"."+ts.Name.Name] = exprString(qualify(field.Type, name))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+type generator struct {
+	pkg          string
+	expected     map[string]string
+	queryResults map[string]string
+	imports      map[string]string
+	used         map[string]bool
+}
+
+func (g *generator) writeMethod(w *bytes.Buffer, name string, fn *ast.FuncType) {
+	var params, args, delegateArgs []string
+	var queries []query
+	for i, field := range fn.Params.List {
+		typ := g.typeString(field.Type)
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+		}
+		for _, n := range names {
+			params = append(params, n.Name+" "+typ)
+			args = append(args, n.Name)
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				delegateArgs = append(delegateArgs, n.Name+"...")
+			} else {
+				delegateArgs = append(delegateArgs, n.Name)
+			}
+			if star, ok := field.Type.(*ast.StarExpr); ok {
+				if result, ok := g.queryResults[exprString(star.X)]; ok {
+					queries = append(queries, query{name: n.Name, expected: g.expected[result]})
+				}
+			}
+		}
+	}
+
+	var results []string
+	if fn.Results != nil {
+		for _, field := range fn.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				results = append(results, g.typeString(field.Type))
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\nfunc (m *SQLStoreMock) %s(%s) ", name, strings.Join(params, ", "))
+	if len(results) == 1 {
+		fmt.Fprintf(w, "%s ", results[0])
+	} else if len(results) > 1 {
+		fmt.Fprintf(w, "(%s) ", strings.Join(results, ", "))
+	}
+	w.WriteString("{\n")
+
+	fmt.Fprintf(w, "\tc := m.call(%s)\n", strings.Join(append([]string{strconv.Quote(name)}, args...), ", "))
+	delegate := fmt.Sprintf("m.Fallback.%s(%s)", name, strings.Join(delegateArgs, ", "))
+	if len(results) > 0 {
+		delegate = "return " + delegate
+	} else {
+		delegate += "\n\t\treturn"
+	}
+	fmt.Fprintf(w, "\tif c.fallback {\n\t\t%s\n\t}\n", delegate)
+
+	// The results start with the Expected* fields, or zero values, and are replaced by the queued
+	// response of the call, if any.
+	var targets []string
+	for _, q := range queries {
+		if q.expected != "" {
+			fmt.Fprintf(w, "\t%s.Result = m.%s\n", q.name, q.expected)
+		}
+		targets = append(targets, "&"+q.name+".Result")
+	}
+	var values []string
+	for i, typ := range results {
+		v := fmt.Sprintf("r%d", i)
+		switch {
+		case typ == "error" && i == len(results)-1:
+			v = "err"
+			fmt.Fprintf(w, "\terr := c.err\n")
+		case g.expected[typ] != "":
+			fmt.Fprintf(w, "\t%s := m.%s\n", v, g.expected[typ])
+		case g.expected["*"+typ] != "":
+			fmt.Fprintf(w, "\tvar %s %s\n\tif m.%s != nil {\n\t\t%s = *m.%s\n\t}\n", v, typ, g.expected["*"+typ], v, g.expected["*"+typ])
+		default:
+			fmt.Fprintf(w, "\tvar %s %s\n", v, typ)
+		}
+		values = append(values, v)
+		targets = append(targets, "&"+v)
+	}
+	if len(targets) > 0 {
+		fmt.Fprintf(w, "\tc.respond(%s)\n", strings.Join(targets, ", "))
+	}
+	if len(values) > 0 {
+		fmt.Fprintf(w, "\treturn %s\n", strings.Join(values, ", "))
+	}
+	w.WriteString("}\n")
+}
+
+// query is a query parameter of a method, whose Result is set to the expected field, if any.
+type query struct {
+	name     string
+	expected string
+}
+
+// typeString returns the source of a type of the Store interface as used in the mockstore package,
+// and records the imports it needs.
+func (g *generator) typeString(expr ast.Expr) string {
+	expr = qualify(expr, g.pkg)
+	if g.used == nil {
+		g.used = map[string]bool{}
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				g.used[id.Name] = true
+			}
+			return false
+		}
+		return true
+	})
+	if g.used[g.pkg] && g.imports[g.pkg] == "" {
+		g.imports[g.pkg] = "github.com/grafana/grafana/pkg/services/" + g.pkg
+	}
+	return exprString(expr)
+}
+
+// qualify returns a copy of a type expression with the types declared in pkg qualified by the
+// package name.
+func qualify(expr ast.Expr, pkg string) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(e.Name) {
+			return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: ast.NewIdent(e.Name)}
+		}
+		return e
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(e.X, pkg)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: qualify(e.Elt, pkg)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualify(e.Key, pkg), Value: qualify(e.Value, pkg)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: qualify(e.Elt, pkg)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: e.Dir, Value: qualify(e.Value, pkg)}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: qualifyFields(e.Params, pkg), Results: qualifyFields(e.Results, pkg)}
+	default:
+		return expr
+	}
+}
+
+func qualifyFields(fields *ast.FieldList, pkg string) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	list := &ast.FieldList{}
+	for _, f := range fields.List {
+		list.List = append(list.List, &ast.Field{Names: f.Names, Type: qualify(f.Type, pkg)})
+	}
+	return list
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), expr); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestGeneratedMockIsUpToDate fails when the Store interface or the Expected* fields of SQLStoreMock
+// changed without running go generate in the mockstore package.
+func TestGeneratedMockIsUpToDate(t *testing.T) {
+	src, err := Generate(Options{
+		StoreFile: "../../../store.go",
+		MockFile:  "../../mockstore.go",
+		ModelsDir: "../../../../../models",
+	})
+	require.NoError(t, err)
+
+	generated, err := ioutil.ReadFile("../../mockstore_gen.go")
+	require.NoError(t, err)
+	require.Equal(t, string(generated), string(src), "mockstore_gen.go is out of date, run go generate in pkg/services/sqlstore/mockstore")
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	src := `package sqlstore
+
+type Store interface {
+	UserStore
+	InTransaction() error
+}
+
+type UserStore interface {
+	CreateUser() error
+	GetUser() error
+}
+
+type NotAnInterface struct{}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "store.go", src, 0)
+	require.NoError(t, err)
+
+	methods, err := interfaceMethods(file, findType(file, "Store").Type.(*ast.InterfaceType))
+	require.NoError(t, err)
+	var names []string
+	for _, method := range methods {
+		names = append(names, method.Names[0].Name)
+	}
+	require.Equal(t, []string{"CreateUser", "GetUser", "InTransaction"}, names)
+
+	for embedded, want := range map[string]string{
+		"OrgStore":       "OrgStore isn't declared",
+		"NotAnInterface": "NotAnInterface isn't an interface",
+		"io.Closer":      "Store embeds an interface of another package, which isn't supported",
+	} {
+		file, err := parser.ParseFile(token.NewFileSet(), "store.go", src+"\ntype Broken interface{ "+embedded+" }\n", 0)
+		require.NoError(t, err)
+		_, err = interfaceMethods(file, findType(file, "Broken").Type.(*ast.InterfaceType))
+		require.EqualError(t, err, want)
+	}
+}
@@ -0,0 +1,112 @@
+// Package mockstore provides a mock of sqlstore.Store for testing services without a database.
+//
+// The methods of SQLStoreMock are generated from the Store interface, run go generate after changing it.
+//
+//go:generate go run ./internal/storemockgen -store ../store.go -mock mockstore.go -models ../../../models -output mockstore_gen.go
+package mockstore
+
+import (
+	"reflect"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// SQLStoreMock is a mock of sqlstore.Store. Queries return, or set as the Result of their query, the
+// Expected* value of their result type, and calls return ExpectedError, unless a matching
+// expectation registered with Expect overrides the error, or a response queued or a handler
+// registered with OnCall overrides both. InTransaction, WithDbSession and WithTransactionalDbSession
+// run their callback when they don't fail. The mock is safe for concurrent use, once its Expected*
+// fields are set.
+type SQLStoreMock struct {
+	ExpectedUser                   *models.User
+	ExpectedSignedInUser           *models.SignedInUser
+	ExpectedDatasource             *models.DataSource
+	ExpectedOrg                    *models.Org
+	ExpectedTeam                   *models.Team
+	ExpectedDashboard              *models.Dashboard
+	ExpectedDashboardProvisioning  *models.DashboardProvisioning
+	ExpectedDashboardProvisionings []*models.DashboardProvisioning
+	ExpectedAlertNotificationUID   string
+	ExpectedPluginSettings         []*models.PluginSettingInfoDTO
+	ExpectedPreferences            *models.Preferences
+	ExpectedError                  error
+
+	// CommitError, if not nil, makes InTransaction and WithTransactionalDbSession fail with it when
+	// committing, after their callback succeeded.
+	CommitError error
+
+	// Quotas are the limits and usages of quota targets returned by the quota queries, by target.
+	// The limit of a target without a quota is the default of the query, and its usage is 0.
+	Quotas map[string]Quota
+
+	// Fallback is the store calls that don't match any expectation are delegated to, if not nil,
+	// so only the calls under test need to be mocked and the others can use a test database.
+	Fallback sqlstore.Store
+
+	expectations expectations
+	sessions     sessionEngine
+}
+
+var _ sqlstore.Store = (*SQLStoreMock)(nil)
+
+func NewSQLStoreMock() *SQLStoreMock {
+	return &SQLStoreMock{}
+}
+
+// callResult is how the mock answers a call.
+type callResult struct {
+	method   string
+	args     []interface{}
+	fallback bool
+	err      error
+	response []interface{}
+	handler  reflect.Value
+}
+
+// call records a call of method and returns the error it should fail with and the response queued
+// for it or its handler, if any, or whether the call should be delegated to Fallback.
+func (m *SQLStoreMock) call(method string, args ...interface{}) callResult {
+	exp, response, handler := m.expectations.called(method, args)
+	c := callResult{method: method, args: args, err: m.ExpectedError, response: response, handler: handler}
+	switch {
+	case response != nil || handler.IsValid():
+	case exp == nil && m.Fallback != nil:
+		c.fallback = true
+	case exp != nil && exp.err != nil:
+		c.err = exp.err
+	}
+	if c.err == nil && c.response == nil && !c.handler.IsValid() && !c.fallback {
+		if handler := m.defaultHandler(method); handler != nil {
+			c.handler = reflect.ValueOf(handler)
+		}
+	}
+	return c
+}
+
+// defaultHandler returns the handler of the calls of method that aren't answered otherwise, or nil.
+// The methods running callbacks run them, so the code under test isn't skipped, NewSession returns
+// a session of an in-memory database, see sessionEngine, and the quota queries return Quotas.
+func (m *SQLStoreMock) defaultHandler(method string) interface{} {
+	switch method {
+	case "NewSession":
+		return m.sessions.newSession
+	case "InTransaction":
+		return m.inTransaction
+	case "WithDbSession":
+		return m.withDbSession
+	case "WithTransactionalDbSession":
+		return m.withTransactionalDbSession
+	case "GetOrgQuotaByTarget":
+		return m.getOrgQuotaByTarget
+	case "GetOrgQuotas":
+		return m.getOrgQuotas
+	case "GetUserQuotaByTarget":
+		return m.getUserQuotaByTarget
+	case "GetUserQuotas":
+		return m.getUserQuotas
+	case "GetGlobalQuotaByTarget":
+		return m.getGlobalQuotaByTarget
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package mockstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLStoreMockExpectedResults(t *testing.T) {
+	m := NewSQLStoreMock()
+	m.ExpectedOrg = &models.Org{Id: 1, Name: "Main Org."}
+	m.ExpectedTeam = &models.Team{Id: 2, Name: "editors"}
+	m.ExpectedDashboard = &models.Dashboard{Id: 3, Uid: "abc"}
+	m.ExpectedSignedInUser = &models.SignedInUser{UserId: 4, Login: "bob"}
+	m.ExpectedPreferences = &models.Preferences{Theme: "dark"}
+	m.ExpectedAlertNotificationUID = "notifier"
+
+	org, err := m.GetOrgByName("Main Org.")
+	require.NoError(t, err)
+	require.Equal(t, m.ExpectedOrg, org)
+	created, err := m.CreateOrgWithMember("Main Org.", 4)
+	require.NoError(t, err)
+	require.Equal(t, *m.ExpectedOrg, created)
+
+	team, err := m.CreateTeam("editors", "", 1)
+	require.NoError(t, err)
+	require.Equal(t, *m.ExpectedTeam, team)
+
+	dash, err := m.GetDashboard(0, 1, "abc", "")
+	require.NoError(t, err)
+	require.Equal(t, m.ExpectedDashboard, dash)
+
+	userQuery := &models.GetSignedInUserQuery{Login: "bob"}
+	require.NoError(t, m.GetSignedInUserWithCache(userQuery))
+	require.Equal(t, m.ExpectedSignedInUser, userQuery.Result)
+
+	prefsQuery := &models.GetPreferencesWithDefaultsQuery{User: m.ExpectedSignedInUser}
+	require.NoError(t, m.GetPreferencesWithDefaults(prefsQuery))
+	require.Equal(t, m.ExpectedPreferences, prefsQuery.Result)
+
+	uidQuery := &models.GetAlertNotificationUidQuery{Id: 5, OrgId: 1}
+	require.NoError(t, m.GetAlertNotificationUidWithId(uidQuery))
+	require.Equal(t, "notifier", uidQuery.Result)
+
+	m.ExpectedError = errors.New("boom")
+	_, err = m.GetOrgByName("Main Org.")
+	require.Equal(t, m.ExpectedError, err)
+}
+
+func TestSQLStoreMockFallback(t *testing.T) {
+	m := NewSQLStoreMock()
+	m.Fallback = NewFakeSQLStore()
+	m.ExpectedError = errors.New("not delegated")
+	m.Expect("CreateTeam", "editors", "", int64(1)).Return(models.ErrTeamNameTaken)
+
+	user, err := m.CreateUser(context.Background(), models.CreateUserCommand{Login: "bob"})
+	require.NoError(t, err)
+	query := &models.GetSignedInUserQuery{UserId: user.Id}
+	require.NoError(t, m.GetSignedInUserWithCache(query))
+	require.Equal(t, "bob", query.Result.Login)
+
+	_, err = m.CreateTeam("editors", "", 1)
+	require.Equal(t, models.ErrTeamNameTaken, err)
+	_, err = m.CreateTeam("viewers", "", 1)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, m.CallCount("CreateTeam"))
+	require.Equal(t, 1, m.CallCount("CreateUser"))
+}
@@ -0,0 +1,67 @@
+package mockstore
+
+import (
+	"sort"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Quota is the limit and usage of a quota target, see SQLStoreMock.Quotas.
+type Quota struct {
+	Limit int64
+	Used  int64
+}
+
+// quota returns the quota of target, with the default limit if it isn't set.
+func (m *SQLStoreMock) quota(target string, defaultLimit int64) Quota {
+	if q, ok := m.Quotas[target]; ok {
+		return q
+	}
+	return Quota{Limit: defaultLimit}
+}
+
+// quotaTargets returns the targets of Quotas, sorted.
+func (m *SQLStoreMock) quotaTargets() []string {
+	targets := make([]string, 0, len(m.Quotas))
+	for target := range m.Quotas {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (m *SQLStoreMock) getOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
+	q := m.quota(query.Target, query.Default)
+	query.Result = &models.OrgQuotaDTO{OrgId: query.OrgId, Target: query.Target, Limit: q.Limit, Used: q.Used}
+	return nil
+}
+
+func (m *SQLStoreMock) getOrgQuotas(query *models.GetOrgQuotasQuery) error {
+	query.Result = []*models.OrgQuotaDTO{}
+	for _, target := range m.quotaTargets() {
+		q := m.Quotas[target]
+		query.Result = append(query.Result, &models.OrgQuotaDTO{OrgId: query.OrgId, Target: target, Limit: q.Limit, Used: q.Used})
+	}
+	return nil
+}
+
+func (m *SQLStoreMock) getUserQuotaByTarget(query *models.GetUserQuotaByTargetQuery) error {
+	q := m.quota(query.Target, query.Default)
+	query.Result = &models.UserQuotaDTO{UserId: query.UserId, Target: query.Target, Limit: q.Limit, Used: q.Used}
+	return nil
+}
+
+func (m *SQLStoreMock) getUserQuotas(query *models.GetUserQuotasQuery) error {
+	query.Result = []*models.UserQuotaDTO{}
+	for _, target := range m.quotaTargets() {
+		q := m.Quotas[target]
+		query.Result = append(query.Result, &models.UserQuotaDTO{UserId: query.UserId, Target: target, Limit: q.Limit, Used: q.Used})
+	}
+	return nil
+}
+
+func (m *SQLStoreMock) getGlobalQuotaByTarget(query *models.GetGlobalQuotaByTargetQuery) error {
+	q := m.quota(query.Target, query.Default)
+	query.Result = &models.GlobalQuotaDTO{Target: query.Target, Limit: q.Limit, Used: q.Used}
+	return nil
+}
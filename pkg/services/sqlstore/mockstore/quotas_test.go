@@ -0,0 +1,39 @@
+package mockstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLStoreMockQuotas(t *testing.T) {
+	m := NewSQLStoreMock()
+	m.Quotas = map[string]Quota{
+		"dashboard":   {Limit: 10, Used: 10},
+		"data_source": {Limit: -1, Used: 3},
+	}
+
+	orgQuery := &models.GetOrgQuotaByTargetQuery{OrgId: 1, Target: "dashboard", Default: 100}
+	require.NoError(t, m.GetOrgQuotaByTarget(orgQuery))
+	require.Equal(t, &models.OrgQuotaDTO{OrgId: 1, Target: "dashboard", Limit: 10, Used: 10}, orgQuery.Result)
+
+	userQuery := &models.GetUserQuotaByTargetQuery{UserId: 2, Target: "org_user", Default: 5}
+	require.NoError(t, m.GetUserQuotaByTarget(userQuery))
+	require.Equal(t, &models.UserQuotaDTO{UserId: 2, Target: "org_user", Limit: 5}, userQuery.Result)
+
+	globalQuery := &models.GetGlobalQuotaByTargetQuery{Target: "data_source", Default: 100}
+	require.NoError(t, m.GetGlobalQuotaByTarget(globalQuery))
+	require.Equal(t, &models.GlobalQuotaDTO{Target: "data_source", Limit: -1, Used: 3}, globalQuery.Result)
+
+	orgQuotas := &models.GetOrgQuotasQuery{OrgId: 1}
+	require.NoError(t, m.GetOrgQuotas(orgQuotas))
+	require.Len(t, orgQuotas.Result, 2)
+	require.Equal(t, "dashboard", orgQuotas.Result[0].Target)
+	require.Equal(t, "data_source", orgQuotas.Result[1].Target)
+
+	m.ExpectedError = errors.New("boom")
+	require.Equal(t, m.ExpectedError, m.GetOrgQuotaByTarget(&models.GetOrgQuotaByTargetQuery{Target: "dashboard"}))
+	require.Equal(t, 2, m.CallCount("GetOrgQuotaByTarget"))
+}
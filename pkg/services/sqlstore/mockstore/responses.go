@@ -0,0 +1,128 @@
+package mockstore
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Responses queues the responses of the calls of a method, see SQLStoreMock.OnCall.
+type Responses struct {
+	m      *SQLStoreMock
+	method string
+}
+
+// OnCall returns the responses of the calls of method, to queue responses with ReturnOnce, like
+//
+//	m.OnCall("GetDashboard").ReturnOnce(dash, nil).ReturnOnce(nil, models.ErrDashboardNotFound)
+//
+// so the first call of GetDashboard returns dash and the second one fails, for testing retries and
+// pagination. The calls after the queued responses are answered as usual.
+func (m *SQLStoreMock) OnCall(method string) *Responses {
+	return &Responses{m: m, method: method}
+}
+
+// ReturnOnce queues the response of a call. The values are the results of the method, preceded by
+// the Result of the query for methods taking a query, like (signedInUser, nil) for
+// GetSignedInUserWithCache. nil values are the zero values of their result. Responses take
+// precedence over the Expected* fields, the errors of expectations and the Fallback store.
+func (r *Responses) ReturnOnce(values ...interface{}) *Responses {
+	e := &r.m.expectations
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.responses == nil {
+		e.responses = map[string][][]interface{}{}
+	}
+	e.responses[r.method] = append(e.responses[r.method], values)
+	return r
+}
+
+// Do registers fn as the handler of the calls of the method that don't have a queued response. fn
+// must have the signature of the method, like
+//
+//	m.OnCall("GetSignedInUserWithCache").Do(func(query *models.GetSignedInUserQuery) error {
+//		if query.OrgId != 1 {
+//			return models.ErrUserNotFound
+//		}
+//		query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: 1}
+//		return nil
+//	})
+//
+// fn is called with the arguments of each call, and its results are the results of the call. The Result of queries
+// is set to the Expected* value of its type before fn is called, so fn can change it. Handlers take
+// precedence over the Expected* fields, the errors of expectations and the Fallback store. Do
+// panics if fn doesn't have the signature of the method.
+func (r *Responses) Do(fn interface{}) *Responses {
+	method, ok := reflect.TypeOf(r.m).MethodByName(r.method)
+	if !ok {
+		panic(fmt.Sprintf("mockstore: SQLStoreMock has no method %s", r.method))
+	}
+	want := reflect.ValueOf(r.m).Method(method.Index).Type()
+	if got := reflect.TypeOf(fn); got != want {
+		panic(fmt.Sprintf("mockstore: handler of %s is a %v, want %v", r.method, got, want))
+	}
+
+	e := &r.m.expectations
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.handlers == nil {
+		e.handlers = map[string]reflect.Value{}
+	}
+	e.handlers[r.method] = reflect.ValueOf(fn)
+	return r
+}
+
+// respond sets the results of the call, pointed to by targets, to its queued response, or to the
+// results of its handler, if any. It panics if the response doesn't match the results, as the test
+// is wrong.
+func (c callResult) respond(targets ...interface{}) {
+	if c.handler.IsValid() {
+		c.handle(targets)
+		return
+	}
+	if c.response == nil {
+		return
+	}
+	if len(c.response) != len(targets) {
+		panic(fmt.Sprintf("mockstore: response of %s has %d values, want %d", c.method, len(c.response), len(targets)))
+	}
+	for i, value := range c.response {
+		target := reflect.ValueOf(targets[i]).Elem()
+		if value == nil {
+			target.Set(reflect.Zero(target.Type()))
+			continue
+		}
+		v := reflect.ValueOf(value)
+		if !v.Type().AssignableTo(target.Type()) {
+			panic(fmt.Sprintf("mockstore: value %d of the response of %s is a %s, want %s", i, c.method, v.Type(), target.Type()))
+		}
+		target.Set(v)
+	}
+}
+
+// handle calls the handler with the arguments of the call, and sets the results of the call to its
+// results. The targets of query results come first and are skipped, as the handler sets them.
+func (c callResult) handle(targets []interface{}) {
+	fnType := c.handler.Type()
+	in := make([]reflect.Value, len(c.args))
+	for i, arg := range c.args {
+		if arg == nil {
+			in[i] = reflect.Zero(fnType.In(i))
+		} else {
+			in[i] = reflect.ValueOf(arg)
+		}
+	}
+
+	var out []reflect.Value
+	if fnType.IsVariadic() {
+		out = c.handler.CallSlice(in)
+	} else {
+		out = c.handler.Call(in)
+	}
+
+	targets = targets[len(targets)-len(out):]
+	for i, v := range out {
+		reflect.ValueOf(targets[i]).Elem().Set(v)
+	}
+}
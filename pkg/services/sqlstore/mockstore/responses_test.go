@@ -0,0 +1,127 @@
+package mockstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnCall(t *testing.T) {
+	t.Run("responses are returned in order", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.ExpectedDashboard = &models.Dashboard{Id: 3}
+		dash := &models.Dashboard{Id: 1}
+		m.OnCall("GetDashboard").ReturnOnce(dash, nil).ReturnOnce(nil, models.ErrDashboardNotFound)
+
+		got, err := m.GetDashboard(1, 1, "", "")
+		require.NoError(t, err)
+		require.Equal(t, dash, got)
+
+		got, err = m.GetDashboard(1, 1, "", "")
+		require.Equal(t, models.ErrDashboardNotFound, err)
+		require.Nil(t, got)
+
+		got, err = m.GetDashboard(1, 1, "", "")
+		require.NoError(t, err)
+		require.Equal(t, m.ExpectedDashboard, got)
+		require.Equal(t, 3, m.CallCount("GetDashboard"))
+	})
+
+	t.Run("query results are set", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		user := &models.SignedInUser{UserId: 1}
+		m.OnCall("GetSignedInUserWithCache").ReturnOnce(user, nil)
+
+		query := &models.GetSignedInUserQuery{UserId: 1}
+		require.NoError(t, m.GetSignedInUserWithCache(query))
+		require.Equal(t, user, query.Result)
+	})
+
+	t.Run("responses take precedence over expectations and fallback", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.Fallback = NewFakeSQLStore()
+		m.Expect("GetOrgByName", "Main Org.").Return(errors.New("expectation"))
+		m.OnCall("GetOrgByName").ReturnOnce(&models.Org{Id: 1}, nil)
+
+		org, err := m.GetOrgByName("Main Org.")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), org.Id)
+		require.True(t, m.AssertExpectations(t))
+	})
+
+	t.Run("invalid responses panic", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.OnCall("GetOrgByName").ReturnOnce(nil).ReturnOnce(models.Org{}, nil)
+
+		require.PanicsWithValue(t, "mockstore: response of GetOrgByName has 1 values, want 2", func() {
+			_, _ = m.GetOrgByName("Main Org.")
+		})
+		require.PanicsWithValue(t, "mockstore: value 0 of the response of GetOrgByName is a models.Org, want *models.Org", func() {
+			_, _ = m.GetOrgByName("Main Org.")
+		})
+	})
+}
+
+func TestOnCallDo(t *testing.T) {
+	t.Run("handler answers calls", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		var created []models.CreateUserCommand
+		m.OnCall("CreateUser").Do(func(ctx context.Context, cmd models.CreateUserCommand) (*models.User, error) {
+			created = append(created, cmd)
+			if cmd.Login == "admin" {
+				return nil, models.ErrUserAlreadyExists
+			}
+			return &models.User{Id: int64(len(created)), Login: cmd.Login}, nil
+		})
+
+		user, err := m.CreateUser(context.Background(), models.CreateUserCommand{Login: "bob"})
+		require.NoError(t, err)
+		require.Equal(t, &models.User{Id: 1, Login: "bob"}, user)
+		_, err = m.CreateUser(context.Background(), models.CreateUserCommand{Login: "admin"})
+		require.Equal(t, models.ErrUserAlreadyExists, err)
+		require.Len(t, created, 2)
+	})
+
+	t.Run("handler changes query results", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.ExpectedSignedInUser = &models.SignedInUser{UserId: 1, OrgRole: models.ROLE_VIEWER}
+		m.OnCall("GetSignedInUserWithCache").Do(func(query *models.GetSignedInUserQuery) error {
+			if query.OrgId == 2 {
+				query.Result = &models.SignedInUser{UserId: 1, OrgId: 2, OrgRole: models.ROLE_ADMIN}
+			}
+			return nil
+		})
+
+		query := &models.GetSignedInUserQuery{UserId: 1, OrgId: 1}
+		require.NoError(t, m.GetSignedInUserWithCache(query))
+		require.Equal(t, models.ROLE_VIEWER, query.Result.OrgRole)
+
+		query = &models.GetSignedInUserQuery{UserId: 1, OrgId: 2}
+		require.NoError(t, m.GetSignedInUserWithCache(query))
+		require.Equal(t, models.ROLE_ADMIN, query.Result.OrgRole)
+	})
+
+	t.Run("queued responses take precedence", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.OnCall("GetOrgByName").
+			Do(func(name string) (*models.Org, error) { return &models.Org{Name: name}, nil }).
+			ReturnOnce(nil, models.ErrOrgNotFound)
+
+		_, err := m.GetOrgByName("Main Org.")
+		require.Equal(t, models.ErrOrgNotFound, err)
+		org, err := m.GetOrgByName("Main Org.")
+		require.NoError(t, err)
+		require.Equal(t, "Main Org.", org.Name)
+	})
+
+	t.Run("handler with the wrong signature panics", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		require.PanicsWithValue(t, "mockstore: handler of GetOrgByName is a func(string) error, want func(string) (*models.Org, error)", func() {
+			m.OnCall("GetOrgByName").Do(func(name string) error { return nil })
+		})
+		require.Panics(t, func() { m.OnCall("NoSuchMethod").Do(func() {}) })
+	})
+}
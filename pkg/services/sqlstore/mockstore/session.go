@@ -0,0 +1,73 @@
+package mockstore
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"xorm.io/xorm"
+)
+
+// sessionDBs counts the databases of the sessions, so each store has its own.
+var sessionDBs int64
+
+// sessionEngine creates the sessions of a store on an in-memory SQLite database, so code using raw
+// sessions can be tested without a database server. The database is created by the first session
+// and starts empty: tests create the tables they need, like with
+//
+//	m.NewSession().Sync2(new(models.Star))
+//
+// The database is separate from the state of the store, so rows written with sessions aren't
+// returned by the other methods of the store.
+type sessionEngine struct {
+	once   sync.Once
+	engine *xorm.Engine
+}
+
+// newSession returns a new session of the database. It panics if the database can't be opened.
+func (e *sessionEngine) newSession() *sqlstore.DBSession {
+	e.once.Do(func() {
+		// A named database with a shared cache is kept by the idle connections of the engine, unlike
+		// :memory: which is a different database for each connection.
+		name := fmt.Sprintf("file:mockstore%d?mode=memory&cache=shared", atomic.AddInt64(&sessionDBs, 1))
+		engine, err := xorm.NewEngine(migrator.SQLite, name)
+		if err != nil {
+			panic(fmt.Sprintf("mockstore: failed to open the session database: %v", err))
+		}
+		engine.SetMaxIdleConns(1)
+		engine.SetConnMaxLifetime(0)
+		e.engine = engine
+	})
+	return &sqlstore.DBSession{Session: e.engine.NewSession()}
+}
+
+// withDbSession calls callback with a new session, and closes it.
+func (e *sessionEngine) withDbSession(callback sqlstore.DBTransactionFunc) error {
+	sess := e.newSession()
+	defer sess.Close()
+
+	return callback(sess)
+}
+
+// withTransactionalDbSession calls callback with a session in a transaction, and commits it if
+// callback succeeded. The transaction is rolled back if callback fails, or if commitErr isn't nil,
+// in which case it's returned.
+func (e *sessionEngine) withTransactionalDbSession(callback sqlstore.DBTransactionFunc, commitErr error) error {
+	sess := e.newSession()
+	defer sess.Close()
+
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if err := callback(sess); err != nil {
+		_ = sess.Rollback()
+		return err
+	}
+	if commitErr != nil {
+		_ = sess.Rollback()
+		return commitErr
+	}
+	return sess.Commit()
+}
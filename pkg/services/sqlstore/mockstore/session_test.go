@@ -0,0 +1,56 @@
+package mockstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]sqlstore.Store{
+		"mock": NewSQLStoreMock(),
+		"fake": NewFakeSQLStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			sess := store.NewSession()
+			require.NotNil(t, sess)
+			require.NoError(t, sess.Sync2(new(models.Star)))
+			sess.Close()
+
+			require.NoError(t, store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				_, err := sess.Insert(&models.Star{UserId: 1, DashboardId: 2})
+				return err
+			}))
+			err := store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				if _, err := sess.Insert(&models.Star{UserId: 1, DashboardId: 3}); err != nil {
+					return err
+				}
+				return errors.New("boom")
+			})
+			require.EqualError(t, err, "boom")
+
+			require.NoError(t, store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				var stars []models.Star
+				require.NoError(t, sess.Find(&stars))
+				require.Len(t, stars, 1)
+				require.Equal(t, int64(2), stars[0].DashboardId)
+				return nil
+			}))
+		})
+	}
+
+	t.Run("stores have their own database", func(t *testing.T) {
+		sess := NewSQLStoreMock().NewSession()
+		defer sess.Close()
+		exists, err := sess.IsTableExist(new(models.Star))
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
@@ -0,0 +1,34 @@
+package mockstore
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// transactionKey marks the contexts of the transactions of the mock, so nested transactions aren't
+// committed.
+type transactionKey struct{}
+
+// inTransaction calls fn, and fails with CommitError after fn succeeded, unless the transaction is
+// nested in another one.
+func (m *SQLStoreMock) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(transactionKey{}) != nil {
+		return fn(ctx)
+	}
+	if err := fn(context.WithValue(ctx, transactionKey{}, true)); err != nil {
+		return err
+	}
+	return m.CommitError
+}
+
+// withDbSession calls callback with a session of the in-memory database of the mock.
+func (m *SQLStoreMock) withDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	return m.sessions.withDbSession(callback)
+}
+
+// withTransactionalDbSession calls callback with a session of the in-memory database of the mock
+// in a transaction, and fails with CommitError after callback succeeded.
+func (m *SQLStoreMock) withTransactionalDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	return m.sessions.withTransactionalDbSession(callback, m.CommitError)
+}
@@ -0,0 +1,102 @@
+package mockstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLStoreMockTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("callbacks are called", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		called := 0
+		require.NoError(t, m.InTransaction(ctx, func(ctx context.Context) error {
+			called++
+			return nil
+		}))
+		require.NoError(t, m.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			called++
+			return nil
+		}))
+		require.NoError(t, m.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			called++
+			return nil
+		}))
+		require.Equal(t, 3, called)
+	})
+
+	t.Run("errors of callbacks are returned", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		err := errors.New("boom")
+		require.Equal(t, err, m.InTransaction(ctx, func(ctx context.Context) error { return err }))
+	})
+
+	t.Run("commit fails with CommitError", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.CommitError = errors.New("commit failed")
+		err := m.InTransaction(ctx, func(ctx context.Context) error {
+			// Nested transactions aren't committed.
+			return m.InTransaction(ctx, func(ctx context.Context) error { return nil })
+		})
+		require.Equal(t, m.CommitError, err)
+		require.Equal(t, m.CommitError, m.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error { return nil }))
+		require.NoError(t, m.WithDbSession(ctx, func(sess *sqlstore.DBSession) error { return nil }))
+	})
+
+	t.Run("callbacks aren't called when the call fails", func(t *testing.T) {
+		m := NewSQLStoreMock()
+		m.ExpectedError = errors.New("begin failed")
+		require.Equal(t, m.ExpectedError, m.InTransaction(ctx, func(ctx context.Context) error {
+			t.Fatal("callback called")
+			return nil
+		}))
+	})
+}
+
+func TestFakeSQLStoreTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("writes are rolled back on error", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		user, err := s.CreateUser(ctx, models.CreateUserCommand{Login: "admin", IsAdmin: true})
+		require.NoError(t, err)
+
+		err = s.InTransaction(ctx, func(ctx context.Context) error {
+			if _, err := s.CreateUser(ctx, models.CreateUserCommand{Login: "bob"}); err != nil {
+				return err
+			}
+			if _, err := s.CreateUser(ctx, models.CreateUserCommand{Login: "alice", IsAdmin: true}); err != nil {
+				return err
+			}
+			if err := s.UpdateUserPermissions(user.Id, false); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		})
+		require.EqualError(t, err, "boom")
+
+		require.Equal(t, models.ErrUserNotFound, s.GetUserByLogin(&models.GetUserByLoginQuery{LoginOrEmail: "bob"}))
+		query := &models.GetUserByIdQuery{Id: user.Id}
+		require.NoError(t, s.GetUserById(query))
+		require.True(t, query.Result.IsAdmin)
+	})
+
+	t.Run("writes are rolled back when commit fails", func(t *testing.T) {
+		s := NewFakeSQLStore()
+		s.CommitError = errors.New("commit failed")
+		err := s.InTransaction(ctx, func(ctx context.Context) error {
+			_, err := s.CreateTeam("editors", "", 1)
+			return err
+		})
+		require.Equal(t, s.CommitError, err)
+
+		_, err = s.CreateTeam("editors", "", 1)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,210 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// queryTimeoutKey is used as key to save the query timeout of a session in `context.Context`
+type queryTimeoutKey struct{}
+
+// withQueryTimeout returns ctx with the time each query run with it may take before it's canceled,
+// if the query runs through a queryTimeoutDriver.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// queryContext returns the context to run a query with, which is canceled after the query timeout
+// of ctx, and whether ctx has a query timeout.
+func queryContext(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	timeout, ok := ctx.Value(queryTimeoutKey{}).(time.Duration)
+	if !ok || timeout <= 0 {
+		return ctx, func() {}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, true
+}
+
+// queryTimeoutDriver wraps a driver to cancel each query that takes longer than the query timeout
+// of its context, see withQueryTimeout. The timeout of a query includes reading its rows. The
+// transactions of the queries aren't canceled by their timeout, only by their context.
+type queryTimeoutDriver struct {
+	driver.Driver
+}
+
+func (d *queryTimeoutDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &queryTimeoutConn{Conn: conn}, nil
+}
+
+// queryTimeoutConn is a connection of a queryTimeoutDriver. It falls back to what database/sql does
+// for the optional interfaces the connection it wraps doesn't implement.
+type queryTimeoutConn struct {
+	driver.Conn
+}
+
+var (
+	_ driver.ConnBeginTx        = (*queryTimeoutConn)(nil)
+	_ driver.ConnPrepareContext = (*queryTimeoutConn)(nil)
+	_ driver.ExecerContext      = (*queryTimeoutConn)(nil)
+	_ driver.QueryerContext     = (*queryTimeoutConn)(nil)
+	_ driver.Pinger             = (*queryTimeoutConn)(nil)
+	_ driver.SessionResetter    = (*queryTimeoutConn)(nil)
+	_ driver.NamedValueChecker  = (*queryTimeoutConn)(nil)
+)
+
+func (c *queryTimeoutConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if conn, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return conn.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+		return nil, errors.New("sqlstore: driver does not support non-default transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *queryTimeoutConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if conn, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = conn.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &queryTimeoutStmt{Stmt: stmt}, nil
+}
+
+func (c *queryTimeoutConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, cancel, _ := queryContext(ctx)
+	defer cancel()
+	return conn.ExecContext(ctx, query, args)
+}
+
+func (c *queryTimeoutConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, cancel, hasTimeout := queryContext(ctx)
+	rows, err := conn.QueryContext(ctx, query, args)
+	return wrapRows(rows, err, cancel, hasTimeout)
+}
+
+func (c *queryTimeoutConn) Ping(ctx context.Context) error {
+	if conn, ok := c.Conn.(driver.Pinger); ok {
+		return conn.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *queryTimeoutConn) ResetSession(ctx context.Context) error {
+	if conn, ok := c.Conn.(driver.SessionResetter); ok {
+		return conn.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *queryTimeoutConn) CheckNamedValue(value *driver.NamedValue) error {
+	if conn, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return conn.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// queryTimeoutStmt is a prepared statement of a queryTimeoutConn.
+type queryTimeoutStmt struct {
+	driver.Stmt
+}
+
+var (
+	_ driver.StmtExecContext   = (*queryTimeoutStmt)(nil)
+	_ driver.StmtQueryContext  = (*queryTimeoutStmt)(nil)
+	_ driver.NamedValueChecker = (*queryTimeoutStmt)(nil)
+)
+
+func (s *queryTimeoutStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, cancel, _ := queryContext(ctx)
+	defer cancel()
+
+	if stmt, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return stmt.ExecContext(ctx, args)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values)
+}
+
+func (s *queryTimeoutStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, cancel, hasTimeout := queryContext(ctx)
+
+	if stmt, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err := stmt.QueryContext(ctx, args)
+		return wrapRows(rows, err, cancel, hasTimeout)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rows, err := s.Stmt.Query(values)
+	return wrapRows(rows, err, cancel, hasTimeout)
+}
+
+func (s *queryTimeoutStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if stmt, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return stmt.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sqlstore: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// wrapRows returns the rows of a query releasing its timeout once they're closed, if it has one,
+// and releases the timeout right away if the query failed.
+func wrapRows(rows driver.Rows, err error, cancel context.CancelFunc, hasTimeout bool) (driver.Rows, error) {
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if !hasTimeout {
+		return rows, nil
+	}
+	return &queryTimeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+// queryTimeoutRows are the rows of a query with a timeout.
+type queryTimeoutRows struct {
+	driver.Rows
+	cancel context.CancelFunc
+}
+
+func (r *queryTimeoutRows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
@@ -245,3 +245,28 @@ func GetGlobalQuotaByTarget(query *models.GetGlobalQuotaByTargetQuery) error {
 
 	return nil
 }
+
+// GetOrgQuotaByTarget gets the limit and usage of a quota target of an org.
+func (ss *SQLStore) GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
+	return GetOrgQuotaByTarget(query)
+}
+
+// GetOrgQuotas gets the limits and usages of the quotas of an org.
+func (ss *SQLStore) GetOrgQuotas(query *models.GetOrgQuotasQuery) error {
+	return GetOrgQuotas(query)
+}
+
+// GetUserQuotaByTarget gets the limit and usage of a quota target of a user.
+func (ss *SQLStore) GetUserQuotaByTarget(query *models.GetUserQuotaByTargetQuery) error {
+	return GetUserQuotaByTarget(query)
+}
+
+// GetUserQuotas gets the limits and usages of the quotas of a user.
+func (ss *SQLStore) GetUserQuotas(query *models.GetUserQuotasQuery) error {
+	return GetUserQuotas(query)
+}
+
+// GetGlobalQuotaByTarget gets the limit and usage of a global quota target.
+func (ss *SQLStore) GetGlobalQuotaByTarget(query *models.GetGlobalQuotaByTargetQuery) error {
+	return GetGlobalQuotaByTarget(query)
+}
@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"context"
+	"database/sql"
 	"reflect"
 
 	"xorm.io/xorm"
@@ -10,9 +11,12 @@ import (
 type DBSession struct {
 	*xorm.Session
 	events []interface{}
+	// rowsAffected is the number of rows affected by the statements run with Exec, Insert, Update
+	// and Delete of the session, for its span.
+	rowsAffected int64
 }
 
-type dbTransactionFunc func(sess *DBSession) error
+type DBTransactionFunc func(sess *DBSession) error
 
 func (sess *DBSession) publishAfterCommit(msg interface{}) {
 	sess.events = append(sess.events, msg)
@@ -36,10 +40,11 @@ func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DB
 		return sess, nil
 	}
 
-	newSess := &DBSession{Session: engine.NewSession()}
+	newSess := newSessionWithContext(ctx, engine)
 	if beginTran {
 		err := newSess.Begin()
 		if err != nil {
+			newSess.Close()
 			return nil, err
 		}
 	}
@@ -47,17 +52,64 @@ func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DB
 }
 
 // WithDbSession calls the callback with a session.
-func (ss *SQLStore) WithDbSession(ctx context.Context, callback dbTransactionFunc) error {
+func (ss *SQLStore) WithDbSession(ctx context.Context, callback DBTransactionFunc) error {
 	return withDbSession(ctx, ss.engine, callback)
 }
 
-func withDbSession(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc) error {
-	sess := &DBSession{Session: engine.NewSession()}
-	defer sess.Close()
+func withDbSession(ctx context.Context, engine *xorm.Engine, callback DBTransactionFunc) (err error) {
+	span, ctx := startSessionSpan(ctx, engine, "session")
+	sess := newSessionWithContext(ctx, engine)
+	defer func() {
+		sess.Close()
+		finishSessionSpan(span, sess, err)
+	}()
 
 	return callback(sess)
 }
 
+// newSessionWithContext returns a new session running its queries with ctx, so they're canceled
+// when ctx is, like when the request of the session is canceled. Each query is canceled after the
+// query timeout of the database configuration too, if any, see queryTimeoutDriver.
+func newSessionWithContext(ctx context.Context, engine *xorm.Engine) *DBSession {
+	if queryTimeout > 0 {
+		ctx = withQueryTimeout(ctx, queryTimeout)
+	}
+	return &DBSession{Session: engine.NewSession().Context(ctx)}
+}
+
+// Exec runs a statement, counting the rows it affected.
+func (sess *DBSession) Exec(sqlOrArgs ...interface{}) (sql.Result, error) {
+	result, err := sess.Session.Exec(sqlOrArgs...)
+	if err != nil {
+		return result, err
+	}
+	if affected, err := result.RowsAffected(); err == nil {
+		sess.rowsAffected += affected
+	}
+	return result, nil
+}
+
+// Insert inserts records, counting the rows it affected.
+func (sess *DBSession) Insert(beans ...interface{}) (int64, error) {
+	affected, err := sess.Session.Insert(beans...)
+	sess.rowsAffected += affected
+	return affected, err
+}
+
+// Update updates records, counting the rows it affected.
+func (sess *DBSession) Update(bean interface{}, condiBean ...interface{}) (int64, error) {
+	affected, err := sess.Session.Update(bean, condiBean...)
+	sess.rowsAffected += affected
+	return affected, err
+}
+
+// Delete deletes records, counting the rows it affected.
+func (sess *DBSession) Delete(bean interface{}) (int64, error) {
+	affected, err := sess.Session.Delete(bean)
+	sess.rowsAffected += affected
+	return affected, err
+}
+
 func (sess *DBSession) InsertId(bean interface{}) (int64, error) {
 	table := sess.DB().Mapper.Obj2Table(getTypeName(bean))
 
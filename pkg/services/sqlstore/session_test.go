@@ -0,0 +1,238 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/mattn/go-sqlite3"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func TestSessionContext(t *testing.T) {
+	engine, err := xorm.NewEngine(driverName(migrator.SQLite), ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = engine.Close() })
+	// Every connection to :memory: has a database of its own.
+	engine.SetMaxOpenConns(1)
+
+	query := func(sess *DBSession) error {
+		_, err := sess.Exec("SELECT 1")
+		return err
+	}
+	slowQuery := func(sess *DBSession) error {
+		_, err := sess.Exec("WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 100000000) SELECT COUNT(*) FROM c")
+		return err
+	}
+
+	t.Run("queries run with the context", func(t *testing.T) {
+		require.NoError(t, withDbSession(context.Background(), engine, query))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, withDbSession(ctx, engine, query), context.Canceled)
+		require.ErrorIs(t, inTransactionWithRetryCtx(ctx, engine, query, 0), context.Canceled)
+	})
+
+	t.Run("each query times out after the query timeout", func(t *testing.T) {
+		queryTimeout = 50 * time.Millisecond
+		t.Cleanup(func() { queryTimeout = 0 })
+
+		require.ErrorIs(t, withDbSession(context.Background(), engine, slowQuery), context.DeadlineExceeded)
+
+		run := func(sess *DBSession) error {
+			for i := 0; i < 3; i++ {
+				time.Sleep(30 * time.Millisecond)
+				var ids []int64
+				if err := sess.SQL("SELECT 1").Find(&ids); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		require.NoError(t, withDbSession(context.Background(), engine, run))
+		require.NoError(t, inTransactionWithRetryCtx(context.Background(), engine, run, 0))
+	})
+
+	t.Run("nested transactions leave the session of the outermost one open", func(t *testing.T) {
+		require.NoError(t, inTransactionWithRetryCtx(context.Background(), engine, func(sess *DBSession) error {
+			ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+			if err := inTransactionWithRetryCtx(ctx, engine, query, 0); err != nil {
+				return err
+			}
+			require.False(t, sess.IsClosed())
+			return query(sess)
+		}, 0))
+	})
+}
+
+func TestTransactionRetries(t *testing.T) {
+	engine, err := xorm.NewEngine(migrator.SQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = engine.Close() })
+
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	failing := func(failures int, calls *int) DBTransactionFunc {
+		return func(sess *DBSession) error {
+			*calls++
+			if *calls <= failures {
+				return busy
+			}
+			return nil
+		}
+	}
+
+	t.Run("retryable errors are retried", func(t *testing.T) {
+		calls := 0
+		require.NoError(t, inTransactionWithRetryCtx(context.Background(), engine, failing(2, &calls), 0))
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("retries are limited", func(t *testing.T) {
+		transactionRetries = 1
+		t.Cleanup(func() { transactionRetries = 5 })
+
+		calls := 0
+		require.Equal(t, busy, inTransactionWithRetryCtx(context.Background(), engine, failing(2, &calls), 0))
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("retryable errors at commit time are retried", func(t *testing.T) {
+		// Without a busy timeout, committing fails right away while another connection reads.
+		engine, err := xorm.NewEngine(driverName(migrator.SQLite), "file:"+filepath.Join(t.TempDir(), "grafana.db")+"?_busy_timeout=0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = engine.Close() })
+		_, err = engine.Exec("CREATE TABLE retried (id INTEGER)")
+		require.NoError(t, err)
+
+		reader, err := engine.DB().Begin()
+		require.NoError(t, err)
+		var count int
+		require.NoError(t, reader.QueryRow("SELECT COUNT(*) FROM retried").Scan(&count))
+
+		calls := 0
+		err = inTransactionWithRetryCtx(context.Background(), engine, func(sess *DBSession) error {
+			calls++
+			if calls == 2 {
+				if err := reader.Commit(); err != nil {
+					return err
+				}
+			}
+			_, err := sess.Exec("INSERT INTO retried (id) VALUES (1)")
+			return err
+		}, 0)
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+
+		require.NoError(t, engine.DB().QueryRow("SELECT COUNT(*) FROM retried").Scan(&count))
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("other errors aren't retried", func(t *testing.T) {
+		calls := 0
+		err := inTransactionWithRetryCtx(context.Background(), engine, func(sess *DBSession) error {
+			calls++
+			return errors.New("boom")
+		}, 0)
+		require.EqualError(t, err, "boom")
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("nested transactions aren't retried", func(t *testing.T) {
+		sess, err := startSession(context.Background(), engine, true)
+		require.NoError(t, err)
+		defer sess.Close()
+
+		calls := 0
+		ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+		require.Equal(t, busy, inTransactionWithRetryCtx(ctx, engine, failing(1, &calls), 0))
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for retry := 0; retry < 5; retry++ {
+		backoff := 10 * time.Millisecond << retry
+		d := retryBackoff(retry)
+		require.GreaterOrEqual(t, int64(d), int64(backoff/2))
+		require.Less(t, int64(d), int64(backoff))
+	}
+
+	for _, retry := range []int{7, 40, 64, 1000} {
+		d := retryBackoff(retry)
+		require.GreaterOrEqual(t, int64(d), int64(maxRetryBackoff/2))
+		require.Less(t, int64(d), int64(maxRetryBackoff))
+	}
+}
+
+func TestSessionTracing(t *testing.T) {
+	engine, err := xorm.NewEngine(migrator.SQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = engine.Close() })
+
+	tracer := mocktracer.New()
+	prev := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() { opentracing.SetGlobalTracer(prev) })
+
+	_, err = engine.Exec("CREATE TABLE traced (id INTEGER)")
+	require.NoError(t, err)
+
+	t.Run("sessions have spans named after their caller", func(t *testing.T) {
+		tracer.Reset()
+		parent, ctx := opentracing.StartSpanFromContext(context.Background(), "HTTP /api/dashboards")
+		require.NoError(t, insertTraced(ctx, engine))
+		parent.Finish()
+
+		spans := tracer.FinishedSpans()
+		require.Len(t, spans, 2)
+		require.Equal(t, "sqlstore - sqlstore.insertTraced", spans[0].OperationName)
+		require.Equal(t, map[string]interface{}{
+			"db.type":      "sql",
+			"db.dialect":   migrator.SQLite,
+			"db.operation": "sqlstore.insertTraced",
+			"db.session":   "session",
+			"db.rows":      int64(2),
+		}, spans[0].Tags())
+		require.Equal(t, spans[1].SpanContext.SpanID, spans[0].ParentID)
+	})
+
+	t.Run("failed transactions are marked and retries have spans of their own", func(t *testing.T) {
+		tracer.Reset()
+		calls := 0
+		require.NoError(t, inTransactionWithRetryCtx(context.Background(), engine, func(sess *DBSession) error {
+			calls++
+			if calls == 1 {
+				return sqlite3.Error{Code: sqlite3.ErrBusy}
+			}
+			ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+			return inTransactionWithRetryCtx(ctx, engine, func(sess *DBSession) error {
+				_, err := sess.Exec("DELETE FROM traced")
+				return err
+			}, 0)
+		}, 0))
+
+		spans := tracer.FinishedSpans()
+		require.Len(t, spans, 2)
+		require.Equal(t, true, spans[0].Tag("error"))
+		require.Equal(t, 0, spans[0].Tag("db.retry"))
+		require.Nil(t, spans[1].Tag("error"))
+		require.Equal(t, 1, spans[1].Tag("db.retry"))
+		require.Equal(t, "transaction", spans[1].Tag("db.session"))
+		require.Equal(t, int64(2), spans[1].Tag("db.rows"))
+	})
+}
+
+// insertTraced is a store function for TestSessionTracing.
+func insertTraced(ctx context.Context, engine *xorm.Engine) error {
+	return withDbSession(ctx, engine, func(sess *DBSession) error {
+		_, err := sess.Exec("INSERT INTO traced (id) VALUES (1), (2)")
+		return err
+	})
+}
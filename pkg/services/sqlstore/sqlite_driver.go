@@ -0,0 +1,74 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/mattn/go-sqlite3"
+	"xorm.io/core"
+)
+
+// sqliteDriverName is the name of the driver SQLite databases are opened with, see sqliteDriver. It
+// applies the query timeout of sessions too, see queryTimeoutDriver.
+const sqliteDriverName = migrator.SQLite + "WithPragmas"
+
+func init() {
+	sql.Register(sqliteDriverName, &queryTimeoutDriver{Driver: &sqliteDriver{}})
+	core.RegisterDriver(sqliteDriverName, &databaseQueryWrapperDriver{dbType: migrator.SQLite})
+}
+
+// sqliteDriver is the SQLite driver, also setting the cache size of its connections to the
+// _cache_size parameter of the connection string, which go-sqlite3 ignores unlike the parameters
+// of the other pragmas.
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	cacheSize, err := sqliteCacheSize(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cacheSize == "" {
+		return conn, nil
+	}
+
+	if _, err := conn.(*sqlite3.SQLiteConn).Exec(fmt.Sprintf("PRAGMA cache_size = %s;", cacheSize), nil); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sqliteCacheSize returns the _cache_size parameter of the connection string, or an empty string
+// if it has none.
+func sqliteCacheSize(dsn string) (string, error) {
+	pos := strings.IndexRune(dsn, '?')
+	if pos < 0 {
+		return "", nil
+	}
+
+	params, err := url.ParseQuery(dsn[pos+1:])
+	if err != nil {
+		return "", err
+	}
+
+	val := params.Get("_cache_size")
+	if val == "" {
+		return "", nil
+	}
+	if _, err := strconv.ParseInt(val, 10, 64); err != nil {
+		return "", fmt.Errorf("invalid _cache_size: %v: %w", val, err)
+	}
+	return val, nil
+}
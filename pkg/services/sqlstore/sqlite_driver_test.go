@@ -0,0 +1,42 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteDriver(t *testing.T) {
+	open := func(t *testing.T, params string) *sql.DB {
+		db, err := sql.Open(sqliteDriverName, "file:"+filepath.Join(t.TempDir(), "grafana.db")+"?mode=rwc"+params)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		return db
+	}
+
+	pragma := func(t *testing.T, db *sql.DB, name string) string {
+		var val string
+		require.NoError(t, db.QueryRow("PRAGMA "+name).Scan(&val))
+		return val
+	}
+
+	t.Run("sets the pragmas of the connection string", func(t *testing.T) {
+		db := open(t, "&_journal_mode=wal&_busy_timeout=1234&_cache_size=-4000&_synchronous=full")
+		require.Equal(t, "wal", pragma(t, db, "journal_mode"))
+		require.Equal(t, "1234", pragma(t, db, "busy_timeout"))
+		require.Equal(t, "-4000", pragma(t, db, "cache_size"))
+		require.Equal(t, "2", pragma(t, db, "synchronous"))
+	})
+
+	t.Run("keeps the default cache size", func(t *testing.T) {
+		db := open(t, "")
+		require.Equal(t, "-2000", pragma(t, db, "cache_size"))
+	})
+
+	t.Run("rejects invalid cache sizes", func(t *testing.T) {
+		db := open(t, "&_cache_size=large")
+		require.EqualError(t, db.Ping(), `invalid _cache_size: large: strconv.ParseInt: parsing "large": invalid syntax`)
+	})
+}
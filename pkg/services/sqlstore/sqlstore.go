@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -32,9 +33,21 @@ var (
 	x       *xorm.Engine
 	dialect migrator.Dialect
 
+	uidGenerator = util.DefaultUIDGenerator
+
+	// queryTimeout is the time the queries of a session may take before they're canceled, if not 0.
+	queryTimeout time.Duration
+	// transactionRetries is the number of times transactions failing with retryable errors are
+	// retried.
+	transactionRetries = 5
+
 	sqlog log.Logger = log.New("sqlstore")
 )
 
+// maxTransactionRetries is the most times transactions may be retried, which keeps the requests
+// running them from waiting for minutes.
+const maxTransactionRetries = 20
+
 // ContextSessionKey is used as key to save values in `context.Context`
 type ContextSessionKey struct{}
 
@@ -51,6 +64,10 @@ type SQLStore struct {
 	Bus          bus.Bus                  `inject:""`
 	CacheService *localcache.CacheService `inject:""`
 
+	// UIDGenerator generates the UIDs of new dashboards, data sources and alert notifications that
+	// don't have one set. Defaults to util.DefaultUIDGenerator.
+	UIDGenerator util.UIDGenerator
+
 	dbCfg                       DatabaseConfig
 	engine                      *xorm.Engine
 	log                         log.Logger
@@ -74,7 +91,9 @@ func (ss *SQLStore) Register() {
 
 func (ss *SQLStore) Init() error {
 	ss.log = log.New("sqlstore")
-	ss.readConfig()
+	if err := ss.readConfig(); err != nil {
+		return err
+	}
 
 	if err := ss.initEngine(); err != nil {
 		return errutil.Wrap("failed to connect to database", err)
@@ -82,22 +101,23 @@ func (ss *SQLStore) Init() error {
 
 	ss.Dialect = migrator.NewDialect(ss.engine)
 
+	if ss.UIDGenerator == nil {
+		ss.UIDGenerator = util.DefaultUIDGenerator
+	}
+
 	// temporarily still set global var
 	x = ss.engine
 	dialect = ss.Dialect
+	uidGenerator = ss.UIDGenerator
+	queryTimeout = time.Second * time.Duration(ss.dbCfg.QueryTimeout)
+	transactionRetries = ss.dbCfg.TransactionRetries
+	activeUserTimeLimit = defaultActiveUserTimeLimit
+	if ss.Cfg.ActiveUserWindow > 0 {
+		activeUserTimeLimit = ss.Cfg.ActiveUserWindow
+	}
 
 	if !ss.dbCfg.SkipMigrations {
-		migrator := migrator.NewMigrator(ss.engine)
-		migrations.AddMigrations(migrator)
-
-		for _, descriptor := range registry.GetServices() {
-			sc, ok := descriptor.Instance.(registry.DatabaseMigrator)
-			if ok {
-				sc.AddMigration(migrator)
-			}
-		}
-
-		if err := migrator.Start(); err != nil {
+		if err := ss.newMigrator().Start(); err != nil {
 			return err
 		}
 	}
@@ -125,6 +145,29 @@ func (ss *SQLStore) Init() error {
 	return nil
 }
 
+// newMigrator returns a migrator with the migrations of the store and of the services.
+func (ss *SQLStore) newMigrator() *migrator.Migrator {
+	mg := migrator.NewMigrator(ss.engine)
+	mg.LockMigrations = ss.dbCfg.MigrationLocking
+	mg.LockTimeout = time.Second * time.Duration(ss.dbCfg.MigrationLockTimeout)
+	migrations.AddMigrations(mg)
+
+	for _, descriptor := range registry.GetServices() {
+		sc, ok := descriptor.Instance.(registry.DatabaseMigrator)
+		if ok {
+			sc.AddMigration(mg)
+		}
+	}
+	return mg
+}
+
+// Rollback reverts the last n migrations run, so a failed upgrade can be reverted to the schema of
+// the previous version. It fails without reverting any migration if one of them can't be rolled
+// back, see migrator.ReversibleMigration.
+func (ss *SQLStore) Rollback(n int) error {
+	return ss.newMigrator().Rollback(n)
+}
+
 // Sync syncs changes to the database.
 func (ss *SQLStore) Sync() error {
 	return ss.engine.Sync2()
@@ -251,6 +294,25 @@ func (ss *SQLStore) buildConnectionString() (string, error) {
 			ss.dbCfg.User, ss.dbCfg.Pwd, addr.Host, addr.Port, ss.dbCfg.Name, ss.dbCfg.SslMode, ss.dbCfg.ClientCertPath,
 			ss.dbCfg.ClientKeyPath, ss.dbCfg.CaCertPath)
 
+		cnnstr += ss.buildExtraConnectionString(' ')
+	case migrator.Cockroach:
+		addr, err := util.SplitHostPortDefault(ss.dbCfg.Host, "127.0.0.1", "26257")
+		if err != nil {
+			return "", errutil.Wrapf(err, "Invalid host specifier '%s'", ss.dbCfg.Host)
+		}
+
+		if ss.dbCfg.Pwd == "" {
+			ss.dbCfg.Pwd = "''"
+		}
+		if ss.dbCfg.User == "" {
+			ss.dbCfg.User = "''"
+		}
+		// serial_normalization makes SERIAL columns use sequences, like in Postgres, rather than
+		// unique_rowid(), whose IDs are too large for JavaScript numbers.
+		cnnstr = fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=%s sslcert=%s sslkey=%s sslrootcert=%s serial_normalization=sql_sequence",
+			ss.dbCfg.User, ss.dbCfg.Pwd, addr.Host, addr.Port, ss.dbCfg.Name, ss.dbCfg.SslMode, ss.dbCfg.ClientCertPath,
+			ss.dbCfg.ClientKeyPath, ss.dbCfg.CaCertPath)
+
 		cnnstr += ss.buildExtraConnectionString(' ')
 	case migrator.SQLite:
 		// special case for tests
@@ -262,6 +324,18 @@ func (ss *SQLStore) buildConnectionString() (string, error) {
 		}
 
 		cnnstr = fmt.Sprintf("file:%s?cache=%s&mode=rwc", ss.dbCfg.Path, ss.dbCfg.CacheMode)
+		if ss.dbCfg.JournalMode != "" {
+			cnnstr += "&_journal_mode=" + ss.dbCfg.JournalMode
+		}
+		if ss.dbCfg.BusyTimeout > 0 {
+			cnnstr += fmt.Sprintf("&_busy_timeout=%d", ss.dbCfg.BusyTimeout)
+		}
+		if ss.dbCfg.CacheSize != 0 {
+			cnnstr += fmt.Sprintf("&_cache_size=%d", ss.dbCfg.CacheSize)
+		}
+		if ss.dbCfg.Synchronous != "" {
+			cnnstr += "&_synchronous=" + ss.dbCfg.Synchronous
+		}
 		cnnstr += ss.buildExtraConnectionString('&')
 	default:
 		return "", fmt.Errorf("unknown database type: %s", ss.dbCfg.Type)
@@ -288,7 +362,7 @@ func (ss *SQLStore) initEngine() error {
 
 	sqlog.Info("Connecting to DB", "dbtype", ss.dbCfg.Type)
 	if ss.dbCfg.Type == migrator.SQLite && strings.HasPrefix(connectionString, "file:") &&
-		!strings.HasPrefix(connectionString, "file::memory:") {
+		!strings.HasPrefix(connectionString, "file::memory:") && !strings.Contains(connectionString, "mode=memory") {
 		exists, err := fs.Exists(ss.dbCfg.Path)
 		if err != nil {
 			return errutil.Wrapf(err, "can't check for existence of %q", ss.dbCfg.Path)
@@ -316,7 +390,7 @@ func (ss *SQLStore) initEngine() error {
 			}
 		}
 	}
-	engine, err := xorm.NewEngine(ss.dbCfg.Type, connectionString)
+	engine, err := xorm.NewEngine(driverName(ss.dbCfg.Type), connectionString)
 	if err != nil {
 		return err
 	}
@@ -340,7 +414,7 @@ func (ss *SQLStore) initEngine() error {
 }
 
 // readConfig initializes the SQLStore from its configuration.
-func (ss *SQLStore) readConfig() {
+func (ss *SQLStore) readConfig() error {
 	sec := ss.Cfg.Raw.Section("database")
 
 	cfgURL := sec.Key("url").String()
@@ -373,6 +447,12 @@ func (ss *SQLStore) readConfig() {
 	ss.dbCfg.MaxOpenConn = sec.Key("max_open_conn").MustInt(0)
 	ss.dbCfg.MaxIdleConn = sec.Key("max_idle_conn").MustInt(2)
 	ss.dbCfg.ConnMaxLifetime = sec.Key("conn_max_lifetime").MustInt(14400)
+	ss.dbCfg.QueryTimeout = sec.Key("query_timeout").MustInt(0)
+	ss.dbCfg.TransactionRetries = sec.Key("transaction_retries").MustInt(5)
+	if ss.dbCfg.TransactionRetries < 0 || ss.dbCfg.TransactionRetries > maxTransactionRetries {
+		return fmt.Errorf("transaction_retries must be between 0 and %d, got %d", maxTransactionRetries,
+			ss.dbCfg.TransactionRetries)
+	}
 
 	ss.dbCfg.SslMode = sec.Key("ssl_mode").String()
 	ss.dbCfg.CaCertPath = sec.Key("ca_cert_path").String()
@@ -382,7 +462,14 @@ func (ss *SQLStore) readConfig() {
 	ss.dbCfg.Path = sec.Key("path").MustString("data/grafana.db")
 
 	ss.dbCfg.CacheMode = sec.Key("cache_mode").MustString("private")
+	ss.dbCfg.JournalMode = sec.Key("journal_mode").MustString("wal")
+	ss.dbCfg.BusyTimeout = sec.Key("busy_timeout").MustInt(5000)
+	ss.dbCfg.CacheSize = sec.Key("cache_size").MustInt(0)
+	ss.dbCfg.Synchronous = sec.Key("synchronous").String()
 	ss.dbCfg.SkipMigrations = sec.Key("skip_migrations").MustBool()
+	ss.dbCfg.MigrationLocking = sec.Key("migration_locking").MustBool(true)
+	ss.dbCfg.MigrationLockTimeout = sec.Key("migration_lock_timeout").MustInt(0)
+	return nil
 }
 
 // ITestDB is an interface of arguments for testing db
@@ -399,11 +486,22 @@ var testSQLStore *SQLStore
 type InitTestDBOpt struct {
 	// EnsureDefaultOrgAndUser flags whether to ensure that default org and user exist.
 	EnsureDefaultOrgAndUser bool
+	// UIDGenerator replaces the generator of UIDs, for example to get deterministic UIDs.
+	UIDGenerator util.UIDGenerator
 }
 
 // InitTestDB initializes the test DB.
 func InitTestDB(t ITestDB, opts ...InitTestDBOpt) *SQLStore {
 	t.Helper()
+
+	// The store is shared, so the UID generator is reset for every test.
+	uidGen := util.DefaultUIDGenerator
+	for _, opt := range opts {
+		if opt.UIDGenerator != nil {
+			uidGen = opt.UIDGenerator
+		}
+	}
+
 	if testSQLStore == nil {
 		testSQLStore = &SQLStore{}
 		testSQLStore.Bus = bus.New()
@@ -413,6 +511,7 @@ func InitTestDB(t ITestDB, opts ...InitTestDBOpt) *SQLStore {
 		for _, opt := range opts {
 			testSQLStore.skipEnsureDefaultOrgAndUser = !opt.EnsureDefaultOrgAndUser
 		}
+		testSQLStore.UIDGenerator = uidGen
 
 		dbType := migrator.SQLite
 
@@ -482,10 +581,81 @@ func InitTestDB(t ITestDB, opts ...InitTestDBOpt) *SQLStore {
 	if err := testSQLStore.Reset(); err != nil {
 		t.Fatalf("Failed to reset SQLStore: %s", err)
 	}
+	testSQLStore.UIDGenerator = uidGen
+	uidGenerator = uidGen
 
 	return testSQLStore
 }
 
+// ITestDBCleanup is an interface of arguments for testing db that can register cleanup functions.
+type ITestDBCleanup interface {
+	ITestDB
+	Cleanup(func())
+}
+
+// isolatedTestDBInUse is 1 while a store returned by InitIsolatedTestDB is in use.
+var isolatedTestDBInUse int32
+
+// InitIsolatedTestDB initializes a test DB of the given type against connStr and runs all migrations on it.
+// In contrast to InitTestDB the returned store is not shared with other tests. The package level engine
+// is restored, and the store's connection closed, when the test finishes.
+//
+// The store replaces the package level engine until then, which the package level functions use, so
+// only one isolated store can be in use at a time: the tests using it can't call t.Parallel, and
+// calling it while another isolated store is in use fails the test.
+func InitIsolatedTestDB(t ITestDBCleanup, dbType, connStr string, opts ...InitTestDBOpt) *SQLStore {
+	t.Helper()
+
+	if !atomic.CompareAndSwapInt32(&isolatedTestDBInUse, 0, 1) {
+		t.Fatalf("Another isolated test database is in use, tests using InitIsolatedTestDB can't run in parallel")
+	}
+	t.Cleanup(func() { atomic.StoreInt32(&isolatedTestDBInUse, 0) })
+
+	ss := &SQLStore{}
+	ss.Bus = bus.New()
+	ss.CacheService = localcache.New(5*time.Minute, 10*time.Minute)
+	ss.skipEnsureDefaultOrgAndUser = true
+
+	for _, opt := range opts {
+		ss.skipEnsureDefaultOrgAndUser = !opt.EnsureDefaultOrgAndUser
+		if opt.UIDGenerator != nil {
+			ss.UIDGenerator = opt.UIDGenerator
+		}
+	}
+
+	ss.Cfg = setting.NewCfg()
+	sec, err := ss.Cfg.Raw.NewSection("database")
+	if err != nil {
+		t.Fatalf("Failed to create section: %s", err)
+	}
+	if _, err := sec.NewKey("type", dbType); err != nil {
+		t.Fatalf("Failed to create key: %s", err)
+	}
+	if _, err := sec.NewKey("connection_string", connStr); err != nil {
+		t.Fatalf("Failed to create key: %s", err)
+	}
+
+	prevEngine, prevDialect, prevUIDGenerator := x, dialect, uidGenerator
+	t.Cleanup(func() {
+		// temp global vars until we get rid of global vars
+		x, dialect, uidGenerator = prevEngine, prevDialect, prevUIDGenerator
+		if ss.engine != nil {
+			if err := ss.engine.Close(); err != nil {
+				t.Logf("Failed to close test database: %s", err)
+			}
+		}
+	})
+
+	if err := ss.Init(); err != nil {
+		t.Fatalf("Failed to init test database: %s", err)
+	}
+
+	ss.engine.DatabaseTZ = time.UTC
+	ss.engine.TZLocation = time.UTC
+
+	return ss
+}
+
 func IsTestDbMySQL() bool {
 	if db, present := os.LookupEnv("GRAFANA_TEST_DB"); present {
 		return db == migrator.MySQL
@@ -503,22 +673,30 @@ func IsTestDbPostgres() bool {
 }
 
 type DatabaseConfig struct {
-	Type             string
-	Host             string
-	Name             string
-	User             string
-	Pwd              string
-	Path             string
-	SslMode          string
-	CaCertPath       string
-	ClientKeyPath    string
-	ClientCertPath   string
-	ServerCertName   string
-	ConnectionString string
-	MaxOpenConn      int
-	MaxIdleConn      int
-	ConnMaxLifetime  int
-	CacheMode        string
-	UrlQueryParams   map[string][]string
-	SkipMigrations   bool
+	Type                 string
+	Host                 string
+	Name                 string
+	User                 string
+	Pwd                  string
+	Path                 string
+	SslMode              string
+	CaCertPath           string
+	ClientKeyPath        string
+	ClientCertPath       string
+	ServerCertName       string
+	ConnectionString     string
+	MaxOpenConn          int
+	MaxIdleConn          int
+	ConnMaxLifetime      int
+	QueryTimeout         int
+	TransactionRetries   int
+	CacheMode            string
+	JournalMode          string
+	BusyTimeout          int
+	CacheSize            int
+	Synchronous          string
+	UrlQueryParams       map[string][]string
+	SkipMigrations       bool
+	MigrationLocking     bool
+	MigrationLockTimeout int
 }
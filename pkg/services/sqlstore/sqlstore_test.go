@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -66,6 +67,18 @@ var sqlStoreTestCases = []sqlStoreTest{
 		dbHost:        "[::1]",
 		connStrValues: []string{"host=::1", "port=5432"},
 	},
+	{
+		name:          "Cockroach IPv4",
+		dbType:        "cockroach",
+		dbHost:        "1.2.3.4:5678",
+		connStrValues: []string{"host=1.2.3.4", "port=5678", "serial_normalization=sql_sequence"},
+	},
+	{
+		name:          "Cockroach IPv4 (Default Port)",
+		dbType:        "cockroach",
+		dbHost:        "1.2.3.4",
+		connStrValues: []string{"host=1.2.3.4", "port=26257"},
+	},
 }
 
 func TestSQLConnectionString(t *testing.T) {
@@ -76,7 +89,8 @@ func TestSQLConnectionString(t *testing.T) {
 			Convey(testCase.name, func() {
 				sqlstore := &SQLStore{}
 				sqlstore.Cfg = makeSQLStoreTestConfig(testCase.dbType, testCase.dbHost)
-				sqlstore.readConfig()
+				err := sqlstore.readConfig()
+				So(err, ShouldBeNil)
 
 				connStr, err := sqlstore.buildConnectionString()
 
@@ -89,6 +103,22 @@ func TestSQLConnectionString(t *testing.T) {
 	})
 }
 
+func TestTransactionRetriesConfig(t *testing.T) {
+	for _, value := range []string{"0", "5", "20"} {
+		sqlstore := &SQLStore{Cfg: setting.NewCfg()}
+		_, err := sqlstore.Cfg.Raw.Section("database").NewKey("transaction_retries", value)
+		require.NoError(t, err)
+		require.NoError(t, sqlstore.readConfig(), value)
+	}
+
+	for _, value := range []string{"-1", "21", "1000"} {
+		sqlstore := &SQLStore{Cfg: setting.NewCfg()}
+		_, err := sqlstore.Cfg.Raw.Section("database").NewKey("transaction_retries", value)
+		require.NoError(t, err)
+		require.Error(t, sqlstore.readConfig(), value)
+	}
+}
+
 func makeSQLStoreTestConfig(dbType string, host string) *setting.Cfg {
 	cfg := setting.NewCfg()
 
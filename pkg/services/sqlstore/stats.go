@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -16,9 +17,20 @@ func init() {
 	bus.AddHandler("sql", GetAdminStats)
 	bus.AddHandlerCtx("sql", GetAlertNotifiersUsageStats)
 	bus.AddHandlerCtx("sql", GetSystemUserCountStats)
+	bus.AddHandlerCtx("sql", UpdateActiveUserStats)
+	bus.AddHandlerCtx("sql", GetActiveUserStats)
 }
 
-const activeUserTimeLimit = time.Hour * 24 * 30
+const (
+	defaultActiveUserTimeLimit = time.Hour * 24 * 30
+	// activeUserStatsRetentionDays is how many days of active user counts are kept.
+	activeUserStatsRetentionDays = 365
+	activeUserStatsDayFormat     = "2006-01-02"
+)
+
+// activeUserTimeLimit is how recently users must have been seen to be active. It's set from the
+// active_user_window setting when the store is initialized.
+var activeUserTimeLimit = defaultActiveUserTimeLimit
 
 func GetAlertNotifiersUsageStats(ctx context.Context, query *models.GetAlertNotifierUsageStatsQuery) error {
 	var rawSQL = `SELECT COUNT(*) AS count, type FROM ` + dialect.Quote("alert_notification") + ` GROUP BY type`
@@ -51,9 +63,6 @@ func GetSystemStats(query *models.GetSystemStatsQuery) error {
 	sb.Write(`(SELECT COUNT(*) FROM ` + dialect.Quote("playlist") + `) AS playlists,`)
 	sb.Write(`(SELECT COUNT(*) FROM ` + dialect.Quote("alert") + `) AS alerts,`)
 
-	activeUserDeadlineDate := time.Now().Add(-activeUserTimeLimit)
-	sb.Write(`(SELECT COUNT(*) FROM `+dialect.Quote("user")+` WHERE last_seen_at > ?) AS active_users,`, activeUserDeadlineDate)
-
 	sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("dashboard")+` WHERE is_folder = ?) AS dashboards,`, dialect.BooleanStr(false))
 	sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("dashboard")+` WHERE is_folder = ?) AS folders,`, dialect.BooleanStr(true))
 
@@ -88,6 +97,15 @@ func GetSystemStats(query *models.GetSystemStatsQuery) error {
 		return err
 	}
 
+	activeUsers, err := getCurrentActiveUserStats(context.Background())
+	if err != nil {
+		return err
+	}
+	stats.ActiveUsers = activeUsers.ActiveUsers
+	stats.DailyActiveUsers = activeUsers.DailyActiveUsers
+	stats.WeeklyActiveUsers = activeUsers.WeeklyActiveUsers
+	stats.MonthlyActiveUsers = activeUsers.MonthlyActiveUsers
+
 	query.Result = &stats
 
 	return nil
@@ -149,10 +167,6 @@ func GetAdminStats(query *models.GetAdminStatsQuery) error {
 			SELECT COUNT(*)
 			FROM ` + dialect.Quote("user") + `
 		) AS users,
-		(
-			SELECT COUNT(*)
-			FROM ` + dialect.Quote("user") + ` WHERE last_seen_at > ?
-		) AS active_users,
 		` + roleCounterSQL() + `,
 		(
 			SELECT COUNT(*)
@@ -160,15 +174,141 @@ func GetAdminStats(query *models.GetAdminStatsQuery) error {
 		) AS active_sessions`
 
 	var stats models.AdminStats
-	_, err := x.SQL(rawSQL, activeEndDate, activeEndDate.Unix()).Get(&stats)
+	_, err := x.SQL(rawSQL, activeEndDate.Unix()).Get(&stats)
+	if err != nil {
+		return err
+	}
+
+	activeUsers, err := getCurrentActiveUserStats(context.Background())
 	if err != nil {
 		return err
 	}
+	stats.ActiveUsers = int(activeUsers.ActiveUsers)
+	stats.DailyActiveUsers = int(activeUsers.DailyActiveUsers)
+	stats.WeeklyActiveUsers = int(activeUsers.WeeklyActiveUsers)
+	stats.MonthlyActiveUsers = int(activeUsers.MonthlyActiveUsers)
+	stats.ActiveUserWindow = formatActiveUserWindow(activeUserTimeLimit)
 
 	query.Result = &stats
 	return nil
 }
 
+// UpdateActiveUserStats counts the users seen over the last day, week, month and active user
+// window, and saves the counts as the ones of the current day.
+func UpdateActiveUserStats(ctx context.Context, cmd *models.UpdateActiveUserStatsCommand) error {
+	err := saveActiveUserStats(ctx, cmd)
+	if err != nil && dialect.IsUniqueConstraintViolation(err) {
+		// Another instance saved the first counts of the day at the same time, which are
+		// updated now.
+		err = saveActiveUserStats(ctx, cmd)
+	}
+	return err
+}
+
+func saveActiveUserStats(ctx context.Context, cmd *models.UpdateActiveUserStatsCommand) error {
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		now := time.Now()
+		stats, err := countActiveUserStats(sess, now)
+		if err != nil {
+			return err
+		}
+
+		affected, err := sess.Where("day = ?", stats.Day).
+			Cols("daily_active_users", "weekly_active_users", "monthly_active_users", "active_users", "active_user_window", "updated").
+			Update(stats)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			if _, err := sess.Insert(stats); err != nil {
+				return err
+			}
+		}
+
+		oldest := now.UTC().AddDate(0, 0, -activeUserStatsRetentionDays).Format(activeUserStatsDayFormat)
+		if _, err := sess.Exec("DELETE FROM active_user_stats WHERE day < ?", oldest); err != nil {
+			return err
+		}
+
+		cmd.Result = stats
+		return nil
+	})
+}
+
+// countActiveUserStats counts the users seen over the last day, week, month and active user window
+// at now.
+func countActiveUserStats(sess *DBSession, now time.Time) (*models.ActiveUserStats, error) {
+	rawSQL := `SELECT
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("user") + ` WHERE last_seen_at > ?
+		) AS daily_active_users,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("user") + ` WHERE last_seen_at > ?
+		) AS weekly_active_users,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("user") + ` WHERE last_seen_at > ?
+		) AS monthly_active_users,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("user") + ` WHERE last_seen_at > ?
+		) AS active_users`
+
+	var stats models.ActiveUserStats
+	_, err := sess.SQL(rawSQL, now.AddDate(0, 0, -1), now.AddDate(0, 0, -7), now.AddDate(0, 0, -30), now.Add(-activeUserTimeLimit)).Get(&stats)
+	if err != nil {
+		return nil, err
+	}
+	stats.Day = now.UTC().Format(activeUserStatsDayFormat)
+	stats.ActiveUserWindow = int64(activeUserTimeLimit / time.Second)
+	stats.Updated = now
+	return &stats, nil
+}
+
+// GetActiveUserStats gets the active user counts of the last days, including the current one.
+func GetActiveUserStats(ctx context.Context, query *models.GetActiveUserStatsQuery) error {
+	return withDbSession(ctx, x, func(sess *DBSession) error {
+		since := time.Now().UTC().AddDate(0, 0, 1-query.Days).Format(activeUserStatsDayFormat)
+		query.Result = make([]*models.ActiveUserStats, 0)
+		return sess.Where("day >= ?", since).Asc("day").Find(&query.Result)
+	})
+}
+
+// getCurrentActiveUserStats gets the active user counts of the current day, which are counted
+// without being saved if they weren't saved yet today or the active user window changed since.
+func getCurrentActiveUserStats(ctx context.Context) (*models.ActiveUserStats, error) {
+	var stats *models.ActiveUserStats
+	err := withDbSession(ctx, x, func(sess *DBSession) error {
+		now := time.Now()
+		var saved models.ActiveUserStats
+		found, err := sess.Where("day = ?", now.UTC().Format(activeUserStatsDayFormat)).Get(&saved)
+		if err != nil {
+			return err
+		}
+		if found && saved.ActiveUserWindow == int64(activeUserTimeLimit/time.Second) {
+			stats = &saved
+			return nil
+		}
+
+		stats, err = countActiveUserStats(sess, now)
+		return err
+	})
+	return stats, err
+}
+
+// formatActiveUserWindow formats the active user window for the server stats, like "30 days".
+func formatActiveUserWindow(window time.Duration) string {
+	if window%(24*time.Hour) != 0 {
+		return window.String()
+	}
+	if days := int64(window / (24 * time.Hour)); days != 1 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return "1 day"
+}
+
 func GetSystemUserCountStats(ctx context.Context, query *models.GetSystemUserCountStatsQuery) error {
 	return withDbSession(ctx, x, func(sess *DBSession) error {
 		var rawSQL = `SELECT COUNT(id) AS Count FROM ` + dialect.Quote("user")
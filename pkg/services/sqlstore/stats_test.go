@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/stretchr/testify/assert"
@@ -54,6 +55,63 @@ func TestStatsDataAccess(t *testing.T) {
 		query := models.GetAdminStatsQuery{}
 		err := GetAdminStats(&query)
 		assert.NoError(t, err)
+		assert.Equal(t, 1, query.Result.ActiveUsers)
+		assert.Equal(t, 1, query.Result.DailyActiveUsers)
+		assert.Equal(t, "30 days", query.Result.ActiveUserWindow)
+	})
+
+	t.Run("Active user stats are counted over each window and saved per day", func(t *testing.T) {
+		// The 3rd user was last seen three days ago.
+		_, err := x.Exec("UPDATE "+dialect.Quote("user")+" SET last_seen_at = ? WHERE login = ?", time.Now().AddDate(0, 0, -3), "user_test_2_login")
+		require.NoError(t, err)
+
+		cmd := models.UpdateActiveUserStatsCommand{}
+		require.NoError(t, UpdateActiveUserStats(context.Background(), &cmd))
+		assert.Equal(t, int64(1), cmd.Result.DailyActiveUsers)
+		assert.Equal(t, int64(2), cmd.Result.WeeklyActiveUsers)
+		assert.Equal(t, int64(2), cmd.Result.MonthlyActiveUsers)
+		assert.Equal(t, int64(2), cmd.Result.ActiveUsers)
+		assert.Equal(t, int64(30*24*60*60), cmd.Result.ActiveUserWindow)
+
+		query := models.GetActiveUserStatsQuery{Days: 7}
+		require.NoError(t, GetActiveUserStats(context.Background(), &query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, time.Now().UTC().Format("2006-01-02"), query.Result[0].Day)
+		assert.Equal(t, int64(2), query.Result[0].WeeklyActiveUsers)
+
+		// The counts of the day are recounted, but not saved, when the active user window changes.
+		activeUserTimeLimit = 2 * 24 * time.Hour
+		t.Cleanup(func() { activeUserTimeLimit = defaultActiveUserTimeLimit })
+		statsQuery := models.GetSystemStatsQuery{}
+		require.NoError(t, GetSystemStats(&statsQuery))
+		assert.Equal(t, int64(1), statsQuery.Result.ActiveUsers)
+		assert.Equal(t, int64(2), statsQuery.Result.MonthlyActiveUsers)
+
+		query = models.GetActiveUserStatsQuery{Days: 7}
+		require.NoError(t, GetActiveUserStats(context.Background(), &query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, int64(2), query.Result[0].ActiveUsers)
+
+		// Saving the counts again updates the ones of the day.
+		require.NoError(t, UpdateActiveUserStats(context.Background(), &cmd))
+		query = models.GetActiveUserStatsQuery{Days: 7}
+		require.NoError(t, GetActiveUserStats(context.Background(), &query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, int64(1), query.Result[0].ActiveUsers)
+	})
+
+	t.Run("Stats are counted without saving them when the counts of the day weren't saved yet", func(t *testing.T) {
+		_, err := x.Exec("DELETE FROM active_user_stats")
+		require.NoError(t, err)
+
+		query := models.GetAdminStatsQuery{}
+		require.NoError(t, GetAdminStats(&query))
+		assert.Equal(t, 2, query.Result.ActiveUsers)
+		assert.Equal(t, 2, query.Result.WeeklyActiveUsers)
+
+		activeUserQuery := models.GetActiveUserStatsQuery{Days: 7}
+		require.NoError(t, GetActiveUserStats(context.Background(), &activeUserQuery))
+		assert.Empty(t, activeUserQuery.Result)
 	})
 }
 
@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Store is the interface of the SQLStore methods used by services, so they can be tested
+// against mockstore.SQLStoreMock instead of a database. It embeds the interfaces of the domains of
+// the store, so services can depend on, and tests mock, only the domains they use. The methods of
+// SQLStoreMock are generated, run go generate in the mockstore package after changing the
+// interfaces.
+type Store interface {
+	AlertStore
+	DashboardStore
+	DataSourceStore
+	OrgStore
+	PluginSettingStore
+	PreferenceStore
+	TeamStore
+	UserStore
+	QuotaStore
+	SessionStore
+}
+
+// AlertStore is the interface of the alerts and alert notifications methods of Store.
+type AlertStore interface {
+	SaveAlerts(dashID int64, alerts []*models.Alert) error
+	GetAlertNotificationUidWithId(query *models.GetAlertNotificationUidQuery) error
+}
+
+// DashboardStore is the interface of the dashboards, their permissions and their provisioning
+// methods of Store.
+type DashboardStore interface {
+	SaveDashboard(cmd models.SaveDashboardCommand) (*models.Dashboard, error)
+	GetDashboard(id, orgID int64, uid, slug string) (*models.Dashboard, error)
+	ValidateDashboardBeforeSave(dashboard *models.Dashboard, overwrite bool) (bool, error)
+	UpdateDashboardACL(dashboardID int64, items []*models.DashboardAcl) error
+	GetProvisionedDataByDashboardID(dashboardID int64) (*models.DashboardProvisioning, error)
+	SaveProvisionedDashboard(cmd models.SaveDashboardCommand, provisioning *models.DashboardProvisioning) (*models.Dashboard, error)
+	GetProvisionedDashboardData(name string) ([]*models.DashboardProvisioning, error)
+}
+
+// DataSourceStore is the interface of the data sources methods of Store.
+type DataSourceStore interface {
+	GetDataSource(uid string, id int64, name string, orgID int64) (*models.DataSource, error)
+	DeleteDataSource(uid string, id int64, name string, orgID int64) (int64, error)
+}
+
+// OrgStore is the interface of the orgs methods of Store.
+type OrgStore interface {
+	GetOrgByName(name string) (*models.Org, error)
+	CreateOrgWithMember(name string, userID int64) (models.Org, error)
+}
+
+// PluginSettingStore is the interface of the plugin settings methods of Store.
+type PluginSettingStore interface {
+	GetPluginSettings(orgID int64) ([]*models.PluginSettingInfoDTO, error)
+}
+
+// PreferenceStore is the interface of the preferences methods of Store.
+type PreferenceStore interface {
+	GetPreferencesWithDefaults(query *models.GetPreferencesWithDefaultsQuery) error
+}
+
+// TeamStore is the interface of the teams methods of Store.
+type TeamStore interface {
+	CreateTeam(name, email string, orgID int64) (models.Team, error)
+	AddTeamMember(userID, orgID, teamID int64, isExternal bool, permission models.PermissionType) error
+}
+
+// UserStore is the interface of the users methods of Store.
+type UserStore interface {
+	CreateUser(ctx context.Context, cmd models.CreateUserCommand) (*models.User, error)
+	GetSignedInUserWithCache(query *models.GetSignedInUserQuery) error
+	UpdateUserPermissions(userID int64, isAdmin bool) error
+}
+
+// QuotaStore is the interface of the quotas methods of Store.
+type QuotaStore interface {
+	GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error
+	GetOrgQuotas(query *models.GetOrgQuotasQuery) error
+	GetUserQuotaByTarget(query *models.GetUserQuotaByTargetQuery) error
+	GetUserQuotas(query *models.GetUserQuotasQuery) error
+	GetGlobalQuotaByTarget(query *models.GetGlobalQuotaByTargetQuery) error
+}
+
+// SessionStore is the interface of the sessions and transactions methods of Store.
+type SessionStore interface {
+	NewSession() *DBSession
+	WithDbSession(ctx context.Context, callback DBTransactionFunc) error
+	WithTransactionalDbSession(ctx context.Context, callback DBTransactionFunc) error
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var _ Store = (*SQLStore)(nil)
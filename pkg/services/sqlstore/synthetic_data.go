@@ -0,0 +1,269 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandlerCtx("sql", GenerateSyntheticData)
+}
+
+const (
+	syntheticInsertBatchSize = 500
+	syntheticAnnotationSpan  = 30 * 24 * time.Hour
+)
+
+var (
+	syntheticWords = []string{
+		"api", "billing", "cache", "checkout", "cluster", "database", "edge", "frontend", "gateway",
+		"ingest", "kafka", "kubernetes", "latency", "login", "network", "nodes", "payments", "queue",
+		"redis", "search", "storage", "traffic", "worker",
+	}
+	syntheticTags = []string{
+		"prod", "staging", "dev", "sla", "kpi", "infra", "app", "business", "alerts", "team", "legacy",
+	}
+)
+
+// GenerateSyntheticData generates data with skewed distributions like real instances have: a few
+// folders hold most dashboards, a few teams have most members, a few dashboards get most annotations.
+// Everything created is named with a random prefix, so the command can run repeatedly in one org.
+func GenerateSyntheticData(ctx context.Context, cmd *models.GenerateSyntheticDataCommand) error {
+	g := &syntheticGenerator{
+		cmd:    cmd,
+		rnd:    rand.New(rand.NewSource(cmd.Seed)),
+		now:    time.Now(),
+		prefix: "synth-" + strings.ToLower(util.GenerateShortUID()),
+	}
+	cmd.Result = &models.SyntheticDataResult{Prefix: g.prefix}
+
+	if err := g.generateUsersAndTeams(ctx); err != nil {
+		return err
+	}
+	if err := g.generateDashboards(ctx); err != nil {
+		return err
+	}
+	return g.generateAnnotations(ctx)
+}
+
+type syntheticGenerator struct {
+	cmd    *models.GenerateSyntheticDataCommand
+	rnd    *rand.Rand
+	now    time.Time
+	prefix string
+
+	dashboards []*models.Dashboard
+}
+
+// skewed returns a random index below n, with low indexes a lot more likely than high ones.
+func (g *syntheticGenerator) skewed(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return int(rand.NewZipf(g.rnd, 1.2, 1, uint64(n-1)).Uint64())
+}
+
+func (g *syntheticGenerator) title(i int) string {
+	first := syntheticWords[g.rnd.Intn(len(syntheticWords))]
+	second := syntheticWords[g.rnd.Intn(len(syntheticWords))]
+	return fmt.Sprintf("%s %s %s %d", g.prefix, first, second, i)
+}
+
+func (g *syntheticGenerator) generateUsersAndTeams(ctx context.Context) error {
+	cmd := g.cmd
+
+	users := make([]*models.User, 0, cmd.Users)
+	beans := make([]interface{}, 0, cmd.Users)
+	for i := 0; i < cmd.Users; i++ {
+		login := fmt.Sprintf("%s-user-%d", g.prefix, i)
+		user := &models.User{
+			Login:   login,
+			Email:   login + "@example.com",
+			Name:    login,
+			OrgId:   cmd.OrgId,
+			Created: g.now,
+			Updated: g.now,
+		}
+		users = append(users, user)
+		beans = append(beans, user)
+	}
+	if err := insertSyntheticBeans(ctx, "", beans); err != nil {
+		return err
+	}
+
+	teams := make([]*models.Team, 0, cmd.Teams)
+	beans = make([]interface{}, 0, cmd.Teams)
+	for i := 0; i < cmd.Teams; i++ {
+		team := &models.Team{Name: g.title(i), OrgId: cmd.OrgId, Created: g.now, Updated: g.now}
+		teams = append(teams, team)
+		beans = append(beans, team)
+	}
+	if err := insertSyntheticBeans(ctx, "", beans); err != nil {
+		return err
+	}
+
+	beans = make([]interface{}, 0, cmd.Users*2)
+	for _, user := range users {
+		// Most users are viewers, and only a handful are admins.
+		role := models.ROLE_VIEWER
+		switch r := g.rnd.Float64(); {
+		case r < 0.05:
+			role = models.ROLE_ADMIN
+		case r < 0.3:
+			role = models.ROLE_EDITOR
+		}
+		beans = append(beans, &models.OrgUser{OrgId: cmd.OrgId, UserId: user.Id, Role: role, Created: g.now, Updated: g.now})
+
+		if len(teams) == 0 {
+			continue
+		}
+		joined := map[int]bool{}
+		for j := g.rnd.Intn(3) + 1; j > 0; j-- {
+			team := g.skewed(len(teams))
+			if joined[team] {
+				continue
+			}
+			joined[team] = true
+			beans = append(beans, &models.TeamMember{
+				OrgId:   cmd.OrgId,
+				TeamId:  teams[team].Id,
+				UserId:  user.Id,
+				Created: g.now,
+				Updated: g.now,
+			})
+			cmd.Result.TeamMembers++
+		}
+	}
+	if err := insertSyntheticBeans(ctx, "", beans); err != nil {
+		return err
+	}
+
+	cmd.Result.Users = len(users)
+	cmd.Result.Teams = len(teams)
+	return nil
+}
+
+func (g *syntheticGenerator) generateDashboards(ctx context.Context) error {
+	cmd := g.cmd
+
+	folders := make([]*models.Dashboard, 0, cmd.Folders)
+	beans := make([]interface{}, 0, cmd.Folders)
+	for i := 0; i < cmd.Folders; i++ {
+		folder := models.NewDashboardFolder(g.title(i))
+		folder.OrgId = cmd.OrgId
+		folder.SetUid(util.GenerateShortUID())
+		folders = append(folders, folder)
+		beans = append(beans, folder)
+	}
+	if err := insertSyntheticBeans(ctx, "", beans); err != nil {
+		return err
+	}
+
+	g.dashboards = make([]*models.Dashboard, 0, cmd.Dashboards)
+	beans = make([]interface{}, 0, cmd.Dashboards)
+	for i := 0; i < cmd.Dashboards; i++ {
+		dash := models.NewDashboard(g.title(i))
+		dash.OrgId = cmd.OrgId
+		dash.SetUid(util.GenerateShortUID())
+		// Some dashboards are left in the General folder, the rest mostly end up in a few big folders.
+		if len(folders) > 0 && g.rnd.Float64() >= 0.1 {
+			dash.FolderId = folders[g.skewed(len(folders))].Id
+		}
+		g.dashboards = append(g.dashboards, dash)
+		beans = append(beans, dash)
+	}
+	if err := insertSyntheticBeans(ctx, "", beans); err != nil {
+		return err
+	}
+
+	beans = make([]interface{}, 0, cmd.Dashboards*2)
+	for _, dash := range g.dashboards {
+		tagged := map[int]bool{}
+		for j := g.rnd.Intn(4); j > 0; j-- {
+			tag := g.skewed(len(syntheticTags))
+			if tagged[tag] {
+				continue
+			}
+			tagged[tag] = true
+			beans = append(beans, &DashboardTag{DashboardId: dash.Id, Term: syntheticTags[tag]})
+		}
+	}
+	if err := insertSyntheticBeans(ctx, "", beans); err != nil {
+		return err
+	}
+
+	cmd.Result.Folders = len(folders)
+	cmd.Result.Dashboards = len(g.dashboards)
+	return nil
+}
+
+func (g *syntheticGenerator) generateAnnotations(ctx context.Context) error {
+	cmd := g.cmd
+
+	to := g.now.UnixNano() / int64(time.Millisecond)
+	span := syntheticAnnotationSpan.Milliseconds()
+	beans := make([]interface{}, 0, cmd.Annotations)
+	for i := 0; i < cmd.Annotations; i++ {
+		epoch := to - g.rnd.Int63n(span)
+		item := &annotations.Item{
+			OrgId:    cmd.OrgId,
+			Text:     fmt.Sprintf("%s annotation %d", g.prefix, i),
+			Epoch:    epoch,
+			EpochEnd: epoch,
+			Created:  epoch,
+			Updated:  epoch,
+		}
+		// Organization wide annotations are rare, most are on a few busy dashboards.
+		if len(g.dashboards) > 0 && g.rnd.Float64() >= 0.05 {
+			item.DashboardId = g.dashboards[g.skewed(len(g.dashboards))].Id
+			item.PanelId = int64(g.rnd.Intn(8) + 1)
+		}
+		// A fifth of the annotations are regions.
+		if g.rnd.Float64() < 0.2 {
+			item.EpochEnd = epoch + g.rnd.Int63n(int64(time.Hour/time.Millisecond))
+		}
+		beans = append(beans, item)
+	}
+	if err := insertSyntheticBeans(ctx, "annotation", beans); err != nil {
+		return err
+	}
+
+	cmd.Result.Annotations = len(beans)
+	return nil
+}
+
+// insertSyntheticBeans inserts beans into table, or the table of their type if empty, in batched
+// transactions, which is a lot faster than one by one.
+func insertSyntheticBeans(ctx context.Context, table string, beans []interface{}) error {
+	for start := 0; start < len(beans); start += syntheticInsertBatchSize {
+		end := start + syntheticInsertBatchSize
+		if end > len(beans) {
+			end = len(beans)
+		}
+
+		err := inTransactionCtx(ctx, func(sess *DBSession) error {
+			for _, bean := range beans[start:end] {
+				s := sess.Session
+				if table != "" {
+					s = s.Table(table)
+				}
+				if _, err := s.Insert(bean); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSyntheticData(t *testing.T) {
+	InitTestDB(t)
+
+	cmd := &models.GenerateSyntheticDataCommand{
+		OrgId:       1,
+		Folders:     5,
+		Dashboards:  40,
+		Users:       30,
+		Teams:       4,
+		Annotations: 200,
+		Seed:        1,
+	}
+	require.NoError(t, GenerateSyntheticData(context.Background(), cmd))
+
+	result := cmd.Result
+	require.Equal(t, 5, result.Folders)
+	require.Equal(t, 40, result.Dashboards)
+	require.Equal(t, 30, result.Users)
+	require.Equal(t, 4, result.Teams)
+	require.Equal(t, 200, result.Annotations)
+	require.GreaterOrEqual(t, result.TeamMembers, 30)
+
+	users := &models.GetOrgUsersQuery{OrgId: 1, Query: result.Prefix}
+	require.NoError(t, GetOrgUsers(users))
+	require.Len(t, users.Result, 30)
+
+	dashboards := &search.FindPersistedDashboardsQuery{
+		OrgId:        1,
+		Title:        result.Prefix,
+		SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+		Permission:   models.PERMISSION_VIEW,
+		Limit:        1000,
+	}
+	require.NoError(t, SearchDashboards(dashboards))
+	require.Len(t, dashboards.Result, 45)
+
+	t.Run("can run repeatedly in the same org", func(t *testing.T) {
+		again := &models.GenerateSyntheticDataCommand{OrgId: 1, Users: 30, Teams: 4, Folders: 5, Seed: 1}
+		require.NoError(t, GenerateSyntheticData(context.Background(), again))
+		require.NotEqual(t, result.Prefix, again.Result.Prefix)
+	})
+}
@@ -0,0 +1,173 @@
+package testdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// containerStartTimeout bounds how long we wait for a freshly started database to accept connections.
+const containerStartTimeout = 2 * time.Minute
+
+type containerSpec struct {
+	image string
+	port  string
+	env   []string
+	cmd   []string
+	dsn   string
+}
+
+// The MySQL and Postgres images match the services used by the integration tests in CI.
+var containerSpecs = map[string]containerSpec{
+	migrator.MySQL: {
+		image: "mysql:5.6.48",
+		port:  "3306",
+		env:   []string{"MYSQL_ROOT_PASSWORD=rootpass"},
+		dsn:   "root:rootpass@tcp(127.0.0.1:%s)/?collation=utf8mb4_unicode_ci",
+	},
+	migrator.Postgres: {
+		image: "postgres:12.3-alpine",
+		port:  "5432",
+		env:   []string{"POSTGRES_USER=grafanatest", "POSTGRES_PASSWORD=grafanatest", "POSTGRES_DB=grafanatest"},
+		dsn:   "user=grafanatest password=grafanatest host=127.0.0.1 port=%s dbname=grafanatest sslmode=disable",
+	},
+	// The connection string sets serial_normalization like the ones built from the database settings,
+	// see sqlstore.SQLStore.
+	migrator.Cockroach: {
+		image: "cockroachdb/cockroach:v20.2.8",
+		port:  "26257",
+		cmd:   []string{"start-single-node", "--insecure"},
+		dsn:   "user=root host=127.0.0.1 port=%s dbname=defaultdb sslmode=disable serial_normalization=sql_sequence",
+	},
+}
+
+// errServerFailed is returned for the servers whose container already failed to start, so the
+// tests after the first one don't try to start it again.
+var errServerFailed = errors.New("test database container failed to start earlier")
+
+var (
+	serversMu      sync.Mutex
+	servers        = map[string]*server{}
+	serverFailures = map[string]error{}
+	containers     []string
+)
+
+// getServer returns the server to create test databases on, starting a container the first time
+// it's asked for one. A nil server is returned if there's neither a DSN nor docker available. If
+// the container fails to start, the later calls return errServerFailed.
+func getServer(dbType string) (*server, error) {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	if srv, ok := servers[dbType]; ok {
+		return srv, nil
+	}
+	if err, ok := serverFailures[dbType]; ok {
+		return nil, fmt.Errorf("%w: %s", errServerFailed, err)
+	}
+
+	srv := &server{dbType: dbType, dsn: os.Getenv(dsnEnv)}
+	if srv.dsn == "" {
+		if _, err := exec.LookPath("docker"); err != nil {
+			return nil, nil
+		}
+
+		dsn, err := startContainer(dbType)
+		if err != nil {
+			serverFailures[dbType] = err
+			return nil, err
+		}
+		srv.dsn = dsn
+	}
+
+	servers[dbType] = srv
+	return srv, nil
+}
+
+func startContainer(dbType string) (string, error) {
+	spec := containerSpecs[dbType]
+	if image := os.Getenv(imageEnv); image != "" {
+		spec.image = image
+	}
+
+	args := []string{"run", "--detach", "--rm", "--publish", "127.0.0.1::" + spec.port}
+	for _, e := range spec.env {
+		args = append(args, "--env", e)
+	}
+	args = append(args, spec.image)
+	id, err := docker(append(args, spec.cmd...)...)
+	if err != nil {
+		return "", err
+	}
+	containers = append(containers, id)
+
+	// Output looks like "127.0.0.1:49153".
+	binding, err := docker("port", id, spec.port+"/tcp")
+	if err != nil {
+		return "", err
+	}
+	binding = strings.SplitN(binding, "\n", 2)[0]
+	port := binding[strings.LastIndex(binding, ":")+1:]
+
+	srv := &server{dbType: dbType, dsn: fmt.Sprintf(spec.dsn, port)}
+	if err := waitForServer(srv); err != nil {
+		return "", err
+	}
+
+	return srv.dsn, nil
+}
+
+// waitForServer waits until the server accepts connections. Database images restart the server
+// once during initialization, so a successful ping is the only reliable signal.
+func waitForServer(srv *server) error {
+	db, err := srv.open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	deadline := time.Now().Add(containerStartTimeout)
+	for {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s server didn't become ready in %s: %w", srv.dbType, containerStartTimeout, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func stopContainers() {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	for _, id := range containers {
+		if _, err := docker("stop", id); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to stop test database container %s: %s\n", id, err)
+		}
+	}
+	containers = nil
+	servers = map[string]*server{}
+	serverFailures = map[string]error{}
+}
+
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
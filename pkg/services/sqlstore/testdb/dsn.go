@@ -0,0 +1,43 @@
+package testdb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// withDatabase returns dsn with its database replaced by name.
+func withDatabase(dbType, dsn, name string) (string, error) {
+	switch dbType {
+	case migrator.MySQL:
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return "", err
+		}
+		cfg.DBName = name
+		return cfg.FormatDSN(), nil
+	case migrator.Postgres, migrator.Cockroach:
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			u, err := url.Parse(dsn)
+			if err != nil {
+				return "", err
+			}
+			u.Path = "/" + name
+			return u.String(), nil
+		}
+
+		// Key/value connection strings, e.g. "user=grafana host=localhost dbname=grafana".
+		fields := []string{}
+		for _, field := range strings.Fields(dsn) {
+			if !strings.HasPrefix(field, "dbname=") {
+				fields = append(fields, field)
+			}
+		}
+		return strings.Join(append(fields, "dbname="+name), " "), nil
+	default:
+		return "", fmt.Errorf("unsupported database type %q", dbType)
+	}
+}
@@ -0,0 +1,189 @@
+package testdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Seeder inserts consistent test data into a store. Every method fails the test on error and returns
+// the seeder, so fixtures can be chained:
+//
+//	fixtures := testdb.Seed(t, store).
+//		Org("acme").
+//		User("bob", models.ROLE_EDITOR).
+//		Team("ops", "bob").
+//		Folder("infra").
+//		Dashboard("nodes", "linux")
+//
+// Users, teams and folders are created in the org seeded last, and dashboards in the folder seeded
+// last in that org. Seeded entities can be looked up by name afterwards, so names should be unique
+// across orgs.
+type Seeder struct {
+	t     testingT
+	store *sqlstore.SQLStore
+
+	Orgs       map[string]*models.Org
+	Users      map[string]*models.User
+	Teams      map[string]*models.Team
+	Folders    map[string]*models.Dashboard
+	Dashboards map[string]*models.Dashboard
+
+	org    *models.Org
+	folder *models.Dashboard
+}
+
+// testingT is the subset of testing.TB used by the seeder.
+type testingT interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// Seed returns a seeder inserting into store.
+func Seed(t testingT, store *sqlstore.SQLStore) *Seeder {
+	return &Seeder{
+		t:          t,
+		store:      store,
+		Orgs:       map[string]*models.Org{},
+		Users:      map[string]*models.User{},
+		Teams:      map[string]*models.Team{},
+		Folders:    map[string]*models.Dashboard{},
+		Dashboards: map[string]*models.Dashboard{},
+	}
+}
+
+// Org creates an org without members and makes it the current org.
+func (s *Seeder) Org(name string) *Seeder {
+	s.t.Helper()
+
+	org := &models.Org{
+		Name:    name,
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+	err := s.store.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(org)
+		return err
+	})
+	if err != nil {
+		s.t.Fatalf("Failed to seed org %q: %s", name, err)
+	}
+
+	s.Orgs[name] = org
+	s.org = org
+	s.folder = nil
+	return s
+}
+
+// User creates a user and adds it to the current org with role.
+func (s *Seeder) User(login string, role models.RoleType) *Seeder {
+	s.t.Helper()
+	org := s.currentOrg("user", login)
+
+	user, err := s.store.CreateUser(context.Background(), models.CreateUserCommand{
+		Login:        login,
+		Email:        login + "@test.com",
+		Name:         login,
+		SkipOrgSetup: true,
+	})
+	if err != nil {
+		s.t.Fatalf("Failed to seed user %q: %s", login, err)
+	}
+
+	if err := sqlstore.AddOrgUser(&models.AddOrgUserCommand{OrgId: org.Id, UserId: user.Id, Role: role}); err != nil {
+		s.t.Fatalf("Failed to add user %q to org %q: %s", login, org.Name, err)
+	}
+	if user.OrgId <= 0 {
+		user.OrgId = org.Id
+	}
+
+	s.Users[login] = user
+	return s
+}
+
+// Team creates a team in the current org with the given, already seeded, users as members.
+func (s *Seeder) Team(name string, members ...string) *Seeder {
+	s.t.Helper()
+	org := s.currentOrg("team", name)
+
+	team, err := s.store.CreateTeam(name, "", org.Id)
+	if err != nil {
+		s.t.Fatalf("Failed to seed team %q: %s", name, err)
+	}
+
+	for _, login := range members {
+		user, ok := s.Users[login]
+		if !ok {
+			s.t.Fatalf("Failed to seed team %q: user %q hasn't been seeded", name, login)
+		}
+		if err := s.store.AddTeamMember(user.Id, org.Id, team.Id, false, models.PERMISSION_VIEW); err != nil {
+			s.t.Fatalf("Failed to add user %q to team %q: %s", login, name, err)
+		}
+	}
+
+	s.Teams[name] = &team
+	return s
+}
+
+// Folder creates a folder in the current org and makes it the current folder.
+func (s *Seeder) Folder(title string) *Seeder {
+	s.t.Helper()
+
+	folder := s.saveDashboard(title, true, nil)
+	s.Folders[title] = folder
+	s.folder = folder
+	return s
+}
+
+// Dashboard creates a dashboard with tags in the current folder, or the General folder if there's none.
+func (s *Seeder) Dashboard(title string, tags ...string) *Seeder {
+	s.t.Helper()
+
+	s.Dashboards[title] = s.saveDashboard(title, false, tags)
+	return s
+}
+
+func (s *Seeder) saveDashboard(title string, isFolder bool, tags []string) *models.Dashboard {
+	s.t.Helper()
+	org := s.currentOrg("dashboard", title)
+
+	var folderID int64
+	if !isFolder && s.folder != nil {
+		folderID = s.folder.Id
+	}
+
+	dashTags := make([]interface{}, 0, len(tags))
+	for _, tag := range tags {
+		dashTags = append(dashTags, tag)
+	}
+
+	dash, err := s.store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:    org.Id,
+		FolderId: folderID,
+		IsFolder: isFolder,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"id":    nil,
+			"title": title,
+			"tags":  dashTags,
+		}),
+	})
+	if err != nil {
+		s.t.Fatalf("Failed to seed dashboard %q: %s", title, err)
+	}
+
+	dash.Data.Set("id", dash.Id)
+	dash.Data.Set("uid", dash.Uid)
+	return dash
+}
+
+func (s *Seeder) currentOrg(kind, name string) *models.Org {
+	s.t.Helper()
+
+	if s.org == nil {
+		s.t.Fatalf("Failed to seed %s %q: seed an org first", kind, name)
+	}
+	return s.org
+}
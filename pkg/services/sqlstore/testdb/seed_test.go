@@ -0,0 +1,65 @@
+package testdb
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeed(t *testing.T) {
+	store := New(t)
+
+	fixtures := Seed(t, store).
+		Org("acme").
+		User("bob", models.ROLE_EDITOR).
+		User("alice", models.ROLE_VIEWER).
+		Team("ops", "bob", "alice").
+		Dashboard("home").
+		Folder("infra").
+		Dashboard("nodes", "linux", "prod").
+		Org("other").
+		Folder("platform")
+
+	acme := fixtures.Orgs["acme"]
+	bob := fixtures.Users["bob"]
+
+	t.Run("users are members of the org", func(t *testing.T) {
+		query := &models.GetOrgUsersQuery{OrgId: acme.Id}
+		require.NoError(t, sqlstore.GetOrgUsers(query))
+		require.Len(t, query.Result, 2)
+		require.Equal(t, acme.Id, bob.OrgId)
+
+		roles := map[string]models.RoleType{}
+		for _, u := range query.Result {
+			roles[u.Login] = models.RoleType(u.Role)
+		}
+		require.Equal(t, map[string]models.RoleType{"bob": models.ROLE_EDITOR, "alice": models.ROLE_VIEWER}, roles)
+	})
+
+	t.Run("teams have their members", func(t *testing.T) {
+		query := &models.GetTeamMembersQuery{OrgId: acme.Id, TeamId: fixtures.Teams["ops"].Id}
+		require.NoError(t, sqlstore.GetTeamMembers(query))
+		require.Len(t, query.Result, 2)
+	})
+
+	t.Run("dashboards are in the current folder", func(t *testing.T) {
+		require.Equal(t, int64(0), fixtures.Dashboards["home"].FolderId)
+		require.Equal(t, fixtures.Folders["infra"].Id, fixtures.Dashboards["nodes"].FolderId)
+		require.Equal(t, acme.Id, fixtures.Dashboards["nodes"].OrgId)
+
+		query := &models.GetDashboardTagsQuery{OrgId: acme.Id}
+		require.NoError(t, sqlstore.GetDashboardTags(query))
+		require.Len(t, query.Result, 2)
+	})
+
+	t.Run("folders are scoped to the current org", func(t *testing.T) {
+		other := fixtures.Orgs["other"]
+		require.Equal(t, other.Id, fixtures.Folders["platform"].OrgId)
+
+		query := &models.GetDashboardQuery{OrgId: other.Id, Slug: "platform"}
+		require.NoError(t, sqlstore.GetDashboard(query))
+		require.True(t, query.Result.IsFolder)
+	})
+}
@@ -0,0 +1,144 @@
+// Package testdb provides a harness for running store tests against real databases.
+//
+// The database type is read from the GRAFANA_TEST_DB environment variable, and defaults to SQLite.
+// For MySQL, Postgres and CockroachDB the server is either given by GRAFANA_TEST_DB_DSN, which must point at a
+// user allowed to create databases, or a throwaway Docker container is started for the test binary.
+// Every call to New creates a fresh database on that server, so tests do not share any state.
+package testdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// dbTypeEnv selects the database type tests run against.
+	dbTypeEnv = "GRAFANA_TEST_DB"
+	// dsnEnv points the harness at an existing database server instead of a container.
+	dsnEnv = "GRAFANA_TEST_DB_DSN"
+	// imageEnv overrides the Docker image used for the database container.
+	imageEnv = "GRAFANA_TEST_DB_IMAGE"
+
+	dbNamePrefix = "grafana_test_"
+)
+
+// Type returns the database type tests should run against.
+func Type() string {
+	if db, present := os.LookupEnv(dbTypeEnv); present && db != "" {
+		return db
+	}
+
+	return migrator.SQLite
+}
+
+// New returns a SQLStore backed by a new, fully migrated database that is private to t.
+// The database is dropped when the test finishes. The tests using it can't run in parallel, see
+// sqlstore.InitIsolatedTestDB. If the database container fails to start, the test fails and the
+// following tests using the same database type are skipped.
+func New(t *testing.T, opts ...sqlstore.InitTestDBOpt) *sqlstore.SQLStore {
+	t.Helper()
+
+	dbType := Type()
+	name, err := newDatabaseName()
+	if err != nil {
+		t.Fatalf("Failed to generate database name: %s", err)
+	}
+
+	var connStr string
+	switch dbType {
+	case migrator.SQLite:
+		// A named in-memory database is only visible to connections using the same name,
+		// and disappears once its last connection is closed.
+		connStr = fmt.Sprintf("file:%s?mode=memory&cache=shared", name)
+	case migrator.MySQL, migrator.Postgres, migrator.Cockroach:
+		srv, err := getServer(dbType)
+		if errors.Is(err, errServerFailed) {
+			t.Skipf("Skipping %s test: %s", dbType, err)
+		}
+		if err != nil {
+			t.Fatalf("Failed to get %s server: %s", dbType, err)
+		}
+		if srv == nil {
+			t.Skipf("Skipping %s test: set %s or make docker available", dbType, dsnEnv)
+		}
+
+		connStr, err = srv.createDatabase(name)
+		if err != nil {
+			t.Fatalf("Failed to create test database: %s", err)
+		}
+		t.Cleanup(func() {
+			if err := srv.dropDatabase(name); err != nil {
+				t.Logf("Failed to drop test database %q: %s", name, err)
+			}
+		})
+	default:
+		t.Fatalf("Unsupported test database type %q", dbType)
+	}
+
+	t.Logf("Using %s test database %q", dbType, name)
+	return sqlstore.InitIsolatedTestDB(t, dbType, connStr, opts...)
+}
+
+// RunTests runs the tests of a package and stops any database container started for them.
+// It's meant to be called from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(testdb.RunTests(m))
+//	}
+func RunTests(m *testing.M) int {
+	code := m.Run()
+	stopContainers()
+	return code
+}
+
+func newDatabaseName() (string, error) {
+	suffix, err := util.GetRandomString(12, []byte("abcdefghijklmnopqrstuvwxyz0123456789")...)
+	if err != nil {
+		return "", err
+	}
+
+	return dbNamePrefix + suffix, nil
+}
+
+// server is a database server the harness can create test databases on.
+type server struct {
+	dbType string
+	dsn    string
+}
+
+func (s *server) open() (*sql.DB, error) {
+	return sql.Open(s.dbType, s.dsn)
+}
+
+// createDatabase creates the named database and returns a connection string for it.
+func (s *server) createDatabase(name string) (string, error) {
+	db, err := s.open()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec("CREATE DATABASE " + name); err != nil {
+		return "", err
+	}
+
+	return withDatabase(s.dbType, s.dsn, name)
+}
+
+func (s *server) dropDatabase(name string) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	_, err = db.Exec("DROP DATABASE IF EXISTS " + name)
+	return err
+}
@@ -0,0 +1,81 @@
+package sqlstore
+
+import (
+	"context"
+	"runtime"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"xorm.io/xorm"
+)
+
+// sessionHelperFiles are the files of the functions opening sessions for their callers, which are
+// skipped when naming the span of a session.
+var sessionHelperFiles = []string{
+	"/pkg/services/sqlstore/session.go",
+	"/pkg/services/sqlstore/transactions.go",
+	"/pkg/services/sqlstore/tracing.go",
+}
+
+// startSessionSpan starts the span of a session as a child of the span of ctx, if any, and returns
+// ctx with the new span so the queries of the session run with it. The span is named after the
+// function that opened the session, like "sqlstore - sqlstore.SaveAlerts", and tagged with the
+// dialect of the database, so traces show where database time goes. It returns a nil span if no
+// tracer is registered.
+func startSessionSpan(ctx context.Context, engine *xorm.Engine, kind string) (opentracing.Span, context.Context) {
+	if !opentracing.IsGlobalTracerRegistered() {
+		return nil, ctx
+	}
+
+	operation := sessionCaller()
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sqlstore - "+operation)
+	ext.DBType.Set(span, "sql")
+	span.SetTag("db.dialect", migrator.NewDialect(engine).DriverName())
+	span.SetTag("db.operation", operation)
+	span.SetTag("db.session", kind)
+	return span, ctx
+}
+
+// finishSessionSpan tags the span of a session with the rows its statements affected and the
+// error it failed with, if any, and finishes it.
+func finishSessionSpan(span opentracing.Span, sess *DBSession, err error) {
+	if span == nil {
+		return
+	}
+
+	if sess != nil {
+		span.SetTag("db.rows", sess.rowsAffected)
+	}
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(otlog.Error(err))
+	}
+	span.Finish()
+}
+
+// sessionCaller returns the name of the function that opened a session, without its package path.
+func sessionCaller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !isSessionHelperFile(frame.File) {
+			return frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func isSessionHelperFile(file string) bool {
+	for _, helper := range sessionHelperFiles {
+		if strings.HasSuffix(file, helper) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,18 +2,19 @@ package sqlstore
 
 import (
 	"context"
-	"errors"
+	"math/rand"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/util/errutil"
-	"github.com/mattn/go-sqlite3"
+	opentracing "github.com/opentracing/opentracing-go"
 	"xorm.io/xorm"
 )
 
 // WithTransactionalDbSession calls the callback with a session within a transaction.
-func (ss *SQLStore) WithTransactionalDbSession(ctx context.Context, callback dbTransactionFunc) error {
+func (ss *SQLStore) WithTransactionalDbSession(ctx context.Context, callback DBTransactionFunc) error {
 	return inTransactionWithRetryCtx(ctx, ss.engine, callback, 0)
 }
 
@@ -28,39 +29,62 @@ func (ss *SQLStore) inTransactionWithRetry(ctx context.Context, fn func(ctx cont
 	}, retry)
 }
 
-func inTransactionWithRetry(callback dbTransactionFunc, retry int) error {
+func inTransactionWithRetry(callback DBTransactionFunc, retry int) error {
 	return inTransactionWithRetryCtx(context.Background(), x, callback, retry)
 }
 
-func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc, retry int) error {
-	sess, err := startSession(ctx, engine, true)
+func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callback DBTransactionFunc, retry int) (err error) {
+	_, nested := ctx.Value(ContextSessionKey{}).(*DBSession)
+	// Nested transactions are part of the span of the outermost one.
+	var span opentracing.Span
+	sessCtx := ctx
+	if !nested {
+		span, sessCtx = startSessionSpan(ctx, engine, "transaction")
+		if span != nil {
+			span.SetTag("db.retry", retry)
+		}
+	}
+	sess, err := startSession(sessCtx, engine, true)
 	if err != nil {
+		finishSessionSpan(span, nil, err)
 		return err
 	}
 
-	defer sess.Close()
+	defer func() {
+		// Nested transactions run with the session of the outermost one, which closes it.
+		if !nested {
+			sess.Close()
+		}
+		finishSessionSpan(span, sess, err)
+	}()
 
 	err = callback(sess)
-
-	// special handling of database locked errors for sqlite, then we can retry 5 times
-	var sqlError sqlite3.Error
-	if errors.As(err, &sqlError) && retry < 5 && sqlError.Code == sqlite3.ErrLocked || sqlError.Code == sqlite3.ErrBusy {
+	if err != nil {
 		if rollErr := sess.Rollback(); rollErr != nil {
 			return errutil.Wrapf(err, "Rolling back transaction due to error failed: %s", rollErr)
 		}
-
-		time.Sleep(time.Millisecond * time.Duration(10))
-		sqlog.Info("Database locked, sleeping then retrying", "error", err, "retry", retry)
-		return inTransactionWithRetry(callback, retry+1)
+	} else {
+		// A failed commit ends the transaction too.
+		err = sess.Commit()
 	}
 
-	if err != nil {
-		if rollErr := sess.Rollback(); rollErr != nil {
-			return errutil.Wrapf(err, "Rolling back transaction due to error failed: %s", rollErr)
+	// Transactions failing with deadlocks, serialization failures or locked databases can succeed
+	// when retried, including the ones failing to commit, like Postgres reports some serialization
+	// failures. Nested transactions are retried by the outermost one.
+	if err != nil && !nested && retry < transactionRetries && migrator.NewDialect(engine).IsRetryableError(err) {
+		// The retry has a span of its own.
+		finishSessionSpan(span, sess, err)
+		span = nil
+
+		sqlog.Info("Retryable database error, sleeping then retrying", "error", err, "retry", retry)
+		select {
+		case <-time.After(retryBackoff(retry)):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		return err
+		return inTransactionWithRetryCtx(ctx, engine, callback, retry+1)
 	}
-	if err := sess.Commit(); err != nil {
+	if err != nil {
 		return err
 	}
 
@@ -75,10 +99,27 @@ func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callbac
 	return nil
 }
 
-func inTransaction(callback dbTransactionFunc) error {
+// maxRetryBackoff is the longest time to wait before a retry of a transaction.
+const maxRetryBackoff = time.Second
+
+// retryBackoff returns the time to wait before a retry of a transaction, which doubles with each
+// retry up to maxRetryBackoff, with a random jitter so the transactions that conflicted don't
+// conflict again.
+func retryBackoff(retry int) time.Duration {
+	backoff := 10 * time.Millisecond
+	for i := 0; i < retry && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+}
+
+func inTransaction(callback DBTransactionFunc) error {
 	return inTransactionWithRetry(callback, 0)
 }
 
-func inTransactionCtx(ctx context.Context, callback dbTransactionFunc) error {
+func inTransactionCtx(ctx context.Context, callback DBTransactionFunc) error {
 	return inTransactionWithRetryCtx(ctx, x, callback, 0)
 }
@@ -188,6 +188,9 @@ type Cfg struct {
 	Raw    *ini.File
 	Logger log.Logger
 
+	// args are the command line arguments the config was loaded with.
+	args *CommandLineArgs
+
 	// HTTP Server Settings
 	CertFile         string
 	KeyFile          string
@@ -322,8 +325,22 @@ type Cfg struct {
 
 	// User
 	UserInviteMaxLifetime time.Duration
+	ActiveUserWindow      time.Duration
 	HiddenUsers           map[string]struct{}
 
+	// Short links
+	ShortLinkUnvisitedLifetime time.Duration
+	ShortLinkMaxIdleTime       time.Duration
+
+	// Query history
+	QueryHistoryMaxAge            time.Duration
+	QueryHistoryMaxEntriesPerUser int
+
+	// SQL data sources
+	SQLDatasourceMaxOpenConnsDefault    int
+	SQLDatasourceMaxIdleConnsDefault    int
+	SQLDatasourceMaxConnLifetimeDefault int
+
 	// Annotations
 	AnnotationCleanupJobBatchSize      int64
 	AlertingAnnotationCleanupSetting   AnnotationCleanupSettings
@@ -391,6 +408,26 @@ func (cfg Cfg) IsPanelLibraryEnabled() bool {
 	return cfg.FeatureToggles["panelLibrary"]
 }
 
+// IsSyntheticDataEnabled returns whether the admin API for generating synthetic data is enabled.
+func (cfg Cfg) IsSyntheticDataEnabled() bool {
+	return cfg.FeatureToggles["syntheticData"]
+}
+
+// IsDashboardInsightsEnabled returns whether the dashboard usage insights feature is enabled.
+func (cfg Cfg) IsDashboardInsightsEnabled() bool {
+	return cfg.FeatureToggles["dashboardInsights"]
+}
+
+// IsQueryHistoryEnabled returns whether the query history is stored in the database.
+func (cfg Cfg) IsQueryHistoryEnabled() bool {
+	return cfg.FeatureToggles["queryHistory"]
+}
+
+// IsReportingEnabled returns whether scheduled PDF reports are enabled.
+func (cfg Cfg) IsReportingEnabled() bool {
+	return cfg.FeatureToggles["reporting"]
+}
+
 type CommandLineArgs struct {
 	Config   string
 	HomePath string
@@ -589,18 +626,20 @@ func makeAbsolute(path string, root string) string {
 	return filepath.Join(root, path)
 }
 
-func loadSpecifiedConfigFile(configFile string, masterFile *ini.File) error {
+// loadSpecifiedConfigFile applies the config file to masterFile, and returns the path of the
+// config file it applied, if any.
+func loadSpecifiedConfigFile(configFile string, masterFile *ini.File) (string, error) {
 	if configFile == "" {
 		configFile = filepath.Join(HomePath, CustomInitPath)
 		// return without error if custom file does not exist
 		if !pathExists(configFile) {
-			return nil
+			return "", nil
 		}
 	}
 
 	userConfig, err := ini.Load(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to parse %q: %w", configFile, err)
+		return "", fmt.Errorf("failed to parse %q: %w", configFile, err)
 	}
 
 	userConfig.BlockMode = false
@@ -623,8 +662,22 @@ func loadSpecifiedConfigFile(configFile string, masterFile *ini.File) error {
 		}
 	}
 
-	configFiles = append(configFiles, configFile)
-	return nil
+	return configFile, nil
+}
+
+// applyConfigOverrides applies the overrides of environment variables and command line properties
+// to the parsed config files, and expands the variables of its values.
+func applyConfigOverrides(commandLineProps map[string]string, parsedFile *ini.File) error {
+	// apply environment overrides
+	if err := applyEnvVariableOverrides(parsedFile); err != nil {
+		return err
+	}
+
+	// apply command line overrides
+	applyCommandLineProperties(commandLineProps, parsedFile)
+
+	// evaluate config values containing environment variables
+	return expandConfig(parsedFile)
 }
 
 func (cfg *Cfg) loadConfiguration(args *CommandLineArgs) (*ini.File, error) {
@@ -654,7 +707,7 @@ func (cfg *Cfg) loadConfiguration(args *CommandLineArgs) (*ini.File, error) {
 	applyCommandLineDefaultProperties(commandLineProps, parsedFile)
 
 	// load specified config file
-	err = loadSpecifiedConfigFile(args.Config, parsedFile)
+	configFile, err := loadSpecifiedConfigFile(args.Config, parsedFile)
 	if err != nil {
 		err2 := cfg.initLogging(parsedFile)
 		if err2 != nil {
@@ -662,18 +715,11 @@ func (cfg *Cfg) loadConfiguration(args *CommandLineArgs) (*ini.File, error) {
 		}
 		log.Fatalf(3, err.Error())
 	}
-
-	// apply environment overrides
-	err = applyEnvVariableOverrides(parsedFile)
-	if err != nil {
-		return nil, err
+	if configFile != "" {
+		configFiles = append(configFiles, configFile)
 	}
 
-	// apply command line overrides
-	applyCommandLineProperties(commandLineProps, parsedFile)
-
-	// evaluate config values containing environment variables
-	err = expandConfig(parsedFile)
+	err = applyConfigOverrides(commandLineProps, parsedFile)
 	if err != nil {
 		return nil, err
 	}
@@ -775,6 +821,8 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		return err
 	}
 
+	cfg.args = args
+
 	cfg.Raw = iniFile
 
 	// Temporarily keep global, to make refactor in steps
@@ -815,6 +863,11 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	DataProxyIdleConnTimeout = dataproxy.Key("idle_conn_timeout_seconds").MustInt(90)
 	cfg.SendUserHeader = dataproxy.Key("send_user_header").MustBool(false)
 
+	sqlDatasources := iniFile.Section("sql_datasources")
+	cfg.SQLDatasourceMaxOpenConnsDefault = sqlDatasources.Key("max_open_conns_default").MustInt(0)
+	cfg.SQLDatasourceMaxIdleConnsDefault = sqlDatasources.Key("max_idle_conns_default").MustInt(2)
+	cfg.SQLDatasourceMaxConnLifetimeDefault = sqlDatasources.Key("max_conn_lifetime_default").MustInt(14400)
+
 	if err := readSecuritySettings(iniFile, cfg); err != nil {
 		return err
 	}
@@ -830,6 +883,14 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 
 	cfg.DefaultHomeDashboardPath = dashboards.Key("default_home_dashboard_path").MustString("")
 
+	if err := readShortLinksSettings(iniFile, cfg); err != nil {
+		return err
+	}
+
+	if err := readQueryHistorySettings(iniFile, cfg); err != nil {
+		return err
+	}
+
 	if err := readUserSettings(iniFile, cfg); err != nil {
 		return err
 	}
@@ -998,9 +1059,36 @@ func (cfg *Cfg) initLogging(file *ini.File) error {
 	}
 	logsPath := valueAsString(file.Section("paths"), "logs", "")
 	cfg.LogsPath = makeAbsolute(logsPath, HomePath)
+	log.SetVersion(BuildVersion)
+	log.SetBuildInfo(BuildCommit, BuildStamp)
 	return log.ReadLoggingConfig(logModes, cfg.LogsPath, file)
 }
 
+// ReloadLogging reads the config files the config was loaded from again, and applies their logging
+// config. Other settings are not changed.
+func (cfg *Cfg) ReloadLogging() error {
+	if cfg.args == nil {
+		return errors.New("the config wasn't loaded from config files")
+	}
+
+	parsedFile, err := ini.Load(path.Join(HomePath, "conf/defaults.ini"))
+	if err != nil {
+		return fmt.Errorf("failed to parse defaults.ini: %w", err)
+	}
+	parsedFile.BlockMode = false
+
+	commandLineProps := getCommandLineProperties(cfg.args.Args)
+	applyCommandLineDefaultProperties(commandLineProps, parsedFile)
+	if _, err := loadSpecifiedConfigFile(cfg.args.Config, parsedFile); err != nil {
+		return err
+	}
+	if err := applyConfigOverrides(commandLineProps, parsedFile); err != nil {
+		return err
+	}
+
+	return cfg.initLogging(parsedFile)
+}
+
 func (cfg *Cfg) LogConfigSources() {
 	var text bytes.Buffer
 
@@ -1247,6 +1335,14 @@ func readUserSettings(iniFile *ini.File, cfg *Cfg) error {
 		return errors.New("the minimum supported value for the `user_invite_max_lifetime_duration` configuration is 15m (15 minutes)")
 	}
 
+	cfg.ActiveUserWindow, err = gtime.ParseDuration(valueAsString(users, "active_user_window", "30d"))
+	if err != nil {
+		return err
+	}
+	if cfg.ActiveUserWindow < time.Hour*24 {
+		return errors.New("the minimum supported value for the `active_user_window` configuration is 1d (1 day)")
+	}
+
 	cfg.HiddenUsers = make(map[string]struct{})
 	hiddenUsers := users.Key("hidden_users").MustString("")
 	for _, user := range strings.Split(hiddenUsers, ",") {
@@ -1315,6 +1411,38 @@ func readSnapshotsSettings(cfg *Cfg, iniFile *ini.File) error {
 	return nil
 }
 
+func readShortLinksSettings(iniFile *ini.File, cfg *Cfg) error {
+	shortLinks := iniFile.Section("short_links")
+
+	var err error
+	cfg.ShortLinkUnvisitedLifetime, err = gtime.ParseDuration(valueAsString(shortLinks, "unvisited_lifetime", "7d"))
+	if err != nil {
+		return fmt.Errorf("invalid value for short_links.unvisited_lifetime: %w", err)
+	}
+	cfg.ShortLinkMaxIdleTime, err = gtime.ParseDuration(valueAsString(shortLinks, "max_idle_time", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid value for short_links.max_idle_time: %w", err)
+	}
+
+	return nil
+}
+
+func readQueryHistorySettings(iniFile *ini.File, cfg *Cfg) error {
+	queryHistory := iniFile.Section("query_history")
+
+	var err error
+	cfg.QueryHistoryMaxAge, err = gtime.ParseDuration(valueAsString(queryHistory, "max_age", "14d"))
+	if err != nil {
+		return fmt.Errorf("invalid value for query_history.max_age: %w", err)
+	}
+	cfg.QueryHistoryMaxEntriesPerUser = queryHistory.Key("max_entries_per_user").MustInt(1000)
+	if cfg.QueryHistoryMaxEntriesPerUser < 0 {
+		return fmt.Errorf("invalid value for query_history.max_entries_per_user: %d", cfg.QueryHistoryMaxEntriesPerUser)
+	}
+
+	return nil
+}
+
 func (cfg *Cfg) readServerSettings(iniFile *ini.File) error {
 	server := iniFile.Section("server")
 	var err error
@@ -1367,6 +1495,9 @@ func (cfg *Cfg) readServerSettings(iniFile *ini.File) error {
 		if err != nil {
 			return err
 		}
+		if (cfg.CDNRootURL.Scheme != "http" && cfg.CDNRootURL.Scheme != "https") || cfg.CDNRootURL.Host == "" {
+			return fmt.Errorf("cdn_url must be an absolute http or https URL, got %q", cdnURL)
+		}
 	}
 
 	cfg.ReadTimeout = server.Key("read_timeout").MustDuration(0)
@@ -9,6 +9,7 @@ type OrgQuota struct {
 	DataSource int64 `target:"data_source"`
 	Dashboard  int64 `target:"dashboard"`
 	ApiKey     int64 `target:"api_key"`
+	ShortURL   int64 `target:"short_url"`
 }
 
 type UserQuota struct {
@@ -21,6 +22,7 @@ type GlobalQuota struct {
 	DataSource int64 `target:"data_source"`
 	Dashboard  int64 `target:"dashboard"`
 	ApiKey     int64 `target:"api_key"`
+	ShortURL   int64 `target:"short_url"`
 	Session    int64 `target:"-"`
 }
 
@@ -70,6 +72,7 @@ func (cfg *Cfg) readQuotaSettings() {
 		DataSource: quota.Key("org_data_source").MustInt64(10),
 		Dashboard:  quota.Key("org_dashboard").MustInt64(10),
 		ApiKey:     quota.Key("org_api_key").MustInt64(10),
+		ShortURL:   quota.Key("org_short_url").MustInt64(-1),
 	}
 
 	// per User limits
@@ -84,6 +87,7 @@ func (cfg *Cfg) readQuotaSettings() {
 		DataSource: quota.Key("global_data_source").MustInt64(-1),
 		Dashboard:  quota.Key("global_dashboard").MustInt64(-1),
 		ApiKey:     quota.Key("global_api_key").MustInt64(-1),
+		ShortURL:   quota.Key("global_short_url").MustInt64(-1),
 		Session:    quota.Key("global_session").MustInt64(-1),
 	}
 
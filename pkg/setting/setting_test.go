@@ -2,6 +2,7 @@ package setting
 
 import (
 	"bufio"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
@@ -11,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/stretchr/testify/require"
 
 	"gopkg.in/ini.v1"
@@ -327,6 +329,30 @@ func TestParseAppURLAndSubURL(t *testing.T) {
 	}
 }
 
+func TestReloadLogging(t *testing.T) {
+	skipStaticRootValidation = true
+	configFile := filepath.Join(t.TempDir(), "grafana.ini")
+	writeConfig := func(config string) {
+		require.NoError(t, ioutil.WriteFile(configFile, []byte(config), 0600))
+	}
+
+	writeConfig("[log]\nmode = console\nfilters = sqlstore:debug\n")
+	cfg := NewCfg()
+	require.NoError(t, cfg.Load(&CommandLineArgs{HomePath: "../../", Config: configFile}))
+	require.Equal(t, "debug", log.GetLevels()["sqlstore"])
+
+	writeConfig("[log]\nmode = console\nfilters = sqlstore:error\n")
+	require.NoError(t, cfg.ReloadLogging())
+	require.Equal(t, "error", log.GetLevels()["sqlstore"])
+
+	// Invalid config files keep the current logging config.
+	writeConfig("[log\n")
+	require.Error(t, cfg.ReloadLogging())
+	require.Equal(t, "error", log.GetLevels()["sqlstore"])
+
+	require.Error(t, NewCfg().ReloadLogging())
+}
+
 func TestAuthDurationSettings(t *testing.T) {
 	const maxInactiveDaysTest = 240 * time.Hour
 
@@ -427,3 +453,25 @@ func TestGetCDNPathWithAlphaVersion(t *testing.T) {
 	require.Equal(t, "http://cdn.grafana.com/grafana-oss/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana-oss"))
 	require.Equal(t, "http://cdn.grafana.com/grafana/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana"))
 }
+
+func TestCDNURLMustBeAbsolute(t *testing.T) {
+	for cdnURL, valid := range map[string]bool{
+		"https://cdn.grafana.com":     true,
+		"http://cdn.grafana.com/sub":  true,
+		"cdn.grafana.com":             false,
+		"/public":                     false,
+		"ftp://cdn.grafana.com/build": false,
+	} {
+		cfg := NewCfg()
+		err := cfg.Load(&CommandLineArgs{
+			HomePath: "../../",
+			Args:     []string{"cfg:server.cdn_url=" + cdnURL},
+		})
+		if valid {
+			require.NoError(t, err, cdnURL)
+			require.Equal(t, cdnURL, cfg.CDNRootURL.String())
+		} else {
+			require.Error(t, err, cdnURL)
+		}
+	}
+}
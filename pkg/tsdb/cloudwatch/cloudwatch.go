@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
@@ -18,6 +19,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/aws/aws-sdk-go/service/oam/oamiface"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -31,6 +34,12 @@ import (
 const cloudWatchTSFormat = "2006-01-02 15:04:05.000"
 const defaultRegion = "default"
 
+// allAccounts is the account ID used by queries spanning the monitoring account and all its linked accounts.
+const allAccounts = "all"
+
+// accountIdDimension is the label added to series returned for a specific account.
+const accountIdDimension = "AccountId"
+
 // Constants also defined in datasource/cloudwatch/datasource.ts
 const logIdentifierInternal = "__log__grafana_internal__"
 const logStreamIdentifierInternal = "__logstream__grafana_internal__"
@@ -80,6 +89,10 @@ type cloudWatchExecutor struct {
 	ec2Client  ec2iface.EC2API
 	rgtaClient resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
 
+	// oamClients are the OAM clients of the regions, see getOAMClient.
+	oamClients     map[string]oamiface.OAMAPI
+	oamClientsLock sync.Mutex
+
 	logsService *LogsService
 	cfg         *setting.Cfg
 	sessions    SessionCache
@@ -139,6 +152,27 @@ func (e *cloudWatchExecutor) getRGTAClient(region string) (resourcegroupstagging
 	return e.rgtaClient, nil
 }
 
+// getOAMClient returns the OAM client of the region, which is created once per region.
+func (e *cloudWatchExecutor) getOAMClient(region string) (oamiface.OAMAPI, error) {
+	e.oamClientsLock.Lock()
+	defer e.oamClientsLock.Unlock()
+
+	if client, ok := e.oamClients[region]; ok {
+		return client, nil
+	}
+
+	sess, err := e.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	if e.oamClients == nil {
+		e.oamClients = make(map[string]oamiface.OAMAPI)
+	}
+	e.oamClients[region] = newOAMClient(sess)
+
+	return e.oamClients[region], nil
+}
+
 func (e *cloudWatchExecutor) alertQuery(ctx context.Context, logsClient cloudwatchlogsiface.CloudWatchLogsAPI,
 	queryContext plugins.DataQuery) (*cloudwatchlogs.GetQueryResultsOutput, error) {
 	const maxAttempts = 8
@@ -372,3 +406,10 @@ var newEC2Client = func(provider client.ConfigProvider) ec2iface.EC2API {
 var newRGTAClient = func(provider client.ConfigProvider) resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
 	return resourcegroupstaggingapi.New(provider)
 }
+
+// OAM client factory.
+//
+// Stubbable by tests.
+var newOAMClient = func(provider client.ConfigProvider) oamiface.OAMAPI {
+	return oam.New(provider)
+}
@@ -19,6 +19,13 @@ type cloudWatchQuery struct {
 	MatchExact              bool
 	UsedExpression          string
 	RequestExceededMaxLimit bool
+	AccountId               string
+}
+
+// isSingleAccountQuery returns true if the query targets one specific account, either the monitoring
+// account itself or one of its linked source accounts.
+func (q *cloudWatchQuery) isSingleAccountQuery() bool {
+	return q.AccountId != "" && q.AccountId != allAccounts
 }
 
 func (q *cloudWatchQuery) isMathExpression() bool {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMetricDataQueryBuilder_buildSearchExpression(t *testing.T) {
@@ -211,4 +212,55 @@ func TestMetricDataQueryBuilder_buildSearchExpression(t *testing.T) {
 
 		assert.Contains(t, res, `lb4\"\"`, "Expected escape double quotes")
 	})
+	t.Run("Query targets a single linked account", func(t *testing.T) {
+		query := &cloudWatchQuery{
+			Namespace:  "AWS/EC2",
+			MetricName: "CPUUtilization",
+			Dimensions: map[string][]string{
+				"InstanceId": {"*"},
+			},
+			Period:     300,
+			MatchExact: true,
+			AccountId:  "123456789012",
+		}
+
+		res := buildSearchExpression(query, "Average")
+		assert.Equal(t, `REMOVE_EMPTY(SEARCH('{"AWS/EC2","InstanceId"} MetricName="CPUUtilization" :aws.AccountId="123456789012"', 'Average', 300))`, res)
+	})
+
+	t.Run("Query targets all linked accounts", func(t *testing.T) {
+		query := &cloudWatchQuery{
+			Namespace:  "AWS/EC2",
+			MetricName: "CPUUtilization",
+			Period:     300,
+			MatchExact: false,
+			AccountId:  "all",
+		}
+
+		res := buildSearchExpression(query, "Average")
+		assert.NotContains(t, res, "aws.AccountId")
+	})
+}
+
+func TestMetricDataQueryBuilder_buildMetricDataQuery(t *testing.T) {
+	t.Run("Metric stat query sets the account ID", func(t *testing.T) {
+		query := &cloudWatchQuery{
+			Id:         "a",
+			Namespace:  "AWS/EC2",
+			MetricName: "CPUUtilization",
+			Dimensions: map[string][]string{
+				"InstanceId": {"i-123"},
+			},
+			Stats:      "Average",
+			Period:     300,
+			MatchExact: true,
+			AccountId:  "123456789012",
+		}
+
+		executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+		mdq, err := executor.buildMetricDataQuery(query)
+		require.NoError(t, err)
+		require.NotNil(t, mdq.MetricStat)
+		assert.Equal(t, "123456789012", *mdq.AccountId)
+	})
 }
@@ -11,9 +11,11 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/oam"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/metrics"
@@ -266,6 +268,8 @@ func (e *cloudWatchExecutor) executeMetricFindQuery(ctx context.Context, queryCo
 		data, err = e.handleGetEc2InstanceAttribute(ctx, parameters, queryContext)
 	case "resource_arns":
 		data, err = e.handleGetResourceArns(ctx, parameters, queryContext)
+	case "accounts":
+		data, err = e.handleGetAccounts(ctx, parameters, queryContext)
 	}
 	if err != nil {
 		return plugins.DataResponse{}, err
@@ -444,6 +448,7 @@ func (e *cloudWatchExecutor) handleGetDimensionValues(ctx context.Context, param
 	metricName := parameters.Get("metricName").MustString()
 	dimensionKey := parameters.Get("dimensionKey").MustString()
 	dimensionsJson := parameters.Get("dimensions").MustMap()
+	accountId := parameters.Get("accountId").MustString()
 
 	var dimensions []*cloudwatch.DimensionFilter
 	for k, v := range dimensionsJson {
@@ -469,14 +474,25 @@ func (e *cloudWatchExecutor) handleGetDimensionValues(ctx context.Context, param
 	if metricName != "" {
 		params.MetricName = aws.String(metricName)
 	}
-	metrics, err := e.listMetrics(region, params)
+	setAccountParams(params, accountId)
+	metrics, owningAccounts, err := e.listMetricsWithAccounts(region, params)
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]suggestData, 0)
 	dupCheck := make(map[string]bool)
-	for _, metric := range metrics {
+	for i, metric := range metrics {
+		// The account a metric belongs to is not one of its dimensions, but for a monitoring account
+		// it can be used the same way to tell linked accounts apart.
+		if dimensionKey == accountIdDimension && i < len(owningAccounts) && owningAccounts[i] != nil {
+			if _, exists := dupCheck[*owningAccounts[i]]; !exists {
+				dupCheck[*owningAccounts[i]] = true
+				result = append(result, suggestData{Text: *owningAccounts[i], Value: *owningAccounts[i]})
+			}
+			continue
+		}
+
 		for _, dim := range metric.Dimensions {
 			if *dim.Name == dimensionKey {
 				if _, exists := dupCheck[*dim.Value]; exists {
@@ -639,13 +655,22 @@ func (e *cloudWatchExecutor) handleGetResourceArns(ctx context.Context, paramete
 }
 
 func (e *cloudWatchExecutor) listMetrics(region string, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error) {
+	cloudWatchMetrics, _, err := e.listMetricsWithAccounts(region, params)
+	return cloudWatchMetrics, err
+}
+
+// listMetricsWithAccounts lists metrics along with the ID of the account owning each of them.
+// Owning accounts are only returned by CloudWatch when linked accounts are included in the request.
+func (e *cloudWatchExecutor) listMetricsWithAccounts(region string, params *cloudwatch.ListMetricsInput) (
+	[]*cloudwatch.Metric, []*string, error) {
 	client, err := e.getCWClient(region)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	plog.Debug("Listing metrics pages")
 	cloudWatchMetrics := []*cloudwatch.Metric{}
+	owningAccounts := []*string{}
 
 	pageNum := 0
 	err = client.ListMetricsPages(params, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
@@ -657,10 +682,82 @@ func (e *cloudWatchExecutor) listMetrics(region string, params *cloudwatch.ListM
 				cloudWatchMetrics = append(cloudWatchMetrics, metric.(*cloudwatch.Metric))
 			}
 		}
+		owningAccounts = append(owningAccounts, page.OwningAccounts...)
 		return !lastPage && pageNum < e.cfg.AWSListMetricsPageLimit
 	})
 
-	return cloudWatchMetrics, err
+	return cloudWatchMetrics, owningAccounts, err
+}
+
+// setAccountParams makes a ListMetrics request cover the linked accounts of a monitoring account.
+// An empty account ID keeps the request scoped to the account of the data source credentials.
+func setAccountParams(params *cloudwatch.ListMetricsInput, accountId string) {
+	if accountId == "" {
+		return
+	}
+
+	params.IncludeLinkedAccounts = aws.Bool(true)
+	if accountId != allAccounts {
+		params.OwningAccount = aws.String(accountId)
+	}
+}
+
+// handleGetAccounts lists the monitoring account and the source accounts linked to it through
+// CloudWatch cross-account observability. An account that is not a monitoring account has no sinks,
+// in which case no accounts are returned.
+func (e *cloudWatchExecutor) handleGetAccounts(ctx context.Context, parameters *simplejson.Json,
+	queryContext plugins.DataQuery) ([]suggestData, error) {
+	region := parameters.Get("region").MustString()
+
+	client, err := e.getOAMClient(region)
+	if err != nil {
+		return nil, err
+	}
+
+	var sinks []*oam.ListSinksItem
+	if err := client.ListSinksPagesWithContext(ctx, &oam.ListSinksInput{},
+		func(page *oam.ListSinksOutput, lastPage bool) bool {
+			sinks = append(sinks, page.Items...)
+			return !lastPage
+		}); err != nil {
+		return nil, fmt.Errorf("failed to call oam:ListSinks, %w", err)
+	}
+
+	result := make([]suggestData, 0)
+	if len(sinks) == 0 {
+		return result, nil
+	}
+
+	sinkArn, err := arn.Parse(*sinks[0].Arn)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, suggestData{Text: sinkArn.AccountID + " (monitoring account)", Value: sinkArn.AccountID})
+
+	var links []*oam.ListAttachedLinksItem
+	if err := client.ListAttachedLinksPagesWithContext(ctx,
+		&oam.ListAttachedLinksInput{SinkIdentifier: sinks[0].Arn},
+		func(page *oam.ListAttachedLinksOutput, lastPage bool) bool {
+			links = append(links, page.Items...)
+			return !lastPage
+		}); err != nil {
+		return nil, fmt.Errorf("failed to call oam:ListAttachedLinks, %w", err)
+	}
+
+	for _, link := range links {
+		linkArn, err := arn.Parse(*link.LinkArn)
+		if err != nil {
+			return nil, err
+		}
+
+		text := linkArn.AccountID
+		if link.Label != nil && *link.Label != "" {
+			text = fmt.Sprintf("%s (%s)", *link.Label, linkArn.AccountID)
+		}
+		result = append(result, suggestData{Text: text, Value: linkArn.AccountID})
+	}
+
+	return result, nil
 }
 
 func (e *cloudWatchExecutor) ec2DescribeInstances(region string, filters []*ec2.Filter, instanceIds []*string) (*ec2.DescribeInstancesOutput, error) {
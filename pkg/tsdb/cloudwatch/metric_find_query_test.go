@@ -11,6 +11,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/aws/aws-sdk-go/service/oam/oamiface"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -547,3 +549,150 @@ func TestQuery_ListMetricsPagination(t *testing.T) {
 		assert.Equal(t, len(metrics), len(response))
 	})
 }
+
+func TestQuery_Accounts(t *testing.T) {
+	origNewOAMClient := newOAMClient
+	t.Cleanup(func() {
+		newOAMClient = origNewOAMClient
+	})
+
+	var cli fakeOAMClient
+
+	newOAMClient = func(client.ConfigProvider) oamiface.OAMAPI {
+		return cli
+	}
+
+	query := plugins.DataQuery{
+		Queries: []plugins.DataSubQuery{
+			{
+				Model: simplejson.NewFromAny(map[string]interface{}{
+					"type":    "metricFindQuery",
+					"subtype": "accounts",
+					"region":  "us-east-1",
+				}),
+			},
+		},
+	}
+
+	t.Run("Monitoring account with linked accounts", func(t *testing.T) {
+		cli = fakeOAMClient{
+			sinks: []*oam.ListSinksItem{
+				{Arn: aws.String("arn:aws:oam:us-east-1:111111111111:sink/abc")},
+			},
+			links: []*oam.ListAttachedLinksItem{
+				{LinkArn: aws.String("arn:aws:oam:us-east-1:222222222222:link/def"), Label: aws.String("staging")},
+				{LinkArn: aws.String("arn:aws:oam:us-east-1:333333333333:link/ghi")},
+			},
+		}
+		executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+		resp, err := executor.DataQuery(context.Background(), fakeDataSource(), query)
+		require.NoError(t, err)
+
+		assert.Equal(t, []plugins.DataRowValues{
+			{"111111111111 (monitoring account)", "111111111111"},
+			{"staging (222222222222)", "222222222222"},
+			{"333333333333", "333333333333"},
+		}, resp.Results[""].Tables[0].Rows)
+	})
+
+	t.Run("Account without sinks is not a monitoring account", func(t *testing.T) {
+		cli = fakeOAMClient{}
+		executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+		resp, err := executor.DataQuery(context.Background(), fakeDataSource(), query)
+		require.NoError(t, err)
+
+		assert.Empty(t, resp.Results[""].Tables[0].Rows)
+	})
+}
+
+func TestGetOAMClient(t *testing.T) {
+	origNewOAMClient := newOAMClient
+	t.Cleanup(func() {
+		newOAMClient = origNewOAMClient
+	})
+
+	var created []*fakeOAMClient
+	newOAMClient = func(client.ConfigProvider) oamiface.OAMAPI {
+		cli := &fakeOAMClient{}
+		created = append(created, cli)
+		return cli
+	}
+
+	executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+	executor.DataSource = fakeDataSource()
+
+	usEast, err := executor.getOAMClient("us-east-1")
+	require.NoError(t, err)
+	euWest, err := executor.getOAMClient("eu-west-1")
+	require.NoError(t, err)
+	usEastAgain, err := executor.getOAMClient("us-east-1")
+	require.NoError(t, err)
+
+	require.Len(t, created, 2)
+	assert.Same(t, created[0], usEast)
+	assert.Same(t, created[1], euWest)
+	assert.Same(t, usEast, usEastAgain)
+}
+
+func TestQuery_DimensionValuesForLinkedAccounts(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+
+	client := FakeCWClient{
+		Metrics: []*cloudwatch.Metric{
+			{
+				MetricName: aws.String("CPUUtilization"),
+				Dimensions: []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}},
+			},
+			{
+				MetricName: aws.String("CPUUtilization"),
+				Dimensions: []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-2")}},
+			},
+		},
+		OwningAccounts: []*string{aws.String("111111111111"), aws.String("222222222222")},
+	}
+
+	NewCWClient = func(sess *session.Session) cloudwatchiface.CloudWatchAPI {
+		return client
+	}
+
+	t.Run("Account IDs can be listed as a dimension", func(t *testing.T) {
+		executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+		resp, err := executor.DataQuery(context.Background(), fakeDataSource(), plugins.DataQuery{
+			Queries: []plugins.DataSubQuery{
+				{
+					Model: simplejson.NewFromAny(map[string]interface{}{
+						"type":         "metricFindQuery",
+						"subtype":      "dimension_values",
+						"region":       "us-east-1",
+						"namespace":    "AWS/EC2",
+						"metricName":   "CPUUtilization",
+						"dimensionKey": "AccountId",
+						"accountId":    "all",
+					}),
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []plugins.DataRowValues{
+			{"111111111111", "111111111111"},
+			{"222222222222", "222222222222"},
+		}, resp.Results[""].Tables[0].Rows)
+	})
+
+	t.Run("Linked accounts are only included when an account is requested", func(t *testing.T) {
+		params := &cloudwatch.ListMetricsInput{}
+		setAccountParams(params, "")
+		assert.Nil(t, params.IncludeLinkedAccounts)
+
+		setAccountParams(params, "all")
+		assert.True(t, *params.IncludeLinkedAccounts)
+		assert.Nil(t, params.OwningAccount)
+
+		setAccountParams(params, "222222222222")
+		assert.Equal(t, "222222222222", *params.OwningAccount)
+	})
+}
@@ -47,6 +47,7 @@ func (e *cloudWatchExecutor) transformRequestQueriesToCloudWatchQueries(requestQ
 				Expression: requestQuery.Expression,
 				ReturnData: requestQuery.ReturnData,
 				MatchExact: requestQuery.MatchExact,
+				AccountId:  requestQuery.AccountId,
 			}
 			cloudwatchQueries[id] = query
 		}
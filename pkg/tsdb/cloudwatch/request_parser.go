@@ -104,6 +104,7 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 	}
 
 	matchExact := model.Get("matchExact").MustBool(true)
+	accountId := model.Get("accountId").MustString("")
 
 	return &requestQuery{
 		RefId:      refId,
@@ -118,6 +119,7 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 		Expression: expression,
 		ReturnData: returnData,
 		MatchExact: matchExact,
+		AccountId:  accountId,
 	}, nil
 }
 
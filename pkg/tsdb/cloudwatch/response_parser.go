@@ -144,6 +144,10 @@ func parseMetricResults(results map[string]*cloudwatch.MetricDataResult, labels
 				}
 			}
 
+			if query.isSingleAccountQuery() {
+				tags[accountIdDimension] = query.AccountId
+			}
+
 			timestamps := []*time.Time{}
 			points := []*float64{}
 			for j, t := range result.Timestamps {
@@ -73,6 +73,39 @@ func TestCloudWatchResponseParser(t *testing.T) {
 		assert.Equal(t, "lb2", frame2.Fields[1].Labels["LoadBalancer"])
 	})
 
+	t.Run("Account ID is added as a label for single account queries", func(t *testing.T) {
+		timestamp := time.Unix(0, 0)
+		labels := []string{"lb1"}
+		mdrs := map[string]*cloudwatch.MetricDataResult{
+			"lb1": {
+				Id:         aws.String("id1"),
+				Label:      aws.String("lb1"),
+				Timestamps: []*time.Time{aws.Time(timestamp)},
+				Values:     []*float64{aws.Float64(10)},
+				StatusCode: aws.String("Complete"),
+			},
+		}
+
+		query := &cloudWatchQuery{
+			RefId:      "refId1",
+			Region:     "us-east-1",
+			Namespace:  "AWS/ApplicationELB",
+			MetricName: "TargetResponseTime",
+			Dimensions: map[string][]string{
+				"LoadBalancer": {"lb1"},
+			},
+			Stats:     "Average",
+			Period:    60,
+			Alias:     "{{LoadBalancer}} in {{AccountId}}",
+			AccountId: "123456789012",
+		}
+		frames, _, err := parseMetricResults(mdrs, labels, query)
+		require.NoError(t, err)
+
+		assert.Equal(t, "lb1 in 123456789012", frames[0].Name)
+		assert.Equal(t, "123456789012", frames[0].Fields[1].Labels["AccountId"])
+	})
+
 	t.Run("Expand dimension value using substring", func(t *testing.T) {
 		timestamp := time.Unix(0, 0)
 		labels := []string{"lb1 Sum", "lb2 Average"}
@@ -12,6 +12,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/aws/aws-sdk-go/service/oam/oamiface"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
@@ -81,6 +83,8 @@ type FakeCWClient struct {
 	cloudwatchiface.CloudWatchAPI
 
 	Metrics []*cloudwatch.Metric
+	// OwningAccounts holds the account ID of each metric, returned when linked accounts are included.
+	OwningAccounts []*string
 
 	MetricsPerPage int
 }
@@ -91,10 +95,17 @@ func (c FakeCWClient) ListMetricsPages(input *cloudwatch.ListMetricsInput, fn fu
 	}
 	chunks := chunkSlice(c.Metrics, c.MetricsPerPage)
 
+	offset := 0
 	for i, metrics := range chunks {
-		response := fn(&cloudwatch.ListMetricsOutput{
+		output := &cloudwatch.ListMetricsOutput{
 			Metrics: metrics,
-		}, i+1 == len(chunks))
+		}
+		if input.IncludeLinkedAccounts != nil && *input.IncludeLinkedAccounts && len(c.OwningAccounts) > 0 {
+			output.OwningAccounts = c.OwningAccounts[offset : offset+len(metrics)]
+		}
+		offset += len(metrics)
+
+		response := fn(output, i+1 == len(chunks))
 		if !response {
 			break
 		}
@@ -161,6 +172,29 @@ func (c fakeRGTAClient) GetResourcesPages(in *resourcegroupstaggingapi.GetResour
 	return nil
 }
 
+type fakeOAMClient struct {
+	oamiface.OAMAPI
+
+	sinks []*oam.ListSinksItem
+	links []*oam.ListAttachedLinksItem
+}
+
+func (c fakeOAMClient) ListSinksPagesWithContext(ctx context.Context, in *oam.ListSinksInput,
+	fn func(*oam.ListSinksOutput, bool) bool, opts ...request.Option) error {
+	fn(&oam.ListSinksOutput{
+		Items: c.sinks,
+	}, true)
+	return nil
+}
+
+func (c fakeOAMClient) ListAttachedLinksPagesWithContext(ctx context.Context, in *oam.ListAttachedLinksInput,
+	fn func(*oam.ListAttachedLinksOutput, bool) bool, opts ...request.Option) error {
+	fn(&oam.ListAttachedLinksOutput{
+		Items: c.links,
+	}, true)
+	return nil
+}
+
 func chunkSlice(slice []*cloudwatch.Metric, chunkSize int) [][]*cloudwatch.Metric {
 	var chunks [][]*cloudwatch.Metric
 	for {
@@ -21,6 +21,7 @@ type requestQuery struct {
 	Period             int
 	Alias              string
 	MatchExact         bool
+	AccountId          string
 }
 
 type cloudwatchResponse struct {
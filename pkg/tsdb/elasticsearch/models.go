@@ -1,6 +1,8 @@
 package elasticsearch
 
 import (
+	"strconv"
+
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
@@ -51,6 +53,7 @@ var metricAggType = map[string]string{
 	"serial_diff":    "Serial Difference",
 	"bucket_script":  "Bucket Script",
 	"raw_document":   "Raw Document",
+	"raw_data":       "Raw Data",
 }
 
 var extendedStats = map[string]string{
@@ -91,6 +94,31 @@ func isPipelineAggWithMultipleBucketPaths(metricType string) bool {
 	return false
 }
 
+// isDocumentQuery returns true if the query fetches raw documents rather than aggregations.
+func isDocumentQuery(q *Query) bool {
+	if len(q.BucketAggs) > 0 || len(q.Metrics) == 0 {
+		return false
+	}
+	metricType := q.Metrics[0].Type
+	return metricType == rawDocumentType || metricType == rawDataType
+}
+
+// documentQuerySize returns the number of documents to fetch for a raw document or raw data query.
+// The size is stored as a string by the query editor, but may be a number in provisioned dashboards.
+func documentQuerySize(metric *MetricAgg) int {
+	size := metric.Settings.Get("size")
+	if s, err := size.String(); err == nil {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			return v
+		}
+		return defaultDocumentQuerySize
+	}
+	if v := size.MustInt(defaultDocumentQuerySize); v > 0 {
+		return v
+	}
+	return defaultDocumentQuerySize
+}
+
 func describeMetric(metricType, field string) string {
 	text := metricAggType[metricType]
 	if metricType == countType {
@@ -18,12 +18,16 @@ const (
 	countType         = "count"
 	percentilesType   = "percentiles"
 	extendedStatsType = "extended_stats"
+	rawDocumentType   = "raw_document"
+	rawDataType       = "raw_data"
 	// Bucket types
 	dateHistType    = "date_histogram"
 	histogramType   = "histogram"
 	filtersType     = "filters"
 	termsType       = "terms"
 	geohashGridType = "geohash_grid"
+
+	defaultDocumentQuerySize = 500
 )
 
 type responseParser struct {
@@ -66,6 +70,15 @@ func (rp *responseParser) getTimeSeries() (plugins.DataResponse, error) {
 		queryRes := plugins.DataQueryResult{
 			Meta: debugInfo,
 		}
+
+		if isDocumentQuery(target) {
+			if res.Hits != nil && len(res.Hits.Hits) > 0 {
+				queryRes.Tables = append(queryRes.Tables, processHits(res.Hits.Hits))
+			}
+			result.Results[target.RefID] = queryRes
+			continue
+		}
+
 		props := make(map[string]string)
 		table := plugins.DataTable{
 			Columns: make([]plugins.DataTableColumn, 0),
@@ -87,6 +100,75 @@ func (rp *responseParser) getTimeSeries() (plugins.DataResponse, error) {
 	return result, nil
 }
 
+// processHits turns the documents of a raw document or raw data query into a table. Nested
+// _source properties are flattened into columns named `level1.level2...`, and since documents
+// don't need to share the same properties, missing values are left empty.
+func processHits(hits []map[string]interface{}) plugins.DataTable {
+	docs := make([]map[string]interface{}, 0, len(hits))
+	propNames := map[string]bool{}
+
+	for _, hit := range hits {
+		doc := map[string]interface{}{
+			"_id":    hit["_id"],
+			"_type":  hit["_type"],
+			"_index": hit["_index"],
+		}
+
+		if source, ok := hit["_source"].(map[string]interface{}); ok {
+			flattenDoc(doc, "", source)
+		}
+
+		if fields, ok := hit["fields"].(map[string]interface{}); ok {
+			for name, value := range fields {
+				doc[name] = value
+			}
+		}
+
+		for name := range doc {
+			propNames[name] = true
+		}
+		docs = append(docs, doc)
+	}
+
+	columns := make([]string, 0, len(propNames))
+	for name := range propNames {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	table := plugins.DataTable{
+		Columns: make([]plugins.DataTableColumn, 0, len(columns)),
+		Rows:    make([]plugins.DataRowValues, 0, len(docs)),
+	}
+	for _, name := range columns {
+		table.Columns = append(table.Columns, plugins.DataTableColumn{Text: name})
+	}
+	for _, doc := range docs {
+		values := make(plugins.DataRowValues, 0, len(columns))
+		for _, name := range columns {
+			values = append(values, doc[name])
+		}
+		table.Rows = append(table.Rows, values)
+	}
+
+	return table
+}
+
+func flattenDoc(target map[string]interface{}, prefix string, source map[string]interface{}) {
+	for key, value := range source {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenDoc(target, name, nested)
+			continue
+		}
+		target[name] = value
+	}
+}
+
 func (rp *responseParser) processBuckets(aggs map[string]interface{}, target *Query,
 	series *plugins.DataTimeSeriesSlice, table *plugins.DataTable, props map[string]string, depth int) error {
 	var err error
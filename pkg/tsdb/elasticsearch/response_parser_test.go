@@ -16,6 +16,57 @@ import (
 
 func TestResponseParser(t *testing.T) {
 	Convey("Elasticsearch response parser test", t, func() {
+		Convey("Raw data query", func() {
+			targets := map[string]string{
+				"A": `{
+					"timeField": "@timestamp",
+					"metrics": [{ "type": "raw_data", "id": "1" }],
+					"bucketAggs": []
+				}`,
+			}
+			response := `{
+				"responses": [
+					{
+						"hits": {
+							"total": 2,
+							"hits": [
+								{
+									"_id": "1",
+									"_type": "_doc",
+									"_index": "logs",
+									"_source": { "@timestamp": "2021-01-01T00:00:00Z", "host": { "name": "a" } }
+								},
+								{
+									"_id": "2",
+									"_type": "_doc",
+									"_index": "logs",
+									"_source": { "@timestamp": "2021-01-01T00:00:01Z", "message": "hello" }
+								}
+							]
+						}
+					}
+				]
+			}`
+			rp, err := newResponseParserForTest(targets, response)
+			So(err, ShouldBeNil)
+			result, err := rp.getTimeSeries()
+			So(err, ShouldBeNil)
+
+			queryRes := result.Results["A"]
+			So(queryRes.Series, ShouldHaveLength, 0)
+			So(queryRes.Tables, ShouldHaveLength, 1)
+
+			table := queryRes.Tables[0]
+			So(table.Columns, ShouldHaveLength, 6)
+			So(table.Columns[0].Text, ShouldEqual, "@timestamp")
+			So(table.Columns[4].Text, ShouldEqual, "host.name")
+			So(table.Columns[5].Text, ShouldEqual, "message")
+			So(table.Rows, ShouldHaveLength, 2)
+			So(table.Rows[0][4], ShouldEqual, "a")
+			So(table.Rows[0][5], ShouldBeNil)
+			So(table.Rows[1][5], ShouldEqual, "hello")
+		})
+
 		Convey("Simple query and count", func() {
 			targets := map[string]string{
 				"A": `{
@@ -78,7 +78,7 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 	}
 
 	if len(q.BucketAggs) == 0 {
-		if len(q.Metrics) == 0 || q.Metrics[0].Type != "raw_document" {
+		if !isDocumentQuery(q) {
 			result.Results[q.RefID] = plugins.DataQueryResult{
 				RefID:       q.RefID,
 				Error:       fmt.Errorf("invalid query, missing metrics and aggregations"),
@@ -87,9 +87,9 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 			return nil
 		}
 		metric := q.Metrics[0]
-		b.Size(metric.Settings.Get("size").MustInt(500))
-		b.SortDesc("@timestamp", "boolean")
-		b.AddDocValueField("@timestamp")
+		b.Size(documentQuerySize(metric))
+		b.SortDesc(e.client.GetTimeField(), "boolean")
+		b.AddDocValueField(e.client.GetTimeField())
 		return nil
 	}
 
@@ -377,6 +377,22 @@ func TestExecuteTimeSeriesQuery(t *testing.T) {
 			So(sr.Size, ShouldEqual, 1337)
 		})
 
+		Convey("With raw data metric and custom time field", func() {
+			c := newFakeClient(7)
+			c.timeField = "timestamp"
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "timestamp",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "raw_data", "settings": { "size": "100" }	}]
+			}`, from, to, 15*time.Second)
+			So(err, ShouldBeNil)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			So(sr.Size, ShouldEqual, 100)
+			So(sr.Sort, ShouldContainKey, "timestamp")
+			So(sr.CustomProps["docvalue_fields"], ShouldResemble, []string{"timestamp"})
+		})
+
 		Convey("With date histogram agg", func() {
 			c := newFakeClient(5)
 			_, err := executeTsdbQuery(c, `{
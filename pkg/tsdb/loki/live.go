@@ -0,0 +1,125 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// maxTailDuration bounds how long a single live tail keeps its connection to Loki open.
+const maxTailDuration = 15 * time.Minute
+
+// TailRunnerSupplier manages the `plugin/loki/*` channels.
+type TailRunnerSupplier struct {
+	Publisher models.ChannelPublisher
+	Service   *Service
+
+	runnerOnce sync.Once
+	runner     *tailRunner
+}
+
+type tailRunner struct {
+	publish   models.ChannelPublisher
+	service   *Service
+	running   map[string]bool
+	runningMu sync.Mutex
+}
+
+// GetHandlerForPath gets the channel handler for a certain path. All the channels
+// share the runner of the supplier, which runs one tail per channel.
+func (s *TailRunnerSupplier) GetHandlerForPath(path string) (models.ChannelHandler, error) {
+	s.runnerOnce.Do(func() {
+		s.runner = &tailRunner{
+			publish: s.Publisher,
+			service: s.Service,
+			running: make(map[string]bool),
+		}
+	})
+	return s.runner, nil
+}
+
+// OnSubscribe starts tailing the Loki query registered for the channel.
+func (r *tailRunner) OnSubscribe(c *centrifuge.Client, e centrifuge.SubscribeEvent) (centrifuge.SubscribeReply, error) {
+	r.runningMu.Lock()
+	defer r.runningMu.Unlock()
+
+	if _, ok := r.running[e.Channel]; ok {
+		return centrifuge.SubscribeReply{}, nil
+	}
+
+	user, ok := livecontext.GetContextSignedUser(c.Context())
+	if !ok {
+		return centrifuge.SubscribeReply{}, centrifuge.ErrorUnauthorized
+	}
+
+	tail, err := r.service.takeTail(e.Channel, user.OrgId)
+	if err != nil {
+		return centrifuge.SubscribeReply{}, err
+	}
+
+	r.running[e.Channel] = true
+	go func() {
+		if err := r.publishTail(e.Channel, tail); err != nil {
+			plog.Error("Live tail failed", "channel", e.Channel, "error", err)
+		}
+	}()
+
+	return centrifuge.SubscribeReply{}, nil
+}
+
+// OnPublish checks if a message from the websocket can be broadcast on this channel
+func (r *tailRunner) OnPublish(c *centrifuge.Client, e centrifuge.PublishEvent) (centrifuge.PublishReply, error) {
+	return centrifuge.PublishReply{}, fmt.Errorf("can not publish")
+}
+
+func (r *tailRunner) publishTail(channelName string, tail *tailRequest) error {
+	defer func() {
+		r.runningMu.Lock()
+		delete(r.running, channelName)
+		r.runningMu.Unlock()
+	}()
+
+	query := tail.query
+	conn, err := tail.settings.client().LiveTailQueryConn(query.Expr, 0, query.MaxLines, query.Start.UnixNano(), true)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maxTailDuration)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		if err := conn.Close(); err != nil {
+			plog.Debug("Failed to close live tail connection", "error", err)
+		}
+	}()
+
+	for {
+		var resp loghttp.TailResponse
+		if err := conn.ReadJSON(&resp); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if len(resp.DroppedStreams) > 0 {
+			plog.Debug("Live tail dropped entries", "channel", channelName, "count", len(resp.DroppedStreams))
+		}
+
+		msg, err := marshalFrames(query.RefID, streamsToFrames(resp.Streams, query))
+		if err != nil {
+			return err
+		}
+
+		if err := r.publish(channelName, msg); err != nil {
+			return err
+		}
+	}
+}
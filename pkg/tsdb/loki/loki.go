@@ -2,15 +2,24 @@ package loki
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/google/uuid"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/grafana/loki/pkg/loghttp"
@@ -19,69 +28,193 @@ import (
 	"github.com/prometheus/common/model"
 )
 
-type LokiExecutor struct {
+const (
+	// defaultMaxLines is used for log queries when neither the query nor the data source set a limit.
+	defaultMaxLines = 1000
+	// tailChannelPrefix is the Grafana Live channel namespace used for live tailing.
+	tailChannelPrefix = "plugin/loki/"
+	// tailRequestTTL is how long a live tail query waits for a subscriber before it's dropped.
+	tailRequestTTL = time.Minute
+)
+
+var (
+	plog         = log.New("tsdb.loki")
+	legendFormat = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
+)
+
+func init() {
+	registry.RegisterService(&Service{})
+}
+
+// Service executes Loki queries as a core backend plugin and keeps track of
+// pending live tail requests until a Grafana Live client subscribes to them.
+type Service struct {
+	BackendPluginManager backendplugin.Manager `inject:""`
+
 	intervalCalculator interval.Calculator
+	tailsMu            sync.Mutex
+	tails              map[string]*tailRequest
+}
+
+// tailRequest is a live tail query waiting for a subscriber of the org that ran it.
+type tailRequest struct {
+	orgID    int64
+	query    *lokiQuery
+	settings *datasourceSettings
+	expires  time.Time
 }
 
-func NewExecutor(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-	return newExecutor(), nil
+// datasourceSettings holds the connection details of a Loki data source.
+type datasourceSettings struct {
+	URL               string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	MaxLines          int
+	JSONData          *simplejson.Json
 }
 
-func newExecutor() *LokiExecutor {
-	return &LokiExecutor{
-		intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
+// Init is called by the DI framework to initialize the instance.
+func (s *Service) Init() error {
+	s.intervalCalculator = interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1})
+	s.tails = make(map[string]*tailRequest)
+
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler: s,
+	})
+	if err := s.BackendPluginManager.Register("loki", factory); err != nil {
+		plog.Error("Failed to register plugin", "error", err)
 	}
+	return nil
 }
 
-var (
-	plog         = log.New("tsdb.loki")
-	legendFormat = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
-)
+func newDatasourceSettings(instance *backend.DataSourceInstanceSettings) (*datasourceSettings, error) {
+	if instance == nil {
+		return nil, fmt.Errorf("missing data source instance settings")
+	}
 
-// DataQuery executes a Loki query.
-func (e *LokiExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
-	queryContext plugins.DataQuery) (plugins.DataResponse, error) {
-	result := plugins.DataResponse{
-		Results: map[string]plugins.DataQueryResult{},
+	jsonData, err := simplejson.NewJson(instance.JSONData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data source settings: %w", err)
 	}
 
-	client := &client.DefaultClient{
-		Address:  dsInfo.Url,
-		Username: dsInfo.BasicAuthUser,
-		Password: dsInfo.DecryptedBasicAuthPassword(),
+	password := instance.DecryptedSecureJSONData["basicAuthPassword"]
+	return &datasourceSettings{
+		URL:               instance.URL,
+		BasicAuthUser:     instance.BasicAuthUser,
+		BasicAuthPassword: password,
+		MaxLines:          jsonData.Get("maxLines").MustInt(defaultMaxLines),
+		JSONData:          jsonData,
+	}, nil
+}
+
+func (ds *datasourceSettings) client() *client.DefaultClient {
+	return &client.DefaultClient{
+		Address:  ds.URL,
+		Username: ds.BasicAuthUser,
+		Password: ds.BasicAuthPassword,
 	}
+}
 
-	queries, err := e.parseQuery(dsInfo, queryContext)
+// QueryData executes Loki queries.
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	settings, err := newDatasourceSettings(req.PluginContext.DataSourceInstanceSettings)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
+	queries, err := s.parseQuery(settings, req.Queries)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := backend.NewQueryDataResponse()
+	lokiClient := settings.client()
 	for _, query := range queries {
-		plog.Debug("Sending query", "start", query.Start, "end", query.End, "step", query.Step, "query", query.Expr)
-		span, _ := opentracing.StartSpanFromContext(ctx, "alerting.loki")
-		span.SetTag("expr", query.Expr)
-		span.SetTag("start_unixnano", query.Start.UnixNano())
-		span.SetTag("stop_unixnano", query.End.UnixNano())
-		defer span.Finish()
-
-		//Currently hard coded as not used - applies to log queries
-		limit := 1000
-		//Currently hard coded as not used - applies to queries which produce a stream response
-		interval := time.Second * 1
-
-		value, err := client.QueryRange(query.Expr, limit, query.Start, query.End, logproto.BACKWARD, query.Step, interval, false)
-		if err != nil {
-			return plugins.DataResponse{}, err
+		if query.Live {
+			resp.Responses[query.RefID] = s.startTail(req.PluginContext.OrgID, query, settings)
+			continue
 		}
 
-		queryResult, err := parseResponse(value, query)
-		if err != nil {
-			return plugins.DataResponse{}, err
+		resp.Responses[query.RefID] = executeQuery(ctx, lokiClient, query)
+	}
+
+	return resp, nil
+}
+
+func executeQuery(ctx context.Context, lokiClient *client.DefaultClient, query *lokiQuery) backend.DataResponse {
+	plog.Debug("Sending query", "start", query.Start, "end", query.End, "step", query.Step, "query", query.Expr,
+		"limit", query.MaxLines, "direction", query.Direction)
+	span, _ := opentracing.StartSpanFromContext(ctx, "alerting.loki")
+	span.SetTag("expr", query.Expr)
+	span.SetTag("start_unixnano", query.Start.UnixNano())
+	span.SetTag("stop_unixnano", query.End.UnixNano())
+	defer span.Finish()
+
+	//Currently hard coded as not used - applies to queries which produce a stream response
+	interval := time.Second * 1
+
+	var value *loghttp.QueryResponse
+	var err error
+	if query.Instant {
+		value, err = lokiClient.Query(query.Expr, query.MaxLines, query.End, query.Direction, true)
+	} else {
+		value, err = lokiClient.QueryRange(query.Expr, query.MaxLines, query.Start, query.End, query.Direction,
+			query.Step, interval, true)
+	}
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	frames, err := parseResponse(value, query)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	return backend.DataResponse{Frames: frames}
+}
+
+// startTail stores the query so that it can be picked up by a subscriber of
+// the returned Grafana Live channel in the same org, within tailRequestTTL.
+func (s *Service) startTail(orgID int64, query *lokiQuery, settings *datasourceSettings) backend.DataResponse {
+	channelName := tailChannelPrefix + uuid.New().String()
+	now := time.Now()
+
+	s.tailsMu.Lock()
+	// drop the queries nobody subscribed to
+	for name, tail := range s.tails {
+		if now.After(tail.expires) {
+			delete(s.tails, name)
 		}
-		result.Results[query.RefID] = queryResult
 	}
+	s.tails[channelName] = &tailRequest{orgID: orgID, query: query, settings: settings, expires: now.Add(tailRequestTTL)}
+	s.tailsMu.Unlock()
+
+	frame := data.NewFrame(query.RefID)
+	frame.RefID = query.RefID
+	frame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeLogs,
+		Custom: map[string]interface{}{
+			"channelName": channelName,
+		},
+	}
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
 
-	return result, nil
+// takeTail removes and returns the tail request registered for the channel by
+// the org. The tails of other orgs aren't found, so they can't be taken.
+func (s *Service) takeTail(channelName string, orgID int64) (*tailRequest, error) {
+	s.tailsMu.Lock()
+	defer s.tailsMu.Unlock()
+
+	tail, ok := s.tails[channelName]
+	if !ok || tail.orgID != orgID {
+		return nil, fmt.Errorf("tail with channel name '%s' not found", channelName)
+	}
+	delete(s.tails, channelName)
+	if time.Now().After(tail.expires) {
+		return nil, fmt.Errorf("tail with channel name '%s' not found", channelName)
+	}
+	return tail, nil
 }
 
 //If legend (using of name or pattern instead of time series name) is used, use that name/pattern for formatting
@@ -103,75 +236,163 @@ func formatLegend(metric model.Metric, query *lokiQuery) string {
 	return string(result)
 }
 
-func (e *LokiExecutor) parseQuery(dsInfo *models.DataSource, queryContext plugins.DataQuery) ([]*lokiQuery, error) {
+// parseDirection converts the direction set on the query model into a Loki direction.
+// Log queries return the newest lines first unless asked otherwise.
+func parseDirection(direction string) (logproto.Direction, error) {
+	if direction == "" {
+		return logproto.BACKWARD, nil
+	}
+
+	value, ok := logproto.Direction_value[strings.ToUpper(direction)]
+	if !ok {
+		return logproto.BACKWARD, fmt.Errorf("invalid direction %q", direction)
+	}
+	return logproto.Direction(value), nil
+}
+
+func (s *Service) parseQuery(settings *datasourceSettings, queries []backend.DataQuery) ([]*lokiQuery, error) {
+	dsInfo := &models.DataSource{JsonData: settings.JSONData}
+
 	qs := []*lokiQuery{}
-	for _, queryModel := range queryContext.Queries {
-		expr, err := queryModel.Model.Get("expr").String()
+	for _, query := range queries {
+		queryModel, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query: %w", err)
+		}
+
+		expr, err := queryModel.Get("expr").String()
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse Expr: %v", err)
 		}
 
-		format := queryModel.Model.Get("legendFormat").MustString("")
+		format := queryModel.Get("legendFormat").MustString("")
 
-		start, err := queryContext.TimeRange.ParseFrom()
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse From: %v", err)
+		maxLines := queryModel.Get("maxLines").MustInt(settings.MaxLines)
+		if maxLines <= 0 {
+			return nil, fmt.Errorf("invalid maxLines %d, must be greater than zero", maxLines)
 		}
 
-		end, err := queryContext.TimeRange.ParseTo()
+		direction, err := parseDirection(queryModel.Get("direction").MustString(""))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse To: %v", err)
+			return nil, err
 		}
 
-		dsInterval, err := interval.GetIntervalFrom(dsInfo, queryModel.Model, time.Second)
+		dsInterval, err := interval.GetIntervalFrom(dsInfo, queryModel, time.Second)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse Interval: %v", err)
 		}
 
-		interval := e.intervalCalculator.Calculate(*queryContext.TimeRange, dsInterval)
+		timeRange := plugins.NewDataTimeRange(
+			strconv.FormatInt(query.TimeRange.From.UnixNano()/int64(time.Millisecond), 10),
+			strconv.FormatInt(query.TimeRange.To.UnixNano()/int64(time.Millisecond), 10))
+		interval := s.intervalCalculator.Calculate(timeRange, dsInterval)
 		step := time.Duration(int64(interval.Value))
 
 		qs = append(qs, &lokiQuery{
 			Expr:         expr,
 			Step:         step,
 			LegendFormat: format,
-			Start:        start,
-			End:          end,
-			RefID:        queryModel.RefID,
+			Start:        query.TimeRange.From,
+			End:          query.TimeRange.To,
+			RefID:        query.RefID,
+			MaxLines:     maxLines,
+			Direction:    direction,
+			Instant:      queryModel.Get("instant").MustBool(false),
+			Live:         queryModel.Get("live").MustBool(false),
 		})
 	}
 
 	return qs, nil
 }
 
-func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (plugins.DataQueryResult, error) {
-	var queryRes plugins.DataQueryResult
+func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (data.Frames, error) {
+	switch result := value.Data.Result.(type) {
+	case loghttp.Matrix:
+		return matrixToFrames(result, query), nil
+	case loghttp.Vector:
+		return vectorToFrames(result, query), nil
+	case loghttp.Streams:
+		return streamsToFrames(result, query), nil
+	default:
+		return nil, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
+	}
+}
 
-	//We are currently processing only matrix results (for alerting)
-	data, ok := value.Data.Result.(loghttp.Matrix)
-	if !ok {
-		return queryRes, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
+func labelsFromMetric(metric model.Metric) data.Labels {
+	labels := make(data.Labels, len(metric))
+	for k, v := range metric {
+		labels[string(k)] = string(v)
 	}
+	return labels
+}
 
-	for _, v := range data {
-		series := plugins.DataTimeSeries{
-			Name:   formatLegend(v.Metric, query),
-			Tags:   make(map[string]string, len(v.Metric)),
-			Points: make([]plugins.DataTimePoint, 0, len(v.Values)),
+func matrixToFrames(matrix loghttp.Matrix, query *lokiQuery) data.Frames {
+	frames := make(data.Frames, 0, len(matrix))
+	for _, v := range matrix {
+		timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(v.Values))
+		valueField := data.NewFieldFromFieldType(data.FieldTypeFloat64, len(v.Values))
+		for i, k := range v.Values {
+			timeField.Set(i, k.Timestamp.Time().UTC())
+			valueField.Set(i, float64(k.Value))
 		}
 
-		for k, v := range v.Metric {
-			series.Tags[string(k)] = string(v)
-		}
+		timeField.Name = data.TimeSeriesTimeFieldName
+		valueField.Name = data.TimeSeriesValueFieldName
+		valueField.Labels = labelsFromMetric(v.Metric)
+		valueField.Config = &data.FieldConfig{DisplayNameFromDS: formatLegend(v.Metric, query)}
 
-		for _, k := range v.Values {
-			series.Points = append(series.Points, plugins.DataTimePoint{
-				null.FloatFrom(float64(k.Value)), null.FloatFrom(float64(k.Timestamp.Unix() * 1000)),
-			})
+		frame := data.NewFrame(formatLegend(v.Metric, query), timeField, valueField)
+		frame.RefID = query.RefID
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func vectorToFrames(vector loghttp.Vector, query *lokiQuery) data.Frames {
+	frames := make(data.Frames, 0, len(vector))
+	for _, v := range vector {
+		name := formatLegend(v.Metric, query)
+		timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, []time.Time{v.Timestamp.Time().UTC()})
+		valueField := data.NewField(data.TimeSeriesValueFieldName, labelsFromMetric(v.Metric),
+			[]float64{float64(v.Value)})
+		valueField.Config = &data.FieldConfig{DisplayNameFromDS: name}
+
+		frame := data.NewFrame(name, timeField, valueField)
+		frame.RefID = query.RefID
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func streamsToFrames(streams loghttp.Streams, query *lokiQuery) data.Frames {
+	frames := make(data.Frames, 0, len(streams))
+	for _, stream := range streams {
+		times := make([]time.Time, 0, len(stream.Entries))
+		lines := make([]string, 0, len(stream.Entries))
+		for _, entry := range stream.Entries {
+			times = append(times, entry.Timestamp.UTC())
+			lines = append(lines, entry.Line)
 		}
 
-		queryRes.Series = append(queryRes.Series, series)
+		frame := data.NewFrame("",
+			data.NewField("ts", nil, times),
+			data.NewField("line", data.Labels(stream.Labels), lines),
+		)
+		frame.RefID = query.RefID
+		frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeLogs}
+		frames = append(frames, frame)
 	}
+	return frames
+}
 
-	return queryRes, nil
+// marshalFrames encodes frames as a data response for publishing over Grafana Live.
+func marshalFrames(refID string, frames data.Frames) ([]byte, error) {
+	return json.Marshal(plugins.DataResponse{
+		Results: map[string]plugins.DataQueryResult{
+			refID: {
+				RefID:      refID,
+				Dataframes: plugins.NewDecodedDataFrames(frames),
+			},
+		},
+	})
 }
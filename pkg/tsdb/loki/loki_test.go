@@ -4,16 +4,30 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
-	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/tsdb/interval"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
 	p "github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
 
 func TestLoki(t *testing.T) {
-	dsInfo := &models.DataSource{
-		JsonData: simplejson.New(),
+	settings := &datasourceSettings{
+		MaxLines: defaultMaxLines,
+		JSONData: simplejson.New(),
+	}
+
+	service := &Service{
+		intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
+		tails:              make(map[string]*tailRequest),
+	}
+
+	timeRange := func(d time.Duration) backend.TimeRange {
+		now := time.Now()
+		return backend.TimeRange{From: now.Add(-d), To: now}
 	}
 
 	t.Run("converting metric name", func(t *testing.T) {
@@ -44,53 +58,148 @@ func TestLoki(t *testing.T) {
 	})
 
 	t.Run("parsing query model with step", func(t *testing.T) {
-		json := `{
-				"expr": "go_goroutines",
-				"format": "time_series",
-				"refId": "A"
-			}`
-		jsonModel, err := simplejson.NewJson([]byte(json))
-		require.NoError(t, err)
-		timeRange := plugins.NewDataTimeRange("12h", "now")
-		queryContext := plugins.DataQuery{
-			Queries: []plugins.DataSubQuery{
-				{Model: jsonModel},
+		queries := []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON: []byte(`{
+					"expr": "go_goroutines",
+					"format": "time_series",
+					"refId": "A"
+				}`),
+				TimeRange: timeRange(12 * time.Hour),
 			},
-			TimeRange: &timeRange,
 		}
 
-		exe := newExecutor()
-		require.NoError(t, err)
-		models, err := exe.parseQuery(dsInfo, queryContext)
+		models, err := service.parseQuery(settings, queries)
 		require.NoError(t, err)
 		require.Equal(t, time.Second*30, models[0].Step)
 	})
 
 	t.Run("parsing query model without step parameter", func(t *testing.T) {
-		json := `{
-				"expr": "go_goroutines",
-				"format": "time_series",
-				"refId": "A"
-			}`
-		jsonModel, err := simplejson.NewJson([]byte(json))
-		require.NoError(t, err)
-		timeRange := plugins.NewDataTimeRange("48h", "now")
-		queryContext := plugins.DataQuery{
-			TimeRange: &timeRange,
-			Queries: []plugins.DataSubQuery{
-				{Model: jsonModel},
+		queries := []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON: []byte(`{
+					"expr": "go_goroutines",
+					"format": "time_series",
+					"refId": "A"
+				}`),
+				TimeRange: timeRange(48 * time.Hour),
 			},
 		}
-		exe := newExecutor()
-		require.NoError(t, err)
-		models, err := exe.parseQuery(dsInfo, queryContext)
+
+		models, err := service.parseQuery(settings, queries)
 		require.NoError(t, err)
 		require.Equal(t, time.Minute*2, models[0].Step)
 
-		timeRange = plugins.NewDataTimeRange("1h", "now")
-		queryContext.TimeRange = &timeRange
-		models, err = exe.parseQuery(dsInfo, queryContext)
+		queries[0].TimeRange = timeRange(time.Hour)
+		models, err = service.parseQuery(settings, queries)
 		require.NoError(t, err)
 		require.Equal(t, time.Second*2, models[0].Step)
 	})
+
+	t.Run("parsing query model with limit and direction", func(t *testing.T) {
+		queries := []backend.DataQuery{
+			{
+				RefID:     "A",
+				JSON:      []byte(`{"expr": "{app=\"backend\"}", "maxLines": 50, "direction": "forward"}`),
+				TimeRange: timeRange(time.Hour),
+			},
+			{
+				RefID:     "B",
+				JSON:      []byte(`{"expr": "{app=\"backend\"}"}`),
+				TimeRange: timeRange(time.Hour),
+			},
+		}
+
+		models, err := service.parseQuery(settings, queries)
+		require.NoError(t, err)
+		require.Equal(t, 50, models[0].MaxLines)
+		require.Equal(t, logproto.FORWARD, models[0].Direction)
+		require.Equal(t, defaultMaxLines, models[1].MaxLines)
+		require.Equal(t, logproto.BACKWARD, models[1].Direction)
+	})
+
+	t.Run("parsing query model with invalid direction", func(t *testing.T) {
+		queries := []backend.DataQuery{
+			{
+				RefID:     "A",
+				JSON:      []byte(`{"expr": "{app=\"backend\"}", "direction": "sideways"}`),
+				TimeRange: timeRange(time.Hour),
+			},
+		}
+
+		_, err := service.parseQuery(settings, queries)
+		require.Error(t, err)
+	})
+
+	t.Run("converting streams to log frames", func(t *testing.T) {
+		ts := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		value := &loghttp.QueryResponse{
+			Data: loghttp.QueryResponseData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: loghttp.Streams{
+					{
+						Labels:  loghttp.LabelSet{"app": "backend"},
+						Entries: []loghttp.Entry{{Timestamp: ts, Line: "hello"}},
+					},
+				},
+			},
+		}
+
+		frames, err := parseResponse(value, &lokiQuery{RefID: "A"})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, "A", frames[0].RefID)
+		require.Equal(t, data.VisType(data.VisTypeLogs), frames[0].Meta.PreferredVisualization)
+		require.Equal(t, ts, frames[0].Fields[0].At(0))
+		require.Equal(t, "hello", frames[0].Fields[1].At(0))
+		require.Equal(t, data.Labels{"app": "backend"}, frames[0].Fields[1].Labels)
+	})
+
+	t.Run("live queries register a tail channel", func(t *testing.T) {
+		query := &lokiQuery{RefID: "A", Expr: `{app="backend"}`, Live: true}
+		resp := service.startTail(1, query, settings)
+		require.Len(t, resp.Frames, 1)
+
+		custom := resp.Frames[0].Meta.Custom.(map[string]interface{})
+		channelName := custom["channelName"].(string)
+		require.Contains(t, channelName, tailChannelPrefix)
+
+		_, err := service.takeTail(channelName, 2)
+		require.Error(t, err)
+
+		tail, err := service.takeTail(channelName, 1)
+		require.NoError(t, err)
+		require.Equal(t, query, tail.query)
+
+		_, err = service.takeTail(channelName, 1)
+		require.Error(t, err)
+	})
+
+	t.Run("live queries without subscriber expire", func(t *testing.T) {
+		query := &lokiQuery{RefID: "A", Expr: `{app="backend"}`, Live: true}
+		resp := service.startTail(1, query, settings)
+		expired := resp.Frames[0].Meta.Custom.(map[string]interface{})["channelName"].(string)
+		service.tails[expired].expires = time.Now().Add(-time.Second)
+
+		_, err := service.takeTail(expired, 1)
+		require.Error(t, err)
+
+		resp = service.startTail(1, query, settings)
+		expired = resp.Frames[0].Meta.Custom.(map[string]interface{})["channelName"].(string)
+		service.tails[expired].expires = time.Now().Add(-time.Second)
+
+		service.startTail(1, query, settings)
+		require.NotContains(t, service.tails, expired)
+	})
+
+	t.Run("tail channels share a runner", func(t *testing.T) {
+		supplier := &TailRunnerSupplier{Service: service}
+		first, err := supplier.GetHandlerForPath("a")
+		require.NoError(t, err)
+		second, err := supplier.GetHandlerForPath("b")
+		require.NoError(t, err)
+		require.Same(t, first, second)
+	})
 }
@@ -20,7 +20,7 @@ import (
 
 var logger = log.New("tsdb.mssql")
 
-func NewExecutor(datasource *models.DataSource) (plugins.DataPlugin, error) {
+func NewExecutor(cfg *setting.Cfg, datasource *models.DataSource) (plugins.DataPlugin, error) {
 	cnnstr, err := generateConnectionString(datasource)
 	if err != nil {
 		return nil, err
@@ -35,6 +35,7 @@ func NewExecutor(datasource *models.DataSource) (plugins.DataPlugin, error) {
 		ConnectionString:  cnnstr,
 		Datasource:        datasource,
 		MetricColumnTypes: []string{"VARCHAR", "CHAR", "NVARCHAR", "NCHAR"},
+		ConnectionLimits:  sqleng.NewConnectionLimits(cfg, datasource),
 	}
 
 	queryResultTransformer := mssqlQueryResultTransformer{
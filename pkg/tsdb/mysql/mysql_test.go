@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/sqleng"
 	"xorm.io/xorm"
 
@@ -52,7 +53,7 @@ func TestMySQL(t *testing.T) {
 			return sql, nil
 		}
 
-		exe, err := NewExecutor(&models.DataSource{
+		exe, err := NewExecutor(setting.NewCfg(), &models.DataSource{
 			JsonData:       simplejson.New(),
 			SecureJsonData: securejsondata.SecureJsonData{},
 		})
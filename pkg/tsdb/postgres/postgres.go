@@ -54,6 +54,7 @@ func (s *PostgresService) NewExecutor(datasource *models.DataSource) (plugins.Da
 		ConnectionString:  cnnstr,
 		Datasource:        datasource,
 		MetricColumnTypes: []string{"UNKNOWN", "TEXT", "VARCHAR", "CHAR"},
+		ConnectionLimits:  sqleng.NewConnectionLimits(s.Cfg, datasource),
 	}
 
 	queryResultTransformer := postgresQueryResultTransformer{
@@ -14,7 +14,6 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/elasticsearch"
 	"github.com/grafana/grafana/pkg/tsdb/graphite"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb"
-	"github.com/grafana/grafana/pkg/tsdb/loki"
 	"github.com/grafana/grafana/pkg/tsdb/mssql"
 	"github.com/grafana/grafana/pkg/tsdb/mysql"
 	"github.com/grafana/grafana/pkg/tsdb/opentsdb"
@@ -56,14 +55,17 @@ func (s *Service) Init() error {
 	s.registry["opentsdb"] = opentsdb.NewExecutor
 	s.registry["prometheus"] = prometheus.NewExecutor
 	s.registry["influxdb"] = influxdb.NewExecutor
-	s.registry["mssql"] = mssql.NewExecutor
+	s.registry["mssql"] = func(ds *models.DataSource) (plugins.DataPlugin, error) {
+		return mssql.NewExecutor(s.Cfg, ds)
+	}
 	s.registry["postgres"] = s.PostgresService.NewExecutor
-	s.registry["mysql"] = mysql.NewExecutor
+	s.registry["mysql"] = func(ds *models.DataSource) (plugins.DataPlugin, error) {
+		return mysql.NewExecutor(s.Cfg, ds)
+	}
 	s.registry["elasticsearch"] = elasticsearch.NewExecutor
 	s.registry["cloudwatch"] = s.CloudWatchService.NewExecutor
 	s.registry["stackdriver"] = s.CloudMonitoringService.NewExecutor
 	s.registry["grafana-azure-monitor-datasource"] = s.AzureMonitorService.NewExecutor
-	s.registry["loki"] = loki.NewExecutor
 	s.registry["tempo"] = tempo.NewExecutor
 	return nil
 }
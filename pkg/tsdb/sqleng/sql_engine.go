@@ -79,6 +79,37 @@ type DataPluginConfiguration struct {
 	ConnectionString  string
 	TimeColumnNames   []string
 	MetricColumnTypes []string
+	ConnectionLimits  ConnectionLimits
+}
+
+// ConnectionLimits are the limits of the connection pool of a data source.
+type ConnectionLimits struct {
+	// MaxOpenConns is the maximum number of open connections, 0 means unlimited.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused, 0 means forever.
+	ConnMaxLifetime time.Duration
+}
+
+// NewConnectionLimits returns the connection limits of a data source, using the server wide defaults
+// for the limits it doesn't set.
+func NewConnectionLimits(cfg *setting.Cfg, datasource *models.DataSource) ConnectionLimits {
+	jsonData := datasource.JsonData
+	if jsonData == nil {
+		jsonData = simplejson.New()
+	}
+
+	limits := ConnectionLimits{
+		MaxOpenConns:    jsonData.Get("maxOpenConns").MustInt(cfg.SQLDatasourceMaxOpenConnsDefault),
+		MaxIdleConns:    jsonData.Get("maxIdleConns").MustInt(cfg.SQLDatasourceMaxIdleConnsDefault),
+		ConnMaxLifetime: time.Duration(jsonData.Get("connMaxLifetime").MustInt(cfg.SQLDatasourceMaxConnLifetimeDefault)) * time.Second,
+	}
+	// Idle connections count towards the open ones, so there can't be more of them.
+	if limits.MaxOpenConns > 0 && limits.MaxIdleConns > limits.MaxOpenConns {
+		limits.MaxIdleConns = limits.MaxOpenConns
+	}
+	return limits
 }
 
 // NewDataPlugin returns a new plugins.DataPlugin
@@ -114,12 +145,17 @@ func NewDataPlugin(config DataPluginConfiguration, queryResultTransformer SqlQue
 		return nil, err
 	}
 
-	maxOpenConns := config.Datasource.JsonData.Get("maxOpenConns").MustInt(0)
-	engine.SetMaxOpenConns(maxOpenConns)
-	maxIdleConns := config.Datasource.JsonData.Get("maxIdleConns").MustInt(2)
-	engine.SetMaxIdleConns(maxIdleConns)
-	connMaxLifetime := config.Datasource.JsonData.Get("connMaxLifetime").MustInt(14400)
-	engine.SetConnMaxLifetime(time.Duration(connMaxLifetime) * time.Second)
+	engine.SetMaxOpenConns(config.ConnectionLimits.MaxOpenConns)
+	engine.SetMaxIdleConns(config.ConnectionLimits.MaxIdleConns)
+	engine.SetConnMaxLifetime(config.ConnectionLimits.ConnMaxLifetime)
+
+	// The data source was updated, so close the connections of the previous version, which
+	// would otherwise count against the database's connection limit until they time out.
+	if previous, present := engineCache.cache[config.Datasource.Id]; present {
+		if err := previous.Close(); err != nil {
+			plugin.log.Warn("Failed to close connections of previous data source version", "datasource", config.Datasource.Name, "err", err)
+		}
+	}
 
 	engineCache.versions[config.Datasource.Id] = config.Datasource.Version
 	engineCache.cache[config.Datasource.Id] = engine
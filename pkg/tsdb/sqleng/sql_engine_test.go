@@ -7,9 +7,13 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
 )
 
 func TestSQLEngine(t *testing.T) {
@@ -310,3 +314,63 @@ func TestSQLEngine(t *testing.T) {
 		}
 	})
 }
+
+func TestNewConnectionLimits(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.SQLDatasourceMaxOpenConnsDefault = 10
+	cfg.SQLDatasourceMaxIdleConnsDefault = 2
+	cfg.SQLDatasourceMaxConnLifetimeDefault = 3600
+
+	t.Run("data sources without limits use the defaults", func(t *testing.T) {
+		limits := NewConnectionLimits(cfg, &models.DataSource{JsonData: simplejson.New()})
+		require.Equal(t, ConnectionLimits{MaxOpenConns: 10, MaxIdleConns: 2, ConnMaxLifetime: time.Hour}, limits)
+
+		limits = NewConnectionLimits(cfg, &models.DataSource{})
+		require.Equal(t, ConnectionLimits{MaxOpenConns: 10, MaxIdleConns: 2, ConnMaxLifetime: time.Hour}, limits)
+	})
+
+	t.Run("data sources override the defaults", func(t *testing.T) {
+		limits := NewConnectionLimits(cfg, &models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"maxOpenConns":    0,
+			"maxIdleConns":    5,
+			"connMaxLifetime": 60,
+		})})
+		require.Equal(t, ConnectionLimits{MaxOpenConns: 0, MaxIdleConns: 5, ConnMaxLifetime: time.Minute}, limits)
+	})
+
+	t.Run("idle connections are limited to the open ones", func(t *testing.T) {
+		limits := NewConnectionLimits(cfg, &models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"maxOpenConns": 3,
+			"maxIdleConns": 5,
+		})})
+		require.Equal(t, 3, limits.MaxOpenConns)
+		require.Equal(t, 3, limits.MaxIdleConns)
+	})
+}
+
+func TestNewDataPluginClosesPreviousVersion(t *testing.T) {
+	t.Cleanup(func() {
+		engineCache.Lock()
+		defer engineCache.Unlock()
+		delete(engineCache.cache, -1)
+		delete(engineCache.versions, -1)
+	})
+
+	ds := &models.DataSource{Id: -1, Version: 1, JsonData: simplejson.New()}
+	newPlugin := func() *xorm.Engine {
+		config := DataPluginConfiguration{DriverName: "sqlite3", ConnectionString: ":memory:", Datasource: ds}
+		plugin, err := NewDataPlugin(config, nil, nil, log.New("test"))
+		require.NoError(t, err)
+		return plugin.(*dataPlugin).engine
+	}
+
+	first := newPlugin()
+	require.NoError(t, first.Ping())
+	require.Same(t, first, newPlugin())
+
+	ds.Version = 2
+	second := newPlugin()
+	require.NotSame(t, first, second)
+	require.Error(t, first.Ping())
+	require.NoError(t, second.Ping())
+}
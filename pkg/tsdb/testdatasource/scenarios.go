@@ -39,6 +39,10 @@ const (
 	serverError500Query               queryType = "server_error_500"
 	logsQuery                         queryType = "logs"
 	nodeGraphQuery                    queryType = "node_graph"
+	streamingFramesQuery              queryType = "streaming_frames"
+	exemplarsQuery                    queryType = "exemplars"
+	heatmapLeBucketsQuery             queryType = "heatmap_le_buckets"
+	wideSeriesQuery                   queryType = "wide_series"
 )
 
 type queryType string
@@ -188,6 +192,32 @@ Timestamps will line up evenly on timeStepSeconds (For example, 60 seconds means
 		Name: "Node Graph",
 	})
 
+	p.registerScenario(&Scenario{
+		ID:      string(streamingFramesQuery),
+		Name:    "Streaming Frames",
+		handler: p.handleStreamingFramesScenario,
+		Description: `Streaming Frames returns a random walk with value, min and max fields for the time range,
+and the Grafana Live channel that keeps publishing measurements of the same shape.`,
+	})
+
+	p.registerScenario(&Scenario{
+		ID:      string(exemplarsQuery),
+		Name:    "Exemplars",
+		handler: p.handleExemplarsScenario,
+	})
+
+	p.registerScenario(&Scenario{
+		ID:      string(heatmapLeBucketsQuery),
+		Name:    "Heatmap buckets (le)",
+		handler: p.handleHeatmapLeBucketsScenario,
+	})
+
+	p.registerScenario(&Scenario{
+		ID:      string(wideSeriesQuery),
+		Name:    "Wide Series",
+		handler: p.handleWideSeriesScenario,
+	})
+
 	p.queryMux.HandleFunc("", p.handleFallbackScenario)
 }
 
@@ -516,6 +546,80 @@ func (p *testDataPlugin) handleLinearHeatmapBucketDataScenario(ctx context.Conte
 	return resp, nil
 }
 
+func (p *testDataPlugin) handleHeatmapLeBucketsScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		respD.Frames = append(respD.Frames, heatmapLeBuckets(q, model)...)
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+func (p *testDataPlugin) handleStreamingFramesScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		frame, err := streamingFrame(q, model)
+		if err != nil {
+			respD.Error = err
+		} else {
+			respD.Frames = append(respD.Frames, frame)
+		}
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+func (p *testDataPlugin) handleExemplarsScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		series := randomWalk(q, model, 0)
+		respD := resp.Responses[q.RefID]
+		respD.Frames = append(respD.Frames, series, exemplarsForSeries(series, model))
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+func (p *testDataPlugin) handleWideSeriesScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		respD.Frames = append(respD.Frames, wideSeries(q, model))
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
 func (p *testDataPlugin) handleTableStaticScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	resp := backend.NewQueryDataResponse()
 
@@ -877,6 +981,140 @@ func randomHeatmapData(query backend.DataQuery, fnBucketGen func(index int) floa
 	return frame
 }
 
+// heatmapLeBuckets returns one cumulative series per bucket, labeled with its upper bound,
+// like the bucket series of a Prometheus histogram.
+func heatmapLeBuckets(query backend.DataQuery, model *simplejson.Json) []*data.Frame {
+	bucketCount := model.Get("bucketCount").MustInt(10)
+	bucketSize := model.Get("bucketSize").MustFloat64(10)
+	from := query.TimeRange.From.UnixNano() / int64(time.Millisecond)
+	to := query.TimeRange.To.UnixNano() / int64(time.Millisecond)
+
+	timeVec := make([]time.Time, 0)
+	for timeWalkerMs := from; int64(len(timeVec)) < query.MaxDataPoints && timeWalkerMs < to; timeWalkerMs += query.Interval.Milliseconds() {
+		timeVec = append(timeVec, time.Unix(timeWalkerMs/int64(1e+3), (timeWalkerMs%int64(1e+3))*int64(1e+6)))
+	}
+
+	// Buckets are cumulative, each one counts everything below its bound.
+	counts := make([]float64, len(timeVec))
+	frames := make([]*data.Frame, 0, bucketCount+1)
+	for i := 0; i <= bucketCount; i++ {
+		le := "+Inf"
+		if i < bucketCount {
+			le = strconv.FormatFloat(float64(i+1)*bucketSize, 'f', -1, 64)
+		}
+
+		values := make([]float64, len(counts))
+		for j := range counts {
+			counts[j] += float64(rand.Int63n(10))
+			values[j] = counts[j]
+		}
+
+		frames = append(frames, data.NewFrame("",
+			data.NewField("time", nil, append([]time.Time{}, timeVec...)),
+			data.NewField(le, data.Labels{"le": le}, values),
+		))
+	}
+
+	return frames
+}
+
+// streamingFrame returns the data for the time range of a Grafana Live testdata channel, and the channel
+// itself so the client can subscribe to it.
+func streamingFrame(query backend.DataQuery, model *simplejson.Json) (*data.Frame, error) {
+	channel := model.Get("channel").MustString(liveChannels[0])
+	known := false
+	for _, c := range liveChannels {
+		if c == channel {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown testdata channel %q", channel)
+	}
+
+	timeWalkerMs := query.TimeRange.From.UnixNano() / int64(time.Millisecond)
+	to := query.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	walker := model.Get("startValue").MustFloat64(rand.Float64() * 100)
+	spread := 50.0
+
+	frame := data.NewFrame(channel,
+		data.NewField("time", nil, []time.Time{}),
+		data.NewField("value", nil, []float64{}),
+		data.NewField("min", nil, []float64{}),
+		data.NewField("max", nil, []float64{}),
+	).SetMeta(&data.FrameMeta{
+		Custom: map[string]interface{}{
+			"channel": "grafana/testdata/" + channel,
+		},
+	})
+
+	for i := int64(0); i < query.MaxDataPoints && timeWalkerMs < to; i++ {
+		walker += rand.Float64() - 0.5
+
+		t := time.Unix(timeWalkerMs/int64(1e+3), (timeWalkerMs%int64(1e+3))*int64(1e+6))
+		min := walker - ((rand.Float64() * spread) + 0.01)
+		max := walker + ((rand.Float64() * spread) + 0.01)
+		frame.AppendRow(t, walker, min, max)
+
+		timeWalkerMs += query.Interval.Milliseconds()
+	}
+
+	return frame, nil
+}
+
+// exemplarsForSeries picks every nth point of a random walk series as an exemplar with a trace ID.
+func exemplarsForSeries(series *data.Frame, model *simplejson.Json) *data.Frame {
+	every := model.Get("exemplarEvery").MustInt(10)
+	if every < 1 {
+		every = 1
+	}
+
+	frame := data.NewFrame("exemplar",
+		data.NewField("Time", nil, []time.Time{}),
+		data.NewField("Value", nil, []float64{}),
+		data.NewField("traceID", nil, []string{}),
+	).SetMeta(&data.FrameMeta{
+		Custom: map[string]interface{}{
+			"resultType": "exemplar",
+		},
+	})
+
+	for i := 0; i < series.Rows(); i += every {
+		t := series.Fields[0].At(i).(*time.Time)
+		v := series.Fields[1].At(i).(*float64)
+		frame.AppendRow(*t, *v, fmt.Sprintf("%016x", rand.Uint64()))
+	}
+
+	return frame
+}
+
+// wideSeries returns a single frame with a random walk field per series, sharing the time field.
+func wideSeries(query backend.DataQuery, model *simplejson.Json) *data.Frame {
+	seriesCount := model.Get("seriesCount").MustInt(1000)
+	spread := model.Get("spread").MustFloat64(1)
+	from := query.TimeRange.From.UnixNano() / int64(time.Millisecond)
+	to := query.TimeRange.To.UnixNano() / int64(time.Millisecond)
+
+	timeVec := make([]time.Time, 0)
+	for timeWalkerMs := from; int64(len(timeVec)) < query.MaxDataPoints && timeWalkerMs < to; timeWalkerMs += query.Interval.Milliseconds() {
+		timeVec = append(timeVec, time.Unix(timeWalkerMs/int64(1e+3), (timeWalkerMs%int64(1e+3))*int64(1e+6)))
+	}
+
+	frame := data.NewFrame(query.RefID, data.NewField("time", nil, timeVec))
+	for i := 0; i < seriesCount; i++ {
+		values := make([]float64, len(timeVec))
+		walker := rand.Float64() * 100
+		for j := range values {
+			values[j] = walker
+			walker += (rand.Float64() - 0.5) * spread
+		}
+		frame.Fields = append(frame.Fields, data.NewField(fmt.Sprintf("%s-series%d", query.RefID, i), nil, values))
+	}
+
+	return frame
+}
+
 func newSeriesForQuery(query backend.DataQuery, model *simplejson.Json, index int) *data.Frame {
 	alias := model.Get("alias").MustString("")
 	suffix := ""
@@ -969,6 +1207,12 @@ func fromStringOrNumber(val *simplejson.Json) (null.Float, error) {
 	}
 }
 
+// liveChannels are the testdata channels published by Grafana Live, see pkg/services/live/features/testdata.go.
+var liveChannels = []string{
+	"random-2s-stream",
+	"random-flakey-stream",
+}
+
 var serverNames = []string{
 	"Backend-ops-01",
 	"Backend-ops-02",
@@ -185,6 +185,123 @@ func TestTestdataScenarios(t *testing.T) {
 			require.True(t, maxNil)
 		})
 	})
+
+	newQuery := func(t *testing.T, model *simplejson.Json) backend.DataQuery {
+		timeRange := plugins.DataTimeRange{From: "5m", To: "now", Now: time.Now()}
+
+		modelBytes, err := model.MarshalJSON()
+		require.NoError(t, err)
+
+		return backend.DataQuery{
+			RefID: "A",
+			TimeRange: backend.TimeRange{
+				From: timeRange.MustGetFrom(),
+				To:   timeRange.MustGetTo(),
+			},
+			Interval:      100 * time.Millisecond,
+			MaxDataPoints: 100,
+			JSON:          modelBytes,
+		}
+	}
+
+	t.Run("streaming frames", func(t *testing.T) {
+		t.Run("Should return the live channel with the data", func(t *testing.T) {
+			model := simplejson.New()
+			model.Set("channel", "random-flakey-stream")
+			query := newQuery(t, model)
+
+			resp, err := p.handleStreamingFramesScenario(context.Background(), &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{query},
+			})
+			require.NoError(t, err)
+
+			dResp := resp.Responses[query.RefID]
+			require.NoError(t, dResp.Error)
+			require.Len(t, dResp.Frames, 1)
+			frame := dResp.Frames[0]
+			require.Equal(t, 100, frame.Rows())
+			require.Len(t, frame.Fields, 4)
+			require.Equal(t, map[string]interface{}{"channel": "grafana/testdata/random-flakey-stream"}, frame.Meta.Custom)
+		})
+
+		t.Run("Should fail for unknown channels", func(t *testing.T) {
+			model := simplejson.New()
+			model.Set("channel", "unknown")
+			query := newQuery(t, model)
+
+			resp, err := p.handleStreamingFramesScenario(context.Background(), &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{query},
+			})
+			require.NoError(t, err)
+			require.Error(t, resp.Responses[query.RefID].Error)
+		})
+	})
+
+	t.Run("exemplars", func(t *testing.T) {
+		t.Run("Should return exemplars on the series", func(t *testing.T) {
+			model := simplejson.New()
+			model.Set("exemplarEvery", 5)
+			query := newQuery(t, model)
+
+			resp, err := p.handleExemplarsScenario(context.Background(), &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{query},
+			})
+			require.NoError(t, err)
+
+			dResp := resp.Responses[query.RefID]
+			require.Len(t, dResp.Frames, 2)
+			series, exemplars := dResp.Frames[0], dResp.Frames[1]
+			require.Equal(t, "exemplar", exemplars.Name)
+			require.Equal(t, (series.Rows()+4)/5, exemplars.Rows())
+
+			value, ok := series.ConcreteAt(1, 5)
+			require.True(t, ok)
+			require.Equal(t, value, exemplars.At(1, 1))
+			require.Len(t, exemplars.At(2, 1), 16)
+		})
+	})
+
+	t.Run("heatmap le buckets", func(t *testing.T) {
+		t.Run("Should return cumulative buckets", func(t *testing.T) {
+			model := simplejson.New()
+			model.Set("bucketCount", 3)
+			model.Set("bucketSize", 5)
+			query := newQuery(t, model)
+
+			resp, err := p.handleHeatmapLeBucketsScenario(context.Background(), &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{query},
+			})
+			require.NoError(t, err)
+
+			frames := resp.Responses[query.RefID].Frames
+			require.Len(t, frames, 4)
+			for i, le := range []string{"5", "10", "15", "+Inf"} {
+				require.Equal(t, data.Labels{"le": le}, frames[i].Fields[1].Labels)
+			}
+			for i := 1; i < len(frames); i++ {
+				require.GreaterOrEqual(t, frames[i].At(1, 0), frames[i-1].At(1, 0))
+			}
+		})
+	})
+
+	t.Run("wide series", func(t *testing.T) {
+		t.Run("Should return a single wide frame", func(t *testing.T) {
+			model := simplejson.New()
+			model.Set("seriesCount", 500)
+			query := newQuery(t, model)
+
+			resp, err := p.handleWideSeriesScenario(context.Background(), &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{query},
+			})
+			require.NoError(t, err)
+
+			frames := resp.Responses[query.RefID].Frames
+			require.Len(t, frames, 1)
+			require.Len(t, frames[0].Fields, 501)
+			require.Equal(t, 100, frames[0].Rows())
+			require.Equal(t, "A-series499", frames[0].Fields[500].Name)
+		})
+	})
 }
 
 func TestParseLabels(t *testing.T) {
@@ -1,7 +1,9 @@
 package util
 
 import (
+	"fmt"
 	"regexp"
+	"sync/atomic"
 
 	"github.com/teris-io/shortid"
 )
@@ -24,3 +26,27 @@ func IsValidShortUID(uid string) bool {
 func GenerateShortUID() string {
 	return shortid.MustGenerate()
 }
+
+// UIDGenerator generates unique identifiers for new resources, such as dashboards and data sources.
+type UIDGenerator interface {
+	GenerateUID() string
+}
+
+// UIDGeneratorFunc is an adapter to allow the use of ordinary functions as UID generators.
+type UIDGeneratorFunc func() string
+
+// GenerateUID calls f().
+func (f UIDGeneratorFunc) GenerateUID() string {
+	return f()
+}
+
+// DefaultUIDGenerator generates random short UIDs.
+var DefaultUIDGenerator UIDGenerator = UIDGeneratorFunc(GenerateShortUID)
+
+// NewSequentialUIDGenerator returns a generator of the deterministic UIDs prefix-1, prefix-2 and so on.
+func NewSequentialUIDGenerator(prefix string) UIDGenerator {
+	var n int64
+	return UIDGeneratorFunc(func() string {
+		return fmt.Sprintf("%s-%d", prefix, atomic.AddInt64(&n, 1))
+	})
+}
@@ -1,6 +1,10 @@
 package util
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func TestAllowedCharMatchesUidPattern(t *testing.T) {
 	for _, c := range allowedChars {
@@ -9,3 +13,10 @@ func TestAllowedCharMatchesUidPattern(t *testing.T) {
 		}
 	}
 }
+
+func TestSequentialUIDGenerator(t *testing.T) {
+	gen := NewSequentialUIDGenerator("dash")
+	require.Equal(t, "dash-1", gen.GenerateUID())
+	require.Equal(t, "dash-2", gen.GenerateUID())
+	require.Equal(t, "other-1", NewSequentialUIDGenerator("other").GenerateUID())
+}
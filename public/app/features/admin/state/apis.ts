@@ -13,10 +13,13 @@ export const getServerStats = async (): Promise<ServerStat[]> => {
       { name: 'Total admins', value: res.admins },
       { name: 'Total editors', value: res.editors },
       { name: 'Total viewers', value: res.viewers },
-      { name: 'Active users (seen last 30 days)', value: res.activeUsers },
-      { name: 'Active admins (seen last 30 days)', value: res.activeAdmins },
-      { name: 'Active editors (seen last 30 days)', value: res.activeEditors },
-      { name: 'Active viewers (seen last 30 days)', value: res.activeViewers },
+      { name: `Active users (seen last ${res.activeUserWindow})`, value: res.activeUsers },
+      { name: `Active admins (seen last ${res.activeUserWindow})`, value: res.activeAdmins },
+      { name: `Active editors (seen last ${res.activeUserWindow})`, value: res.activeEditors },
+      { name: `Active viewers (seen last ${res.activeUserWindow})`, value: res.activeViewers },
+      { name: 'Daily active users', value: res.dailyActiveUsers },
+      { name: 'Weekly active users', value: res.weeklyActiveUsers },
+      { name: 'Monthly active users', value: res.monthlyActiveUsers },
       { name: 'Active sessions', value: res.activeSessions },
       { name: 'Total dashboards', value: res.dashboards },
       { name: 'Total orgs', value: res.orgs },
@@ -30,6 +30,10 @@ export const useDashboardSave = (dashboard: DashboardModel) => {
       // important that these happen before location redirect below
       appEvents.publish(new DashboardSavedEvent());
       appEvents.emit(AppEvents.alertSuccess, ['Dashboard saved']);
+      if (state.value.warning) {
+        // Other users are still editing the dashboard
+        appEvents.emit(AppEvents.alertWarning, [state.value.warning]);
+      }
 
       // Using global locationService because save modals are rendered as a separate React tree
       const currentPath = locationService.getLocation().pathname;
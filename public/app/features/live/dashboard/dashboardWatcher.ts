@@ -12,7 +12,7 @@ import {
   isLiveChannelMessageEvent,
 } from '@grafana/data';
 import { DashboardChangedModal } from './DashboardChangedModal';
-import { DashboardEvent, DashboardEventAction } from './types';
+import { DashboardEvent, DashboardEventAction, DashboardPresence } from './types';
 import { CoreGrafanaLiveFeature } from '../scopes';
 import { sessionId } from '../live';
 import { ShowModalReactEvent } from '../../../types/events';
@@ -24,6 +24,7 @@ class DashboardWatcher {
   ignoreSave?: boolean;
   editing = false;
   lastEditing?: DashboardEvent;
+  presence: DashboardPresence[] = [];
 
   setEditingState(state: boolean) {
     const changed = (this.editing = state);
@@ -73,6 +74,7 @@ class DashboardWatcher {
       this.channel.disconnect();
     }
     this.uid = undefined;
+    this.presence = [];
   }
 
   ignoreNextSave() {
@@ -97,6 +99,10 @@ class DashboardWatcher {
       }
 
       if (isLiveChannelMessageEvent(event)) {
+        if (event.message.presence) {
+          this.presence = event.message.presence;
+        }
+
         if (event.message.sessionId === sessionId) {
           return; // skip internal messages
         }
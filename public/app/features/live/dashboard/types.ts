@@ -3,6 +3,16 @@ export enum DashboardEventAction {
   EditingStarted = 'editing-started', // Sent when someone (who can save!) opens the editor
   EditingCanceled = 'editing-cancelled', // Sent when someone discards changes, or unsubscribes while editing
   Deleted = 'deleted',
+  Presence = 'presence', // Sent when someone starts or stops viewing the dashboard
+}
+
+export interface DashboardPresence {
+  userId: number;
+  login: string;
+  name: string;
+  sessionId?: string;
+  editing: boolean;
+  since: string;
 }
 
 export interface DashboardEvent {
@@ -12,4 +22,5 @@ export interface DashboardEvent {
   message?: string;
   sessionId?: string;
   timestamp?: number;
+  presence?: DashboardPresence[]; // Everyone viewing or editing the dashboard after the event
 }